@@ -0,0 +1,303 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/banditmoscow1337/benc/cmd/dynamic"
+	bstd "github.com/banditmoscow1337/benc/std/golang"
+)
+
+// runCat reads benc frames from stdin and prints one JSON object per line.
+// With -trace it writes the decode operations of every frame to a file as well, which
+// `benc replay` compares against. With -tagged the frames hold variants, as `benc tag`
+// writes them, and with -schema-header messages after a bstd.SchemaHeader, as
+// `benc pack -schema-header` writes them, which both decode without a schema.
+func runCat(args []string) {
+	fs := flag.NewFlagSet("cat", flag.ExitOnError)
+	traceFlag := fs.String("trace", "", "File to write the decode trace of every frame to, one JSON object per line")
+	taggedFlag := fs.Bool("tagged", false, "Decode the frames as variants, without -schema and -type")
+	schemaHeaderFlag := fs.Bool("schema-header", false, "Decode the frames by the schema in their schema header, without -schema and -type")
+	watchFlag := fs.Duration("watch", 0, "Interval of checking the schema for changes, which are used without a restart (0 disables it)")
+	maxFrame := maxFrameFlag(fs)
+	loadCodec := codecFlags(fs)
+	fs.Parse(args)
+
+	var codec *dynamic.Codec
+	var typeName string
+	schemaless := "-tagged"
+	if *schemaHeaderFlag {
+		schemaless = "-schema-header"
+	}
+	if *taggedFlag && *schemaHeaderFlag {
+		log.Fatal("-tagged and -schema-header can't be combined")
+	} else if !*taggedFlag && !*schemaHeaderFlag {
+		codec, typeName = loadCodec()
+	} else if *traceFlag != "" {
+		log.Fatalf("-trace needs the -schema of the frames, it can't be combined with %s", schemaless)
+	} else if *watchFlag != 0 {
+		log.Fatalf("-watch needs the -schema of the frames, it can't be combined with %s", schemaless)
+	}
+	headers := headerCodecs{}
+	reloader := watchSchema(codec, *watchFlag, typeName)
+
+	r := bufio.NewReader(os.Stdin)
+	w := bufio.NewWriter(os.Stdout)
+	defer w.Flush()
+	enc := json.NewEncoder(w)
+
+	var traces *traceWriter
+	if *traceFlag != "" {
+		var err error
+		if traces, err = createTrace(*traceFlag); err != nil {
+			log.Fatal(err)
+		}
+		defer traces.Close()
+		codec.Trace = &dynamic.Trace{}
+	}
+
+	var buf []byte
+	for i := 0; ; i++ {
+		msg, err := bstd.ReadFrame(r, buf, *maxFrame)
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			w.Flush()
+			log.Fatalf("frame %d: %v", i, err)
+		}
+		buf = msg
+
+		var v any
+		if reloader != nil {
+			trace := codec.Trace
+			codec = reloader.Codec()
+			codec.Trace = trace
+		}
+		if *taggedFlag {
+			v, err = decodeTaggedFrame(msg)
+		} else if *schemaHeaderFlag {
+			v, err = headers.decodeFrame(msg)
+		} else {
+			v, err = decodeFrame(codec, typeName, msg)
+		}
+		if traces != nil {
+			if terr := traces.Write(i, codec.Trace, err); terr != nil {
+				log.Fatal(terr)
+			}
+			if err != nil {
+				traces.Close()
+			}
+		}
+		if err != nil {
+			w.Flush()
+			log.Fatalf("frame %d: %v", i, err)
+		}
+		if err = enc.Encode(v); err != nil {
+			log.Fatalf("frame %d: %v", i, err)
+		}
+	}
+}
+
+// runPack reads JSON values from stdin and writes them as benc frames, the reverse of runCat.
+// With -schema-header every message follows a bstd.SchemaHeader with the schema.
+func runPack(args []string) {
+	fs := flag.NewFlagSet("pack", flag.ExitOnError)
+	schemaHeaderFlag := fs.Bool("schema-header", false, "Write the compressed schema before every message, which `benc cat -schema-header` decodes")
+	codec, typeName := parseCodecFlags(fs, args)
+
+	var header []byte
+	if *schemaHeaderFlag {
+		source, err := codec.ReadInput()
+		if err != nil {
+			log.Fatal(err)
+		}
+		h := bstd.NewSchemaHeader(strings.TrimPrefix(filepath.Ext(codec.InputFile), "."), typeName, source)
+		header = make([]byte, bstd.SizeSchemaHeader(h))
+		bstd.MarshalSchemaHeader(0, header, h)
+	}
+
+	dec := json.NewDecoder(bufio.NewReader(os.Stdin))
+	w := bufio.NewWriter(os.Stdout)
+	defer w.Flush()
+
+	for i := 0; ; i++ {
+		v, err := dynamic.ReadJSON(dec)
+		if err == io.EOF {
+			return
+		} else if err != nil {
+			w.Flush()
+			log.Fatalf("value %d: %v", i, err)
+		}
+
+		msg, err := codec.Encode(typeName, v)
+		if err != nil {
+			w.Flush()
+			log.Fatalf("value %d: %v", i, err)
+		}
+		if header != nil {
+			msg = append(header[:len(header):len(header)], msg...)
+		}
+		if err = bstd.WriteFrame(w, msg); err != nil {
+			log.Fatalf("value %d: %v", i, err)
+		}
+	}
+}
+
+// runTag reads benc frames from stdin and writes them as frames of variants, the tagged mode,
+// which `benc cat -tagged` and bstd.UnmarshalVariant decode without the schema.
+func runTag(args []string) {
+	fs := flag.NewFlagSet("tag", flag.ExitOnError)
+	maxFrame := maxFrameFlag(fs)
+	codec, typeName := parseCodecFlags(fs, args)
+
+	r := bufio.NewReader(os.Stdin)
+	w := bufio.NewWriter(os.Stdout)
+	defer w.Flush()
+
+	var buf []byte
+	for i := 0; ; i++ {
+		msg, err := bstd.ReadFrame(r, buf, *maxFrame)
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			w.Flush()
+			log.Fatalf("frame %d: %v", i, err)
+		}
+		buf = msg
+
+		n, tagged, err := codec.Tag(typeName, 0, msg)
+		if err == nil && n != len(msg) {
+			err = errors.New("trailing bytes after message")
+		}
+		if err != nil {
+			w.Flush()
+			log.Fatalf("frame %d: %v", i, err)
+		}
+		if err = bstd.WriteFrame(w, tagged); err != nil {
+			log.Fatalf("frame %d: %v", i, err)
+		}
+	}
+}
+
+// decodeFrame decodes a message of the named type, which has to fill the frame 'msg' entirely.
+// A trace of the codec is reset first, so it holds the operations of this message only.
+func decodeFrame(codec *dynamic.Codec, typeName string, msg []byte) (any, error) {
+	if codec.Trace != nil {
+		codec.Trace.Reset()
+	}
+	n, v, err := codec.Decode(typeName, 0, msg)
+	if err == nil && n != len(msg) {
+		err = errors.New("trailing bytes after message")
+	}
+	return v, err
+}
+
+// decodeTaggedFrame decodes a variant, which has to fill the frame 'msg' entirely.
+func decodeTaggedFrame(msg []byte) (any, error) {
+	n, v, err := dynamic.DecodeVariant(0, msg)
+	if err == nil && n != len(msg) {
+		err = errors.New("trailing bytes after message")
+	}
+	return v, err
+}
+
+// headerCodecs are the codecs of the schemas of the schema headers decoded so far, by their
+// format and compressed schema, as the messages of a file mostly carry the same schema.
+type headerCodecs map[string]*dynamic.Codec
+
+// decodeFrame decodes a message after its bstd.SchemaHeader by the schema of the header,
+// which have to fill the frame 'msg' entirely.
+func (hc headerCodecs) decodeFrame(msg []byte) (any, error) {
+	n, h, err := bstd.UnmarshalSchemaHeader(0, msg)
+	if err != nil {
+		return nil, err
+	}
+	key := h.Format + "\x00" + string(h.Schema)
+	codec, ok := hc[key]
+	if !ok {
+		source, err := h.Source()
+		if err != nil {
+			return nil, err
+		}
+		ctx, err := parseSchemaSource("schema."+h.Format, source)
+		if err != nil {
+			return nil, fmt.Errorf("parsing the schema of the header: %w", err)
+		}
+		codec = dynamic.New(ctx)
+		hc[key] = codec
+	}
+	if _, ok := codec.TypeSpecs[h.Type]; !ok {
+		return nil, fmt.Errorf("type %s of the header not found in its schema", h.Type)
+	}
+	n, v, err := codec.Decode(h.Type, n, msg)
+	if err == nil && n != len(msg) {
+		err = errors.New("trailing bytes after message")
+	}
+	return v, err
+}
+
+// maxFrameFlag defines the -max-frame flag in fs, the maximum size of the frames read.
+func maxFrameFlag(fs *flag.FlagSet) *int {
+	return fs.Int("max-frame", 64<<20, "Maximum size of a frame in bytes, larger frames are rejected as corrupted")
+}
+
+// parseCodecFlags parses the -schema and -type flags, next to any other flags defined in fs.
+func parseCodecFlags(fs *flag.FlagSet, args []string) (*dynamic.Codec, string) {
+	loadCodec := codecFlags(fs)
+	fs.Parse(args)
+	return loadCodec()
+}
+
+// watchSchema returns a Reloader of the schema of 'codec', which checks the schema for changes
+// every 'interval' and logs its reloads, or nil, if 'interval' is zero. The reloaded schema
+// has to declare the types of 'types' as well.
+func watchSchema(codec *dynamic.Codec, interval time.Duration, types ...string) *dynamic.Reloader {
+	if interval <= 0 {
+		return nil
+	}
+	reloader, err := dynamic.NewReloader(codec, parseSchema, types...)
+	if err != nil {
+		log.Fatal(err)
+	}
+	go reloader.Watch(interval, nil, func(err error) {
+		if err != nil {
+			log.Printf("keeping the last schema: %v", err)
+			return
+		}
+		log.Printf("reloaded %s", codec.InputFile)
+	})
+	return reloader
+}
+
+// codecFlags defines the -schema and -type flags in fs and returns the function loading
+// the codec of them, once fs is parsed.
+func codecFlags(fs *flag.FlagSet) func() (*dynamic.Codec, string) {
+	schemaFlag := fs.String("schema", "", "Schema file describing the messages")
+	typeFlag := fs.String("type", "", "Name of the message type")
+
+	return func() (*dynamic.Codec, string) {
+		if *schemaFlag == "" || *typeFlag == "" {
+			log.Fatalf("Usage: benc %s -schema <input_file> -type <type>", fs.Name())
+		}
+
+		ctx := loadSchema(*schemaFlag)
+		if ctx == nil {
+			os.Exit(1)
+		}
+		if _, ok := ctx.TypeSpecs[*typeFlag]; !ok {
+			log.Fatalf("type %s not found in %s", *typeFlag, *schemaFlag)
+		}
+		return dynamic.New(ctx), *typeFlag
+	}
+}
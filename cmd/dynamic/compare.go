@@ -0,0 +1,184 @@
+package dynamic
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"time"
+
+	"github.com/banditmoscow1337/benc/cmd/generator/common"
+)
+
+// Compare returns the first difference between the values want and got of the named type, e.g.
+// a value of Literal and its decoded encoding, nil if they are equal. Values are equal if they
+// encode alike, except times, which are compared as times, nil being the zero time.Time, so a
+// time its encoding can't hold, like the zero time.Time of bstd.MarshalTime, differs.
+func (c *Codec) Compare(typeName string, want, got any) error {
+	return c.compare(&ast.Ident{Name: typeName}, want, got)
+}
+
+func (c *Codec) compare(expr ast.Expr, want, got any) error {
+	if _, ok := c.nativeType(expr); ok || common.IsVariantType(expr) {
+		return c.compareEncoded(expr, want, got)
+	}
+
+	switch t := expr.(type) {
+	case *ast.Ident:
+		if ts, ok := c.TypeSpecs[t.Name]; ok {
+			return c.compare(ts.Type, want, got)
+		}
+		if _, ok := compactTimes[t.Name]; ok || t.Name == zonedTime {
+			return compareTime(t.Name, want, got)
+		}
+		if t.Name == dictString {
+			// the encoding of a dict string depends on the strings before it
+			ws, _ := want.(string)
+			gs, _ := got.(string)
+			if ws != gs {
+				return fmt.Errorf("expected %q, got %q", ws, gs)
+			}
+			return nil
+		}
+	case *ast.ParenExpr:
+		return c.compare(t.X, want, got)
+	case *ast.StructType:
+		wantObj, err := asObject(want)
+		if err != nil {
+			return err
+		}
+		gotObj, err := asObject(got)
+		if err != nil {
+			return err
+		}
+		for _, field := range t.Fields.List {
+			if c.ShouldIgnoreField(field) || c.IsUnsupportedType(field.Type) {
+				continue
+			}
+			for _, name := range field.Names {
+				wv, _ := wantObj.Get(name.Name)
+				gv, _ := gotObj.Get(name.Name)
+				if err := c.compare(c.fieldType(field), wv, gv); err != nil {
+					return fmt.Errorf("%s: %w", name.Name, err)
+				}
+			}
+		}
+		return nil
+	case *ast.StarExpr:
+		if want == nil || got == nil {
+			if want != nil || got != nil {
+				return fmt.Errorf("expected %v, got %v", want, got)
+			}
+			return nil
+		}
+		return c.compare(t.X, want, got)
+	case *ast.IndexExpr:
+		if elt, ok := common.OptionElt(t); ok {
+			return c.compare(&ast.StarExpr{X: elt}, want, got)
+		}
+	case *ast.SelectorExpr:
+		if elt, ok := nullTypes[c.ExprToString(t)]; ok {
+			return c.compare(&ast.StarExpr{X: elt}, want, got)
+		}
+		if c.ExprToString(t) == "time.Time" {
+			return compareTime(c.ExprToString(t), want, got)
+		}
+	case *ast.ArrayType:
+		if isByte(t.Elt) {
+			break
+		}
+		wantVs, _ := want.([]any)
+		gotVs, _ := got.([]any)
+		if t.Len != nil {
+			// a missing array is the zero one
+			l, err := c.arrayLen(t)
+			if err != nil {
+				return err
+			}
+			if want == nil {
+				wantVs = make([]any, l)
+			}
+			if got == nil {
+				gotVs = make([]any, l)
+			}
+		}
+		if len(wantVs) != len(gotVs) {
+			return fmt.Errorf("expected %d elements, got %d", len(wantVs), len(gotVs))
+		}
+		for i := range wantVs {
+			if err := c.compare(t.Elt, wantVs[i], gotVs[i]); err != nil {
+				return fmt.Errorf("index [%d]: %w", i, err)
+			}
+		}
+		return nil
+	case *ast.MapType:
+		wantObj, err := asObject(want)
+		if err != nil {
+			return err
+		}
+		gotObj, err := asObject(got)
+		if err != nil {
+			return err
+		}
+		if len(wantObj.Fields) != len(gotObj.Fields) {
+			return fmt.Errorf("expected %d entries, got %d", len(wantObj.Fields), len(gotObj.Fields))
+		}
+		for _, f := range wantObj.Fields {
+			gv, ok := gotObj.Get(f.Key)
+			if !ok {
+				return fmt.Errorf("key %q is missing", f.Key)
+			}
+			if err := c.compare(t.Value, f.Value, gv); err != nil {
+				return fmt.Errorf("key %q value: %w", f.Key, err)
+			}
+		}
+		return nil
+	}
+	return c.compareEncoded(expr, want, got)
+}
+
+// compareEncoded compares want and got by their encoding as expr.
+func (c *Codec) compareEncoded(expr ast.Expr, want, got any) error {
+	wb, err := c.encode(nil, expr, want)
+	if err != nil {
+		return err
+	}
+	gb, err := c.encode(nil, expr, got)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(wb, gb) {
+		return fmt.Errorf("expected %v, got %v", want, got)
+	}
+	return nil
+}
+
+// compareTime compares the times want and got of the time type name, e.g. zonedTime.
+func compareTime(name string, want, got any) error {
+	wt, err := parseTimeValue(name, want)
+	if err != nil {
+		return err
+	}
+	gt, err := parseTimeValue(name, got)
+	if err != nil {
+		return err
+	}
+	if !wt.Equal(gt) {
+		return fmt.Errorf("expected %s, got %s", wt.Format(time.RFC3339Nano), gt.Format(time.RFC3339Nano))
+	}
+	return nil
+}
+
+// parseTimeValue parses the string form of a time of the time type name, nil being the zero time.Time.
+func parseTimeValue(name string, v any) (time.Time, error) {
+	s, ok := v.(string)
+	if !ok && v != nil {
+		return time.Time{}, fmt.Errorf("expected an RFC 3339 string, got %T", v)
+	}
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if name == zonedTime {
+		return parseZoned(s)
+	}
+	return time.Parse(time.RFC3339Nano, s)
+}
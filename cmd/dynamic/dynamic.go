@@ -0,0 +1,1103 @@
+// Package dynamic encodes and decodes benc messages at runtime, driven by the
+// parsed schema instead of generated code. It is used by the CLI tooling to
+// inspect benc data, e.g. converting frames to JSON and back.
+package dynamic
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/ast"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/banditmoscow1337/benc/cmd/generator/common"
+	bstd "github.com/banditmoscow1337/benc/std/golang"
+)
+
+var ErrUnsupportedType = errors.New("unsupported type")
+
+// Codec decodes benc messages into JSON compatible values and encodes them back.
+//
+// Decoded values are: nil, bool, int64, uint64, float64, string, []byte,
+// []any, *Object (structs and maps) and the string forms of time.Time (RFC 3339,
+// see timeType for the ones keeping their zone), time.Duration and the types bstd handles
+// natively, see nativeTypes. Variants decode to the same values, their maps to Objects.
+type Codec struct {
+	*common.Context
+	// dict is the string dictionary of the dict field currently decoded or encoded.
+	dict *bstd.StringDict
+	// Trace, if set, records the operations of Decode.
+	Trace *Trace
+}
+
+func New(ctx *common.Context) *Codec {
+	cc := *ctx
+	cc.Variants = true
+	return &Codec{Context: &cc}
+}
+
+// Field is a single key-value pair of an Object.
+type Field struct {
+	Key   string
+	Value any
+}
+
+// Object is a struct or map value, which keeps the wire order of its fields.
+type Object struct {
+	Fields []Field
+}
+
+// Get returns the value of the field with the given key.
+func (o *Object) Get(key string) (any, bool) {
+	for _, f := range o.Fields {
+		if f.Key == key {
+			return f.Value, true
+		}
+	}
+	return nil, false
+}
+
+func (o *Object) MarshalJSON() ([]byte, error) {
+	b := []byte{'{'}
+	for i, f := range o.Fields {
+		if i > 0 {
+			b = append(b, ',')
+		}
+		b = strconv.AppendQuote(b, f.Key)
+		b = append(b, ':')
+		v, err := json.Marshal(f.Value)
+		if err != nil {
+			return nil, err
+		}
+		b = append(b, v...)
+	}
+	return append(b, '}'), nil
+}
+
+// Decode unmarshals a message of the named type from b at offset n.
+func (c *Codec) Decode(typeName string, n int, b []byte) (int, any, error) {
+	if _, ok := c.TypeSpecs[typeName]; !ok {
+		return 0, nil, fmt.Errorf("unknown type %q", typeName)
+	}
+	return c.decode(ast.NewIdent(typeName), n, b)
+}
+
+// Encode marshals v as a message of the named type. v is expected to be in
+// the form produced by Decode or by encoding/json with UseNumber.
+func (c *Codec) Encode(typeName string, v any) ([]byte, error) {
+	ts, ok := c.TypeSpecs[typeName]
+	if !ok {
+		return nil, fmt.Errorf("unknown type %q", typeName)
+	}
+	return c.encode(nil, ts.Type, v)
+}
+
+func (c *Codec) decode(expr ast.Expr, n int, b []byte) (int, any, error) {
+	if c.Trace != nil {
+		return c.traceDecode(expr, n, b)
+	}
+	return c.decodeValue(expr, n, b)
+}
+
+func (c *Codec) decodeValue(expr ast.Expr, n int, b []byte) (int, any, error) {
+	if common.IsVariantType(expr) {
+		return DecodeVariant(n, b)
+	}
+	if nt, ok := c.nativeType(expr); ok {
+		return nt.decode(n, b)
+	}
+	switch t := expr.(type) {
+	case *ast.Ident:
+		if ts, ok := c.TypeSpecs[t.Name]; ok {
+			return c.decode(ts.Type, n, b)
+		}
+		if e, ok := c.Enums[t.Name]; ok {
+			return decodeEnum(e, n, b)
+		}
+		if name, ok := strings.CutPrefix(t.Name, deltaPrefix); ok {
+			return decodeDelta(name, n, b)
+		}
+		if t.Name == dictString {
+			return bstd.UnmarshalStringDict(n, b, c.dict)
+		}
+		if ct, ok := compactTimes[t.Name]; ok {
+			return ct.decode(n, b)
+		}
+		return decodeBasic(t.Name, n, b)
+	case *ast.StructType:
+		layout, words, wordSize, err := c.flagLayout(t)
+		if err != nil {
+			return 0, nil, err
+		}
+		var flags []uint32
+		obj := &Object{}
+		for _, field := range t.Fields.List {
+			if c.ShouldIgnoreField(field) || c.IsUnsupportedType(field.Type) {
+				continue
+			}
+			for _, name := range field.Names {
+				if fb, ok := layout[name.Name]; ok {
+					if isFirstFlag(fb) {
+						if n, flags, err = decodeFlags(n, b, words, wordSize); err != nil {
+							return 0, nil, err
+						}
+					}
+					obj.Fields = append(obj.Fields, Field{Key: name.Name, Value: flagValue(flags, fb, c.ExprToString(field.Type))})
+					continue
+				}
+				var v any
+				if n, v, err = c.decode(c.fieldType(field), n, b); err != nil {
+					return 0, nil, fmt.Errorf("%s: %w", name.Name, err)
+				}
+				obj.Fields = append(obj.Fields, Field{Key: name.Name, Value: v})
+			}
+		}
+		return n, obj, nil
+	case *ast.ParenExpr:
+		cc := *c
+		cc.dict = &bstd.StringDict{}
+		return cc.decode(t.X, n, b)
+	case *ast.StarExpr:
+		n, ok, err := bstd.UnmarshalBool(n, b)
+		if err != nil || !ok {
+			return n, nil, err
+		}
+		return c.decode(t.X, n, b)
+	case *ast.IndexExpr:
+		if elt, ok := common.OptionElt(t); ok {
+			return c.decode(&ast.StarExpr{X: elt}, n, b)
+		}
+		if elt, ok := sparseElt(t); ok {
+			return c.decodeSparse(elt, n, b)
+		}
+	case *ast.SelectorExpr:
+		if elt, ok := nullTypes[c.ExprToString(t)]; ok {
+			return c.decode(&ast.StarExpr{X: elt}, n, b)
+		}
+		switch c.ExprToString(t) {
+		case "time.Time":
+			n, v, err := bstd.UnmarshalTime(n, b)
+			return n, v.UTC().Format(time.RFC3339Nano), err
+		case "time.Duration":
+			n, v, err := bstd.UnmarshalDuration(n, b)
+			return n, v.String(), err
+		}
+	case *ast.ArrayType:
+		if t.Len != nil {
+			l, err := c.arrayLen(t)
+			if err != nil {
+				return 0, nil, err
+			}
+			if isByte(t.Elt) {
+				v := make([]byte, l)
+				n, err = bstd.UnmarshalByteArray(n, b, v)
+				return n, v, err
+			}
+			vs := make([]any, l)
+			for i := range vs {
+				if n, vs[i], err = c.decode(t.Elt, n, b); err != nil {
+					return 0, nil, err
+				}
+			}
+			return n, vs, nil
+		}
+		if isByte(t.Elt) {
+			return bstd.UnmarshalBytesCopied(n, b)
+		}
+		n, count, err := bstd.UnmarshalUint(n, b)
+		if err != nil {
+			return 0, nil, err
+		}
+		vs := make([]any, 0, min(count, uint(len(b)-n)))
+		for range count {
+			var v any
+			if n, v, err = c.decode(t.Elt, n, b); err != nil {
+				return 0, nil, err
+			}
+			vs = append(vs, v)
+		}
+		n, err = skipTerminator(n, b)
+		return n, vs, err
+	case *ast.Ellipsis:
+		n, count, err := bstd.UnmarshalUint(n, b)
+		if err != nil {
+			return 0, nil, err
+		}
+		vs := make([]any, 0, min(count, uint(len(b)-n)))
+		for remaining := count; remaining > 0; {
+			var run uint
+			if n, run, err = bstd.UnmarshalUint(n, b); err != nil {
+				return 0, nil, err
+			}
+			if run == 0 || run > remaining {
+				return 0, nil, bstd.ErrRunLength
+			}
+			var v any
+			if n, v, err = c.decode(t.Elt, n, b); err != nil {
+				return 0, nil, err
+			}
+			for range run {
+				vs = append(vs, v)
+			}
+			remaining -= run
+		}
+		if isByte(t.Elt) {
+			bs := make([]byte, len(vs))
+			for i, v := range vs {
+				bs[i] = byte(v.(uint64))
+			}
+			return n, bs, nil
+		}
+		return n, vs, nil
+	case *ast.MapType:
+		n, count, err := bstd.UnmarshalUint(n, b)
+		if err != nil {
+			return 0, nil, err
+		}
+		obj := &Object{}
+		for range count {
+			var k, v any
+			if n, k, err = c.decode(t.Key, n, b); err != nil {
+				return 0, nil, err
+			}
+			if n, v, err = c.decode(t.Value, n, b); err != nil {
+				return 0, nil, err
+			}
+			obj.Fields = append(obj.Fields, Field{Key: keyString(k), Value: v})
+		}
+		n, err = skipTerminator(n, b)
+		return n, obj, err
+	}
+	return 0, nil, fmt.Errorf("%w: %s", ErrUnsupportedType, c.ExprToString(expr))
+}
+
+func decodeBasic(name string, n int, b []byte) (int, any, error) {
+	var v any
+	var err error
+	switch name {
+	case "bool":
+		n, v, err = bstd.UnmarshalBool(n, b)
+	case "byte", "uint8":
+		var u uint8
+		n, u, err = bstd.UnmarshalByte(n, b)
+		v = uint64(u)
+	case "int8":
+		var i int8
+		n, i, err = bstd.UnmarshalInt8(n, b)
+		v = int64(i)
+	case "int16":
+		var i int16
+		n, i, err = bstd.UnmarshalInt16(n, b)
+		v = int64(i)
+	case "int32", "rune":
+		var i int32
+		n, i, err = bstd.UnmarshalInt32(n, b)
+		v = int64(i)
+	case "int64":
+		n, v, err = bstd.UnmarshalInt64(n, b)
+	case "int":
+		var i int
+		n, i, err = bstd.UnmarshalInt(n, b)
+		v = int64(i)
+	case "uint16":
+		var u uint16
+		n, u, err = bstd.UnmarshalUint16(n, b)
+		v = uint64(u)
+	case "uint32":
+		var u uint32
+		n, u, err = bstd.UnmarshalUint32(n, b)
+		v = uint64(u)
+	case "uint64":
+		n, v, err = bstd.UnmarshalUint64(n, b)
+	case "uint", "uintptr":
+		var u uint
+		n, u, err = bstd.UnmarshalUint(n, b)
+		v = uint64(u)
+	case "float32":
+		var f float32
+		n, f, err = bstd.UnmarshalFloat32(n, b)
+		v = float64(f)
+	case "float64":
+		n, v, err = bstd.UnmarshalFloat64(n, b)
+	case "string":
+		n, v, err = bstd.UnmarshalString(n, b)
+	case nulString:
+		n, v, err = bstd.UnmarshalStringNul(n, b)
+	case zonedTime:
+		return decodeZoned(n, b)
+	case gorillaType:
+		var fs []float64
+		n, fs, err = bstd.UnmarshalSliceGorilla(n, b)
+		vs := make([]any, len(fs))
+		for i, f := range fs {
+			vs[i] = f
+		}
+		v = vs
+	case varintPrefix + "int16":
+		var i int16
+		n, i, err = bstd.UnmarshalInt16Varint(n, b)
+		v = int64(i)
+	case varintPrefix + "int32":
+		var i int32
+		n, i, err = bstd.UnmarshalInt32Varint(n, b)
+		v = int64(i)
+	case varintPrefix + "int64":
+		n, v, err = bstd.UnmarshalInt64Varint(n, b)
+	case varintPrefix + "uint16":
+		var u uint16
+		n, u, err = bstd.UnmarshalUint16Varint(n, b)
+		v = uint64(u)
+	case varintPrefix + "uint32":
+		var u uint32
+		n, u, err = bstd.UnmarshalUint32Varint(n, b)
+		v = uint64(u)
+	case varintPrefix + "uint64":
+		n, v, err = bstd.UnmarshalUint64Varint(n, b)
+	default:
+		return 0, nil, fmt.Errorf("%w: %s", ErrUnsupportedType, name)
+	}
+	return n, v, err
+}
+
+func (c *Codec) encode(b []byte, expr ast.Expr, v any) ([]byte, error) {
+	if common.IsVariantType(expr) {
+		return encodeVariant(b, v)
+	}
+	if nt, ok := c.nativeType(expr); ok {
+		return nt.encode(b, v)
+	}
+	switch t := expr.(type) {
+	case *ast.Ident:
+		if ts, ok := c.TypeSpecs[t.Name]; ok {
+			return c.encode(b, ts.Type, v)
+		}
+		if e, ok := c.Enums[t.Name]; ok {
+			return encodeEnum(b, e, v)
+		}
+		if name, ok := strings.CutPrefix(t.Name, deltaPrefix); ok {
+			return encodeDelta(b, name, v)
+		}
+		if ct, ok := compactTimes[t.Name]; ok {
+			return ct.encode(b, v)
+		}
+		if t.Name == dictString {
+			s, ok := v.(string)
+			if !ok && v != nil {
+				return nil, fmt.Errorf("expected a string, got %T", v)
+			}
+			// room for the string itself, a reference to the dictionary takes less
+			n := len(b)
+			b = append(b, make([]byte, bstd.SizeStringDict(&bstd.StringDict{}, s))...)
+			return b[:bstd.MarshalStringDict(n, b, c.dict, s)], nil
+		}
+		return encodeBasic(b, t.Name, v)
+	case *ast.ParenExpr:
+		cc := *c
+		cc.dict = &bstd.StringDict{}
+		return cc.encode(b, t.X, v)
+	case *ast.StructType:
+		obj, err := asObject(v)
+		if err != nil {
+			return nil, err
+		}
+		layout, words, wordSize, err := c.flagLayout(t)
+		if err != nil {
+			return nil, err
+		}
+		for _, field := range t.Fields.List {
+			if c.ShouldIgnoreField(field) || c.IsUnsupportedType(field.Type) {
+				continue
+			}
+			for _, name := range field.Names {
+				if fb, ok := layout[name.Name]; ok {
+					if isFirstFlag(fb) {
+						if b, err = c.encodeFlags(b, t, layout, words, wordSize, obj); err != nil {
+							return nil, err
+						}
+					}
+					continue
+				}
+				fv, _ := obj.Get(name.Name)
+				if b, err = c.encode(b, c.fieldType(field), fv); err != nil {
+					return nil, fmt.Errorf("%s: %w", name.Name, err)
+				}
+			}
+		}
+		return b, nil
+	case *ast.StarExpr:
+		if v == nil {
+			return appendWith(b, bstd.SizeBool(), func(n int, b []byte) int { return bstd.MarshalBool(n, b, false) }), nil
+		}
+		b = appendWith(b, bstd.SizeBool(), func(n int, b []byte) int { return bstd.MarshalBool(n, b, true) })
+		return c.encode(b, t.X, v)
+	case *ast.IndexExpr:
+		if elt, ok := common.OptionElt(t); ok {
+			return c.encode(b, &ast.StarExpr{X: elt}, v)
+		}
+		if elt, ok := sparseElt(t); ok {
+			return c.encodeSparse(b, elt, v)
+		}
+	case *ast.SelectorExpr:
+		if elt, ok := nullTypes[c.ExprToString(t)]; ok {
+			return c.encode(b, &ast.StarExpr{X: elt}, v)
+		}
+		switch c.ExprToString(t) {
+		case "time.Time":
+			s, ok := v.(string)
+			if !ok && v != nil {
+				return nil, fmt.Errorf("expected an RFC 3339 string, got %T", v)
+			}
+			var tm time.Time
+			if s != "" {
+				var err error
+				if tm, err = time.Parse(time.RFC3339Nano, s); err != nil {
+					return nil, err
+				}
+			}
+			return appendWith(b, bstd.SizeTime(), func(n int, b []byte) int { return bstd.MarshalTime(n, b, tm) }), nil
+		case "time.Duration":
+			var d time.Duration
+			switch dv := v.(type) {
+			case string:
+				var err error
+				if d, err = time.ParseDuration(dv); err != nil {
+					return nil, err
+				}
+			case nil:
+			default:
+				i, err := toInt64(v)
+				if err != nil {
+					return nil, err
+				}
+				d = time.Duration(i)
+			}
+			return appendWith(b, bstd.SizeDuration(), func(n int, b []byte) int { return bstd.MarshalDuration(n, b, d) }), nil
+		}
+	case *ast.ArrayType:
+		if isByte(t.Elt) {
+			bs, err := toBytes(v)
+			if err != nil {
+				return nil, err
+			}
+			if t.Len != nil {
+				l, err := c.arrayLen(t)
+				if err != nil {
+					return nil, err
+				}
+				if v == nil {
+					// a missing array is the zero one, like a missing value of any other type
+					bs = make([]byte, l)
+				}
+				if len(bs) != l {
+					return nil, fmt.Errorf("expected %d bytes, got %d", l, len(bs))
+				}
+				return append(b, bs...), nil
+			}
+			return appendWith(b, bstd.SizeBytes(bs), func(n int, b []byte) int { return bstd.MarshalBytes(n, b, bs) }), nil
+		}
+		vs, ok := v.([]any)
+		if !ok && v != nil {
+			return nil, fmt.Errorf("expected an array, got %T", v)
+		}
+		if t.Len != nil {
+			l, err := c.arrayLen(t)
+			if err != nil {
+				return nil, err
+			}
+			if v == nil {
+				vs = make([]any, l)
+			}
+			if len(vs) != l {
+				return nil, fmt.Errorf("expected %d elements, got %d", l, len(vs))
+			}
+		} else {
+			b = appendWith(b, bstd.SizeUint(uint(len(vs))), func(n int, b []byte) int { return bstd.MarshalUint(n, b, uint(len(vs))) })
+		}
+		var err error
+		for i, ev := range vs {
+			if b, err = c.encode(b, t.Elt, ev); err != nil {
+				return nil, fmt.Errorf("index [%d]: %w", i, err)
+			}
+		}
+		if t.Len == nil {
+			b = append(b, 1, 1, 1, 1)
+		}
+		return b, nil
+	case *ast.Ellipsis:
+		vs, ok := v.([]any)
+		if isByte(t.Elt) {
+			bs, err := toBytes(v)
+			if err != nil {
+				return nil, err
+			}
+			vs, ok = make([]any, len(bs)), true
+			for i, e := range bs {
+				vs[i] = uint64(e)
+			}
+		}
+		if !ok && v != nil {
+			return nil, fmt.Errorf("expected an array, got %T", v)
+		}
+		b = appendWith(b, bstd.SizeUint(uint(len(vs))), func(n int, b []byte) int { return bstd.MarshalUint(n, b, uint(len(vs))) })
+
+		// runs are detected on the encoded elements, so equal numbers of different Go types match
+		var prev []byte
+		run := 0
+		flush := func() {
+			b = appendWith(b, bstd.SizeUint(uint(run)), func(n int, b []byte) int { return bstd.MarshalUint(n, b, uint(run)) })
+			b = append(b, prev...)
+		}
+		for i, ev := range vs {
+			e, err := c.encode(nil, t.Elt, ev)
+			if err != nil {
+				return nil, fmt.Errorf("index [%d]: %w", i, err)
+			}
+			if run > 0 && bytes.Equal(e, prev) {
+				run++
+				continue
+			}
+			if run > 0 {
+				flush()
+			}
+			prev, run = e, 1
+		}
+		if run > 0 {
+			flush()
+		}
+		return b, nil
+	case *ast.MapType:
+		obj, err := asObject(v)
+		if err != nil {
+			return nil, err
+		}
+		b = appendWith(b, bstd.SizeUint(uint(len(obj.Fields))), func(n int, b []byte) int { return bstd.MarshalUint(n, b, uint(len(obj.Fields))) })
+		for _, f := range obj.Fields {
+			key, err := c.parseKey(t.Key, f.Key)
+			if err != nil {
+				return nil, err
+			}
+			if b, err = c.encode(b, t.Key, key); err != nil {
+				return nil, fmt.Errorf("key %q: %w", f.Key, err)
+			}
+			if b, err = c.encode(b, t.Value, f.Value); err != nil {
+				return nil, fmt.Errorf("key %q value: %w", f.Key, err)
+			}
+		}
+		return append(b, 1, 1, 1, 1), nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnsupportedType, c.ExprToString(expr))
+}
+
+func encodeBasic(b []byte, name string, v any) ([]byte, error) {
+	switch name {
+	case "bool":
+		bv, ok := v.(bool)
+		if !ok && v != nil {
+			return nil, fmt.Errorf("expected a bool, got %T", v)
+		}
+		return appendWith(b, bstd.SizeBool(), func(n int, b []byte) int { return bstd.MarshalBool(n, b, bv) }), nil
+	case "string":
+		s, ok := v.(string)
+		if !ok && v != nil {
+			return nil, fmt.Errorf("expected a string, got %T", v)
+		}
+		return appendWith(b, bstd.SizeString(s), func(n int, b []byte) int { return bstd.MarshalString(n, b, s) }), nil
+	case nulString:
+		s, ok := v.(string)
+		if !ok && v != nil {
+			return nil, fmt.Errorf("expected a string, got %T", v)
+		}
+		return appendWith(b, bstd.SizeStringNul(s), func(n int, b []byte) int { return bstd.MarshalStringNul(n, b, s) }), nil
+	case zonedTime:
+		return encodeZoned(b, v)
+	case gorillaType:
+		vs, ok := v.([]any)
+		if !ok && v != nil {
+			return nil, fmt.Errorf("expected an array, got %T", v)
+		}
+		fs := make([]float64, len(vs))
+		for i, ev := range vs {
+			var err error
+			if fs[i], err = toFloat64(ev); err != nil {
+				return nil, fmt.Errorf("index [%d]: %w", i, err)
+			}
+		}
+		return appendWith(b, bstd.SizeSliceGorilla(fs), func(n int, b []byte) int { return bstd.MarshalSliceGorilla(n, b, fs) }), nil
+	case "float32", "float64":
+		f, err := toFloat64(v)
+		if err != nil {
+			return nil, err
+		}
+		if name == "float32" {
+			return appendWith(b, bstd.SizeFloat32(), func(n int, b []byte) int { return bstd.MarshalFloat32(n, b, float32(f)) }), nil
+		}
+		return appendWith(b, bstd.SizeFloat64(), func(n int, b []byte) int { return bstd.MarshalFloat64(n, b, f) }), nil
+	case "byte", "uint8", "uint16", "uint32", "uint64", "uint", "uintptr",
+		varintPrefix + "uint16", varintPrefix + "uint32", varintPrefix + "uint64":
+		u, err := toUint64(v)
+		if err != nil {
+			return nil, err
+		}
+		switch name {
+		case varintPrefix + "uint16":
+			return appendWith(b, bstd.SizeUint16Varint(uint16(u)), func(n int, b []byte) int { return bstd.MarshalUint16Varint(n, b, uint16(u)) }), nil
+		case varintPrefix + "uint32":
+			return appendWith(b, bstd.SizeUint32Varint(uint32(u)), func(n int, b []byte) int { return bstd.MarshalUint32Varint(n, b, uint32(u)) }), nil
+		case varintPrefix + "uint64":
+			return appendWith(b, bstd.SizeUint64Varint(u), func(n int, b []byte) int { return bstd.MarshalUint64Varint(n, b, u) }), nil
+		case "uint16":
+			return appendWith(b, bstd.SizeUint16(), func(n int, b []byte) int { return bstd.MarshalUint16(n, b, uint16(u)) }), nil
+		case "uint32":
+			return appendWith(b, bstd.SizeUint32(), func(n int, b []byte) int { return bstd.MarshalUint32(n, b, uint32(u)) }), nil
+		case "uint64":
+			return appendWith(b, bstd.SizeUint64(), func(n int, b []byte) int { return bstd.MarshalUint64(n, b, u) }), nil
+		case "uint", "uintptr":
+			return appendWith(b, bstd.SizeUint(uint(u)), func(n int, b []byte) int { return bstd.MarshalUint(n, b, uint(u)) }), nil
+		}
+		return append(b, byte(u)), nil
+	case "int8", "int16", "int32", "rune", "int64", "int",
+		varintPrefix + "int16", varintPrefix + "int32", varintPrefix + "int64":
+		i, err := toInt64(v)
+		if err != nil {
+			return nil, err
+		}
+		switch name {
+		case varintPrefix + "int16":
+			return appendWith(b, bstd.SizeInt16Varint(int16(i)), func(n int, b []byte) int { return bstd.MarshalInt16Varint(n, b, int16(i)) }), nil
+		case varintPrefix + "int32":
+			return appendWith(b, bstd.SizeInt32Varint(int32(i)), func(n int, b []byte) int { return bstd.MarshalInt32Varint(n, b, int32(i)) }), nil
+		case varintPrefix + "int64":
+			return appendWith(b, bstd.SizeInt64Varint(i), func(n int, b []byte) int { return bstd.MarshalInt64Varint(n, b, i) }), nil
+		case "int8":
+			return append(b, byte(i)), nil
+		case "int16":
+			return appendWith(b, bstd.SizeInt16(), func(n int, b []byte) int { return bstd.MarshalInt16(n, b, int16(i)) }), nil
+		case "int32", "rune":
+			return appendWith(b, bstd.SizeInt32(), func(n int, b []byte) int { return bstd.MarshalInt32(n, b, int32(i)) }), nil
+		case "int64":
+			return appendWith(b, bstd.SizeInt64(), func(n int, b []byte) int { return bstd.MarshalInt64(n, b, i) }), nil
+		}
+		return appendWith(b, bstd.SizeInt(int(i)), func(n int, b []byte) int { return bstd.MarshalInt(n, b, int(i)) }), nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnsupportedType, name)
+}
+
+// varintPrefix marks the integer types of varint fields, deltaPrefix the delta encoded
+// integer slices, gorillaType the Gorilla encoded []float64, dictString the strings
+// of dict fields, nulString the strings of nul fields, zonedTime the times of zone fields,
+// compactPrefix the times of compact time fields and bfloat16Float the float32s of bfloat16
+// fields, see fieldType.
+const (
+	varintPrefix  = "varint "
+	deltaPrefix   = "delta "
+	gorillaType   = "gorilla float64"
+	dictString    = "dict string"
+	nulString     = "nul string"
+	zonedTime     = "zoned time.Time"
+	compactPrefix = "compact time.Time "
+	bfloat16Float = "bfloat16 float32"
+)
+
+// fieldType returns the type of the field. If the field uses the varint encoding,
+// its integer types are renamed to varintPrefix + name, e.g. "varint int64".
+// Run-length encoded slices are returned as *ast.Ellipsis of their element type, sparse
+// encoded slices as *ast.IndexExpr of sparseSlice and their element type, delta encoded slices as deltaPrefix + element type, e.g. "delta uint64", and
+// Gorilla encoded slices as gorillaType. The type of a dict field is wrapped into an
+// *ast.ParenExpr, which starts a new string dictionary, and its strings are renamed
+// to dictString. The strings of a nul field are renamed to nulString. The times of a zone field are renamed to zonedTime, the ones of a compact
+// time field to compactPrefix + unit, e.g. "compact time.Time ms". The float32s of a bfloat16
+// field are renamed to bfloat16Float, which is unsupported, as the experimental encoding is
+// left out of the builds without the benc_experimental build tag.
+func (c *Codec) fieldType(field *ast.Field) ast.Expr {
+	typ := field.Type
+	if c.IsGorillaField(field) {
+		return &ast.Ident{Name: gorillaType}
+	}
+	if t, ok := typ.(*ast.ArrayType); ok && t.Len == nil && c.IsDeltaField(field) {
+		if elt, ok := t.Elt.(*ast.Ident); ok {
+			return &ast.Ident{Name: deltaPrefix + elt.Name}
+		}
+	}
+	if c.IsVarintField(field) {
+		typ = varintType(typ)
+	}
+	if t, ok := typ.(*ast.ArrayType); ok && t.Len == nil && c.IsRLEField(field) {
+		typ = &ast.Ellipsis{Elt: t.Elt}
+	}
+	if t, ok := typ.(*ast.ArrayType); ok && t.Len == nil && c.IsSparseField(field) {
+		typ = &ast.IndexExpr{X: &ast.Ident{Name: sparseSlice}, Index: t.Elt}
+	}
+	if c.IsZoneField(field) {
+		typ = timeType(c.Context, typ, zonedTime)
+	}
+	if unit := c.FieldCompactTime(field); unit != "" {
+		typ = timeType(c.Context, typ, compactPrefix+unit)
+	}
+	if c.IsBFloat16Field(field) {
+		typ = bfloat16Type(typ)
+	}
+	if c.IsNulField(field) {
+		typ = nulType(typ)
+	}
+	if c.IsDictField(field) {
+		typ = &ast.ParenExpr{X: dictType(typ)}
+	}
+	return typ
+}
+
+func dictType(expr ast.Expr) ast.Expr {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		if t.Name == "string" {
+			return &ast.Ident{Name: dictString}
+		}
+	case *ast.StarExpr:
+		return &ast.StarExpr{X: dictType(t.X)}
+	case *ast.IndexExpr:
+		if elt, ok := common.OptionElt(t); ok {
+			return &ast.StarExpr{X: dictType(elt)}
+		}
+	case *ast.ArrayType:
+		return &ast.ArrayType{Len: t.Len, Elt: dictType(t.Elt)}
+	case *ast.MapType:
+		return &ast.MapType{Key: dictType(t.Key), Value: dictType(t.Value)}
+	}
+	return expr
+}
+
+func nulType(expr ast.Expr) ast.Expr {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		if t.Name == "string" {
+			return &ast.Ident{Name: nulString}
+		}
+	case *ast.StarExpr:
+		return &ast.StarExpr{X: nulType(t.X)}
+	case *ast.IndexExpr:
+		if elt, ok := common.OptionElt(t); ok {
+			return &ast.StarExpr{X: nulType(elt)}
+		}
+	case *ast.ArrayType:
+		return &ast.ArrayType{Len: t.Len, Elt: nulType(t.Elt)}
+	case *ast.MapType:
+		return &ast.MapType{Key: nulType(t.Key), Value: nulType(t.Value)}
+	}
+	return expr
+}
+
+func bfloat16Type(expr ast.Expr) ast.Expr {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		if t.Name == "float32" {
+			return &ast.Ident{Name: bfloat16Float}
+		}
+	case *ast.StarExpr:
+		return &ast.StarExpr{X: bfloat16Type(t.X)}
+	case *ast.IndexExpr:
+		if elt, ok := common.OptionElt(t); ok {
+			return &ast.StarExpr{X: bfloat16Type(elt)}
+		}
+	case *ast.ArrayType:
+		return &ast.ArrayType{Len: t.Len, Elt: bfloat16Type(t.Elt)}
+	case *ast.MapType:
+		return &ast.MapType{Key: bfloat16Type(t.Key), Value: bfloat16Type(t.Value)}
+	}
+	return expr
+}
+
+func varintType(expr ast.Expr) ast.Expr {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		if common.VarintTypes[t.Name] {
+			return &ast.Ident{Name: varintPrefix + t.Name}
+		}
+	case *ast.StarExpr:
+		return &ast.StarExpr{X: varintType(t.X)}
+	case *ast.IndexExpr:
+		if elt, ok := common.OptionElt(t); ok {
+			return &ast.StarExpr{X: varintType(elt)}
+		}
+	case *ast.ArrayType:
+		return &ast.ArrayType{Len: t.Len, Elt: varintType(t.Elt)}
+	case *ast.MapType:
+		return &ast.MapType{Key: varintType(t.Key), Value: varintType(t.Value)}
+	}
+	return expr
+}
+
+// decodeDelta unmarshals a delta encoded slice of the integer type name, see bstd.UnmarshalSliceDelta.
+func decodeDelta(name string, n int, b []byte) (int, any, error) {
+	n, count, err := bstd.UnmarshalUint(n, b)
+	if err != nil {
+		return 0, nil, err
+	}
+	vs := make([]any, 0, min(count, uint(len(b)-n)))
+	var prev int64
+	for range count {
+		var delta int64
+		if n, delta, err = bstd.UnmarshalInt64Varint(n, b); err != nil {
+			return 0, nil, err
+		}
+		prev = truncateInt(name, prev+delta)
+		vs = append(vs, intValue(name, prev))
+	}
+	return n, vs, nil
+}
+
+// encodeDelta marshals the integers vs as delta encoded slice of the integer type name,
+// see bstd.MarshalSliceDelta.
+func encodeDelta(b []byte, name string, v any) ([]byte, error) {
+	vs, ok := v.([]any)
+	if !ok && v != nil {
+		return nil, fmt.Errorf("expected an array, got %T", v)
+	}
+	b = appendWith(b, bstd.SizeUint(uint(len(vs))), func(n int, b []byte) int { return bstd.MarshalUint(n, b, uint(len(vs))) })
+	var prev int64
+	for i, ev := range vs {
+		var x int64
+		var err error
+		if name[0] == 'u' {
+			var u uint64
+			u, err = toUint64(ev)
+			x = int64(u)
+		} else {
+			x, err = toInt64(ev)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("index [%d]: %w", i, err)
+		}
+		x = truncateInt(name, x)
+		delta := x - prev
+		b = appendWith(b, bstd.SizeInt64Varint(delta), func(n int, b []byte) int { return bstd.MarshalInt64Varint(n, b, delta) })
+		prev = x
+	}
+	return b, nil
+}
+
+// truncateInt converts x to the integer type name and back to int64,
+// like the int64(T(x)) of the generated code.
+func truncateInt(name string, x int64) int64 {
+	switch name {
+	case "int8":
+		return int64(int8(x))
+	case "int16":
+		return int64(int16(x))
+	case "int32", "rune":
+		return int64(int32(x))
+	case "uint16":
+		return int64(uint16(x))
+	case "uint32":
+		return int64(uint32(x))
+	}
+	return x
+}
+
+// intValue returns the integer x of the type name in its decoded form, uint64 or int64.
+func intValue(name string, x int64) any {
+	if name[0] == 'u' {
+		return uint64(x)
+	}
+	return x
+}
+
+// appendWith grows b by size bytes and marshals into the new space.
+func appendWith(b []byte, size int, marshal func(n int, b []byte) int) []byte {
+	n := len(b)
+	b = append(b, make([]byte, size)...)
+	marshal(n, b)
+	return b
+}
+
+func skipTerminator(n int, b []byte) (int, error) {
+	if len(b)-n < 4 {
+		return 0, bstd.ErrBufTooSmall
+	}
+	return n + 4, nil
+}
+
+// arrayLen evaluates the length of a fixed size array, which must be an integer literal.
+func (c *Codec) arrayLen(t *ast.ArrayType) (int, error) {
+	if lit, ok := t.Len.(*ast.BasicLit); ok {
+		if l, err := strconv.Atoi(lit.Value); err == nil {
+			return l, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: array length %s is not an integer literal", ErrUnsupportedType, c.ExprToString(t.Len))
+}
+
+// parseKey converts a JSON object key back into a value of the map key type.
+func (c *Codec) parseKey(expr ast.Expr, key string) (any, error) {
+	if id, ok := expr.(*ast.Ident); ok {
+		if ts, ok := c.TypeSpecs[id.Name]; ok {
+			return c.parseKey(ts.Type, key)
+		}
+		if _, ok := c.Enums[id.Name]; ok {
+			return key, nil
+		}
+		switch id.Name {
+		case "string", dictString, nulString:
+			return key, nil
+		case "bool":
+			return strconv.ParseBool(key)
+		case "float32", "float64":
+			return strconv.ParseFloat(key, 64)
+		}
+	}
+	if _, ok := c.nativeType(expr); ok {
+		return key, nil
+	}
+	if _, ok := expr.(*ast.StructType); ok {
+		// struct keys are written as their JSON object, see keyString
+		return ReadJSON(json.NewDecoder(strings.NewReader(key)))
+	}
+	return json.Number(key), nil
+}
+
+func keyString(k any) string {
+	switch k := k.(type) {
+	case string:
+		return k
+	case []byte:
+		return base64.StdEncoding.EncodeToString(k)
+	case *Object:
+		b, _ := k.MarshalJSON()
+		return string(b)
+	default:
+		return fmt.Sprint(k)
+	}
+}
+
+func asObject(v any) (*Object, error) {
+	switch v := v.(type) {
+	case *Object:
+		return v, nil
+	case map[string]any:
+		obj := &Object{}
+		for k, fv := range v {
+			obj.Fields = append(obj.Fields, Field{Key: k, Value: fv})
+		}
+		return obj, nil
+	case nil:
+		return &Object{}, nil
+	}
+	return nil, fmt.Errorf("expected an object, got %T", v)
+}
+
+func toBytes(v any) ([]byte, error) {
+	switch v := v.(type) {
+	case []byte:
+		return v, nil
+	case string:
+		return base64.StdEncoding.DecodeString(v)
+	case nil:
+		return nil, nil
+	}
+	return nil, fmt.Errorf("expected base64 encoded bytes, got %T", v)
+}
+
+func toInt64(v any) (int64, error) {
+	switch v := v.(type) {
+	case int64:
+		return v, nil
+	case uint64:
+		return int64(v), nil
+	case float64:
+		return int64(v), nil
+	case json.Number:
+		return strconv.ParseInt(string(v), 10, 64)
+	case nil:
+		return 0, nil
+	}
+	return 0, fmt.Errorf("expected an integer, got %T", v)
+}
+
+func toUint64(v any) (uint64, error) {
+	switch v := v.(type) {
+	case uint64:
+		return v, nil
+	case int64:
+		return uint64(v), nil
+	case float64:
+		return uint64(v), nil
+	case json.Number:
+		return strconv.ParseUint(string(v), 10, 64)
+	case nil:
+		return 0, nil
+	}
+	return 0, fmt.Errorf("expected an unsigned integer, got %T", v)
+}
+
+func toFloat64(v any) (float64, error) {
+	switch v := v.(type) {
+	case float64:
+		return v, nil
+	case int64:
+		return float64(v), nil
+	case uint64:
+		return float64(v), nil
+	case json.Number:
+		return v.Float64()
+	case nil:
+		return 0, nil
+	}
+	return 0, fmt.Errorf("expected a number, got %T", v)
+}
+
+func isByte(expr ast.Expr) bool {
+	id, ok := expr.(*ast.Ident)
+	return ok && (id.Name == "byte" || id.Name == "uint8")
+}
+
+// ReadJSON reads the next JSON value from dec in the form expected by Encode.
+// Unlike decoding into `any`, objects keep the order of their keys.
+// It returns io.EOF if dec has no more values.
+func ReadJSON(dec *json.Decoder) (any, error) {
+	dec.UseNumber()
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	return readJSONValue(dec, tok)
+}
+
+func readJSONValue(dec *json.Decoder, tok json.Token) (any, error) {
+	delim, ok := tok.(json.Delim)
+	if !ok {
+		return tok, nil
+	}
+
+	switch delim {
+	case '[':
+		vs := []any{}
+		for dec.More() {
+			v, err := ReadJSON(dec)
+			if err != nil {
+				return nil, err
+			}
+			vs = append(vs, v)
+		}
+		_, err := dec.Token()
+		return vs, err
+	case '{':
+		obj := &Object{}
+		for dec.More() {
+			tok, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			key, _ := tok.(string)
+			v, err := ReadJSON(dec)
+			if err != nil {
+				return nil, err
+			}
+			obj.Fields = append(obj.Fields, Field{Key: key, Value: v})
+		}
+		_, err := dec.Token()
+		return obj, err
+	}
+	return nil, fmt.Errorf("unexpected JSON delimiter %v", delim)
+}
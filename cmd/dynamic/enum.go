@@ -0,0 +1,63 @@
+package dynamic
+
+import (
+	"fmt"
+	"go/ast"
+	"math/rand"
+	"slices"
+
+	"github.com/banditmoscow1337/benc/cmd/generator/common"
+	bstd "github.com/banditmoscow1337/benc/std/golang"
+)
+
+// The constants of the //benc:enum types, see bstd.EnumTable, are the names of the constants,
+// which are marshalled as their ordinal. nil stands for the first constant.
+
+func decodeEnum(e *common.Enum, n int, b []byte) (int, any, error) {
+	n, i, err := bstd.UnmarshalUint(n, b)
+	if err != nil {
+		return 0, nil, err
+	}
+	if i >= uint(len(e.Values)) {
+		return 0, nil, fmt.Errorf("%w: ordinal %d exceeds the %d constants of %s", bstd.ErrInvalidData, i, len(e.Values), e.Name)
+	}
+	return n, e.Values[i], nil
+}
+
+func encodeEnum(b []byte, e *common.Enum, v any) ([]byte, error) {
+	name, ok := v.(string)
+	if !ok && v != nil {
+		return nil, fmt.Errorf("expected the name of a %s constant, got %T", e.Name, v)
+	}
+	i := 0
+	if v != nil {
+		if i = slices.Index(e.Values, name); i < 0 {
+			return nil, fmt.Errorf("%q is no %s constant", name, e.Name)
+		}
+	}
+	return appendWith(b, bstd.SizeUint(uint(i)), func(n int, b []byte) int { return bstd.MarshalUint(n, b, uint(i)) }), nil
+}
+
+// enumLiteral returns the name of the constant expr refers to, e.g. Red or schema.Red.
+func (c *Codec) enumLiteral(e *common.Enum, expr ast.Expr) (any, error) {
+	name := ""
+	switch x := expr.(type) {
+	case *ast.Ident:
+		name = x.Name
+	case *ast.SelectorExpr:
+		name = x.Sel.Name
+	}
+	if !slices.Contains(e.Values, name) {
+		return nil, fmt.Errorf("%s is no %s constant", c.ExprToString(expr), e.Name)
+	}
+	return name, nil
+}
+
+// enumBounds returns the bounds of the ordinal of a constant of e.
+func enumBounds(e *common.Enum) Bounds {
+	return Bounds{1, bstd.SizeUint(uint(len(e.Values) - 1))}
+}
+
+func randomEnum(r *rand.Rand, e *common.Enum) any {
+	return e.Values[r.Intn(len(e.Values))]
+}
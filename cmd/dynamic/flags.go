@@ -0,0 +1,93 @@
+package dynamic
+
+import (
+	"fmt"
+	"go/ast"
+
+	"github.com/banditmoscow1337/benc/cmd/generator/common"
+	bstd "github.com/banditmoscow1337/benc/std/golang"
+)
+
+// flagLayout returns the packed fields of the struct st, see common.FlagLayout, and the bytes
+// of its flag words, see common.FlagWordSize.
+func (c *Codec) flagLayout(st *ast.StructType) (map[string]common.FlagBits, int, int, error) {
+	for _, ts := range c.Types {
+		if ts.Type == st {
+			layout, words, err := c.FlagLayout(ts)
+			return layout, words, c.FlagWordSize(ts), err
+		}
+	}
+	return nil, 0, 0, nil
+}
+
+// isFirstFlag reports whether fb is the place of the first packed field, where the flag words are written.
+func isFirstFlag(fb common.FlagBits) bool {
+	return fb.Word == 0 && fb.Shift == 0
+}
+
+// decodeFlags unmarshals the flag words of a struct, uint32s or bytes if 'size' is 1.
+func decodeFlags(n int, b []byte, words, size int) (int, []uint32, error) {
+	flags := make([]uint32, words)
+	for i := range flags {
+		var err error
+		if size == 1 {
+			var byt byte
+			n, byt, err = bstd.UnmarshalByte(n, b)
+			flags[i] = uint32(byt)
+		} else {
+			n, flags[i], err = bstd.UnmarshalUint32(n, b)
+		}
+		if err != nil {
+			return 0, nil, err
+		}
+	}
+	return n, flags, nil
+}
+
+// flagValue returns the value of the packed field of the type typ, a bool or an uint64.
+func flagValue(flags []uint32, fb common.FlagBits, typ string) any {
+	v := uint64(flags[fb.Word]>>fb.Shift) & (1<<fb.Bits - 1)
+	if typ == "bool" {
+		return v != 0
+	}
+	return v
+}
+
+// encodeFlags marshals the flag words of the packed fields of st from obj, uint32s or bytes if
+// 'size' is 1.
+func (c *Codec) encodeFlags(b []byte, st *ast.StructType, layout map[string]common.FlagBits, words, size int, obj *Object) ([]byte, error) {
+	flags := make([]uint32, words)
+	for _, field := range st.Fields.List {
+		for _, name := range field.Names {
+			fb, ok := layout[name.Name]
+			if !ok {
+				continue
+			}
+			fv, _ := obj.Get(name.Name)
+			var v uint64
+			if c.ExprToString(field.Type) == "bool" {
+				bv, ok := fv.(bool)
+				if !ok && fv != nil {
+					return nil, fmt.Errorf("%s: expected a bool, got %T", name.Name, fv)
+				}
+				if bv {
+					v = 1
+				}
+			} else {
+				var err error
+				if v, err = toUint64(fv); err != nil {
+					return nil, fmt.Errorf("%s: %w", name.Name, err)
+				}
+			}
+			flags[fb.Word] |= uint32(v&(1<<fb.Bits-1)) << fb.Shift
+		}
+	}
+	for _, f := range flags {
+		if size == 1 {
+			b = appendWith(b, bstd.SizeByte(), func(n int, b []byte) int { return bstd.MarshalByte(n, b, byte(f)) })
+		} else {
+			b = appendWith(b, bstd.SizeUint32(), func(n int, b []byte) int { return bstd.MarshalUint32(n, b, f) })
+		}
+	}
+	return b, nil
+}
@@ -0,0 +1,235 @@
+package dynamic
+
+import (
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"strconv"
+	"strings"
+
+	"github.com/banditmoscow1337/benc/cmd/generator/common"
+)
+
+// Literal converts the Go expression expr of the given type, e.g. a composite literal
+// like Point{X: 1, Y: 2}, into a value in the form expected by Encode. Besides composite
+// literals, expr may contain constant expressions, nil and &T{...} for pointers and
+// bstd.Some(...), bstd.None[T]() and bstd.Option[T]{} for options.
+func (c *Codec) Literal(typ, expr ast.Expr) (any, error) {
+	if paren, ok := expr.(*ast.ParenExpr); ok {
+		return c.Literal(typ, paren.X)
+	}
+	if common.IsVariantType(typ) {
+		return c.variantLiteral(expr)
+	}
+
+	switch t := typ.(type) {
+	case *ast.Ident:
+		if ts, ok := c.TypeSpecs[t.Name]; ok {
+			return c.Literal(ts.Type, expr)
+		}
+		if e, ok := c.Enums[t.Name]; ok {
+			return c.enumLiteral(e, expr)
+		}
+		return c.constant(t.Name, expr)
+	case *ast.StructType:
+		lit, ok := expr.(*ast.CompositeLit)
+		if !ok {
+			return nil, fmt.Errorf("expected a composite literal, got %s", c.ExprToString(expr))
+		}
+		var names []string
+		for _, field := range t.Fields.List {
+			for _, name := range field.Names {
+				names = append(names, name.Name)
+			}
+		}
+		obj := &Object{}
+		for i, elt := range lit.Elts {
+			name, value := "", elt
+			if kv, ok := elt.(*ast.KeyValueExpr); ok {
+				key, ok := kv.Key.(*ast.Ident)
+				if !ok {
+					return nil, fmt.Errorf("invalid field name %s", c.ExprToString(kv.Key))
+				}
+				name, value = key.Name, kv.Value
+			} else if i < len(names) {
+				name = names[i]
+			} else {
+				return nil, fmt.Errorf("too many values in %s", c.ExprToString(expr))
+			}
+			fieldType := c.structField(t, name)
+			if fieldType == nil {
+				return nil, fmt.Errorf("unknown field %s", name)
+			}
+			v, err := c.Literal(fieldType, value)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", name, err)
+			}
+			obj.Fields = append(obj.Fields, Field{Key: name, Value: v})
+		}
+		return obj, nil
+	case *ast.StarExpr:
+		if id, ok := expr.(*ast.Ident); ok && id.Name == "nil" {
+			return nil, nil
+		}
+		if u, ok := expr.(*ast.UnaryExpr); ok && u.Op == token.AND {
+			return c.Literal(t.X, u.X)
+		}
+		return nil, fmt.Errorf("expected nil or &%s{...}, got %s", c.ExprToString(t.X), c.ExprToString(expr))
+	case *ast.IndexExpr:
+		if elt, ok := common.OptionElt(t); ok {
+			return c.optionLiteral(elt, expr)
+		}
+	case *ast.SelectorExpr:
+		if c.ExprToString(t) == "time.Duration" {
+			return c.constant("int64", expr)
+		}
+	case *ast.ArrayType:
+		if id, ok := expr.(*ast.Ident); ok && id.Name == "nil" && t.Len == nil {
+			return nil, nil
+		}
+		if isByte(t.Elt) {
+			// []byte("...")
+			if call, ok := expr.(*ast.CallExpr); ok && len(call.Args) == 1 {
+				s, err := c.constant("string", call.Args[0])
+				if err != nil {
+					return nil, err
+				}
+				return []byte(s.(string)), nil
+			}
+		}
+		lit, ok := expr.(*ast.CompositeLit)
+		if !ok {
+			return nil, fmt.Errorf("expected a composite literal, got %s", c.ExprToString(expr))
+		}
+		vs := make([]any, 0, len(lit.Elts))
+		if t.Len != nil {
+			l, err := c.arrayLen(t)
+			if err != nil {
+				return nil, err
+			}
+			vs = make([]any, 0, l)
+		}
+		var bs []byte
+		for i, elt := range lit.Elts {
+			if _, ok := elt.(*ast.KeyValueExpr); ok {
+				return nil, fmt.Errorf("indexed elements are not supported: %s", c.ExprToString(elt))
+			}
+			v, err := c.Literal(t.Elt, elt)
+			if err != nil {
+				return nil, fmt.Errorf("index [%d]: %w", i, err)
+			}
+			if isByte(t.Elt) {
+				bs = append(bs, byte(v.(uint64)))
+			}
+			vs = append(vs, v)
+		}
+		if t.Len != nil {
+			// the remaining elements of an array are zero values
+			l, _ := c.arrayLen(t)
+			for len(vs) < l {
+				if isByte(t.Elt) {
+					bs = append(bs, 0)
+				}
+				vs = append(vs, nil)
+			}
+		}
+		if isByte(t.Elt) {
+			return bs, nil
+		}
+		return vs, nil
+	case *ast.MapType:
+		if id, ok := expr.(*ast.Ident); ok && id.Name == "nil" {
+			return &Object{}, nil
+		}
+		lit, ok := expr.(*ast.CompositeLit)
+		if !ok {
+			return nil, fmt.Errorf("expected a composite literal, got %s", c.ExprToString(expr))
+		}
+		obj := &Object{}
+		for _, elt := range lit.Elts {
+			kv, ok := elt.(*ast.KeyValueExpr)
+			if !ok {
+				return nil, fmt.Errorf("expected a key-value pair, got %s", c.ExprToString(elt))
+			}
+			k, err := c.Literal(t.Key, kv.Key)
+			if err != nil {
+				return nil, err
+			}
+			v, err := c.Literal(t.Value, kv.Value)
+			if err != nil {
+				return nil, fmt.Errorf("key %s: %w", c.ExprToString(kv.Key), err)
+			}
+			obj.Fields = append(obj.Fields, Field{Key: keyString(k), Value: v})
+		}
+		return obj, nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnsupportedType, c.ExprToString(typ))
+}
+
+// structField returns the type of the named field of st.
+func (c *Codec) structField(st *ast.StructType, name string) ast.Expr {
+	for _, field := range st.Fields.List {
+		for _, n := range field.Names {
+			if n.Name == name {
+				return field.Type
+			}
+		}
+	}
+	return nil
+}
+
+// constant evaluates the constant expression expr as a value of the basic type name.
+func (c *Codec) constant(name string, expr ast.Expr) (any, error) {
+	tv, err := types.Eval(token.NewFileSet(), nil, token.NoPos, c.ExprToString(expr))
+	if err != nil {
+		return nil, err
+	}
+	if tv.Value == nil {
+		return nil, fmt.Errorf("%s is not a constant", c.ExprToString(expr))
+	}
+
+	v := tv.Value
+	switch name {
+	case "bool":
+		if v.Kind() == constant.Bool {
+			return constant.BoolVal(v), nil
+		}
+	case "string":
+		if v.Kind() == constant.String {
+			return constant.StringVal(v), nil
+		}
+	case "float32", "float64":
+		if f, ok := constant.Float64Val(constant.ToFloat(v)); ok || v.Kind() == constant.Float {
+			return f, nil
+		}
+	case "byte", "uint8", "uint16", "uint32", "uint64", "uint", "uintptr":
+		if u, ok := constant.Uint64Val(constant.ToInt(v)); ok {
+			return u, nil
+		}
+	default:
+		if i, ok := constant.Int64Val(constant.ToInt(v)); ok {
+			return i, nil
+		}
+	}
+	return nil, fmt.Errorf("%s is not a valid %s", strconv.Quote(c.ExprToString(expr)), name)
+}
+
+// optionLiteral converts expr, an option of 'elt', like a pointer: nil if it is empty.
+func (c *Codec) optionLiteral(elt, expr ast.Expr) (any, error) {
+	switch e := expr.(type) {
+	case *ast.CallExpr:
+		switch fun := strings.TrimPrefix(c.ExprToString(e.Fun), "bstd."); {
+		case (fun == "Some" || strings.HasPrefix(fun, "Some[")) && len(e.Args) == 1:
+			return c.Literal(elt, e.Args[0])
+		case strings.HasPrefix(fun, "None[") && len(e.Args) == 0:
+			return nil, nil
+		}
+	case *ast.CompositeLit:
+		if len(e.Elts) == 0 {
+			return nil, nil
+		}
+	}
+	return nil, fmt.Errorf("expected bstd.Some(...) or bstd.None[%s](), got %s", c.ExprToString(elt), c.ExprToString(expr))
+}
@@ -0,0 +1,526 @@
+package dynamic
+
+import (
+	"encoding"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"math"
+	"math/big"
+	"math/rand"
+	"net"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+
+	bstd "github.com/banditmoscow1337/benc/std/golang"
+)
+
+// The package-qualified types bstd handles natively, like *big.Int, are strings in their text
+// form, except json.RawMessage and bstd.Bitset. nil stands for the nil pointer of the pointer
+// types and for the zero value of the others.
+
+// nativeType is the encoding of a package-qualified type bstd handles natively.
+type nativeType struct {
+	bounds Bounds
+	decode func(n int, b []byte) (int, any, error)
+	encode func(b []byte, v any) ([]byte, error)
+	random func(r *rand.Rand) any
+}
+
+// nativeTypes are the nativeTypes by their type, the pointer included for the types only used
+// through pointers, e.g. "*big.Int".
+var nativeTypes = map[string]nativeType{
+	"*big.Int": {
+		bounds: Bounds{bstd.SizeBigInt(nil), Unbounded},
+		decode: func(n int, b []byte) (int, any, error) {
+			n, x, err := bstd.UnmarshalBigInt(n, b)
+			return textValue(n, x, err)
+		},
+		encode: func(b []byte, v any) ([]byte, error) {
+			x, err := parseText(v, "an integer", func(s string) (*big.Int, bool) { return new(big.Int).SetString(s, 10) })
+			if err != nil {
+				return nil, err
+			}
+			return appendWith(b, bstd.SizeBigInt(x), func(n int, b []byte) int { return bstd.MarshalBigInt(n, b, x) }), nil
+		},
+		random: func(r *rand.Rand) any { return bstd.GenerateBigInt(r, 0).String() },
+	},
+	"*big.Float": {
+		bounds: Bounds{bstd.SizeBigFloat(nil), Unbounded},
+		decode: func(n int, b []byte) (int, any, error) {
+			n, x, err := bstd.UnmarshalBigFloat(n, b)
+			if err != nil || x == nil {
+				return n, nil, err
+			}
+			return n, x.Text('g', -1), nil
+		},
+		encode: func(b []byte, v any) ([]byte, error) {
+			x, err := parseText(v, "a number", parseBigFloat)
+			if err != nil {
+				return nil, err
+			}
+			return appendWith(b, bstd.SizeBigFloat(x), func(n int, b []byte) int { return bstd.MarshalBigFloat(n, b, x) }), nil
+		},
+		random: func(r *rand.Rand) any { return bstd.GenerateBigFloat(r, 0).Text('g', -1) },
+	},
+	"*big.Rat": {
+		bounds: Bounds{bstd.SizeBigRat(nil), Unbounded},
+		decode: func(n int, b []byte) (int, any, error) {
+			n, x, err := bstd.UnmarshalBigRat(n, b)
+			if err != nil || x == nil {
+				return n, nil, err
+			}
+			return n, x.RatString(), nil
+		},
+		encode: func(b []byte, v any) ([]byte, error) {
+			x, err := parseText(v, "a fraction", func(s string) (*big.Rat, bool) { return new(big.Rat).SetString(s) })
+			if err != nil {
+				return nil, err
+			}
+			return appendWith(b, bstd.SizeBigRat(x), func(n int, b []byte) int { return bstd.MarshalBigRat(n, b, x) }), nil
+		},
+		random: func(r *rand.Rand) any { return bstd.GenerateBigRat(r, 0).RatString() },
+	},
+	"uuid.UUID": {
+		bounds: Bounds{bstd.SizeUUID(), bstd.SizeUUID()},
+		decode: func(n int, b []byte) (int, any, error) {
+			n, id, err := bstd.UnmarshalUUID(n, b)
+			if err != nil {
+				return 0, nil, err
+			}
+			return n, formatUUID(id), nil
+		},
+		encode: func(b []byte, v any) ([]byte, error) {
+			id, err := parseText(v, "a UUID", parseUUID)
+			if err != nil {
+				return nil, err
+			}
+			return appendWith(b, bstd.SizeUUID(), func(n int, b []byte) int { return bstd.MarshalUUID(n, b, id) }), nil
+		},
+		random: func(r *rand.Rand) any { return formatUUID(bstd.GenerateUUID[[16]byte](r, 0)) },
+	},
+	"netip.Addr":     textType(bstd.SizeAddr, bstd.MarshalAddr, bstd.UnmarshalAddr, bstd.GenerateAddr),
+	"netip.AddrPort": textType(bstd.SizeAddrPort, bstd.MarshalAddrPort, bstd.UnmarshalAddrPort, bstd.GenerateAddrPort),
+	"netip.Prefix":   textType(bstd.SizePrefix, bstd.MarshalPrefix, bstd.UnmarshalPrefix, bstd.GeneratePrefix),
+	"net.IP": {
+		bounds: Bounds{bstd.SizeIP(nil), bstd.SizeIP(make(net.IP, net.IPv6len))},
+		decode: func(n int, b []byte) (int, any, error) {
+			n, ip, err := bstd.UnmarshalIP(n, b)
+			if err != nil || len(ip) == 0 {
+				return n, nil, err
+			}
+			s, err := formatIP(ip)
+			if err != nil {
+				return 0, nil, err
+			}
+			return n, s, nil
+		},
+		encode: func(b []byte, v any) ([]byte, error) {
+			ip, err := parseText(v, "an IP address", parseIP)
+			if err != nil {
+				return nil, err
+			}
+			return appendWith(b, bstd.SizeIP(ip), func(n int, b []byte) int { return bstd.MarshalIP(n, b, ip) }), nil
+		},
+		random: func(r *rand.Rand) any {
+			s, _ := formatIP(bstd.GenerateIP(r, 0))
+			return s
+		},
+	},
+	"net.HardwareAddr": {
+		bounds: Bounds{bstd.SizeHardwareAddr(nil), Unbounded},
+		decode: func(n int, b []byte) (int, any, error) {
+			n, addr, err := bstd.UnmarshalHardwareAddr(n, b)
+			if err != nil || len(addr) == 0 {
+				return n, nil, err
+			}
+			return n, addr.String(), nil
+		},
+		encode: func(b []byte, v any) ([]byte, error) {
+			addr, err := parseText(v, "a hardware address", parseHardwareAddr)
+			if err != nil {
+				return nil, err
+			}
+			return appendWith(b, bstd.SizeHardwareAddr(addr), func(n int, b []byte) int { return bstd.MarshalHardwareAddr(n, b, addr) }), nil
+		},
+		random: func(r *rand.Rand) any { return bstd.GenerateHardwareAddr(r, 0).String() },
+	},
+	"*url.URL": {
+		bounds: Bounds{bstd.SizeURL(nil), Unbounded},
+		decode: func(n int, b []byte) (int, any, error) {
+			n, u, err := bstd.UnmarshalURL(n, b)
+			return textValue(n, u, err)
+		},
+		encode: func(b []byte, v any) ([]byte, error) {
+			u, err := parseText(v, "a URL", parseURL)
+			if err != nil {
+				return nil, err
+			}
+			return appendWith(b, bstd.SizeURL(u), func(n int, b []byte) int { return bstd.MarshalURL(n, b, u) }), nil
+		},
+		random: func(r *rand.Rand) any { return bstd.GenerateURL(r, 0).String() },
+	},
+	// raw JSON messages are kept as json.RawMessage, so they are written as the JSON they hold
+	"json.RawMessage": {
+		bounds: Bounds{bstd.SizeRawMessage(nil), Unbounded},
+		decode: func(n int, b []byte) (int, any, error) {
+			n, msg, err := bstd.UnmarshalRawMessage(n, b)
+			if err != nil || len(msg) == 0 {
+				return n, nil, err
+			}
+			if !json.Valid(msg) {
+				return 0, nil, fmt.Errorf("%w: invalid raw JSON message", bstd.ErrInvalidData)
+			}
+			return n, msg, nil
+		},
+		encode: func(b []byte, v any) ([]byte, error) {
+			var msg json.RawMessage
+			switch v := v.(type) {
+			case json.RawMessage:
+				msg = v
+			case nil:
+			default:
+				var err error
+				if msg, err = json.Marshal(v); err != nil {
+					return nil, err
+				}
+			}
+			return appendWith(b, bstd.SizeRawMessage(msg), func(n int, b []byte) int { return bstd.MarshalRawMessage(n, b, msg) }), nil
+		},
+		random: func(r *rand.Rand) any { return bstd.GenerateRawMessage(r, 0) },
+	},
+	"bstd.Decimal": {
+		bounds: Bounds{bstd.SizeDecimal(bstd.Decimal{}), bstd.SizeDecimal(bstd.Decimal{Mantissa: math.MinInt64, Scale: math.MinInt32})},
+		decode: func(n int, b []byte) (int, any, error) {
+			n, d, err := bstd.UnmarshalDecimal(n, b)
+			if err != nil {
+				return 0, nil, err
+			}
+			return n, formatDecimal(big.NewInt(d.Mantissa), d.Scale), nil
+		},
+		encode: func(b []byte, v any) ([]byte, error) {
+			d, err := parseText(v, "a decimal", func(s string) (bstd.Decimal, bool) {
+				m, scale, ok := parseDecimal(s)
+				return bstd.Decimal{Mantissa: m.Int64(), Scale: scale}, ok && m.IsInt64()
+			})
+			if err != nil {
+				return nil, err
+			}
+			return appendWith(b, bstd.SizeDecimal(d), func(n int, b []byte) int { return bstd.MarshalDecimal(n, b, d) }), nil
+		},
+		random: func(r *rand.Rand) any {
+			d := bstd.GenerateDecimal(r, 0)
+			return formatDecimal(big.NewInt(d.Mantissa), d.Scale)
+		},
+	},
+	"decimal.Decimal": {
+		bounds: Bounds{bstd.SizeBigDecimal(bigDecimal{coefficient: new(big.Int)}), Unbounded},
+		decode: func(n int, b []byte) (int, any, error) {
+			n, d, err := bstd.UnmarshalBigDecimal(n, b, newBigDecimal)
+			if err != nil {
+				return 0, nil, err
+			}
+			return n, formatDecimal(d.coefficient, -d.exponent), nil
+		},
+		encode: func(b []byte, v any) ([]byte, error) {
+			d, err := parseText(v, "a decimal", func(s string) (bigDecimal, bool) {
+				coef, scale, ok := parseDecimal(s)
+				return bigDecimal{coef, -scale}, ok && scale != math.MinInt32
+			})
+			if d.coefficient == nil {
+				// the zero decimal, whose coefficient is 0, not nil
+				d.coefficient = new(big.Int)
+			}
+			if err != nil {
+				return nil, err
+			}
+			return appendWith(b, bstd.SizeBigDecimal(d), func(n int, b []byte) int { return bstd.MarshalBigDecimal(n, b, d) }), nil
+		},
+		random: func(r *rand.Rand) any {
+			d := bstd.GenerateBigDecimal(newBigDecimal)(r, 0)
+			return formatDecimal(d.coefficient, -d.exponent)
+		},
+	},
+	// bitsets are arrays of their integers in ascending order
+	"bstd.Bitset": {
+		bounds: Bounds{bstd.SizeBitset(nil), Unbounded},
+		decode: func(n int, b []byte) (int, any, error) {
+			n, set, err := bstd.UnmarshalBitset(n, b)
+			if err != nil {
+				return 0, nil, err
+			}
+			return n, bitsetValue(set), nil
+		},
+		encode: func(b []byte, v any) ([]byte, error) {
+			vs, ok := v.([]any)
+			if !ok && v != nil {
+				return nil, fmt.Errorf("expected an array, got %T", v)
+			}
+			var set bstd.Bitset
+			for i, ev := range vs {
+				x, err := toUint64(ev)
+				if err != nil {
+					return nil, fmt.Errorf("index [%d]: %w", i, err)
+				}
+				if x > math.MaxInt32 {
+					return nil, fmt.Errorf("index [%d]: %d is too large for a bitset", i, x)
+				}
+				set.Set(int(x))
+			}
+			return appendWith(b, bstd.SizeBitset(set), func(n int, b []byte) int { return bstd.MarshalBitset(n, b, set) }), nil
+		},
+		random: func(r *rand.Rand) any { return bitsetValue(bstd.GenerateBitset(r, 0)) },
+	},
+}
+
+// textType returns the nativeType of T, whose text form is the one of its MarshalText and
+// UnmarshalText methods, e.g. netip.Addr. The zero value of T has to marshal as empty text.
+func textType[T encoding.TextMarshaler, PT interface {
+	*T
+	encoding.TextUnmarshaler
+}](size func(T) int, marshal func(int, []byte, T) int, unmarshal func(int, []byte) (int, T, error), generate func(*rand.Rand, int) T) nativeType {
+	var zero T
+	return nativeType{
+		bounds: Bounds{size(zero), Unbounded},
+		decode: func(n int, b []byte) (int, any, error) {
+			n, x, err := unmarshal(n, b)
+			if err != nil {
+				return 0, nil, err
+			}
+			text, err := x.MarshalText()
+			if err != nil {
+				return 0, nil, err
+			}
+			return n, string(text), nil
+		},
+		encode: func(b []byte, v any) ([]byte, error) {
+			s, ok := v.(string)
+			if !ok && v != nil {
+				return nil, fmt.Errorf("expected a string, got %T", v)
+			}
+			var x T
+			if err := PT(&x).UnmarshalText([]byte(s)); err != nil {
+				return nil, err
+			}
+			return appendWith(b, size(x), func(n int, b []byte) int { return marshal(n, b, x) }), nil
+		},
+		random: func(r *rand.Rand) any {
+			text, _ := generate(r, 0).MarshalText()
+			return string(text)
+		},
+	}
+}
+
+// nullTypes are the value types of the Null types of database/sql by their type, which are
+// marshalled like a pointer to the value, see bstd.MarshalNullString. nil stands for an invalid Null.
+var nullTypes = map[string]ast.Expr{
+	"sql.NullString":  ast.NewIdent("string"),
+	"sql.NullInt64":   ast.NewIdent("int64"),
+	"sql.NullInt32":   ast.NewIdent("int32"),
+	"sql.NullInt16":   ast.NewIdent("int16"),
+	"sql.NullByte":    ast.NewIdent("byte"),
+	"sql.NullFloat64": ast.NewIdent("float64"),
+	"sql.NullBool":    ast.NewIdent("bool"),
+	"sql.NullTime":    &ast.SelectorExpr{X: ast.NewIdent("time"), Sel: ast.NewIdent("Time")},
+}
+
+// nativeType returns the nativeType of expr, if bstd handles it natively.
+func (c *Codec) nativeType(expr ast.Expr) (nativeType, bool) {
+	switch t := expr.(type) {
+	case *ast.SelectorExpr:
+	case *ast.StarExpr:
+		if _, ok := t.X.(*ast.SelectorExpr); !ok {
+			return nativeType{}, false
+		}
+	default:
+		return nativeType{}, false
+	}
+	nt, ok := nativeTypes[c.ExprToString(expr)]
+	return nt, ok
+}
+
+// textValue returns the text form of the unmarshalled x, nil for a nil pointer.
+func textValue[T interface {
+	comparable
+	fmt.Stringer
+}](n int, x T, err error) (int, any, error) {
+	var zero T
+	if err != nil || x == zero {
+		return n, nil, err
+	}
+	return n, x.String(), nil
+}
+
+// parseText parses the text form v of a value with parse, which reports whether s is valid.
+// nil is the zero value of T. Numbers are accepted in place of their text form.
+func parseText[T any](v any, what string, parse func(s string) (T, bool)) (T, error) {
+	var x T
+	var s string
+	switch v := v.(type) {
+	case nil:
+		return x, nil
+	case string:
+		s = v
+	case fmt.Stringer:
+		// json.Number
+		s = v.String()
+	case int64, uint64, float64:
+		s = fmt.Sprint(v)
+	default:
+		return x, fmt.Errorf("expected %s, got %T", what, v)
+	}
+	x, ok := parse(s)
+	if !ok {
+		return x, fmt.Errorf("%q is not %s", s, what)
+	}
+	return x, nil
+}
+
+// parseBigFloat parses s with a precision of at least 64 bits, enough for all of its digits.
+func parseBigFloat(s string) (*big.Float, bool) {
+	x, _, err := big.ParseFloat(s, 10, max(64, 4*uint(len(s))), big.ToNearestEven)
+	return x, err == nil
+}
+
+// formatUUID returns the UUID in its canonical form, e.g. "f81d4fae-7dec-11d0-a765-00a0c91e6bf6".
+func formatUUID(id [16]byte) string {
+	h := hex.EncodeToString(id[:])
+	return h[:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:]
+}
+
+// parseUUID parses a UUID in its canonical form, or its 32 hex digits without dashes.
+func parseUUID(s string) ([16]byte, bool) {
+	var id [16]byte
+	if len(s) == 36 && s[8] == '-' && s[13] == '-' && s[18] == '-' && s[23] == '-' {
+		s = s[:8] + s[9:13] + s[14:18] + s[19:23] + s[24:]
+	}
+	if len(s) != 32 {
+		return id, false
+	}
+	_, err := hex.Decode(id[:], []byte(s))
+	return id, err == nil
+}
+
+// formatIP returns the IP address in its text form. An IPv4 address in its 16 byte form is
+// written as IPv4-mapped IPv6 address, e.g. "::ffff:192.0.2.1", so it keeps its form.
+func formatIP(ip net.IP) (string, error) {
+	addr, ok := netip.AddrFromSlice(ip)
+	if !ok {
+		return "", fmt.Errorf("%w: IP address of %d bytes", bstd.ErrInvalidData, len(ip))
+	}
+	return addr.String(), nil
+}
+
+// parseIP parses the text form of an IP address, see formatIP.
+func parseIP(s string) (net.IP, bool) {
+	if s == "" {
+		return nil, true
+	}
+	addr, err := netip.ParseAddr(s)
+	if err != nil || addr.Zone() != "" {
+		return nil, false
+	}
+	return addr.AsSlice(), true
+}
+
+// parseHardwareAddr parses a hardware address of any length in the form of
+// net.HardwareAddr.String, e.g. "00:00:5e:00:53:01".
+func parseHardwareAddr(s string) (net.HardwareAddr, bool) {
+	if s == "" {
+		return nil, true
+	}
+	addr := make(net.HardwareAddr, 0, (len(s)+1)/3)
+	for part := range strings.SplitSeq(s, ":") {
+		if len(part) != 2 {
+			return nil, false
+		}
+		x, err := strconv.ParseUint(part, 16, 8)
+		if err != nil {
+			return nil, false
+		}
+		addr = append(addr, byte(x))
+	}
+	return addr, true
+}
+
+// parseURL parses a URL, the empty string is the nil URL, like bstd.UnmarshalURL does.
+func parseURL(s string) (*url.URL, bool) {
+	if s == "" {
+		return nil, true
+	}
+	u, err := url.Parse(s)
+	return u, err == nil
+}
+
+// bigDecimal is a bstd.BigDecimal, which stands for the decimal types of other packages.
+type bigDecimal struct {
+	coefficient *big.Int
+	exponent    int32
+}
+
+func newBigDecimal(coefficient *big.Int, exponent int32) bigDecimal {
+	return bigDecimal{coefficient, exponent}
+}
+
+func (d bigDecimal) Coefficient() *big.Int { return d.coefficient }
+func (d bigDecimal) Exponent() int32       { return d.exponent }
+
+// formatDecimal returns the decimal m / 10^scale in plain notation with scale fractional digits,
+// e.g. "123.45", or for a negative scale as m followed by the exponent, e.g. "12e3". The text
+// keeps the scale, "1.0" and "1.00" are different decimals.
+func formatDecimal(m *big.Int, scale int32) string {
+	if scale < 0 {
+		return m.String() + "e" + strconv.Itoa(-int(scale))
+	}
+	digits := new(big.Int).Abs(m).String()
+	if pad := int(scale) + 1 - len(digits); pad > 0 {
+		digits = strings.Repeat("0", pad) + digits
+	}
+	sign := ""
+	if m.Sign() < 0 {
+		sign = "-"
+	}
+	if scale == 0 {
+		return sign + digits
+	}
+	i := len(digits) - int(scale)
+	return sign + digits[:i] + "." + digits[i:]
+}
+
+// parseDecimal parses the text form of a decimal, see formatDecimal, into its unscaled value
+// and scale. An exponent is accepted with any fraction, e.g. "1.5e3" is 15 with the scale -2.
+func parseDecimal(s string) (*big.Int, int32, bool) {
+	mantissa, exp, hasExp := strings.Cut(strings.ToLower(s), "e")
+	scale := 0
+	if whole, frac, ok := strings.Cut(mantissa, "."); ok {
+		if frac == "" || strings.ContainsAny(frac, "+-") {
+			return nil, 0, false
+		}
+		mantissa, scale = whole+frac, len(frac)
+	}
+	if hasExp {
+		e, err := strconv.Atoi(exp)
+		if err != nil {
+			return nil, 0, false
+		}
+		scale -= e
+	}
+	m, ok := new(big.Int).SetString(mantissa, 10)
+	if !ok || scale < math.MinInt32 || scale > math.MaxInt32 {
+		return nil, 0, false
+	}
+	return m, int32(scale), true
+}
+
+// bitsetValue returns the integers of the set.
+func bitsetValue(set bstd.Bitset) []any {
+	indices := set.Indices()
+	vs := make([]any, len(indices))
+	for i, x := range indices {
+		vs[i] = uint64(x)
+	}
+	return vs
+}
@@ -0,0 +1,85 @@
+package dynamic
+
+import (
+	"fmt"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/banditmoscow1337/benc/cmd/generator/common"
+)
+
+// Reloader holds the Codec of a schema file and replaces it, once the file changes, so a
+// long-running tool decodes with the schema as it is edited, without a restart.
+type Reloader struct {
+	path string
+	load func(path string) (*common.Context, error)
+	// types are the types the tool uses, which a reloaded schema has to keep
+	types []string
+
+	codec   atomic.Pointer[Codec]
+	modTime time.Time
+	size    int64
+}
+
+// NewReloader returns a Reloader of the schema of 'codec', which 'load' parses again on a
+// change. A reloaded schema is rejected, unless it still declares every type of 'types'.
+func NewReloader(codec *Codec, load func(path string) (*common.Context, error), types ...string) (*Reloader, error) {
+	info, err := os.Stat(codec.InputFile)
+	if err != nil {
+		return nil, err
+	}
+	r := &Reloader{path: codec.InputFile, load: load, types: types, modTime: info.ModTime(), size: info.Size()}
+	r.codec.Store(codec)
+	return r, nil
+}
+
+// Codec returns a copy of the current codec. The copy isn't shared, so the goroutine calling it
+// may use it, e.g. set its Trace, while another one reloads the schema.
+func (r *Reloader) Codec() *Codec {
+	c := *r.codec.Load()
+	return &c
+}
+
+// Reload loads the schema again, if its file changed since it was loaded last, and reports
+// whether it did. After an error the current codec stays in use, and the file is loaded again
+// on its next change.
+func (r *Reloader) Reload() (bool, error) {
+	info, err := os.Stat(r.path)
+	if err != nil {
+		return false, err
+	}
+	if info.ModTime().Equal(r.modTime) && info.Size() == r.size {
+		return false, nil
+	}
+	r.modTime, r.size = info.ModTime(), info.Size()
+
+	ctx, err := r.load(r.path)
+	if err != nil {
+		return false, err
+	}
+	for _, name := range r.types {
+		if _, ok := ctx.TypeSpecs[name]; !ok {
+			return false, fmt.Errorf("type %s not found in %s", name, r.path)
+		}
+	}
+	r.codec.Store(New(ctx))
+	return true, nil
+}
+
+// Watch calls Reload every 'interval' until 'stop' is closed, passing every load of the schema
+// and every error to 'reloaded'.
+func (r *Reloader) Watch(interval time.Duration, stop <-chan struct{}, reloaded func(err error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if ok, err := r.Reload(); ok || err != nil {
+				reloaded(err)
+			}
+		}
+	}
+}
@@ -0,0 +1,442 @@
+package dynamic
+
+import (
+	"fmt"
+	"go/ast"
+	"math"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/banditmoscow1337/benc/cmd/generator/common"
+	bstd "github.com/banditmoscow1337/benc/std/golang"
+)
+
+// Unbounded is the maximum size of values without a length limit.
+const Unbounded = -1
+
+// Bounds is the range of the encoded size of a type.
+type Bounds struct {
+	Min, Max int
+}
+
+// SizeBounds returns the smallest and largest possible encoded size of the named type.
+// The maximum is Unbounded, unless every string, slice and map of the type has a
+// //benc:maxlen comment and the type isn't recursive.
+func (c *Codec) SizeBounds(typeName string) (Bounds, error) {
+	ts, ok := c.TypeSpecs[typeName]
+	if !ok {
+		return Bounds{}, fmt.Errorf("unknown type %q", typeName)
+	}
+	return c.bounds(ts.Type, nil, map[string]bool{typeName: true})
+}
+
+func (c *Codec) bounds(expr ast.Expr, maxLen []int, visiting map[string]bool) (Bounds, error) {
+	if common.IsVariantType(expr) {
+		return Bounds{bstd.SizeByte(), Unbounded}, nil
+	}
+	if nt, ok := c.nativeType(expr); ok {
+		return nt.bounds, nil
+	}
+	switch t := expr.(type) {
+	case *ast.Ident:
+		if ts, ok := c.TypeSpecs[t.Name]; ok {
+			if visiting[t.Name] {
+				return Bounds{0, Unbounded}, nil
+			}
+			visiting[t.Name] = true
+			defer delete(visiting, t.Name)
+			return c.bounds(ts.Type, maxLen, visiting)
+		}
+		if e, ok := c.Enums[t.Name]; ok {
+			return enumBounds(e), nil
+		}
+		if strings.HasPrefix(t.Name, deltaPrefix) {
+			return lenBounds(maxLen, Bounds{1, 1}, bstd.SizeInt64Varint(math.MinInt64)), nil
+		}
+		if ct, ok := compactTimes[t.Name]; ok {
+			return Bounds{ct.size, ct.size}, nil
+		}
+		switch t.Name {
+		case "bool", "byte", "uint8", "int8":
+			return Bounds{1, 1}, nil
+		case "int16", "uint16":
+			return Bounds{2, 2}, nil
+		case "int32", "uint32", "rune", "float32":
+			return Bounds{4, 4}, nil
+		case "int64", "uint64", "float64":
+			return Bounds{8, 8}, nil
+		case "int", "uint", "uintptr":
+			return Bounds{1, bstd.SizeUint(^uint(0))}, nil
+		case varintPrefix + "int16", varintPrefix + "uint16":
+			return Bounds{1, bstd.SizeUint16Varint(math.MaxUint16)}, nil
+		case varintPrefix + "int32", varintPrefix + "uint32":
+			return Bounds{1, bstd.SizeUint32Varint(math.MaxUint32)}, nil
+		case varintPrefix + "int64", varintPrefix + "uint64":
+			return Bounds{1, bstd.SizeUint64Varint(math.MaxUint64)}, nil
+		case "string":
+			return lenBounds(maxLen, Bounds{1, 1}, 1), nil
+		case gorillaType:
+			// the count and the length of the bit stream, a value takes at most 77 bits
+			return lenBounds(maxLen, Bounds{2, 2}, 10), nil
+		case nulString:
+			// the length and the NUL terminator, the length counts the NUL, which may take
+			// another varint byte
+			return lenBounds(maxLen, Bounds{2, 3}, 1), nil
+		case dictString:
+			// a one byte reference at the smallest, the 0 and the string at the largest
+			return lenBounds(maxLen, Bounds{1, 2}, 1), nil
+		case zonedTime:
+			// the zone name is unbounded
+			return Bounds{bstd.SizeInt64() + bstd.SizeInt32() + bstd.SizeString(""), Unbounded}, nil
+		}
+	case *ast.ParenExpr:
+		return c.bounds(t.X, maxLen, visiting)
+	case *ast.StructType:
+		layout, words, wordSize, err := c.flagLayout(t)
+		if err != nil {
+			return Bounds{}, err
+		}
+		b := Bounds{wordSize * words, wordSize * words}
+		for _, field := range t.Fields.List {
+			if c.ShouldIgnoreField(field) || c.IsUnsupportedType(field.Type) {
+				continue
+			}
+			if _, ok := layout[field.Names[0].Name]; ok {
+				continue
+			}
+			fieldMaxLen, err := c.FieldMaxLen(field)
+			if err != nil {
+				return Bounds{}, err
+			}
+			fb, err := c.bounds(c.fieldType(field), fieldMaxLen, visiting)
+			if err != nil {
+				return Bounds{}, fmt.Errorf("%s: %w", field.Names[0].Name, err)
+			}
+			for range field.Names {
+				b = b.add(fb, 1)
+			}
+		}
+		return b, nil
+	case *ast.StarExpr:
+		elt, err := c.bounds(t.X, maxLen, visiting)
+		if err != nil {
+			return Bounds{}, err
+		}
+		return Bounds{1, 1}.add(Bounds{0, elt.Max}, 1), nil
+	case *ast.IndexExpr:
+		if elt, ok := common.OptionElt(t); ok {
+			return c.bounds(&ast.StarExpr{X: elt}, maxLen, visiting)
+		}
+		if elt, ok := sparseElt(t); ok {
+			eb, err := c.bounds(elt, next(maxLen), visiting)
+			if err != nil {
+				return Bounds{}, err
+			}
+			return sparseBounds(maxLen, eb), nil
+		}
+	case *ast.SelectorExpr:
+		if elt, ok := nullTypes[c.ExprToString(t)]; ok {
+			return c.bounds(&ast.StarExpr{X: elt}, maxLen, visiting)
+		}
+		switch c.ExprToString(t) {
+		case "time.Time":
+			return Bounds{bstd.SizeTime(), bstd.SizeTime()}, nil
+		case "time.Duration":
+			return Bounds{bstd.SizeDuration(), bstd.SizeDuration()}, nil
+		}
+	case *ast.ArrayType:
+		if t.Len != nil {
+			l, err := c.arrayLen(t)
+			if err != nil {
+				return Bounds{}, err
+			}
+			elt, err := c.bounds(t.Elt, maxLen, visiting)
+			if err != nil {
+				return Bounds{}, err
+			}
+			return Bounds{}.add(elt, l), nil
+		}
+		if isByte(t.Elt) {
+			return lenBounds(maxLen, Bounds{1, 1}, 1), nil
+		}
+		elt, err := c.bounds(t.Elt, next(maxLen), visiting)
+		if err != nil {
+			return Bounds{}, err
+		}
+		return lenBounds(maxLen, Bounds{5, 5}, elt.Max), nil
+	case *ast.Ellipsis:
+		elt, err := c.bounds(t.Elt, next(maxLen), visiting)
+		if err != nil {
+			return Bounds{}, err
+		}
+		// at worst every element is a run of its own, with a one byte run length
+		return lenBounds(maxLen, Bounds{1, 1}, elt.add(Bounds{1, 1}, 1).Max), nil
+	case *ast.MapType:
+		key, err := c.bounds(t.Key, next(maxLen), visiting)
+		if err != nil {
+			return Bounds{}, err
+		}
+		value, err := c.bounds(t.Value, next(maxLen), visiting)
+		if err != nil {
+			return Bounds{}, err
+		}
+		return lenBounds(maxLen, Bounds{5, 5}, key.add(value, 1).Max), nil
+	}
+	return Bounds{}, fmt.Errorf("%w: %s", ErrUnsupportedType, c.ExprToString(expr))
+}
+
+// add returns the bounds of b followed by count values within o.
+func (b Bounds) add(o Bounds, count int) Bounds {
+	b.Min += o.Min * count
+	if b.Max == Unbounded || o.Max == Unbounded {
+		b.Max = Unbounded
+	} else {
+		b.Max += o.Max * count
+	}
+	return b
+}
+
+// lenBounds returns the bounds of a length prefixed value, which is empty at its
+// smallest and holds maxLen[0] elements of eltMax bytes each at its largest.
+func lenBounds(maxLen []int, empty Bounds, eltMax int) Bounds {
+	if len(maxLen) == 0 || eltMax == Unbounded {
+		return Bounds{empty.Min, Unbounded}
+	}
+	l := maxLen[0]
+	return Bounds{empty.Min, empty.Max - 1 + bstd.SizeUint(uint(l)) + l*eltMax}
+}
+
+func next(maxLen []int) []int {
+	if len(maxLen) == 0 {
+		return nil
+	}
+	return maxLen[1:]
+}
+
+// Random returns a random value of the named type in the form expected by Encode.
+// Lengths are limited by //benc:maxlen comments and recursion stops at the given depth.
+func (c *Codec) Random(r *rand.Rand, typeName string, depth int) (any, error) {
+	ts, ok := c.TypeSpecs[typeName]
+	if !ok {
+		return nil, fmt.Errorf("unknown type %q", typeName)
+	}
+	return c.random(r, ts.Type, nil, depth)
+}
+
+func (c *Codec) random(r *rand.Rand, expr ast.Expr, maxLen []int, depth int) (any, error) {
+	length := func(def int) int {
+		if len(maxLen) > 0 {
+			return r.Intn(maxLen[0] + 1)
+		}
+		return def
+	}
+
+	if common.IsVariantType(expr) {
+		return randomVariant(r, depth), nil
+	}
+	if nt, ok := c.nativeType(expr); ok {
+		return nt.random(r), nil
+	}
+	switch t := expr.(type) {
+	case *ast.Ident:
+		if ts, ok := c.TypeSpecs[t.Name]; ok {
+			return c.random(r, ts.Type, maxLen, depth)
+		}
+		if e, ok := c.Enums[t.Name]; ok {
+			return randomEnum(r, e), nil
+		}
+		if ct, ok := compactTimes[t.Name]; ok {
+			return ct.random(r), nil
+		}
+		if name, ok := strings.CutPrefix(t.Name, deltaPrefix); ok {
+			// increasing values, as the encoding is meant for sorted IDs or timestamps
+			l := 0
+			if depth > 0 {
+				l = length(1 + r.Intn(20))
+			}
+			vs := make([]any, l)
+			x := int64(r.Intn(1000))
+			for i := range vs {
+				x = truncateInt(name, x+int64(r.Intn(100)))
+				vs[i] = intValue(name, x)
+			}
+			return vs, nil
+		}
+		switch name := strings.TrimPrefix(t.Name, varintPrefix); name {
+		case "bool":
+			return r.Intn(2) == 1, nil
+		case "byte", "uint8", "uint16", "uint32", "uint64", "uint", "uintptr":
+			u := r.Uint64()
+			switch name {
+			case "byte", "uint8":
+				u = uint64(uint8(u))
+			case "uint16":
+				u = uint64(uint16(u))
+			case "uint32":
+				u = uint64(uint32(u))
+			}
+			return u, nil
+		case "int8", "int16", "int32", "rune", "int64", "int":
+			i := int64(r.Uint64())
+			switch name {
+			case "int8":
+				i = int64(int8(i))
+			case "int16":
+				i = int64(int16(i))
+			case "int32", "rune":
+				i = int64(int32(i))
+			}
+			return i, nil
+		case "float32", "float64":
+			return r.NormFloat64(), nil
+		case "string", nulString:
+			return bstd.RandomString(r, length(5+r.Intn(15))), nil
+		case dictString:
+			// short strings, which repeat often
+			return bstd.RandomString(r, length(1+r.Intn(2))), nil
+		case zonedTime:
+			return randomZoned(r), nil
+		case gorillaType:
+			// a slowly changing series, as the encoding is meant for metrics
+			l := 0
+			if depth > 0 {
+				l = length(1 + r.Intn(20))
+			}
+			vs := make([]any, l)
+			f := float64(r.Intn(100))
+			for i := range vs {
+				if r.Intn(3) == 0 {
+					f += float64(r.Intn(9)-4) / 4
+				}
+				vs[i] = f
+			}
+			return vs, nil
+		}
+	case *ast.ParenExpr:
+		return c.random(r, t.X, maxLen, depth)
+	case *ast.StructType:
+		layout, _, _, err := c.flagLayout(t)
+		if err != nil {
+			return nil, err
+		}
+		obj := &Object{}
+		for _, field := range t.Fields.List {
+			if c.ShouldIgnoreField(field) || c.IsUnsupportedType(field.Type) {
+				continue
+			}
+			fieldMaxLen, err := c.FieldMaxLen(field)
+			if err != nil {
+				return nil, err
+			}
+			for _, name := range field.Names {
+				v, err := c.random(r, c.fieldType(field), fieldMaxLen, depth)
+				if err != nil {
+					return nil, fmt.Errorf("%s: %w", name.Name, err)
+				}
+				if fb, ok := layout[name.Name]; ok {
+					if u, ok := v.(uint64); ok {
+						v = u & (1<<fb.Bits - 1)
+					}
+				}
+				obj.Fields = append(obj.Fields, Field{Key: name.Name, Value: v})
+			}
+		}
+		return obj, nil
+	case *ast.StarExpr:
+		if depth <= 0 || r.Intn(2) == 0 {
+			return nil, nil
+		}
+		return c.random(r, t.X, maxLen, depth-1)
+	case *ast.IndexExpr:
+		if elt, ok := common.OptionElt(t); ok {
+			return c.random(r, &ast.StarExpr{X: elt}, maxLen, depth)
+		}
+		if elt, ok := sparseElt(t); ok {
+			l := 0
+			if depth > 0 {
+				l = length(1 + r.Intn(20))
+			}
+			return c.randomSparse(r, elt, l, maxLen, depth)
+		}
+	case *ast.SelectorExpr:
+		if elt, ok := nullTypes[c.ExprToString(t)]; ok {
+			return c.random(r, &ast.StarExpr{X: elt}, maxLen, depth)
+		}
+		switch c.ExprToString(t) {
+		case "time.Time":
+			return time.Unix(r.Int63n(1<<32), r.Int63n(1e9)).UTC().Format(time.RFC3339Nano), nil
+		case "time.Duration":
+			return time.Duration(r.Int63()).String(), nil
+		}
+	case *ast.ArrayType:
+		l := 0
+		if t.Len != nil {
+			var err error
+			if l, err = c.arrayLen(t); err != nil {
+				return nil, err
+			}
+		} else if isByte(t.Elt) {
+			return bstd.RandomBytes(r, length(3+r.Intn(7))), nil
+		} else if depth > 0 {
+			l = length(1 + r.Intn(3))
+		}
+		if isByte(t.Elt) {
+			return bstd.RandomBytes(r, l), nil
+		}
+		vs := make([]any, l)
+		for i := range vs {
+			var err error
+			if vs[i], err = c.random(r, t.Elt, next(maxLen), depth-1); err != nil {
+				return nil, err
+			}
+		}
+		return vs, nil
+	case *ast.Ellipsis:
+		// runs of up to 8 equal elements, as the encoding is meant for repetitive slices
+		l := 0
+		if depth > 0 {
+			l = length(1 + r.Intn(20))
+		}
+		vs := make([]any, 0, l)
+		for len(vs) < l {
+			v, err := c.random(r, t.Elt, next(maxLen), depth-1)
+			if err != nil {
+				return nil, err
+			}
+			for range min(1+r.Intn(8), l-len(vs)) {
+				vs = append(vs, v)
+			}
+		}
+		if isByte(t.Elt) {
+			bs := make([]byte, len(vs))
+			for i, v := range vs {
+				bs[i] = byte(v.(uint64))
+			}
+			return bs, nil
+		}
+		return vs, nil
+	case *ast.MapType:
+		obj := &Object{}
+		if depth <= 0 {
+			return obj, nil
+		}
+		seen := make(map[string]bool)
+		for range length(1 + r.Intn(3)) {
+			k, err := c.random(r, t.Key, next(maxLen), depth-1)
+			if err != nil {
+				return nil, err
+			}
+			v, err := c.random(r, t.Value, next(maxLen), depth-1)
+			if err != nil {
+				return nil, err
+			}
+			if key := keyString(k); !seen[key] {
+				seen[key] = true
+				obj.Fields = append(obj.Fields, Field{Key: key, Value: v})
+			}
+		}
+		return obj, nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnsupportedType, c.ExprToString(expr))
+}
@@ -0,0 +1,164 @@
+package dynamic
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"math/rand"
+
+	bstd "github.com/banditmoscow1337/benc/std/golang"
+)
+
+// The sparse encoded slices, see bstd.MarshalSliceSparse, are arrays like plain slices, whose
+// elements equal to the zero value of their type are left out on the wire.
+
+// sparseSlice marks the sparse encoded slices: fieldType returns them as an *ast.IndexExpr of
+// sparseSlice and their element type.
+const sparseSlice = "sparse slice"
+
+// sparseElt returns the element type, if expr is a sparse encoded slice.
+func sparseElt(expr *ast.IndexExpr) (ast.Expr, bool) {
+	id, ok := expr.X.(*ast.Ident)
+	return expr.Index, ok && id.Name == sparseSlice
+}
+
+// sparseZero returns the encoding and the value of the zero element of elt.
+func (c *Codec) sparseZero(elt ast.Expr) ([]byte, any, error) {
+	zero, err := c.encode(nil, elt, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	// not traced, the zero value isn't part of the message
+	_, v, err := c.decodeValue(elt, 0, zero)
+	return zero, v, err
+}
+
+func (c *Codec) decodeSparse(elt ast.Expr, n int, b []byte) (int, any, error) {
+	_, zero, err := c.sparseZero(elt)
+	if err != nil {
+		return 0, nil, err
+	}
+	n, length, err := bstd.UnmarshalUint(n, b)
+	if err != nil {
+		return 0, nil, err
+	}
+	n, count, err := bstd.UnmarshalUint(n, b)
+	if err != nil {
+		return 0, nil, err
+	}
+	if count > length {
+		return 0, nil, bstd.ErrSparseIndex
+	}
+
+	vs := make([]any, length)
+	for i := range vs {
+		vs[i] = zero
+	}
+	for next := uint(0); count > 0; count-- {
+		var gap uint
+		if n, gap, err = bstd.UnmarshalUint(n, b); err != nil {
+			return 0, nil, err
+		}
+		if gap >= length-next {
+			return 0, nil, bstd.ErrSparseIndex
+		}
+		next += gap
+		if n, vs[next], err = c.decode(elt, n, b); err != nil {
+			return 0, nil, err
+		}
+		next++
+	}
+	if isByte(elt) {
+		bs := make([]byte, len(vs))
+		for i, v := range vs {
+			bs[i] = byte(v.(uint64))
+		}
+		return n, bs, nil
+	}
+	return n, vs, nil
+}
+
+func (c *Codec) encodeSparse(b []byte, elt ast.Expr, v any) ([]byte, error) {
+	vs, ok := v.([]any)
+	if isByte(elt) {
+		bs, err := toBytes(v)
+		if err != nil {
+			return nil, err
+		}
+		vs, ok = make([]any, len(bs)), true
+		for i, e := range bs {
+			vs[i] = uint64(e)
+		}
+	}
+	if !ok && v != nil {
+		return nil, fmt.Errorf("expected an array, got %T", v)
+	}
+	zero, _, err := c.sparseZero(elt)
+	if err != nil {
+		return nil, err
+	}
+
+	// zero elements are detected on their encoding, besides -0, which Go compares equal to 0
+	type entry struct {
+		index int
+		e     []byte
+	}
+	var entries []entry
+	for i, ev := range vs {
+		e, err := c.encode(nil, elt, ev)
+		if err != nil {
+			return nil, fmt.Errorf("index [%d]: %w", i, err)
+		}
+		if f, err := toFloat64(ev); bytes.Equal(e, zero) || (err == nil && f == 0) {
+			continue
+		}
+		entries = append(entries, entry{i, e})
+	}
+
+	b = appendWith(b, bstd.SizeUint(uint(len(vs))), func(n int, b []byte) int { return bstd.MarshalUint(n, b, uint(len(vs))) })
+	b = appendWith(b, bstd.SizeUint(uint(len(entries))), func(n int, b []byte) int { return bstd.MarshalUint(n, b, uint(len(entries))) })
+	next := 0
+	for _, en := range entries {
+		gap := uint(en.index - next)
+		b = appendWith(b, bstd.SizeUint(gap), func(n int, b []byte) int { return bstd.MarshalUint(n, b, gap) })
+		b = append(b, en.e...)
+		next = en.index + 1
+	}
+	return b, nil
+}
+
+// sparseBounds returns the bounds of a sparse slice of elements within 'elt'.
+func sparseBounds(maxLen []int, elt Bounds) Bounds {
+	if len(maxLen) == 0 || elt.Max == Unbounded {
+		return Bounds{2, Unbounded}
+	}
+	// at worst every element is non-zero, each with a gap of up to the length
+	l := maxLen[0]
+	return Bounds{2, 2*bstd.SizeUint(uint(l)) + l*(bstd.SizeUint(uint(l))+elt.Max)}
+}
+
+// randomSparse returns a random slice of 'l' elements of elt, three of four of them zero, as
+// the encoding is meant for mostly zero slices.
+func (c *Codec) randomSparse(r *rand.Rand, elt ast.Expr, l int, maxLen []int, depth int) (any, error) {
+	_, zero, err := c.sparseZero(elt)
+	if err != nil {
+		return nil, err
+	}
+	vs := make([]any, l)
+	for i := range vs {
+		vs[i] = zero
+		if r.Intn(4) == 0 {
+			if vs[i], err = c.random(r, elt, next(maxLen), depth-1); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if isByte(elt) {
+		bs := make([]byte, len(vs))
+		for i, v := range vs {
+			bs[i] = byte(v.(uint64))
+		}
+		return bs, nil
+	}
+	return vs, nil
+}
@@ -0,0 +1,55 @@
+package dynamic
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+
+	bstd "github.com/banditmoscow1337/benc/std/golang"
+)
+
+// The times of compact time fields, see bstd.MarshalTimeSeconds, are RFC 3339 strings in UTC
+// like the other times, truncated to the unit of the field.
+
+// compactTime is the encoding of the times of a compact time field.
+type compactTime struct {
+	unit      time.Duration
+	size      int
+	marshal   func(n int, b []byte, t time.Time) int
+	unmarshal func(n int, b []byte) (int, time.Time, error)
+}
+
+// compactTimes are the compactTime encodings by their type, see fieldType.
+var compactTimes = map[string]compactTime{
+	compactPrefix + "s":  {time.Second, bstd.SizeTimeSeconds(), bstd.MarshalTimeSeconds, bstd.UnmarshalTimeSeconds},
+	compactPrefix + "ms": {time.Millisecond, bstd.SizeTimeMillis(), bstd.MarshalTimeMillis, bstd.UnmarshalTimeMillis},
+	compactPrefix + "us": {time.Microsecond, bstd.SizeTimeMicros(), bstd.MarshalTimeMicros, bstd.UnmarshalTimeMicros},
+}
+
+func (ct compactTime) decode(n int, b []byte) (int, any, error) {
+	n, t, err := ct.unmarshal(n, b)
+	if err != nil {
+		return 0, nil, err
+	}
+	return n, t.UTC().Format(time.RFC3339Nano), nil
+}
+
+func (ct compactTime) encode(b []byte, v any) ([]byte, error) {
+	s, ok := v.(string)
+	if !ok && v != nil {
+		return nil, fmt.Errorf("expected an RFC 3339 string, got %T", v)
+	}
+	t := time.Unix(0, 0)
+	if s != "" {
+		var err error
+		if t, err = time.Parse(time.RFC3339Nano, s); err != nil {
+			return nil, err
+		}
+	}
+	return appendWith(b, ct.size, func(n int, b []byte) int { return ct.marshal(n, b, t) }), nil
+}
+
+// random returns a time between 1970 and 2106, which every unit encodes.
+func (ct compactTime) random(r *rand.Rand) string {
+	return time.Unix(r.Int63n(1<<32), r.Int63n(1e9)).Truncate(ct.unit).UTC().Format(time.RFC3339Nano)
+}
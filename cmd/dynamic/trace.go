@@ -0,0 +1,62 @@
+package dynamic
+
+import (
+	"go/ast"
+)
+
+// TraceOp is a decode operation: a value of Type, decoded from the Length bytes at Offset.
+// Length is -1 if decoding the value failed.
+type TraceOp struct {
+	Offset int    `json:"offset"`
+	Type   string `json:"type"`
+	Length int    `json:"length"`
+}
+
+// Trace records the decode operations of a Codec in the order they start, outer values before
+// the values they contain. Decoding the same message with the same schema always records the
+// same operations, so a trace recorded elsewhere can be replayed and compared, see Diff.
+type Trace struct {
+	Ops []TraceOp `json:"ops"`
+}
+
+// Reset clears the operations, keeping the memory for the next message.
+func (t *Trace) Reset() {
+	t.Ops = t.Ops[:0]
+}
+
+// Diff returns the index of the operation where 'other' diverges from t, -1 if both recorded
+// the same operations. That is the first operation decoding another type or at another
+// offset, or if there is none, the last one with another length. The values containing it
+// differ in length as well, but come before it.
+func (t *Trace) Diff(other *Trace) int {
+	last := -1
+	for i := range max(len(t.Ops), len(other.Ops)) {
+		if i >= len(t.Ops) || i >= len(other.Ops) {
+			return i
+		}
+		a, b := t.Ops[i], other.Ops[i]
+		if a.Offset != b.Offset || a.Type != b.Type {
+			return i
+		}
+		if a.Length != b.Length {
+			last = i
+		}
+	}
+	return last
+}
+
+// traceDecode decodes expr like decode, recording the operation in c.Trace.
+func (c *Codec) traceDecode(expr ast.Expr, n int, b []byte) (int, any, error) {
+	switch expr.(type) {
+	case *ast.StructType, *ast.ParenExpr:
+		// recorded as the schema type, or the field type they stand for
+		return c.decodeValue(expr, n, b)
+	}
+	i := len(c.Trace.Ops)
+	c.Trace.Ops = append(c.Trace.Ops, TraceOp{Offset: n, Type: c.ExprToString(expr), Length: -1})
+	end, v, err := c.decodeValue(expr, n, b)
+	if err == nil {
+		c.Trace.Ops[i].Length = end - n
+	}
+	return end, v, err
+}
@@ -0,0 +1,154 @@
+package dynamic
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"maps"
+	"math/rand"
+	"slices"
+	"strconv"
+
+	bstd "github.com/banditmoscow1337/benc/std/golang"
+)
+
+// DecodeVariant decodes a variant, see bstd.UnmarshalVariant, into the values Decode
+// returns. Its maps become Objects with the keys in order.
+func DecodeVariant(n int, b []byte) (int, any, error) {
+	n, v, err := bstd.UnmarshalVariant(n, b)
+	if err != nil {
+		return 0, nil, err
+	}
+	return n, fromVariant(v), nil
+}
+
+func fromVariant(v any) any {
+	switch t := v.(type) {
+	case map[string]any:
+		obj := &Object{}
+		for _, k := range slices.Sorted(maps.Keys(t)) {
+			obj.Fields = append(obj.Fields, Field{Key: k, Value: fromVariant(t[k])})
+		}
+		return obj
+	case []any:
+		for i, e := range t {
+			t[i] = fromVariant(e)
+		}
+	}
+	return v
+}
+
+// Tag converts the message of the named type at offset n of b into a variant, the tagged
+// mode of benc, which bstd.SkipVariant and DecodeVariant walk without the schema. Structs
+// and maps become variant maps, keyed by the field names and the keys in string form.
+func (c *Codec) Tag(typeName string, n int, b []byte) (int, []byte, error) {
+	n, v, err := c.Decode(typeName, n, b)
+	if err != nil {
+		return 0, nil, err
+	}
+	tagged, err := encodeVariant(nil, v)
+	if err != nil {
+		return 0, nil, err
+	}
+	return n, tagged, nil
+}
+
+// encodeVariant encodes v as variant. JSON numbers become int64, if they are integers,
+// uint64 if only that holds them, float64 otherwise.
+func encodeVariant(b []byte, v any) ([]byte, error) {
+	vv, err := toVariant(v)
+	if err != nil {
+		return nil, err
+	}
+	if err = bstd.CheckVariant(vv); err != nil {
+		return nil, err
+	}
+	return appendWith(b, bstd.SizeVariant(vv), func(n int, b []byte) int { return bstd.MarshalVariant(n, b, vv) }), nil
+}
+
+func toVariant(v any) (any, error) {
+	switch t := v.(type) {
+	case json.Number:
+		if i, err := t.Int64(); err == nil {
+			return i, nil
+		}
+		if u, err := strconv.ParseUint(t.String(), 10, 64); err == nil {
+			return u, nil
+		}
+		return t.Float64()
+	case uint64:
+		if t > 1<<63-1 {
+			return t, nil
+		}
+		return int64(t), nil
+	case *Object:
+		m := make(map[string]any, len(t.Fields))
+		for _, f := range t.Fields {
+			e, err := toVariant(f.Value)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", f.Key, err)
+			}
+			m[f.Key] = e
+		}
+		return m, nil
+	case []any:
+		l := make([]any, len(t))
+		for i, e := range t {
+			var err error
+			if l[i], err = toVariant(e); err != nil {
+				return nil, fmt.Errorf("index [%d]: %w", i, err)
+			}
+		}
+		return l, nil
+	}
+	return v, nil
+}
+
+// randomVariant returns a random variant in decoded form.
+func randomVariant(r *rand.Rand, depth int) any {
+	return fromVariant(bstd.GenerateVariant(r, depth))
+}
+
+// variantLiteral converts expr, the value of a variant: nil, a constant, []byte("..."),
+// a map[string]any{...} or a []any{...}.
+func (c *Codec) variantLiteral(expr ast.Expr) (any, error) {
+	switch e := expr.(type) {
+	case *ast.Ident:
+		if e.Name == "nil" {
+			return nil, nil
+		}
+	case *ast.CompositeLit:
+		switch typ := c.ExprToString(e.Type); typ {
+		case "map[string]any", "map[string]interface{}", "[]any", "[]interface{}":
+			return c.Literal(e.Type, expr)
+		default:
+			return nil, fmt.Errorf("%s is no variant type", typ)
+		}
+	case *ast.CallExpr:
+		if c.ExprToString(e.Fun) == "[]byte" {
+			return c.Literal(e.Fun, expr)
+		}
+	}
+
+	tv, err := types.Eval(token.NewFileSet(), nil, token.NoPos, c.ExprToString(expr))
+	if err != nil {
+		return nil, err
+	}
+	if tv.Value == nil {
+		return nil, fmt.Errorf("%s is not a constant", c.ExprToString(expr))
+	}
+	switch tv.Value.Kind() {
+	case constant.Bool:
+		return c.constant("bool", expr)
+	case constant.String:
+		return c.constant("string", expr)
+	case constant.Int:
+		return c.constant("int64", expr)
+	case constant.Float:
+		return c.constant("float64", expr)
+	}
+	return nil, fmt.Errorf("%s is no variant value", strconv.Quote(c.ExprToString(expr)))
+}
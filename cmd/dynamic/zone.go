@@ -0,0 +1,90 @@
+package dynamic
+
+import (
+	"fmt"
+	"go/ast"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/banditmoscow1337/benc/cmd/generator/common"
+	bstd "github.com/banditmoscow1337/benc/std/golang"
+)
+
+// The times of zone fields, see bstd.MarshalTimeWithZone, are RFC 3339 strings in their zone,
+// followed by the name of the zone in brackets unless it is UTC or unnamed, e.g.
+// "2024-03-01T09:00:00+01:00[Europe/Berlin]".
+
+// timeType renames the time.Time types of expr to name, e.g. zonedTime.
+func timeType(c *common.Context, expr ast.Expr, name string) ast.Expr {
+	switch t := expr.(type) {
+	case *ast.SelectorExpr:
+		if c.ExprToString(t) == "time.Time" {
+			return &ast.Ident{Name: name}
+		}
+	case *ast.StarExpr:
+		return &ast.StarExpr{X: timeType(c, t.X, name)}
+	case *ast.IndexExpr:
+		if elt, ok := common.OptionElt(t); ok {
+			return &ast.StarExpr{X: timeType(c, elt, name)}
+		}
+	case *ast.ArrayType:
+		return &ast.ArrayType{Len: t.Len, Elt: timeType(c, t.Elt, name)}
+	case *ast.MapType:
+		return &ast.MapType{Key: timeType(c, t.Key, name), Value: timeType(c, t.Value, name)}
+	}
+	return expr
+}
+
+func formatZoned(t time.Time) string {
+	s := t.Format(time.RFC3339Nano)
+	if name := t.Location().String(); name != "" && name != "UTC" {
+		s += "[" + name + "]"
+	}
+	return s
+}
+
+// parseZoned parses the string form of a zoned time. The time gets a fixed zone of the name
+// and the offset, which marshals like the location of the name.
+func parseZoned(s string) (time.Time, error) {
+	name := ""
+	if i := strings.LastIndexByte(s, '['); i >= 0 && strings.HasSuffix(s, "]") {
+		s, name = s[:i], s[i+1:len(s)-1]
+	}
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return time.Time{}, err
+	}
+	_, offset := t.Zone()
+	if name == "" && offset == 0 {
+		return t.UTC(), nil
+	}
+	return t.In(time.FixedZone(name, offset)), nil
+}
+
+func decodeZoned(n int, b []byte) (int, any, error) {
+	n, t, err := bstd.UnmarshalTimeWithZone(n, b)
+	if err != nil {
+		return 0, nil, err
+	}
+	return n, formatZoned(t), nil
+}
+
+func encodeZoned(b []byte, v any) ([]byte, error) {
+	s, ok := v.(string)
+	if !ok && v != nil {
+		return nil, fmt.Errorf("expected an RFC 3339 string, got %T", v)
+	}
+	t := time.Unix(0, 0).UTC()
+	if s != "" {
+		var err error
+		if t, err = parseZoned(s); err != nil {
+			return nil, err
+		}
+	}
+	return appendWith(b, bstd.SizeTimeWithZone(t), func(n int, b []byte) int { return bstd.MarshalTimeWithZone(n, b, t) }), nil
+}
+
+func randomZoned(r *rand.Rand) string {
+	return formatZoned(bstd.GenerateTimeWithZone(r, 0))
+}
@@ -19,6 +19,12 @@ func New(ctx *common.Context) *generator {
 }
 
 func (g *generator) Generate() error {
+	if err := g.CheckGoOnlyEncodings("c"); err != nil {
+		return err
+	}
+	if err := g.checkNulFields(); err != nil {
+		return err
+	}
 	// 1. Generate Header (.h)
 	g.generateHeader()
 	g.WriteFile(&g.buf, "_benc", ".h")
@@ -30,14 +36,43 @@ func (g *generator) Generate() error {
 	return nil
 }
 
+// checkNulFields returns an error if a nul field is no string, as only the strings of a
+// struct are unmarshalled to char* into the buffer.
+func (g *generator) checkNulFields() error {
+	for _, ts := range g.Types {
+		st, ok := ts.Type.(*ast.StructType)
+		if !ok {
+			continue
+		}
+		for _, field := range st.Fields.List {
+			if len(field.Names) > 0 && g.IsNulField(field) && !g.isNulString(field) {
+				return fmt.Errorf("%s.%s: nul fields other than strings are not supported by the c generator yet", ts.Name.Name, field.Names[0].Name)
+			}
+		}
+	}
+	return nil
+}
+
+// isNulString reports whether the field is a string with a `benc:"nul"` tag, which is
+// unmarshalled to a const char* pointing into the buffer instead of a copy.
+func (g *generator) isNulString(field *ast.Field) bool {
+	ident, ok := field.Type.(*ast.Ident)
+	return ok && ident.Name == "string" && g.IsNulField(field)
+}
+
 // --- Header Generation ---
 
 func (g *generator) generateHeader() {
 	hGuard := fmt.Sprintf("%s_BENC_H", strings.ToUpper(g.BaseName))
-	g.printf("#ifndef %s\n#define %s\n\n", hGuard, hGuard)
+	g.printf("%s#ifndef %s\n#define %s\n\n", g.FingerprintComment("// "), hGuard, hGuard)
 	g.printf("#include \"benc.h\"\n\n")
 	g.printf("#ifdef __cplusplus\nextern \"C\" {\n#endif\n\n")
 
+	// Enum Definitions
+	for _, e := range g.SortedEnums() {
+		g.generateCEnumDef(e)
+	}
+
 	// Struct Definitions
 	for _, ts := range g.Types {
 		g.generateCStructDef(ts)
@@ -56,6 +91,23 @@ func (g *generator) generateHeader() {
 	g.printf("#ifdef __cplusplus\n}\n#endif\n#endif // %s\n", hGuard)
 }
 
+// generateCEnumDef declares the //benc:enum type e as C enum numbering its constants by their
+// ordinal, prefixed with the name of the type, e.g. Color_Red.
+func (g *generator) generateCEnumDef(e *common.Enum) {
+	g.printf("typedef enum {\n")
+	for _, v := range e.Values {
+		g.printf("\t%s_%s,\n", e.Name, v)
+	}
+	g.printf("} %s;\n\n", e.Name)
+
+	g.printf("// --- %s ---\n", e.Name)
+	g.printf("const char* %s_name(%s v);\n", e.Name, e.Name)
+	g.printf("bool %s_parse(const char* name, %s* out);\n", e.Name, e.Name)
+	g.printf("size_t %s_size(%s* v);\n", e.Name, e.Name)
+	g.printf("bstd_status %s_marshal(uint8_t* buf, size_t len, size_t* off, %s* v);\n", e.Name, e.Name)
+	g.printf("bstd_status %s_unmarshal(const uint8_t* buf, size_t len, size_t* off, %s* v);\n\n", e.Name, e.Name)
+}
+
 func (g *generator) generateCStructDef(ts *ast.TypeSpec) {
 	st, ok := ts.Type.(*ast.StructType)
 	if !ok {
@@ -69,11 +121,14 @@ func (g *generator) generateCStructDef(ts *ast.TypeSpec) {
 		}
 		
 		cType, nameSuffix := g.toCType(field.Type)
+		if g.isNulString(field) {
+			cType = "const char*"
+		}
 		for _, name := range field.Names {
-			g.printf("\t%s %s%s;\n", cType, name.Name, nameSuffix)
+			g.printf("\t%s %s%s;\n", cType, g.FieldName(name.Name), nameSuffix)
 			// Add count fields for slices/maps
 			if _, isArray := field.Type.(*ast.ArrayType); isArray {
-				g.printf("\tsize_t %s_count;\n", name.Name)
+				g.printf("\tsize_t %s_count;\n", g.FieldName(name.Name))
 			} else if _, isMap := field.Type.(*ast.MapType); isMap {
 				// We need keys and values for maps
 				// The toCType for map returns the key* type. We need value* and count.
@@ -101,8 +156,8 @@ func (g *generator) generateCStructDef(ts *ast.TypeSpec) {
 				// The loop above printed: `KeyType* name;` (if we mapped Map to Key*)
 				// We assume `toCType` for Map returns "KeyType*" and suffix "_keys".
 				// Then we add:
-				g.printf("\t%s %s_values;\n", valType, name.Name)
-				g.printf("\tsize_t %s_count;\n", name.Name)
+				g.printf("\t%s %s_values;\n", valType, g.FieldName(name.Name))
+				g.printf("\tsize_t %s_count;\n", g.FieldName(name.Name))
 			}
 		}
 	}
@@ -112,8 +167,13 @@ func (g *generator) generateCStructDef(ts *ast.TypeSpec) {
 // --- Source Generation ---
 
 func (g *generator) generateSource() {
-	g.printf("#include \"%s_benc.h\"\n", g.BaseName)
-	g.printf("#include <stdlib.h>\n\n") // for NULL
+	g.printf("%s#include \"%s_benc.h\"\n", g.FingerprintComment("// "), g.BaseName)
+	g.printf("#include <stdlib.h>\n") // for NULL
+	g.printf("#include <string.h>\n\n") // for strcmp
+
+	for _, e := range g.SortedEnums() {
+		g.generateCEnumImpl(e)
+	}
 
 	for _, ts := range g.Types {
 		if _, ok := ts.Type.(*ast.StructType); ok {
@@ -122,6 +182,53 @@ func (g *generator) generateSource() {
 	}
 }
 
+// generateCEnumImpl marshals a constant of e as its ordinal, which is its value in C.
+func (g *generator) generateCEnumImpl(e *common.Enum) {
+	name := e.Name
+	g.printf("static const char* const %s_names[] = {", name)
+	for i, v := range e.Values {
+		if i > 0 {
+			g.printf(", ")
+		}
+		g.printf("%q", v)
+	}
+	g.printf("};\n\n")
+
+	g.printf("const char* %s_name(%s v) {\n", name, name)
+	g.printf("\treturn (size_t)v < %d ? %s_names[v] : NULL;\n}\n\n", len(e.Values), name)
+
+	g.printf("bool %s_parse(const char* name, %s* out) {\n", name, name)
+	g.printf("\tfor (size_t i = 0; i < %d; ++i) {\n", len(e.Values))
+	g.printf("\t\tif (strcmp(%s_names[i], name) == 0) { *out = (%s)i; return true; }\n", name, name)
+	g.printf("\t}\n\treturn false;\n}\n\n")
+
+	g.printf("size_t %s_size(%s* v) {\n", name, name)
+	g.printf("\treturn bstd_size_uint((uintptr_t)*v);\n}\n\n")
+
+	g.printf("bstd_status %s_marshal(uint8_t* buf, size_t len, size_t* off, %s* v) {\n", name, name)
+	g.printf("\tif ((size_t)*v >= %d) return BSTD_ERR_INVALID_ARGUMENT;\n", len(e.Values))
+	g.printf("\treturn bstd_marshal_uint(buf, len, off, (uintptr_t)*v);\n}\n\n")
+
+	g.printf("bstd_status %s_unmarshal(const uint8_t* buf, size_t len, size_t* off, %s* v) {\n", name, name)
+	g.printf("\tsize_t start = *off;\n")
+	g.printf("\tuintptr_t i = 0;\n")
+	g.printf("\tbstd_status status = bstd_unmarshal_uint(buf, len, off, &i);\n")
+	g.printf("\tif (status != BSTD_OK) return status;\n")
+	g.printf("\tif (i >= %d) { *off = start; return BSTD_ERR_INVALID_DATA; }\n", len(e.Values))
+	g.printf("\t*v = (%s)i;\n", name)
+	g.printf("\treturn BSTD_OK;\n}\n\n")
+}
+
+// isCNamed reports whether the type typeName has its own size, marshal and unmarshal functions,
+// which take a pointer to the value: a struct or a //benc:enum type.
+func (g *generator) isCNamed(typeName string) bool {
+	if _, ok := g.TypeSpecs[typeName]; ok {
+		return true
+	}
+	_, ok := g.Enums[typeName]
+	return ok
+}
+
 func (g *generator) generateCStructImpl(ts *ast.TypeSpec) {
 	name := ts.Name.Name
 	fields := g.GetSupportedFields(ts)
@@ -131,7 +238,12 @@ func (g *generator) generateCStructImpl(ts *ast.TypeSpec) {
 	g.printf("\tsize_t s = 0;\n")
 	for _, f := range fields {
 		for _, n := range f.Names {
-			g.printf("\ts += %s;\n", g.cSizeExpr(f.Type, "v->"+n.Name))
+			access := "v->" + g.FieldName(n.Name)
+			if g.isNulString(f) {
+				g.printf("\ts += bstd_size_string_nul(%s);\n", access)
+				continue
+			}
+			g.printf("\ts += %s;\n", g.cSizeExpr(f.Type, access))
 		}
 	}
 	g.printf("\treturn s;\n}\n\n")
@@ -141,7 +253,12 @@ func (g *generator) generateCStructImpl(ts *ast.TypeSpec) {
 	g.printf("\tbstd_status status = BSTD_OK;\n")
 	for _, f := range fields {
 		for _, n := range f.Names {
-			g.printf("\tif ((status = %s) != BSTD_OK) return status;\n", g.cMarshalExpr(f.Type, "v->"+n.Name))
+			access := "v->" + g.FieldName(n.Name)
+			if g.isNulString(f) {
+				g.printf("\tif ((status = bstd_marshal_string_nul(buf, len, off, %s)) != BSTD_OK) return status;\n", access)
+				continue
+			}
+			g.printf("\tif ((status = %s) != BSTD_OK) return status;\n", g.cMarshalExpr(f.Type, access))
 		}
 	}
 	g.printf("\treturn BSTD_OK;\n}\n\n")
@@ -151,7 +268,12 @@ func (g *generator) generateCStructImpl(ts *ast.TypeSpec) {
 	g.printf("\tbstd_status status = BSTD_OK;\n")
 	for _, f := range fields {
 		for _, n := range f.Names {
-			g.printf("\tif ((status = %s) != BSTD_OK) return status;\n", g.cUnmarshalExpr(f.Type, "v->"+n.Name))
+			access := "v->" + g.FieldName(n.Name)
+			if g.isNulString(f) {
+				g.printf("\tif ((status = bstd_unmarshal_string_nul(buf, len, off, &%s)) != BSTD_OK) return status;\n", access)
+				continue
+			}
+			g.printf("\tif ((status = %s) != BSTD_OK) return status;\n", g.cUnmarshalExpr(f.Type, access))
 		}
 	}
 	g.printf("\treturn BSTD_OK;\n}\n\n")
@@ -160,7 +282,11 @@ func (g *generator) generateCStructImpl(ts *ast.TypeSpec) {
 	g.printf("void %s_free(%s* v) {\n", name, name)
 	for _, f := range fields {
 		for _, n := range f.Names {
-			g.printf("\t%s;\n", g.cFreeExpr(f.Type, "v->"+n.Name))
+			if g.isNulString(f) {
+				// points into the buffer it was unmarshalled from
+				continue
+			}
+			g.printf("\t%s;\n", g.cFreeExpr(f.Type, "v->"+g.FieldName(n.Name)))
 		}
 	}
 	g.printf("}\n\n")
@@ -170,8 +296,8 @@ func (g *generator) generateCStructImpl(ts *ast.TypeSpec) {
 
 func (g *generator) cSizeExpr(t ast.Expr, access string) string {
 	typeName := g.ExprToString(t)
-	// Check for nested structs
-	if _, ok := g.TypeSpecs[typeName]; ok {
+	// Check for nested structs and enums
+	if g.isCNamed(typeName) {
 		return fmt.Sprintf("%s_size(&%s)", typeName, access)
 	}
 
@@ -198,7 +324,7 @@ func (g *generator) cSizeExpr(t ast.Expr, access string) string {
 
 func (g *generator) cMarshalExpr(t ast.Expr, access string) string {
 	typeName := g.ExprToString(t)
-	if _, ok := g.TypeSpecs[typeName]; ok {
+	if g.isCNamed(typeName) {
 		return fmt.Sprintf("%s_marshal(buf, len, off, &%s)", typeName, access)
 	}
 
@@ -226,7 +352,7 @@ func (g *generator) cMarshalExpr(t ast.Expr, access string) string {
 
 func (g *generator) cUnmarshalExpr(t ast.Expr, access string) string {
 	typeName := g.ExprToString(t)
-	if _, ok := g.TypeSpecs[typeName]; ok {
+	if g.isCNamed(typeName) {
 		return fmt.Sprintf("%s_unmarshal(buf, len, off, &%s)", typeName, access)
 	}
 
@@ -323,7 +449,7 @@ func (g *generator) toCTypeRaw(t ast.Expr) string {
 
 func (g *generator) cSizeFunc(t ast.Expr) string {
 	if ident, ok := t.(*ast.Ident); ok {
-		if _, isStruct := g.TypeSpecs[ident.Name]; isStruct {
+		if g.isCNamed(ident.Name) {
 			return ident.Name + "_size"
 		}
 		if ident.Name == "string" { return "bstd_size_string" } // special sig
@@ -334,7 +460,7 @@ func (g *generator) cSizeFunc(t ast.Expr) string {
 
 func (g *generator) cMarshalFunc(t ast.Expr) string {
 	if ident, ok := t.(*ast.Ident); ok {
-		if _, isStruct := g.TypeSpecs[ident.Name]; isStruct {
+		if g.isCNamed(ident.Name) {
 			return ident.Name + "_marshal"
 		}
 		if ident.Name == "string" { return "bstd_marshal_string" }
@@ -345,7 +471,7 @@ func (g *generator) cMarshalFunc(t ast.Expr) string {
 
 func (g *generator) cUnmarshalFunc(t ast.Expr) string {
 	if ident, ok := t.(*ast.Ident); ok {
-		if _, isStruct := g.TypeSpecs[ident.Name]; isStruct {
+		if g.isCNamed(ident.Name) {
 			return ident.Name + "_unmarshal"
 		}
 		if ident.Name == "string" { return "bstd_unmarshal_string_alloc" }
@@ -392,6 +518,7 @@ func (g *generator) Tests() error {
 	// (gen.h only provides a few examples, so we generate wrappers for all primitives 
 	// to ensure slice/map generators have function pointers to use).
 	g.generatePrimitiveWrappers()
+	g.generateEnumWrappers()
 
 	// 3. Generate Forward Declarations for Structs
 	// This handles circular references (e.g. linked lists)
@@ -423,7 +550,7 @@ func (g *generator) Tests() error {
 // --- Test Generation Helpers ---
 
 func (g *generator) generateTestHeader() {
-	g.printf("#include <stdio.h>\n")
+	g.printf("%s#include <stdio.h>\n", g.FingerprintComment("// "))
 	g.printf("#include <stdlib.h>\n")
 	g.printf("#include <time.h>\n")
 	g.printf("#include <assert.h>\n")
@@ -463,6 +590,15 @@ func (g *generator) generatePrimitiveWrappers() {
 	g.printf("\n")
 }
 
+// generateEnumWrappers generates and compares the constants of the //benc:enum types.
+func (g *generator) generateEnumWrappers() {
+	for _, e := range g.SortedEnums() {
+		g.printf("static void generate_%s(void* out) { *(%s*)out = (%s)(rand() %% %d); }\n", e.Name, e.Name, e.Name, len(e.Values))
+		g.printf("static bool compare_%s(const void* a, const void* b) { return *(%s*)a == *(%s*)b; }\n", e.Name, e.Name, e.Name)
+	}
+	g.printf("\n")
+}
+
 func (g *generator) generateStructTestImpl(ts *ast.TypeSpec) {
 	name := ts.Name.Name
 	//st := ts.Type.(*ast.StructType)
@@ -475,7 +611,7 @@ func (g *generator) generateStructTestImpl(ts *ast.TypeSpec) {
 
 	for _, f := range fields {
 		for _, n := range f.Names {
-			g.printf("\t%s;\n", g.cGenerateExpr(f.Type, "v->"+n.Name))
+			g.printf("\t%s;\n", g.cGenerateExpr(f.Type, "v->"+g.FieldName(n.Name)))
 		}
 	}
 	g.printf("}\n\n")
@@ -487,7 +623,7 @@ func (g *generator) generateStructTestImpl(ts *ast.TypeSpec) {
 
 	for _, f := range fields {
 		for _, n := range f.Names {
-			g.printf("\tif (!%s) return false;\n", g.cCompareExpr(f.Type, "a->"+n.Name, "b->"+n.Name))
+			g.printf("\tif (!%s) return false;\n", g.cCompareExpr(f.Type, "a->"+g.FieldName(n.Name), "b->"+g.FieldName(n.Name)))
 		}
 	}
 	g.printf("\treturn true;\n}\n\n")
@@ -497,55 +633,73 @@ func (g *generator) generateTestRunners() {
 	g.printf("// --- Test Runners ---\n")
 	for _, ts := range g.Types {
 		if _, ok := ts.Type.(*ast.StructType); ok {
-			name := ts.Name.Name
-			g.printf("void test_%s() {\n", name)
-			g.printf("\tprintf(\"Testing %s... \");\n", name)
-			
-			// 1. Generate
-			g.printf("\t%s original;\n", name)
-			// Zero init is important so free doesn't crash if generate fails or logic is partial
-			g.printf("\tmemset(&original, 0, sizeof(original));\n") 
-			g.printf("\tgenerate_%s(&original);\n", name)
-
-			// 2. Measure
-			g.printf("\tsize_t size = %s_size(&original);\n", name)
-
-			// 3. Marshal
-			g.printf("\tuint8_t* buf = (uint8_t*)malloc(size);\n")
-			g.printf("\tsize_t off = 0;\n")
-			g.printf("\tbstd_status status = %s_marshal(buf, size, &off, &original);\n", name)
-			g.printf("\tif (status != BSTD_OK) { printf(\"Marshal failed code %%d\\n\", status); exit(1); }\n")
-			g.printf("\tif (off != size) { printf(\"Size mismatch: size %%zu, off %%zu\\n\", size, off); exit(1); }\n")
-
-			// 4. Unmarshal
-			g.printf("\t%s copy;\n", name)
-			g.printf("\tmemset(&copy, 0, sizeof(copy));\n")
-			g.printf("\toff = 0;\n")
-			g.printf("\tstatus = %s_unmarshal(buf, size, &off, &copy);\n", name)
-			g.printf("\tif (status != BSTD_OK) { printf(\"Unmarshal failed code %%d\\n\", status); exit(1); }\n")
-
-			// 5. Compare
-			g.printf("\tif (!compare_%s(&original, &copy)) {\n", name)
-			g.printf("\t\tprintf(\"Comparison failed!\\n\");\n")
-			g.printf("\t\texit(1);\n")
-			g.printf("\t}\n")
-
-			// 6. Cleanup
-			g.printf("\t%s_free(&original);\n", name)
-			g.printf("\t%s_free(&copy);\n", name)
-			g.printf("\tfree(buf);\n")
-			g.printf("\tprintf(\"OK\\n\");\n")
-			g.printf("}\n\n")
+			g.generateTestRunner(ts.Name.Name, "", nil)
+			if fields := g.LargeMapFields(ts); len(fields) > 0 {
+				g.generateTestRunner(ts.Name.Name, "_large_maps", fields)
+			}
 		}
 	}
 }
 
+// generateTestRunner generates the round trip test test_<name><suffix> of the struct name,
+// whose map fields largeMaps are replaced by maps of LARGE_MAP_COUNT entries.
+func (g *generator) generateTestRunner(name, suffix string, largeMaps []*ast.Field) {
+	g.printf("void test_%s%s() {\n", name, suffix)
+	g.printf("\tprintf(\"Testing %s%s... \");\n", name, suffix)
+	
+	// 1. Generate
+	g.printf("\t%s original;\n", name)
+	// Zero init is important so free doesn't crash if generate fails or logic is partial
+	g.printf("\tmemset(&original, 0, sizeof(original));\n") 
+	g.printf("\tgenerate_%s(&original);\n", name)
+	for _, field := range largeMaps {
+		for _, n := range field.Names {
+			access := "original." + g.FieldName(n.Name)
+			g.printf("\t%s;\n", g.cFreeExpr(field.Type, access))
+			g.printf("\t%s;\n", g.cMapGenerateExpr(field.Type.(*ast.MapType), access, "generate_large_map_alloc"))
+		}
+	}
+
+	// 2. Measure
+	g.printf("\tsize_t size = %s_size(&original);\n", name)
+
+	// 3. Marshal
+	g.printf("\tuint8_t* buf = (uint8_t*)malloc(size);\n")
+	g.printf("\tsize_t off = 0;\n")
+	g.printf("\tbstd_status status = %s_marshal(buf, size, &off, &original);\n", name)
+	g.printf("\tif (status != BSTD_OK) { printf(\"Marshal failed code %%d\\n\", status); exit(1); }\n")
+	g.printf("\tif (off != size) { printf(\"Size mismatch: size %%zu, off %%zu\\n\", size, off); exit(1); }\n")
+
+	// 4. Unmarshal
+	g.printf("\t%s copy;\n", name)
+	g.printf("\tmemset(&copy, 0, sizeof(copy));\n")
+	g.printf("\toff = 0;\n")
+	g.printf("\tstatus = %s_unmarshal(buf, size, &off, &copy);\n", name)
+	g.printf("\tif (status != BSTD_OK) { printf(\"Unmarshal failed code %%d\\n\", status); exit(1); }\n")
+
+	// 5. Compare
+	g.printf("\tif (!compare_%s(&original, &copy)) {\n", name)
+	g.printf("\t\tprintf(\"Comparison failed!\\n\");\n")
+	g.printf("\t\texit(1);\n")
+	g.printf("\t}\n")
+
+	// 6. Cleanup
+	g.printf("\t%s_free(&original);\n", name)
+	g.printf("\t%s_free(&copy);\n", name)
+	g.printf("\tfree(buf);\n")
+	g.printf("\tprintf(\"OK\\n\");\n")
+	g.printf("}\n\n")
+}
+
 func (g *generator) generateTestMain() {
 	g.printf("int main() {\n")
 	g.printf("\tsrand(time(NULL));\n")
 	for _, ts := range g.Types {
 		if _, ok := ts.Type.(*ast.StructType); ok {
 			g.printf("\ttest_%s();\n", ts.Name.Name)
+			if len(g.LargeMapFields(ts)) > 0 {
+				g.printf("\ttest_%s_large_maps();\n", ts.Name.Name)
+			}
 		}
 	}
 	g.printf("\tprintf(\"All tests passed!\\n\");\n")
@@ -558,8 +712,8 @@ func (g *generator) generateTestMain() {
 func (g *generator) cGenerateExpr(t ast.Expr, access string) string {
 	typeName := g.ExprToString(t)
 
-	// Nested Struct (Direct)
-	if _, ok := g.TypeSpecs[typeName]; ok {
+	// Nested Struct or Enum (Direct)
+	if g.isCNamed(typeName) {
 		return fmt.Sprintf("generate_%s(&%s)", typeName, access)
 	}
 
@@ -589,18 +743,24 @@ func (g *generator) cGenerateExpr(t ast.Expr, access string) string {
 			access, g.toCTypeRaw(t), access, g.toCTypeRaw(t.Elt), elemGen)
 
 	case *ast.MapType:
-		keyGen := g.cGenericName(t.Key, "generate", "")
-		valGen := g.cGenericName(t.Value, "generate", "")
-		return fmt.Sprintf("generate_map_alloc((void**)&%s_keys, (void**)&%s_values, &%s_count, sizeof(%s), sizeof(%s), %s, %s)",
-			access, access, access, g.toCTypeRaw(t.Key), g.toCTypeRaw(t.Value), keyGen, valGen)
+		return g.cMapGenerateExpr(t, access, "generate_map_alloc")
 	}
 	return ""
 }
 
+// cMapGenerateExpr returns the call of the gen.h function fn, generate_map_alloc or
+// generate_large_map_alloc, generating the map into access.
+func (g *generator) cMapGenerateExpr(t *ast.MapType, access, fn string) string {
+	keyGen := g.cGenericName(t.Key, "generate", "")
+	valGen := g.cGenericName(t.Value, "generate", "")
+	return fmt.Sprintf("%s((void**)&%s_keys, (void**)&%s_values, &%s_count, sizeof(%s), sizeof(%s), %s, %s)",
+		fn, access, access, access, g.toCTypeRaw(t.Key), g.toCTypeRaw(t.Value), keyGen, valGen)
+}
+
 func (g *generator) cCompareExpr(t ast.Expr, accessA, accessB string) string {
 	typeName := g.ExprToString(t)
 
-	if _, ok := g.TypeSpecs[typeName]; ok {
+	if g.isCNamed(typeName) {
 		return fmt.Sprintf("compare_%s(&%s, &%s)", typeName, accessA, accessB)
 	}
 
@@ -637,8 +797,8 @@ func (g *generator) cCompareExpr(t ast.Expr, accessA, accessB string) string {
 // Helper to get the name of the generic wrapper function: e.g. "generate_int32_generic" or "generate_MyStruct"
 func (g *generator) cGenericName(t ast.Expr, prefix, suffix string) string {
 	typeName := g.ExprToString(t)
-	if _, ok := g.TypeSpecs[typeName]; ok {
-		// Structs match the signature naturally
+	if g.isCNamed(typeName) {
+		// Structs and enums match the signature naturally
 		return fmt.Sprintf("%s_%s", prefix, typeName)
 	}
 	
@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"go/ast"
 	"log"
-	"os"
 	"strings"
 	"text/scanner"
 
@@ -14,11 +13,18 @@ import (
 // Parse reads a C header file and extracts structs as Go AST TypeSpecs.
 // It applies heuristics to detect slices (pointer + _count) and maps (_keys + _values + _count).
 func Parse(ctx *common.Context) {
+	if err := ParseFile(ctx); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// ParseFile is Parse returning the error of a file, which can't be read, instead of exiting.
+func ParseFile(ctx *common.Context) error {
 	log.Printf("Parsing C17 input: %s", ctx.InputFile)
 
-	file, err := os.Open(ctx.InputFile)
+	file, err := ctx.OpenInput()
 	if err != nil {
-		log.Fatalf("failed to open file %s: %v", ctx.InputFile, err)
+		return fmt.Errorf("failed to open file %s: %v", ctx.InputFile, err)
 	}
 	defer file.Close()
 
@@ -42,6 +48,7 @@ func Parse(ctx *common.Context) {
 			}
 		}
 	}
+	return nil
 }
 
 type cField struct {
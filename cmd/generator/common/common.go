@@ -6,9 +6,13 @@ import (
 	"go/ast"
 	"go/format"
 	"go/token"
+	"io"
 	"log"
 	"os"
 	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
 	"strings"
 )
 
@@ -22,14 +26,81 @@ var FixedSizeTypes = map[string]bool{
 	"bool": true, "byte": true, "rune": true, "int8": true, "uint8": true,
 	"int16": true, "uint16": true, "int32": true, "uint32": true,
 	"int64": true, "uint64": true, "float32": true, "float64": true,
-	"time.Time": true,
+	"time.Time": true, "time.Duration": true, "uuid.UUID": true,
 }
 
 // Context holds the shared state of the generation process (AST info).
 type Context struct {
 	InputFile, PkgName, BaseName, OutputDir   string
+	// Source is the schema, if it isn't read from InputFile, e.g. the one of a
+	// bstd.SchemaHeader, see ReadInput. InputFile names its format by its extension then.
+	Source []byte
 	TypeSpecs map[string]*ast.TypeSpec
 	Types []*ast.TypeSpec
+	// Imports maps the package names used by the schema to their import paths.
+	Imports map[string]string
+	// Examples maps type names to the example values of their //benc:example comments.
+	Examples map[string][]Example
+	// Enums maps the names of the //benc:enum types to their constants, see Enum.
+	Enums map[string]*Enum
+	// DryRun prints the diff of every output file to stdout instead of writing it.
+	DryRun bool
+	// DeclareTypes is set by the parsers of schemas that aren't Go source, so the go
+	// generator declares the types next to their methods.
+	DeclareTypes bool
+	// Naming is the naming convention of the fields in the language currently generated, see FieldName.
+	Naming string
+	// Variants is set by the generators of the languages that marshal `any` and `interface{}`
+	// as variants, see IsVariantType. The others skip fields of these types as unsupported.
+	Variants bool
+	// Fingerprint identifies the generator in the header of every generated file, e.g.
+	// "benc v1.4.0", see FingerprintComment. Empty leaves the headers unstamped.
+	Fingerprint string
+	names  map[string]string
+}
+
+// FingerprintComment returns the line stamping a generated file with the Fingerprint,
+// starting with the comment prefix of the language, or "" if there is no fingerprint.
+func (c *Context) FingerprintComment(prefix string) string {
+	if c.Fingerprint == "" {
+		return ""
+	}
+	return prefix + "Generator: " + c.Fingerprint + "\n"
+}
+
+// Example is a variable of the schema holding an example value of a type.
+type Example struct {
+	Name  string
+	Value ast.Expr
+}
+
+// Enum is a type of the schema with a //benc:enum comment, an integer type, whose constants
+// are marshalled as their ordinal, a varint, while the generated code maps them to their
+// names, see bstd.EnumTable. Enum types aren't part of Types.
+type Enum struct {
+	Name string
+	// Values are the names of the constants, indexed by their ordinal.
+	Values []string
+}
+
+// EnumOf returns the enum, if expr names an enum type of the schema.
+func (c *Context) EnumOf(expr ast.Expr) (*Enum, bool) {
+	id, ok := expr.(*ast.Ident)
+	if !ok {
+		return nil, false
+	}
+	e, ok := c.Enums[id.Name]
+	return e, ok
+}
+
+// SortedEnums returns the enums of the schema sorted by name.
+func (c *Context) SortedEnums() []*Enum {
+	enums := make([]*Enum, 0, len(c.Enums))
+	for _, e := range c.Enums {
+		enums = append(enums, e)
+	}
+	sort.Slice(enums, func(i, j int) bool { return enums[i].Name < enums[j].Name })
+	return enums
 }
 
 // NewContext creates a new shared context.
@@ -37,6 +108,9 @@ func NewContext(inputFile string) (ctx *Context) {
 	ctx =  &Context{
 		InputFile: inputFile,
 		TypeSpecs: make(map[string]*ast.TypeSpec),
+		Imports: make(map[string]string),
+		Examples: make(map[string][]Example),
+		Enums: make(map[string]*Enum),
 		BaseName: strings.TrimSuffix(filepath.Base(inputFile), filepath.Ext(inputFile)),
 		OutputDir: filepath.Dir(inputFile),
 	}
@@ -44,6 +118,22 @@ func NewContext(inputFile string) (ctx *Context) {
 	return
 }
 
+// OpenInput opens the schema, the Source or else the InputFile, for the parsers.
+func (ctx *Context) OpenInput() (io.ReadCloser, error) {
+	if ctx.Source != nil {
+		return io.NopCloser(bytes.NewReader(ctx.Source)), nil
+	}
+	return os.Open(ctx.InputFile)
+}
+
+// ReadInput returns the schema, the Source or else the content of the InputFile.
+func (ctx *Context) ReadInput() ([]byte, error) {
+	if ctx.Source != nil {
+		return ctx.Source, nil
+	}
+	return os.ReadFile(ctx.InputFile)
+}
+
 func (ctx *Context) Type2TypeSpecs() bool {
 	if len(ctx.Types) == 0 {
 		log.Printf("no structs or classes found in %s", ctx.InputFile)
@@ -83,13 +173,412 @@ func (c *Context) ShouldIgnoreField(field *ast.Field) bool {
 	return checkGroup(field.Doc) || checkGroup(field.Comment)
 }
 
+// FieldDirective returns the arguments of the //benc:<name> comment of the field,
+// e.g. "16" for //benc:maxlen 16, and whether the field has such a comment.
+func (c *Context) FieldDirective(field *ast.Field, name string) (string, bool) {
+	for _, cg := range []*ast.CommentGroup{field.Doc, field.Comment} {
+		if cg == nil {
+			continue
+		}
+		for _, cm := range cg.List {
+			directive, args, _ := strings.Cut(strings.TrimPrefix(cm.Text, "//"), " ")
+			if directive == "benc:"+name {
+				return strings.TrimSpace(args), true
+			}
+		}
+	}
+	return "", false
+}
+
+// TypeDirective returns the arguments of the //benc:<name> comment of the type,
+// like FieldDirective does for fields.
+func (c *Context) TypeDirective(ts *ast.TypeSpec, name string) (string, bool) {
+	if ts.Doc == nil {
+		return "", false
+	}
+	for _, cm := range ts.Doc.List {
+		directive, args, _ := strings.Cut(strings.TrimPrefix(cm.Text, "//"), " ")
+		if directive == "benc:"+name {
+			return strings.TrimSpace(args), true
+		}
+	}
+	return "", false
+}
+
+// FlagBits is the place of a field in the flag words of a //benc:flags struct:
+// Bits bits of the uint32 Word, starting at bit Shift.
+type FlagBits struct {
+	Word, Shift, Bits int
+}
+
+// FlagWordSize returns the bytes of a flag word of the struct ts: 4, a uint32, or 1, a flag
+// byte (see bstd.MarshalFlags) for a //benc:flags byte comment.
+func (c *Context) FlagWordSize(ts *ast.TypeSpec) int {
+	if arg, _ := c.TypeDirective(ts, "flags"); arg == "byte" {
+		return 1
+	}
+	return 4
+}
+
+// FlagLayout returns the places of the packed fields of the struct ts, keyed by field name,
+// and the number of flag words. A struct with a //benc:flags comment packs its bool fields
+// and its unsigned integer fields with a //benc:bits <n> comment, e.g. an enum, in field
+// order into uint32 words, or bytes with a //benc:flags byte comment, which are written in
+// place of the first packed field. A field starts a new word if it doesn't fit into the
+// current one. Integers are cut off to their bits.
+func (c *Context) FlagLayout(ts *ast.TypeSpec) (map[string]FlagBits, int, error) {
+	st, ok := ts.Type.(*ast.StructType)
+	if !ok {
+		return nil, 0, nil
+	}
+	arg, flags := c.TypeDirective(ts, "flags")
+	if arg != "" && arg != "byte" {
+		return nil, 0, fmt.Errorf("%s: invalid //benc:flags %q, expected no argument or byte", ts.Name.Name, arg)
+	}
+	wordBits := 8 * c.FlagWordSize(ts)
+
+	layout := make(map[string]FlagBits)
+	word, shift := 0, 0
+	for _, field := range st.Fields.List {
+		if c.ShouldIgnoreField(field) || c.IsUnsupportedType(field.Type) {
+			continue
+		}
+		typ := c.ExprToString(field.Type)
+		bits := 0
+		if arg, ok := c.FieldDirective(field, "bits"); ok {
+			if !flags {
+				return nil, 0, fmt.Errorf("%s.%s: //benc:bits needs //benc:flags on %s", ts.Name.Name, field.Names[0].Name, ts.Name.Name)
+			}
+			switch typ {
+			case "byte", "uint8", "uint16", "uint32", "uint64", "uint":
+			default:
+				return nil, 0, fmt.Errorf("%s.%s: //benc:bits field %s is no unsigned integer", ts.Name.Name, field.Names[0].Name, typ)
+			}
+			n, err := strconv.Atoi(arg)
+			if err != nil || n < 1 || n > wordBits {
+				return nil, 0, fmt.Errorf("%s.%s: invalid //benc:bits %q, expected 1 to %d", ts.Name.Name, field.Names[0].Name, arg, wordBits)
+			}
+			bits = n
+		} else if flags && typ == "bool" {
+			bits = 1
+		}
+		if bits == 0 {
+			continue
+		}
+		for _, name := range field.Names {
+			if shift+bits > wordBits {
+				word, shift = word+1, 0
+			}
+			layout[name.Name] = FlagBits{Word: word, Shift: shift, Bits: bits}
+			shift += bits
+		}
+	}
+	if len(layout) == 0 {
+		return nil, 0, nil
+	}
+	return layout, word + 1, nil
+}
+
+// IsVarintField reports whether the integers of the field are encoded as varints,
+// selected by a `benc:"varint"` struct tag or a //benc:varint comment. The zigzag
+// option selects varints as well, see IsZigZagField.
+func (c *Context) IsVarintField(field *ast.Field) bool {
+	return c.hasFieldOption(field, "varint") || c.IsZigZagField(field)
+}
+
+// IsZigZagField reports whether the field has the zigzag option, the name of the varint
+// encoding for signed integers: small positive and negative values take a single byte.
+func (c *Context) IsZigZagField(field *ast.Field) bool {
+	return c.hasFieldOption(field, "zigzag")
+}
+
+// IsRLEField reports whether the slice of the field is run-length encoded, selected by a
+// `benc:"rle"` struct tag or a //benc:rle comment.
+func (c *Context) IsRLEField(field *ast.Field) bool {
+	return c.hasFieldOption(field, "rle")
+}
+
+// IsSparseField reports whether the slice of the field is sparse encoded, only its non-zero
+// elements with their positions, selected by a `benc:"sparse"` struct tag or a //benc:sparse comment.
+func (c *Context) IsSparseField(field *ast.Field) bool {
+	return c.hasFieldOption(field, "sparse")
+}
+
+// IsDeltaField reports whether the integer slice of the field is delta encoded, selected
+// by a `benc:"delta"` struct tag or a //benc:delta comment.
+func (c *Context) IsDeltaField(field *ast.Field) bool {
+	return c.hasFieldOption(field, "delta")
+}
+
+// IsGorillaField reports whether the []float64 of the field is Gorilla (XOR) encoded,
+// selected by a `benc:"gorilla"` struct tag or a //benc:gorilla comment.
+func (c *Context) IsGorillaField(field *ast.Field) bool {
+	return c.hasFieldOption(field, "gorilla")
+}
+
+// IsDictField reports whether the strings of the field are written with a string dictionary,
+// once and referenced by index afterwards, selected by a `benc:"dict"` struct tag or a
+// //benc:dict comment.
+func (c *Context) IsDictField(field *ast.Field) bool {
+	return c.hasFieldOption(field, "dict")
+}
+
+// IsZoneField reports whether the times of the field keep their zone, see
+// bstd.MarshalTimeWithZone, selected by a `benc:"zone"` struct tag or a //benc:zone comment.
+func (c *Context) IsZoneField(field *ast.Field) bool {
+	return c.hasFieldOption(field, "zone")
+}
+
+// IsBFloat16Field reports whether the float32s of the field are encoded as bfloat16, see
+// bstd.MarshalBFloat16, selected by a `benc:"bfloat16"` struct tag or a //benc:bfloat16
+// comment. The encoding is experimental, the generated code builds with the benc_experimental
+// build tag only.
+func (c *Context) IsBFloat16Field(field *ast.Field) bool {
+	return c.hasFieldOption(field, "bfloat16")
+}
+
+// IsUTF8Field reports whether the strings of the field are checked to be valid UTF-8 when
+// unmarshalling, see bstd.UnmarshalStringValidated, selected by a `benc:"utf8"` struct tag or
+// a //benc:utf8 comment.
+func (c *Context) IsUTF8Field(field *ast.Field) bool {
+	return c.hasFieldOption(field, "utf8")
+}
+
+// IsCroppedField reports whether the strings and byte slices of the field share the bytes of
+// the buffer they are unmarshalled from instead of being copied, see bstd.UnmarshalStringCropped
+// and bstd.UnmarshalBytesCropped, selected by a `benc:"cropped"` struct tag or a //benc:cropped
+// comment.
+func (c *Context) IsCroppedField(field *ast.Field) bool {
+	return c.hasFieldOption(field, "cropped")
+}
+
+// IsNulField reports whether the strings of the field are marshalled with a NUL terminator,
+// which C reads as char* without copying, see bstd.MarshalStringNul, selected by a
+// `benc:"nul"` struct tag or a //benc:nul comment.
+func (c *Context) IsNulField(field *ast.Field) bool {
+	return c.hasFieldOption(field, "nul")
+}
+
+// IsTruncatedField reports whether the unsigned integer of the field records the slices and
+// maps MarshalTruncated truncated, selected by a `benc:"truncated"` struct tag or a
+// //benc:truncated comment.
+func (c *Context) IsTruncatedField(field *ast.Field) bool {
+	return c.hasFieldOption(field, "truncated")
+}
+
+// IsLabelField reports whether the field is a metric label of a //benc:metrics struct,
+// selected by a `benc:"label"` struct tag or a //benc:label comment.
+func (c *Context) IsLabelField(field *ast.Field) bool {
+	return c.hasFieldOption(field, "label")
+}
+
+// hasFieldOption reports whether the field has the option as //benc:<option> comment
+// or in its `benc:"..."` struct tag.
+func (c *Context) hasFieldOption(field *ast.Field, option string) bool {
+	if _, ok := c.FieldDirective(field, option); ok {
+		return true
+	}
+	if field.Tag == nil {
+		return false
+	}
+	tag, err := strconv.Unquote(field.Tag.Value)
+	if err != nil {
+		return false
+	}
+	for opt := range strings.SplitSeq(reflect.StructTag(tag).Get("benc"), ",") {
+		if strings.TrimSpace(opt) == option {
+			return true
+		}
+	}
+	return false
+}
+
+// VarintTypes are the integer types with a varint encoding next to their fixed one.
+var VarintTypes = map[string]bool{
+	"int16": true, "int32": true, "int64": true, "uint16": true, "uint32": true, "uint64": true,
+}
+
+// CheckGoOnlyEncodings returns an error if a field of the schema requests the varint, the
+// run-length, the sparse, the delta, the Gorilla, the string dictionary, the zone, the compact time or the bfloat16 encoding, contains a bstd.Option
+// or a struct packs its fields into flag words, for the generators of the languages without support for them.
+// The NUL-terminated strings are supported by Go and C only.
+func (c *Context) CheckGoOnlyEncodings(lang string) error {
+	for _, ts := range c.Types {
+		st, ok := ts.Type.(*ast.StructType)
+		if !ok {
+			continue
+		}
+		if _, ok := c.TypeDirective(ts, "flags"); ok {
+			return fmt.Errorf("%s: flags structs are not supported by the %s generator yet", ts.Name.Name, lang)
+		}
+		for _, field := range st.Fields.List {
+			if len(field.Names) == 0 {
+				continue
+			}
+			encoding := ""
+			if c.IsVarintField(field) {
+				encoding = "varint"
+			} else if c.IsRLEField(field) {
+				encoding = "rle"
+			} else if c.IsSparseField(field) {
+				encoding = "sparse"
+			} else if c.IsDeltaField(field) {
+				encoding = "delta"
+			} else if c.IsGorillaField(field) {
+				encoding = "gorilla"
+			} else if c.IsDictField(field) {
+				encoding = "dict"
+			} else if c.IsZoneField(field) {
+				encoding = "zone"
+			} else if c.FieldCompactTime(field) != "" {
+				encoding = "compact time"
+			} else if c.IsBFloat16Field(field) {
+				encoding = "bfloat16"
+			} else if c.IsUTF8Field(field) {
+				encoding = "utf8"
+			} else if c.IsNulField(field) && lang != "c" {
+				encoding = "nul"
+			} else if containsOption(field.Type) {
+				encoding = "bstd.Option"
+			}
+			if encoding != "" {
+				return fmt.Errorf("%s.%s: %s fields are not supported by the %s generator yet", ts.Name.Name, field.Names[0].Name, encoding, lang)
+			}
+		}
+	}
+	return nil
+}
+
+// OptionElt returns the value type T, if expr is a bstd.Option[T].
+func OptionElt(expr ast.Expr) (ast.Expr, bool) {
+	idx, ok := expr.(*ast.IndexExpr)
+	if !ok {
+		return nil, false
+	}
+	sel, ok := idx.X.(*ast.SelectorExpr)
+	if !ok || sel.Sel.Name != "Option" {
+		return nil, false
+	}
+	if pkg, ok := sel.X.(*ast.Ident); !ok || pkg.Name != "bstd" {
+		return nil, false
+	}
+	return idx.Index, true
+}
+
+// IsVariantType reports whether expr is `any` or `interface{}`, whose values are marshalled
+// as JSON-like variants, see bstd.MarshalVariant.
+func IsVariantType(expr ast.Expr) bool {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		return t.Name == "any"
+	case *ast.InterfaceType:
+		return t.Methods == nil || len(t.Methods.List) == 0
+	}
+	return false
+}
+
+// containsOption reports whether expr is or contains a bstd.Option.
+func containsOption(expr ast.Expr) bool {
+	switch t := expr.(type) {
+	case *ast.IndexExpr:
+		_, ok := OptionElt(t)
+		return ok
+	case *ast.StarExpr:
+		return containsOption(t.X)
+	case *ast.ArrayType:
+		return containsOption(t.Elt)
+	case *ast.MapType:
+		return containsOption(t.Key) || containsOption(t.Value)
+	}
+	return false
+}
+
+// FieldMaxLen returns the maximum lengths of the //benc:maxlen comment of the field, one per
+// nesting level, e.g. //benc:maxlen 10 32 on a []string allows 10 strings of 32 bytes each.
+// Maps use the next level for both, keys and values.
+func (c *Context) FieldMaxLen(field *ast.Field) ([]int, error) {
+	args, ok := c.FieldDirective(field, "maxlen")
+	if !ok {
+		return nil, nil
+	}
+	var maxLen []int
+	for arg := range strings.FieldsSeq(args) {
+		l, err := strconv.Atoi(arg)
+		if err != nil || l < 0 {
+			return nil, fmt.Errorf("invalid //benc:maxlen %q", args)
+		}
+		maxLen = append(maxLen, l)
+	}
+	if len(maxLen) == 0 {
+		return nil, fmt.Errorf("//benc:maxlen requires a length")
+	}
+	return maxLen, nil
+}
+
+// FieldDuplicates returns the bstd.DuplicateKeyPolicy constant of the //benc:duplicates comment
+// of the field, e.g. "bstd.DuplicatesError" for //benc:duplicates error, deciding about the
+// duplicate keys of its maps when unmarshalled, or "" if it has no such comment.
+func (c *Context) FieldDuplicates(field *ast.Field) (string, error) {
+	arg, ok := c.FieldDirective(field, "duplicates")
+	if !ok {
+		return "", nil
+	}
+	policy, ok := duplicatePolicies[arg]
+	if !ok {
+		return "", fmt.Errorf("invalid //benc:duplicates %q, expected last, first or error", arg)
+	}
+	return policy, nil
+}
+
+// duplicatePolicies maps the arguments of //benc:duplicates to their bstd.DuplicateKeyPolicy constants.
+var duplicatePolicies = map[string]string{
+	"last": "bstd.DuplicatesLastWins", "first": "bstd.DuplicatesFirstWins", "error": "bstd.DuplicatesError",
+}
+
+// timePrecisions maps the units of //benc:precision to their time.Duration constants.
+var timePrecisions = map[string]string{
+	"s": "time.Second", "ms": "time.Millisecond", "us": "time.Microsecond", "ns": "time.Nanosecond",
+}
+
+// FieldPrecision returns the time.Duration constant of the //benc:precision comment of the
+// field, e.g. "time.Millisecond" for //benc:precision ms, the precision its times are
+// truncated to when marshalled, or "" if it has no such comment. See bstd.TimeOptions.
+// A second argument compact selects the compact encoding of the unit, see FieldCompactTime.
+func (c *Context) FieldPrecision(field *ast.Field) (string, error) {
+	arg, ok := c.FieldDirective(field, "precision")
+	if !ok {
+		return "", nil
+	}
+	unit, compact, _ := strings.Cut(arg, " ")
+	precision, ok := timePrecisions[unit]
+	if !ok || compact != "" && compact != "compact" {
+		return "", fmt.Errorf("invalid //benc:precision %q, expected s, ms, us or ns, optionally followed by compact", arg)
+	}
+	return precision, nil
+}
+
+// FieldCompactTime returns the unit of the //benc:precision <unit> compact comment of the
+// field, whose times are encoded in the unit instead of nanoseconds, e.g. by
+// bstd.MarshalTimeMillis for ms, or "" if it has no such comment. The compact encoding of ns
+// is the one of bstd.MarshalTime, so "" is returned for it as well.
+func (c *Context) FieldCompactTime(field *ast.Field) string {
+	arg, _ := c.FieldDirective(field, "precision")
+	unit, compact, _ := strings.Cut(arg, " ")
+	if compact != "compact" || unit == "ns" {
+		return ""
+	}
+	return unit
+}
+
 // IsUnsupportedType recursively checks if a type expression contains an ignored type.
 func (c *Context) IsUnsupportedType(expr ast.Expr) bool {
 	switch t := expr.(type) {
 	case *ast.Ident:
 		switch t.Name {
-		case "any", "complex64", "complex128", "uintptr", "chan", "func", "error":
+		case "complex64", "complex128", "uintptr", "chan", "func", "error":
 			return true
+		case "any":
+			return !c.Variants
 		default:
 			if ts, ok := c.TypeSpecs[t.Name]; ok {
 				return c.IsUnsupportedType(ts.Type)
@@ -99,13 +588,18 @@ func (c *Context) IsUnsupportedType(expr ast.Expr) bool {
 	case *ast.FuncType, *ast.ChanType:
 		return true
 	case *ast.InterfaceType:
-		return true
+		return !c.Variants || !IsVariantType(t)
 	case *ast.ArrayType:
 		return c.IsUnsupportedType(t.Elt)
 	case *ast.MapType:
 		return c.IsUnsupportedType(t.Key) || c.IsUnsupportedType(t.Value)
 	case *ast.StarExpr:
 		return c.IsUnsupportedType(t.X)
+	case *ast.IndexExpr:
+		if elt, ok := OptionElt(t); ok {
+			return c.IsUnsupportedType(elt)
+		}
+		return false
 	case *ast.SelectorExpr:
 		sel := c.ExprToString(t)
 		if sel == "sync.Mutex" || sel == "sync.RWMutex" || sel == "unsafe.Pointer" {
@@ -141,8 +635,36 @@ func (c *Context) GetSupportedFields(ts *ast.TypeSpec) []*ast.Field {
 	return supportedFields
 }
 
+// LargeMapFields returns the supported map fields of the struct ts, whose length isn't limited
+// by a //benc:maxlen comment, which the generated tests fill with thousands of entries.
+func (c *Context) LargeMapFields(ts *ast.TypeSpec) []*ast.Field {
+	var fields []*ast.Field
+	for _, field := range c.GetSupportedFields(ts) {
+		if _, ok := field.Type.(*ast.MapType); !ok {
+			continue
+		}
+		if _, ok := c.FieldDirective(field, "maxlen"); !ok {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
 func (ctx *Context) WriteFile(content *bytes.Buffer, prefix, lang string) error {
 	path := filepath.Join(ctx.OutputDir, fmt.Sprintf("%s_"+prefix+"."+lang, ctx.BaseName))
+	if ctx.DryRun {
+		oldPath := path
+		old, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			oldPath = os.DevNull
+		} else if err != nil {
+			log.Fatalf("failed to read file %s: %v", path, err)
+			return err
+		}
+		fmt.Print(UnifiedDiff(oldPath, path, string(old), content.String()))
+		content.Reset()
+		return nil
+	}
 	if err := os.WriteFile(path, content.Bytes(), 0644); err != nil {
 		log.Fatalf("failed to write file %s: %v", path, err)
 		return err
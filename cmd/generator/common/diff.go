@@ -0,0 +1,161 @@
+package common
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines shown around every change.
+const diffContext = 3
+
+// maxDiffCells limits the size of the table used to find the longest common
+// subsequence of the changed lines; larger changes are shown as a whole.
+const maxDiffCells = 1 << 22
+
+type diffLine struct {
+	op   byte // ' ', '-' or '+'
+	text string
+}
+
+// UnifiedDiff returns the unified diff turning the file oldName with content a
+// into newName with content b, or an empty string if a and b are equal.
+func UnifiedDiff(oldName, newName, a, b string) string {
+	if a == b {
+		return ""
+	}
+	lines := diffLines(splitLines(a), splitLines(b))
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", oldName, newName)
+
+	for i := 0; i < len(lines); {
+		if lines[i].op == ' ' {
+			i++
+			continue
+		}
+
+		// a hunk starts diffContext lines before the change and ends once
+		// more than 2*diffContext unchanged lines follow the last change
+		start := max(i-diffContext, 0)
+		end := i
+		for j, same := i, 0; j < len(lines) && same <= 2*diffContext; j++ {
+			if lines[j].op == ' ' {
+				same++
+			} else {
+				same, end = 0, j+1
+			}
+		}
+		end = min(end+diffContext, len(lines))
+
+		oldStart, newStart := 1, 1
+		for _, l := range lines[:start] {
+			if l.op != '+' {
+				oldStart++
+			}
+			if l.op != '-' {
+				newStart++
+			}
+		}
+		oldLen, newLen := 0, 0
+		for _, l := range lines[start:end] {
+			if l.op != '+' {
+				oldLen++
+			}
+			if l.op != '-' {
+				newLen++
+			}
+		}
+		if oldLen == 0 {
+			oldStart--
+		}
+		if newLen == 0 {
+			newStart--
+		}
+
+		fmt.Fprintf(&sb, "@@ -%d,%d +%d,%d @@\n", oldStart, oldLen, newStart, newLen)
+		for _, l := range lines[start:end] {
+			sb.WriteByte(l.op)
+			sb.WriteString(l.text)
+			if !strings.HasSuffix(l.text, "\n") {
+				sb.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+		i = end
+	}
+	return sb.String()
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffLines returns the lines of a and b marked as unchanged, removed or added.
+func diffLines(a, b []string) []diffLine {
+	var lines []diffLine
+
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		lines = append(lines, diffLine{' ', a[prefix]})
+		prefix++
+	}
+	a, b = a[prefix:], b[prefix:]
+
+	suffix := 0
+	for suffix < len(a) && suffix < len(b) && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	common := a[len(a)-suffix:]
+	a, b = a[:len(a)-suffix], b[:len(b)-suffix]
+
+	if (len(a)+1)*(len(b)+1) > maxDiffCells {
+		for _, l := range a {
+			lines = append(lines, diffLine{'-', l})
+		}
+		for _, l := range b {
+			lines = append(lines, diffLine{'+', l})
+		}
+	} else {
+		// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+		lcs := make([][]int32, len(a)+1)
+		for i := range lcs {
+			lcs[i] = make([]int32, len(b)+1)
+		}
+		for i := len(a) - 1; i >= 0; i-- {
+			for j := len(b) - 1; j >= 0; j-- {
+				if a[i] == b[j] {
+					lcs[i][j] = lcs[i+1][j+1] + 1
+				} else {
+					lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+				}
+			}
+		}
+
+		i, j := 0, 0
+		for i < len(a) || j < len(b) {
+			switch {
+			case i < len(a) && j < len(b) && a[i] == b[j]:
+				lines = append(lines, diffLine{' ', a[i]})
+				i++
+				j++
+			case j == len(b) || i < len(a) && lcs[i+1][j] >= lcs[i][j+1]:
+				lines = append(lines, diffLine{'-', a[i]})
+				i++
+			default:
+				lines = append(lines, diffLine{'+', b[j]})
+				j++
+			}
+		}
+	}
+
+	for _, l := range common {
+		lines = append(lines, diffLine{' ', l})
+	}
+	return lines
+}
@@ -0,0 +1,118 @@
+package common
+
+import (
+	"go/ast"
+	"slices"
+	"strings"
+)
+
+// Dependency is an edge of the schema graph: the schema type From refers to the schema type To
+// through its field Field, which is empty for the types other than structs, e.g. a map alias.
+// Indirect is set if the reference goes through a pointer, a slice, a map or a bstd.Option,
+// otherwise From embeds a value of To, which can't be part of a cycle.
+type Dependency struct {
+	From, To, Field string
+	Indirect        bool
+}
+
+// Dependencies returns the references of the type ts to the schema types, in field order.
+// Ignored fields and fields of unsupported types are left out, as the generators do.
+func (c *Context) Dependencies(ts *ast.TypeSpec) []Dependency {
+	var deps []Dependency
+	var walk func(expr ast.Expr, field string, indirect bool)
+	walk = func(expr ast.Expr, field string, indirect bool) {
+		switch t := expr.(type) {
+		case *ast.Ident:
+			if _, ok := c.TypeSpecs[t.Name]; ok {
+				deps = append(deps, Dependency{From: ts.Name.Name, To: t.Name, Field: field, Indirect: indirect})
+			}
+		case *ast.StarExpr:
+			walk(t.X, field, true)
+		case *ast.ArrayType:
+			walk(t.Elt, field, indirect || t.Len == nil)
+		case *ast.MapType:
+			walk(t.Key, field, true)
+			walk(t.Value, field, true)
+		case *ast.IndexExpr:
+			if elt, ok := OptionElt(t); ok {
+				walk(elt, field, true)
+			}
+		}
+	}
+
+	st, ok := ts.Type.(*ast.StructType)
+	if !ok {
+		walk(ts.Type, "", false)
+		return deps
+	}
+	for _, field := range st.Fields.List {
+		if c.ShouldIgnoreField(field) || c.IsUnsupportedType(field.Type) {
+			continue
+		}
+		// an embedded field is named by its type
+		name := c.ExprToString(field.Type)
+		if len(field.Names) > 0 {
+			names := make([]string, len(field.Names))
+			for i, fName := range field.Names {
+				names[i] = fName.Name
+			}
+			name = strings.Join(names, ", ")
+		}
+		walk(field.Type, name, false)
+	}
+	return deps
+}
+
+// Cycles returns the groups of schema types referring to each other, directly or through other
+// types, e.g. a tree node holding pointers to its children. Every group is sorted by name and
+// the groups by their first name. A cycle always goes through an indirect dependency.
+func (c *Context) Cycles() [][]string {
+	// Tarjan's algorithm of the strongly connected components
+	index := make(map[string]int)
+	low := make(map[string]int)
+	onStack := make(map[string]bool)
+	var stack []string
+	var cycles [][]string
+
+	var visit func(name string)
+	visit = func(name string) {
+		index[name] = len(index)
+		low[name] = index[name]
+		stack = append(stack, name)
+		onStack[name] = true
+
+		selfLoop := false
+		for _, dep := range c.Dependencies(c.TypeSpecs[name]) {
+			if dep.To == name {
+				selfLoop = true
+			}
+			if _, ok := index[dep.To]; !ok {
+				visit(dep.To)
+				low[name] = min(low[name], low[dep.To])
+			} else if onStack[dep.To] {
+				low[name] = min(low[name], index[dep.To])
+			}
+		}
+
+		if low[name] != index[name] {
+			return
+		}
+		i := slices.Index(stack, name)
+		group := slices.Clone(stack[i:])
+		stack = stack[:i]
+		for _, member := range group {
+			onStack[member] = false
+		}
+		if len(group) > 1 || selfLoop {
+			slices.Sort(group)
+			cycles = append(cycles, group)
+		}
+	}
+	for _, ts := range c.Types {
+		if _, ok := index[ts.Name.Name]; !ok {
+			visit(ts.Name.Name)
+		}
+	}
+	slices.SortFunc(cycles, func(x, y []string) int { return strings.Compare(x[0], y[0]) })
+	return cycles
+}
@@ -0,0 +1,304 @@
+package common
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// LockFile is the name of the file next to the schemas that records the wire layouts of
+// their structs, see CheckLock.
+const LockFile = "benc.lock"
+
+// LockEntry is the recorded layout of a struct: the hash of its Layout and the version of
+// its //benc:frozen comment, zero if the struct isn't frozen.
+type LockEntry struct {
+	Version int    `json:"version,omitempty"`
+	Hash    string `json:"hash"`
+}
+
+// Lock maps the schema file names of a directory to their structs, and those to their entries.
+type Lock map[string]map[string]LockEntry
+
+// ReadLock reads the lockfile of the schema directory, an empty Lock if there is none.
+func (c *Context) ReadLock() (Lock, error) {
+	lock := make(Lock)
+	b, err := os.ReadFile(filepath.Join(c.OutputDir, LockFile))
+	if os.IsNotExist(err) {
+		return lock, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(b, &lock); err != nil {
+		return nil, fmt.Errorf("%s: %w", LockFile, err)
+	}
+	return lock, nil
+}
+
+// WriteLock writes the lockfile of the schema directory, or prints the diff of it on a dry run.
+func (c *Context) WriteLock(lock Lock) error {
+	b, err := json.MarshalIndent(lock, "", "\t")
+	if err != nil {
+		return err
+	}
+	b = append(b, '\n')
+
+	path := filepath.Join(c.OutputDir, LockFile)
+	if c.DryRun {
+		old, err := os.ReadFile(path)
+		oldPath := path
+		if os.IsNotExist(err) {
+			oldPath = os.DevNull
+		} else if err != nil {
+			return err
+		}
+		fmt.Print(UnifiedDiff(oldPath, path, string(old), string(b)))
+		return nil
+	}
+	return os.WriteFile(path, b, 0644)
+}
+
+// FrozenVersion returns the version of the //benc:frozen comment of the type, 1 if it has
+// none, and whether the type is frozen.
+func (c *Context) FrozenVersion(ts *ast.TypeSpec) (int, bool, error) {
+	arg, ok := c.TypeDirective(ts, "frozen")
+	if !ok {
+		return 0, false, nil
+	}
+	if arg == "" {
+		return 1, true, nil
+	}
+	v, err := strconv.Atoi(strings.TrimPrefix(arg, "v"))
+	if err != nil || v < 1 {
+		return 0, false, fmt.Errorf("%s: invalid //benc:frozen version %q, expected a positive integer", ts.Name.Name, arg)
+	}
+	return v, true, nil
+}
+
+// CheckLock compares the layouts of the structs of the schema with the ones in the lockfile,
+// before generating code. It fails if the layout of a struct with a //benc:frozen comment
+// changed while its version stayed the same, or its version went back. Once the schema was
+// locked by UpdateLock, it fails as well if the layout of any other struct changed, or a
+// struct was added or removed. Otherwise the entries of the frozen structs are updated: new
+// ones are added and the ones with a bumped version replaced.
+func (c *Context) CheckLock() error {
+	return c.updateLock(false)
+}
+
+// UpdateLock records the layouts of all structs of the schema in the lockfile, which locks
+// the schema, see CheckLock. The rules of frozen structs still apply.
+func (c *Context) UpdateLock() error {
+	return c.updateLock(true)
+}
+
+func (c *Context) updateLock(relock bool) error {
+	lock, err := c.ReadLock()
+	if err != nil {
+		return err
+	}
+	schema := filepath.Base(c.InputFile)
+	recorded := lock[schema]
+	// frozen structs have a version, the others are only recorded by UpdateLock
+	locked := relock
+	for _, entry := range recorded {
+		locked = locked || entry.Version == 0
+	}
+
+	current := make(map[string]LockEntry)
+	for _, ts := range c.Types {
+		if _, ok := ts.Type.(*ast.StructType); !ok {
+			continue
+		}
+		version, frozen, err := c.FrozenVersion(ts)
+		if err != nil {
+			return err
+		}
+		if !frozen && !locked {
+			continue
+		}
+		name := ts.Name.Name
+		entry := LockEntry{Version: version, Hash: c.LayoutHash(ts)}
+		old, ok := recorded[name]
+		switch {
+		case frozen && ok && entry.Version < old.Version:
+			return fmt.Errorf("%s: //benc:frozen version %d is older than version %d in %s", name, entry.Version, old.Version, LockFile)
+		case frozen && ok && entry.Version == old.Version && entry.Hash != old.Hash:
+			return fmt.Errorf("%s: the wire layout of the frozen struct changed, bump its //benc:frozen version to %d if that is intended", name, old.Version+1)
+		case !frozen && !relock && !ok:
+			return fmt.Errorf("%s: the struct is not in %s yet, run `benc lock` to add it", name, LockFile)
+		case !frozen && !relock && entry.Hash != old.Hash:
+			return fmt.Errorf("%s: the wire layout changed since the last `benc lock`, run it again if that is intended", name)
+		}
+		current[name] = entry
+	}
+	if locked && !relock {
+		for name := range recorded {
+			if _, ok := current[name]; !ok {
+				return fmt.Errorf("%s: the struct of %s is missing in the schema, run `benc lock` if it was removed on purpose", name, LockFile)
+			}
+		}
+	}
+
+	if len(current) == 0 && recorded == nil {
+		return nil
+	}
+	if len(current) == 0 {
+		delete(lock, schema)
+	} else {
+		lock[schema] = current
+	}
+	old, _ := json.Marshal(recorded)
+	now, _ := json.Marshal(current)
+	if bytes.Equal(old, now) {
+		return nil
+	}
+	if err = c.WriteLock(lock); err != nil {
+		return err
+	}
+	if !c.DryRun {
+		log.Printf("Updated the layouts of %s in %s", schema, filepath.Join(c.OutputDir, LockFile))
+	}
+	return nil
+}
+
+// LayoutHash returns the hex encoded SHA-256 of the Layout of the struct ts.
+func (c *Context) LayoutHash(ts *ast.TypeSpec) string {
+	sum := sha256.Sum256([]byte(c.Layout(ts)))
+	return hex.EncodeToString(sum[:])
+}
+
+// Layout describes the wire layout of the struct ts: the types and encodings of its fields in
+// order, with the schema types they use expanded. The names of fields and types don't change
+// the wire, so they aren't part of it. Fields of type `any` count as variants, whichever
+// language is generated.
+func (c *Context) Layout(ts *ast.TypeSpec) string {
+	lc := *c
+	lc.Variants = true
+	var b strings.Builder
+	lc.writeStructLayout(&b, ts, nil)
+	return b.String()
+}
+
+// writeStructLayout writes the layout of the struct ts. 'stack' holds the structs being
+// written, a recursive reference is written as its depth in it.
+func (c *Context) writeStructLayout(b *strings.Builder, ts *ast.TypeSpec, stack []string) {
+	for i, name := range stack {
+		if name == ts.Name.Name {
+			fmt.Fprintf(b, "@%d", i)
+			return
+		}
+	}
+	stack = append(stack, ts.Name.Name)
+
+	bits, _, _ := c.FlagLayout(ts)
+	if _, ok := c.TypeDirective(ts, "flags"); ok {
+		b.WriteString("flags")
+		if c.FlagWordSize(ts) == 1 {
+			b.WriteString(" byte")
+		}
+	}
+	b.WriteString("{")
+	for _, field := range c.GetSupportedFields(ts) {
+		var opts []string
+		for _, opt := range []struct {
+			name string
+			set  bool
+		}{
+			{"varint", c.IsVarintField(field)},
+			{"zigzag", c.IsZigZagField(field)},
+			{"rle", c.IsRLEField(field)},
+			{"sparse", c.IsSparseField(field)},
+			{"delta", c.IsDeltaField(field)},
+			{"gorilla", c.IsGorillaField(field)},
+			{"dict", c.IsDictField(field)},
+			{"zone", c.IsZoneField(field)},
+			{"bfloat16", c.IsBFloat16Field(field)},
+			{"nul", c.IsNulField(field)},
+		} {
+			if opt.set {
+				opts = append(opts, opt.name)
+			}
+		}
+		if unit := c.FieldCompactTime(field); unit != "" {
+			opts = append(opts, "compact("+unit+")")
+		}
+		for _, name := range field.Names {
+			c.writeTypeLayout(b, field.Type, stack)
+			for _, opt := range opts {
+				b.WriteString(" " + opt)
+			}
+			if fb, ok := bits[name.Name]; ok {
+				fmt.Fprintf(b, " bits(%d,%d,%d)", fb.Word, fb.Shift, fb.Bits)
+			}
+			b.WriteString(";")
+		}
+	}
+	b.WriteString("}")
+}
+
+// writeTypeLayout writes the layout of a field type.
+func (c *Context) writeTypeLayout(b *strings.Builder, expr ast.Expr, stack []string) {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		if ts, ok := c.TypeSpecs[t.Name]; ok {
+			if _, ok := ts.Type.(*ast.StructType); ok {
+				c.writeStructLayout(b, ts, stack)
+				return
+			}
+			c.writeTypeLayout(b, ts.Type, stack)
+			return
+		}
+		if e, ok := c.Enums[t.Name]; ok {
+			// the ordinals follow the order of the constants
+			b.WriteString("enum{" + strings.Join(e.Values, ",") + "}")
+			return
+		}
+		switch t.Name {
+		case "byte":
+			b.WriteString("uint8")
+		case "rune":
+			b.WriteString("int32")
+		default:
+			b.WriteString(t.Name)
+		}
+	case *ast.StarExpr:
+		b.WriteString("*")
+		c.writeTypeLayout(b, t.X, stack)
+	case *ast.ArrayType:
+		b.WriteString("[")
+		if t.Len != nil {
+			b.WriteString(c.ExprToString(t.Len))
+		}
+		b.WriteString("]")
+		c.writeTypeLayout(b, t.Elt, stack)
+	case *ast.MapType:
+		b.WriteString("map[")
+		c.writeTypeLayout(b, t.Key, stack)
+		b.WriteString("]")
+		c.writeTypeLayout(b, t.Value, stack)
+	case *ast.IndexExpr:
+		if elt, ok := OptionElt(t); ok {
+			b.WriteString("option[")
+			c.writeTypeLayout(b, elt, stack)
+			b.WriteString("]")
+			return
+		}
+		b.WriteString(c.ExprToString(t))
+	case *ast.InterfaceType:
+		if IsVariantType(t) {
+			b.WriteString("any")
+			return
+		}
+		b.WriteString(c.ExprToString(t))
+	default:
+		b.WriteString(c.ExprToString(expr))
+	}
+}
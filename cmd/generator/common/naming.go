@@ -0,0 +1,128 @@
+package common
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Naming conventions for the field names of the generated code.
+// NamingKeep uses the names of the schema verbatim.
+const (
+	NamingKeep   = "keep"
+	NamingSnake  = "snake"
+	NamingCamel  = "camel"
+	NamingPascal = "pascal"
+)
+
+// ParseNaming parses a comma separated list of `lang=convention` pairs, e.g. "js=camel,c=snake".
+func ParseNaming(s string) (map[string]string, error) {
+	namings := make(map[string]string)
+	if s == "" {
+		return namings, nil
+	}
+	for pair := range strings.SplitSeq(s, ",") {
+		lang, convention, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid naming %q, expected lang=convention", pair)
+		}
+		switch convention {
+		case NamingKeep, NamingSnake, NamingCamel, NamingPascal:
+			namings[lang] = convention
+		default:
+			return nil, fmt.Errorf("unknown naming convention %q for %s", convention, lang)
+		}
+	}
+	return namings, nil
+}
+
+// SetNaming sets the naming convention used by FieldName and forgets the names of the previous language.
+func (c *Context) SetNaming(convention string) {
+	c.Naming = convention
+	c.names = nil
+}
+
+// FieldName converts the schema field name to the current naming convention.
+// Converted names are recorded for the name manifest.
+func (c *Context) FieldName(name string) string {
+	if c.Naming == "" || c.Naming == NamingKeep {
+		return name
+	}
+	converted := ConvertName(name, c.Naming)
+	if c.names == nil {
+		c.names = make(map[string]string)
+	}
+	c.names[name] = converted
+	return converted
+}
+
+// WriteNameManifest writes the mapping from schema field names to the generated ones
+// as <base>_<lang>_names.json, if a naming convention is set.
+func (c *Context) WriteNameManifest(lang string) error {
+	if len(c.names) == 0 {
+		return nil
+	}
+	b, err := json.MarshalIndent(c.names, "", "\t")
+	if err != nil {
+		return err
+	}
+	return c.WriteFile(bytes.NewBuffer(append(b, '\n')), lang+"_names", "json")
+}
+
+// ConvertName converts a name like "UserID" or "http_status" to the given naming convention.
+func ConvertName(name, convention string) string {
+	words := splitWords(name)
+	if len(words) == 0 {
+		return name
+	}
+
+	switch convention {
+	case NamingSnake:
+		for i, w := range words {
+			words[i] = strings.ToLower(w)
+		}
+		return strings.Join(words, "_")
+	case NamingCamel, NamingPascal:
+		for i, w := range words {
+			w = strings.ToLower(w)
+			if i > 0 || convention == NamingPascal {
+				w = strings.ToUpper(w[:1]) + w[1:]
+			}
+			words[i] = w
+		}
+		return strings.Join(words, "")
+	}
+	return name
+}
+
+// splitWords splits a name at underscores and case changes, keeping acronyms together,
+// e.g. "HTTPServerIDs" becomes ["HTTP", "Server", "IDs"].
+func splitWords(name string) []string {
+	var words []string
+	runes := []rune(name)
+	start := 0
+	for i := 0; i <= len(runes); i++ {
+		if i < len(runes) && runes[i] != '_' {
+			if i == start || !unicode.IsUpper(runes[i]) {
+				continue
+			}
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if nextLower && runes[i+1] == 's' && (i+2 == len(runes) || !unicode.IsLower(runes[i+2])) {
+				nextLower = false // plural acronym like "IDs"
+			}
+			if !unicode.IsUpper(prev) && prev != '_' || unicode.IsUpper(prev) && nextLower {
+				words = append(words, string(runes[start:i]))
+				start = i
+			}
+			continue
+		}
+		if i > start {
+			words = append(words, string(runes[start:i]))
+		}
+		start = i + 1
+	}
+	return words
+}
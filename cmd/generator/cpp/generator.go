@@ -19,18 +19,27 @@ func New(ctx *common.Context) common.Generator {
 }
 
 func (g *generator) Generate() (err error) {
-	g.printf("// Code generated by benc generator; DO NOT EDIT.\n")
+	if err = g.CheckGoOnlyEncodings("cpp"); err != nil {
+		return
+	}
+	g.printf("// Code generated by benc generator; DO NOT EDIT.\n%s", g.FingerprintComment("// "))
 	g.printf("#pragma once\n\n")
 	g.printf("#include \"std.hpp\"\n")
 	g.printf("#include <vector>\n")
 	g.printf("#include <string>\n")
 	g.printf("#include <map>\n")
+	g.printf("#include <array>\n")
 	g.printf("#include <optional>\n")
 	g.printf("#include <variant>\n\n")
 	
 	// Open namespace
 	g.printf("namespace %s {\n\n", g.PkgName)
 
+	// Enums
+	for _, e := range g.SortedEnums() {
+		g.generateCppEnum(e)
+	}
+
 	// Forward declarations
 	for _, ts := range g.Types {
 		g.printf("struct %s;\n", ts.Name.Name)
@@ -52,6 +61,42 @@ func (g *generator) Generate() (err error) {
 	return
 }
 
+// generateCppEnum declares the //benc:enum type e as enum class, whose values are the ordinals
+// of its constants, along with the table of their names and the functions marshalling them.
+func (g *generator) generateCppEnum(e *common.Enum) {
+	name := e.Name
+	g.printf("enum class %s : uint32_t { %s };\n\n", name, strings.Join(e.Values, ", "))
+
+	names := make([]string, len(e.Values))
+	for i, v := range e.Values {
+		names[i] = fmt.Sprintf("%q", v)
+	}
+	g.printf("inline constexpr std::array<std::string_view, %d> %sNames{%s};\n\n", len(names), name, strings.Join(names, ", "))
+
+	g.printf("inline std::string_view to_string(%s v) noexcept {\n", name)
+	g.printf("\tauto i = static_cast<std::size_t>(v);\n")
+	g.printf("\treturn i < %sNames.size() ? %sNames[i] : std::string_view{};\n", name, name)
+	g.printf("}\n\n")
+
+	g.printf("inline std::optional<%s> Parse%s(std::string_view name) noexcept {\n", name, name)
+	g.printf("\tfor (std::size_t i = 0; i < %sNames.size(); ++i) {\n", name)
+	g.printf("\t\tif (%sNames[i] == name) return static_cast<%s>(i);\n", name, name)
+	g.printf("\t}\n")
+	g.printf("\treturn std::nullopt;\n")
+	g.printf("}\n\n")
+
+	g.printf("inline std::size_t Size%s(%s v) noexcept { return bstd::size_uintptr(static_cast<uintptr_t>(v)); }\n\n", name, name)
+	g.printf("inline std::size_t Marshal%s(std::span<std::byte> b, std::size_t n, %s v) noexcept { return bstd::marshal_uintptr(b, n, static_cast<uintptr_t>(v)); }\n\n", name, name)
+
+	g.printf("inline bstd::Result<%s> Unmarshal%s(std::span<const std::byte> b, std::size_t n) noexcept {\n", name, name)
+	g.printf("\tauto res = bstd::unmarshal_uintptr(b, n);\n")
+	g.printf("\tif (auto* err = std::get_if<bstd::Error>(&res)) return *err;\n")
+	g.printf("\tauto& [i, off] = std::get<bstd::UnmarshalResult<uintptr_t>>(res);\n")
+	g.printf("\tif (i >= %sNames.size()) return bstd::Error::InvalidData;\n", name)
+	g.printf("\treturn bstd::UnmarshalResult<%s>{static_cast<%s>(i), off};\n", name, name)
+	g.printf("}\n\n")
+}
+
 func (g *generator) generateCppStruct(ts *ast.TypeSpec) error {
 	name := ts.Name.Name
 	_, ok := ts.Type.(*ast.StructType)
@@ -67,7 +112,7 @@ func (g *generator) generateCppStruct(ts *ast.TypeSpec) error {
 	for _, field := range supportedFields {
 		cppType := g.toCppType(field.Type)
 		for _, fName := range field.Names {
-			g.printf("\t%s %s;\n", cppType, fName.Name)
+			g.printf("\t%s %s;\n", cppType, g.FieldName(fName.Name))
 		}
 	}
 	g.printf("\n")
@@ -77,7 +122,7 @@ func (g *generator) generateCppStruct(ts *ast.TypeSpec) error {
 	g.printf("\t\tstd::size_t s = 0;\n")
 	for _, field := range supportedFields {
 		for _, fName := range field.Names {
-			g.printf("\t\ts += %s;\n", g.getSizeExpr(field.Type, fName.Name))
+			g.printf("\t\ts += %s;\n", g.getSizeExpr(field.Type, g.FieldName(fName.Name)))
 		}
 	}
 	g.printf("\t\treturn s;\n")
@@ -87,7 +132,7 @@ func (g *generator) generateCppStruct(ts *ast.TypeSpec) error {
 	g.printf("\tstd::size_t Marshal(std::span<std::byte> b, std::size_t n) const {\n")
 	for _, field := range supportedFields {
 		for _, fName := range field.Names {
-			g.printf("\t\tn = %s;\n", g.getMarshalExpr(field.Type, "n", "b", fName.Name))
+			g.printf("\t\tn = %s;\n", g.getMarshalExpr(field.Type, "n", "b", g.FieldName(fName.Name)))
 		}
 	}
 	g.printf("\t\treturn n;\n")
@@ -102,7 +147,7 @@ func (g *generator) generateCppStruct(ts *ast.TypeSpec) error {
 			g.printf("\t\t\tauto res = %s;\n", g.getUnmarshalExpr(field.Type, "n", "b"))
 			g.printf("\t\t\tif (auto* err = std::get_if<bstd::Error>(&res)) return *err;\n")
 			g.printf("\t\t\tauto& [val, off] = std::get<bstd::UnmarshalResult<%s>>(res);\n", g.toCppType(field.Type))
-			g.printf("\t\t\tthis->%s = std::move(val);\n", fName.Name)
+			g.printf("\t\t\tthis->%s = std::move(val);\n", g.FieldName(fName.Name))
 			g.printf("\t\t\tn = off;\n")
 			g.printf("\t\t}\n")
 		}
@@ -119,7 +164,7 @@ func (g *generator) generateCppStruct(ts *ast.TypeSpec) error {
 // -----------------------------------------------------------------------------
 
 func (g *generator) Tests() error{
-	g.printf("// Code generated by benc generator; DO NOT EDIT.\n")
+	g.printf("// Code generated by benc generator; DO NOT EDIT.\n%s", g.FingerprintComment("// "))
 	g.printf("#include \"gen.h\"\n")
 	g.printf("#include \"%s.hpp\"\n", g.PkgName) // Assumes output file is pkgname.hpp
 	g.printf("#include <iostream>\n\n")
@@ -143,21 +188,9 @@ func (g *generator) Tests() error{
 			g.printf("\t// Test %s\n", name)
 			g.printf("\t{\n")
 			g.printf("\t\tauto original = Generate%s(rng, bstd::gen::MaxDepth);\n", name)
-			g.printf("\t\tstd::size_t s = original.Size();\n")
-			g.printf("\t\tstd::vector<std::byte> buf(s);\n")
-			g.printf("\t\tstd::size_t n = original.Marshal(buf, 0);\n")
-			g.printf("\t\tif (n != s) { std::cerr << \"[FAIL] %s: Size mismatch\" << std::endl; errors++; }\n", name)
-			g.printf("\t\telse {\n")
-			g.printf("\t\t\t%s copy;\n", name)
-			g.printf("\t\t\tauto res = copy.Unmarshal(buf, 0);\n")
-			g.printf("\t\t\tif (std::holds_alternative<bstd::Error>(res)) { std::cerr << \"[FAIL] %s: Unmarshal error\" << std::endl; errors++; }\n", name)
-			g.printf("\t\t\telse {\n")
-			g.printf("\t\t\t\tif (auto err = Compare%s(original, copy)) {\n", name)
-			g.printf("\t\t\t\t\tstd::cerr << \"[FAIL] %s: \" << *err << std::endl; errors++;\n", name)
-			g.printf("\t\t\t\t}\n")
-			g.printf("\t\t\t}\n")
-			g.printf("\t\t}\n")
+			g.generateCppTestRoundTrip(name)
 			g.printf("\t}\n\n")
+			g.generateCppTestLargeMaps(ts)
 		}
 	}
 
@@ -168,6 +201,47 @@ func (g *generator) Tests() error{
 	return g.WriteFile(&g.buf, "_benc_test", ".cpp")
 }
 
+// generateCppTestLargeMaps generates a round trip test of the struct ts with maps of
+// bstd::gen::LargeMapCount entries.
+func (g *generator) generateCppTestLargeMaps(ts *ast.TypeSpec) {
+	fields := g.LargeMapFields(ts)
+	if len(fields) == 0 {
+		return
+	}
+	name := ts.Name.Name
+	g.printf("\t// Test %s with large maps\n", name)
+	g.printf("\t{\n")
+	g.printf("\t\tauto original = Generate%s(rng, bstd::gen::MaxDepth);\n", name)
+	g.printf("\t\tauto& g = rng;\n")
+	g.printf("\t\tconst int depth = 2; // the keys and values are generated at depth 1\n")
+	for _, field := range fields {
+		for _, fName := range field.Names {
+			g.printf("\t\toriginal.%s = %s;\n", g.FieldName(fName.Name), g.getMapGenExpr(field.Type.(*ast.MapType), "GenerateLargeMap"))
+		}
+	}
+	g.generateCppTestRoundTrip(name)
+	g.printf("\t}\n\n")
+}
+
+// generateCppTestRoundTrip generates the marshalling, unmarshalling and comparison of the
+// value original of the struct name, counting the failures in errors.
+func (g *generator) generateCppTestRoundTrip(name string) {
+	g.printf("\t\tstd::size_t s = original.Size();\n")
+	g.printf("\t\tstd::vector<std::byte> buf(s);\n")
+	g.printf("\t\tstd::size_t n = original.Marshal(buf, 0);\n")
+	g.printf("\t\tif (n != s) { std::cerr << \"[FAIL] %s: Size mismatch\" << std::endl; errors++; }\n", name)
+	g.printf("\t\telse {\n")
+	g.printf("\t\t\t%s copy;\n", name)
+	g.printf("\t\t\tauto res = copy.Unmarshal(buf, 0);\n")
+	g.printf("\t\t\tif (std::holds_alternative<bstd::Error>(res)) { std::cerr << \"[FAIL] %s: Unmarshal error\" << std::endl; errors++; }\n", name)
+	g.printf("\t\t\telse {\n")
+	g.printf("\t\t\t\tif (auto err = Compare%s(original, copy)) {\n", name)
+	g.printf("\t\t\t\t\tstd::cerr << \"[FAIL] %s: \" << *err << std::endl; errors++;\n", name)
+	g.printf("\t\t\t\t}\n")
+	g.printf("\t\t\t}\n")
+	g.printf("\t\t}\n")
+}
+
 func (g *generator) generateCppTestGenerator(ts *ast.TypeSpec) {
 	name := ts.Name.Name
 	st, ok := ts.Type.(*ast.StructType)
@@ -181,7 +255,7 @@ func (g *generator) generateCppTestGenerator(ts *ast.TypeSpec) {
 	for _, field := range st.Fields.List {
 		if g.ShouldIgnoreField(field) { continue }
 		for _, fName := range field.Names {
-			g.printf("\tobj.%s = %s;\n", fName.Name, g.getTestGenExpr(field.Type))
+			g.printf("\tobj.%s = %s;\n", g.FieldName(fName.Name), g.getTestGenExpr(field.Type))
 		}
 	}
 	g.printf("\treturn obj;\n")
@@ -198,7 +272,7 @@ func (g *generator) generateCppTestComparer(ts *ast.TypeSpec) {
 		if g.ShouldIgnoreField(field) { continue }
 		for _, fName := range field.Names {
 			g.printf("\tif (auto err = bstd::gen::CompareField(\"%s\", [&]() { return %s; })) return err;\n", 
-				fName.Name, g.getTestCompareExpr(field.Type, "a."+fName.Name, "b."+fName.Name))
+				g.FieldName(fName.Name), g.getTestCompareExpr(field.Type, "a."+g.FieldName(fName.Name), "b."+g.FieldName(fName.Name)))
 		}
 	}
 	g.printf("\treturn std::nullopt;\n")
@@ -254,6 +328,9 @@ func (g *generator) getSizeExpr(expr ast.Expr, val string) string {
 		// If it's a map alias or struct, it has a Size() method
 		return fmt.Sprintf("%s.Size()", val)
 	}
+	if _, ok := g.Enums[typeName]; ok {
+		return fmt.Sprintf("Size%s(%s)", typeName, val)
+	}
 
 	switch t := expr.(type) {
 	case *ast.Ident:
@@ -299,6 +376,9 @@ func (g *generator) getMarshalExpr(expr ast.Expr, n, buf, val string) string {
 	if _, ok := g.TypeSpecs[typeName]; ok {
 		return fmt.Sprintf("%s.Marshal(%s, %s)", val, buf, n)
 	}
+	if _, ok := g.Enums[typeName]; ok {
+		return fmt.Sprintf("Marshal%s(%s, %s, %s)", typeName, buf, n, val)
+	}
 
 	switch t := expr.(type) {
 	case *ast.Ident:
@@ -340,6 +420,9 @@ func (g *generator) getUnmarshalExpr(expr ast.Expr, n, buf string) string {
 		// For structs, we probably want to instantiate T, call T.Unmarshal, return {T, off}.
 		return fmt.Sprintf("[](std::span<const std::byte> b, std::size_t n) -> bstd::Result<%s> { %s v; auto r = v.Unmarshal(b, n); if(auto* e = std::get_if<bstd::Error>(&r)) return *e; return bstd::UnmarshalResult<%s>{std::move(v), std::get<std::size_t>(r)}; }(%s, %s)", typeName, typeName, typeName, buf, n)
 	}
+	if _, ok := g.Enums[typeName]; ok {
+		return fmt.Sprintf("Unmarshal%s(%s, %s)", typeName, buf, n)
+	}
 
 	switch t := expr.(type) {
 	case *ast.Ident:
@@ -403,6 +486,9 @@ func (g *generator) getTestGenExpr(expr ast.Expr) string {
 			if _, ok := g.TypeSpecs[t.Name]; ok {
 				return fmt.Sprintf("Generate%s(g, depth - 1)", t.Name)
 			}
+			if e, ok := g.Enums[t.Name]; ok {
+				return fmt.Sprintf("static_cast<%s>(std::uniform_int_distribution<std::size_t>(0, %d)(g))", t.Name, len(e.Values)-1)
+			}
 			return fmt.Sprintf("bstd::gen::Generate%s%s(g, depth)", strings.ToUpper(t.Name[:1]), t.Name[1:])
 		}
 	case *ast.StarExpr:
@@ -413,18 +499,27 @@ func (g *generator) getTestGenExpr(expr ast.Expr) string {
 		}
 		return fmt.Sprintf("bstd::gen::GenerateSlice<%s>(g, depth, [](auto& g, int d) { return %s; })", g.toCppType(t.Elt), g.getTestGenExpr(t.Elt))
 	case *ast.MapType:
-		return fmt.Sprintf("bstd::gen::GenerateMap<%s, %s>(g, depth, [](auto& g, int d) { return %s; }, [](auto& g, int d) { return %s; })", 
-			g.toCppType(t.Key), g.toCppType(t.Value), g.getTestGenExpr(t.Key), g.getTestGenExpr(t.Value))
+		return g.getMapGenExpr(t, "GenerateMap")
 	}
 	return "{}"
 }
 
+// getMapGenExpr returns the call of the gen.h function fn, GenerateMap or GenerateLargeMap,
+// generating the map.
+func (g *generator) getMapGenExpr(t *ast.MapType, fn string) string {
+	return fmt.Sprintf("bstd::gen::%s<%s, %s>(g, depth, [](auto& g, int d) { return %s; }, [](auto& g, int d) { return %s; })",
+		fn, g.toCppType(t.Key), g.toCppType(t.Value), g.getTestGenExpr(t.Key), g.getTestGenExpr(t.Value))
+}
+
 func (g *generator) getTestCompareExpr(expr ast.Expr, a, b string) string {
 	switch t := expr.(type) {
 	case *ast.Ident:
 		if _, ok := g.TypeSpecs[t.Name]; ok {
 			return fmt.Sprintf("Compare%s(%s, %s)", t.Name, a, b)
 		}
+		if _, ok := g.Enums[t.Name]; ok {
+			return fmt.Sprintf("bstd::gen::ComparePrimitive(to_string(%s), to_string(%s))", a, b)
+		}
 		return fmt.Sprintf("bstd::gen::ComparePrimitive(%s, %s)", a, b)
 	case *ast.ArrayType:
 		if g.toCppType(t.Elt) == "uint8_t" {
@@ -0,0 +1,393 @@
+package flatbuffers
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"log"
+	"strings"
+	"text/scanner"
+
+	"github.com/banditmoscow1337/benc/cmd/generator/common"
+)
+
+// scalarTypes maps the FlatBuffers scalar types to the Go types of the same size.
+var scalarTypes = map[string]string{
+	"bool": "bool",
+	"byte": "int8", "int8": "int8",
+	"ubyte": "uint8", "uint8": "uint8",
+	"short": "int16", "int16": "int16",
+	"ushort": "uint16", "uint16": "uint16",
+	"int": "int32", "int32": "int32",
+	"uint": "uint32", "uint32": "uint32",
+	"long": "int64", "int64": "int64",
+	"ulong": "uint64", "uint64": "uint64",
+	"float": "float32", "float32": "float32",
+	"double": "float64", "float64": "float64",
+	"string": "string",
+}
+
+// Parse reads a FlatBuffers schema (.fbs) and maps its tables and structs onto Go AST TypeSpecs.
+// Scalars become the Go types of the same size, vectors slices, fixed-length arrays arrays and
+// enums their underlying integer type. Table fields referring to another table become pointers,
+// since such fields are optional and tables may refer to themselves. Field names are converted
+// to PascalCase, deprecated fields are left out. Unions and includes are not supported.
+//
+// The types don't exist in Go yet, so the go generator declares them next to their methods.
+func Parse(ctx *common.Context) error {
+	log.Printf("Parsing FlatBuffers input: %s", ctx.InputFile)
+
+	file, err := ctx.OpenInput()
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	p := &parser{
+		kinds: make(map[string]string),
+		enums: make(map[string]string),
+	}
+	p.s.Init(file)
+	p.s.Filename = ctx.InputFile
+	p.s.Mode = scanner.ScanIdents | scanner.ScanInts | scanner.ScanFloats | scanner.ScanStrings | scanner.ScanComments | scanner.SkipComments
+	p.s.Error = func(s *scanner.Scanner, msg string) {
+		if p.err == nil {
+			p.err = fmt.Errorf("%s: %s", s.Position, msg)
+		}
+	}
+
+	if err = p.parse(); err != nil {
+		return err
+	}
+
+	ctx.PkgName = strings.ToLower(ctx.BaseName)
+	if p.namespace != "" {
+		ctx.PkgName = strings.ToLower(p.namespace[strings.LastIndex(p.namespace, ".")+1:])
+	}
+	ctx.DeclareTypes = true
+
+	for _, d := range p.decls {
+		fields := make([]*ast.Field, 0, len(d.fields))
+		for _, f := range d.fields {
+			typ, err := p.typeExpr(f.typ, d.kind == "table")
+			if err != nil {
+				return fmt.Errorf("%s: field %s of %s: %w", f.pos, f.name, d.name, err)
+			}
+			fields = append(fields, &ast.Field{
+				Names: []*ast.Ident{{Name: common.ConvertName(f.name, common.NamingPascal)}},
+				Type:  typ,
+			})
+		}
+		ctx.Types = append(ctx.Types, &ast.TypeSpec{
+			Name: &ast.Ident{Name: d.name},
+			Type: &ast.StructType{Fields: &ast.FieldList{List: fields}},
+		})
+	}
+	return nil
+}
+
+// decl is a table or struct of the schema, resolved to Go types once all declarations are known.
+type decl struct {
+	kind, name string
+	fields     []field
+}
+
+type field struct {
+	name, typ string
+	pos       scanner.Position
+}
+
+type parser struct {
+	s         scanner.Scanner
+	err       error
+	namespace string
+	decls     []decl
+	// kinds maps the declared type names to table, struct, enum or union.
+	kinds map[string]string
+	// enums maps the enum names to their underlying Go type.
+	enums map[string]string
+}
+
+func (p *parser) next() string {
+	p.s.Scan()
+	return p.s.TokenText()
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	if p.err != nil {
+		return p.err
+	}
+	return fmt.Errorf("%s: %s", p.s.Position, fmt.Sprintf(format, args...))
+}
+
+func (p *parser) expect(text string) error {
+	if got := p.next(); got != text {
+		return p.errorf("expected %q, got %q", text, got)
+	}
+	return nil
+}
+
+func (p *parser) ident() (string, error) {
+	if p.s.Scan() != scanner.Ident {
+		return "", p.errorf("expected identifier, got %q", p.s.TokenText())
+	}
+	return p.s.TokenText(), nil
+}
+
+// qualifiedIdent parses a name like "MyGame.Sample.Monster".
+func (p *parser) qualifiedIdent() (string, error) {
+	name, err := p.ident()
+	if err != nil {
+		return "", err
+	}
+	return p.qualifiedRest(name)
+}
+
+// qualifiedRest parses the rest of a qualified name after its first part 'name'.
+func (p *parser) qualifiedRest(name string) (string, error) {
+	for p.s.Peek() == '.' {
+		p.s.Scan()
+		part, err := p.ident()
+		if err != nil {
+			return "", err
+		}
+		name += "." + part
+	}
+	return name, nil
+}
+
+// skipPast skips all tokens up to and including 'text'.
+func (p *parser) skipPast(text string) error {
+	for {
+		tok := p.s.Scan()
+		if tok == scanner.EOF {
+			return p.errorf("expected %q, got EOF", text)
+		}
+		if p.s.TokenText() == text {
+			return p.err
+		}
+	}
+}
+
+func (p *parser) parse() error {
+	for {
+		tok := p.s.Scan()
+		if p.err != nil {
+			return p.err
+		}
+		if tok == scanner.EOF {
+			return nil
+		}
+
+		var err error
+		switch kw := p.s.TokenText(); kw {
+		case "namespace":
+			if p.namespace, err = p.qualifiedIdent(); err == nil {
+				err = p.expect(";")
+			}
+		case "attribute", "file_identifier", "file_extension", "root_type":
+			err = p.skipPast(";")
+		case "table", "struct":
+			err = p.parseDecl(kw)
+		case "enum", "union":
+			err = p.parseEnum(kw)
+		case "include":
+			err = p.errorf("includes are not supported, merge the schemas into one file")
+		case "rpc_service":
+			log.Printf("%s: skipping rpc_service", p.s.Position)
+			err = p.skipPast("}")
+		default:
+			err = p.errorf("unexpected %q", kw)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// parseDecl parses a table or struct: `table Name (attributes) { name: type = default (attributes); ... }`.
+func (p *parser) parseDecl(kind string) error {
+	name, err := p.ident()
+	if err != nil {
+		return err
+	}
+	if _, ok := p.kinds[name]; ok {
+		return p.errorf("%s is declared twice", name)
+	}
+	p.kinds[name] = kind
+
+	text := p.next()
+	if text == "(" {
+		if _, err = p.attributes(); err != nil {
+			return err
+		}
+		text = p.next()
+	}
+	if text != "{" {
+		return p.errorf("expected \"{\", got %q", text)
+	}
+
+	d := decl{kind: kind, name: name}
+	for {
+		if p.s.Scan() != scanner.Ident {
+			if p.s.TokenText() == "}" {
+				break
+			}
+			return p.errorf("expected field name, got %q", p.s.TokenText())
+		}
+		f := field{name: p.s.TokenText(), pos: p.s.Position}
+		if err = p.expect(":"); err != nil {
+			return err
+		}
+		if f.typ, err = p.fieldType(); err != nil {
+			return err
+		}
+
+		deprecated := false
+		text = p.next()
+		if text == "=" {
+			// the default only matters to FlatBuffers, benc always writes the value
+			for text != "(" && text != ";" {
+				if p.s.Scan() == scanner.EOF || p.err != nil {
+					return p.errorf("unterminated field %s", f.name)
+				}
+				text = p.s.TokenText()
+			}
+		}
+		if text == "(" {
+			if deprecated, err = p.attributes(); err != nil {
+				return err
+			}
+			text = p.next()
+		}
+		if text != ";" {
+			return p.errorf("expected \";\", got %q", text)
+		}
+		if !deprecated {
+			d.fields = append(d.fields, f)
+		}
+	}
+	p.decls = append(p.decls, d)
+	return nil
+}
+
+// fieldType parses a type like "short", "[Monster]", "[float:3]" or "MyGame.Vec3" into its source text.
+func (p *parser) fieldType() (string, error) {
+	if tok := p.s.Scan(); p.s.TokenText() != "[" {
+		if tok != scanner.Ident {
+			return "", p.errorf("expected type, got %q", p.s.TokenText())
+		}
+		return p.qualifiedRest(p.s.TokenText())
+	}
+	elt, err := p.fieldType()
+	if err != nil {
+		return "", err
+	}
+	text := p.next()
+	if text == ":" {
+		if p.s.Scan() != scanner.Int {
+			return "", p.errorf("expected array length, got %q", p.s.TokenText())
+		}
+		elt += ":" + p.s.TokenText()
+		text = p.next()
+	}
+	if text != "]" {
+		return "", p.errorf("expected \"]\", got %q", text)
+	}
+	return "[" + elt + "]", nil
+}
+
+// attributes skips the attributes after "(" up to the closing ")" and reports whether one of them is deprecated.
+func (p *parser) attributes() (deprecated bool, err error) {
+	for {
+		tok := p.s.Scan()
+		switch {
+		case tok == scanner.EOF || p.err != nil:
+			return false, p.errorf("unterminated attributes")
+		case p.s.TokenText() == ")":
+			return deprecated, nil
+		case p.s.TokenText() == "deprecated":
+			deprecated = true
+		}
+	}
+}
+
+// parseEnum parses `enum Name : type { ... }` or `union Name { ... }`, of which only the name
+// and the underlying type matter.
+func (p *parser) parseEnum(kind string) error {
+	name, err := p.ident()
+	if err != nil {
+		return err
+	}
+	if _, ok := p.kinds[name]; ok {
+		return p.errorf("%s is declared twice", name)
+	}
+	p.kinds[name] = kind
+
+	if kind == "enum" {
+		if err = p.expect(":"); err != nil {
+			return err
+		}
+		underlying, err := p.ident()
+		if err != nil {
+			return err
+		}
+		goType, ok := scalarTypes[underlying]
+		if !ok || goType == "string" || goType == "bool" || strings.HasPrefix(goType, "float") {
+			return p.errorf("enum %s has no integer type", name)
+		}
+		p.enums[name] = goType
+	}
+	return p.skipPast("}")
+}
+
+// typeExpr resolves the type 'typ' of a field. References to tables are pointers in tables.
+func (p *parser) typeExpr(typ string, inTable bool) (ast.Expr, error) {
+	if strings.HasPrefix(typ, "[") {
+		inner := typ[1 : len(typ)-1]
+		eltType, length, fixed := cutArrayLength(inner)
+		if strings.HasPrefix(eltType, "[") {
+			return nil, fmt.Errorf("nested vectors are not allowed in FlatBuffers")
+		}
+		elt, err := p.typeExpr(eltType, false)
+		if err != nil {
+			return nil, err
+		}
+		if id, ok := elt.(*ast.Ident); ok && id.Name == "uint8" {
+			elt = &ast.Ident{Name: "byte"}
+		}
+		arr := &ast.ArrayType{Elt: elt}
+		if fixed {
+			arr.Len = &ast.BasicLit{Kind: token.INT, Value: length}
+		}
+		return arr, nil
+	}
+
+	if goType, ok := scalarTypes[typ]; ok {
+		return &ast.Ident{Name: goType}, nil
+	}
+	name := typ[strings.LastIndex(typ, ".")+1:]
+	switch p.kinds[name] {
+	case "enum":
+		return &ast.Ident{Name: p.enums[name]}, nil
+	case "struct":
+		return &ast.Ident{Name: name}, nil
+	case "table":
+		if inTable {
+			return &ast.StarExpr{X: &ast.Ident{Name: name}}, nil
+		}
+		return &ast.Ident{Name: name}, nil
+	case "union":
+		return nil, fmt.Errorf("union %s is not supported", name)
+	}
+	return nil, fmt.Errorf("unknown type %s", typ)
+}
+
+// cutArrayLength splits "float:3" into "float" and "3".
+func cutArrayLength(s string) (elt, length string, fixed bool) {
+	i := strings.LastIndex(s, ":")
+	if i < 0 {
+		return s, "", false
+	}
+	return s[:i], s[i+1:], true
+}
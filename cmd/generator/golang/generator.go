@@ -5,111 +5,1061 @@ import (
 	"fmt"
 	"go/ast"
 	"go/format"
+	"go/scanner"
+	"go/token"
 	"log"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
+	"unicode"
 
+	"github.com/banditmoscow1337/benc/cmd/dynamic"
 	"github.com/banditmoscow1337/benc/cmd/generator/common"
+	bstd "github.com/banditmoscow1337/benc/std/golang"
 )
 
+// Options configures the go backend.
+type Options struct {
+	// Package is the directory of the package the code is generated into. If it is empty, the
+	// code is generated into the package of the schema, as methods of the schema types.
+	// Otherwise the functions Size<T>, Marshal<T> and Unmarshal<T> are generated instead,
+	// keeping the schema package free of any serialization code.
+	Package string
+	// SchemaImport is the import path of the schema package, required if Package is set.
+	SchemaImport string
+	// Canonical marshals maps and variants with their entries sorted, so identical values
+	// marshal to identical bytes, see the canonical mode of bstd.
+	Canonical bool
+	// SizeHistogram reports the size of every marshalled message to the hook of bstd.SetSizeHook.
+	SizeHistogram bool
+	// SchemaHeader generates SizeWithSchema and MarshalWithSchema, which write the compressed
+	// schema in a bstd.SchemaHeader before the message.
+	SchemaHeader bool
+}
+
 type generator struct {
 	*common.Context
 	buf bytes.Buffer
+	// schemaPkg and schemaImport are the name and import path of the schema package,
+	// if the code is generated into another package.
+	schemaPkg, schemaImport string
+	// varint selects the varint encoding of the integers in the field currently generated,
+	// dict the string dictionary of its strings and zone the zone keeping encoding of its times.
+	// utf8 validates its strings when unmarshalling and cropped unmarshals its strings and
+	// byte slices without copying them. bfloat16 selects the experimental bfloat16 encoding
+	// of its float32s and nul the NUL-terminated encoding of its strings.
+	varint, dict, zone, utf8, cropped, bfloat16, nul bool
+	// streaming is set while DecodeFrom is generated, whose reader reuses its buffer, so
+	// cropped fields are copied there.
+	streaming bool
+	// precision is the time.Duration constant the times of the field currently generated are
+	// truncated to, see common.FieldPrecision.
+	precision string
+	// compact is the unit of the compact encoding of the times of the field currently
+	// generated, see common.FieldCompactTime.
+	compact string
+	// duplicates is the bstd.DuplicateKeyPolicy constant of the maps of the field currently
+	// unmarshalled, see common.FieldDuplicates.
+	duplicates string
+	// canonical is Options.Canonical, sizeHistogram Options.SizeHistogram and schemaHeader
+	// Options.SchemaHeader.
+	canonical, sizeHistogram, schemaHeader bool
 }
 
-func New(ctx *common.Context) common.Generator {
-	return &generator{Context: ctx}
+func New(ctx *common.Context, opts Options) common.Generator {
+	out := *ctx
+	out.Variants = true
+	g := &generator{Context: &out, canonical: opts.Canonical, sizeHistogram: opts.SizeHistogram, schemaHeader: opts.SchemaHeader}
+	if opts.Package != "" {
+		out.OutputDir = opts.Package
+		out.PkgName = filepath.Base(opts.Package)
+		g.schemaPkg, g.schemaImport = ctx.PkgName, opts.SchemaImport
+	}
+	return g
 }
 
 func (g *generator) Generate() (err error) {
-	g.printf("// Code generated by benc generator; DO NOT EDIT.\n\n")
-	g.printf("package %s\n\n", g.PkgName)
-	g.printf("import (\n")
-	g.printf("\tbstd \"github.com/banditmoscow1337/benc/std/golang\"\n")
-	g.printf(")\n\n")
-
+	if g.DeclareTypes {
+		if g.schemaImport != "" {
+			return fmt.Errorf("-go-package needs a Go schema, the types of %s are declared by the generated code", g.InputFile)
+		}
+		if err = g.declareTypes(); err != nil {
+			return
+		}
+	}
+	if err = g.generateGoEnums(); err != nil {
+		return
+	}
 	for _, ts := range g.Types {
 		if err = g.generateGoMethods(ts); err != nil {
 			err = fmt.Errorf("generating methods for %s: %w", ts.Name.Name, err)
 			return
 		}
 	}
+	if err = g.generateGoSchema(); err != nil {
+		return
+	}
+
+	imports := []string{`bstd "github.com/banditmoscow1337/benc/std/golang"`}
+	if referencesPackage(g.buf.String(), "io") {
+		imports = append(imports, `"io"`)
+	}
+	if referencesPackage(g.buf.String(), "strconv") {
+		imports = append(imports, `"strconv"`)
+	}
+	g.writeHeader(imports...)
 
 	return g.formatGo("benc")
 }
 
+// declareTypes writes the declarations of the schema types, for schemas that aren't Go source.
+func (g *generator) declareTypes() error {
+	for _, ts := range g.Types {
+		decl := &ast.GenDecl{Tok: token.TYPE, Specs: []ast.Spec{ts}}
+		if err := format.Node(&g.buf, token.NewFileSet(), decl); err != nil {
+			return fmt.Errorf("declaring %s: %w", ts.Name.Name, err)
+		}
+		g.printf("\n\n")
+	}
+	return nil
+}
+
+// generateGoEnums generates the bstd.EnumTable of every //benc:enum type, with the String
+// method and the Parse<T> function of the enum, or the String<T> function replacing the method,
+// if the code is generated into another package.
+func (g *generator) generateGoEnums() error {
+	for _, e := range g.SortedEnums() {
+		name := e.Name
+		if err := g.checkExported(name); err != nil {
+			return err
+		}
+		values := make([]string, len(e.Values))
+		quoted := make([]string, len(e.Values))
+		for i, v := range e.Values {
+			if err := g.checkExported(name + "." + v); err != nil {
+				return err
+			}
+			values[i], quoted[i] = v, strconv.Quote(v)
+			if g.schemaPkg != "" {
+				values[i] = g.schemaPkg + "." + v
+			}
+		}
+		typ, table, receiver := g.qualify(name), enumTable(name), g.receiverName(name)
+
+		g.printf("// %s maps the %s constants to their ordinals on the wire and to their names.\n", table, name)
+		g.printf("var %s = bstd.NewEnumTable([]%s{%s}, %s)\n\n", table, typ, strings.Join(values, ", "), strings.Join(quoted, ", "))
+		if g.schemaPkg == "" {
+			g.printf("// String returns the name of the %s constant.\n", name)
+			g.printf("func (%s %s) String() string {\n\treturn %s.Name(%s)\n}\n\n", receiver, name, table, receiver)
+		} else {
+			g.printf("// String%s returns the name of the %s constant.\n", name, name)
+			g.printf("func String%s(%s %s) string {\n\treturn %s.Name(%s)\n}\n\n", name, receiver, typ, table, receiver)
+		}
+		g.printf("// Parse%s returns the %s constant named s and whether there is one.\n", name, name)
+		g.printf("func Parse%s(s string) (%s, bool) {\n\treturn %s.Parse(s)\n}\n\n", name, typ, table)
+	}
+	return nil
+}
+
+// enumTable returns the name of the generated bstd.EnumTable of the enum name.
+func enumTable(name string) string {
+	return "bencEnum" + name
+}
+
 func (g *generator) generateGoMethods(ts *ast.TypeSpec) error {
+	var err error
 	switch ts.Type.(type) {
 	case *ast.StructType:
-		return g.generateGoStructMethods(ts)
+		err = g.generateGoStructMethods(ts)
 	case *ast.MapType:
-		return g.generateGoMapAliasMethods(ts)
+		err = g.generateGoMapAliasMethods(ts)
+	default:
+		return nil
+	}
+	if err == nil && g.schemaHeader {
+		g.generateGoMarshalWithSchema(ts)
+	}
+	return err
+}
+
+// generateGoSchema generates the variable holding the schema compressed, as
+// bstd.SchemaHeader.Schema, if the code is generated with -go-schema-header.
+func (g *generator) generateGoSchema() error {
+	if !g.schemaHeader {
+		return nil
+	}
+	source, err := g.ReadInput()
+	if err != nil {
+		return fmt.Errorf("reading the schema for its header: %w", err)
 	}
+	h := bstd.NewSchemaHeader(g.schemaFormat(), "", source)
+	g.printf("// %s is the schema of the types of this file compressed by DEFLATE, which\n", g.schemaVar())
+	g.printf("// MarshalWithSchema writes into the bstd.SchemaHeader before a message.\n")
+	g.printf("var %s = []byte(%s)\n\n", g.schemaVar(), quoteBytes(h.Schema))
 	return nil
 }
 
+// quoteBytes returns the Go string literal of the bytes b, escaping every byte, which isn't
+// printable ASCII, so binary data isn't read as UTF-8.
+func quoteBytes(b []byte) string {
+	var sb strings.Builder
+	sb.WriteByte('"')
+	for _, c := range b {
+		if c >= ' ' && c <= '~' && c != '"' && c != '\\' {
+			sb.WriteByte(c)
+		} else {
+			fmt.Fprintf(&sb, "\\x%02x", c)
+		}
+	}
+	sb.WriteByte('"')
+	return sb.String()
+}
+
+// schemaVar returns the name of the variable holding the compressed schema, which is unique
+// among the schemas of a package, e.g. bencSchemaUserEvents for user_events.go.
+func (g *generator) schemaVar() string {
+	base := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return '_'
+	}, g.BaseName)
+	return "bencSchema" + common.ConvertName(base, common.NamingPascal)
+}
+
+// schemaFormat returns the format of the schema, the extension of its file, e.g. "go".
+func (g *generator) schemaFormat() string {
+	return strings.TrimPrefix(filepath.Ext(g.InputFile), ".")
+}
+
+// generateGoMarshalWithSchema generates SizeWithSchema and MarshalWithSchema of the type ts,
+// which write the message after its bstd.SchemaHeader.
+func (g *generator) generateGoMarshalWithSchema(ts *ast.TypeSpec) {
+	name := ts.Name.Name
+	receiver := g.receiverName(name)
+	header := fmt.Sprintf("bstd.SchemaHeader{Version: bstd.WireFormatVersion, Format: %q, Type: %q, Schema: %s}", g.schemaFormat(), name, g.schemaVar())
+	self := receiver
+	if g.schemaPkg != "" {
+		self = "*" + receiver
+	}
+
+	g.printf("// SizeWithSchema returns the bytes needed by MarshalWithSchema.\n")
+	g.funcDecl(name, receiver, "SizeWithSchema", "", "int")
+	g.printf("\treturn bstd.SizeSchemaHeader(%s) + %s\n}\n\n", header, g.methodCall(name, "Size", self))
+
+	g.printf("// MarshalWithSchema marshals the %s after a bstd.SchemaHeader with the schema, so it is\n", name)
+	g.printf("// decoded without the schema, e.g. by `benc cat -schema-header`.\n")
+	g.funcDecl(name, receiver, "MarshalWithSchema", "tn int, b []byte", "int")
+	g.printf("\tn := bstd.MarshalSchemaHeader(tn, b, %s)\n", header)
+	g.printf("\treturn %s\n}\n\n", g.methodCall(name, "Marshal", self, "n", "b"))
+}
+
 func (g *generator) generateGoStructMethods(ts *ast.TypeSpec) error {
 	name := ts.Name.Name
-	receiver := strings.ToLower(name[:1]) + name[1:]
+	receiver := g.receiverName(name)
 	supportedFields := g.GetSupportedFields(ts)
 
+	if err := g.checkExported(name); err != nil {
+		return err
+	}
+	for _, field := range supportedFields {
+		if err := g.checkMapKeys(field.Type); err != nil {
+			return err
+		}
+		if g.IsVarintField(field) && !hasVarintInts(field.Type, false) {
+			return fmt.Errorf("varint field %s contains no 16, 32 or 64-bit integers", g.ExprToString(field.Type))
+		}
+		if g.IsZigZagField(field) && !hasVarintInts(field.Type, true) {
+			return fmt.Errorf("zigzag field %s contains no signed integers", g.ExprToString(field.Type))
+		}
+		if g.IsRLEField(field) && !isRLESlice(field.Type) {
+			return fmt.Errorf("rle field %s is no slice of bools, bytes or integers", g.ExprToString(field.Type))
+		}
+		if g.IsSparseField(field) {
+			if !isSparseSlice(field.Type) {
+				return fmt.Errorf("sparse field %s is no slice of bools, numbers or strings", g.ExprToString(field.Type))
+			}
+			if g.IsRLEField(field) || g.IsDeltaField(field) || g.IsGorillaField(field) || g.IsDictField(field) {
+				return fmt.Errorf("sparse field %s can't be rle, delta, gorilla or dict encoded as well", g.ExprToString(field.Type))
+			}
+		}
+		if g.IsDeltaField(field) {
+			if !isDeltaSlice(field.Type) {
+				return fmt.Errorf("delta field %s is no slice of integers other than bytes", g.ExprToString(field.Type))
+			}
+			if g.IsVarintField(field) || g.IsRLEField(field) {
+				return fmt.Errorf("delta field %s can't be varint or rle encoded as well", g.ExprToString(field.Type))
+			}
+		}
+		if g.IsDictField(field) && !hasStrings(field.Type) {
+			return fmt.Errorf("dict field %s contains no strings", g.ExprToString(field.Type))
+		}
+		if g.IsDictField(field) && g.canonical && g.containsMap(field.Type, map[string]bool{}) {
+			return fmt.Errorf("dict field %s contains a map, whose entries -go-canonical reorders", g.ExprToString(field.Type))
+		}
+		if g.IsUTF8Field(field) {
+			if !hasStrings(field.Type) {
+				return fmt.Errorf("utf8 field %s contains no strings", g.ExprToString(field.Type))
+			}
+			if g.IsDictField(field) {
+				return fmt.Errorf("utf8 field %s can't be dict encoded as well", g.ExprToString(field.Type))
+			}
+		}
+		if g.IsCroppedField(field) {
+			if !hasStringsOrBytes(field.Type) {
+				return fmt.Errorf("cropped field %s contains no strings or byte slices", g.ExprToString(field.Type))
+			}
+			if g.IsDictField(field) || g.IsUTF8Field(field) || g.IsRLEField(field) {
+				return fmt.Errorf("cropped field %s can't be dict, utf8 or rle encoded as well", g.ExprToString(field.Type))
+			}
+			if _, ok := g.TypeDirective(ts, "flags"); ok {
+				return fmt.Errorf("cropped field %s can't be in a flags struct, which DecodeFrom unmarshals as a whole", g.ExprToString(field.Type))
+			}
+		}
+		if g.IsBFloat16Field(field) {
+			if !hasFloat32s(field.Type) {
+				return fmt.Errorf("bfloat16 field %s contains no float32", g.ExprToString(field.Type))
+			}
+			if g.IsSparseField(field) || g.IsRLEField(field) {
+				return fmt.Errorf("bfloat16 field %s can't be sparse or rle encoded as well", g.ExprToString(field.Type))
+			}
+		}
+		if g.IsNulField(field) {
+			if !hasStrings(field.Type) {
+				return fmt.Errorf("nul field %s contains no strings", g.ExprToString(field.Type))
+			}
+			if g.IsDictField(field) || g.IsUTF8Field(field) || g.IsCroppedField(field) || g.IsSparseField(field) {
+				return fmt.Errorf("nul field %s can't be dict, utf8, cropped or sparse encoded as well", g.ExprToString(field.Type))
+			}
+		}
+		if g.IsZoneField(field) && !hasTimes(field.Type) {
+			return fmt.Errorf("zone field %s contains no time.Time", g.ExprToString(field.Type))
+		}
+		if precision, err := g.FieldPrecision(field); err != nil {
+			return err
+		} else if precision != "" && !hasTimes(field.Type) {
+			return fmt.Errorf("precision field %s contains no time.Time", g.ExprToString(field.Type))
+		} else if g.FieldCompactTime(field) != "" && g.IsZoneField(field) {
+			return fmt.Errorf("compact time field %s can't keep the zone of its times", g.ExprToString(field.Type))
+		}
+		if duplicates, err := g.FieldDuplicates(field); err != nil {
+			return err
+		} else if duplicates != "" && !hasMaps(field.Type) {
+			return fmt.Errorf("duplicates field %s contains no map", g.ExprToString(field.Type))
+		}
+		if g.IsGorillaField(field) {
+			if g.ExprToString(field.Type) != "[]float64" {
+				return fmt.Errorf("gorilla field %s is no []float64", g.ExprToString(field.Type))
+			}
+			if g.IsRLEField(field) || g.IsDeltaField(field) {
+				return fmt.Errorf("gorilla field %s can't be rle or delta encoded as well", g.ExprToString(field.Type))
+			}
+		}
+		for _, fName := range field.Names {
+			if err := g.checkExported(name + "." + fName.Name); err != nil {
+				return err
+			}
+		}
+	}
+	layout, words, err := g.FlagLayout(ts)
+	if err != nil {
+		return err
+	}
+
 	// Size Method
-	g.printf("func (%s *%s) Size() (s int) {\n", receiver, name)
+	g.funcDecl(name, receiver, "Size", "", "(s int)")
 	for _, field := range supportedFields {
 		if g.IsUnsupportedType(field.Type) {
 			continue
 		}
 		for _, fName := range field.Names {
-			g.printf("\ts += %s\n", g.getGoSizeExpr(field.Type, fmt.Sprintf("%s.%s", receiver, fName.Name)))
+			if fb, ok := layout[fName.Name]; ok {
+				if fb.Word == 0 && fb.Shift == 0 {
+					g.printf("\ts += %d * bstd.Size%s()\n", words, g.flagWord(ts))
+				}
+				continue
+			}
+			g.printf("\ts += %s\n", g.fieldSizeExpr(field, fmt.Sprintf("%s.%s", receiver, fName.Name)))
 		}
 	}
 	g.printf("\treturn\n}\n\n")
 
 	// Marshal Method
-	g.printf("func (%s *%s) Marshal(tn int, b []byte) (n int) {\n\tn = tn\n", receiver, name)
+	if err := g.generateGoExampleDoc(name); err != nil {
+		return err
+	}
+	g.funcDecl(name, receiver, "Marshal", "tn int, b []byte", "(n int)")
+	g.printf("\tn = tn\n")
 	for _, field := range supportedFields {
 		if g.IsUnsupportedType(field.Type) {
 			continue
 		}
 		for _, fName := range field.Names {
-			g.printf("\tn = %s\n", g.getGoMarshalExpr(field.Type, "n", "b", fmt.Sprintf("%s.%s", receiver, fName.Name)))
+			if fb, ok := layout[fName.Name]; ok {
+				if fb.Word == 0 && fb.Shift == 0 {
+					g.generateGoFlagsMarshal(ts, receiver, layout, words)
+				}
+				continue
+			}
+			g.printf("\tn = %s\n", g.fieldMarshalExpr(field, "n", "b", fmt.Sprintf("%s.%s", receiver, fName.Name)))
 		}
 	}
+	g.observeSize(name)
 	g.printf("\treturn n\n}\n\n")
 
 	// Unmarshal Method
-	g.printf("func (%s *%s) Unmarshal(tn int, b []byte) (n int, err error) {\n\tn = tn\n", receiver, name)
+	g.funcDecl(name, receiver, "Unmarshal", "tn int, b []byte", "(n int, err error)")
+	g.printf("\tn = tn\n")
 	for _, field := range supportedFields {
 		if g.IsUnsupportedType(field.Type) {
 			continue
 		}
 		for _, fName := range field.Names {
-			g.printf("\tif %s; err != nil {\n\t\treturn\n\t}\n", g.getGoUnmarshalExpr(field.Type, "n", "b", fmt.Sprintf("%s.%s", receiver, fName.Name)))
+			if fb, ok := layout[fName.Name]; ok {
+				if fb.Word == 0 && fb.Shift == 0 {
+					g.generateGoFlagsUnmarshal(ts, receiver, layout, words)
+				}
+				continue
+			}
+			g.printf("\tif %s; err != nil {\n\t\treturn\n\t}\n", g.fieldUnmarshalExpr(field, "n", "b", fmt.Sprintf("%s.%s", receiver, fName.Name)))
 		}
 	}
 	g.printf("\treturn\n}\n\n")
+
+	// Skip Function
+	g.printf("// Skip%s skips a marshalled %s without unmarshalling it, see bstd.Validate.\n", name, name)
+	g.printf("func Skip%s(n int, b []byte) (int, error) {\n\tvar err error\n", name)
+	for _, field := range supportedFields {
+		if g.IsUnsupportedType(field.Type) {
+			continue
+		}
+		for _, fName := range field.Names {
+			if fb, ok := layout[fName.Name]; ok {
+				if fb.Word == 0 && fb.Shift == 0 {
+					g.printf("\tif n, err = bstd.SkipArray(n, b, %d, bstd.Skip%s); err != nil {\n\t\treturn 0, err\n\t}\n", words, g.flagWord(ts))
+				}
+				continue
+			}
+			g.printf("\tif n, err = %s(n, b); err != nil {\n\t\treturn 0, err\n\t}\n", g.fieldSkipExpr(field))
+		}
+	}
+	g.printf("\treturn n, nil\n}\n\n")
+
+	g.generateGoEncodeTo(ts, receiver, supportedFields, len(layout) > 0)
+	g.generateGoDecodeFrom(ts, receiver, supportedFields, len(layout) > 0)
+	g.generateGoMerge(ts)
+	g.generateGoBuilder(ts, layout, words, g.flagWord(ts))
+	if err := g.generateGoLabels(ts, receiver); err != nil {
+		return err
+	}
+	return g.generateGoMarshalTruncated(ts, receiver)
+}
+
+// flagFields returns the names of the packed fields of ts in the order of their bits.
+func (g *generator) flagFields(ts *ast.TypeSpec, layout map[string]common.FlagBits) []string {
+	var names []string
+	for _, field := range ts.Type.(*ast.StructType).Fields.List {
+		for _, fName := range field.Names {
+			if _, ok := layout[fName.Name]; ok {
+				names = append(names, fName.Name)
+			}
+		}
+	}
+	return names
+}
+
+// flagWord returns the bstd name of the flag words of the //benc:flags struct ts, Uint32 or
+// Byte, see common.FlagWordSize.
+func (g *generator) flagWord(ts *ast.TypeSpec) string {
+	if g.FlagWordSize(ts) == 1 {
+		return "Byte"
+	}
+	return "Uint32"
+}
+
+// boolWord returns the fields of the flag word w, if it holds bools only.
+func (g *generator) boolWord(ts *ast.TypeSpec, names []string, layout map[string]common.FlagBits, w int) ([]string, bool) {
+	var fields []string
+	for _, f := range names {
+		if layout[f].Word != w {
+			continue
+		}
+		if layout[f].Bits != 1 || g.fieldTypeName(ts, f) != "bool" {
+			return nil, false
+		}
+		fields = append(fields, f)
+	}
+	return fields, true
+}
+
+// generateGoFlagsMarshal generates the marshalling of the flag words of a //benc:flags struct.
+// Flag bytes holding bools only are written by bstd.MarshalFlags.
+func (g *generator) generateGoFlagsMarshal(ts *ast.TypeSpec, receiver string, layout map[string]common.FlagBits, words int) {
+	names := g.flagFields(ts, layout)
+	word := g.flagWord(ts)
+	declared := false
+	for w := range words {
+		if fields, ok := g.boolWord(ts, names, layout, w); ok && word == "Byte" {
+			for i, f := range fields {
+				fields[i] = receiver + "." + f
+			}
+			g.printf("\tn = bstd.MarshalFlags(n, b, %s)\n", strings.Join(fields, ", "))
+			continue
+		}
+		if declared {
+			g.printf("\tbencFlags = 0\n")
+		} else {
+			g.printf("\tvar bencFlags %s\n", strings.ToLower(word))
+			declared = true
+		}
+		for _, f := range names {
+			fb := layout[f]
+			if fb.Word != w {
+				continue
+			}
+			if fb.Bits == 1 && g.fieldTypeName(ts, f) == "bool" {
+				g.printf("\tif %s.%s {\n\t\tbencFlags |= 1 << %d\n\t}\n", receiver, f, fb.Shift)
+			} else {
+				g.printf("\tbencFlags |= (%s(%s.%s) & %#x) << %d\n", strings.ToLower(word), receiver, f, uint64(1)<<fb.Bits-1, fb.Shift)
+			}
+		}
+		g.printf("\tn = bstd.Marshal%s(n, b, bencFlags)\n", word)
+	}
+}
+
+// generateGoFlagsUnmarshal generates the unmarshalling of the flag words of a //benc:flags struct.
+// Flag bytes holding bools only are read by bstd.UnmarshalFlags.
+func (g *generator) generateGoFlagsUnmarshal(ts *ast.TypeSpec, receiver string, layout map[string]common.FlagBits, words int) {
+	names := g.flagFields(ts, layout)
+	word := g.flagWord(ts)
+	declared := false
+	for w := range words {
+		if fields, ok := g.boolWord(ts, names, layout, w); ok && word == "Byte" {
+			for i, f := range fields {
+				fields[i] = "&" + receiver + "." + f
+			}
+			g.printf("\tif n, err = bstd.UnmarshalFlags(n, b, %s); err != nil {\n\t\treturn\n\t}\n", strings.Join(fields, ", "))
+			continue
+		}
+		if !declared {
+			g.printf("\tvar bencFlags %s\n", strings.ToLower(word))
+			declared = true
+		}
+		g.printf("\tif n, bencFlags, err = bstd.Unmarshal%s(n, b); err != nil {\n\t\treturn\n\t}\n", word)
+		for _, f := range names {
+			fb := layout[f]
+			if fb.Word != w {
+				continue
+			}
+			if typ := g.fieldTypeName(ts, f); typ == "bool" {
+				g.printf("\t%s.%s = bencFlags&(1<<%d) != 0\n", receiver, f, fb.Shift)
+			} else {
+				g.printf("\t%s.%s = %s(bencFlags >> %d & %#x)\n", receiver, f, typ, fb.Shift, uint64(1)<<fb.Bits-1)
+			}
+		}
+	}
+}
+
+// intBits are the sizes of the unsigned integers packed into flag words, see common.FlagLayout.
+var intBits = map[string]int{"byte": 8, "uint8": 8, "uint16": 16, "uint32": 32, "uint64": 64, "uint": 64}
+
+// fieldTypeName returns the type of the field name of the struct ts.
+func (g *generator) fieldTypeName(ts *ast.TypeSpec, name string) string {
+	for _, field := range ts.Type.(*ast.StructType).Fields.List {
+		for _, fName := range field.Names {
+			if fName.Name == name {
+				return g.ExprToString(field.Type)
+			}
+		}
+	}
+	return ""
+}
+
+// generateGoMerge generates Merge<T>, which copies the fields selected by a field mask from src to dst.
+// Fields of nested schema structs can be selected one by one, all other fields are assigned as a whole.
+func (g *generator) generateGoMerge(ts *ast.TypeSpec) {
+	name := ts.Name.Name
+	g.printf("// Merge%s copies the fields of src selected by mask into dst.\n", name)
+	g.printf("// Slices, maps and pointers are assigned, not copied.\n")
+	g.printf("func Merge%s(dst, src *%s, mask bstd.FieldMask) {\n", name, g.qualify(name))
+	for _, field := range ts.Type.(*ast.StructType).Fields.List {
+		for _, fName := range field.Names {
+			if fName.Name == "_" {
+				continue
+			}
+			f := fName.Name
+			g.printf("\tif mask.Contains(%q) {\n\t\tdst.%s = src.%s\n\t}", f, f, f)
+
+			nested, isPtr := g.nestedStruct(field.Type)
+			switch {
+			case nested == "":
+				g.printf("\n")
+			case isPtr:
+				g.printf(" else if sub := mask.Sub(%q); len(sub.Paths) > 0 && src.%s != nil {\n", f, f)
+				g.printf("\t\tif dst.%s == nil {\n\t\t\tdst.%s = new(%s)\n\t\t}\n", f, f, g.qualify(nested))
+				g.printf("\t\tMerge%s(dst.%s, src.%s, sub)\n\t}\n", nested, f, f)
+			default:
+				g.printf(" else if sub := mask.Sub(%q); len(sub.Paths) > 0 {\n", f)
+				g.printf("\t\tMerge%s(&dst.%s, &src.%s, sub)\n\t}\n", nested, f, f)
+			}
+		}
+	}
+	g.printf("}\n\n")
+}
+
+// observeSize reports the size of the message marshalled by the Marshal method of the type
+// name to the size hook, if the code is generated with -go-size-histogram.
+func (g *generator) observeSize(name string) {
+	if g.sizeHistogram {
+		g.printf("\tbstd.ObserveSize(%q, n-tn)\n", name)
+	}
+}
+
+// generateGoEncodeTo generates EncodeTo, which writes the bytes of Marshal to w field by field,
+// so a large message is streamed without marshalling it into one buffer. Strings, byte slices
+// and nested structs are written as they are, the other fields are marshalled into a scratch
+// buffer by bstd.MarshalTo, as is the whole message, if whole is set, e.g. for flag words.
+func (g *generator) generateGoEncodeTo(ts *ast.TypeSpec, receiver string, fields []*ast.Field, whole bool) {
+	name := ts.Name.Name
+	g.funcDecl(name, receiver, "EncodeTo", "w io.Writer", "(n int64, err error)")
+	if whole {
+		self := receiver
+		if g.schemaPkg != "" {
+			self = "*" + receiver
+		}
+		marshal := g.methodCall(name, "Marshal", self, "n", "b")
+		g.printf("\twn, err := bstd.MarshalTo(w, %s, func(n int, b []byte) int { return %s })\n", g.methodCall(name, "Size", self), marshal)
+		g.printf("\treturn int64(wn), err\n}\n\n")
+		return
+	}
+
+	var body strings.Builder
+	var wn, en bool
+	for _, field := range fields {
+		if g.IsUnsupportedType(field.Type) {
+			continue
+		}
+		for _, fName := range field.Names {
+			varName := fmt.Sprintf("%s.%s", receiver, fName.Name)
+			marshal := g.fieldMarshalExpr(field, "n", "b", varName)
+			nested, isPtr := g.nestedStruct(field.Type)
+			switch {
+			case marshal == fmt.Sprintf("bstd.MarshalString(n, b, %s)", varName):
+				fmt.Fprintf(&body, "\twn, err = bstd.MarshalStringTo(w, %s)\n", varName)
+				wn = true
+			case marshal == fmt.Sprintf("bstd.MarshalBytes(n, b, %s)", varName):
+				fmt.Fprintf(&body, "\twn, err = bstd.MarshalBytesTo(w, %s)\n", varName)
+				wn = true
+			case nested != "" && !isPtr && marshal == g.methodCall(nested, "Marshal", varName, "n", "b"):
+				fmt.Fprintf(&body, "\ten, err = %s\n", g.methodCall(nested, "EncodeTo", varName, "w"))
+				fmt.Fprintf(&body, "\tif n += en; err != nil {\n\t\treturn\n\t}\n")
+				en = true
+				continue
+			default:
+				fmt.Fprintf(&body, "\twn, err = bstd.MarshalTo(w, %s, func(n int, b []byte) int { return %s })\n", g.fieldSizeExpr(field, varName), marshal)
+				wn = true
+			}
+			fmt.Fprintf(&body, "\tif n += int64(wn); err != nil {\n\t\treturn\n\t}\n")
+		}
+	}
+	if wn {
+		g.printf("\tvar wn int\n")
+	}
+	if en {
+		g.printf("\tvar en int64\n")
+	}
+	g.printf("%s", body.String())
+	if g.sizeHistogram {
+		g.printf("\tbstd.ObserveSize(%q, int(n))\n", name)
+	}
+	g.printf("\treturn\n}\n\n")
+}
+
+// generateGoDecodeFrom generates DecodeFrom, which reads the message written by Marshal from a
+// bstd.Reader field by field, so a large message is unmarshalled without reading it into one
+// buffer. Strings, byte slices and nested structs are read by their own methods, the other
+// fields are unmarshalled from the buffer of the reader, as is the whole message, if whole is set.
+func (g *generator) generateGoDecodeFrom(ts *ast.TypeSpec, receiver string, fields []*ast.Field, whole bool) {
+	name := ts.Name.Name
+	g.streaming = true
+	defer func() { g.streaming = false }()
+	g.funcDecl(name, receiver, "DecodeFrom", "r *bstd.Reader", "(err error)")
+	if whole {
+		self := receiver
+		if g.schemaPkg != "" {
+			self = "*" + receiver
+		}
+		g.printf("\treturn r.Unmarshal(func(n int, b []byte) (int, error) { return %s })\n}\n\n", g.methodCall(name, "Unmarshal", self, "n", "b"))
+		return
+	}
+
+	// the reader ending after the first field ended in the middle of the message
+	ret := "return"
+	for _, field := range fields {
+		if g.IsUnsupportedType(field.Type) {
+			continue
+		}
+		for _, fName := range field.Names {
+			varName := fmt.Sprintf("%s.%s", receiver, fName.Name)
+			unmarshal := g.fieldUnmarshalExpr(field, "n", "b", varName)
+			nested, isPtr := g.nestedStruct(field.Type)
+			switch {
+			case unmarshal == fmt.Sprintf("n, %s, err = bstd.UnmarshalString(n, b)", varName):
+				g.printf("\tif %s, err = r.UnmarshalString(); err != nil {\n", varName)
+			case unmarshal == fmt.Sprintf("n, %s, err = bstd.UnmarshalBytesCopied(n, b)", varName):
+				g.printf("\tif %s, err = r.UnmarshalBytes(); err != nil {\n", varName)
+			case nested != "" && !isPtr && unmarshal == "n, err = "+g.methodCall(nested, "Unmarshal", varName, "n", "b"):
+				g.printf("\tif err = %s; err != nil {\n", g.methodCall(nested, "DecodeFrom", varName, "r"))
+			default:
+				g.printf("\tif err = r.Unmarshal(func(n int, b []byte) (int, error) { var err error; %s; return n, err }); err != nil {\n", unmarshal)
+			}
+			g.printf("\t\t%s\n\t}\n", ret)
+			ret = "return bstd.UnexpectedEOF(err)"
+		}
+	}
+	g.printf("\treturn\n}\n\n")
+}
+
+// generateGoLabels generates Labels for a //benc:metrics struct, which returns its label fields
+// as metric labels, named by the snake case of the field names.
+func (g *generator) generateGoLabels(ts *ast.TypeSpec, receiver string) error {
+	name := ts.Name.Name
+	_, metrics := g.TypeDirective(ts, "metrics")
+
+	var labels []string
+	for _, field := range ts.Type.(*ast.StructType).Fields.List {
+		for _, fName := range field.Names {
+			if metrics && fName.Name == "Labels" && g.schemaPkg == "" {
+				return fmt.Errorf("field %s.Labels collides with the generated Labels method", name)
+			}
+		}
+		if !g.IsLabelField(field) || len(field.Names) == 0 {
+			continue
+		}
+		if !metrics {
+			return fmt.Errorf("%s.%s: //benc:label needs //benc:metrics on %s", name, field.Names[0].Name, name)
+		}
+		for _, fName := range field.Names {
+			value, ok := labelValue(field.Type, fmt.Sprintf("%s.%s", receiver, fName.Name))
+			if !ok {
+				return fmt.Errorf("label field %s.%s is no string, bool or integer", name, fName.Name)
+			}
+			labels = append(labels, fmt.Sprintf("\t\t%q: %s,\n", common.ConvertName(fName.Name, common.NamingSnake), value))
+		}
+	}
+	if !metrics {
+		return nil
+	}
+	if len(labels) == 0 {
+		return fmt.Errorf("//benc:metrics struct %s has no label fields", name)
+	}
+
+	g.printf("// Labels returns the label fields of the %s as metric labels, e.g. prometheus.Labels.\n", name)
+	g.funcDecl(name, receiver, "Labels", "", "map[string]string")
+	g.printf("\treturn map[string]string{\n%s\t}\n}\n\n", strings.Join(labels, ""))
 	return nil
 }
 
+// truncatedBits are the bits of the unsigned integers a truncated field may have.
+var truncatedBits = map[string]int{"uint8": 8, "byte": 8, "uint16": 16, "uint32": 32, "uint64": 64, "uint": 64}
+
+// generateGoMarshalTruncated generates MarshalTruncated for a struct with a truncated field,
+// which drops the last elements of the slices and maps of the struct, the last field first,
+// until it fits into the buffer, and the constants of the bits of the truncated field.
+func (g *generator) generateGoMarshalTruncated(ts *ast.TypeSpec, receiver string) error {
+	name := ts.Name.Name
+	var flags, flagsType string
+	var fields []*ast.Field
+	var names []string
+	for _, field := range g.GetSupportedFields(ts) {
+		if g.IsTruncatedField(field) {
+			id, ok := field.Type.(*ast.Ident)
+			if !ok || truncatedBits[id.Name] == 0 {
+				return fmt.Errorf("truncated field %s is no unsigned integer", g.ExprToString(field.Type))
+			}
+			if flags != "" || len(field.Names) != 1 {
+				return fmt.Errorf("%s has more than one truncated field", name)
+			}
+			flags, flagsType = field.Names[0].Name, id.Name
+			continue
+		}
+		switch t := field.Type.(type) {
+		case *ast.ArrayType:
+			if t.Len != nil {
+				continue
+			}
+		case *ast.MapType:
+		default:
+			continue
+		}
+		for _, fName := range field.Names {
+			if fName.Name != "_" {
+				fields = append(fields, field)
+				names = append(names, fName.Name)
+			}
+		}
+	}
+	if flags == "" {
+		return nil
+	}
+	if len(names) == 0 {
+		return fmt.Errorf("%s has a truncated field, but no slices or maps", name)
+	}
+	if len(names) > truncatedBits[flagsType] {
+		return fmt.Errorf("%s has %d slices and maps, more than the %d bits of %s.%s", name, len(names), truncatedBits[flagsType], name, flags)
+	}
+
+	g.printf("// The bits of %s.%s, which MarshalTruncated sets for the fields it truncated.\n", name, flags)
+	g.printf("const (\n")
+	for i, f := range names {
+		if i == 0 {
+			g.printf("\t%sTruncated%s %s = 1 << iota\n", name, f, flagsType)
+		} else {
+			g.printf("\t%sTruncated%s\n", name, f)
+		}
+	}
+	g.printf(")\n\n")
+
+	g.printf("// MarshalTruncated marshals the %s into b, dropping the last elements of its slices and\n", name)
+	g.printf("// entries of its maps, the last field first, until it fits into b, and sets the bits of the\n")
+	g.printf("// truncated fields in %s. The %s itself is left as it is. It returns the bytes\n", flags, name)
+	g.printf("// marshalled, or bstd.ErrBufTooSmall if the %s doesn't fit with empty slices and maps.\n", name)
+	g.funcDecl(name, receiver, "MarshalTruncated", "b []byte", "(int, error)")
+	g.printf("\tfit := *%s\n", receiver)
+	size := g.methodCall(name, "Size", "fit")
+	g.printf("\tfor excess := %s - len(b); excess > 0; excess = %s - len(b) {\n\t\tswitch {\n", size, size)
+	for i := len(names) - 1; i >= 0; i-- {
+		f, field := names[i], fields[i]
+		fit := "FitSlice"
+		if _, ok := field.Type.(*ast.MapType); ok {
+			fit = "FitMap"
+		}
+		g.printf("\t\tcase len(fit.%s) > 0:\n", f)
+		g.printf("\t\t\tfit.%s = bstd.%s(fit.%s, %s-excess, func(v %s) int { return %s })\n", f, fit, f,
+			g.fieldSizeExpr(field, "fit."+f), g.qualify(g.ExprToString(field.Type)), g.fieldSizeExpr(field, "v"))
+		g.printf("\t\t\tfit.%s |= %sTruncated%s\n", flags, name, f)
+	}
+	g.printf("\t\tdefault:\n\t\t\treturn 0, bstd.ErrBufTooSmall\n\t\t}\n\t}\n")
+	g.printf("\treturn %s, nil\n}\n\n", g.methodCall(name, "Marshal", "fit", "0", "b"))
+	return nil
+}
+
+// labelValue returns the expression formatting varName of type expr as label value, false if
+// the type is no string, bool or integer.
+func labelValue(expr ast.Expr, varName string) (string, bool) {
+	id, ok := expr.(*ast.Ident)
+	if !ok {
+		return "", false
+	}
+	switch id.Name {
+	case "string":
+		return varName, true
+	case "bool":
+		return fmt.Sprintf("strconv.FormatBool(%s)", varName), true
+	case "int", "int8", "int16", "int32", "int64":
+		return fmt.Sprintf("strconv.FormatInt(int64(%s), 10)", varName), true
+	case "uint", "uint8", "byte", "uint16", "uint32", "uint64", "uintptr":
+		return fmt.Sprintf("strconv.FormatUint(uint64(%s), 10)", varName), true
+	}
+	return "", false
+}
+
+// generateGoBuilder generates <T>Builder, which sets the fields of a T one by one and keeps
+// the marshalled size of every field, so Build doesn't need to call Size.
+func (g *generator) generateGoBuilder(ts *ast.TypeSpec, layout map[string]common.FlagBits, words int, flagWord string) {
+	name := ts.Name.Name
+	builder := name + "Builder"
+
+	var fields []*ast.Field
+	var names []string
+	for _, field := range g.GetSupportedFields(ts) {
+		for _, fName := range field.Names {
+			fields = append(fields, field)
+			names = append(names, fName.Name)
+		}
+	}
+
+	g.printf("// %s builds a %s, keeping track of its marshalled size while the fields are set.\n", builder, name)
+	g.printf("// Values must not be modified after they have been set.\n")
+	g.printf("type %s struct {\n\tv %s\n\tsizes [%d]int\n\ts int\n}\n\n", builder, g.qualify(name), len(names))
+
+	g.printf("func New%s() *%s {\n\tbuilder := &%s{}\n", builder, builder, builder)
+	for i, f := range names {
+		if _, ok := layout[f]; ok {
+			continue
+		}
+		g.printf("\tbuilder.sizes[%d] = %s\n", i, g.fieldSizeExpr(fields[i], "builder.v."+f))
+		g.printf("\tbuilder.s += builder.sizes[%d]\n", i)
+	}
+	if words > 0 {
+		g.printf("\t// the flag words of the packed fields\n")
+		g.printf("\tbuilder.s += %d * bstd.Size%s()\n", words, flagWord)
+	}
+	g.printf("\treturn builder\n}\n\n")
+
+	for i, f := range names {
+		g.printf("func (builder *%s) Set%s(v %s) *%s {\n", builder, f, g.qualify(g.ExprToString(fields[i].Type)), builder)
+		if _, ok := layout[f]; !ok {
+			g.printf("\ts := %s\n", g.fieldSizeExpr(fields[i], "v"))
+			g.printf("\tbuilder.s += s - builder.sizes[%d]\n", i)
+			g.printf("\tbuilder.sizes[%d] = s\n", i)
+		}
+		g.printf("\tbuilder.v.%s = v\n", f)
+		g.printf("\treturn builder\n}\n\n")
+	}
+
+	g.printf("// Size returns the marshalled size of the built %s.\n", name)
+	g.printf("func (builder *%s) Size() int {\n\treturn builder.s\n}\n\n", builder)
+
+	g.printf("// Value returns the built %s.\n", name)
+	g.printf("func (builder *%s) Value() %s {\n\treturn builder.v\n}\n\n", builder, g.qualify(name))
+
+	g.printf("// Build marshals the built %s into a new buffer of exactly Size bytes.\n", name)
+	g.printf("func (builder *%s) Build() []byte {\n", builder)
+	g.printf("\tb := make([]byte, builder.s)\n")
+	g.printf("\t%s\n", g.methodCall(name, "Marshal", "builder.v", "0", "b"))
+	g.printf("\treturn b\n}\n\n")
+}
+
+// generateGoExampleDoc documents the encoding of the //benc:example values of the type name.
+func (g *generator) generateGoExampleDoc(name string) error {
+	if len(g.Examples[name]) == 0 {
+		return nil
+	}
+	g.printf("// The encoding of the examples of %s", name)
+	if g.containsMap(&ast.Ident{Name: name}, map[string]bool{}) {
+		g.printf(", the entries of maps may be in any order")
+	}
+	g.printf(":\n//\n")
+	for _, ex := range g.Examples[name] {
+		golden, err := g.exampleBytes(name, ex)
+		if err != nil {
+			return err
+		}
+		g.printf("//\t%s (%d bytes):\n", ex.Name, len(golden))
+		for i := 0; i < len(golden); i += 16 {
+			line := golden[i:min(i+16, len(golden))]
+			g.printf("//\t  % x\n", line)
+		}
+	}
+	return nil
+}
+
+// exampleBytes returns the encoding of the example of the type name, as computed by the schema driven codec.
+func (g *generator) exampleBytes(name string, ex common.Example) ([]byte, error) {
+	if lit, ok := ex.Value.(*ast.CompositeLit); !ok || g.ExprToString(lit.Type) != name {
+		return nil, fmt.Errorf("example %s must be a %s{...} literal", ex.Name, name)
+	}
+	if err := g.checkExported(ex.Name); err != nil {
+		return nil, err
+	}
+	codec := dynamic.New(g.Context)
+	v, err := codec.Literal(&ast.Ident{Name: name}, ex.Value)
+	if err != nil {
+		return nil, fmt.Errorf("example %s: %w", ex.Name, err)
+	}
+	golden, err := codec.Encode(name, v)
+	if err != nil {
+		return nil, fmt.Errorf("example %s: %w", ex.Name, err)
+	}
+	// the generated test compares the example with its unmarshalled golden bytes, which fails
+	// on values the encoding doesn't keep, e.g. the zero time.Time
+	_, decoded, err := codec.Decode(name, 0, golden)
+	if err != nil {
+		return nil, fmt.Errorf("example %s: %w", ex.Name, err)
+	}
+	if err = codec.Compare(name, v, decoded); err != nil {
+		return nil, fmt.Errorf("example %s doesn't round trip: %w", ex.Name, err)
+	}
+	return golden, nil
+}
+
+// containsMap reports whether a value of expr can contain a map.
+func (g *generator) containsMap(expr ast.Expr, visited map[string]bool) bool {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		ts, ok := g.TypeSpecs[t.Name]
+		if !ok || visited[t.Name] {
+			return false
+		}
+		visited[t.Name] = true
+		return g.containsMap(ts.Type, visited)
+	case *ast.StructType:
+		for _, field := range t.Fields.List {
+			if g.containsMap(field.Type, visited) {
+				return true
+			}
+		}
+	case *ast.StarExpr:
+		return g.containsMap(t.X, visited)
+	case *ast.IndexExpr:
+		if elt, ok := common.OptionElt(t); ok {
+			return g.containsMap(elt, visited)
+		}
+	case *ast.ArrayType:
+		return g.containsMap(t.Elt, visited)
+	case *ast.MapType:
+		return true
+	}
+	return false
+}
+
+// nestedStruct returns the name of the schema struct, if expr is one or a pointer to one.
+func (g *generator) nestedStruct(expr ast.Expr) (name string, isPtr bool) {
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr, isPtr = star.X, true
+	}
+	ident, ok := expr.(*ast.Ident)
+	if !ok {
+		return "", false
+	}
+	if ts, ok := g.TypeSpecs[ident.Name]; ok {
+		if _, ok := ts.Type.(*ast.StructType); ok {
+			return ident.Name, isPtr
+		}
+	}
+	return "", false
+}
+
 func (g *generator) generateGoMapAliasMethods(ts *ast.TypeSpec) error {
 	name := ts.Name.Name
-	receiver := strings.ToLower(name[:1]) + name[1:]
+	receiver := g.receiverName(name)
 	mapType := ts.Type.(*ast.MapType)
 
 	if g.IsUnsupportedType(mapType) {
 		return nil
 	}
+	if err := g.checkExported(name); err != nil {
+		return err
+	}
+	if err := g.checkMapKeys(mapType); err != nil {
+		return err
+	}
 
-	g.printf("func (%s *%s) Size() (s int) {\n", receiver, name)
+	g.funcDecl(name, receiver, "Size", "", "(s int)")
 	g.printf("\ts += %s\n", g.getGoSizeExpr(mapType, "*"+receiver))
 	g.printf("\treturn\n}\n\n")
 
-	g.printf("func (%s *%s) Marshal(tn int, b []byte) (n int) {\n\tn = tn\n", receiver, name)
+	g.funcDecl(name, receiver, "Marshal", "tn int, b []byte", "(n int)")
+	g.printf("\tn = tn\n")
 	g.printf("\tn = %s\n", g.getGoMarshalExpr(mapType, "n", "b", "*"+receiver))
+	g.observeSize(name)
 	g.printf("\treturn\n}\n\n")
 
-	g.printf("func (%s *%s) Unmarshal(tn int, b []byte) (n int, err error) {\n\tn = tn\n", receiver, name)
+	g.funcDecl(name, receiver, "Unmarshal", "tn int, b []byte", "(n int, err error)")
+	g.printf("\tn = tn\n")
 	g.printf("\tif %s; err != nil {\n\t\treturn\n\t}\n", g.getGoUnmarshalExpr(mapType, "n", "b", "*"+receiver))
 	g.printf("\treturn\n}\n\n")
+
+	g.printf("// Skip%s skips a marshalled %s without unmarshalling it, see bstd.Validate.\n", name, name)
+	g.printf("func Skip%s(n int, b []byte) (int, error) {\n", name)
+	g.printf("\treturn %s(n, b)\n}\n\n", g.getGoSkipExpr(mapType))
+
+	g.generateGoEncodeTo(ts, receiver, nil, true)
+	g.generateGoDecodeFrom(ts, receiver, nil, true)
 	return nil
 }
 
@@ -118,16 +1068,8 @@ func (g *generator) generateGoMapAliasMethods(ts *ast.TypeSpec) error {
 // -----------------------------------------------------------------------------
 
 func (g *generator) Tests() (err error) {
-	g.printf("// Code generated by benc generator; DO NOT EDIT.\n\n")
-	g.printf("package %s\n\n", g.PkgName)
-	g.printf("import (\n")
-	g.printf("\t\"math/rand\"\n")
-	g.printf("\t\"testing\"\n")
-	g.printf("\t\"time\"\n\n")
-	g.printf("\tbtst \"github.com/banditmoscow1337/benc/std/golang\"\n")
-	g.printf(")\n\n")
-
 	for _, ts := range g.Types {
+		g.generateGoTestSeed(ts)
 		g.generateGoTestGenerator(ts)
 		g.generateGoTestComparer(ts)
 	}
@@ -142,22 +1084,71 @@ func (g *generator) Tests() (err error) {
 	}
 	if topLevelStruct != nil {
 		g.generateGoTestMain(topLevelStruct)
+		g.generateGoTestEncodeTo(topLevelStruct)
+		g.generateGoTestDecodeFrom(topLevelStruct)
+		g.generateGoTestMerge(topLevelStruct)
+		g.generateGoTestBuilder(topLevelStruct)
+		g.generateGoTestMarshalWithSchema(topLevelStruct)
+	}
+	for _, ts := range g.Types {
+		g.generateGoTestWireSize(ts)
+		g.generateGoTestMarshalTruncated(ts)
+		g.generateGoTestLargeMaps(ts)
 	}
 
+	imports := []string{`"math/rand"`, `"testing"`, `btst "github.com/banditmoscow1337/benc/std/golang"`}
+	if referencesPackage(g.buf.String(), "time") {
+		imports = append(imports, `"time"`)
+	}
+	if topLevelStruct != nil {
+		imports = append([]string{`"bytes"`, `"io"`}, imports...)
+		imports = append(imports, `"testing/iotest"`)
+	}
+	for _, ts := range g.Types {
+		for _, ex := range g.Examples[ts.Name.Name] {
+			exact, err := g.generateGoTestExample(ts.Name.Name, ex)
+			if err != nil {
+				return err
+			}
+			if exact && !slices.Contains(imports, `"bytes"`) {
+				imports = append([]string{`"bytes"`}, imports...)
+			}
+		}
+	}
+
+	g.writeHeader(imports...)
+
 	return g.formatGo("benc_test")
 }
 
+// generateGoTestSeed generates the seed of the test data of the struct ts, derived from the hash
+// of its layout, so the data is the same in every run and only changes with the wire layout.
+func (g *generator) generateGoTestSeed(ts *ast.TypeSpec) {
+	if _, ok := ts.Type.(*ast.StructType); !ok {
+		return
+	}
+	seed, _ := strconv.ParseUint(g.LayoutHash(ts)[:16], 16, 64)
+	g.printf("// seed%s seeds the test data of %s. It is derived from the wire layout of %s,\n", ts.Name.Name, ts.Name.Name, ts.Name.Name)
+	g.printf("// so the data is the same in every run, until the layout changes.\n")
+	g.printf("const seed%s = %d\n\n", ts.Name.Name, int64(seed))
+}
+
 func (g *generator) generateGoTestGenerator(ts *ast.TypeSpec) {
 	name := ts.Name.Name
-	g.printf("func Generate%s(r *rand.Rand, depth int) %s {\n", name, name)
-	g.printf("\tif depth <= 0 { return *new(%s) }\n", name)
+	g.printf("func Generate%s(r *rand.Rand, depth int) %s {\n", name, g.qualify(name))
+	g.printf("\tif depth <= 0 { return *new(%s) }\n", g.qualify(name))
 	switch t := ts.Type.(type) {
 	case *ast.StructType:
-		g.printf("\treturn %s{\n", name)
+		// the errors are reported by Generate already
+		layout, _, _ := g.FlagLayout(ts)
+		g.printf("\treturn %s{\n", g.qualify(name))
 		for _, field := range t.Fields.List {
 			if g.ShouldIgnoreField(field) || g.IsUnsupportedType(field.Type) {
 				continue
 			}
+			g.zone, g.compact = g.IsZoneField(field), g.FieldCompactTime(field)
+			g.precision, _ = g.FieldPrecision(field)
+			g.bfloat16 = g.IsBFloat16Field(field)
 			for _, fName := range field.Names {
 				gen := g.getTypeInfo(field.Type).TestGenerator
 				if strings.HasPrefix(gen, "func") {
@@ -165,8 +1156,13 @@ func (g *generator) generateGoTestGenerator(ts *ast.TypeSpec) {
 				} else {
 					gen = fmt.Sprintf("%s(r, depth-1)", gen)
 				}
+				if fb, ok := layout[fName.Name]; ok && fb.Bits < intBits[g.ExprToString(field.Type)] {
+					// packed integers keep only their bits
+					gen = fmt.Sprintf("%s & %#x", gen, uint64(1)<<fb.Bits-1)
+				}
 				g.printf("\t\t%s: %s,\n", fName.Name, gen)
 			}
+			g.zone, g.compact, g.precision, g.bfloat16 = false, "", "", false
 		}
 		g.printf("\t}\n")
 	case *ast.MapType, *ast.ArrayType:
@@ -185,17 +1181,19 @@ func (g *generator) generateGoTestGenerator(ts *ast.TypeSpec) {
 
 func (g *generator) generateGoTestComparer(ts *ast.TypeSpec) {
 	name := ts.Name.Name
-	g.printf("func Compare%s(a, b %s) error {\n", name, name)
+	g.printf("func Compare%s(a, b %s) error {\n", name, g.qualify(name))
 	switch t := ts.Type.(type) {
 	case *ast.StructType:
 		for _, field := range t.Fields.List {
 			if g.ShouldIgnoreField(field) || g.IsUnsupportedType(field.Type) {
 				continue
 			}
+			g.zone, g.compact = g.IsZoneField(field), g.FieldCompactTime(field)
 			for _, fName := range field.Names {
 				comparer := g.getTypeInfo(field.Type).TestComparer
 				g.printf("\tif err := btst.CompareField(\"%s\", func() error { return %s(a.%s, b.%s) }); err != nil {\n\t\treturn err\n\t}\n", fName.Name, comparer, fName.Name, fName.Name)
 			}
+			g.zone, g.compact = false, ""
 		}
 		g.printf("\treturn nil\n")
 	default:
@@ -212,34 +1210,383 @@ func (g *generator) generateGoTestComparer(ts *ast.TypeSpec) {
 func (g *generator) generateGoTestMain(ts *ast.TypeSpec) {
 	name := ts.Name.Name
 	g.printf("func Test%s(t *testing.T) {\n", name)
-	g.printf("\tr := rand.New(rand.NewSource(time.Now().UnixNano()))\n")
+	g.printf("\tr := rand.New(rand.NewSource(seed%s))\n", name)
 	g.printf("\toriginal := Generate%s(r, btst.MaxDepth)\n\n", name)
-	g.printf("\ts := original.Size()\n")
+	g.printf("\ts := %s\n", g.methodCall(name, "Size", "original"))
 	g.printf("\tbuf := make([]byte, s)\n")
-	g.printf("\tn := original.Marshal(0, buf)\n\n")
+	g.printf("\tn := %s\n\n", g.methodCall(name, "Marshal", "original", "0", "buf"))
 	g.printf("\tif n != s {\n")
 	g.printf("\t\tt.Fatalf(\"Marshal size mismatch: expected %%d, got %%d\", s, n)\n")
 	g.printf("\t}\n\n")
-	g.printf("\tvar copy %s\n", name)
-	g.printf("\tbytesRead, err := copy.Unmarshal(0, buf)\n")
+	g.printf("\tvar copy %s\n", g.qualify(name))
+	g.printf("\tbytesRead, err := %s\n", g.methodCall(name, "Unmarshal", "copy", "0", "buf"))
 	g.printf("\tif err != nil {\n")
 	g.printf("\t\tt.Fatalf(\"Unmarshal failed: %%v\", err)\n")
 	g.printf("\t}\n")
 	g.printf("\tif bytesRead != s {\n")
 	g.printf("\t\tt.Fatalf(\"Unmarshal bytes read mismatch: expected %%d, got %%d\", s, bytesRead)\n")
 	g.printf("\t}\n\n")
+	g.printf("\tif err := btst.Validate(buf, Skip%s); err != nil {\n", name)
+	g.printf("\t\tt.Fatalf(\"Validate failed: %%v\", err)\n")
+	g.printf("\t}\n\n")
 	g.printf("\tif err := Compare%s(original, copy); err != nil {\n", name)
 	g.printf("\t\tt.Fatalf(\"Comparison failed: %%v\\nOriginal: %%#v\\nCopy: %%#v\", err, original, copy)\n")
 	g.printf("\t}\n")
 	g.printf("}\n\n")
 }
 
+// generateGoTestEncodeTo generates a test of EncodeTo, which must write the bytes of Marshal. The
+// written message is unmarshalled and compared, as maps are marshalled in no fixed order.
+func (g *generator) generateGoTestEncodeTo(ts *ast.TypeSpec) {
+	name := ts.Name.Name
+	g.printf("func Test%sEncodeTo(t *testing.T) {\n", name)
+	g.printf("\tr := rand.New(rand.NewSource(seed%s))\n", name)
+	g.printf("\toriginal := Generate%s(r, btst.MaxDepth)\n\n", name)
+	g.printf("\tvar buf bytes.Buffer\n")
+	g.printf("\tn, err := %s\n", g.methodCall(name, "EncodeTo", "original", "&buf"))
+	g.printf("\tif err != nil {\n")
+	g.printf("\t\tt.Fatalf(\"EncodeTo failed: %%v\", err)\n")
+	g.printf("\t}\n")
+	g.printf("\tif s := %s; n != int64(s) || buf.Len() != s {\n", g.methodCall(name, "Size", "original"))
+	g.printf("\t\tt.Fatalf(\"EncodeTo size mismatch: expected %%d, got %%d (%%d written)\", s, n, buf.Len())\n")
+	g.printf("\t}\n\n")
+	g.printf("\tvar copy %s\n", g.qualify(name))
+	g.printf("\tif _, err := %s; err != nil {\n", g.methodCall(name, "Unmarshal", "copy", "0", "buf.Bytes()"))
+	g.printf("\t\tt.Fatalf(\"Unmarshal failed: %%v\", err)\n")
+	g.printf("\t}\n")
+	g.printf("\tif err := Compare%s(original, copy); err != nil {\n", name)
+	g.printf("\t\tt.Fatalf(\"Comparison failed: %%v\", err)\n")
+	g.printf("\t}\n")
+	g.printf("}\n\n")
+}
+
+// generateGoTestDecodeFrom generates a test of DecodeFrom, which reads two marshalled messages
+// from a stream delivering a byte at a time, to cover the refills of the reader.
+func (g *generator) generateGoTestDecodeFrom(ts *ast.TypeSpec) {
+	name := ts.Name.Name
+	g.printf("func Test%sDecodeFrom(t *testing.T) {\n", name)
+	g.printf("\tr := rand.New(rand.NewSource(seed%s))\n", name)
+	g.printf("\toriginals := []%s{Generate%s(r, btst.MaxDepth), Generate%s(r, btst.MaxDepth)}\n\n", g.qualify(name), name, name)
+	g.printf("\tvar buf []byte\n")
+	g.printf("\tfor _, original := range originals {\n")
+	g.printf("\t\tb := make([]byte, %s)\n", g.methodCall(name, "Size", "original"))
+	g.printf("\t\t%s\n", g.methodCall(name, "Marshal", "original", "0", "b"))
+	g.printf("\t\tbuf = append(buf, b...)\n")
+	g.printf("\t}\n\n")
+	g.printf("\treader := btst.NewReader(iotest.OneByteReader(bytes.NewReader(buf)), 0)\n")
+	g.printf("\tfor _, original := range originals {\n")
+	g.printf("\t\tvar copy %s\n", g.qualify(name))
+	g.printf("\t\tif err := %s; err != nil {\n", g.methodCall(name, "DecodeFrom", "copy", "reader"))
+	g.printf("\t\t\tt.Fatalf(\"DecodeFrom failed: %%v\", err)\n")
+	g.printf("\t\t}\n")
+	g.printf("\t\tif err := Compare%s(original, copy); err != nil {\n", name)
+	g.printf("\t\t\tt.Fatalf(\"Comparison failed: %%v\", err)\n")
+	g.printf("\t\t}\n")
+	g.printf("\t}\n")
+	g.printf("\tvar copy %s\n", g.qualify(name))
+	g.printf("\tif err := %s; err != io.EOF {\n", g.methodCall(name, "DecodeFrom", "copy", "reader"))
+	g.printf("\t\tt.Fatalf(\"expected io.EOF after the last message, got %%v\", err)\n")
+	g.printf("\t}\n")
+	g.printf("}\n\n")
+}
+
+func (g *generator) generateGoTestMerge(ts *ast.TypeSpec) {
+	name := ts.Name.Name
+	var paths []string
+	for _, field := range g.GetSupportedFields(ts) {
+		for _, fName := range field.Names {
+			paths = append(paths, strconv.Quote(fName.Name))
+		}
+	}
+
+	g.printf("func TestMerge%s(t *testing.T) {\n", name)
+	g.printf("\tr := rand.New(rand.NewSource(seed%s))\n", name)
+	g.printf("\tdst := Generate%s(r, btst.MaxDepth)\n", name)
+	g.printf("\tsrc := Generate%s(r, btst.MaxDepth)\n\n", name)
+	g.printf("\tMerge%s(&dst, &src, btst.NewFieldMask(%s))\n\n", name, strings.Join(paths, ", "))
+	g.printf("\tif err := Compare%s(src, dst); err != nil {\n", name)
+	g.printf("\t\tt.Fatalf(\"Merge of all fields failed: %%v\", err)\n")
+	g.printf("\t}\n")
+	g.printf("}\n\n")
+}
+
+func (g *generator) generateGoTestBuilder(ts *ast.TypeSpec) {
+	name := ts.Name.Name
+	g.printf("func Test%sBuilder(t *testing.T) {\n", name)
+	g.printf("\tr := rand.New(rand.NewSource(seed%s))\n", name)
+	g.printf("\toriginal := Generate%s(r, btst.MaxDepth)\n\n", name)
+	g.printf("\tbuilder := New%sBuilder()\n", name)
+	for _, field := range g.GetSupportedFields(ts) {
+		for _, fName := range field.Names {
+			g.printf("\tbuilder.Set%s(original.%s)\n", fName.Name, fName.Name)
+		}
+	}
+	g.printf("\n\tif s := %s; builder.Size() != s {\n", g.methodCall(name, "Size", "original"))
+	g.printf("\t\tt.Fatalf(\"Builder size mismatch: expected %%d, got %%d\", s, builder.Size())\n")
+	g.printf("\t}\n\n")
+	g.printf("\tvar copy %s\n", g.qualify(name))
+	g.printf("\tif _, err := %s; err != nil {\n", g.methodCall(name, "Unmarshal", "copy", "0", "builder.Build()"))
+	g.printf("\t\tt.Fatalf(\"Unmarshal failed: %%v\", err)\n")
+	g.printf("\t}\n")
+	g.printf("\tif err := Compare%s(original, copy); err != nil {\n", name)
+	g.printf("\t\tt.Fatalf(\"Comparison failed: %%v\", err)\n")
+	g.printf("\t}\n")
+	g.printf("}\n\n")
+}
+
+// generateGoTestWireSize generates a test of the wire size of the zero value of the struct ts, as
+// computed by the schema driven codec, documenting its bytes by offset, so a change of the
+// layout changing the size of the struct fails until the test is generated again. The test of
+// a struct the schema driven codec can't encode is skipped, giving the reason.
+func (g *generator) generateGoTestWireSize(ts *ast.TypeSpec) {
+	if _, ok := ts.Type.(*ast.StructType); !ok || g.IsUnsupportedType(ts.Type) {
+		return
+	}
+	name := ts.Name.Name
+	zero, err := dynamic.New(g.Context).Encode(name, nil)
+	if err != nil {
+		g.printf("func Test%sWireSize(t *testing.T) {\n", name)
+		g.printf("\tt.Skip(%s)\n", strconv.Quote("the schema driven codec can't encode "+name+": "+err.Error()))
+		g.printf("}\n\n")
+		return
+	}
+
+	g.printf("// The zero %s takes %d bytes:\n", name, len(zero))
+	if len(zero) > 0 {
+		g.printf("//\n")
+	}
+	for i := 0; i < len(zero); i += 16 {
+		g.printf("//\t%04x  % x\n", i, zero[i:min(i+16, len(zero))])
+	}
+	g.printf("func Test%sWireSize(t *testing.T) {\n", name)
+	g.printf("\tconst size = %d\n", len(zero))
+	g.printf("\tvar zero %s\n", g.qualify(name))
+	g.printf("\tif s := %s; s != size {\n", g.methodCall(name, "Size", "zero"))
+	g.printf("\t\tt.Fatalf(\"Size of the zero %s changed: expected %%d, got %%d\", size, s)\n", name)
+	g.printf("\t}\n")
+	g.printf("\tbuf := make([]byte, size)\n")
+	g.printf("\tif n := %s; n != size {\n", g.methodCall(name, "Marshal", "zero", "0", "buf"))
+	g.printf("\t\tt.Fatalf(\"Marshal of the zero %s changed: expected %%d bytes, got %%d\", size, n)\n", name)
+	g.printf("\t}\n")
+	g.printf("}\n\n")
+}
+
+// generateGoTestMarshalTruncated generates a test of MarshalTruncated for a struct with a
+// truncated field, which marshals a random value into half of its size and unmarshals it.
+func (g *generator) generateGoTestMarshalTruncated(ts *ast.TypeSpec) {
+	if !slices.ContainsFunc(g.GetSupportedFields(ts), g.IsTruncatedField) {
+		return
+	}
+	name := ts.Name.Name
+	g.printf("func Test%sMarshalTruncated(t *testing.T) {\n", name)
+	g.printf("\tr := rand.New(rand.NewSource(seed%s))\n", name)
+	g.printf("\toriginal := Generate%s(r, btst.MaxDepth)\n\n", name)
+	g.printf("\tbuf := make([]byte, %s)\n", g.methodCall(name, "Size", "original"))
+	g.printf("\tif n, err := %s; err != nil || n != len(buf) {\n", g.methodCall(name, "MarshalTruncated", "original", "buf"))
+	g.printf("\t\tt.Fatalf(\"MarshalTruncated into a buffer of the size failed: %%d bytes, %%v\", n, err)\n")
+	g.printf("\t}\n")
+	g.printf("\tvar copy %s\n", g.qualify(name))
+	g.printf("\tif _, err := %s; err != nil {\n", g.methodCall(name, "Unmarshal", "copy", "0", "buf"))
+	g.printf("\t\tt.Fatalf(\"Unmarshal failed: %%v\", err)\n")
+	g.printf("\t}\n")
+	g.printf("\tif err := Compare%s(original, copy); err != nil {\n", name)
+	g.printf("\t\tt.Fatalf(\"MarshalTruncated truncated a fitting %s: %%v\", err)\n", name)
+	g.printf("\t}\n\n")
+	g.printf("\tbuf = buf[:len(buf)/2]\n")
+	g.printf("\tn, err := %s\n", g.methodCall(name, "MarshalTruncated", "original", "buf"))
+	g.printf("\tif err == btst.ErrBufTooSmall {\n")
+	g.printf("\t\treturn\n")
+	g.printf("\t}\n")
+	g.printf("\tif err != nil || n > len(buf) {\n")
+	g.printf("\t\tt.Fatalf(\"MarshalTruncated into %%d bytes failed: %%d bytes, %%v\", len(buf), n, err)\n")
+	g.printf("\t}\n")
+	g.printf("\tif bytesRead, err := %s; err != nil || bytesRead != n {\n", g.methodCall(name, "Unmarshal", "copy", "0", "buf[:n]"))
+	g.printf("\t\tt.Fatalf(\"Unmarshal of the truncated %s failed: %%d of %%d bytes, %%v\", bytesRead, n, err)\n", name)
+	g.printf("\t}\n")
+	g.printf("}\n\n")
+}
+
+// generateGoTestMarshalWithSchema generates a test of MarshalWithSchema for the struct ts,
+// if the code is generated with -go-schema-header.
+func (g *generator) generateGoTestMarshalWithSchema(ts *ast.TypeSpec) {
+	if !g.schemaHeader {
+		return
+	}
+	name := ts.Name.Name
+	g.printf("func Test%sMarshalWithSchema(t *testing.T) {\n", name)
+	g.printf("\tr := rand.New(rand.NewSource(seed%s))\n", name)
+	g.printf("\toriginal := Generate%s(r, btst.MaxDepth)\n\n", name)
+	g.printf("\tbuf := make([]byte, %s)\n", g.methodCall(name, "SizeWithSchema", "original"))
+	g.printf("\tif n := %s; n != len(buf) {\n", g.methodCall(name, "MarshalWithSchema", "original", "0", "buf"))
+	g.printf("\t\tt.Fatalf(\"MarshalWithSchema wrote %%d of %%d bytes\", n, len(buf))\n")
+	g.printf("\t}\n")
+	g.printf("\tn, h, err := btst.UnmarshalSchemaHeader(0, buf)\n")
+	g.printf("\tif err != nil || h.Type != %q {\n", name)
+	g.printf("\t\tt.Fatalf(\"UnmarshalSchemaHeader got the type %%q, %%v\", h.Type, err)\n")
+	g.printf("\t}\n")
+	g.printf("\tif _, err = h.Source(); err != nil {\n")
+	g.printf("\t\tt.Fatalf(\"decompressing the schema failed: %%v\", err)\n")
+	g.printf("\t}\n")
+	g.printf("\tvar copy %s\n", g.qualify(name))
+	g.printf("\tif bytesRead, err := %s; err != nil || bytesRead != len(buf) {\n", g.methodCall(name, "Unmarshal", "copy", "n", "buf"))
+	g.printf("\t\tt.Fatalf(\"Unmarshal after the schema header failed: %%d of %%d bytes, %%v\", bytesRead, len(buf), err)\n")
+	g.printf("\t}\n")
+	g.printf("\tif err := Compare%s(original, copy); err != nil {\n", name)
+	g.printf("\t\tt.Fatalf(\"MarshalWithSchema round trip failed: %%v\", err)\n")
+	g.printf("\t}\n")
+	g.printf("}\n\n")
+}
+
+// generateGoTestLargeMaps generates a round trip test of the struct ts with maps of
+// btst.LargeMapCount entries, whose values are compared instead of the bytes, as the entries of
+// a map are marshalled in no fixed order.
+func (g *generator) generateGoTestLargeMaps(ts *ast.TypeSpec) {
+	if _, ok := ts.Type.(*ast.StructType); !ok {
+		return
+	}
+	fields := g.LargeMapFields(ts)
+	if len(fields) == 0 {
+		return
+	}
+	name := ts.Name.Name
+	g.printf("func Test%sLargeMaps(t *testing.T) {\n", name)
+	g.printf("\tr := rand.New(rand.NewSource(seed%s))\n", name)
+	g.printf("\toriginal := Generate%s(r, btst.MaxDepth)\n", name)
+	for _, field := range fields {
+		t := field.Type.(*ast.MapType)
+		g.zone, g.compact = g.IsZoneField(field), g.FieldCompactTime(field)
+		g.precision, _ = g.FieldPrecision(field)
+		g.bfloat16 = g.IsBFloat16Field(field)
+		keyGen, valGen := g.getTypeInfo(t.Key).TestGenerator, g.getTypeInfo(t.Value).TestGenerator
+		g.zone, g.compact, g.precision, g.bfloat16 = false, "", "", false
+		for _, fName := range field.Names {
+			g.printf("\toriginal.%s = btst.GenerateLargeMap(r, 1, %s, %s)\n", fName.Name, keyGen, valGen)
+		}
+	}
+	g.printf("\n\ts := %s\n", g.methodCall(name, "Size", "original"))
+	g.printf("\tbuf := make([]byte, s)\n")
+	g.printf("\tif n := %s; n != s {\n", g.methodCall(name, "Marshal", "original", "0", "buf"))
+	g.printf("\t\tt.Fatalf(\"Marshal size mismatch: expected %%d, got %%d\", s, n)\n")
+	g.printf("\t}\n")
+	g.printf("\tvar copy %s\n", g.qualify(name))
+	g.printf("\tif bytesRead, err := %s; err != nil || bytesRead != s {\n", g.methodCall(name, "Unmarshal", "copy", "0", "buf"))
+	g.printf("\t\tt.Fatalf(\"Unmarshal failed: %%d of %%d bytes, %%v\", bytesRead, s, err)\n")
+	g.printf("\t}\n")
+	g.printf("\tif err := Compare%s(original, copy); err != nil {\n", name)
+	g.printf("\t\tt.Fatalf(\"Comparison failed: %%v\", err)\n")
+	g.printf("\t}\n")
+	g.printf("}\n\n")
+}
+
+// generateGoTestExample generates a golden test of the example ex of the type name. The encoding
+// is compared byte by byte, unless the type contains maps, whose entries have no fixed order.
+func (g *generator) generateGoTestExample(name string, ex common.Example) (exact bool, err error) {
+	golden, err := g.exampleBytes(name, ex)
+	if err != nil {
+		return false, err
+	}
+	exact = !g.containsMap(&ast.Ident{Name: name}, map[string]bool{})
+	value := ex.Name
+	if g.schemaPkg != "" {
+		value = g.schemaPkg + "." + ex.Name
+	}
+
+	g.printf("func TestGolden%s(t *testing.T) {\n", strings.ToUpper(ex.Name[:1])+ex.Name[1:])
+	g.printf("\tgolden := []byte{")
+	for i, c := range golden {
+		if i%16 == 0 {
+			g.printf("\n\t\t")
+		}
+		g.printf("0x%02x, ", c)
+	}
+	g.printf("\n\t}\n\n")
+	g.printf("\texample := %s\n", value)
+	g.printf("\tif s := %s; s != len(golden) {\n", g.methodCall(name, "Size", "example"))
+	g.printf("\t\tt.Fatalf(\"Size mismatch: expected %%d, got %%d\", len(golden), s)\n")
+	g.printf("\t}\n")
+	if exact {
+		g.printf("\tbuf := make([]byte, len(golden))\n")
+		g.printf("\t%s\n", g.methodCall(name, "Marshal", "example", "0", "buf"))
+		g.printf("\tif !bytes.Equal(buf, golden) {\n")
+		g.printf("\t\tt.Fatalf(\"Encoding of %s changed:\\ngot  %%x\\nwant %%x\", buf, golden)\n", ex.Name)
+		g.printf("\t}\n")
+	}
+	g.printf("\n\tvar copy %s\n", g.qualify(name))
+	g.printf("\tif _, err := %s; err != nil {\n", g.methodCall(name, "Unmarshal", "copy", "0", "golden"))
+	g.printf("\t\tt.Fatalf(\"Unmarshal failed: %%v\", err)\n")
+	g.printf("\t}\n")
+	g.printf("\tif err := Compare%s(example, copy); err != nil {\n", name)
+	g.printf("\t\tt.Fatalf(\"Comparison failed: %%v\", err)\n")
+	g.printf("\t}\n")
+	g.printf("}\n\n")
+	return exact, nil
+}
+
 // -----------------------------------------------------------------------------
 // HELPER METHODS (Private to this package)
 // -----------------------------------------------------------------------------
 
-func (g *generator) printf(format string, args ...interface{}) {
-	_, _ = fmt.Fprintf(&g.buf, format, args...)
+func (g *generator) printf(format string, args ...interface{}) {
+	_, _ = fmt.Fprintf(&g.buf, format, args...)
+}
+
+// writeHeader prepends the file header to the already generated body. Besides
+// the given imports, every schema import referenced by the body is included.
+func (g *generator) writeHeader(imports ...string) {
+	body := g.buf.String()
+	g.buf.Reset()
+
+	g.printf("// Code generated by benc generator; DO NOT EDIT.\n%s\n", g.FingerprintComment("// "))
+	g.printf("package %s\n\n", g.PkgName)
+	g.printf("import (\n")
+	for _, imp := range imports {
+		g.printf("\t%s\n", imp)
+	}
+	if g.schemaImport != "" && referencesPackage(body, g.schemaPkg) {
+		if path.Base(g.schemaImport) == g.schemaPkg {
+			g.printf("\t%q\n", g.schemaImport)
+		} else {
+			g.printf("\t%s %q\n", g.schemaPkg, g.schemaImport)
+		}
+	}
+
+	names := make([]string, 0, len(g.Imports))
+	for name := range g.Imports {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		path := g.Imports[name]
+		if slices.Contains(imports, strconv.Quote(path)) || !referencesPackage(body, name) {
+			continue
+		}
+		if path[strings.LastIndex(path, "/")+1:] == name {
+			g.printf("\t%q\n", path)
+		} else {
+			g.printf("\t%s %q\n", name, path)
+		}
+	}
+	g.printf(")\n\n")
+	g.buf.WriteString(body)
+}
+
+// referencesPackage reports whether src contains a qualified identifier `name.X`.
+func referencesPackage(src, name string) bool {
+	for i := strings.Index(src, name+"."); i >= 0; {
+		if i == 0 || !isIdentByte(src[i-1]) {
+			return true
+		}
+		next := strings.Index(src[i+1:], name+".")
+		if next < 0 {
+			break
+		}
+		i += next + 1
+	}
+	return false
+}
+
+func isIdentByte(c byte) bool {
+	return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
 }
 
 func (g *generator) formatGo(prefix string) (err error) {
@@ -263,28 +1610,337 @@ func (g *generator) formatGo(prefix string) (err error) {
 
 // Go Expression Logic
 
+// fieldSizeExpr is getGoSizeExpr for the type of a struct field, honoring its encoding options.
+func (g *generator) fieldSizeExpr(field *ast.Field, varName string) string {
+	g.varint, g.zone, g.compact = g.IsVarintField(field), g.IsZoneField(field), g.FieldCompactTime(field)
+	g.bfloat16, g.nul = g.IsBFloat16Field(field), g.IsNulField(field)
+	defer func() { g.varint, g.zone, g.compact, g.bfloat16, g.nul = false, false, "", false, false }()
+	if g.IsDeltaField(field) {
+		return fmt.Sprintf("bstd.SizeSliceDelta(%s)", varName)
+	}
+	if g.IsGorillaField(field) {
+		return fmt.Sprintf("bstd.SizeSliceGorilla(%s)", varName)
+	}
+	if g.IsRLEField(field) {
+		elt := field.Type.(*ast.ArrayType).Elt
+		eltSizer := fmt.Sprintf("func(v %s) int { return %s }", g.ExprToString(elt), g.getGoSizeExpr(elt, "v"))
+		return fmt.Sprintf("bstd.SizeSliceRLE(%s, %s)", varName, eltSizer)
+	}
+	if g.IsSparseField(field) {
+		elt := field.Type.(*ast.ArrayType).Elt
+		eltSizer := fmt.Sprintf("func(v %s) int { return %s }", g.ExprToString(elt), g.getGoSizeExpr(elt, "v"))
+		return fmt.Sprintf("bstd.SizeSliceSparse(%s, %s)", varName, eltSizer)
+	}
+	if g.IsDictField(field) {
+		g.dict = true
+		defer func() { g.dict = false }()
+		return fmt.Sprintf("func() int { var dict bstd.StringDict; return %s }()", g.getGoSizeExpr(field.Type, varName))
+	}
+	return g.getGoSizeExpr(field.Type, varName)
+}
+
+// fieldMarshalExpr is getGoMarshalExpr for the type of a struct field, honoring its encoding options.
+func (g *generator) fieldMarshalExpr(field *ast.Field, n, buf, varName string) string {
+	g.varint, g.zone, g.compact = g.IsVarintField(field), g.IsZoneField(field), g.FieldCompactTime(field)
+	g.bfloat16, g.nul = g.IsBFloat16Field(field), g.IsNulField(field)
+	// the errors are reported by generateGoStructMethods already
+	g.precision, _ = g.FieldPrecision(field)
+	defer func() { g.varint, g.zone, g.compact, g.precision, g.bfloat16, g.nul = false, false, "", "", false, false }()
+	if g.IsDeltaField(field) {
+		return fmt.Sprintf("bstd.MarshalSliceDelta(%s, %s, %s)", n, buf, varName)
+	}
+	if g.IsGorillaField(field) {
+		return fmt.Sprintf("bstd.MarshalSliceGorilla(%s, %s, %s)", n, buf, varName)
+	}
+	if g.IsRLEField(field) {
+		elt := field.Type.(*ast.ArrayType).Elt
+		eltMarshaler := fmt.Sprintf("func(n int, b []byte, v %s) int { return %s }", g.ExprToString(elt), g.getGoMarshalExpr(elt, "n", "b", "v"))
+		return fmt.Sprintf("bstd.MarshalSliceRLE(%s, %s, %s, %s)", n, buf, varName, eltMarshaler)
+	}
+	if g.IsSparseField(field) {
+		elt := field.Type.(*ast.ArrayType).Elt
+		eltMarshaler := fmt.Sprintf("func(n int, b []byte, v %s) int { return %s }", g.ExprToString(elt), g.getGoMarshalExpr(elt, "n", "b", "v"))
+		return fmt.Sprintf("bstd.MarshalSliceSparse(%s, %s, %s, %s)", n, buf, varName, eltMarshaler)
+	}
+	if g.IsDictField(field) {
+		g.dict = true
+		defer func() { g.dict = false }()
+		return fmt.Sprintf("func() int { var dict bstd.StringDict; return %s }()", g.getGoMarshalExpr(field.Type, n, buf, varName))
+	}
+	return g.getGoMarshalExpr(field.Type, n, buf, varName)
+}
+
+// fieldUnmarshalExpr is getGoUnmarshalExpr for the type of a struct field, honoring its encoding options.
+func (g *generator) fieldUnmarshalExpr(field *ast.Field, n, buf, varName string) string {
+	g.varint, g.zone, g.utf8 = g.IsVarintField(field), g.IsZoneField(field), g.IsUTF8Field(field)
+	g.cropped = g.IsCroppedField(field) && !g.streaming
+	g.compact, g.bfloat16, g.nul = g.FieldCompactTime(field), g.IsBFloat16Field(field), g.IsNulField(field)
+	// the errors are reported by generateGoStructMethods already
+	g.duplicates, _ = g.FieldDuplicates(field)
+	defer func() {
+		g.varint, g.zone, g.utf8, g.cropped, g.compact, g.duplicates = false, false, false, false, "", ""
+		g.bfloat16, g.nul = false, false
+	}()
+	if g.IsDeltaField(field) {
+		elt := field.Type.(*ast.ArrayType).Elt
+		return fmt.Sprintf("n, %s, err = bstd.UnmarshalSliceDelta[%s](%s, %s)", varName, g.ExprToString(elt), n, buf)
+	}
+	if g.IsGorillaField(field) {
+		return fmt.Sprintf("n, %s, err = bstd.UnmarshalSliceGorilla(%s, %s)", varName, n, buf)
+	}
+	if g.IsRLEField(field) {
+		elt := field.Type.(*ast.ArrayType).Elt
+		eltType := g.ExprToString(elt)
+		eltUnmarshaler := fmt.Sprintf("func(n int, b []byte) (int, %s, error) { var v %s; var err error; %s; return n, v, err }", eltType, eltType, g.getGoUnmarshalExpr(elt, "n", "b", "v"))
+		return fmt.Sprintf("n, %s, err = bstd.UnmarshalSliceRLE(%s, %s, %s)", varName, n, buf, eltUnmarshaler)
+	}
+	if g.IsSparseField(field) {
+		elt := field.Type.(*ast.ArrayType).Elt
+		eltType := g.ExprToString(elt)
+		eltUnmarshaler := fmt.Sprintf("func(n int, b []byte) (int, %s, error) { var v %s; var err error; %s; return n, v, err }", eltType, eltType, g.getGoUnmarshalExpr(elt, "n", "b", "v"))
+		return fmt.Sprintf("n, %s, err = bstd.UnmarshalSliceSparse(%s, %s, %s)", varName, n, buf, eltUnmarshaler)
+	}
+	if g.IsDictField(field) {
+		g.dict = true
+		defer func() { g.dict = false }()
+		return fmt.Sprintf("n, err = func() (int, error) { var dict bstd.StringDict; var err error; %s; return n, err }()", g.getGoUnmarshalExpr(field.Type, n, buf, varName))
+	}
+	return g.getGoUnmarshalExpr(field.Type, n, buf, varName)
+}
+
+// fieldSkipExpr is getGoSkipExpr for the type of a struct field, honoring its encoding options.
+func (g *generator) fieldSkipExpr(field *ast.Field) string {
+	g.varint, g.zone, g.compact = g.IsVarintField(field), g.IsZoneField(field), g.FieldCompactTime(field)
+	g.bfloat16, g.nul = g.IsBFloat16Field(field), g.IsNulField(field)
+	defer func() { g.varint, g.zone, g.compact, g.bfloat16, g.nul = false, false, "", false, false }()
+	if g.IsDeltaField(field) {
+		return "bstd.SkipSliceDelta"
+	}
+	if g.IsGorillaField(field) {
+		return "bstd.SkipSliceGorilla"
+	}
+	if g.IsRLEField(field) {
+		elt := field.Type.(*ast.ArrayType).Elt
+		return fmt.Sprintf("bstd.SkipSliceRLEOf(%s)", g.getGoSkipExpr(elt))
+	}
+	if g.IsSparseField(field) {
+		elt := field.Type.(*ast.ArrayType).Elt
+		return fmt.Sprintf("bstd.SkipSliceSparseOf(%s)", g.getGoSkipExpr(elt))
+	}
+	if g.IsDictField(field) {
+		g.dict = true
+		defer func() { g.dict = false }()
+	}
+	return g.getGoSkipExpr(field.Type)
+}
+
+// isRLESlice reports whether expr is a slice the run-length encoding supports,
+// one of bools, bytes or integers.
+func isRLESlice(expr ast.Expr) bool {
+	t, ok := expr.(*ast.ArrayType)
+	if !ok || t.Len != nil {
+		return false
+	}
+	elt, ok := t.Elt.(*ast.Ident)
+	if !ok {
+		return false
+	}
+	switch elt.Name {
+	case "bool", "byte", "rune", "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64":
+		return true
+	}
+	return false
+}
+
+// isSparseSlice reports whether expr is a slice the sparse encoding supports, one of bools,
+// numbers or strings, whose zero value is the one left out.
+func isSparseSlice(expr ast.Expr) bool {
+	t, ok := expr.(*ast.ArrayType)
+	if !ok || t.Len != nil {
+		return false
+	}
+	elt, ok := t.Elt.(*ast.Ident)
+	if !ok {
+		return false
+	}
+	switch elt.Name {
+	case "bool", "byte", "rune", "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64",
+		"float32", "float64", "string":
+		return true
+	}
+	return false
+}
+
+// isDeltaSlice reports whether expr is a slice the delta encoding supports, one of integers.
+func isDeltaSlice(expr ast.Expr) bool {
+	t, ok := expr.(*ast.ArrayType)
+	if !ok || t.Len != nil {
+		return false
+	}
+	elt, ok := t.Elt.(*ast.Ident)
+	if !ok {
+		return false
+	}
+	switch elt.Name {
+	case "rune", "int", "int8", "int16", "int32", "int64", "uint", "uint16", "uint32", "uint64":
+		return true
+	}
+	return false
+}
+
+// hasStrings reports whether expr contains strings, which the string dictionary applies to.
+func hasStrings(expr ast.Expr) bool {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		return t.Name == "string"
+	case *ast.StarExpr:
+		return hasStrings(t.X)
+	case *ast.IndexExpr:
+		elt, ok := common.OptionElt(t)
+		return ok && hasStrings(elt)
+	case *ast.ArrayType:
+		return hasStrings(t.Elt)
+	case *ast.MapType:
+		return hasStrings(t.Key) || hasStrings(t.Value)
+	}
+	return false
+}
+
+// hasStringsOrBytes reports whether expr contains a string or a byte slice.
+func hasStringsOrBytes(expr ast.Expr) bool {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		return t.Name == "string"
+	case *ast.StarExpr:
+		return hasStringsOrBytes(t.X)
+	case *ast.IndexExpr:
+		elt, ok := common.OptionElt(t)
+		return ok && hasStringsOrBytes(elt)
+	case *ast.ArrayType:
+		if id, ok := t.Elt.(*ast.Ident); ok && id.Name == "byte" {
+			return t.Len == nil
+		}
+		return hasStringsOrBytes(t.Elt)
+	case *ast.MapType:
+		return hasStringsOrBytes(t.Key) || hasStringsOrBytes(t.Value)
+	}
+	return false
+}
+
+// hasTimes reports whether expr contains a time.Time.
+func hasTimes(expr ast.Expr) bool {
+	switch t := expr.(type) {
+	case *ast.SelectorExpr:
+		id, ok := t.X.(*ast.Ident)
+		return ok && id.Name == "time" && t.Sel.Name == "Time"
+	case *ast.StarExpr:
+		return hasTimes(t.X)
+	case *ast.IndexExpr:
+		elt, ok := common.OptionElt(t)
+		return ok && hasTimes(elt)
+	case *ast.ArrayType:
+		return hasTimes(t.Elt)
+	case *ast.MapType:
+		return hasTimes(t.Key) || hasTimes(t.Value)
+	}
+	return false
+}
+
+// hasFloat32s reports whether expr contains a float32.
+func hasFloat32s(expr ast.Expr) bool {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		return t.Name == "float32"
+	case *ast.StarExpr:
+		return hasFloat32s(t.X)
+	case *ast.IndexExpr:
+		elt, ok := common.OptionElt(t)
+		return ok && hasFloat32s(elt)
+	case *ast.ArrayType:
+		return hasFloat32s(t.Elt)
+	case *ast.MapType:
+		return hasFloat32s(t.Key) || hasFloat32s(t.Value)
+	}
+	return false
+}
+
+// hasMaps reports whether expr contains a map, besides those of the schema types it refers to.
+func hasMaps(expr ast.Expr) bool {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return hasMaps(t.X)
+	case *ast.IndexExpr:
+		elt, ok := common.OptionElt(t)
+		return ok && hasMaps(elt)
+	case *ast.ArrayType:
+		return hasMaps(t.Elt)
+	case *ast.MapType:
+		return true
+	}
+	return false
+}
+
+// hasVarintInts reports whether expr contains an integer type with a varint encoding,
+// which must be signed if signed is set.
+func hasVarintInts(expr ast.Expr, signed bool) bool {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		if signed {
+			return t.Name == "int" || common.VarintTypes[t.Name] && t.Name[0] == 'i'
+		}
+		return common.VarintTypes[t.Name] || t.Name == "int" || t.Name == "uint"
+	case *ast.StarExpr:
+		return hasVarintInts(t.X, signed)
+	case *ast.IndexExpr:
+		elt, ok := common.OptionElt(t)
+		return ok && hasVarintInts(elt, signed)
+	case *ast.ArrayType:
+		return hasVarintInts(t.Elt, signed)
+	case *ast.MapType:
+		return hasVarintInts(t.Key, signed) || hasVarintInts(t.Value, signed)
+	}
+	return false
+}
+
 func (g *generator) getGoSizeExpr(expr ast.Expr, varName string) string {
 	typeName := g.ExprToString(expr)
 
 	if ts, ok := g.TypeSpecs[typeName]; ok {
 		if _, isMap := ts.Type.(*ast.MapType); isMap {
-			receiverName := strings.ToLower(typeName[:1]) + typeName[1:]
+			receiverName := g.receiverName(typeName)
 			if varName != "*"+receiverName {
-				return fmt.Sprintf("%s.Size()", varName)
+				return g.methodCall(typeName, "Size", varName)
 			}
 		} else {
-			return fmt.Sprintf("%s.Size()", varName)
+			return g.methodCall(typeName, "Size", varName)
 		}
 	}
 	if _, ok := g.TypeSpecs[typeName]; ok {
-		return fmt.Sprintf("%s.Size()", varName)
+		return g.methodCall(typeName, "Size", varName)
+	}
+
+	if common.IsVariantType(expr) {
+		return fmt.Sprintf("bstd.SizeVariant(%s)", varName)
+	}
+	if e, ok := g.EnumOf(expr); ok {
+		return fmt.Sprintf("%s.Size(%s)", enumTable(e.Name), varName)
 	}
 
 	switch t := expr.(type) {
 	case *ast.Ident:
 		info := g.getTypeInfo(t)
+		if g.varint && common.VarintTypes[info.TypeName] {
+			return fmt.Sprintf("bstd.Size%sVarint(%s)", strings.Title(info.TypeName), varName)
+		}
+		if g.dict && info.TypeName == "string" {
+			return fmt.Sprintf("bstd.SizeStringDict(&dict, %s)", varName)
+		}
 		sizer := "bstd.Size" + strings.Title(info.TypeName)
-		if info.TypeName == "byte" {
+		if g.bfloat16 && info.TypeName == "float32" {
+			sizer = "bstd.SizeBFloat16"
+		} else if g.nul && info.TypeName == "string" {
+			sizer = "bstd.SizeStringNul"
+		} else if info.TypeName == "byte" {
 			sizer = "bstd.SizeByte"
 		} else if info.TypeName == "rune" {
 			sizer = "bstd.SizeInt32"
@@ -294,11 +1950,24 @@ func (g *generator) getGoSizeExpr(expr ast.Expr, varName string) string {
 		}
 		return fmt.Sprintf("%s(%s)", sizer, varName)
 	case *ast.StarExpr:
-		eltSizer := fmt.Sprintf("func(v %s) int { return %s }", g.ExprToString(t.X), g.getGoSizeExpr(t.X, "v"))
+		if st, ok := g.selectorType(typeName); ok {
+			return fmt.Sprintf("bstd.Size%s(%s)", st.Name, varName)
+		}
+		eltSizer := fmt.Sprintf("func(v %s) int { return %s }", g.qualify(g.ExprToString(t.X)), g.getGoSizeExpr(t.X, "v"))
 		return fmt.Sprintf("bstd.SizePointer(%s, %s)", varName, eltSizer)
+	case *ast.IndexExpr:
+		elt, ok := common.OptionElt(t)
+		if !ok {
+			return "0"
+		}
+		eltSizer := fmt.Sprintf("func(v %s) int { return %s }", g.qualify(g.ExprToString(elt)), g.getGoSizeExpr(elt, "v"))
+		return fmt.Sprintf("bstd.SizeOption(%s, %s)", varName, eltSizer)
 	case *ast.SelectorExpr:
-		if g.ExprToString(t) == "time.Time" {
-			return "bstd.SizeTime()"
+		if st, ok := g.selectorType(g.ExprToString(t)); ok {
+			if st.IsFixedSize {
+				return fmt.Sprintf("bstd.Size%s()", st.Name)
+			}
+			return fmt.Sprintf("bstd.Size%s(%s)", st.Name, varName)
 		}
 		return fmt.Sprintf("%s.Size()", varName)
 	case *ast.ArrayType:
@@ -309,7 +1978,7 @@ func (g *generator) getGoSizeExpr(expr ast.Expr, varName string) string {
 			if eltInfo.TypeName == "byte" {
 				return fmt.Sprintf("bstd.SizeByteArray(%s)", lenStr)
 			}
-			if eltInfo.IsFixedSize {
+			if eltInfo.IsFixedSize && !g.varint {
 				// Optimization for fixed size elements (e.g., [5]int64 -> 5 * 8)
 				return fmt.Sprintf("(%s * %s)", lenStr, g.getGoSizeExpr(t.Elt, "(*new("+eltInfo.TypeName+"))"))
 			}
@@ -326,10 +1995,19 @@ func (g *generator) getGoSizeExpr(expr ast.Expr, varName string) string {
 		eltSizer := fmt.Sprintf("func(v %s) int { return %s }", eltInfo.TypeName, g.getGoSizeExpr(t.Elt, "v"))
 		return fmt.Sprintf("bstd.SizeSlice(%s, %s)", varName, eltSizer)
 	case *ast.MapType:
+		if fn, ok := g.bytesMapFunc(t, "Size"); ok {
+			return fmt.Sprintf("%s(%s)", fn, varName)
+		}
 		keyInfo := g.getTypeInfo(t.Key)
 		valInfo := g.getTypeInfo(t.Value)
-		keySizer := fmt.Sprintf("func(k %s) int { return %s }", keyInfo.TypeName, g.getGoSizeExpr(t.Key, "k"))
-		valSizer := fmt.Sprintf("func(v %s) int { return %s }", valInfo.TypeName, g.getGoSizeExpr(t.Value, "v"))
+		keySizer, ok := g.messageFunc(t.Key, "Size")
+		if !ok {
+			keySizer = fmt.Sprintf("func(k %s) int { return %s }", keyInfo.TypeName, g.getGoSizeExpr(t.Key, "k"))
+		}
+		valSizer, ok := g.messageFunc(t.Value, "Size")
+		if !ok {
+			valSizer = fmt.Sprintf("func(v %s) int { return %s }", valInfo.TypeName, g.getGoSizeExpr(t.Value, "v"))
+		}
 		return fmt.Sprintf("bstd.SizeMap(%s, %s, %s)", varName, keySizer, valSizer)
 	default:
 		return "0"
@@ -339,19 +2017,49 @@ func (g *generator) getGoSizeExpr(expr ast.Expr, varName string) string {
 func (g *generator) getGoMarshalExpr(expr ast.Expr, n, buf, varName string) string {
 	typeName := g.ExprToString(expr)
 	if _, ok := g.TypeSpecs[typeName]; ok {
-		return fmt.Sprintf("%s.Marshal(%s, %s)", varName, n, buf)
+		return g.methodCall(typeName, "Marshal", varName, n, buf)
+	}
+	if common.IsVariantType(expr) {
+		if g.canonical {
+			return fmt.Sprintf("bstd.MarshalVariantCanonical(%s, %s, %s)", n, buf, varName)
+		}
+		return fmt.Sprintf("bstd.MarshalVariant(%s, %s, %s)", n, buf, varName)
+	}
+	if e, ok := g.EnumOf(expr); ok {
+		return fmt.Sprintf("%s.Marshal(%s, %s, %s)", enumTable(e.Name), n, buf, varName)
 	}
 	info := g.getTypeInfo(expr)
 	switch t := expr.(type) {
 	case *ast.Ident:
+		if g.varint && common.VarintTypes[info.TypeName] {
+			return fmt.Sprintf("%sVarint(%s, %s, %s)", info.Marshaler, n, buf, varName)
+		}
+		if g.dict && info.TypeName == "string" {
+			return fmt.Sprintf("bstd.MarshalStringDict(%s, %s, &dict, %s)", n, buf, varName)
+		}
 		return fmt.Sprintf("%s(%s, %s, %s)", info.Marshaler, n, buf, varName)
 	case *ast.StarExpr:
-		eltType := g.ExprToString(t.X)
+		if st, ok := g.selectorType(typeName); ok {
+			return fmt.Sprintf("bstd.Marshal%s(%s, %s, %s)", st.Name, n, buf, varName)
+		}
+		eltType := g.qualify(g.ExprToString(t.X))
 		eltMarshalFn := fmt.Sprintf("func(n int, b []byte, v %s) int { return %s }", eltType, g.getGoMarshalExpr(t.X, "n", "b", "v"))
 		return fmt.Sprintf("bstd.MarshalPointer(%s, %s, %s, %s)", n, buf, varName, eltMarshalFn)
+	case *ast.IndexExpr:
+		elt, ok := common.OptionElt(t)
+		if !ok {
+			return n
+		}
+		eltType := g.qualify(g.ExprToString(elt))
+		eltMarshalFn := fmt.Sprintf("func(n int, b []byte, v %s) int { return %s }", eltType, g.getGoMarshalExpr(elt, "n", "b", "v"))
+		return fmt.Sprintf("bstd.MarshalOption(%s, %s, %s, %s)", n, buf, varName, eltMarshalFn)
 	case *ast.SelectorExpr:
-		if g.ExprToString(t) == "time.Time" {
-			return fmt.Sprintf("bstd.MarshalTime(%s, %s, %s)", n, buf, varName)
+		if st, ok := g.selectorType(g.ExprToString(t)); ok {
+			if g.precision != "" && g.compact == "" && g.ExprToString(t) == "time.Time" {
+				method := strings.Replace(st.Name, "Time", "Marshal", 1)
+				return fmt.Sprintf("bstd.TimeOptions{Precision: %s}.%s(%s, %s, %s)", g.precision, method, n, buf, varName)
+			}
+			return fmt.Sprintf("bstd.Marshal%s(%s, %s, %s)", st.Name, n, buf, varName)
 		}
 		return fmt.Sprintf("%s.Marshal(%s, %s)", varName, n, buf)
 	case *ast.ArrayType:
@@ -373,10 +2081,22 @@ func (g *generator) getGoMarshalExpr(expr ast.Expr, n, buf, varName string) stri
 		eltMarshaler := fmt.Sprintf("func(n int, b []byte, v %s) int { return %s }", eltInfo.TypeName, g.getGoMarshalExpr(t.Elt, "n", "b", "v"))
 		return fmt.Sprintf("bstd.MarshalSlice(%s, %s, %s, %s)", n, buf, varName, eltMarshaler)
 	case *ast.MapType:
+		if fn, ok := g.bytesMapFunc(t, "Marshal"); ok {
+			return fmt.Sprintf("%s(%s, %s, %s)", fn, n, buf, varName)
+		}
 		keyInfo := g.getTypeInfo(t.Key)
 		valInfo := g.getTypeInfo(t.Value)
-		keyMarshal := fmt.Sprintf("func(n int, b []byte, k %s) int { return %s }", keyInfo.TypeName, g.getGoMarshalExpr(t.Key, "n", "b", "k"))
-		valMarshal := fmt.Sprintf("func(n int, b []byte, v %s) int { return %s }", valInfo.TypeName, g.getGoMarshalExpr(t.Value, "n", "b", "v"))
+		keyMarshal, ok := g.messageFunc(t.Key, "Marshal")
+		if !ok {
+			keyMarshal = fmt.Sprintf("func(n int, b []byte, k %s) int { return %s }", keyInfo.TypeName, g.getGoMarshalExpr(t.Key, "n", "b", "k"))
+		}
+		valMarshal, ok := g.messageFunc(t.Value, "Marshal")
+		if !ok {
+			valMarshal = fmt.Sprintf("func(n int, b []byte, v %s) int { return %s }", valInfo.TypeName, g.getGoMarshalExpr(t.Value, "n", "b", "v"))
+		}
+		if g.canonical {
+			return fmt.Sprintf("bstd.MarshalMapCanonical(%s, %s, %s, %s, %s)", n, buf, varName, keyMarshal, valMarshal)
+		}
 		return fmt.Sprintf("bstd.MarshalMap(%s, %s, %s, %s, %s)", n, buf, varName, keyMarshal, valMarshal)
 	default:
 		return n
@@ -386,20 +2106,49 @@ func (g *generator) getGoMarshalExpr(expr ast.Expr, n, buf, varName string) stri
 func (g *generator) getGoUnmarshalExpr(expr ast.Expr, n, buf, varName string) string {
 	typeName := g.ExprToString(expr)
 	if _, ok := g.TypeSpecs[typeName]; ok {
-		return fmt.Sprintf("n, err = %s.Unmarshal(%s, %s)", varName, n, buf)
+		return "n, err = " + g.methodCall(typeName, "Unmarshal", varName, n, buf)
+	}
+	if common.IsVariantType(expr) {
+		return fmt.Sprintf("n, %s, err = bstd.UnmarshalVariant(%s, %s)", varName, n, buf)
+	}
+	if e, ok := g.EnumOf(expr); ok {
+		return fmt.Sprintf("n, %s, err = %s.Unmarshal(%s, %s)", varName, enumTable(e.Name), n, buf)
 	}
 	info := g.getTypeInfo(expr)
 	switch t := expr.(type) {
 	case *ast.Ident:
+		if g.varint && common.VarintTypes[info.TypeName] {
+			return fmt.Sprintf("n, %s, err = %sVarint(%s, %s)", varName, info.Unmarshaler, n, buf)
+		}
+		if g.dict && info.TypeName == "string" {
+			return fmt.Sprintf("n, %s, err = bstd.UnmarshalStringDict(%s, %s, &dict)", varName, n, buf)
+		}
+		if g.utf8 && info.TypeName == "string" {
+			return fmt.Sprintf("n, %s, err = bstd.UnmarshalStringValidated(%s, %s)", varName, n, buf)
+		}
+		if g.cropped && info.TypeName == "string" {
+			return fmt.Sprintf("n, %s, err = bstd.UnmarshalStringCropped(%s, %s)", varName, n, buf)
+		}
 		return fmt.Sprintf("n, %s, err = %s(%s, %s)", varName, info.Unmarshaler, n, buf)
 	case *ast.StarExpr:
-		eltType := g.ExprToString(t.X)
+		if st, ok := g.selectorType(typeName); ok {
+			return fmt.Sprintf("n, %s, err = bstd.Unmarshal%s(%s)", varName, st.Name, st.unmarshalArgs(n, buf))
+		}
+		eltType := g.qualify(g.ExprToString(t.X))
 		// FIX: Added "var err error;" to declare err locally
 		eltUnmarshalFn := fmt.Sprintf("func(n int, b []byte, v *%s) (int, error) { var err error; %s; return n, err }", eltType, g.getGoUnmarshalExpr(t.X, "n", "b", "(*v)"))
 		return fmt.Sprintf("n, %s, err = bstd.UnmarshalPointer[%s](%s, %s, %s)", varName, eltType, n, buf, eltUnmarshalFn)
+	case *ast.IndexExpr:
+		elt, ok := common.OptionElt(t)
+		if !ok {
+			return ""
+		}
+		eltType := g.qualify(g.ExprToString(elt))
+		eltUnmarshalFn := fmt.Sprintf("func(n int, b []byte, v *%s) (int, error) { var err error; %s; return n, err }", eltType, g.getGoUnmarshalExpr(elt, "n", "b", "(*v)"))
+		return fmt.Sprintf("n, %s, err = bstd.UnmarshalOption[%s](%s, %s, %s)", varName, eltType, n, buf, eltUnmarshalFn)
 	case *ast.SelectorExpr:
-		if g.ExprToString(t) == "time.Time" {
-			return fmt.Sprintf("n, %s, err = bstd.UnmarshalTime(%s, %s)", varName, n, buf)
+		if st, ok := g.selectorType(g.ExprToString(t)); ok {
+			return fmt.Sprintf("n, %s, err = bstd.Unmarshal%s(%s)", varName, st.Name, st.unmarshalArgs(n, buf))
 		}
 		return fmt.Sprintf("n, err = %s.Unmarshal(%s, %s)", varName, n, buf)
 	case *ast.ArrayType:
@@ -416,25 +2165,369 @@ func (g *generator) getGoUnmarshalExpr(expr ast.Expr, n, buf, varName string) st
 		// Slice
 		eltInfo := g.getTypeInfo(t.Elt)
 		if eltInfo.TypeName == "byte" {
+			if g.cropped {
+				return fmt.Sprintf("n, %s, err = bstd.UnmarshalBytesCropped(%s, %s)", varName, n, buf)
+			}
 			return fmt.Sprintf("n, %s, err = bstd.UnmarshalBytesCopied(%s, %s)", varName, n, buf)
 		}
 		// FIX: Added "var err error;" to declare err locally
 		eltUnmarshaler := fmt.Sprintf("func(n int, b []byte, v *%s) (int, error) { var err error; %s; return n, err }", eltInfo.TypeName, g.getGoUnmarshalExpr(t.Elt, "n", "b", "(*v)"))
 		return fmt.Sprintf("n, %s, err = bstd.UnmarshalSlice[%s](%s, %s, %s)", varName, eltInfo.TypeName, n, buf, eltUnmarshaler)
 	case *ast.MapType:
+		if fn, ok := g.bytesMapFunc(t, "Unmarshal"); ok {
+			if g.cropped {
+				fn += "Cropped"
+			}
+			return fmt.Sprintf("n, %s, err = %s(%s, %s)", varName, fn, n, buf)
+		}
 		keyInfo := g.getTypeInfo(t.Key)
 		valInfo := g.getTypeInfo(t.Value)
 		// FIX: Added "var err error;" to declare err locally in both key and value unmarshalers
-		keyUnmarshal := fmt.Sprintf("func(n int, b []byte, k *%s) (int, error) { var err error; %s; return n, err }", keyInfo.TypeName, g.getGoUnmarshalExpr(t.Key, "n", "b", "(*k)"))
-		valUnmarshal := fmt.Sprintf("func(n int, b []byte, v *%s) (int, error) { var err error; %s; return n, err }", valInfo.TypeName, g.getGoUnmarshalExpr(t.Value, "n", "b", "(*v)"))
+		keyUnmarshal, ok := g.messageFunc(t.Key, "Unmarshal")
+		if !ok {
+			keyUnmarshal = fmt.Sprintf("func(n int, b []byte, k *%s) (int, error) { var err error; %s; return n, err }", keyInfo.TypeName, g.getGoUnmarshalExpr(t.Key, "n", "b", "(*k)"))
+		}
+		valUnmarshal, ok := g.messageFunc(t.Value, "Unmarshal")
+		if !ok {
+			valUnmarshal = fmt.Sprintf("func(n int, b []byte, v *%s) (int, error) { var err error; %s; return n, err }", valInfo.TypeName, g.getGoUnmarshalExpr(t.Value, "n", "b", "(*v)"))
+		}
+		if g.duplicates != "" {
+			return fmt.Sprintf("n, %s, err = bstd.UnmarshalMapLimited[%s, %s](%s, %s, %s, %s, bstd.Limits{Duplicates: %s})", varName, keyInfo.TypeName, valInfo.TypeName, n, buf, keyUnmarshal, valUnmarshal, g.duplicates)
+		}
 		return fmt.Sprintf("n, %s, err = bstd.UnmarshalMap[%s, %s](%s, %s, %s, %s)", varName, keyInfo.TypeName, valInfo.TypeName, n, buf, keyUnmarshal, valUnmarshal)
 	default:
 		return fmt.Sprintf("n, %s, err = %s(%s, %s)", varName, info.Unmarshaler, n, buf)
 	}
 }
 
+// getGoSkipExpr returns a bstd.SkipFunc skipping a marshalled value of expr.
+func (g *generator) getGoSkipExpr(expr ast.Expr) string {
+	typeName := g.ExprToString(expr)
+	if _, ok := g.TypeSpecs[typeName]; ok {
+		return "Skip" + typeName
+	}
+	if common.IsVariantType(expr) {
+		return "bstd.SkipVariant"
+	}
+	if _, ok := g.EnumOf(expr); ok {
+		return "bstd.SkipVarint"
+	}
+	info := g.getTypeInfo(expr)
+	switch t := expr.(type) {
+	case *ast.Ident:
+		if g.dict && info.TypeName == "string" {
+			return "bstd.SkipStringDict"
+		}
+		skipper := strings.Replace(info.Unmarshaler, "Unmarshal", "Skip", 1)
+		if g.varint && common.VarintTypes[info.TypeName] {
+			return skipper + "Varint"
+		}
+		if skipper == "bstd.SkipInt" {
+			return "bstd.SkipVarint"
+		}
+		return skipper
+	case *ast.StarExpr:
+		if st, ok := g.selectorType(typeName); ok {
+			return "bstd.Skip" + st.Name
+		}
+		return fmt.Sprintf("bstd.SkipPointerOf(%s)", g.getGoSkipExpr(t.X))
+	case *ast.IndexExpr:
+		elt, ok := common.OptionElt(t)
+		if !ok {
+			return ""
+		}
+		return fmt.Sprintf("bstd.SkipOptionOf(%s)", g.getGoSkipExpr(elt))
+	case *ast.SelectorExpr:
+		if st, ok := g.selectorType(typeName); ok {
+			return "bstd.Skip" + st.Name
+		}
+		// types of other packages can only be skipped by unmarshalling them
+		return fmt.Sprintf("func(n int, b []byte) (int, error) { var v %s; return v.Unmarshal(n, b) }", typeName)
+	case *ast.ArrayType:
+		if t.Len != nil {
+			// Fixed Array
+			if g.getTypeInfo(t.Elt).TypeName == "byte" {
+				return fmt.Sprintf("bstd.SkipFixed(%s)", g.ExprToString(t.Len))
+			}
+			return fmt.Sprintf("bstd.SkipN(%s, %s)", g.getGoSkipExpr(t.Elt), g.ExprToString(t.Len))
+		}
+
+		// Slice
+		if g.getTypeInfo(t.Elt).TypeName == "byte" {
+			return "bstd.SkipBytes"
+		}
+		return fmt.Sprintf("bstd.SkipSliceOf(%s)", g.getGoSkipExpr(t.Elt))
+	case *ast.MapType:
+		if fn, ok := g.bytesMapFunc(t, "Skip"); ok {
+			return fn
+		}
+		return fmt.Sprintf("bstd.SkipMapOf(%s, %s)", g.getGoSkipExpr(t.Key), g.getGoSkipExpr(t.Value))
+	default:
+		return strings.Replace(info.Unmarshaler, "Unmarshal", "Skip", 1)
+	}
+}
+
+// bytesMapFunc returns the bstd function of the kind, e.g. "Size", specialized for the
+// binary-valued map t, map[string][]byte or map[uint64][]byte, unless the options of the field
+// change the encoding of its keys, the order of its entries or their duplicate keys.
+func (g *generator) bytesMapFunc(t *ast.MapType, kind string) (string, bool) {
+	if g.varint || g.dict || g.utf8 || g.nul || g.canonical || g.duplicates != "" {
+		return "", false
+	}
+	switch g.ExprToString(t) {
+	case "map[string][]byte":
+		return "bstd." + kind + "MapStringBytes", true
+	case "map[uint64][]byte":
+		return "bstd." + kind + "MapUint64Bytes", true
+	}
+	return "", false
+}
+
+// messageFunc returns bstd.<kind>Message instantiated for expr, if expr is a
+// type of the schema and therefore implements bstd.Message.
+func (g *generator) messageFunc(expr ast.Expr, kind string) (string, bool) {
+	id, ok := expr.(*ast.Ident)
+	if !ok {
+		return "", false
+	}
+	if _, ok := g.TypeSpecs[id.Name]; !ok || g.schemaPkg != "" {
+		return "", false
+	}
+	return fmt.Sprintf("bstd.%sMessage[%s]", kind, id.Name), true
+}
+
+// funcDecl starts the declaration of the generated method of the schema type name, or of the
+// function replacing it, if the code is generated into another package.
+func (g *generator) funcDecl(name, receiver, method, params, results string) {
+	if g.schemaPkg == "" {
+		g.printf("func (%s *%s) %s(%s) %s {\n", receiver, name, method, params, results)
+		return
+	}
+	if params != "" {
+		params += ", "
+	}
+	g.printf("func %s%s(%s%s *%s) %s {\n", method, name, params, receiver, g.qualify(name), results)
+}
+
+// methodCall calls the generated method of the schema type typeName on varName, e.g.
+// `v.Size()` or `SizeT(&v)`, if the code is generated into another package.
+func (g *generator) methodCall(typeName, method, varName string, args ...string) string {
+	if g.schemaPkg == "" {
+		return fmt.Sprintf("%s.%s(%s)", varName, method, strings.Join(args, ", "))
+	}
+	ptr := "&" + varName
+	if strings.HasPrefix(varName, "(*") && strings.HasSuffix(varName, ")") {
+		ptr = varName[2 : len(varName)-1]
+	} else if strings.HasPrefix(varName, "*") {
+		ptr = varName[1:]
+	}
+	return fmt.Sprintf("%s%s(%s)", method, typeName, strings.Join(append(args, ptr), ", "))
+}
+
+func (g *generator) receiverName(name string) string {
+	receiver := strings.ToLower(name[:1]) + name[1:]
+	if receiver == g.schemaPkg {
+		receiver += "Value"
+	}
+	return receiver
+}
+
+// qualify prefixes the schema types in the type expression typ with the name of the
+// schema package, if the code is generated into another package.
+func (g *generator) qualify(typ string) string {
+	if g.schemaPkg == "" {
+		return typ
+	}
+
+	fset := token.NewFileSet()
+	file := fset.AddFile("", fset.Base(), len(typ))
+	var s scanner.Scanner
+	s.Init(file, []byte(typ), nil, 0)
+
+	var b strings.Builder
+	last, prev := 0, token.ILLEGAL
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+		_, enum := g.Enums[lit]
+		if _, ok := g.TypeSpecs[lit]; (ok || enum) && tok == token.IDENT && prev != token.PERIOD {
+			off := file.Offset(pos)
+			b.WriteString(typ[last:off])
+			b.WriteString(g.schemaPkg + ".")
+			last = off
+		}
+		prev = tok
+	}
+	b.WriteString(typ[last:])
+	return b.String()
+}
+
+// checkExported returns an error if name can't be accessed by the generated code,
+// because it is unexported and the code is generated into another package.
+func (g *generator) checkExported(name string) error {
+	ident := name[strings.LastIndex(name, ".")+1:]
+	if g.schemaPkg == "" || ident == "_" || ast.IsExported(ident) {
+		return nil
+	}
+	return fmt.Errorf("%s is unexported and can't be accessed from package %s", name, g.PkgName)
+}
+
+// checkMapKeys returns an error if a map inside of expr has a key type that
+// isn't comparable, e.g. a struct containing a slice.
+func (g *generator) checkMapKeys(expr ast.Expr) error {
+	switch t := expr.(type) {
+	case *ast.ArrayType:
+		return g.checkMapKeys(t.Elt)
+	case *ast.StarExpr:
+		return g.checkMapKeys(t.X)
+	case *ast.IndexExpr:
+		if elt, ok := common.OptionElt(t); ok {
+			return g.checkMapKeys(elt)
+		}
+	case *ast.MapType:
+		if common.IsVariantType(t.Key) {
+			return fmt.Errorf("map key %s is a variant, which can't be a key", g.ExprToString(t.Key))
+		}
+		if !g.isComparable(t.Key) {
+			return fmt.Errorf("map key %s is not comparable", g.ExprToString(t.Key))
+		}
+		return g.checkMapKeys(t.Value)
+	}
+	return nil
+}
+
+func (g *generator) isComparable(expr ast.Expr) bool {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		if ts, ok := g.TypeSpecs[t.Name]; ok {
+			return g.isComparable(ts.Type)
+		}
+	case *ast.StructType:
+		for _, field := range t.Fields.List {
+			if !g.isComparable(field.Type) {
+				return false
+			}
+		}
+	case *ast.ArrayType:
+		return t.Len != nil && g.isComparable(t.Elt)
+	case *ast.IndexExpr:
+		elt, ok := common.OptionElt(t)
+		return ok && g.isComparable(elt)
+	case *ast.MapType, *ast.FuncType:
+		return false
+	}
+	return true
+}
+
 // Type Info Logic
 
+// selectorType describes a package-qualified type that bstd handles natively.
+// Name is the suffix of the bstd functions, e.g. "Time" for bstd.SizeTime.
+// Types with HasComparer are compared by btst.Compare<Name> instead of ==,
+// IsGeneric types are generated by btst.Generate<Name>[T] to get the named type.
+// Constructor is passed to bstd.Unmarshal<Name> and btst.Generate<Name> of types
+// that bstd can't create itself, e.g. the decimal types of other packages.
+// TestName replaces Name in the btst functions of types tested as another type.
+type selectorType struct {
+	Name        string
+	IsFixedSize bool
+	HasComparer bool
+	IsGeneric   bool
+	Constructor string
+	TestName    string
+}
+
+var selectorTypes = map[string]selectorType{
+	"time.Time":        {Name: "Time", IsFixedSize: true, HasComparer: true},
+	"time.Duration":    {Name: "Duration", IsFixedSize: true},
+	"uuid.UUID":        {Name: "UUID", IsFixedSize: true, IsGeneric: true},
+	"json.RawMessage":  {Name: "RawMessage", HasComparer: true},
+	"net.IP":           {Name: "IP", HasComparer: true},
+	"net.HardwareAddr": {Name: "HardwareAddr", HasComparer: true},
+	"netip.Addr":       {Name: "Addr"},
+	"netip.AddrPort":   {Name: "AddrPort"},
+	"netip.Prefix":     {Name: "Prefix"},
+	// big numbers and URLs are only used through pointers, so the pointer is part of the type
+	"*big.Int":   {Name: "BigInt", HasComparer: true},
+	"*big.Float": {Name: "BigFloat", HasComparer: true},
+	"*big.Rat":   {Name: "BigRat", HasComparer: true},
+	"*url.URL":   {Name: "URL", HasComparer: true},
+	// fixed-point decimals: bstd's own and the shopspring/decimal style types
+	"bstd.Decimal":    {Name: "Decimal"},
+	"decimal.Decimal": {Name: "BigDecimal", HasComparer: true, Constructor: "decimal.NewFromBigInt"},
+	// sets of integers packed into words
+	"bstd.Bitset": {Name: "Bitset", HasComparer: true},
+	// the Null types of database/sql, marshalled like pointers
+	"sql.NullString":  {Name: "NullString"},
+	"sql.NullInt64":   {Name: "NullInt64"},
+	"sql.NullInt32":   {Name: "NullInt32"},
+	"sql.NullInt16":   {Name: "NullInt16"},
+	"sql.NullByte":    {Name: "NullByte"},
+	"sql.NullFloat64": {Name: "NullFloat64"},
+	"sql.NullBool":    {Name: "NullBool"},
+	"sql.NullTime":    {Name: "NullTime", HasComparer: true},
+}
+
+// zonedTime is the selectorType of the time.Time of zone fields, see common.IsZoneField.
+var zonedTime = selectorType{Name: "TimeWithZone", HasComparer: true}
+
+// compactTimes are the selectorTypes of the time.Time of compact time fields by their unit,
+// see common.FieldCompactTime. They are generated and compared as plain times.
+var compactTimes = map[string]selectorType{
+	"s":  {Name: "TimeSeconds", IsFixedSize: true, HasComparer: true, TestName: "Time"},
+	"ms": {Name: "TimeMillis", IsFixedSize: true, HasComparer: true, TestName: "Time"},
+	"us": {Name: "TimeMicros", IsFixedSize: true, HasComparer: true, TestName: "Time"},
+}
+
+// selectorType returns the selectorType of the package-qualified type sel, honoring the
+// encoding options of the field currently generated.
+func (g *generator) selectorType(sel string) (selectorType, bool) {
+	if g.zone && sel == "time.Time" {
+		return zonedTime, true
+	}
+	if g.compact != "" && sel == "time.Time" {
+		return compactTimes[g.compact], true
+	}
+	st, ok := selectorTypes[sel]
+	return st, ok
+}
+
+// unmarshalArgs returns the arguments of bstd.Unmarshal<Name>.
+func (st selectorType) unmarshalArgs(n, buf string) string {
+	if st.Constructor != "" {
+		return fmt.Sprintf("%s, %s, %s", n, buf, st.Constructor)
+	}
+	return n + ", " + buf
+}
+
+func (st selectorType) typeInfo(typeName string) typeGenInfo {
+	testName := st.Name
+	if st.TestName != "" {
+		testName = st.TestName
+	}
+	comparer := fmt.Sprintf("btst.ComparePrimitive[%s]", typeName)
+	if st.HasComparer {
+		comparer = "btst.Compare" + testName
+	}
+	generator := "btst.Generate" + testName
+	if st.IsGeneric {
+		generator += "[" + typeName + "]"
+	}
+	if st.Constructor != "" {
+		generator += "(" + st.Constructor + ")"
+	}
+	return typeGenInfo{
+		TypeName:      typeName,
+		Marshaler:     "bstd.Marshal" + st.Name,
+		Unmarshaler:   "bstd.Unmarshal" + st.Name,
+		TestGenerator: generator,
+		TestComparer:  comparer,
+		IsFixedSize:   st.IsFixedSize,
+	}
+}
+
 type typeGenInfo struct {
 	TypeName      string
 	Marshaler     string
@@ -445,17 +2538,33 @@ type typeGenInfo struct {
 }
 
 func (g *generator) getTypeInfo(expr ast.Expr) typeGenInfo {
-	typeName := g.ExprToString(expr)
+	typeName := g.qualify(g.ExprToString(expr))
+	if common.IsVariantType(expr) {
+		return typeGenInfo{
+			TypeName:      "any",
+			Marshaler:     "bstd.MarshalVariant",
+			Unmarshaler:   "bstd.UnmarshalVariant",
+			TestGenerator: "btst.GenerateVariant",
+			TestComparer:  "btst.CompareVariant",
+		}
+	}
 
 	switch t := expr.(type) {
 	case *ast.Ident:
 		if _, ok := g.TypeSpecs[t.Name]; ok {
 			return typeGenInfo{
-				TypeName:      t.Name,
+				TypeName:      typeName,
 				TestGenerator: fmt.Sprintf("Generate%s", t.Name),
 				TestComparer:  fmt.Sprintf("Compare%s", t.Name),
 			}
 		}
+		if _, ok := g.Enums[t.Name]; ok {
+			return typeGenInfo{
+				TypeName:      typeName,
+				TestGenerator: fmt.Sprintf("btst.GenerateEnum(%s)", enumTable(t.Name)),
+				TestComparer:  fmt.Sprintf("btst.ComparePrimitive[%s]", typeName),
+			}
+		}
 		title := strings.Title(t.Name)
 		if t.Name == "byte" || t.Name == "uint8" {
 			title = "Byte"
@@ -468,16 +2577,27 @@ func (g *generator) getTypeInfo(expr ast.Expr) typeGenInfo {
 		if t.Name == "uintptr" {
 			title = "Uintptr"
 		}
+		if t.Name == "float32" && g.bfloat16 {
+			title = "BFloat16"
+		}
+		testGen := "btst.Generate" + title
+		if t.Name == "string" && g.nul {
+			// the NUL terminator is added by the marshaller
+			title = "StringNul"
+		}
 		return typeGenInfo{
 			TypeName:      typeName,
 			Marshaler:     "bstd.Marshal" + title,
 			Unmarshaler:   "bstd.Unmarshal" + title,
-			TestGenerator: "btst.Generate" + title,
+			TestGenerator: testGen,
 			TestComparer:  fmt.Sprintf("btst.ComparePrimitive[%s]", typeName),
 			IsFixedSize:   common.FixedSizeTypes[t.Name],
 		}
 
 	case *ast.StarExpr:
+		if st, ok := g.selectorType(typeName); ok {
+			return st.typeInfo(typeName)
+		}
 		eltInfo := g.getTypeInfo(t.X)
 		return typeGenInfo{
 			TypeName:      "*" + eltInfo.TypeName,
@@ -485,17 +2605,27 @@ func (g *generator) getTypeInfo(expr ast.Expr) typeGenInfo {
 			TestComparer:  fmt.Sprintf("func(a, b *%s) error { return btst.ComparePointer(a, b, %s) }", eltInfo.TypeName, eltInfo.TestComparer),
 		}
 
+	case *ast.IndexExpr:
+		elt, ok := common.OptionElt(t)
+		if !ok {
+			break
+		}
+		eltInfo := g.getTypeInfo(elt)
+		return typeGenInfo{
+			TypeName:      typeName,
+			TestGenerator: fmt.Sprintf("func(r *rand.Rand, d int) %s { return btst.GenerateOption(r, d, %s) }", typeName, eltInfo.TestGenerator),
+			TestComparer:  fmt.Sprintf("func(a, b %s) error { return btst.CompareOption(a, b, %s) }", typeName, eltInfo.TestComparer),
+		}
+
 	case *ast.SelectorExpr:
 		sel := g.ExprToString(t)
-		if sel == "time.Time" {
-			return typeGenInfo{
-				TypeName:      "time.Time",
-				Marshaler:     "bstd.MarshalTime",
-				Unmarshaler:   "bstd.UnmarshalTime",
-				TestGenerator: "btst.GenerateTime",
-				TestComparer:  "btst.ComparePrimitive[time.Time]",
-				IsFixedSize:   true,
+		if st, ok := g.selectorType(sel); ok {
+			info := st.typeInfo(sel)
+			if g.precision != "" && sel == "time.Time" {
+				// the times are generated truncated, as they unmarshal
+				info.TestGenerator = fmt.Sprintf("func(r *rand.Rand, d int) time.Time { return %s(r, d).Truncate(%s) }", info.TestGenerator, g.precision)
 			}
+			return info
 		}
 		return typeGenInfo{
 			TypeName:      sel,
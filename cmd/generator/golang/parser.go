@@ -1,30 +1,196 @@
 package golang
 
 import (
+	"fmt"
 	"go/ast"
 	"go/parser"
 	"go/token"
 	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
 
 	"github.com/banditmoscow1337/benc/cmd/generator/common"
 )
 
 func Parse(ctx *common.Context) {
+	if err := ParseFile(ctx); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// ParseFile is Parse returning the error of a schema, which doesn't parse, instead of exiting,
+// e.g. for reloading a schema while it is edited.
+func ParseFile(ctx *common.Context) error {
 	log.Printf("Parsing GO input: %s", ctx.InputFile)
 
 	fset := token.NewFileSet()
-	node, err := parser.ParseFile(fset, ctx.InputFile, nil, parser.ParseComments)
+	var src any
+	if ctx.Source != nil {
+		src = ctx.Source
+	}
+	node, err := parser.ParseFile(fset, ctx.InputFile, src, parser.ParseComments)
 	if err != nil {
-		log.Fatalf("failed to parse input file %s: %v", ctx.InputFile, err)
+		return fmt.Errorf("failed to parse input file %s: %v", ctx.InputFile, err)
 	}
 
 	ctx.PkgName = node.Name.Name
 	ctx.Types = collectTypes(node)
+	collectImports(ctx, node)
+	if err = collectEnums(ctx, node); err != nil {
+		return fmt.Errorf("failed to parse input file %s: %v", ctx.InputFile, err)
+	}
+	if err = collectExamples(ctx, node); err != nil {
+		return fmt.Errorf("failed to parse input file %s: %v", ctx.InputFile, err)
+	}
+	return nil
+}
+
+// enumTypes are the underlying types of the //benc:enum types.
+var enumTypes = map[string]bool{
+	"int": true, "int8": true, "int16": true, "int32": true, "int64": true,
+	"uint": true, "uint8": true, "uint16": true, "uint32": true, "uint64": true, "byte": true,
+}
+
+// collectEnums collects the types with a //benc:enum comment and their constants in the order
+// of their declaration, which numbers their ordinals. A constant spec without type and value
+// repeats the type of the spec before it, like iota does; blank constants are left out.
+func collectEnums(ctx *common.Context, node *ast.File) error {
+	ctx.Enums = make(map[string]*common.Enum)
+	for _, decl := range node.Decls {
+		gd, ok := decl.(*ast.GenDecl)
+		if !ok || gd.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range gd.Specs {
+			ts := spec.(*ast.TypeSpec)
+			if _, ok := ctx.TypeDirective(ts, "enum"); !ok {
+				continue
+			}
+			if id, ok := ts.Type.(*ast.Ident); !ok || !enumTypes[id.Name] || ts.Assign.IsValid() {
+				return fmt.Errorf("%s: //benc:enum type is no defined integer type", ts.Name.Name)
+			}
+			ctx.Enums[ts.Name.Name] = &common.Enum{Name: ts.Name.Name}
+		}
+	}
+
+	for _, decl := range node.Decls {
+		gd, ok := decl.(*ast.GenDecl)
+		if !ok || gd.Tok != token.CONST {
+			continue
+		}
+		typ := ""
+		for _, spec := range gd.Specs {
+			vs := spec.(*ast.ValueSpec)
+			if vs.Type != nil {
+				typ = ctx.ExprToString(vs.Type)
+			} else if len(vs.Values) > 0 {
+				typ = ""
+			}
+			e, ok := ctx.Enums[typ]
+			if !ok {
+				continue
+			}
+			for _, name := range vs.Names {
+				if name.Name != "_" {
+					e.Values = append(e.Values, name.Name)
+				}
+			}
+		}
+	}
+
+	for name, e := range ctx.Enums {
+		if len(e.Values) == 0 {
+			return fmt.Errorf("%s: //benc:enum type has no constants", name)
+		}
+	}
+	return nil
+}
+
+// collectExamples resolves the //benc:example <var>... comments of the types
+// to the values of the variables they reference.
+func collectExamples(ctx *common.Context, node *ast.File) error {
+	vars := make(map[string]ast.Expr)
+	for _, decl := range node.Decls {
+		gd, ok := decl.(*ast.GenDecl)
+		if !ok || gd.Tok != token.VAR {
+			continue
+		}
+		for _, spec := range gd.Specs {
+			vs := spec.(*ast.ValueSpec)
+			for i, name := range vs.Names {
+				if i < len(vs.Values) {
+					vars[name.Name] = vs.Values[i]
+				}
+			}
+		}
+	}
+
+	for _, decl := range node.Decls {
+		gd, ok := decl.(*ast.GenDecl)
+		if !ok || gd.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range gd.Specs {
+			ts := spec.(*ast.TypeSpec)
+			doc := ts.Doc
+			if doc == nil && len(gd.Specs) == 1 {
+				doc = gd.Doc
+			}
+			if doc == nil {
+				continue
+			}
+			for _, cm := range doc.List {
+				args, ok := strings.CutPrefix(cm.Text, "//benc:example")
+				if !ok || args != "" && args[0] != ' ' {
+					continue
+				}
+				names := strings.Fields(args)
+				if len(names) == 0 {
+					return fmt.Errorf("%s: //benc:example requires the name of a variable", ts.Name.Name)
+				}
+				for _, name := range names {
+					value, ok := vars[name]
+					if !ok {
+						return fmt.Errorf("%s: example variable %s not found", ts.Name.Name, name)
+					}
+					ctx.Examples[ts.Name.Name] = append(ctx.Examples[ts.Name.Name], common.Example{Name: name, Value: value})
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func collectImports(ctx *common.Context, node *ast.File) {
+	for _, imp := range node.Imports {
+		path, err := strconv.Unquote(imp.Path.Value)
+		if err != nil {
+			continue
+		}
+		name := path[strings.LastIndex(path, "/")+1:]
+		if imp.Name != nil {
+			name = imp.Name.Name
+		}
+		if name == "_" || name == "." {
+			continue
+		}
+		ctx.Imports[name] = path
+	}
 }
 
 func collectTypes(node *ast.File) []*ast.TypeSpec {
 	var types []*ast.TypeSpec
 	ast.Inspect(node, func(n ast.Node) bool {
+		if gd, ok := n.(*ast.GenDecl); ok && gd.Tok == token.TYPE && len(gd.Specs) == 1 {
+			// the comment of `type T struct` belongs to the declaration, move it to the type for TypeDirective
+			if ts := gd.Specs[0].(*ast.TypeSpec); ts.Doc == nil {
+				ts.Doc = gd.Doc
+			}
+			return true
+		}
 		ts, ok := n.(*ast.TypeSpec)
 		if !ok {
 			return true
@@ -36,4 +202,31 @@ func collectTypes(node *ast.File) []*ast.TypeSpec {
 		return false
 	})
 	return types
-}
\ No newline at end of file
+}
+
+// ImportPath returns the import path of the package in dir, derived from the
+// module path of the nearest go.mod.
+func ImportPath(dir string) (string, error) {
+	start, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	dir = start
+	for rel := ""; ; {
+		data, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+		if err == nil {
+			for line := range strings.SplitSeq(string(data), "\n") {
+				if mod, ok := strings.CutPrefix(strings.TrimSpace(line), "module "); ok {
+					return path.Join(strings.Trim(strings.TrimSpace(mod), `"`), rel), nil
+				}
+			}
+			return "", fmt.Errorf("no module path in %s", filepath.Join(dir, "go.mod"))
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", fmt.Errorf("no go.mod found for %s", start)
+		}
+		rel = path.Join(filepath.Base(dir), rel)
+		dir = parent
+	}
+}
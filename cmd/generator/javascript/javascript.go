@@ -9,13 +9,28 @@ import (
 	"github.com/banditmoscow1337/benc/cmd/generator/common"
 )
 
+// Representations of maps with 64-bit integer keys (int, int64, uint, uint64) in JS,
+// numbers can't be used as keys, because they lose precision above 2^53.
+const (
+	Int64KeysBigInt = "bigint" // a Map with BigInt keys
+	Int64KeysString = "string" // a plain object with decimal string keys
+)
+
+type Options struct {
+	// Int64MapKeys is the representation of maps with 64-bit integer keys, Int64KeysBigInt by default.
+	Int64MapKeys string
+}
+
 type generator struct {
 	*common.Context
+	Options
 	buf bytes.Buffer
 }
 
-func New(ctx *common.Context) *generator {
-	return &generator{Context: ctx}
+func New(ctx *common.Context, opts Options) *generator {
+	out := *ctx
+	out.Variants = true
+	return &generator{Context: &out, Options: opts}
 }
 
 // -----------------------------------------------------------------------------
@@ -24,15 +39,29 @@ func New(ctx *common.Context) *generator {
 
 
 func (g *generator) Generate() (err error) {
-	g.printf("/**\n * Code generated by benc generator; DO NOT EDIT.\n */\n\n")
+	if err = g.CheckGoOnlyEncodings("js"); err != nil {
+		return
+	}
+	g.printf("/**\n * Code generated by benc generator; DO NOT EDIT.\n%s */\n\n", g.FingerprintComment(" * "))
 	g.printf("const bstd = require('./std.js');\n\n")
 
+	for _, e := range g.SortedEnums() {
+		names := make([]string, len(e.Values))
+		for i, v := range e.Values {
+			names[i] = "'" + v + "'"
+		}
+		g.printf("/** The names of the %s constants, marshalled as their ordinal, see bstd.enumTable. */\n", e.Name)
+		g.printf("const %s = bstd.enumTable([%s]);\n\n", e.Name, strings.Join(names, ", "))
+	}
 	for _, ts := range g.Types {
 		if err = g.generateJSClass(ts); err != nil {
 			return
 		}
 	}
 	g.printf("module.exports = {\n")
+	for _, e := range g.SortedEnums() {
+		g.printf("\t%s,\n", e.Name)
+	}
 	for _, ts := range g.Types {
 		g.printf("\t%s,\n", ts.Name.Name)
 	}
@@ -55,7 +84,7 @@ func (g *generator) generateJSClass(ts *ast.TypeSpec) error {
 	for _, field := range supportedFields {
 		for _, fName := range field.Names {
 			def := g.getJSDefaultValue(field.Type)
-			g.printf("\t\tthis.%s = %s;\n", fName.Name, def)
+			g.printf("\t\tthis.%s = %s;\n", g.FieldName(fName.Name), def)
 		}
 	}
 	g.printf("\t}\n\n")
@@ -65,7 +94,7 @@ func (g *generator) generateJSClass(ts *ast.TypeSpec) error {
 	for _, field := range supportedFields {
 		for _, fName := range field.Names {
 			// JS access is this.FieldName
-			accessor := fmt.Sprintf("this.%s", fName.Name)
+			accessor := fmt.Sprintf("this.%s", g.FieldName(fName.Name))
 			g.printf("\t\ts += %s;\n", g.getJSSizeExpr(field.Type, accessor))
 		}
 	}
@@ -75,7 +104,7 @@ func (g *generator) generateJSClass(ts *ast.TypeSpec) error {
 	g.printf("\tmarshal(n, b) {\n")
 	for _, field := range supportedFields {
 		for _, fName := range field.Names {
-			accessor := fmt.Sprintf("this.%s", fName.Name)
+			accessor := fmt.Sprintf("this.%s", g.FieldName(fName.Name))
 			g.printf("\t\tn = %s;\n", g.getJSMarshalExpr(field.Type, "n", "b", accessor))
 		}
 	}
@@ -86,7 +115,7 @@ func (g *generator) generateJSClass(ts *ast.TypeSpec) error {
 	g.printf("\t\tlet v;\n") // generic temp var
 	for _, field := range supportedFields {
 		for _, fName := range field.Names {
-			target := fmt.Sprintf("this.%s", fName.Name)
+			target := fmt.Sprintf("this.%s", g.FieldName(fName.Name))
 			g.printf("\t\t%s\n", g.getJSUnmarshalExpr(field.Type, "n", "b", target))
 		}
 	}
@@ -101,7 +130,7 @@ func (g *generator) generateJSClass(ts *ast.TypeSpec) error {
 // -----------------------------------------------------------------------------
 
 func (g *generator) Tests() error {
-	g.printf("/**\n * Code generated by benc generator; DO NOT EDIT.\n */\n\n")
+	g.printf("/**\n * Code generated by benc generator; DO NOT EDIT.\n%s */\n\n", g.FingerprintComment(" * "))
 	g.printf("const bstd = require('./std.js');\n")
 	g.printf("const gen = require('./gen.js');\n")
 	g.printf("const { %s } = require('./%s_benc.js');\n\n", g.getAllTypeNames(), g.PkgName)
@@ -126,7 +155,7 @@ func (g *generator) Tests() error {
 			name := ts.Name.Name
 			g.printf("\ttest('%s Serialization', () => {\n", name)
 			g.printf("\t\tconst original = Generate%s(gen.MaxDepth);\n", name)
-			g.printf("\t\tconst s = original.size();\n", name)
+			g.printf("\t\tconst s = original.size();\n")
 			g.printf("\t\tconst buf = new Uint8Array(s);\n")
 			g.printf("\t\tconst n = original.marshal(0, buf);\n")
 			g.printf("\t\texpect(n).toBe(s);\n\n")
@@ -136,6 +165,7 @@ func (g *generator) Tests() error {
 			g.printf("\t\tconst err = Compare%s(original, copy);\n", name)
 			g.printf("\t\texpect(err).toBeNull();\n")
 			g.printf("\t});\n\n")
+			g.generateJSTestLargeMaps(ts)
 		}
 	}
 
@@ -144,6 +174,33 @@ func (g *generator) Tests() error {
 	return g.WriteFile(&g.buf, "_benc_test", ".js")
 }
 
+// generateJSTestLargeMaps generates a round trip test of the struct ts with maps of
+// gen.LargeMapCount entries, whose values are compared instead of the bytes, as the entries of
+// a map are marshalled in no fixed order.
+func (g *generator) generateJSTestLargeMaps(ts *ast.TypeSpec) {
+	fields := g.LargeMapFields(ts)
+	if len(fields) == 0 {
+		return
+	}
+	name := ts.Name.Name
+	g.printf("\ttest('%s Large Maps', () => {\n", name)
+	g.printf("\t\tconst original = Generate%s(gen.MaxDepth);\n", name)
+	g.printf("\t\tconst depth = 2; // the keys and values are generated at depth 1\n")
+	for _, field := range fields {
+		for _, fName := range field.Names {
+			g.printf("\t\toriginal.%s = %s;\n", g.FieldName(fName.Name), g.mapGenerator(field.Type.(*ast.MapType), "GenerateLarge"))
+		}
+	}
+	g.printf("\t\tconst s = original.size();\n")
+	g.printf("\t\tconst buf = new Uint8Array(s);\n")
+	g.printf("\t\texpect(original.marshal(0, buf)).toBe(s);\n\n")
+	g.printf("\t\tconst copy = new %s();\n", name)
+	g.printf("\t\tconst [readN, _] = copy.unmarshal(0, buf);\n")
+	g.printf("\t\texpect(readN).toBe(s);\n")
+	g.printf("\t\texpect(Compare%s(original, copy)).toBeNull();\n", name)
+	g.printf("\t});\n\n")
+}
+
 func (g *generator) generateJSTestGenerator(ts *ast.TypeSpec) {
 	name := ts.Name.Name
 	g.printf("function Generate%s(depth) {\n", name)
@@ -158,7 +215,7 @@ func (g *generator) generateJSTestGenerator(ts *ast.TypeSpec) {
 			}
 			for _, fName := range field.Names {
 				gen := g.getJSTypeInfo(field.Type).TestGenerator
-				g.printf("\tobj.%s = %s;\n", fName.Name, gen)
+				g.printf("\tobj.%s = %s;\n", g.FieldName(fName.Name), gen)
 			}
 		}
 		g.printf("\treturn obj;\n")
@@ -181,7 +238,7 @@ func (g *generator) generateJSTestComparer(ts *ast.TypeSpec) {
 			}
 			for _, fName := range field.Names {
 				comparer := g.getJSTypeInfo(field.Type).TestComparer
-				g.printf("\terr = gen.CompareField('%s', () => %s(a.%s, b.%s));\n", fName.Name, comparer, fName.Name, fName.Name)
+				g.printf("\terr = gen.CompareField('%s', () => (%s)(a.%s, b.%s));\n", g.FieldName(fName.Name), comparer, g.FieldName(fName.Name), g.FieldName(fName.Name))
 				g.printf("\tif (err) return err;\n")
 			}
 		}
@@ -202,9 +259,12 @@ func (g *generator) printf(format string, args ...interface{}) {
 }
 
 func (g *generator) getAllTypeNames() string {
-	names := make([]string, len(g.Types))
-	for i, t := range g.Types {
-		names[i] = t.Name.Name
+	var names []string
+	for _, e := range g.SortedEnums() {
+		names = append(names, e.Name)
+	}
+	for _, t := range g.Types {
+		names = append(names, t.Name.Name)
 	}
 	return strings.Join(names, ", ")
 }
@@ -216,6 +276,12 @@ func (g *generator) getJSDefaultValue(expr ast.Expr) string {
 	if _, ok := g.TypeSpecs[typeName]; ok {
 		return "null"
 	}
+	if e, ok := g.EnumOf(expr); ok {
+		return e.Name + ".names[0]"
+	}
+	if common.IsVariantType(expr) {
+		return "null"
+	}
 
 	switch t := expr.(type) {
 	case *ast.Ident:
@@ -239,6 +305,9 @@ func (g *generator) getJSDefaultValue(expr ast.Expr) string {
 		}
 		return "[]"
 	case *ast.MapType:
+		if g.isObjectMap(t) {
+			return "{}"
+		}
 		return "new Map()"
 	case *ast.StarExpr:
 		return "null"
@@ -257,6 +326,12 @@ func (g *generator) getJSSizeExpr(expr ast.Expr, accessor string) string {
 	if _, ok := g.TypeSpecs[typeName]; ok {
 		return fmt.Sprintf("%s.size()", accessor)
 	}
+	if e, ok := g.EnumOf(expr); ok {
+		return fmt.Sprintf("%s.size(%s)", e.Name, accessor)
+	}
+	if common.IsVariantType(expr) {
+		return fmt.Sprintf("bstd.sizeVariant(%s)", accessor)
+	}
 
 	switch t := expr.(type) {
 	case *ast.Ident:
@@ -285,7 +360,7 @@ func (g *generator) getJSSizeExpr(expr ast.Expr, accessor string) string {
 	case *ast.MapType:
 		keySizer := fmt.Sprintf("(k) => %s", g.getJSSizeExpr(t.Key, "k"))
 		valSizer := fmt.Sprintf("(v) => %s", g.getJSSizeExpr(t.Value, "v"))
-		return fmt.Sprintf("bstd.sizeMap(%s, %s, %s)", accessor, keySizer, valSizer)
+		return fmt.Sprintf("bstd.size%s(%s, %s, %s)", g.mapKind(t), accessor, keySizer, valSizer)
 	case *ast.SelectorExpr:
 		if g.ExprToString(t) == "time.Time" {
 			return "bstd.sizeTime()"
@@ -301,6 +376,12 @@ func (g *generator) getJSMarshalExpr(expr ast.Expr, n, b, accessor string) strin
 	if _, ok := g.TypeSpecs[typeName]; ok {
 		return fmt.Sprintf("%s.marshal(%s, %s)", accessor, n, b)
 	}
+	if e, ok := g.EnumOf(expr); ok {
+		return fmt.Sprintf("%s.marshal(%s, %s, %s)", e.Name, n, b, accessor)
+	}
+	if common.IsVariantType(expr) {
+		return fmt.Sprintf("bstd.marshalVariant(%s, %s, %s)", n, b, accessor)
+	}
 
 	switch t := expr.(type) {
 	case *ast.Ident:
@@ -329,7 +410,7 @@ func (g *generator) getJSMarshalExpr(expr ast.Expr, n, b, accessor string) strin
 	case *ast.MapType:
 		keyMarshal := fmt.Sprintf("(n, b, k) => %s", g.getJSMarshalExpr(t.Key, "n", "b", "k"))
 		valMarshal := fmt.Sprintf("(n, b, v) => %s", g.getJSMarshalExpr(t.Value, "n", "b", "v"))
-		return fmt.Sprintf("bstd.marshalMap(%s, %s, %s, %s, %s)", n, b, accessor, keyMarshal, valMarshal)
+		return fmt.Sprintf("bstd.marshal%s(%s, %s, %s, %s, %s)", g.mapKind(t), n, b, accessor, keyMarshal, valMarshal)
 	case *ast.SelectorExpr:
 		if g.ExprToString(t) == "time.Time" {
 			return fmt.Sprintf("bstd.marshalTime(%s, %s, %s)", n, b, accessor)
@@ -345,6 +426,12 @@ func (g *generator) getJSUnmarshalExpr(expr ast.Expr, n, b, target string) strin
 	if _, ok := g.TypeSpecs[typeName]; ok {
 		return fmt.Sprintf("[%s, _] = %s.unmarshal(%s, %s);", n, target, n, b)
 	}
+	if e, ok := g.EnumOf(expr); ok {
+		return fmt.Sprintf("[%s, %s] = %s.unmarshal(%s, %s);", n, target, e.Name, n, b)
+	}
+	if common.IsVariantType(expr) {
+		return fmt.Sprintf("[%s, %s] = bstd.unmarshalVariant(%s, %s);", n, target, n, b)
+	}
 
 	if _, ok := g.TypeSpecs[typeName]; ok {
 		return fmt.Sprintf("%s = new %s();\n\t\t[%s, _] = %s.unmarshal(%s, %s);", target, typeName, n, target, n, b)
@@ -374,7 +461,7 @@ func (g *generator) getJSUnmarshalExpr(expr ast.Expr, n, b, target string) strin
 	case *ast.MapType:
 		keyUnmarshal := fmt.Sprintf("(n, b) => {\n\t\t\tlet v;\n\t\t\t%s\n\t\t\treturn [n, v];\n\t\t}", g.getJSUnmarshalExpr(t.Key, "n", "b", "v"))
 		valUnmarshal := fmt.Sprintf("(n, b) => {\n\t\t\tlet v;\n\t\t\t%s\n\t\t\treturn [n, v];\n\t\t}", g.getJSUnmarshalExpr(t.Value, "n", "b", "v"))
-		return fmt.Sprintf("[%s, %s] = bstd.unmarshalMap(%s, %s, %s, %s);", n, target, n, b, keyUnmarshal, valUnmarshal)
+		return fmt.Sprintf("[%s, %s] = bstd.unmarshal%s(%s, %s, %s, %s);", n, target, g.mapKind(t), n, b, keyUnmarshal, valUnmarshal)
 
 	case *ast.SelectorExpr:
 		if g.ExprToString(t) == "time.Time" {
@@ -392,6 +479,12 @@ type typeGenInfo struct {
 }
 
 func (g *generator) getJSTypeInfo(expr ast.Expr) typeGenInfo {
+	if common.IsVariantType(expr) {
+		return typeGenInfo{
+			TestGenerator: "gen.GenerateVariant(depth - 1)",
+			TestComparer:  "gen.CompareVariant",
+		}
+	}
 	switch t := expr.(type) {
 	case *ast.Ident:
 		if _, ok := g.TypeSpecs[t.Name]; ok {
@@ -400,6 +493,12 @@ func (g *generator) getJSTypeInfo(expr ast.Expr) typeGenInfo {
 				TestComparer:  fmt.Sprintf("Compare%s", t.Name),
 			}
 		}
+		if _, ok := g.Enums[t.Name]; ok {
+			return typeGenInfo{
+				TestGenerator: fmt.Sprintf("gen.GenerateEnum(depth - 1, %s)", t.Name),
+				TestComparer:  "gen.ComparePrimitive",
+			}
+		}
 		title := strings.Title(t.Name)
 		switch t.Name {
 		case "byte", "uint8":
@@ -430,11 +529,9 @@ func (g *generator) getJSTypeInfo(expr ast.Expr) typeGenInfo {
 			TestComparer:  fmt.Sprintf("(a, b) => gen.CompareSlice(a, b, %s)", eltInfo.TestComparer),
 		}
 	case *ast.MapType:
-		keyInfo := g.getJSTypeInfo(t.Key)
-		valInfo := g.getJSTypeInfo(t.Value)
 		return typeGenInfo{
-			TestGenerator: fmt.Sprintf("gen.GenerateMap(depth - 1, (d) => %s, (d) => %s)", keyInfo.TestGenerator, valInfo.TestGenerator),
-			TestComparer:  fmt.Sprintf("(a, b) => gen.CompareMap(a, b, %s)", valInfo.TestComparer),
+			TestGenerator: g.mapGenerator(t, "Generate"),
+			TestComparer:  fmt.Sprintf("(a, b) => gen.Compare%s(a, b, %s)", g.mapKind(t), g.getJSTypeInfo(t.Value).TestComparer),
 		}
 	case *ast.SelectorExpr:
 		if g.ExprToString(t) == "time.Time" {
@@ -447,6 +544,40 @@ func (g *generator) getJSTypeInfo(expr ast.Expr) typeGenInfo {
 	return typeGenInfo{TestGenerator: "null", TestComparer: "(a,b) => null"}
 }
 
+// mapGenerator returns the call of the gen function fn, Generate or GenerateLarge, generating
+// the map.
+func (g *generator) mapGenerator(t *ast.MapType, fn string) string {
+	keyGen := g.getJSTypeInfo(t.Key).TestGenerator
+	if is64BitInt(t.Key) {
+		// The generators return numbers for int and uint, but keys must be exact
+		keyGen = fmt.Sprintf("BigInt(%s)", keyGen)
+	}
+	return fmt.Sprintf("gen.%s%s(depth - 1, (d) => %s, (d) => %s)", fn, g.mapKind(t), keyGen, g.getJSTypeInfo(t.Value).TestGenerator)
+}
+
+// isObjectMap reports whether the map is represented as a plain object, see Int64KeysString.
+func (g *generator) isObjectMap(t *ast.MapType) bool {
+	return g.Int64MapKeys == Int64KeysString && is64BitInt(t.Key)
+}
+
+// mapKind returns the suffix of the bstd and gen map functions for the map.
+func (g *generator) mapKind(t *ast.MapType) string {
+	if g.isObjectMap(t) {
+		return "ObjectMap"
+	}
+	return "Map"
+}
+
+func is64BitInt(expr ast.Expr) bool {
+	if ident, ok := expr.(*ast.Ident); ok {
+		switch ident.Name {
+		case "int", "int64", "uint", "uint64", "uintptr":
+			return true
+		}
+	}
+	return false
+}
+
 func isByteSlice(t *ast.ArrayType) bool {
 	if ident, ok := t.Elt.(*ast.Ident); ok {
 		return ident.Name == "byte" || ident.Name == "uint8"
@@ -5,7 +5,6 @@ import (
 	"go/ast"
 	"go/parser"
 	"log"
-	"os"
 	"strings"
 	"text/scanner"
 
@@ -18,7 +17,7 @@ import (
 func Parse(ctx *common.Context) (err error) {
 	log.Printf("Parsing JS input: %s", ctx.InputFile)
 
-	file, err := os.Open(ctx.InputFile)
+	file, err := ctx.OpenInput()
 	if err != nil {
 		return
 	}
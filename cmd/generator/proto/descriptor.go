@@ -0,0 +1,329 @@
+// Package proto exports the shapes of a schema as protocol buffers descriptors, so proto
+// tooling like grpcurl or buf can show benc messages. The descriptors describe the fields
+// only, protobuf can't decode the benc encoding.
+package proto
+
+import (
+	"encoding/binary"
+	"go/ast"
+	"maps"
+	"slices"
+
+	"github.com/banditmoscow1337/benc/cmd/generator/common"
+)
+
+// Field types and labels of descriptor.proto.
+const (
+	typeDouble  = 1
+	typeFloat   = 2
+	typeInt64   = 3
+	typeUint64  = 4
+	typeInt32   = 5
+	typeBool    = 8
+	typeString  = 9
+	typeMessage = 11
+	typeBytes   = 12
+	typeUint32  = 13
+
+	labelOptional = 1
+	labelRepeated = 3
+)
+
+// scalarTypes maps the Go types to the protobuf types holding their values.
+var scalarTypes = map[string]int{
+	"bool":    typeBool,
+	"int8":    typeInt32,
+	"int16":   typeInt32,
+	"int32":   typeInt32,
+	"rune":    typeInt32,
+	"int":     typeInt64,
+	"int64":   typeInt64,
+	"byte":    typeUint32,
+	"uint8":   typeUint32,
+	"uint16":  typeUint32,
+	"uint32":  typeUint32,
+	"uint":    typeUint64,
+	"uint64":  typeUint64,
+	"float32": typeFloat,
+	"float64": typeDouble,
+	"string":  typeString,
+}
+
+// wellKnown are the well-known types standing in for Go types, by their file.
+var wellKnown = map[string]struct{ file, name string }{
+	"time.Time":     {"google/protobuf/timestamp.proto", "Timestamp"},
+	"time.Duration": {"google/protobuf/duration.proto", "Duration"},
+}
+
+// sqlNullTypes are the Null types of database/sql, optional fields of the type of their value.
+var sqlNullTypes = map[string]ast.Expr{
+	"sql.NullString":  ast.NewIdent("string"),
+	"sql.NullInt64":   ast.NewIdent("int64"),
+	"sql.NullInt32":   ast.NewIdent("int32"),
+	"sql.NullInt16":   ast.NewIdent("int16"),
+	"sql.NullByte":    ast.NewIdent("byte"),
+	"sql.NullFloat64": ast.NewIdent("float64"),
+	"sql.NullBool":    ast.NewIdent("bool"),
+	"sql.NullTime":    &ast.SelectorExpr{X: ast.NewIdent("time"), Sel: ast.NewIdent("Time")},
+}
+
+// FileDescriptorSet returns the serialized google.protobuf.FileDescriptorSet of the schema:
+// a proto3 file with a message per struct, in the package of the schema, plus the
+// well-known types it uses. The mapping is best-effort:
+//
+//   - fields are numbered from 1 in the order of the struct and named in snake_case
+//   - integers become the protobuf integer of their size, pointers, bstd.Option and the
+//     sql.Null types optional fields
+//   - slices and arrays become repeated fields, maps map fields
+//   - time.Time and time.Duration become google.protobuf.Timestamp and Duration
+//   - anything protobuf has no equivalent for, like nested slices, map keys of other types
+//     than integers, bools and strings or the other package types, becomes bytes
+func FileDescriptorSet(ctx *common.Context) []byte {
+	e := &exporter{Context: ctx, deps: make(map[string]bool)}
+
+	var file message
+	file.str(1, ctx.BaseName+".proto")
+	if ctx.PkgName != "" {
+		file.str(2, ctx.PkgName)
+	}
+	var messages []message
+	for _, ts := range ctx.Types {
+		st, ok := ts.Type.(*ast.StructType)
+		if !ok {
+			continue
+		}
+		messages = append(messages, e.message(ts.Name.Name, st))
+	}
+
+	var set message
+	for _, dep := range slices.Sorted(maps.Keys(e.deps)) {
+		set.msg(1, wellKnownFile(dep))
+		file.str(3, dep)
+	}
+	for _, msg := range messages {
+		file.msg(4, msg)
+	}
+	file.str(12, "proto3")
+	set.msg(1, file)
+	return set
+}
+
+type exporter struct {
+	*common.Context
+	// deps are the files of the well-known types used by the schema.
+	deps map[string]bool
+}
+
+// message returns the DescriptorProto of the struct 'name'.
+func (e *exporter) message(name string, st *ast.StructType) message {
+	var msg, nested, oneofs message
+	msg.str(1, name)
+	number, oneof := 0, 0
+	for _, field := range e.GetSupportedFields(&ast.TypeSpec{Name: &ast.Ident{Name: name}, Type: st}) {
+		for _, fieldName := range field.Names {
+			number++
+			protoName := common.ConvertName(fieldName.Name, common.NamingSnake)
+
+			f := fieldDesc{name: protoName, json: common.ConvertName(fieldName.Name, common.NamingCamel), number: number, label: labelOptional}
+			typ, optional := e.unwrap(field.Type)
+			switch t := typ.(type) {
+			case *ast.MapType:
+				entry := common.ConvertName(fieldName.Name, common.NamingPascal) + "Entry"
+				nested.msg(3, e.mapEntry(entry, t))
+				f.label, f.typ, f.typeName = labelRepeated, typeMessage, e.typeName(name+"."+entry)
+			case *ast.ArrayType:
+				if isByte(t.Elt) {
+					f.typ = typeBytes
+					break
+				}
+				f.label = labelRepeated
+				f.typ, f.typeName = e.scalar(t.Elt)
+			default:
+				f.typ, f.typeName = e.scalar(typ)
+				if optional && f.typ != typeMessage {
+					oneofs.msg(8, oneofDesc("_"+protoName))
+					f.oneof, f.optional = oneof, true
+					oneof++
+				}
+			}
+			msg.msg(2, f.encode())
+		}
+	}
+	msg = append(msg, nested...)
+	return append(msg, oneofs...)
+}
+
+// mapEntry returns the nested DescriptorProto of the entries of a map field. Maps with keys
+// protobuf doesn't allow become repeated entries without the map_entry option.
+func (e *exporter) mapEntry(name string, t *ast.MapType) message {
+	var msg message
+	msg.str(1, name)
+	key := fieldDesc{name: "key", json: "key", number: 1, label: labelOptional}
+	key.typ, key.typeName = e.scalar(t.Key)
+	value := fieldDesc{name: "value", json: "value", number: 2, label: labelOptional}
+	value.typ, value.typeName = e.scalar(t.Value)
+	msg.msg(2, key.encode())
+	msg.msg(2, value.encode())
+
+	switch key.typ {
+	case typeDouble, typeFloat, typeBytes, typeMessage:
+	default:
+		var options message
+		options.varint(7, 1)
+		msg.msg(7, options)
+	}
+	return msg
+}
+
+// unwrap resolves the named types of the schema that aren't structs and removes pointers
+// options and sql.Null types from expr, which makes the field optional.
+func (e *exporter) unwrap(expr ast.Expr) (ast.Expr, bool) {
+	optional := false
+	for range 32 {
+		switch t := expr.(type) {
+		case *ast.Ident:
+			ts, ok := e.TypeSpecs[t.Name]
+			if !ok {
+				return expr, optional
+			}
+			if _, ok = ts.Type.(*ast.StructType); ok {
+				return expr, optional
+			}
+			expr = ts.Type
+		case *ast.StarExpr:
+			if e.ExprToString(t) == "*url.URL" {
+				return expr, optional
+			}
+			expr, optional = t.X, true
+		case *ast.IndexExpr:
+			elt, ok := common.OptionElt(t)
+			if !ok {
+				return expr, optional
+			}
+			expr, optional = elt, true
+		case *ast.SelectorExpr:
+			elt, ok := sqlNullTypes[e.ExprToString(t)]
+			if !ok {
+				return expr, optional
+			}
+			expr, optional = elt, true
+		default:
+			return expr, optional
+		}
+	}
+	return expr, optional
+}
+
+// scalar returns the type and the type name of a single value of type expr.
+func (e *exporter) scalar(expr ast.Expr) (int, string) {
+	expr, _ = e.unwrap(expr)
+	switch t := expr.(type) {
+	case *ast.Ident:
+		if _, ok := e.TypeSpecs[t.Name]; ok {
+			return typeMessage, e.typeName(t.Name)
+		}
+		if typ, ok := scalarTypes[t.Name]; ok {
+			return typ, ""
+		}
+	case *ast.SelectorExpr:
+		if wk, ok := wellKnown[e.ExprToString(t)]; ok {
+			e.deps[wk.file] = true
+			return typeMessage, ".google.protobuf." + wk.name
+		}
+	case *ast.StarExpr:
+		if e.ExprToString(t) == "*url.URL" {
+			return typeString, ""
+		}
+	}
+	return typeBytes, ""
+}
+
+// typeName returns the fully qualified name of the message 'name'.
+func (e *exporter) typeName(name string) string {
+	if e.PkgName == "" {
+		return "." + name
+	}
+	return "." + e.PkgName + "." + name
+}
+
+func isByte(expr ast.Expr) bool {
+	id, ok := expr.(*ast.Ident)
+	return ok && (id.Name == "byte" || id.Name == "uint8")
+}
+
+// fieldDesc is a FieldDescriptorProto.
+type fieldDesc struct {
+	name, json, typeName string
+	number, label, typ   int
+	oneof                int
+	optional             bool
+}
+
+func (f fieldDesc) encode() message {
+	var m message
+	m.str(1, f.name)
+	m.varint(3, uint64(f.number))
+	m.varint(4, uint64(f.label))
+	m.varint(5, uint64(f.typ))
+	if f.typeName != "" {
+		m.str(6, f.typeName)
+	}
+	if f.optional {
+		m.varint(9, uint64(f.oneof))
+	}
+	m.str(10, f.json)
+	if f.optional {
+		m.varint(17, 1)
+	}
+	return m
+}
+
+// oneofDesc returns a OneofDescriptorProto.
+func oneofDesc(name string) message {
+	var m message
+	m.str(1, name)
+	return m
+}
+
+// wellKnownFile returns the FileDescriptorProto of a well-known type file. Timestamp and
+// Duration have the same fields.
+func wellKnownFile(file string) message {
+	var seconds, nanos, msg, m message
+	seconds = fieldDesc{name: "seconds", json: "seconds", number: 1, label: labelOptional, typ: typeInt64}.encode()
+	nanos = fieldDesc{name: "nanos", json: "nanos", number: 2, label: labelOptional, typ: typeInt32}.encode()
+	for _, wk := range wellKnown {
+		if wk.file == file {
+			msg.str(1, wk.name)
+		}
+	}
+	msg.msg(2, seconds)
+	msg.msg(2, nanos)
+
+	m.str(1, file)
+	m.str(2, "google.protobuf")
+	m.msg(4, msg)
+	m.str(12, "proto3")
+	return m
+}
+
+// message is a protobuf message in the wire format, built field by field.
+type message []byte
+
+func (m *message) varint(field int, v uint64) {
+	*m = binary.AppendUvarint(*m, uint64(field)<<3)
+	*m = binary.AppendUvarint(*m, v)
+}
+
+func (m *message) bytes(field int, b []byte) {
+	*m = binary.AppendUvarint(*m, uint64(field)<<3|2)
+	*m = binary.AppendUvarint(*m, uint64(len(b)))
+	*m = append(*m, b...)
+}
+
+func (m *message) str(field int, s string) {
+	m.bytes(field, []byte(s))
+}
+
+func (m *message) msg(field int, sub message) {
+	m.bytes(field, sub)
+}
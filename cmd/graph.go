@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"go/ast"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/banditmoscow1337/benc/cmd/generator/common"
+)
+
+// runGraph prints the dependency graph of the schema types as DOT or Mermaid, to review the
+// relationships of a large schema: solid edges embed a value of the other type, dashed edges
+// refer to it through a pointer, slice, map or bstd.Option, and the edges of cycles are red.
+func runGraph(args []string) {
+	fs := flag.NewFlagSet("graph", flag.ExitOnError)
+	formatFlag := fs.String("format", "dot", "Output format: dot or mermaid")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 || (*formatFlag != "dot" && *formatFlag != "mermaid") {
+		log.Fatal("Usage: benc graph [-format dot|mermaid] <input_file>")
+	}
+	ctx := loadSchema(fs.Arg(0))
+	if ctx == nil {
+		os.Exit(1)
+	}
+
+	w := bufio.NewWriter(os.Stdout)
+	if *formatFlag == "dot" {
+		writeDOT(w, ctx)
+	} else {
+		writeMermaid(w, ctx)
+	}
+	if err := w.Flush(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// cycleGroups maps the types of the cycles of the schema to the index of their cycle.
+func cycleGroups(ctx *common.Context) ([][]string, map[string]int) {
+	cycles := ctx.Cycles()
+	groups := make(map[string]int)
+	for i, cycle := range cycles {
+		for _, name := range cycle {
+			groups[name] = i
+		}
+	}
+	return cycles, groups
+}
+
+// inCycle reports whether the dependency is an edge of a cycle, see cycleGroups.
+func inCycle(groups map[string]int, dep common.Dependency) bool {
+	from, ok := groups[dep.From]
+	to, ok2 := groups[dep.To]
+	return ok && ok2 && from == to
+}
+
+func writeDOT(w io.Writer, ctx *common.Context) {
+	cycles, groups := cycleGroups(ctx)
+	fmt.Fprintf(w, "digraph %q {\n", ctx.BaseName)
+	fmt.Fprintf(w, "\trankdir=LR;\n\tnode [shape=box];\n")
+	for _, cycle := range cycles {
+		fmt.Fprintf(w, "\t// cycle: %s\n", strings.Join(cycle, ", "))
+	}
+	for _, ts := range ctx.Types {
+		if _, ok := ts.Type.(*ast.StructType); ok {
+			fmt.Fprintf(w, "\t%q;\n", ts.Name.Name)
+		} else {
+			fmt.Fprintf(w, "\t%q [shape=ellipse];\n", ts.Name.Name)
+		}
+	}
+	for _, ts := range ctx.Types {
+		for _, dep := range ctx.Dependencies(ts) {
+			var attrs []string
+			if dep.Field != "" {
+				attrs = append(attrs, fmt.Sprintf("label=%q", dep.Field))
+			}
+			if dep.Indirect {
+				attrs = append(attrs, "style=dashed")
+			}
+			if inCycle(groups, dep) {
+				attrs = append(attrs, "color=red")
+			}
+			fmt.Fprintf(w, "\t%q -> %q", dep.From, dep.To)
+			if len(attrs) > 0 {
+				fmt.Fprintf(w, " [%s]", strings.Join(attrs, ", "))
+			}
+			fmt.Fprintf(w, ";\n")
+		}
+	}
+	fmt.Fprintf(w, "}\n")
+}
+
+func writeMermaid(w io.Writer, ctx *common.Context) {
+	cycles, groups := cycleGroups(ctx)
+	fmt.Fprintf(w, "graph LR\n")
+	for _, cycle := range cycles {
+		fmt.Fprintf(w, "\t%%%% cycle: %s\n", strings.Join(cycle, ", "))
+	}
+	for _, ts := range ctx.Types {
+		if _, ok := ts.Type.(*ast.StructType); ok {
+			fmt.Fprintf(w, "\t%s[%s]\n", ts.Name.Name, ts.Name.Name)
+		} else {
+			fmt.Fprintf(w, "\t%s([%s])\n", ts.Name.Name, ts.Name.Name)
+		}
+	}
+	// the edges of cycles are styled by their index, in the order of their declaration
+	var red []string
+	edges := 0
+	for _, ts := range ctx.Types {
+		for _, dep := range ctx.Dependencies(ts) {
+			arrow := "-->"
+			if dep.Indirect {
+				arrow = "-.->"
+			}
+			if dep.Field != "" {
+				arrow += "|" + dep.Field + "|"
+			}
+			fmt.Fprintf(w, "\t%s %s %s\n", dep.From, arrow, dep.To)
+			if inCycle(groups, dep) {
+				red = append(red, fmt.Sprint(edges))
+			}
+			edges++
+		}
+	}
+	if len(red) > 0 {
+		fmt.Fprintf(w, "\tlinkStyle %s stroke:red\n", strings.Join(red, ","))
+	}
+}
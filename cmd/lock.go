@@ -0,0 +1,30 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+)
+
+// runLock records the wire layouts of all structs of the schemas in the benc.lock files next
+// to them, which code generation verifies from then on.
+func runLock(args []string) {
+	fs := flag.NewFlagSet("lock", flag.ExitOnError)
+	dryRunFlag := fs.Bool("dry-run", false, "Print a unified diff of the changes to benc.lock instead of writing it")
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		log.Fatal("Usage: benc lock [-dry-run] <input_file>...")
+	}
+
+	for _, input := range fs.Args() {
+		ctx := loadSchema(input)
+		if ctx == nil {
+			os.Exit(1)
+		}
+		ctx.DryRun = *dryRunFlag
+		if err := ctx.UpdateLock(); err != nil {
+			log.Fatalf("%s: %v", input, err)
+		}
+	}
+}
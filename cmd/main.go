@@ -1,51 +1,109 @@
 package main
 
 import (
+	"errors"
 	"flag"
+	"fmt"
 	"log"
+	"os"
 	"strings"
 
 	"github.com/banditmoscow1337/benc/cmd/generator/c"
 	"github.com/banditmoscow1337/benc/cmd/generator/cpp"
+	"github.com/banditmoscow1337/benc/cmd/generator/flatbuffers"
 	"github.com/banditmoscow1337/benc/cmd/generator/golang"
 	"github.com/banditmoscow1337/benc/cmd/generator/javascript"
 
 	"github.com/banditmoscow1337/benc/cmd/generator/common"
 )
 
+// subcommands are the tools next to code generation, invoked as `benc <name> ...`.
+var subcommands = map[string]func(args []string){
+	"cat":         runCat,
+	"graph":       runGraph,
+	"lock":        runLock,
+	"mockserve":   runMockserve,
+	"pack":        runPack,
+	"proto":       runProto,
+	"replay":      runReplay,
+	"sample":      runSample,
+	"selfinstall": runSelfinstall,
+	"size":        runSize,
+	"tag":         runTag,
+}
+
 func main() {
+	if len(os.Args) > 1 {
+		if run, ok := subcommands[os.Args[1]]; ok {
+			run(os.Args[2:])
+			return
+		}
+	}
+
 	langFlag := flag.String("lang", "go", "Comma separated list of languages to generate (go, js, c)")
+	jsInt64KeysFlag := flag.String("js-int64-keys", javascript.Int64KeysBigInt, "Representation of maps with 64-bit integer keys in js: bigint (Map with BigInt keys) or string (object with string keys)")
+	goPackageFlag := flag.String("go-package", "", "Directory of a separate package the go code is generated into, instead of the package of the schema")
+	goSchemaImportFlag := flag.String("go-schema-import", "", "Import path of the schema package, used with -go-package (default: derived from go.mod)")
+	goSizeHistogramFlag := flag.Bool("go-size-histogram", false, "Report the size of every marshalled message to the hook of bstd.SetSizeHook")
+	goCanonicalFlag := flag.Bool("go-canonical", false, "Marshal maps and variants sorted, so identical values marshal to identical bytes, e.g. for signing")
+	goSchemaHeaderFlag := flag.Bool("go-schema-header", false, "Generate MarshalWithSchema, which writes the compressed schema before the message, so it decodes without the schema")
+	dryRunFlag := flag.Bool("dry-run", false, "Print a unified diff of the changes to every output file instead of writing them")
+	namingFlag := flag.String("naming", "", "Comma separated field naming conventions per language, e.g. js=camel,c=snake (keep, snake, camel, pascal)")
+	fingerprintFlag := flag.Bool("fingerprint", false, "Stamp the generated files with the version of the generator")
 	flag.Parse()
 
+	namings, err := common.ParseNaming(*namingFlag)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *jsInt64KeysFlag != javascript.Int64KeysBigInt && *jsInt64KeysFlag != javascript.Int64KeysString {
+		log.Fatalf("invalid -js-int64-keys %q, expected bigint or string", *jsInt64KeysFlag)
+	}
+	if _, ok := namings["go"]; ok {
+		log.Fatal("the field names of the go backend can't be changed, they are the schema names")
+	}
+
 	args := flag.Args()
 	if len(args) < 1 {
 		log.Fatal("Usage: go run main.go -lang=go,js,c,cpp <input_file>")
 	}
 
-	ctx := common.NewContext(args[0])
-
-	// Detect Input Type
-	if strings.HasSuffix(ctx.InputFile, ".js") {
-		javascript.Parse(ctx)
-	} else if strings.HasSuffix(ctx.InputFile, ".c") || strings.HasSuffix(ctx.InputFile, ".h") {
-		c.Parse(ctx)
-	} else {
-		golang.Parse(ctx)
+	ctx := loadSchema(args[0])
+	if ctx == nil {
+		return
+	}
+	ctx.DryRun = *dryRunFlag
+	if *fingerprintFlag {
+		ctx.Fingerprint = "benc " + generatorVersion()
+	}
+	if err = ctx.CheckLock(); err != nil {
+		log.Fatal(err)
 	}
 
-	if !ctx.Type2TypeSpecs() {
-		return
+	goOpts := golang.Options{Package: *goPackageFlag, SchemaImport: *goSchemaImportFlag, Canonical: *goCanonicalFlag, SizeHistogram: *goSizeHistogramFlag, SchemaHeader: *goSchemaHeaderFlag}
+	if goOpts.Package != "" {
+		if goOpts.SchemaImport == "" {
+			if goOpts.SchemaImport, err = golang.ImportPath(ctx.OutputDir); err != nil {
+				log.Fatalf("can't determine the import path of the schema package, set -go-schema-import: %v", err)
+			}
+		}
+		if !ctx.DryRun {
+			if err = os.MkdirAll(goOpts.Package, 0755); err != nil {
+				log.Fatal(err)
+			}
+		}
 	}
 
 	var generator common.Generator
 	
 	for lang := range strings.SplitSeq(*langFlag, ",") {
 		lang = strings.TrimSpace(lang)
+		ctx.SetNaming(namings[lang])
 		switch lang {
 		case "go":
-			generator = golang.New(ctx)
+			generator = golang.New(ctx, goOpts)
 		case "js":
-			generator = javascript.New(ctx)
+			generator = javascript.New(ctx, javascript.Options{Int64MapKeys: *jsInt64KeysFlag})
 		case "c":
 			generator = c.New(ctx)
 		case "cpp":
@@ -59,5 +117,64 @@ func main() {
 		if err :=generator.Tests(); err != nil {
 			log.Fatalf("%s generation test failed: %v", lang, err)
 		}
+
+		if err := ctx.WriteNameManifest(lang); err != nil {
+			log.Fatalf("%s name manifest failed: %v", lang, err)
+		}
+	}
+}
+
+// errNoTypes is returned by parseSchema for an input without types.
+var errNoTypes = errors.New("no structs or classes found")
+
+// loadSchema parses the input file with the parser matching its extension.
+// It returns nil if the input contains no types.
+func loadSchema(inputFile string) *common.Context {
+	ctx, err := parseSchema(inputFile)
+	if errors.Is(err, errNoTypes) {
+		log.Print(err)
+		return nil
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	return ctx
+}
+
+// parseSchema is loadSchema returning the errors of the parsers instead of exiting, e.g. for
+// reloading a schema while it is edited.
+func parseSchema(inputFile string) (*common.Context, error) {
+	return parseContext(common.NewContext(inputFile))
+}
+
+// parseSchemaSource is parseSchema of the schema 'source' instead of a file, e.g. the one of a
+// bstd.SchemaHeader. The extension of 'name' selects the parser.
+func parseSchemaSource(name string, source []byte) (*common.Context, error) {
+	ctx := common.NewContext(name)
+	ctx.Source = source
+	return parseContext(ctx)
+}
+
+// parseContext parses the schema of ctx by the parser of the extension of its InputFile.
+func parseContext(ctx *common.Context) (*common.Context, error) {
+	// Detect Input Type
+	var err error
+	if strings.HasSuffix(ctx.InputFile, ".js") {
+		err = javascript.Parse(ctx)
+	} else if strings.HasSuffix(ctx.InputFile, ".c") || strings.HasSuffix(ctx.InputFile, ".h") {
+		err = c.ParseFile(ctx)
+	} else if strings.HasSuffix(ctx.InputFile, ".fbs") {
+		err = flatbuffers.Parse(ctx)
+	} else {
+		err = golang.ParseFile(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ctx.Types) == 0 {
+		return nil, fmt.Errorf("%w in %s", errNoTypes, ctx.InputFile)
 	}
-}
\ No newline at end of file
+	ctx.Type2TypeSpecs()
+	return ctx, nil
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net"
+	"time"
+
+	"github.com/banditmoscow1337/benc/cmd/dynamic"
+	bstd "github.com/banditmoscow1337/benc/std/golang"
+)
+
+// runMockserve answers every frame received over TCP with a frame holding a random message of
+// a schema type, so clients can be developed against a benc API before its server exists.
+// Every connection starts from the same seed, so a client sees the same responses each time.
+func runMockserve(args []string) {
+	fs := flag.NewFlagSet("mockserve", flag.ExitOnError)
+	addrFlag := fs.String("addr", "localhost:7000", "TCP address to listen on")
+	requestFlag := fs.String("request", "", "Type of the request messages, which are checked to decode (default: not checked)")
+	seedFlag := fs.Int64("seed", 0, "Seed of the random responses (0 picks a random seed)")
+	depthFlag := fs.Int("depth", 2, "Maximum nesting of pointers, slices and maps in random responses")
+	watchFlag := fs.Duration("watch", 0, "Interval of checking the schema for changes, which are used without a restart (0 disables it)")
+	maxFrame := maxFrameFlag(fs)
+	codec, typeName := parseCodecFlags(fs, args)
+
+	if *requestFlag != "" {
+		if _, ok := codec.TypeSpecs[*requestFlag]; !ok {
+			log.Fatalf("type %s not found in the schema", *requestFlag)
+		}
+	}
+	seed := *seedFlag
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	types := []string{typeName}
+	if *requestFlag != "" {
+		types = append(types, *requestFlag)
+	}
+	// every request gets a copy of the codec, whose dict state isn't shared between connections
+	current := func() *dynamic.Codec {
+		c := *codec
+		return &c
+	}
+	if reloader := watchSchema(codec, *watchFlag, types...); reloader != nil {
+		current = reloader.Codec
+	}
+
+	ln, err := net.Listen("tcp", *addrFlag)
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("serving random %s frames on %s (seed %d)", typeName, ln.Addr(), seed)
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			log.Fatal(err)
+		}
+		go func() {
+			defer conn.Close()
+			// a request breaking the codec closes its connection, not the server
+			defer func() {
+				if p := recover(); p != nil {
+					log.Printf("%s: panic: %v", conn.RemoteAddr(), p)
+				}
+			}()
+			if err := mockConn(conn, current, typeName, *requestFlag, rand.New(rand.NewSource(seed)), *depthFlag, *maxFrame); err != nil {
+				log.Printf("%s: %v", conn.RemoteAddr(), err)
+			}
+		}()
+	}
+}
+
+// mockConn answers the request frames of conn with random messages of typeName until the
+// client closes the connection, decoding and encoding every one with the codec of 'current'.
+// A request frame larger than maxFrame ends the connection with bstd.ErrFrameTooLarge.
+func mockConn(conn net.Conn, current func() *dynamic.Codec, typeName, requestType string, rng *rand.Rand, depth, maxFrame int) error {
+	r := bufio.NewReader(conn)
+	w := bufio.NewWriter(conn)
+
+	var buf []byte
+	for i := 0; ; i++ {
+		msg, err := bstd.ReadFrame(r, buf, maxFrame)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		buf = msg
+		codec := current()
+		if requestType != "" {
+			if n, _, err := codec.Decode(requestType, 0, msg); err != nil {
+				return fmt.Errorf("request %d: %w", i, err)
+			} else if n != len(msg) {
+				return fmt.Errorf("request %d: %w", i, bstd.ErrTrailingBytes)
+			}
+		}
+
+		v, err := codec.Random(rng, typeName, depth)
+		if err != nil {
+			return err
+		}
+		resp, err := codec.Encode(typeName, v)
+		if err != nil {
+			return err
+		}
+		if err = bstd.WriteFrame(w, resp); err != nil {
+			return err
+		}
+		if err = w.Flush(); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"math/rand"
+	"net"
+	"testing"
+
+	"github.com/banditmoscow1337/benc/cmd/dynamic"
+	bstd "github.com/banditmoscow1337/benc/std/golang"
+)
+
+func TestMockConnHostileLength(t *testing.T) {
+	ctx, err := parseSchemaSource("schema.go", []byte("package schema\n\ntype Pong struct {\n\tID int64\n}\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	codec := dynamic.New(ctx)
+	server, client := net.Pipe()
+	defer client.Close()
+
+	done := make(chan error, 1)
+	go func() {
+		defer server.Close()
+		done <- mockConn(server, func() *dynamic.Codec { return codec }, "Pong", "", rand.New(rand.NewSource(1)), 2, 0)
+	}()
+
+	// a valid request is answered
+	if err = bstd.WriteFrame(client, []byte("ping")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = bstd.ReadFrame(bufio.NewReader(client), nil, 0); err != nil {
+		t.Fatalf("response: %v", err)
+	}
+
+	// a length prefix of 2^56-1 bytes ends the connection without allocating the frame
+	if _, err = client.Write([]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x7f}); err != nil {
+		t.Fatal(err)
+	}
+	if err = <-done; !errors.Is(err, bstd.ErrFrameTooLarge) {
+		t.Fatalf("expected ErrFrameTooLarge, got %v", err)
+	}
+}
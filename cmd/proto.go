@@ -0,0 +1,34 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/banditmoscow1337/benc/cmd/generator/proto"
+)
+
+// runProto writes the protobuf FileDescriptorSet of a schema, for proto tooling like grpcurl or buf.
+func runProto(args []string) {
+	fs := flag.NewFlagSet("proto", flag.ExitOnError)
+	outFlag := fs.String("o", "", "Output file (default: <schema>.pb next to the schema)")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		log.Fatal("Usage: benc proto [-o <out_file>] <input_file>")
+	}
+
+	ctx := loadSchema(fs.Arg(0))
+	if ctx == nil {
+		os.Exit(1)
+	}
+	out := *outFlag
+	if out == "" {
+		out = filepath.Join(ctx.OutputDir, ctx.BaseName+".pb")
+	}
+	if err := os.WriteFile(out, proto.FileDescriptorSet(ctx), 0644); err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("Successfully generated %s", out)
+}
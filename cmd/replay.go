@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/banditmoscow1337/benc/cmd/dynamic"
+	bstd "github.com/banditmoscow1337/benc/std/golang"
+)
+
+// frameTrace is a line of a trace file: the decode operations of a frame and the error
+// decoding it failed with, if any.
+type frameTrace struct {
+	Frame int               `json:"frame"`
+	Ops   []dynamic.TraceOp `json:"ops"`
+	Error string            `json:"error,omitempty"`
+}
+
+// traceWriter writes the lines of a trace file.
+type traceWriter struct {
+	f   *os.File
+	w   *bufio.Writer
+	enc *json.Encoder
+}
+
+func createTrace(path string) (*traceWriter, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	w := bufio.NewWriter(f)
+	return &traceWriter{f: f, w: w, enc: json.NewEncoder(w)}, nil
+}
+
+// Write writes the trace of frame i, which failed to decode with err, if it isn't nil.
+func (t *traceWriter) Write(i int, trace *dynamic.Trace, err error) error {
+	ft := frameTrace{Frame: i, Ops: trace.Ops}
+	if err != nil {
+		ft.Error = err.Error()
+	}
+	return t.enc.Encode(ft)
+}
+
+func (t *traceWriter) Close() error {
+	if err := t.w.Flush(); err != nil {
+		t.f.Close()
+		return err
+	}
+	return t.f.Close()
+}
+
+// runReplay decodes the frames of stdin again and compares their decode operations with the
+// ones of a trace written by `benc cat -trace`, the n-th line of the trace with the n-th frame.
+// It stops at the first operation that differs, with exit code 1, so a decode failure seen
+// elsewhere can be reproduced, and bisected over schema or benc versions, from the trace and
+// the frames alone.
+func runReplay(args []string) {
+	fs := flag.NewFlagSet("replay", flag.ExitOnError)
+	traceFlag := fs.String("trace", "", "Trace file written by benc cat -trace")
+	maxFrame := maxFrameFlag(fs)
+	codec, typeName := parseCodecFlags(fs, args)
+	if *traceFlag == "" {
+		log.Fatal("Usage: benc replay -schema <input_file> -type <type> -trace <trace_file>")
+	}
+
+	f, err := os.Open(*traceFlag)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer f.Close()
+	traces := json.NewDecoder(bufio.NewReader(f))
+	r := bufio.NewReader(os.Stdin)
+	codec.Trace = &dynamic.Trace{}
+
+	var buf []byte
+	i, failed := 0, 0
+	for ; ; i++ {
+		var recorded frameTrace
+		if err = traces.Decode(&recorded); err == io.EOF {
+			break
+		} else if err != nil {
+			log.Fatalf("%s, line %d: %v", *traceFlag, i+1, err)
+		}
+		msg, err := bstd.ReadFrame(r, buf, *maxFrame)
+		if err == io.EOF {
+			log.Fatalf("the trace has more frames than the input, which ends after %d", i)
+		} else if err != nil {
+			log.Fatalf("frame %d: %v", recorded.Frame, err)
+		}
+		buf = msg
+
+		_, err = decodeFrame(codec, typeName, msg)
+		errText := ""
+		if err != nil {
+			errText = err.Error()
+		}
+		if d := (&dynamic.Trace{Ops: recorded.Ops}).Diff(codec.Trace); d >= 0 {
+			fmt.Printf("frame %d: operation %d differs\n  recorded: %s\n  replayed: %s\n", recorded.Frame, d, formatOp(recorded.Ops, d), formatOp(codec.Trace.Ops, d))
+			os.Exit(1)
+		}
+		if errText != recorded.Error {
+			fmt.Printf("frame %d: the operations match, but the error differs\n  recorded: %s\n  replayed: %s\n", recorded.Frame, recorded.Error, errText)
+			os.Exit(1)
+		}
+		if err != nil {
+			fmt.Printf("frame %d: reproduced: %v\n", recorded.Frame, err)
+			failed++
+		}
+	}
+	log.Printf("replayed %d frames, all match the trace (%d failing)", i, failed)
+}
+
+// formatOp describes the operation i of ops.
+func formatOp(ops []dynamic.TraceOp, i int) string {
+	if i >= len(ops) {
+		return "no operation"
+	}
+	op := ops[i]
+	if op.Length < 0 {
+		return fmt.Sprintf("%s at offset %d, failed", op.Type, op.Offset)
+	}
+	return fmt.Sprintf("%s at offset %d, %d bytes", op.Type, op.Offset, op.Length)
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"io"
+	"log"
+	"math/rand"
+	"os"
+	"time"
+
+	bstd "github.com/banditmoscow1337/benc/std/golang"
+)
+
+// runSample copies a random subset of the frames of a capture into a new file.
+// Frames are copied whole, so the output is a valid frame stream itself.
+func runSample(args []string) {
+	fs := flag.NewFlagSet("sample", flag.ExitOnError)
+	rateFlag := fs.Float64("rate", 0.01, "Fraction of frames to keep (0-1)")
+	seedFlag := fs.Int64("seed", 0, "Seed of the random selection (0 picks a random seed)")
+	maxFrame := maxFrameFlag(fs)
+	fs.Parse(args)
+
+	if fs.NArg() != 2 || *rateFlag < 0 || *rateFlag > 1 {
+		log.Fatal("Usage: benc sample -rate <0-1> [-seed <n>] [-max-frame <bytes>] <in_file> <out_file>")
+	}
+
+	seed := *seedFlag
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	rng := rand.New(rand.NewSource(seed))
+
+	in, err := os.Open(fs.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer in.Close()
+
+	out, err := os.Create(fs.Arg(1))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer out.Close()
+
+	r := bufio.NewReader(in)
+	w := bufio.NewWriter(out)
+
+	var buf []byte
+	total, kept := 0, 0
+	for ; ; total++ {
+		msg, err := bstd.ReadFrame(r, buf, *maxFrame)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			log.Fatalf("frame %d: %v", total, err)
+		}
+		buf = msg
+
+		if rng.Float64() >= *rateFlag {
+			continue
+		}
+		if err = bstd.WriteFrame(w, msg); err != nil {
+			log.Fatal(err)
+		}
+		kept++
+	}
+
+	if err = w.Flush(); err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("kept %d of %d frames (seed %d)", kept, total, seed)
+}
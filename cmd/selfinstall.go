@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"strings"
+
+	"github.com/banditmoscow1337/benc/cmd/generator/common"
+	"github.com/banditmoscow1337/benc/std"
+)
+
+// runSelfinstall writes the runtimes the generated code of the languages includes, embedded
+// into the generator, into a directory. With `go run github.com/banditmoscow1337/benc/cmd@<version>`
+// in a go:generate comment the runtimes and the generated code come from the same version,
+// without a checkout of benc or paths relative to one.
+func runSelfinstall(args []string) {
+	fs := flag.NewFlagSet("selfinstall", flag.ExitOnError)
+	langFlag := fs.String("lang", "js", "Comma separated list of languages to install the runtimes of (js, c, cpp)")
+	dirFlag := fs.String("dir", ".", "Directory of the generated code, the runtimes are written next to it")
+	dryRunFlag := fs.Bool("dry-run", false, "Print a unified diff of the changes to every runtime file instead of writing them")
+	fs.Parse(args)
+
+	if fs.NArg() != 0 {
+		log.Fatal("Usage: benc selfinstall [-lang js,c,cpp] [-dir <dir>] [-dry-run]")
+	}
+	var files []std.RuntimeFile
+	langs := make(map[string]string)
+	for lang := range strings.SplitSeq(*langFlag, ",") {
+		lang = strings.TrimSpace(lang)
+		rf, ok := std.Runtime(lang)
+		if !ok && lang == "go" {
+			log.Printf("INFO: the generated go code needs no runtime besides the bstd module")
+			continue
+		} else if !ok {
+			log.Fatalf("unknown language %q, expected js, c or cpp", lang)
+		}
+		for _, f := range rf {
+			if other, ok := langs[f.Name]; ok {
+				log.Fatalf("the %s and %s runtimes both have a %s, install them into different directories", other, lang, f.Name)
+			}
+			langs[f.Name] = lang
+		}
+		files = append(files, rf...)
+	}
+	if !*dryRunFlag {
+		if err := os.MkdirAll(*dirFlag, 0755); err != nil {
+			log.Fatal(err)
+		}
+	}
+	for _, f := range files {
+		if err := installFile(filepath.Join(*dirFlag, f.Name), f.Content, *dryRunFlag); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+// installFile writes content to path, unless the file has it already, or prints the diff
+// of it on a dry run.
+func installFile(path string, content []byte, dryRun bool) error {
+	old, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err == nil && bytes.Equal(old, content) {
+		return nil
+	}
+	if dryRun {
+		oldPath := path
+		if os.IsNotExist(err) {
+			oldPath = os.DevNull
+		}
+		fmt.Print(common.UnifiedDiff(oldPath, path, string(old), string(content)))
+		return nil
+	}
+	if err = os.WriteFile(path, content, 0644); err != nil {
+		return err
+	}
+	log.Printf("Installed %s", path)
+	return nil
+}
+
+// generatorVersion returns the version of the benc module the generator was built from:
+// its module version if it was built by `go run` or `go install` with a version, else the
+// VCS revision of the checkout, "devel" if there is neither.
+func generatorVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "devel"
+	}
+	if v := info.Main.Version; v != "" && v != "(devel)" {
+		return v
+	}
+	var revision, modified string
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			revision = s.Value
+		case "vcs.modified":
+			modified = s.Value
+		}
+	}
+	if revision == "" {
+		return "devel"
+	}
+	if len(revision) > 12 {
+		revision = revision[:12]
+	}
+	if modified == "true" {
+		revision += "-dirty"
+	}
+	return "devel-" + revision
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"go/ast"
+	"log"
+	"math/rand"
+	"os"
+	"slices"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/banditmoscow1337/benc/cmd/dynamic"
+)
+
+// runSize reports the smallest, typical and largest encoded size of a type and of
+// every struct it contains. Typical sizes are measured on random values.
+func runSize(args []string) {
+	fs := flag.NewFlagSet("size", flag.ExitOnError)
+	samplesFlag := fs.Int("samples", 1000, "Number of random values measured per type")
+	seedFlag := fs.Int64("seed", 0, "Seed of the random values (0 picks a random seed)")
+	depthFlag := fs.Int("depth", 2, "Maximum nesting of pointers, slices and maps in random values")
+	codec, typeName := parseCodecFlags(fs, args)
+
+	if *samplesFlag <= 0 {
+		log.Fatal("-samples must be positive")
+	}
+	seed := *seedFlag
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	rng := rand.New(rand.NewSource(seed))
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TYPE\tMIN\tP50\tP95\tMEAN\tMAX")
+	for _, name := range structsOf(codec, typeName) {
+		bounds, err := codec.SizeBounds(name)
+		if err != nil {
+			log.Fatalf("%s: %v", name, err)
+		}
+
+		sizes := make([]int, *samplesFlag)
+		total := 0
+		for i := range sizes {
+			v, err := codec.Random(rng, name, *depthFlag)
+			if err != nil {
+				log.Fatalf("%s: %v", name, err)
+			}
+			msg, err := codec.Encode(name, v)
+			if err != nil {
+				log.Fatalf("%s: %v", name, err)
+			}
+			sizes[i] = len(msg)
+			total += len(msg)
+		}
+		slices.Sort(sizes)
+
+		maxSize := "unbounded"
+		if bounds.Max != dynamic.Unbounded {
+			maxSize = strconv.Itoa(bounds.Max)
+		}
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%.1f\t%s\n", name, bounds.Min,
+			sizes[len(sizes)/2], sizes[len(sizes)*95/100], float64(total)/float64(len(sizes)), maxSize)
+	}
+	w.Flush()
+	log.Printf("%d samples per type (seed %d)", *samplesFlag, seed)
+}
+
+// structsOf returns typeName followed by the schema structs reachable from it.
+func structsOf(codec *dynamic.Codec, typeName string) []string {
+	names := []string{typeName}
+	seen := map[string]bool{typeName: true}
+	var walk func(name string)
+	walk = func(name string) {
+		for _, dep := range codec.Dependencies(codec.TypeSpecs[name]) {
+			if seen[dep.To] {
+				continue
+			}
+			seen[dep.To] = true
+			if _, ok := codec.TypeSpecs[dep.To].Type.(*ast.StructType); ok {
+				names = append(names, dep.To)
+			}
+			walk(dep.To)
+		}
+	}
+	walk(typeName)
+	return names
+}
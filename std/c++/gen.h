@@ -22,6 +22,9 @@ namespace bstd::gen {
 
 // Constants
 constexpr int MaxDepth = 2;
+// LargeMapCount is the number of entries of the maps of GenerateLargeMap, more than a 16-bit
+// count holds.
+constexpr std::size_t LargeMapCount = 70000;
 
 // --- Helper Functions ---
 
@@ -239,6 +242,17 @@ std::map<K, V> GenerateMap(Gen& g, int depth, KGen keyGen, VGen valGen) {
     return m;
 }
 
+// GenerateLargeMap generates a map of LargeMapCount entries, or of fewer, if the keys of keyGen
+// repeat too often, e.g. bools.
+template<typename K, typename V, URBG Gen, typename KGen, typename VGen>
+std::map<K, V> GenerateLargeMap(Gen& g, int depth, KGen keyGen, VGen valGen) {
+    std::map<K, V> m;
+    for (std::size_t i = 0; i < 2 * LargeMapCount && m.size() < LargeMapCount; ++i) {
+        m.emplace(keyGen(g, depth), valGen(g, depth));
+    }
+    return m;
+}
+
 // --- Comparison Functions ---
 
 // Return type is std::optional<std::string>. std::nullopt means success (no error).
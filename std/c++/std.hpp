@@ -44,6 +44,7 @@ enum class Error {
     Ok = 0,             ///< No error
     Overflow,           ///< A variable-length integer overflowed its boundaries
     BufferTooSmall,     ///< The provided buffer is too small for the operation
+    InvalidData,        ///< The data is malformed, e.g. an enum ordinal exceeds its constants
 };
 
 /**
@@ -1,6 +1,8 @@
 /*
  bstd.h - v2.0 - public domain - 2025
  Single-header C17 binary serialization library, a complete port of the Go `bstd` package.
+ The primitives (varints, zigzag, little-endian fixed-width values, length prefixes) follow
+ the Go `wire` package, which documents the format.
 
  USAGE:
  This is a single-header library. To use it, do this in *one* C or C++ file:
@@ -49,6 +51,7 @@ typedef enum {
     BSTD_ERR_OVERFLOW,         // A variable-length integer is malformed or exceeds its limits
     BSTD_ERR_INVALID_ARGUMENT, // A function pointer or other required argument was NULL
     BSTD_ERR_MALLOC_FAILED,    // Memory allocation failed
+    BSTD_ERR_INVALID_DATA,     // The data is malformed, e.g. an enum ordinal exceeds its constants
 } bstd_status;
 
 /**
@@ -163,6 +166,14 @@ bstd_status bstd_marshal_string(uint8_t* buf, size_t buf_len, size_t* offset, co
 bstd_status bstd_unmarshal_string_view(const uint8_t* buf, size_t buf_len, size_t* offset, bstd_string_view* out_view);
 bstd_status bstd_unmarshal_string_alloc(const uint8_t* buf, size_t buf_len, size_t* offset, char** out_str);
 
+// --- NUL-terminated String ---
+// The length prefix counts the trailing NUL too. Unmarshalling points the string into the
+// buffer without copying it, it lives as long as the buffer and is not freed.
+size_t bstd_size_string_nul(const char* str);
+bstd_status bstd_skip_string_nul(const uint8_t* buf, size_t buf_len, size_t* offset);
+bstd_status bstd_marshal_string_nul(uint8_t* buf, size_t buf_len, size_t* offset, const char* str);
+bstd_status bstd_unmarshal_string_nul(const uint8_t* buf, size_t buf_len, size_t* offset, const char** out_str);
+
 // --- Time (as Unix nanoseconds) ---
 size_t bstd_size_time(void);
 bstd_status bstd_skip_time(const uint8_t* buf, size_t buf_len, size_t* offset);
@@ -450,6 +461,47 @@ bstd_status bstd_unmarshal_string_alloc(const uint8_t* buf, size_t buf_len, size
     return BSTD_OK;
 }
 
+// --- NUL-terminated String ---
+size_t bstd_size_string_nul(const char* str) {
+    size_t len = str ? strlen(str) + 1 : 1;
+    return bstd_size_uint((uintptr_t)len) + len;
+}
+
+bstd_status bstd_skip_string_nul(const uint8_t* buf, size_t buf_len, size_t* offset) {
+    return bstd_skip_bytes(buf, buf_len, offset);
+}
+
+bstd_status bstd_marshal_string_nul(uint8_t* buf, size_t buf_len, size_t* offset, const char* str) {
+    size_t len = str ? strlen(str) : 0;
+    size_t initial_offset = *offset;
+    bstd_status status = bstd_marshal_uint(buf, buf_len, offset, (uintptr_t)(len + 1));
+    if (status != BSTD_OK) return status;
+
+    if (buf_len < *offset + len + 1) {
+        *offset = initial_offset;
+        return BSTD_ERR_BUF_TOO_SMALL;
+    }
+    if (len > 0) memcpy(buf + *offset, str, len);
+    buf[*offset + len] = '\0';
+    *offset += len + 1;
+    return BSTD_OK;
+}
+
+bstd_status bstd_unmarshal_string_nul(const uint8_t* buf, size_t buf_len, size_t* offset, const char** out_str) {
+    size_t initial_offset = *offset;
+    const uint8_t* data;
+    size_t len;
+    bstd_status status = bstd_unmarshal_bytes_view(buf, buf_len, offset, &data, &len);
+    if (status != BSTD_OK) return status;
+
+    if (len == 0 || data[len - 1] != '\0') {
+        *offset = initial_offset;
+        return BSTD_ERR_INVALID_DATA;
+    }
+    *out_str = (const char*)data;
+    return BSTD_OK;
+}
+
 // --- Signed Integer (Varint) ---
 size_t bstd_size_int(intptr_t value) {
     return bstd_size_uint(encode_zigzag(value));
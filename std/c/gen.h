@@ -84,8 +84,13 @@ char* generate_string_alloc(void); // Returns a heap-allocated string
 uint8_t* generate_bytes_alloc(size_t* out_len); // Returns heap-allocated bytes
 
 // --- Generic Generators ---
+#define LARGE_MAP_COUNT 70000
+
 void* generate_slice_alloc(size_t* out_count, size_t element_size, generate_fn element_generator);
 void generate_map_alloc(void** out_keys, void** out_values, size_t* out_count, size_t key_size, size_t value_size, generate_fn key_generator, generate_fn value_generator);
+// Generates a map of LARGE_MAP_COUNT entries, more than a 16-bit count holds. As the maps are
+// arrays, its keys may repeat.
+void generate_large_map_alloc(void** out_keys, void** out_values, size_t* out_count, size_t key_size, size_t value_size, generate_fn key_generator, generate_fn value_generator);
 void* generate_pointer_alloc(size_t element_size, generate_fn element_generator);
 
 // --- Generators for TestStruct ---
@@ -181,8 +186,9 @@ void* generate_slice_alloc(size_t* out_count, size_t element_size, generate_fn e
     return slice;
 }
 
-void generate_map_alloc(void** out_keys, void** out_values, size_t* out_count, size_t key_size, size_t value_size, generate_fn key_generator, generate_fn value_generator) {
-    *out_count = random_count();
+// Generates a map of count entries into the arrays of keys and values.
+static void generate_map_count_alloc(size_t count, void** out_keys, void** out_values, size_t* out_count, size_t key_size, size_t value_size, generate_fn key_generator, generate_fn value_generator) {
+    *out_count = count;
     if (*out_count == 0) {
         *out_keys = NULL;
         *out_values = NULL;
@@ -201,6 +207,14 @@ void generate_map_alloc(void** out_keys, void** out_values, size_t* out_count, s
     }
 }
 
+void generate_map_alloc(void** out_keys, void** out_values, size_t* out_count, size_t key_size, size_t value_size, generate_fn key_generator, generate_fn value_generator) {
+    generate_map_count_alloc(random_count(), out_keys, out_values, out_count, key_size, value_size, key_generator, value_generator);
+}
+
+void generate_large_map_alloc(void** out_keys, void** out_values, size_t* out_count, size_t key_size, size_t value_size, generate_fn key_generator, generate_fn value_generator) {
+    generate_map_count_alloc(LARGE_MAP_COUNT, out_keys, out_values, out_count, key_size, value_size, key_generator, value_generator);
+}
+
 void* generate_pointer_alloc(size_t element_size, generate_fn element_generator) {
     if (rand() % 4 == 0) { // 25% chance of being NULL
         return NULL;
@@ -261,11 +275,15 @@ bool compare_map(const void* a_keys, const void* a_values, size_t a_count, const
     const uint8_t* b_k_ptr = (const uint8_t*)b_keys;
     const uint8_t* b_v_ptr = (const uint8_t*)b_values;
 
-    // This is O(n^2), but for small test maps it's fine.
-    // A real implementation might sort or use a hash map for verification.
+    // The entries keep their order through a round trip, so the entry of the same index is
+    // tried first; the search is O(n^2), but only for maps in another order.
     for (size_t i = 0; i < a_count; ++i) {
         const void* key_a = a_k_ptr + i * key_size;
         const void* val_a = a_v_ptr + i * value_size;
+        if (key_comparer(key_a, b_k_ptr + i * key_size)) {
+            if (!value_comparer(val_a, b_v_ptr + i * value_size)) return false;
+            continue;
+        }
         bool found = false;
         for (size_t j = 0; j < b_count; ++j) {
             const void* key_b = b_k_ptr + j * key_size;
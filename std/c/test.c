@@ -507,6 +507,32 @@ bool test_string_view(void) {
     return true;
 }
 
+bool test_string_nul(void) {
+    char* src = generate_string_alloc();
+    const char* dst = NULL;
+    size_t size = bstd_size_string_nul(src);
+    uint8_t* buf = (uint8_t*)malloc(size);
+    TEST_ASSERT(buf != NULL, "malloc failed");
+    size_t offset = 0;
+    bstd_status status = bstd_marshal_string_nul(buf, size, &offset, src);
+    TEST_ASSERT(status == BSTD_OK && offset == size, "Marshal failed");
+    offset = 0;
+    status = bstd_unmarshal_string_nul(buf, size, &offset, &dst);
+    TEST_ASSERT(status == BSTD_OK && offset == size, "Unmarshal failed");
+    TEST_ASSERT(compare_string(src, dst), "String mismatch");
+    TEST_ASSERT((const uint8_t*)dst > buf && (const uint8_t*)dst < buf + size, "String not in the buffer");
+
+    // a plain string lacks the NUL terminator
+    size_t src_len = strlen(src);
+    offset = 0;
+    bstd_marshal_string(buf, size, &offset, src, src_len);
+    offset = 0;
+    TEST_ASSERT(bstd_unmarshal_string_nul(buf, size, &offset, &dst) == BSTD_ERR_INVALID_DATA && offset == 0, "Missing terminator accepted");
+    free(src);
+    free(buf);
+    return true;
+}
+
 // bstd function pointers for a slice of int32
 static size_t sizer_int32(const void* el) { (void)el; return bstd_size_int32(); }
 static bstd_status marshal_int32_ptr(uint8_t* buf, size_t buf_len, size_t* offset, const void* el) { return bstd_marshal_int32(buf, buf_len, offset, *(const int32_t*)el); }
@@ -630,6 +656,7 @@ int main(void) {
     // String, bytes, and view tests
     RUN_TEST(test_string);
     RUN_TEST(test_string_view);
+    RUN_TEST(test_string_nul);
     RUN_TEST(test_bytes);
     
     // Generic container tests
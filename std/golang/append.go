@@ -0,0 +1,201 @@
+package bstd
+
+import (
+	"slices"
+	"time"
+)
+
+// The Append functions marshal a value to the end of a byte slice, growing it as needed, like
+// the Append functions of the standard library, e.g. strconv.AppendInt. They need no size
+// computed up front, which suits ad-hoc encoding:
+//
+//	b = bstd.AppendString(b, name)
+//	b = bstd.AppendUint64Varint(b, id)
+
+// grow extends 'b' by 'size' bytes and returns it, as well as the offset of the new bytes.
+func grow(b []byte, size int) ([]byte, int) {
+	n := len(b)
+	return slices.Grow(b, size)[:n+size], n
+}
+
+// Returns 'b' with the marshalled string appended.
+func AppendString(b []byte, s string) []byte {
+	b, n := grow(b, SizeString(s))
+	MarshalString(n, b, s)
+	return b
+}
+
+// Returns 'b' with the marshalled byte slice appended.
+func AppendBytes(b []byte, bs []byte) []byte {
+	b, n := grow(b, SizeBytes(bs))
+	MarshalBytes(n, b, bs)
+	return b
+}
+
+// Returns 'b' with the marshalled byte appended.
+func AppendByte(b []byte, v byte) []byte {
+	b, n := grow(b, SizeByte())
+	MarshalByte(n, b, v)
+	return b
+}
+
+// Returns 'b' with the marshalled bool appended.
+func AppendBool(b []byte, v bool) []byte {
+	b, n := grow(b, SizeBool())
+	MarshalBool(n, b, v)
+	return b
+}
+
+// Returns 'b' with the marshalled int appended.
+func AppendInt(b []byte, v int) []byte {
+	b, n := grow(b, SizeInt(v))
+	MarshalInt(n, b, v)
+	return b
+}
+
+// Returns 'b' with the marshalled uint appended.
+func AppendUint(b []byte, v uint) []byte {
+	b, n := grow(b, SizeUint(v))
+	MarshalUint(n, b, v)
+	return b
+}
+
+// Returns 'b' with the marshalled uintptr appended.
+func AppendUintptr(b []byte, v uintptr) []byte {
+	b, n := grow(b, SizeUintptr(v))
+	MarshalUintptr(n, b, v)
+	return b
+}
+
+// Returns 'b' with the marshalled int8 appended.
+func AppendInt8(b []byte, v int8) []byte {
+	b, n := grow(b, SizeInt8())
+	MarshalInt8(n, b, v)
+	return b
+}
+
+// Returns 'b' with the marshalled int16 appended.
+func AppendInt16(b []byte, v int16) []byte {
+	b, n := grow(b, SizeInt16())
+	MarshalInt16(n, b, v)
+	return b
+}
+
+// Returns 'b' with the marshalled int32 appended.
+func AppendInt32(b []byte, v int32) []byte {
+	b, n := grow(b, SizeInt32())
+	MarshalInt32(n, b, v)
+	return b
+}
+
+// Returns 'b' with the marshalled int64 appended.
+func AppendInt64(b []byte, v int64) []byte {
+	b, n := grow(b, SizeInt64())
+	MarshalInt64(n, b, v)
+	return b
+}
+
+// Returns 'b' with the marshalled uint16 appended.
+func AppendUint16(b []byte, v uint16) []byte {
+	b, n := grow(b, SizeUint16())
+	MarshalUint16(n, b, v)
+	return b
+}
+
+// Returns 'b' with the marshalled uint32 appended.
+func AppendUint32(b []byte, v uint32) []byte {
+	b, n := grow(b, SizeUint32())
+	MarshalUint32(n, b, v)
+	return b
+}
+
+// Returns 'b' with the marshalled uint64 appended.
+func AppendUint64(b []byte, v uint64) []byte {
+	b, n := grow(b, SizeUint64())
+	MarshalUint64(n, b, v)
+	return b
+}
+
+// Returns 'b' with the marshalled int16 as varint appended.
+func AppendInt16Varint(b []byte, v int16) []byte {
+	b, n := grow(b, SizeInt16Varint(v))
+	MarshalInt16Varint(n, b, v)
+	return b
+}
+
+// Returns 'b' with the marshalled int32 as varint appended.
+func AppendInt32Varint(b []byte, v int32) []byte {
+	b, n := grow(b, SizeInt32Varint(v))
+	MarshalInt32Varint(n, b, v)
+	return b
+}
+
+// Returns 'b' with the marshalled int64 as varint appended.
+func AppendInt64Varint(b []byte, v int64) []byte {
+	b, n := grow(b, SizeInt64Varint(v))
+	MarshalInt64Varint(n, b, v)
+	return b
+}
+
+// Returns 'b' with the marshalled uint16 as varint appended.
+func AppendUint16Varint(b []byte, v uint16) []byte {
+	b, n := grow(b, SizeUint16Varint(v))
+	MarshalUint16Varint(n, b, v)
+	return b
+}
+
+// Returns 'b' with the marshalled uint32 as varint appended.
+func AppendUint32Varint(b []byte, v uint32) []byte {
+	b, n := grow(b, SizeUint32Varint(v))
+	MarshalUint32Varint(n, b, v)
+	return b
+}
+
+// Returns 'b' with the marshalled uint64 as varint appended.
+func AppendUint64Varint(b []byte, v uint64) []byte {
+	b, n := grow(b, SizeUint64Varint(v))
+	MarshalUint64Varint(n, b, v)
+	return b
+}
+
+// Returns 'b' with the marshalled float32 as float16 appended.
+func AppendFloat16(b []byte, v float32) []byte {
+	b, n := grow(b, SizeFloat16())
+	MarshalFloat16(n, b, v)
+	return b
+}
+
+// Returns 'b' with the marshalled float32 appended.
+func AppendFloat32(b []byte, v float32) []byte {
+	b, n := grow(b, SizeFloat32())
+	MarshalFloat32(n, b, v)
+	return b
+}
+
+// Returns 'b' with the marshalled float64 appended.
+func AppendFloat64(b []byte, v float64) []byte {
+	b, n := grow(b, SizeFloat64())
+	MarshalFloat64(n, b, v)
+	return b
+}
+
+// Returns 'b' with the marshalled time appended.
+func AppendTime(b []byte, t time.Time) []byte {
+	b, n := grow(b, SizeTime())
+	MarshalTime(n, b, t)
+	return b
+}
+
+// Returns 'b' with the marshalled duration appended.
+func AppendDuration(b []byte, d time.Duration) []byte {
+	b, n := grow(b, SizeDuration())
+	MarshalDuration(n, b, d)
+	return b
+}
+
+// Returns 'b' with the marshalled UUID appended.
+func AppendUUID(b []byte, id [16]byte) []byte {
+	b, n := grow(b, SizeUUID())
+	MarshalUUID(n, b, id)
+	return b
+}
@@ -0,0 +1,60 @@
+package bstd
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestAppend(t *testing.T) {
+	now := time.Unix(1700000000, 5)
+	id := [16]byte{1, 2, 3}
+	tests := []struct {
+		name    string
+		size    int
+		append  func(b []byte) []byte
+		marshal func(n int, b []byte) int
+	}{
+		{"String", SizeString("benc"), func(b []byte) []byte { return AppendString(b, "benc") }, func(n int, b []byte) int { return MarshalString(n, b, "benc") }},
+		{"Bytes", SizeBytes([]byte{1, 2}), func(b []byte) []byte { return AppendBytes(b, []byte{1, 2}) }, func(n int, b []byte) int { return MarshalBytes(n, b, []byte{1, 2}) }},
+		{"Byte", SizeByte(), func(b []byte) []byte { return AppendByte(b, 7) }, func(n int, b []byte) int { return MarshalByte(n, b, 7) }},
+		{"Bool", SizeBool(), func(b []byte) []byte { return AppendBool(b, true) }, func(n int, b []byte) int { return MarshalBool(n, b, true) }},
+		{"Int", SizeInt(-300), func(b []byte) []byte { return AppendInt(b, -300) }, func(n int, b []byte) int { return MarshalInt(n, b, -300) }},
+		{"Uint", SizeUint(300), func(b []byte) []byte { return AppendUint(b, 300) }, func(n int, b []byte) int { return MarshalUint(n, b, 300) }},
+		{"Uintptr", SizeUintptr(300), func(b []byte) []byte { return AppendUintptr(b, 300) }, func(n int, b []byte) int { return MarshalUintptr(n, b, 300) }},
+		{"Int8", SizeInt8(), func(b []byte) []byte { return AppendInt8(b, -8) }, func(n int, b []byte) int { return MarshalInt8(n, b, -8) }},
+		{"Int16", SizeInt16(), func(b []byte) []byte { return AppendInt16(b, -16) }, func(n int, b []byte) int { return MarshalInt16(n, b, -16) }},
+		{"Int32", SizeInt32(), func(b []byte) []byte { return AppendInt32(b, -32) }, func(n int, b []byte) int { return MarshalInt32(n, b, -32) }},
+		{"Int64", SizeInt64(), func(b []byte) []byte { return AppendInt64(b, -64) }, func(n int, b []byte) int { return MarshalInt64(n, b, -64) }},
+		{"Uint16", SizeUint16(), func(b []byte) []byte { return AppendUint16(b, 16) }, func(n int, b []byte) int { return MarshalUint16(n, b, 16) }},
+		{"Uint32", SizeUint32(), func(b []byte) []byte { return AppendUint32(b, 32) }, func(n int, b []byte) int { return MarshalUint32(n, b, 32) }},
+		{"Uint64", SizeUint64(), func(b []byte) []byte { return AppendUint64(b, 64) }, func(n int, b []byte) int { return MarshalUint64(n, b, 64) }},
+		{"Int16Varint", SizeInt16Varint(-300), func(b []byte) []byte { return AppendInt16Varint(b, -300) }, func(n int, b []byte) int { return MarshalInt16Varint(n, b, -300) }},
+		{"Int32Varint", SizeInt32Varint(-300), func(b []byte) []byte { return AppendInt32Varint(b, -300) }, func(n int, b []byte) int { return MarshalInt32Varint(n, b, -300) }},
+		{"Int64Varint", SizeInt64Varint(-300), func(b []byte) []byte { return AppendInt64Varint(b, -300) }, func(n int, b []byte) int { return MarshalInt64Varint(n, b, -300) }},
+		{"Uint16Varint", SizeUint16Varint(300), func(b []byte) []byte { return AppendUint16Varint(b, 300) }, func(n int, b []byte) int { return MarshalUint16Varint(n, b, 300) }},
+		{"Uint32Varint", SizeUint32Varint(300), func(b []byte) []byte { return AppendUint32Varint(b, 300) }, func(n int, b []byte) int { return MarshalUint32Varint(n, b, 300) }},
+		{"Uint64Varint", SizeUint64Varint(300), func(b []byte) []byte { return AppendUint64Varint(b, 300) }, func(n int, b []byte) int { return MarshalUint64Varint(n, b, 300) }},
+		{"Float16", SizeFloat16(), func(b []byte) []byte { return AppendFloat16(b, 1.5) }, func(n int, b []byte) int { return MarshalFloat16(n, b, 1.5) }},
+		{"Float32", SizeFloat32(), func(b []byte) []byte { return AppendFloat32(b, 1.5) }, func(n int, b []byte) int { return MarshalFloat32(n, b, 1.5) }},
+		{"Float64", SizeFloat64(), func(b []byte) []byte { return AppendFloat64(b, 1.5) }, func(n int, b []byte) int { return MarshalFloat64(n, b, 1.5) }},
+		{"Time", SizeTime(), func(b []byte) []byte { return AppendTime(b, now) }, func(n int, b []byte) int { return MarshalTime(n, b, now) }},
+		{"Duration", SizeDuration(), func(b []byte) []byte { return AppendDuration(b, time.Second) }, func(n int, b []byte) int { return MarshalDuration(n, b, time.Second) }},
+		{"UUID", SizeUUID(), func(b []byte) []byte { return AppendUUID(b, id) }, func(n int, b []byte) int { return MarshalUUID(n, b, id) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expected := make([]byte, 2+tt.size)
+			expected[0], expected[1] = 0xab, 0xcd
+			if n := tt.marshal(2, expected); n != len(expected) {
+				t.Fatalf("marshal returned %d, expected %d", n, len(expected))
+			}
+			// without spare capacity and with enough of it
+			for _, b := range [][]byte{{0xab, 0xcd}, append(make([]byte, 0, 64), 0xab, 0xcd)} {
+				if got := tt.append(b); !bytes.Equal(got, expected) {
+					t.Fatalf("expected % x, got % x", expected, got)
+				}
+			}
+		})
+	}
+}
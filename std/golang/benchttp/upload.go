@@ -0,0 +1,141 @@
+// Package benchttp streams benc payloads of any size over HTTP. It is kept apart from bstd,
+// so programs using the generated code don't link net/http.
+package benchttp
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"net/http"
+	"sync"
+
+	bstd "github.com/banditmoscow1337/benc/std/golang"
+)
+
+// An upload sends a payload of any size, e.g. the snapshot of a multiple gigabyte database, as
+// the body of a HTTP request in the chunked encoding, see bstd.ChunkWriter, while it is marshalled.
+// Neither the client nor the server holds more than a chunk of it in memory. The CRC-32C of the
+// payload follows the body in the trailer UploadChecksumTrailer, which HTTP/1.1 sends after a
+// body of chunked transfer encoding and HTTP/2 after the last DATA frame, so the server finds
+// a truncated or corrupted payload once it read it.
+
+// UploadChecksumTrailer is the trailer holding the CRC-32C (Castagnoli) of the payload of an
+// upload as 8 hex digits.
+const UploadChecksumTrailer = "Benc-Checksum"
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// Returns a request with the method 'method' to 'url', whose body is the payload written by
+// 'write' in chunks of 'chunkSize' bytes, followed by its checksum in the trailer
+// UploadChecksumTrailer. 'write' runs in a goroutine of its own once the body is read, e.g.
+// by http.Client.Do; the error it returns fails the request. Sending the request again, e.g.
+// on a redirect, isn't possible, as the payload is written once.
+//
+// !- Panics, if 'chunkSize' isn't positive.
+func NewUploadRequest(ctx context.Context, method, url string, chunkSize int, write func(w io.Writer) error) (*http.Request, error) {
+	if chunkSize <= 0 {
+		panic("benc: invalid `chunkSize` provided in `NewUploadRequest`")
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	pr, pw := io.Pipe()
+	req.Body = &uploadBody{pr: pr, pw: pw, req: req, chunkSize: chunkSize, write: write}
+	req.ContentLength = -1
+	req.Trailer = http.Header{UploadChecksumTrailer: nil}
+	return req, nil
+}
+
+// uploadBody is the body of an upload request, which starts writing the payload on the first
+// read, so a request, which is never sent, leaves no goroutine behind.
+type uploadBody struct {
+	pr        *io.PipeReader
+	pw        *io.PipeWriter
+	req       *http.Request
+	chunkSize int
+	write     func(w io.Writer) error
+	start     sync.Once
+}
+
+func (ub *uploadBody) Read(p []byte) (int, error) {
+	ub.start.Do(func() { go ub.run() })
+	return ub.pr.Read(p)
+}
+
+func (ub *uploadBody) Close() error {
+	return ub.pr.Close()
+}
+
+// run writes the payload and its checksum. The trailer is set before the pipe is closed, so
+// the transport, which reads the trailer after the end of the body, sees it.
+func (ub *uploadBody) run() {
+	cw := bstd.NewChunkWriter(ub.pw, ub.chunkSize)
+	sum := crc32.New(castagnoli)
+	err := ub.write(io.MultiWriter(sum, cw))
+	if err == nil {
+		err = cw.Close()
+	}
+	if err == nil {
+		ub.req.Trailer.Set(UploadChecksumTrailer, fmt.Sprintf("%08x", sum.Sum32()))
+	}
+	ub.pw.CloseWithError(err)
+}
+
+// UploadReader reads the payload of an upload request on the server, see NewUploadRequest.
+type UploadReader struct {
+	req *http.Request
+	br  *bufio.Reader
+	cr  *bstd.ChunkReader
+	sum hash.Hash32
+	err error
+}
+
+// Returns an UploadReader reading the payload of the upload request 'r'.
+func NewUploadReader(r *http.Request) *UploadReader {
+	br := bufio.NewReader(r.Body)
+	return &UploadReader{req: r, br: br, cr: bstd.NewChunkReader(br), sum: crc32.New(castagnoli)}
+}
+
+// Read reads the bytes of the payload into 'p', returning io.EOF at its end, once its checksum
+// matches the one of the trailer. After an error, every call returns it.
+//
+// Possible errors returned:
+//   - io.EOF                  - the payload ended and its checksum matches.
+//   - io.ErrUnexpectedEOF     - the body ended in the middle of the payload.
+//   - bstd.ErrTrailingBytes   - the body continues after the payload.
+//   - bstd.ErrChecksum        - the trailer is missing or doesn't match the payload.
+//   - any other error of bstd.ChunkReader.Read or of the body
+func (ur *UploadReader) Read(p []byte) (int, error) {
+	if ur.err != nil {
+		return 0, ur.err
+	}
+	n, err := ur.cr.Read(p)
+	ur.sum.Write(p[:n])
+	if err == io.EOF {
+		if err = ur.verify(); err == nil {
+			err = io.EOF
+		}
+	}
+	ur.err = err
+	return n, err
+}
+
+// verify reads the body to its end, which makes the trailer available, and compares the
+// checksum of the trailer with the one of the payload.
+func (ur *UploadReader) verify() error {
+	if _, err := ur.br.ReadByte(); err != io.EOF {
+		if err == nil {
+			err = bstd.ErrTrailingBytes
+		}
+		return err
+	}
+	got := fmt.Sprintf("%08x", ur.sum.Sum32())
+	if want := ur.req.Trailer.Get(UploadChecksumTrailer); want != got {
+		return fmt.Errorf("%w: trailer %s is %q, the payload has %s", bstd.ErrChecksum, UploadChecksumTrailer, want, got)
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package benchttp
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	bstd "github.com/banditmoscow1337/benc/std/golang"
+)
+
+func TestUpload(t *testing.T) {
+	payload := make([]byte, 300_000)
+	for i := range payload {
+		payload[i] = byte(i * 7)
+	}
+
+	var got []byte
+	var readErr error
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, readErr = io.ReadAll(NewUploadReader(r))
+	})
+
+	for _, h2 := range []bool{false, true} {
+		ts := httptest.NewUnstartedServer(handler)
+		ts.EnableHTTP2 = h2
+		ts.StartTLS()
+
+		req, err := NewUploadRequest(context.Background(), http.MethodPost, ts.URL, 4096, func(w io.Writer) error {
+			// pieces of uneven size, as a marshaller writes them
+			for i := 0; i < len(payload); i += 1000 + i%777 {
+				if _, err := w.Write(payload[i:min(i+1000+i%777, len(payload))]); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		res, err := ts.Client().Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		if h2 != (res.ProtoMajor == 2) {
+			t.Fatalf("expected HTTP/2 to be %v, got %s", h2, res.Proto)
+		}
+		if readErr != nil || !bytes.Equal(got, payload) {
+			t.Fatalf("%s: read %d bytes, %v", res.Proto, len(got), readErr)
+		}
+
+		// the error of write fails the request
+		req, _ = NewUploadRequest(context.Background(), http.MethodPost, ts.URL, 4096, func(w io.Writer) error {
+			return io.ErrClosedPipe
+		})
+		if res, err = ts.Client().Do(req); err == nil {
+			res.Body.Close()
+			t.Fatalf("%s: expected the error of write to fail the request", res.Proto)
+		}
+		ts.Close()
+	}
+
+	ts := httptest.NewServer(handler)
+	defer ts.Close()
+	for name, body := range map[string]string{
+		"checksum": "\x03abc\x00",
+		"trailing": "\x03abc\x00\x00",
+		"cut":      "\x03abc",
+	} {
+		req, _ := http.NewRequest(http.MethodPost, ts.URL, io.NopCloser(strings.NewReader(body)))
+		req.ContentLength = -1
+		// the checksum of "abc", which is only right for a body ending after the payload
+		req.Trailer = http.Header{UploadChecksumTrailer: {"364b3fb7"}}
+		if name == "checksum" {
+			req.Trailer.Set(UploadChecksumTrailer, "00000000")
+		}
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		switch name {
+		case "checksum":
+			if !errors.Is(readErr, bstd.ErrChecksum) {
+				t.Fatalf("%s: expected ErrChecksum, got %v", name, readErr)
+			}
+		case "trailing":
+			if !errors.Is(readErr, bstd.ErrTrailingBytes) {
+				t.Fatalf("%s: expected ErrTrailingBytes, got %v", name, readErr)
+			}
+		case "cut":
+			if !errors.Is(readErr, io.ErrUnexpectedEOF) {
+				t.Fatalf("%s: expected io.ErrUnexpectedEOF, got %v", name, readErr)
+			}
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package bstd
+
+// BencMarshaler is implemented by hand-written types, which marshal themselves. Passing a nil
+// sizer or marshaler to SizeSlice, MarshalSlice, SizeMap, MarshalMap, MarshalMapCanonical,
+// SizePointer or MarshalPointer sizes and marshals their elements by it, e.g.
+//
+//	bstd.MarshalSlice(n, b, amounts, nil)
+//
+// The methods may have value or pointer receivers.
+type BencMarshaler interface {
+	// SizeBenc returns the bytes needed to marshal the value.
+	SizeBenc() int
+	// MarshalBenc returns the new offset 'n' after marshalling the value.
+	//
+	// !- Panics, if 'b' is too small.
+	MarshalBenc(n int, b []byte) int
+}
+
+// BencUnmarshaler is the counterpart of BencMarshaler, implemented by pointers to the values.
+// Passing a nil unmarshaler to UnmarshalSlice, UnmarshalMap, UnmarshalPointer and their
+// variants unmarshals their elements by it.
+type BencUnmarshaler interface {
+	// UnmarshalBenc returns the new offset 'n' after unmarshalling the value.
+	//
+	// If a error is returned, n (the int returned) equals zero ( 0 ).
+	UnmarshalBenc(n int, b []byte) (int, error)
+}
+
+// bencMarshaler returns the BencMarshaler of 'v' or its pointer, and panics, if neither
+// implements it, as a nil function was passed for a type, which doesn't marshal itself.
+func bencMarshaler[T any](v *T) BencMarshaler {
+	if m, ok := any(*v).(BencMarshaler); ok {
+		return m
+	}
+	if m, ok := any(v).(BencMarshaler); ok {
+		return m
+	}
+	panic("benc: nil sizer or marshaler provided for a type not implementing `BencMarshaler`")
+}
+
+func sizeBenc[T any](v T) int {
+	return bencMarshaler(&v).SizeBenc()
+}
+
+func marshalBenc[T any](n int, b []byte, v T) int {
+	return bencMarshaler(&v).MarshalBenc(n, b)
+}
+
+func unmarshalBenc[T any](n int, b []byte, v *T) (int, error) {
+	u, ok := any(v).(BencUnmarshaler)
+	if !ok {
+		panic("benc: nil unmarshaler provided for a type not implementing `BencUnmarshaler`")
+	}
+	return u.UnmarshalBenc(n, b)
+}
+
+// mapMarshalers replaces a nil key or value marshaler by the one of BencMarshaler.
+func mapMarshalers[K, V any](kMarshaler MarshalFunc[K], vMarshaler MarshalFunc[V]) (MarshalFunc[K], MarshalFunc[V]) {
+	if kMarshaler == nil {
+		kMarshaler = marshalBenc[K]
+	}
+	if vMarshaler == nil {
+		vMarshaler = marshalBenc[V]
+	}
+	return kMarshaler, vMarshaler
+}
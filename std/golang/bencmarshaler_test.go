@@ -0,0 +1,85 @@
+package bstd
+
+import (
+	"maps"
+	"slices"
+	"testing"
+)
+
+// testCents marshals itself with value receivers as a uint32 of cents.
+type testCents float64
+
+func (c testCents) SizeBenc() int { return SizeUint32() }
+
+func (c testCents) MarshalBenc(n int, b []byte) int {
+	return MarshalUint32(n, b, uint32(c*100))
+}
+
+func (c *testCents) UnmarshalBenc(n int, b []byte) (int, error) {
+	n, u, err := UnmarshalUint32(n, b)
+	*c = testCents(u) / 100
+	return n, err
+}
+
+// testLabel marshals itself with pointer receivers only.
+type testLabel struct{ s string }
+
+func (l *testLabel) SizeBenc() int { return SizeString(l.s) }
+
+func (l *testLabel) MarshalBenc(n int, b []byte) int { return MarshalString(n, b, l.s) }
+
+func (l *testLabel) UnmarshalBenc(n int, b []byte) (int, error) {
+	var err error
+	n, l.s, err = UnmarshalString(n, b)
+	return n, err
+}
+
+func TestBencMarshaler(t *testing.T) {
+	cents := []testCents{1.25, 0, 99.5}
+	s := SizeSlice(cents, nil)
+	if want := SizeFixedSlice(cents, SizeUint32()); s != want {
+		t.Fatalf("expected size %d, got %d", want, s)
+	}
+	buf := make([]byte, s)
+	if n := MarshalSlice(0, buf, cents, nil); n != s {
+		t.Fatalf("expected offset %d, got %d", s, n)
+	}
+	n, gotCents, err := UnmarshalSlice[testCents](0, buf, nil)
+	if err != nil || n != s || !slices.Equal(gotCents, cents) {
+		t.Fatalf("got %v, %d, %v", gotCents, n, err)
+	}
+
+	labels := map[testLabel]testCents{{"a"}: 1, {"bc"}: 2.5}
+	s = SizeMap(labels, nil, nil)
+	buf = make([]byte, s)
+	if n := MarshalMap(0, buf, labels, nil, nil); n != s {
+		t.Fatalf("expected offset %d, got %d", s, n)
+	}
+	n, gotLabels, err := UnmarshalMap[testLabel, testCents](0, buf, nil, nil)
+	if err != nil || n != s || !maps.Equal(gotLabels, labels) {
+		t.Fatalf("got %v, %d, %v", gotLabels, n, err)
+	}
+	canonical := make([]byte, s)
+	if n := MarshalMapCanonical(0, canonical, labels, nil, nil); n != s {
+		t.Fatalf("canonical: expected offset %d, got %d", s, n)
+	}
+
+	for _, p := range []*testLabel{nil, {"x"}} {
+		s = SizePointer(p, nil)
+		buf = make([]byte, s)
+		if n := MarshalPointer(0, buf, p, nil); n != s {
+			t.Fatalf("%v: expected offset %d, got %d", p, s, n)
+		}
+		n, got, err := UnmarshalPointer[testLabel](0, buf, nil)
+		if err != nil || n != s || (got == nil) != (p == nil) || (p != nil && *got != *p) {
+			t.Fatalf("%v: got %v, %d, %v", p, got, n, err)
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected a panic of a nil sizer for a type not implementing BencMarshaler")
+		}
+	}()
+	SizeSlice([]int{1}, nil)
+}
@@ -0,0 +1,76 @@
+//go:build benc_experimental
+
+package bstd
+
+import (
+	"math"
+	"math/rand"
+)
+
+// The bfloat16 encoding is experimental and built with the benc_experimental build tag only.
+// It stores a float32 as brain floating point in 2 bytes, the upper half of its bits: the sign,
+// all 8 exponent bits and 7 mantissa bits. Unlike float16 it keeps the range of float32, so the
+// tensors of ML models, which are trained and exported in bfloat16, round trip without loss,
+// at half the size of float32. Other values are rounded to the nearest bfloat16 (ties to even)
+// and NaNs stay NaNs.
+
+// Returns the new offset 'n' after skipping the marshalled bfloat16.
+//
+// Possible errors returned:
+//   - ErrBufTooSmall       - 'b' was too small to skip the marshalled bfloat16.
+//
+// If a error is returned, n (the int returned) equals zero ( 0 ).
+func SkipBFloat16(n int, b []byte) (int, error) {
+	if len(b)-n < 2 {
+		return 0, ErrBufTooSmall
+	}
+	return n + 2, nil
+}
+
+// Returns the bytes needed to marshal a bfloat16.
+func SizeBFloat16() int {
+	return 2
+}
+
+// Returns the new offset 'n' after marshalling the 32-bit float 'v' as bfloat16.
+//
+// !- Panics, if 'b' is too small.
+func MarshalBFloat16(n int, b []byte, v float32) int {
+	h := float32ToBFloat16(v)
+	u := b[n : n+2]
+	_ = u[1]
+	u[0] = byte(h)
+	u[1] = byte(h >> 8)
+	return n + 2
+}
+
+// Returns the new offset 'n', as well as the bfloat16 as 32-bit float, that got unmarshalled.
+//
+// Possible errors returned:
+//   - ErrBufTooSmall       - 'b' was too small to unmarshal the bfloat16.
+//
+// If a error is returned, n (the int returned) equals zero ( 0 ).
+func UnmarshalBFloat16(n int, b []byte) (int, float32, error) {
+	if len(b)-n < 2 {
+		return 0, 0, ErrBufTooSmall
+	}
+	u := b[n : n+2]
+	_ = u[1]
+	return n + 2, math.Float32frombits(uint32(u[0])<<16 | uint32(u[1])<<24), nil
+}
+
+// GenerateBFloat16 returns a random float32, which is a bfloat16, so it round trips exactly.
+func GenerateBFloat16(r *rand.Rand, depth int) float32 {
+	return math.Float32frombits(math.Float32bits(GenerateFloat32(r, depth)) &^ 0xffff)
+}
+
+// float32ToBFloat16 returns the bits of the bfloat16 nearest to 'v'.
+func float32ToBFloat16(v float32) uint16 {
+	bits := math.Float32bits(v)
+	if bits&0x7fffffff > 0x7f800000 {
+		// a NaN, whose payload may be in the lower half only, is quieted
+		return uint16(bits>>16) | 0x40
+	}
+	// a carry of the rounding into the exponent is correct, up to infinity
+	return uint16((bits + 0x7fff + bits>>16&1) >> 16)
+}
@@ -0,0 +1,68 @@
+//go:build benc_experimental
+
+package bstd
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestBFloat16(t *testing.T) {
+	maxBF := math.Float32frombits(0x7f7f0000)
+	for _, c := range []struct {
+		v, want float32
+	}{
+		{0, 0},
+		{1, 1},
+		{-2.5, -2.5},
+		{maxBF, maxBF},
+		{float32(math.Inf(-1)), float32(math.Inf(-1))},
+		// the range of float32 is kept, unlike by float16
+		{1e38, math.Float32frombits(0x7e96_0000)},
+		{1e-38, math.Float32frombits(0x006d_0000)},
+		// 1 + 2^-8 is halfway between 1 and 1 + 2^-7, ties to the even 1
+		{1 + 1.0/256, 1},
+		{1 + 3.0/256, 1 + 4.0/256},
+		{1 + 1.0/256 + 1.0/65536, 1 + 2.0/256},
+		// beyond the largest bfloat16 the rounding carries into infinity
+		{math.MaxFloat32, float32(math.Inf(1))},
+	} {
+		buf := make([]byte, SizeBFloat16())
+		if n := MarshalBFloat16(0, buf, c.v); n != 2 {
+			t.Fatalf("%g: expected offset 2, got %d", c.v, n)
+		}
+		n, got, err := UnmarshalBFloat16(0, buf)
+		if err != nil || n != 2 || got != c.want {
+			t.Fatalf("%g: expected %g, got %g, %d, %v", c.v, c.want, got, n, err)
+		}
+		if n, err = SkipBFloat16(0, buf); err != nil || n != 2 {
+			t.Fatalf("%g: skip got %d, %v", c.v, n, err)
+		}
+		for i := range 2 {
+			if _, _, err = UnmarshalBFloat16(0, buf[:i]); !errors.Is(err, ErrBufTooSmall) {
+				t.Fatalf("%d bytes: expected ErrBufTooSmall, got %v", i, err)
+			}
+			if _, err = SkipBFloat16(0, buf[:i]); !errors.Is(err, ErrBufTooSmall) {
+				t.Fatalf("%d bytes: skip expected ErrBufTooSmall, got %v", i, err)
+			}
+		}
+	}
+
+	// a NaN with its payload in the lower half of its bits stays a NaN
+	buf := make([]byte, SizeBFloat16())
+	MarshalBFloat16(0, buf, math.Float32frombits(0x7f800001))
+	if _, got, _ := UnmarshalBFloat16(0, buf); got == got {
+		t.Fatalf("expected NaN, got %g", got)
+	}
+
+	r := rand.New(rand.NewSource(1))
+	for range 1000 {
+		v := GenerateBFloat16(r, 0)
+		MarshalBFloat16(0, buf, v)
+		if _, got, _ := UnmarshalBFloat16(0, buf); got != v {
+			t.Fatalf("expected the generated %g to round trip, got %g", v, got)
+		}
+	}
+}
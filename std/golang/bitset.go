@@ -0,0 +1,152 @@
+package bstd
+
+import "math/bits"
+
+// Bitset is a set of non-negative integers, e.g. feature flags or the indices of a sparse
+// boolean slice, as bits packed into 64-bit words: i is in the set, if bit i%64 of word i/64
+// is set. The zero value is an empty set.
+//
+// A Bitset is marshalled as the number of its words as varint followed by the words in little
+// endian. Trailing zero words aren't marshalled, so equal sets marshal to equal bytes.
+type Bitset []uint64
+
+// NewBitset returns a Bitset holding the 'indices'.
+//
+// !- Panics, if an index is negative.
+func NewBitset(indices ...int) Bitset {
+	var s Bitset
+	for _, i := range indices {
+		s.Set(i)
+	}
+	return s
+}
+
+// Set adds 'i' to the set, growing it if needed.
+//
+// !- Panics, if 'i' is negative.
+func (s *Bitset) Set(i int) {
+	if i < 0 {
+		panic("benc: negative index in `Bitset.Set`")
+	}
+	w := i / 64
+	if w >= len(*s) {
+		*s = append(*s, make([]uint64, w+1-len(*s))...)
+	}
+	(*s)[w] |= 1 << (i % 64)
+}
+
+// Clear removes 'i' from the set.
+func (s Bitset) Clear(i int) {
+	if i >= 0 && i/64 < len(s) {
+		s[i/64] &^= 1 << (i % 64)
+	}
+}
+
+// Has reports whether 'i' is in the set.
+func (s Bitset) Has(i int) bool {
+	return i >= 0 && i/64 < len(s) && s[i/64]&(1<<(i%64)) != 0
+}
+
+// Count returns the number of integers in the set.
+func (s Bitset) Count() int {
+	c := 0
+	for _, w := range s {
+		c += bits.OnesCount64(w)
+	}
+	return c
+}
+
+// Indices returns the integers in the set in ascending order.
+func (s Bitset) Indices() []int {
+	indices := make([]int, 0, s.Count())
+	for i, w := range s {
+		for ; w != 0; w &= w - 1 {
+			indices = append(indices, i*64+bits.TrailingZeros64(w))
+		}
+	}
+	return indices
+}
+
+// Equal reports whether both sets hold the same integers, ignoring trailing zero words.
+func (s Bitset) Equal(o Bitset) bool {
+	s, o = s.trimmed(), o.trimmed()
+	if len(s) != len(o) {
+		return false
+	}
+	for i := range s {
+		if s[i] != o[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// trimmed returns the words up to the last non-zero one.
+func (s Bitset) trimmed() Bitset {
+	for len(s) > 0 && s[len(s)-1] == 0 {
+		s = s[:len(s)-1]
+	}
+	return s
+}
+
+// Returns the new offset 'n' after skipping the marshalled bitset.
+//
+// Possible errors returned:
+//   - ErrBufTooSmall       - 'b' was too small to skip the marshalled bitset.
+//   - ErrOverflow          - varint overflowed a N-bit unsigned integer.
+//
+// If a error is returned, n (the int returned) equals zero ( 0 ).
+func SkipBitset(n int, b []byte) (int, error) {
+	n, words, err := UnmarshalUint(n, b)
+	if err != nil {
+		return 0, err
+	}
+	if words > uint(len(b)-n)/8 {
+		return 0, ErrBufTooSmall
+	}
+	return n + 8*int(words), nil
+}
+
+// Returns the bytes needed to marshal the bitset 's'.
+func SizeBitset(s Bitset) int {
+	s = s.trimmed()
+	return SizeUint(uint(len(s))) + 8*len(s)
+}
+
+// Returns the new offset 'n' after marshalling the bitset 's'.
+//
+// !- Panics, if 'b' is too small.
+func MarshalBitset(n int, b []byte, s Bitset) int {
+	s = s.trimmed()
+	n = MarshalUint(n, b, uint(len(s)))
+	for _, w := range s {
+		n = MarshalUint64(n, b, w)
+	}
+	return n
+}
+
+// Returns the new offset 'n', as well as the bitset, that got unmarshalled. An empty set
+// unmarshals as nil.
+//
+// Possible errors returned:
+//   - ErrBufTooSmall       - 'b' was too small to unmarshal the bitset.
+//   - ErrOverflow          - varint overflowed a N-bit unsigned integer.
+//
+// If a error is returned, n (the int returned) equals zero ( 0 ).
+func UnmarshalBitset(n int, b []byte) (int, Bitset, error) {
+	n, words, err := UnmarshalUint(n, b)
+	if err != nil {
+		return 0, nil, err
+	}
+	if words > uint(len(b)-n)/8 {
+		return 0, nil, ErrBufTooSmall
+	}
+	if words == 0 {
+		return n, nil, nil
+	}
+	s := make(Bitset, words)
+	for i := range s {
+		n, s[i], _ = UnmarshalUint64(n, b)
+	}
+	return n, s, nil
+}
@@ -0,0 +1,61 @@
+package bstd
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestBitset(t *testing.T) {
+	s := NewBitset(0, 3, 64, 200)
+	if !s.Has(3) || !s.Has(200) || s.Has(1) || s.Has(-1) || s.Has(1000) {
+		t.Fatalf("unexpected members of %v", s.Indices())
+	}
+	if got := s.Indices(); !slices.Equal(got, []int{0, 3, 64, 200}) {
+		t.Fatalf("expected indices [0 3 64 200], got %v", got)
+	}
+	s.Clear(3)
+	s.Clear(5000)
+	if s.Has(3) || s.Count() != 3 {
+		t.Fatalf("expected 3 to be cleared, got %v", s.Indices())
+	}
+
+	// the trailing zero word of 200 isn't marshalled
+	s.Clear(200)
+	buf := make([]byte, SizeBitset(s))
+	if len(buf) != 1+2*8 {
+		t.Fatalf("expected 17 bytes, got %d", len(buf))
+	}
+	if n := MarshalBitset(0, buf, s); n != len(buf) {
+		t.Fatalf("expected offset %d, got %d", len(buf), n)
+	}
+	n, got, err := UnmarshalBitset(0, buf)
+	if err != nil || n != len(buf) || !got.Equal(s) || len(got) != 2 {
+		t.Fatalf("got %v, %d, %v", got, n, err)
+	}
+	if n, err = SkipBitset(0, buf); err != nil || n != len(buf) {
+		t.Fatalf("skip got %d, %v", n, err)
+	}
+	if _, _, err = UnmarshalBitset(0, buf[:len(buf)-1]); err != ErrBufTooSmall {
+		t.Fatalf("expected ErrBufTooSmall, got %v", err)
+	}
+	if _, err = SkipBitset(0, buf[:len(buf)-1]); err != ErrBufTooSmall {
+		t.Fatalf("expected ErrBufTooSmall, got %v", err)
+	}
+
+	var empty Bitset
+	buf = make([]byte, SizeBitset(Bitset{0, 0}))
+	if len(buf) != 1 || !empty.Equal(Bitset{0, 0}) {
+		t.Fatalf("expected empty sets to take 1 byte, got %d", len(buf))
+	}
+	MarshalBitset(0, buf, Bitset{0, 0})
+	if _, got, err = UnmarshalBitset(0, buf); err != nil || got != nil {
+		t.Fatalf("expected a nil set, got %v, %v", got, err)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected a panic on a negative index")
+		}
+	}()
+	s.Set(-1)
+}
@@ -1,23 +1,36 @@
 package bstd
 
 import (
-	"encoding/binary"
+	"encoding"
+	"encoding/json"
 	"errors"
-	"math"
-	"strconv"
+	"math/big"
+	"math/bits"
+	"net"
+	"net/netip"
+	"net/url"
+	"slices"
 	"time"
+	"unicode/utf8"
 	"unsafe"
 
-	"golang.org/x/exp/constraints"
+	"github.com/banditmoscow1337/benc/wire"
 )
 
-var ErrBufTooSmall = errors.New("buffer too small")
+var ErrBufTooSmall = wire.ErrBufTooSmall
 var ErrReuseBufTooSmall = errors.New("reuse buffer too small")
-var ErrOverflow = errors.New("varint overflows a 64-bit integer")
+var ErrOverflow = wire.ErrOverflow
 var ErrVerifyUnmarshal = errors.New("check for a mistake in the unmarshal process")
 var ErrVerifyMarshal = errors.New("check for a mistake in calculating the size or in the marshal process")
+var ErrInvalidData = errors.New("invalid data")
+var ErrInvalidUTF8 = errors.New("string is not valid UTF-8")
+var ErrLimitExceeded = errors.New("slice or map exceeds the unmarshal limits")
+var ErrMaxDepth = errors.New("containers nested too deeply")
+var ErrTrailingBytes = errors.New("bytes left after the marshalled value")
+var ErrDuplicateKey = errors.New("map contains a duplicate key")
 
 
+type SkipFunc func(n int, b []byte) (int, error)
 type SizeFunc[T any] func(t T) int
 type MarshalFunc[T any] func(n int, b []byte, t T) int
 
@@ -30,31 +43,20 @@ type MarshalFunc[T any] func(n int, b []byte, t T) int
 //
 // If a error is returned, n (the int returned) equals zero ( 0 ).
 func SkipString(n int, b []byte) (int, error) {
-	n, us, err := UnmarshalUint(n, b)
-	if err != nil {
-		return 0, err
-	}
-	s := int(us)
-
-	if len(b)-n < s {
-		return n, ErrBufTooSmall
-	}
-	return n + s, nil
+	return wire.SkipBytes(n, b)
 }
 
 // Returns the bytes needed to marshal a string.
 // For unsafe string marshalling too.
 func SizeString(str string) int {
-	v := len(str)
-	return v + SizeUint(uint(v))
+	return wire.SizeString(str)
 }
 
 // Returns the new offset 'n' after marshalling the string.
 //
 // !- Panics, if 'b' is too small.
 func MarshalString(n int, b []byte, str string) int {
-	n = MarshalUint(n, b, uint(len(str)))
-	return n + copy(b[n:], str)
+	return wire.MarshalString(n, b, str)
 }
 
 // Returns the new offset 'n', as well as the string, that got unmarshalled.
@@ -65,16 +67,31 @@ func MarshalString(n int, b []byte, str string) int {
 //
 // If a error is returned, n (the int returned) equals zero ( 0 ).
 func UnmarshalString(n int, b []byte) (int, string, error) {
-	n, us, err := UnmarshalUint(n, b)
+	n, bs, err := wire.UnmarshalBytes(n, b)
 	if err != nil {
 		return 0, "", err
 	}
-	s := int(us)
+	return n, string(bs), nil
+}
 
-	if len(b)-n < s {
-		return n, "", ErrBufTooSmall
+// Returns the new offset 'n', as well as the string, that got unmarshalled.
+// Unlike UnmarshalString, it checks that the string is valid UTF-8.
+//
+// Possible errors returned:
+//   - ErrOverflow          - varint overflowed a N-bit unsigned integer.
+//   - ErrBufTooSmall       - 'buf' was too small to unmarshal the slice.
+//   - ErrInvalidUTF8       - the string is not valid UTF-8.
+//
+// If a error is returned, n (the int returned) equals zero ( 0 ).
+func UnmarshalStringValidated(n int, b []byte) (int, string, error) {
+	n, bs, err := wire.UnmarshalBytes(n, b)
+	if err != nil {
+		return 0, "", err
 	}
-	return n + s, string(b[n : n+s]), nil
+	if !utf8.Valid(bs) {
+		return 0, "", ErrInvalidUTF8
+	}
+	return n, string(bs), nil
 }
 
 // https://github.com/golang/go/issues/53003#issuecomment-1145241692
@@ -136,12 +153,37 @@ func UnmarshalUnsafeString(n int, b []byte) (int, string, error) {
 		return n, "", nil
 	}
 
-	if len(b)-n < s {
-		return n, "", ErrBufTooSmall
+	if uint(len(b)-n) < us {
+		return 0, "", ErrBufTooSmall
 	}
 	return n + s, b2s(b[n : n+s]), nil
 }
 
+// UnmarshalStringCopied returns a copy of the marshalled string inside `b`, like
+// UnmarshalBytesCopied does for byte slices. It is UnmarshalString.
+//
+// Possible errors returned:
+//   - ErrOverflow          - varint overflowed a N-bit unsigned integer.
+//   - ErrBufTooSmall       - 'buf' was too small to unmarshal the string.
+//
+// If a error is returned, n (the int returned) equals zero ( 0 ).
+func UnmarshalStringCopied(n int, b []byte) (int, string, error) {
+	return UnmarshalString(n, b)
+}
+
+// UnmarshalStringCropped returns the marshalled string inside `b` without copying it, like
+// UnmarshalBytesCropped does for byte slices. It is UnmarshalUnsafeString: the string shares
+// the bytes of `b`, so `b` must not be modified while the string is used.
+//
+// Possible errors returned:
+//   - ErrOverflow          - varint overflowed a N-bit unsigned integer.
+//   - ErrBufTooSmall       - 'buf' was too small to unmarshal the string.
+//
+// If a error is returned, n (the int returned) equals zero ( 0 ).
+func UnmarshalStringCropped(n int, b []byte) (int, string, error) {
+	return UnmarshalUnsafeString(n, b)
+}
+
 // Returns the new offset 'n' after skipping the marshalled slice.
 //
 // Possible errors returned:
@@ -172,7 +214,11 @@ func SkipSlice(n int, b []byte, skipElement func(n int, b []byte) (int, error))
 }
 
 // Returns the bytes needed to marshal a slice with a dynamic element size.
+// A nil 'sizer' sizes the elements by their BencMarshaler.
 func SizeSlice[T any](slice []T, sizer SizeFunc[T]) (s int) {
+	if sizer == nil {
+		sizer = sizeBenc[T]
+	}
 	v := len(slice)
 	s += 4 + SizeUint(uint(v))
 
@@ -191,9 +237,13 @@ func SizeFixedSlice[T any](slice []T, elemSize int) (s int) {
 }
 
 // Returns the new offset 'n' after marshalling the slice.
+// A nil 'marshaler' marshals the elements by their BencMarshaler.
 //
 // !- Panics, if 'b' is too small.
 func MarshalSlice[T any](n int, b []byte, slice []T, marshaler MarshalFunc[T]) int {
+	if marshaler == nil {
+		marshaler = marshalBenc[T]
+	}
 	n = MarshalUint(n, b, uint(len(slice)))
 	for _, t := range slice {
 		n = marshaler(n, b, t)
@@ -208,7 +258,101 @@ func MarshalSlice[T any](n int, b []byte, slice []T, marshaler MarshalFunc[T]) i
 	return n + 4
 }
 
+// Limits bound the slices and maps unmarshalled by UnmarshalSliceLimited and
+// UnmarshalMapLimited, so a forged length prefix can't make them allocate or loop
+// for far longer than the data warrants. Zero fields don't limit.
+type Limits struct {
+	// Duplicates is what happens to a map entry, whose key came before in the same map.
+	Duplicates DuplicateKeyPolicy
+	// MaxElements is the maximum number of elements of a slice or entries of a map.
+	MaxElements int
+	// MaxBytes is the maximum number of bytes of a marshalled slice or map, its length
+	// prefix and terminator included.
+	MaxBytes int
+	// Depth, if set, counts the slices, maps and pointers unmarshalled with it inside of
+	// each other, see Depth.
+	Depth *Depth
+}
+
+// DuplicateKeyPolicy decides about a map entry, whose key came before in the same map. Maps
+// marshalled by benc never contain a key twice, but crafted data may, e.g. to pass a value
+// past a validator that only sees the entry winning in its own decoder.
+type DuplicateKeyPolicy uint8
+
+const (
+	// DuplicatesLastWins keeps the value of the last entry of the key, as assigning the entries
+	// in order does.
+	DuplicatesLastWins DuplicateKeyPolicy = iota
+	// DuplicatesFirstWins keeps the value of the first entry of the key.
+	DuplicatesFirstWins
+	// DuplicatesError fails with ErrDuplicateKey.
+	DuplicatesError
+)
+
+// DefaultMaxDepth is the maximum nesting of a Depth without a maximum of its own.
+const DefaultMaxDepth = 256
+
+// Depth bounds the nesting of slices, maps and pointers, so recursive types can't exhaust
+// the stack on malicious input. The unmarshalers of recursive types pass the same Depth
+// along in their Limits, e.g.
+//
+//	limits := bstd.Limits{Depth: &bstd.Depth{Max: 64}}
+//	var unmarshalNode func(n int, b []byte, v *Node) (int, error)
+//	unmarshalNode = func(n int, b []byte, v *Node) (int, error) {
+//		var err error
+//		n, v.Children, err = bstd.UnmarshalSliceLimited[Node](n, b, unmarshalNode, limits)
+//		return n, err
+//	}
+//
+// A Depth counts one message at a time, it must not be shared between goroutines.
+type Depth struct {
+	// Max is the maximum number of containers inside of each other, DefaultMaxDepth if zero.
+	Max     int
+	current int
+}
+
+// enter enters a container, failing with ErrMaxDepth beyond the maximum.
+func (d *Depth) enter() error {
+	if d == nil {
+		return nil
+	}
+	max := d.Max
+	if max <= 0 {
+		max = DefaultMaxDepth
+	}
+	if d.current >= max {
+		return ErrMaxDepth
+	}
+	d.current++
+	return nil
+}
+
+// leave leaves the container entered last.
+func (d *Depth) leave() {
+	if d != nil {
+		d.current--
+	}
+}
+
+// checkCount reports whether 'count' elements are within the limits.
+func (l Limits) checkCount(count uint) error {
+	if l.MaxElements > 0 && count > uint(l.MaxElements) {
+		return ErrLimitExceeded
+	}
+	return nil
+}
+
+// checkBytes reports whether the 'size' bytes read so far are within the limits.
+func (l Limits) checkBytes(size int) error {
+	if l.MaxBytes > 0 && size > l.MaxBytes {
+		return ErrLimitExceeded
+	}
+	return nil
+}
+
 // Returns the new offset 'n', as well as the slice, that got unmarshalled.
+// The elements are allocated as they are unmarshalled, at most as many up front as
+// bytes are left in 'b'. See UnmarshalSliceLimited to bound the element count.
 //
 // Possible errors returned:
 //   - ErrOverflow          - varint overflowed a N-bit unsigned integer.
@@ -216,39 +360,123 @@ func MarshalSlice[T any](n int, b []byte, slice []T, marshaler MarshalFunc[T]) i
 //
 // If a error is returned, n (the int returned) equals zero ( 0 ).
 func UnmarshalSlice[T any](n int, b []byte, unmarshaler interface{}) (int, []T, error) {
+	return UnmarshalSliceLimited[T](n, b, unmarshaler, Limits{})
+}
+
+// Returns the new offset 'n', as well as the slice, that got unmarshalled.
+// Unlike UnmarshalSlice, it fails once the slice exceeds the limits.
+//
+// Possible errors returned:
+//   - ErrOverflow          - varint overflowed a N-bit unsigned integer.
+//   - ErrBufTooSmall       - 'buf' was too small to unmarshal the slice.
+//   - ErrLimitExceeded     - the slice has more elements or bytes than 'limits' allow.
+//   - ErrMaxDepth          - the slice is nested deeper than the Depth of 'limits' allows.
+//
+// If a error is returned, n (the int returned) equals zero ( 0 ).
+func UnmarshalSliceLimited[T any](n int, b []byte, unmarshaler interface{}, limits Limits) (int, []T, error) {
+	return unmarshalSliceInto[T](n, b, nil, unmarshaler, limits)
+}
+
+// Returns the new offset 'n', as well as the slice, that got unmarshalled into 'dst'.
+// Unlike UnmarshalSlice, it reuses the backing array of 'dst' and only allocates a new one,
+// if the slice has more elements than 'dst' has capacity, e.g. to decode the messages of a
+// hot loop without allocating. The elements of 'dst' are overwritten.
+//
+// Possible errors returned:
+//   - ErrOverflow          - varint overflowed a N-bit unsigned integer.
+//   - ErrBufTooSmall       - 'buf' was too small to unmarshal the slice.
+//
+// If a error is returned, n (the int returned) equals zero ( 0 ).
+func UnmarshalSliceInPlace[T any](n int, b []byte, dst []T, unmarshaler interface{}) (int, []T, error) {
+	if dst == nil {
+		dst = []T{}
+	}
+	return unmarshalSliceInto(n, b, dst, unmarshaler, Limits{})
+}
+
+// unmarshalSliceInto unmarshals a slice into the backing array of 'ts', or a new one if 'ts'
+// is nil or too small.
+func unmarshalSliceInto[T any](n int, b []byte, ts []T, unmarshaler interface{}, limits Limits) (int, []T, error) {
+	start := n
 	n, us, err := UnmarshalUint(n, b)
 	if err != nil {
 		return 0, nil, err
 	}
-	s := int(us)
+	if err = limits.checkCount(us); err != nil {
+		return 0, nil, err
+	}
+	if err = limits.Depth.enter(); err != nil {
+		return 0, nil, err
+	}
+	defer limits.Depth.leave()
 
 	var t T
-	ts := make([]T, s)
+	if ts == nil {
+		ts = make([]T, 0, min(us, uint(len(b)-n)))
+	} else {
+		ts = slices.Grow(ts[:0], int(min(us, uint(len(b)-n))))
+	}
 
+	if unmarshaler == nil {
+		unmarshaler = unmarshalBenc[T]
+	}
 	switch p := unmarshaler.(type) {
 	case func(n int, b []byte) (int, T, error):
-		for i := 0; i < s; i++ {
+		for range us {
 			n, t, err = p(n, b)
 			if err != nil {
 				return 0, nil, err
 			}
+			if err = limits.checkBytes(n - start); err != nil {
+				return 0, nil, err
+			}
 
-			ts[i] = t
+			ts = append(ts, t)
 		}
 	case func(n int, b []byte, v *T) (int, error):
-		for i := 0; i < s; i++ {
-			n, err = p(n, b, &ts[i])
+		for range us {
+			ts = append(ts, t)
+			n, err = p(n, b, &ts[len(ts)-1])
 			if err != nil {
 				return 0, nil, err
 			}
+			if err = limits.checkBytes(n - start); err != nil {
+				return 0, nil, err
+			}
 		}
 	default:
 		panic("benc: invalid `unmarshaler` provided in `UnmarshalSlice`")
 	}
 
+	if len(b)-n < 4 {
+		return 0, nil, ErrBufTooSmall
+	}
+	if err = limits.checkBytes(n + 4 - start); err != nil {
+		return 0, nil, err
+	}
 	return n + 4, ts, nil
 }
 
+// Fixed size arrays are marshalled without a length prefix and terminator,
+// because the length is part of their type.
+
+// Returns the new offset 'n' after skipping the marshalled array of 'count' elements.
+//
+// Possible errors returned:
+//   - ErrBufTooSmall       - 'buf' was too small to skip the marshalled array.
+//   - any error returned by 'skipElement'.
+//
+// If a error is returned, n (the int returned) equals zero ( 0 ).
+func SkipArray(n int, b []byte, count int, skipElement func(n int, b []byte) (int, error)) (int, error) {
+	var err error
+	for range count {
+		if n, err = skipElement(n, b); err != nil {
+			return 0, err
+		}
+	}
+	return n, nil
+}
+
 // Returns the bytes needed to marshal a fixed size array (passed as a slice).
 func SizeArray[T any](s []T, sizer SizeFunc[T]) (sz int) {
 	for _, t := range s {
@@ -289,20 +517,35 @@ func UnmarshalArray[T any](n int, b []byte, s []T, unmarshaler interface{}) (int
 	return n, nil
 }
 
+// Returns the new offset 'n' after skipping the marshalled byte array of 'count' bytes.
+//
+// Possible errors returned:
+//   - ErrBufTooSmall       - 'buf' was too small to skip the marshalled byte array.
+//
+// If a error is returned, n (the int returned) equals zero ( 0 ).
+func SkipByteArray(n int, b []byte, count int) (int, error) {
+	if len(b)-n < count {
+		return 0, ErrBufTooSmall
+	}
+	return n + count, nil
+}
+
 // Returns the bytes needed to marshal a fixed size byte array.
 func SizeByteArray(n int) int {
 	return n
 }
 
 // Returns the new offset 'n' after marshalling the fixed size byte array.
+//
+// !- Panics, if 'b' is too small.
 func MarshalByteArray(n int, b []byte, src []byte) int {
-	return n + copy(b[n:], src)
+	return n + copy(b[n:n+len(src)], src)
 }
 
 // Unmarshals a fixed size byte array into the provided slice 'dst'.
 func UnmarshalByteArray(n int, b []byte, dst []byte) (int, error) {
 	if len(b)-n < len(dst) {
-		return n, ErrBufTooSmall
+		return 0, ErrBufTooSmall
 	}
 	copy(dst, b[n:])
 	return n + len(dst), nil
@@ -344,6 +587,7 @@ func SkipMap(n int, b []byte, skipKey func(n int, b []byte) (int, error), skipVa
 }
 
 // Returns the bytes needed to marshal a map.
+// A nil 'kSizer' or 'vSizer' sizes the keys or values by their BencMarshaler.
 func SizeMap[K comparable, V any](m map[K]V, kSizer interface{}, vSizer interface{}) (s int) {
 	s += 4 + SizeUint(uint(len(m)))
 
@@ -353,6 +597,8 @@ func SizeMap[K comparable, V any](m map[K]V, kSizer interface{}, vSizer interfac
 			s += p()
 		case func(K) int:
 			s += p(k)
+		case nil:
+			s += sizeBenc(k)
 		default:
 			panic("benc: invalid `kSizer` provided in `SizeMap`")
 		}
@@ -362,6 +608,8 @@ func SizeMap[K comparable, V any](m map[K]V, kSizer interface{}, vSizer interfac
 			s += p()
 		case func(V) int:
 			s += p(v)
+		case nil:
+			s += sizeBenc(v)
 		default:
 			panic("benc: invalid `vSizer` provided in `SizeMap`")
 		}
@@ -370,9 +618,11 @@ func SizeMap[K comparable, V any](m map[K]V, kSizer interface{}, vSizer interfac
 }
 
 // Returns the new offset 'n' after marshalling the map.
+// A nil 'kMarshaler' or 'vMarshaler' marshals the keys or values by their BencMarshaler.
 //
 // !- Panics, if 'b' is too small.
 func MarshalMap[K comparable, V any](n int, b []byte, m map[K]V, kMarshaler MarshalFunc[K], vMarshaler MarshalFunc[V]) int {
+	kMarshaler, vMarshaler = mapMarshalers(kMarshaler, vMarshaler)
 	n = MarshalUint(n, b, uint(len(m)))
 	for k, v := range m {
 		n = kMarshaler(n, b, k)
@@ -389,6 +639,9 @@ func MarshalMap[K comparable, V any](n int, b []byte, m map[K]V, kMarshaler Mars
 }
 
 // Returns the new offset 'n', as well as the map, that got unmarshalled.
+// At most as many entries are allocated up front as bytes are left in 'b'. See
+// UnmarshalMapLimited to bound the entry count. Of a key occurring twice, the last
+// entry wins, see DuplicateKeyPolicy.
 //
 // Possible errors returned:
 //   - ErrOverflow          - varint overflowed a N-bit unsigned integer.
@@ -396,17 +649,69 @@ func MarshalMap[K comparable, V any](n int, b []byte, m map[K]V, kMarshaler Mars
 //
 // If a error is returned, n (the int returned) equals zero ( 0 ).
 func UnmarshalMap[K comparable, V any](n int, b []byte, kUnmarshaler interface{}, vUnmarshaler interface{}) (int, map[K]V, error) {
+	return UnmarshalMapLimited[K, V](n, b, kUnmarshaler, vUnmarshaler, Limits{})
+}
+
+// Returns the new offset 'n', as well as the map, that got unmarshalled.
+// Unlike UnmarshalMap, it fails once the map exceeds the limits.
+//
+// Possible errors returned:
+//   - ErrOverflow          - varint overflowed a N-bit unsigned integer.
+//   - ErrBufTooSmall       - 'buf' was too small to unmarshal the map.
+//   - ErrLimitExceeded     - the map has more entries or bytes than 'limits' allow.
+//   - ErrMaxDepth          - the map is nested deeper than the Depth of 'limits' allows.
+//   - ErrDuplicateKey      - a key occurs twice and the Duplicates of 'limits' is DuplicatesError.
+//
+// If a error is returned, n (the int returned) equals zero ( 0 ).
+func UnmarshalMapLimited[K comparable, V any](n int, b []byte, kUnmarshaler interface{}, vUnmarshaler interface{}, limits Limits) (int, map[K]V, error) {
+	return unmarshalMapInto[K, V](n, b, nil, kUnmarshaler, vUnmarshaler, limits)
+}
+
+// Returns the new offset 'n', as well as the map, that got unmarshalled into 'dst'.
+// Unlike UnmarshalMap, it clears and reuses 'dst' instead of allocating a new map, e.g. to
+// decode into recycled messages without allocating. If 'dst' is nil, a new map is returned.
+// On an error 'dst' holds the entries unmarshalled before it.
+//
+// Possible errors returned:
+//   - ErrOverflow          - varint overflowed a N-bit unsigned integer.
+//   - ErrBufTooSmall       - 'buf' was too small to unmarshal the map.
+//
+// If a error is returned, n (the int returned) equals zero ( 0 ).
+func UnmarshalMapInto[K comparable, V any](n int, b []byte, dst map[K]V, kUnmarshaler interface{}, vUnmarshaler interface{}) (int, map[K]V, error) {
+	return unmarshalMapInto(n, b, dst, kUnmarshaler, vUnmarshaler, Limits{})
+}
+
+// unmarshalMapInto unmarshals a map into 'ts' after clearing it, or into a new one if 'ts'
+// is nil.
+func unmarshalMapInto[K comparable, V any](n int, b []byte, ts map[K]V, kUnmarshaler interface{}, vUnmarshaler interface{}, limits Limits) (int, map[K]V, error) {
+	start := n
 	n, us, err := UnmarshalUint(n, b)
 	if err != nil {
 		return 0, nil, err
 	}
-	s := int(us)
+	if err = limits.checkCount(us); err != nil {
+		return 0, nil, err
+	}
+	if err = limits.Depth.enter(); err != nil {
+		return 0, nil, err
+	}
+	defer limits.Depth.leave()
 
 	var k K
 	var v V
-	ts := make(map[K]V, s)
+	if ts == nil {
+		ts = make(map[K]V, min(us, uint(len(b)-n)))
+	} else {
+		clear(ts)
+	}
+	if kUnmarshaler == nil {
+		kUnmarshaler = unmarshalBenc[K]
+	}
+	if vUnmarshaler == nil {
+		vUnmarshaler = unmarshalBenc[V]
+	}
 
-	for range s {
+	for range us {
 		switch p := kUnmarshaler.(type) {
 		case func(n int, b []byte) (int, K, error):
 			n, k, err = p(n, b)
@@ -414,10 +719,13 @@ func UnmarshalMap[K comparable, V any](n int, b []byte, kUnmarshaler interface{}
 				return 0, nil, err
 			}
 		case func(n int, b []byte, k *K) (int, error):
-			n, err = p(n, b, &k)
+			// declared here, as the pointer lets it escape to the heap
+			var pk K
+			n, err = p(n, b, &pk)
 			if err != nil {
 				return 0, nil, err
 			}
+			k = pk
 		default:
 			panic("benc: invalid `kUnmarshaler` provided in `UnmarshalMap`")
 		}
@@ -429,21 +737,79 @@ func UnmarshalMap[K comparable, V any](n int, b []byte, kUnmarshaler interface{}
 				return 0, nil, err
 			}
 		case func(n int, b []byte, v *V) (int, error):
-			n, err = p(n, b, &v)
+			var pv V
+			n, err = p(n, b, &pv)
 			if err != nil {
 				return 0, nil, err
 			}
+			v = pv
 		default:
 			// FIX: Corrected copy-paste error in panic message.
 			panic("benc: invalid `vUnmarshaler` provided in `UnmarshalMap`")
 		}
 
+		if err = limits.checkBytes(n - start); err != nil {
+			return 0, nil, err
+		}
+
+		if limits.Duplicates != DuplicatesLastWins {
+			if _, dup := ts[k]; dup {
+				if limits.Duplicates == DuplicatesError {
+					return 0, nil, ErrDuplicateKey
+				}
+				continue
+			}
+		}
 		ts[k] = v
 	}
 
+	if len(b)-n < 4 {
+		return 0, nil, ErrBufTooSmall
+	}
+	if err = limits.checkBytes(n + 4 - start); err != nil {
+		return 0, nil, err
+	}
 	return n + 4, ts, nil
 }
 
+// Message is implemented by pointers to generated structs, allowing them to
+// be used as keys or values of the slice and map functions, e.g.
+//
+//	bstd.SizeMap(tiles, bstd.SizeMessage[Point], bstd.SizeMessage[Tile])
+type Message[T any] interface {
+	*T
+	Size() int
+	Marshal(n int, b []byte) int
+	Unmarshal(n int, b []byte) (int, error)
+}
+
+// Returns the bytes needed to marshal the message 'v'.
+func SizeMessage[T any, PT Message[T]](v T) int {
+	return PT(&v).Size()
+}
+
+// Returns the new offset 'n' after marshalling the message 'v'.
+//
+// !- Panics, if 'b' is too small.
+func MarshalMessage[T any, PT Message[T]](n int, b []byte, v T) int {
+	return PT(&v).Marshal(n, b)
+}
+
+// Returns the new offset 'n' and the unmarshalled message.
+//
+// Possible errors returned:
+//   - any error returned by the Unmarshal method of the message.
+//
+// If a error is returned, n (the int returned) equals zero ( 0 ).
+func UnmarshalMessage[T any, PT Message[T]](n int, b []byte) (int, T, error) {
+	var v T
+	n, err := PT(&v).Unmarshal(n, b)
+	if err != nil {
+		return 0, v, err
+	}
+	return n, v, nil
+}
+
 // Returns the new offset 'n' after skipping the marshalled byte.
 //
 // Possible errors returned:
@@ -451,10 +817,7 @@ func UnmarshalMap[K comparable, V any](n int, b []byte, kUnmarshaler interface{}
 //
 // If a error is returned, n (the int returned) equals zero ( 0 ).
 func SkipByte(n int, b []byte) (int, error) {
-	if len(b)-n < 1 {
-		return n, ErrBufTooSmall
-	}
-	return n + 1, nil
+	return wire.Skip(n, b, 1)
 }
 
 // Returns the bytes needed to marshal a byte.
@@ -466,8 +829,7 @@ func SizeByte() int {
 //
 // !- Panics, if 'b' is too small.
 func MarshalByte(n int, b []byte, byt byte) int {
-	b[n] = byt
-	return n + 1
+	return wire.MarshalByte(n, b, byt)
 }
 
 // Returns the new offset 'n', as well as the byte, that got unmarshalled.
@@ -477,10 +839,7 @@ func MarshalByte(n int, b []byte, byt byte) int {
 //
 // If a error is returned, n (the int returned) equals zero ( 0 ).
 func UnmarshalByte(n int, b []byte) (int, byte, error) {
-	if len(b)-n < 1 {
-		return n, 0, ErrBufTooSmall
-	}
-	return n + 1, b[n], nil
+	return wire.UnmarshalByte(n, b)
 }
 
 // Returns the new offset 'n' after skipping the marshalled byte slice.
@@ -491,29 +850,19 @@ func UnmarshalByte(n int, b []byte) (int, byte, error) {
 //
 // If a error is returned, n (the int returned) equals zero ( 0 ).
 func SkipBytes(n int, b []byte) (int, error) {
-	n, us, err := UnmarshalUint(n, b)
-	if err != nil {
-		return 0, err
-	}
-	s := int(us)
-	if len(b)-n < s {
-		return n, ErrBufTooSmall
-	}
-	return n + s, nil
+	return wire.SkipBytes(n, b)
 }
 
 // Returns the bytes needed to marshal a byte slice.
 func SizeBytes(bs []byte) int {
-	v := len(bs)
-	return v + SizeUint(uint(v))
+	return wire.SizeBytes(bs)
 }
 
 // Returns the new offset 'n' after marshalling the byte slice.
 //
 // !- Panics, if 'b' is too small.
 func MarshalBytes(n int, b []byte, bs []byte) int {
-	n = MarshalUint(n, b, uint(len(bs)))
-	return n + copy(b[n:], bs)
+	return wire.MarshalBytes(n, b, bs)
 }
 
 // UnmarshalBytesCopied returns a new allocated slice with a copy of the marshalled byte slice inside `b`.
@@ -524,17 +873,13 @@ func MarshalBytes(n int, b []byte, bs []byte) int {
 //
 // It's slower than UnmarshalBytesCropped, but modifications to `b` won't affect the returned byte slice.
 func UnmarshalBytesCopied(n int, b []byte) (int, []byte, error) {
-	n, us, err := UnmarshalUint(n, b)
+	n, bs, err := wire.UnmarshalBytes(n, b)
 	if err != nil {
 		return 0, nil, err
 	}
-	s := int(us)
-	if len(b)-n < s {
-		return 0, nil, ErrBufTooSmall
-	}
-	cb := make([]byte, s)
-	copy(cb, b[n:n+s])
-	return n + s, cb, nil
+	cb := make([]byte, len(bs))
+	copy(cb, bs)
+	return n, cb, nil
 }
 
 // UnmarshalBytesCropped returns a cropped slice of the marshalled byte slice inside `b`.
@@ -545,24 +890,9 @@ func UnmarshalBytesCopied(n int, b []byte) (int, []byte, error) {
 //
 // It's faster than UnmarshalBytesCopied, but modifications to `b` will affect the returned byte slice.
 func UnmarshalBytesCropped(n int, b []byte) (int, []byte, error) {
-	n, us, err := UnmarshalUint(n, b)
-	if err != nil {
-		return 0, nil, err
-	}
-	s := int(us)
-	if len(b)-n < s {
-		return 0, nil, ErrBufTooSmall
-	}
-	return n + s, b[n : n+s], nil
-}
-
-var maxVarintLenMap = map[int]int{
-	64: binary.MaxVarintLen64,
-	32: binary.MaxVarintLen32,
+	return wire.UnmarshalBytes(n, b)
 }
 
-var maxVarintLen = maxVarintLenMap[strconv.IntSize]
-
 // Returns the new offset 'n' after skipping the marshalled varint.
 //
 // Possible errors returned:
@@ -571,44 +901,19 @@ var maxVarintLen = maxVarintLenMap[strconv.IntSize]
 //
 // If a error is returned, n (the int returned) equals zero ( 0 ).
 func SkipVarint(n int, buf []byte) (int, error) {
-	for i, b := range buf[n:] {
-		if i == maxVarintLen {
-			return 0, ErrOverflow
-		}
-		if b < 0x80 {
-			if i == maxVarintLen-1 && b > 1 {
-				return 0, ErrOverflow
-			}
-			return n + i + 1, nil
-		}
-	}
-	return 0, ErrBufTooSmall
+	return wire.SkipUvarint(n, buf, bits.UintSize)
 }
 
 // Returns the bytes needed to marshal a integer.
 func SizeInt(sv int) int {
-	v := uint(encodeZigZag(sv))
-	i := 0
-	for v >= 0x80 {
-		v >>= 7
-		i++
-	}
-	return i + 1
+	return wire.SizeUvarint(uint64(EncodeZigZag(sv)))
 }
 
 // Returns the new offset 'n' after marshalling the integer.
 //
 // !- Panics, if 'b' is too small.
 func MarshalInt(n int, b []byte, sv int) int {
-	v := uint(encodeZigZag(sv))
-	i := n
-	for v >= 0x80 {
-		b[i] = byte(v) | 0x80
-		v >>= 7
-		i++
-	}
-	b[i] = byte(v)
-	return i + 1
+	return wire.MarshalUvarint(n, b, uint64(EncodeZigZag(sv)))
 }
 
 // Returns the new offset 'n', as well as the integer, that got unmarshalled.
@@ -619,22 +924,11 @@ func MarshalInt(n int, b []byte, sv int) int {
 //
 // If a error is returned, n (the int returned) equals zero ( 0 ).
 func UnmarshalInt(n int, buf []byte) (int, int, error) {
-	var x uint
-	var s uint
-	for i, b := range buf[n:] {
-		if i == maxVarintLen {
-			return 0, 0, ErrOverflow
-		}
-		if b < 0x80 {
-			if i == maxVarintLen-1 && b > 1 {
-				return 0, 0, ErrOverflow
-			}
-			return n + i + 1, int(decodeZigZag(x | uint(b)<<s)), nil
-		}
-		x |= uint(b&0x7f) << s
-		s += 7
+	n, v, err := wire.UnmarshalUvarint(n, buf, bits.UintSize)
+	if err != nil {
+		return 0, 0, err
 	}
-	return 0, 0, ErrBufTooSmall
+	return n, DecodeZigZag(uint(v)), nil
 }
 
 // Returns the new offset 'n' after skipping the marshalled unsigned integer.
@@ -644,26 +938,14 @@ func SkipUint(n int, b []byte) (int, error) {
 
 // Returns the bytes needed to marshal a unsigned integer.
 func SizeUint(v uint) int {
-	i := 0
-	for v >= 0x80 {
-		v >>= 7
-		i++
-	}
-	return i + 1
+	return wire.SizeUvarint(uint64(v))
 }
 
 // Returns the new offset 'n' after marshalling the unsigned integer.
 //
 // !- Panics, if 'b' is too small.
 func MarshalUint(n int, b []byte, v uint) int {
-	i := n
-	for v >= 0x80 {
-		b[i] = byte(v) | 0x80
-		v >>= 7
-		i++
-	}
-	b[i] = byte(v)
-	return i + 1
+	return wire.MarshalUvarint(n, b, uint64(v))
 }
 
 // Returns the new offset 'n', as well as the unsigned integer, that got unmarshalled.
@@ -674,22 +956,8 @@ func MarshalUint(n int, b []byte, v uint) int {
 //
 // If a error is returned, n (the int returned) equals zero ( 0 ).
 func UnmarshalUint(n int, buf []byte) (int, uint, error) {
-	var x uint
-	var s uint
-	for i, b := range buf[n:] {
-		if i == maxVarintLen {
-			return 0, 0, ErrOverflow
-		}
-		if b < 0x80 {
-			if i == maxVarintLen-1 && b > 1 {
-				return 0, 0, ErrOverflow
-			}
-			return n + i + 1, x | uint(b)<<s, nil
-		}
-		x |= uint(b&0x7f) << s
-		s += 7
-	}
-	return 0, 0, ErrBufTooSmall
+	n, v, err := wire.UnmarshalUvarint(n, buf, bits.UintSize)
+	return n, uint(v), err
 }
 
 // Returns the new offset 'n' after skipping the marshalled uintptr.
@@ -720,10 +988,7 @@ func UnmarshalUintptr(n int, b []byte) (int, uintptr, error) {
 //
 // If a error is returned, n (the int returned) equals zero ( 0 ).
 func SkipUint64(n int, b []byte) (int, error) {
-	if len(b)-n < 8 {
-		return n, ErrBufTooSmall
-	}
-	return n + 8, nil
+	return wire.Skip(n, b, 8)
 }
 
 // Returns the bytes needed to marshal a 64-bit unsigned integer.
@@ -733,17 +998,7 @@ func SizeUint64() int {
 
 // Returns the new offset 'n' after marshalling the 64-bit unsigned integer.
 func MarshalUint64(n int, b []byte, v uint64) int {
-	u := b[n : n+8]
-	_ = u[7]
-	u[0] = byte(v)
-	u[1] = byte(v >> 8)
-	u[2] = byte(v >> 16)
-	u[3] = byte(v >> 24)
-	u[4] = byte(v >> 32)
-	u[5] = byte(v >> 40)
-	u[6] = byte(v >> 48)
-	u[7] = byte(v >> 56)
-	return n + 8
+	return wire.MarshalUint64(n, b, v)
 }
 
 // Returns the new offset 'n', as well as the 64-bit unsigned integer, that got unmarshalled.
@@ -753,14 +1008,7 @@ func MarshalUint64(n int, b []byte, v uint64) int {
 //
 // If a error is returned, n (the int returned) equals zero ( 0 ).
 func UnmarshalUint64(n int, b []byte) (int, uint64, error) {
-	if len(b)-n < 8 {
-		return n, 0, ErrBufTooSmall
-	}
-	u := b[n : n+8]
-	_ = u[7]
-	v := uint64(u[0]) | uint64(u[1])<<8 | uint64(u[2])<<16 | uint64(u[3])<<24 |
-		uint64(u[4])<<32 | uint64(u[5])<<40 | uint64(u[6])<<48 | uint64(u[7])<<56
-	return n + 8, v, nil
+	return wire.UnmarshalUint64(n, b)
 }
 
 // Returns the new offset 'n' after skipping the marshalled 32-bit unsigned integer.
@@ -770,10 +1018,7 @@ func UnmarshalUint64(n int, b []byte) (int, uint64, error) {
 //
 // If a error is returned, n (the int returned) equals zero ( 0 ).
 func SkipUint32(n int, b []byte) (int, error) {
-	if len(b)-n < 4 {
-		return n, ErrBufTooSmall
-	}
-	return n + 4, nil
+	return wire.Skip(n, b, 4)
 }
 
 // Returns the bytes needed to marshal a 32-bit unsigned integer.
@@ -783,13 +1028,7 @@ func SizeUint32() int {
 
 // Returns the new offset 'n' after marshalling the 32-bit unsigned integer.
 func MarshalUint32(n int, b []byte, v uint32) int {
-	u := b[n : n+4]
-	_ = u[3]
-	u[0] = byte(v)
-	u[1] = byte(v >> 8)
-	u[2] = byte(v >> 16)
-	u[3] = byte(v >> 24)
-	return n + 4
+	return wire.MarshalUint32(n, b, v)
 }
 
 // Returns the new offset 'n', as well as the 32-bit unsigned integer, that got unmarshalled.
@@ -799,13 +1038,7 @@ func MarshalUint32(n int, b []byte, v uint32) int {
 //
 // If a error is returned, n (the int returned) equals zero ( 0 ).
 func UnmarshalUint32(n int, b []byte) (int, uint32, error) {
-	if len(b)-n < 4 {
-		return n, 0, ErrBufTooSmall
-	}
-	u := b[n : n+4]
-	_ = u[3]
-	v := uint32(u[0]) | uint32(u[1])<<8 | uint32(u[2])<<16 | uint32(u[3])<<24
-	return n + 4, v, nil
+	return wire.UnmarshalUint32(n, b)
 }
 
 // Returns the new offset 'n' after skipping the marshalled 16-bit unsigned integer.
@@ -815,10 +1048,7 @@ func UnmarshalUint32(n int, b []byte) (int, uint32, error) {
 //
 // If a error is returned, n (the int returned) equals zero ( 0 ).
 func SkipUint16(n int, b []byte) (int, error) {
-	if len(b)-n < 2 {
-		return n, ErrBufTooSmall
-	}
-	return n + 2, nil
+	return wire.Skip(n, b, 2)
 }
 
 // Returns the bytes needed to marshal a 16-bit unsigned integer.
@@ -828,11 +1058,7 @@ func SizeUint16() int {
 
 // Returns the new offset 'n' after marshalling the 16-bit unsigned integer.
 func MarshalUint16(n int, b []byte, v uint16) int {
-	u := b[n : n+2]
-	_ = u[1]
-	u[0] = byte(v)
-	u[1] = byte(v >> 8)
-	return n + 2
+	return wire.MarshalUint16(n, b, v)
 }
 
 // Returns the new offset 'n', as well as the 16-bit unsigned integer, that got unmarshalled.
@@ -842,13 +1068,7 @@ func MarshalUint16(n int, b []byte, v uint16) int {
 //
 // If a error is returned, n (the int returned) equals zero ( 0 ).
 func UnmarshalUint16(n int, b []byte) (int, uint16, error) {
-	if len(b)-n < 2 {
-		return n, 0, ErrBufTooSmall
-	}
-	u := b[n : n+2]
-	_ = u[1]
-	v := uint16(u[0]) | uint16(u[1])<<8
-	return n + 2, v, nil
+	return wire.UnmarshalUint16(n, b)
 }
 
 // Returns the new offset 'n' after skipping the marshalled 64-bit integer.
@@ -858,10 +1078,7 @@ func UnmarshalUint16(n int, b []byte) (int, uint16, error) {
 //
 // If a error is returned, n (the int returned) equals zero ( 0 ).
 func SkipInt64(n int, b []byte) (int, error) {
-	if len(b)-n < 8 {
-		return n, ErrBufTooSmall
-	}
-	return n + 8, nil
+	return wire.Skip(n, b, 8)
 }
 
 // Returns the bytes needed to marshal a 64-bit integer.
@@ -871,17 +1088,7 @@ func SizeInt64() int {
 
 // Returns the new offset 'n' after marshalling the 64-bit integer.
 func MarshalInt64(n int, b []byte, v int64) int {
-	u := b[n : n+8]
-	_ = u[7]
-	u[0] = byte(v)
-	u[1] = byte(v >> 8)
-	u[2] = byte(v >> 16)
-	u[3] = byte(v >> 24)
-	u[4] = byte(v >> 32)
-	u[5] = byte(v >> 40)
-	u[6] = byte(v >> 48)
-	u[7] = byte(v >> 56)
-	return n + 8
+	return wire.MarshalUint64(n, b, uint64(v))
 }
 
 // Returns the new offset 'n', as well as the 64-bit integer, that got unmarshalled.
@@ -891,14 +1098,8 @@ func MarshalInt64(n int, b []byte, v int64) int {
 //
 // If a error is returned, n (the int returned) equals zero ( 0 ).
 func UnmarshalInt64(n int, b []byte) (int, int64, error) {
-	if len(b)-n < 8 {
-		return n, 0, ErrBufTooSmall
-	}
-	u := b[n : n+8]
-	_ = u[7]
-	v := int64(u[0]) | int64(u[1])<<8 | int64(u[2])<<16 | int64(u[3])<<24 |
-		int64(u[4])<<32 | int64(u[5])<<40 | int64(u[6])<<48 | int64(u[7])<<56
-	return n + 8, v, nil
+	n, v, err := wire.UnmarshalUint64(n, b)
+	return n, int64(v), err
 }
 
 // Returns the new offset 'n' after skipping the marshalled 32-bit integer.
@@ -908,10 +1109,7 @@ func UnmarshalInt64(n int, b []byte) (int, int64, error) {
 //
 // If a error is returned, n (the int returned) equals zero ( 0 ).
 func SkipInt32(n int, b []byte) (int, error) {
-	if len(b)-n < 4 {
-		return n, ErrBufTooSmall
-	}
-	return n + 4, nil
+	return wire.Skip(n, b, 4)
 }
 
 // Returns the bytes needed to marshal a 32-bit integer.
@@ -921,13 +1119,7 @@ func SizeInt32() int {
 
 // Returns the new offset 'n' after marshalling the 32-bit integer.
 func MarshalInt32(n int, b []byte, v int32) int {
-	u := b[n : n+4]
-	_ = u[3]
-	u[0] = byte(v)
-	u[1] = byte(v >> 8)
-	u[2] = byte(v >> 16)
-	u[3] = byte(v >> 24)
-	return n + 4
+	return wire.MarshalUint32(n, b, uint32(v))
 }
 
 // Returns the new offset 'n', as well as the 32-bit integer, that got unmarshalled.
@@ -937,13 +1129,8 @@ func MarshalInt32(n int, b []byte, v int32) int {
 //
 // If a error is returned, n (the int returned) equals zero ( 0 ).
 func UnmarshalInt32(n int, b []byte) (int, int32, error) {
-	if len(b)-n < 4 {
-		return n, 0, ErrBufTooSmall
-	}
-	u := b[n : n+4]
-	_ = u[3]
-	v := int32(u[0]) | int32(u[1])<<8 | int32(u[2])<<16 | int32(u[3])<<24
-	return n + 4, v, nil
+	n, v, err := wire.UnmarshalUint32(n, b)
+	return n, int32(v), err
 }
 
 // Returns the new offset 'n' after skipping the marshalled 16-bit integer.
@@ -953,10 +1140,7 @@ func UnmarshalInt32(n int, b []byte) (int, int32, error) {
 //
 // If a error is returned, n (the int returned) equals zero ( 0 ).
 func SkipInt16(n int, b []byte) (int, error) {
-	if len(b)-n < 2 {
-		return n, ErrBufTooSmall
-	}
-	return n + 2, nil
+	return wire.Skip(n, b, 2)
 }
 
 // Returns the bytes needed to marshal a 16-bit integer.
@@ -966,11 +1150,7 @@ func SizeInt16() int {
 
 // Returns the new offset 'n' after marshalling the 16-bit integer.
 func MarshalInt16(n int, b []byte, v int16) int {
-	u := b[n : n+2]
-	_ = u[1]
-	u[0] = byte(v)
-	u[1] = byte(v >> 8)
-	return n + 2
+	return wire.MarshalUint16(n, b, uint16(v))
 }
 
 // Returns the new offset 'n', as well as the 16-bit integer, that got unmarshalled.
@@ -980,13 +1160,8 @@ func MarshalInt16(n int, b []byte, v int16) int {
 //
 // If a error is returned, n (the int returned) equals zero ( 0 ).
 func UnmarshalInt16(n int, b []byte) (int, int16, error) {
-	if len(b)-n < 2 {
-		return n, 0, ErrBufTooSmall
-	}
-	u := b[n : n+2]
-	_ = u[1]
-	v := int16(u[0]) | int16(u[1])<<8
-	return n + 2, v, nil
+	n, v, err := wire.UnmarshalUint16(n, b)
+	return n, int16(v), err
 }
 
 // Returns the new offset 'n' after skipping the marshalled 8-bit integer.
@@ -1031,10 +1206,7 @@ func UnmarshalInt8(n int, b []byte) (int, int8, error) {
 //
 // If a error is returned, n (the int returned) equals zero ( 0 ).
 func SkipFloat64(n int, b []byte) (int, error) {
-	if len(b)-n < 8 {
-		return n, ErrBufTooSmall
-	}
-	return n + 8, nil
+	return wire.Skip(n, b, 8)
 }
 
 // Returns the bytes needed to marshal a 64-bit float.
@@ -1044,18 +1216,7 @@ func SizeFloat64() int {
 
 // Returns the new offset 'n' after marshalling the 64-bit float.
 func MarshalFloat64(n int, b []byte, v float64) int {
-	v64 := math.Float64bits(v)
-	u := b[n : n+8]
-	_ = u[7]
-	u[0] = byte(v64)
-	u[1] = byte(v64 >> 8)
-	u[2] = byte(v64 >> 16)
-	u[3] = byte(v64 >> 24)
-	u[4] = byte(v64 >> 32)
-	u[5] = byte(v64 >> 40)
-	u[6] = byte(v64 >> 48)
-	u[7] = byte(v64 >> 56)
-	return n + 8
+	return wire.MarshalFloat64(n, b, v)
 }
 
 // Returns the new offset 'n', as well as the 64-bit float, that got unmarshalled.
@@ -1065,14 +1226,7 @@ func MarshalFloat64(n int, b []byte, v float64) int {
 //
 // If a error is returned, n (the int returned) equals zero ( 0 ).
 func UnmarshalFloat64(n int, b []byte) (int, float64, error) {
-	if len(b)-n < 8 {
-		return n, 0, ErrBufTooSmall
-	}
-	u := b[n : n+8]
-	_ = u[7]
-	v := uint64(u[0]) | uint64(u[1])<<8 | uint64(u[2])<<16 | uint64(u[3])<<24 |
-		uint64(u[4])<<32 | uint64(u[5])<<40 | uint64(u[6])<<48 | uint64(u[7])<<56
-	return n + 8, math.Float64frombits(v), nil
+	return wire.UnmarshalFloat64(n, b)
 }
 
 // Returns the new offset 'n' after skipping the marshalled 32-bit float.
@@ -1082,10 +1236,7 @@ func UnmarshalFloat64(n int, b []byte) (int, float64, error) {
 //
 // If a error is returned, n (the int returned) equals zero ( 0 ).
 func SkipFloat32(n int, b []byte) (int, error) {
-	if len(b)-n < 4 {
-		return n, ErrBufTooSmall
-	}
-	return n + 4, nil
+	return wire.Skip(n, b, 4)
 }
 
 // Returns the bytes needed to marshal a 32-bit float.
@@ -1095,14 +1246,7 @@ func SizeFloat32() int {
 
 // Returns the new offset 'n' after marshalling the 32-bit float.
 func MarshalFloat32(n int, b []byte, v float32) int {
-	v32 := math.Float32bits(v)
-	u := b[n : n+4]
-	_ = u[3]
-	u[0] = byte(v32)
-	u[1] = byte(v32 >> 8)
-	u[2] = byte(v32 >> 16)
-	u[3] = byte(v32 >> 24)
-	return n + 4
+	return wire.MarshalFloat32(n, b, v)
 }
 
 // Returns the new offset 'n', as well as the 32-bit float, that got unmarshalled.
@@ -1112,13 +1256,7 @@ func MarshalFloat32(n int, b []byte, v float32) int {
 //
 // If a error is returned, n (the int returned) equals zero ( 0 ).
 func UnmarshalFloat32(n int, b []byte) (int, float32, error) {
-	if len(b)-n < 4 {
-		return n, 0, ErrBufTooSmall
-	}
-	u := b[n : n+4]
-	_ = u[3]
-	v := uint32(u[0]) | uint32(u[1])<<8 | uint32(u[2])<<16 | uint32(u[3])<<24
-	return n + 4, math.Float32frombits(v), nil
+	return wire.UnmarshalFloat32(n, b)
 }
 
 // Returns the new offset 'n' after skipping the marshalled bool.
@@ -1128,10 +1266,7 @@ func UnmarshalFloat32(n int, b []byte) (int, float32, error) {
 //
 // If a error is returned, n (the int returned) equals zero ( 0 ).
 func SkipBool(n int, b []byte) (int, error) {
-	if len(b)-n < 1 {
-		return 0, ErrBufTooSmall
-	}
-	return n + 1, nil
+	return wire.Skip(n, b, 1)
 }
 
 // Returns the bytes needed to marshal a bool.
@@ -1141,12 +1276,7 @@ func SizeBool() int {
 
 // Returns the new offset 'n' after marshalling the bool.
 func MarshalBool(n int, b []byte, v bool) int {
-	var i byte
-	if v {
-		i = 1
-	}
-	b[n] = i
-	return n + 1
+	return wire.MarshalBool(n, b, v)
 }
 
 // Returns the new offset 'n', as well as the bool, that got unmarshalled.
@@ -1156,27 +1286,16 @@ func MarshalBool(n int, b []byte, v bool) int {
 //
 // If a error is returned, n (the int returned) equals zero ( 0 ).
 func UnmarshalBool(n int, b []byte) (int, bool, error) {
-	if len(b)-n < 1 {
-		return 0, false, ErrBufTooSmall
-	}
-	return n + 1, uint8(b[n]) == 1, nil
-}
-
-func encodeZigZag[T constraints.Signed](t T) T {
-	if t < 0 {
-		return ^(t << 1)
-	}
-	return t << 1
-}
-
-func decodeZigZag[T constraints.Unsigned](t T) T {
-	if t&1 == 1 {
-		return ^(t >> 1)
-	}
-	return t >> 1
+	return wire.UnmarshalBool(n, b)
 }
 
 // Time functions
+//
+// MarshalTime writes the instant of the time as UnixNano int64, between the years 1678 and
+// 2262. The location and the monotonic clock reading of the time are not marshalled:
+// UnmarshalTime returns the time in the local zone, so a time.Now() doesn't unmarshal equal to
+// itself by == or reflect.DeepEqual. Compare the times with time.Time.Equal. TimeOptions
+// truncate the times to a coarser precision, MarshalTimeWithZone keeps their zone.
 func SkipTime(n int, b []byte) (int, error) {
 	return SkipInt64(n, b)
 }
@@ -1197,6 +1316,499 @@ func UnmarshalTime(n int, b []byte) (int, time.Time, error) {
 	return n, time.Unix(0, nano), nil
 }
 
+// TimeOptions control how times are marshalled. The wire format stays the one of MarshalTime
+// and MarshalTimeWithZone, so any of their unmarshallers reads the times.
+type TimeOptions struct {
+	// Precision the times are truncated to before marshalling, e.g. time.Millisecond, so a
+	// time compares equal to its unmarshalled copy once truncated the same way. Zero keeps
+	// nanoseconds. Truncation rounds down, like time.Time.Truncate.
+	Precision time.Duration
+}
+
+// Truncate returns 't' truncated to the precision, without its monotonic clock reading.
+func (o TimeOptions) Truncate(t time.Time) time.Time {
+	if o.Precision <= 0 {
+		return t.Round(0)
+	}
+	return t.Truncate(o.Precision)
+}
+
+// Returns the new offset 'n' after marshalling the truncated time, see MarshalTime.
+//
+// !- Panics, if 'b' is too small.
+func (o TimeOptions) Marshal(n int, b []byte, t time.Time) int {
+	return MarshalTime(n, b, o.Truncate(t))
+}
+
+// Returns the new offset 'n' after marshalling the truncated time and its zone, see MarshalTimeWithZone.
+//
+// !- Panics, if 'b' is too small.
+func (o TimeOptions) MarshalWithZone(n int, b []byte, t time.Time) int {
+	return MarshalTimeWithZone(n, b, o.Truncate(t))
+}
+
+// Duration functions
+func SkipDuration(n int, b []byte) (int, error) {
+	return SkipInt64(n, b)
+}
+
+func SizeDuration() int {
+	return 8 // int64 nanoseconds
+}
+
+func MarshalDuration(n int, b []byte, d time.Duration) int {
+	return MarshalInt64(n, b, int64(d))
+}
+
+func UnmarshalDuration(n int, b []byte) (int, time.Duration, error) {
+	n, nano, err := UnmarshalInt64(n, b)
+	if err != nil {
+		return n, 0, err
+	}
+	return n, time.Duration(nano), nil
+}
+
+// UUID functions
+//
+// UUIDs are marshalled as their 16 raw bytes. Types with [16]byte as underlying type,
+// like uuid.UUID of github.com/google/uuid, can be passed and assigned directly.
+func SkipUUID(n int, b []byte) (int, error) {
+	if len(b)-n < 16 {
+		return 0, ErrBufTooSmall
+	}
+	return n + 16, nil
+}
+
+func SizeUUID() int {
+	return 16
+}
+
+// Returns the new offset 'n' after marshalling the UUID.
+//
+// !- Panics, if 'b' is too small.
+func MarshalUUID(n int, b []byte, id [16]byte) int {
+	return n + copy(b[n:n+16], id[:])
+}
+
+// Returns the new offset 'n', as well as the UUID, that got unmarshalled.
+//
+// Possible errors returned:
+//   - ErrBufTooSmall       - 'b' was too small to unmarshal the UUID.
+//
+// If a error is returned, n (the int returned) equals zero ( 0 ).
+func UnmarshalUUID(n int, b []byte) (int, [16]byte, error) {
+	var id [16]byte
+	if len(b)-n < 16 {
+		return 0, id, ErrBufTooSmall
+	}
+	copy(id[:], b[n:])
+	return n + 16, id, nil
+}
+
+// net.IP and net.HardwareAddr functions
+//
+// Both are marshalled like a byte slice, an IPv4 address keeps its 4 or 16 byte form.
+
+func SkipIP(n int, b []byte) (int, error) {
+	return SkipBytes(n, b)
+}
+
+func SizeIP(ip net.IP) int {
+	return SizeBytes(ip)
+}
+
+// Returns the new offset 'n' after marshalling the IP address.
+//
+// !- Panics, if 'b' is too small.
+func MarshalIP(n int, b []byte, ip net.IP) int {
+	return MarshalBytes(n, b, ip)
+}
+
+// Returns the new offset 'n', as well as a copy of the IP address, that got unmarshalled.
+//
+// Possible errors returned:
+//   - ErrOverflow          - varint overflowed a N-bit unsigned integer.
+//   - ErrBufTooSmall       - 'b' was too small to unmarshal the IP address.
+//
+// If a error is returned, n (the int returned) equals zero ( 0 ).
+func UnmarshalIP(n int, b []byte) (int, net.IP, error) {
+	return UnmarshalBytesCopied(n, b)
+}
+
+func SkipHardwareAddr(n int, b []byte) (int, error) {
+	return SkipBytes(n, b)
+}
+
+func SizeHardwareAddr(addr net.HardwareAddr) int {
+	return SizeBytes(addr)
+}
+
+// Returns the new offset 'n' after marshalling the hardware address.
+//
+// !- Panics, if 'b' is too small.
+func MarshalHardwareAddr(n int, b []byte, addr net.HardwareAddr) int {
+	return MarshalBytes(n, b, addr)
+}
+
+// Returns the new offset 'n', as well as a copy of the hardware address, that got unmarshalled.
+//
+// Possible errors returned:
+//   - ErrOverflow          - varint overflowed a N-bit unsigned integer.
+//   - ErrBufTooSmall       - 'b' was too small to unmarshal the hardware address.
+//
+// If a error is returned, n (the int returned) equals zero ( 0 ).
+func UnmarshalHardwareAddr(n int, b []byte) (int, net.HardwareAddr, error) {
+	return UnmarshalBytesCopied(n, b)
+}
+
+// json.RawMessage functions
+//
+// A raw JSON message is marshalled like a byte slice, without validating or compacting it.
+
+func SkipRawMessage(n int, b []byte) (int, error) {
+	return SkipBytes(n, b)
+}
+
+func SizeRawMessage(msg json.RawMessage) int {
+	return SizeBytes(msg)
+}
+
+// Returns the new offset 'n' after marshalling the raw JSON message.
+//
+// !- Panics, if 'b' is too small.
+func MarshalRawMessage(n int, b []byte, msg json.RawMessage) int {
+	return MarshalBytes(n, b, msg)
+}
+
+// Returns the new offset 'n', as well as a copy of the raw JSON message, that got unmarshalled.
+//
+// Possible errors returned:
+//   - ErrOverflow          - varint overflowed a N-bit unsigned integer.
+//   - ErrBufTooSmall       - 'b' was too small to unmarshal the raw JSON message.
+//
+// If a error is returned, n (the int returned) equals zero ( 0 ).
+func UnmarshalRawMessage(n int, b []byte) (int, json.RawMessage, error) {
+	return UnmarshalBytesCopied(n, b)
+}
+
+// netip functions
+//
+// netip.Addr, netip.AddrPort and netip.Prefix are marshalled like a byte slice holding
+// their MarshalBinary representation: 0 bytes for the zero Addr, 4 bytes for IPv4,
+// 16 bytes (plus the zone) for IPv6, followed by the port (2 bytes) or the prefix bits (1 byte).
+
+func SkipAddr(n int, b []byte) (int, error) {
+	return SkipBytes(n, b)
+}
+
+func SizeAddr(addr netip.Addr) int {
+	s := addrBinarySize(addr)
+	return SizeUint(uint(s)) + s
+}
+
+// Returns the new offset 'n' after marshalling the address.
+//
+// !- Panics, if 'b' is too small.
+func MarshalAddr(n int, b []byte, addr netip.Addr) int {
+	return marshalBinary(n, b, addrBinarySize(addr), addr)
+}
+
+// Returns the new offset 'n', as well as the address, that got unmarshalled.
+//
+// Possible errors returned:
+//   - ErrOverflow          - varint overflowed a N-bit unsigned integer.
+//   - ErrBufTooSmall       - 'b' was too small to unmarshal the address.
+//   - any error returned by netip.Addr.UnmarshalBinary.
+//
+// If a error is returned, n (the int returned) equals zero ( 0 ).
+func UnmarshalAddr(n int, b []byte) (int, netip.Addr, error) {
+	var addr netip.Addr
+	n, err := unmarshalBinary(n, b, &addr)
+	return n, addr, err
+}
+
+func SkipAddrPort(n int, b []byte) (int, error) {
+	return SkipBytes(n, b)
+}
+
+func SizeAddrPort(ap netip.AddrPort) int {
+	s := addrBinarySize(ap.Addr()) + 2
+	return SizeUint(uint(s)) + s
+}
+
+// Returns the new offset 'n' after marshalling the address and port.
+//
+// !- Panics, if 'b' is too small.
+func MarshalAddrPort(n int, b []byte, ap netip.AddrPort) int {
+	return marshalBinary(n, b, addrBinarySize(ap.Addr())+2, ap)
+}
+
+// Returns the new offset 'n', as well as the address and port, that got unmarshalled.
+//
+// Possible errors returned:
+//   - ErrOverflow          - varint overflowed a N-bit unsigned integer.
+//   - ErrBufTooSmall       - 'b' was too small to unmarshal the address and port.
+//   - any error returned by netip.AddrPort.UnmarshalBinary.
+//
+// If a error is returned, n (the int returned) equals zero ( 0 ).
+func UnmarshalAddrPort(n int, b []byte) (int, netip.AddrPort, error) {
+	var ap netip.AddrPort
+	n, err := unmarshalBinary(n, b, &ap)
+	return n, ap, err
+}
+
+func SkipPrefix(n int, b []byte) (int, error) {
+	return SkipBytes(n, b)
+}
+
+func SizePrefix(p netip.Prefix) int {
+	s := addrBinarySize(p.Addr()) + 1
+	return SizeUint(uint(s)) + s
+}
+
+// Returns the new offset 'n' after marshalling the IP prefix.
+//
+// !- Panics, if 'b' is too small.
+func MarshalPrefix(n int, b []byte, p netip.Prefix) int {
+	return marshalBinary(n, b, addrBinarySize(p.Addr())+1, p)
+}
+
+// Returns the new offset 'n', as well as the IP prefix, that got unmarshalled.
+//
+// Possible errors returned:
+//   - ErrOverflow          - varint overflowed a N-bit unsigned integer.
+//   - ErrBufTooSmall       - 'b' was too small to unmarshal the IP prefix.
+//   - any error returned by netip.Prefix.UnmarshalBinary.
+//
+// If a error is returned, n (the int returned) equals zero ( 0 ).
+func UnmarshalPrefix(n int, b []byte) (int, netip.Prefix, error) {
+	var p netip.Prefix
+	n, err := unmarshalBinary(n, b, &p)
+	return n, p, err
+}
+
+func addrBinarySize(addr netip.Addr) int {
+	switch {
+	case !addr.IsValid():
+		return 0
+	case addr.Is4():
+		return 4
+	default:
+		return 16 + len(addr.Zone())
+	}
+}
+
+// marshalBinary marshals the 's' bytes long binary representation of 'v' with a length prefix,
+// appending in place to avoid allocations.
+func marshalBinary(n int, b []byte, s int, v encoding.BinaryAppender) int {
+	n = MarshalUint(n, b, uint(s))
+	if _, err := v.AppendBinary(b[n:n:n+s]); err != nil {
+		panic("benc: " + err.Error())
+	}
+	return n + s
+}
+
+func unmarshalBinary(n int, b []byte, v encoding.BinaryUnmarshaler) (int, error) {
+	n, bs, err := UnmarshalBytesCropped(n, b)
+	if err != nil {
+		return 0, err
+	}
+	if err = v.UnmarshalBinary(bs); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// URL functions
+//
+// *url.URL is marshalled as its string form and parsed again when unmarshalled.
+// A nil pointer is marshalled as an empty string, which is unmarshalled as nil.
+
+func SkipURL(n int, b []byte) (int, error) {
+	return SkipString(n, b)
+}
+
+func SizeURL(u *url.URL) int {
+	if u == nil {
+		return SizeString("")
+	}
+	return SizeString(u.String())
+}
+
+// Returns the new offset 'n' after marshalling the URL.
+//
+// !- Panics, if 'b' is too small.
+func MarshalURL(n int, b []byte, u *url.URL) int {
+	if u == nil {
+		return MarshalString(n, b, "")
+	}
+	return MarshalString(n, b, u.String())
+}
+
+// Returns the new offset 'n', as well as the URL, that got unmarshalled.
+//
+// Possible errors returned:
+//   - ErrOverflow          - varint overflowed a N-bit unsigned integer.
+//   - ErrBufTooSmall       - 'b' was too small to unmarshal the URL.
+//   - any error returned by url.Parse.
+//
+// If a error is returned, n (the int returned) equals zero ( 0 ).
+func UnmarshalURL(n int, b []byte) (int, *url.URL, error) {
+	n, s, err := UnmarshalString(n, b)
+	if err != nil || s == "" {
+		return n, nil, err
+	}
+	u, err := url.Parse(s)
+	if err != nil {
+		return 0, nil, err
+	}
+	return n, u, nil
+}
+
+// Big number functions
+//
+// *big.Int, *big.Float and *big.Rat are marshalled like a byte slice holding their
+// GobEncode representation. A nil pointer is marshalled as an empty byte slice.
+
+func SkipBigInt(n int, b []byte) (int, error) {
+	return SkipBytes(n, b)
+}
+
+func SizeBigInt(x *big.Int) int {
+	if x == nil {
+		return SizeUint(0)
+	}
+	s := bigIntGobSize(x)
+	return SizeUint(uint(s)) + s
+}
+
+// Returns the new offset 'n' after marshalling the big integer.
+//
+// !- Panics, if 'b' is too small.
+func MarshalBigInt(n int, b []byte, x *big.Int) int {
+	if x == nil {
+		return MarshalUint(n, b, 0)
+	}
+	s := bigIntGobSize(x)
+	n = MarshalUint(n, b, uint(s))
+
+	// Same layout as big.Int.GobEncode: version and sign byte, followed by the absolute value.
+	b[n] = 1 << 1
+	if x.Sign() < 0 {
+		b[n] |= 1
+	}
+	x.FillBytes(b[n+1 : n+s])
+	return n + s
+}
+
+// Returns the new offset 'n', as well as the big integer, that got unmarshalled.
+//
+// Possible errors returned:
+//   - ErrOverflow          - varint overflowed a N-bit unsigned integer.
+//   - ErrBufTooSmall       - 'b' was too small to unmarshal the big integer.
+//   - any error returned by big.Int.GobDecode.
+//
+// If a error is returned, n (the int returned) equals zero ( 0 ).
+func UnmarshalBigInt(n int, b []byte) (int, *big.Int, error) {
+	n, bs, err := UnmarshalBytesCropped(n, b)
+	if err != nil || len(bs) == 0 {
+		return n, nil, err
+	}
+	x := new(big.Int)
+	if err = x.GobDecode(bs); err != nil {
+		return 0, nil, err
+	}
+	return n, x, nil
+}
+
+func bigIntGobSize(x *big.Int) int {
+	return 1 + (x.BitLen()+7)/8
+}
+
+func SkipBigFloat(n int, b []byte) (int, error) {
+	return SkipBytes(n, b)
+}
+
+// Returns the bytes needed to marshal the big float.
+// The float gets gob encoded to determine the size, prefer SizeBigInt if possible.
+func SizeBigFloat(x *big.Float) int {
+	return SizeBytes(gobEncode(x))
+}
+
+// Returns the new offset 'n' after marshalling the big float.
+//
+// !- Panics, if 'b' is too small.
+func MarshalBigFloat(n int, b []byte, x *big.Float) int {
+	return MarshalBytes(n, b, gobEncode(x))
+}
+
+// Returns the new offset 'n', as well as the big float, that got unmarshalled.
+//
+// Possible errors returned:
+//   - ErrOverflow          - varint overflowed a N-bit unsigned integer.
+//   - ErrBufTooSmall       - 'b' was too small to unmarshal the big float.
+//   - any error returned by big.Float.GobDecode.
+//
+// If a error is returned, n (the int returned) equals zero ( 0 ).
+func UnmarshalBigFloat(n int, b []byte) (int, *big.Float, error) {
+	n, bs, err := UnmarshalBytesCropped(n, b)
+	if err != nil || len(bs) == 0 {
+		return n, nil, err
+	}
+	x := new(big.Float)
+	if err = x.GobDecode(bs); err != nil {
+		return 0, nil, err
+	}
+	return n, x, nil
+}
+
+func SkipBigRat(n int, b []byte) (int, error) {
+	return SkipBytes(n, b)
+}
+
+// Returns the bytes needed to marshal the big rational.
+// The rational gets gob encoded to determine the size.
+func SizeBigRat(x *big.Rat) int {
+	return SizeBytes(gobEncode(x))
+}
+
+// Returns the new offset 'n' after marshalling the big rational.
+//
+// !- Panics, if 'b' is too small.
+func MarshalBigRat(n int, b []byte, x *big.Rat) int {
+	return MarshalBytes(n, b, gobEncode(x))
+}
+
+// Returns the new offset 'n', as well as the big rational, that got unmarshalled.
+//
+// Possible errors returned:
+//   - ErrOverflow          - varint overflowed a N-bit unsigned integer.
+//   - ErrBufTooSmall       - 'b' was too small to unmarshal the big rational.
+//   - any error returned by big.Rat.GobDecode.
+//
+// If a error is returned, n (the int returned) equals zero ( 0 ).
+func UnmarshalBigRat(n int, b []byte) (int, *big.Rat, error) {
+	n, bs, err := UnmarshalBytesCropped(n, b)
+	if err != nil || len(bs) == 0 {
+		return n, nil, err
+	}
+	x := new(big.Rat)
+	if err = x.GobDecode(bs); err != nil {
+		return 0, nil, err
+	}
+	return n, x, nil
+}
+
+// gobEncode returns the gob representation of a big number, nil for a nil pointer.
+// The big types only fail to encode values that can't be represented at all, so this panics like Marshal.
+func gobEncode(x interface{ GobEncode() ([]byte, error) }) []byte {
+	bs, err := x.GobEncode()
+	if err != nil {
+		panic("benc: " + err.Error())
+	}
+	return bs
+}
+
 // Pointer fields by adding a boolean prefix
 
 // Skips over a marshalled pointer field. It reads the boolean prefix and,
@@ -1217,14 +1829,26 @@ func SkipPointer(n int, b []byte, skipElement func(n int, b []byte) (int, error)
 	return n, nil
 }
 
+// Returns the bytes needed to marshal the pointer. A nil 'sizeFn' sizes the value by its
+// BencMarshaler.
 func SizePointer[T any](v *T, sizeFn SizeFunc[T]) int {
+	if sizeFn == nil {
+		sizeFn = sizeBenc[T]
+	}
 	if v != nil {
 		return SizeBool() + sizeFn(*v)
 	}
 	return SizeBool()
 }
 
+// Returns the new offset 'n' after marshalling the pointer. A nil 'marshalFn' marshals the
+// value by its BencMarshaler.
+//
+// !- Panics, if 'b' is too small.
 func MarshalPointer[T any](n int, b []byte, v *T, marshalFn MarshalFunc[T]) int {
+	if marshalFn == nil {
+		marshalFn = marshalBenc[T]
+	}
 	n = MarshalBool(n, b, v != nil)
 
 	if v != nil {
@@ -1235,6 +1859,19 @@ func MarshalPointer[T any](n int, b []byte, v *T, marshalFn MarshalFunc[T]) int
 }
 
 func UnmarshalPointer[T any](n int, b []byte, unmarshaler interface{}) (int, *T, error) {
+	return UnmarshalPointerLimited[T](n, b, unmarshaler, Limits{})
+}
+
+// Returns the new offset 'n', as well as the pointer, that got unmarshalled.
+// Unlike UnmarshalPointer, it counts the value in the Depth of 'limits'.
+//
+// Possible errors returned:
+//   - ErrBufTooSmall       - 'b' was too small to unmarshal the pointer.
+//   - ErrMaxDepth          - the value is nested deeper than the Depth of 'limits' allows.
+//   - any error returned by 'unmarshaler'.
+//
+// If a error is returned, n (the int returned) equals zero ( 0 ).
+func UnmarshalPointerLimited[T any](n int, b []byte, unmarshaler interface{}, limits Limits) (int, *T, error) {
 	// 1. Unmarshal the boolean flag.
 	n, hasValue, err := UnmarshalBool(n, b)
 	if err != nil {
@@ -1248,7 +1885,15 @@ func UnmarshalPointer[T any](n int, b []byte, unmarshaler interface{}) (int, *T,
 	}
 
 	// 3. Only if the flag is true, create and unmarshal the value.
+	if err = limits.Depth.enter(); err != nil {
+		return 0, nil, err
+	}
+	defer limits.Depth.leave()
+
 	var t T
+	if unmarshaler == nil {
+		unmarshaler = unmarshalBenc[T]
+	}
 	switch p := unmarshaler.(type) {
 	case func(n int, b []byte) (int, T, error):
 		n, t, err = p(n, b)
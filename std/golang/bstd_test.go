@@ -1,11 +1,19 @@
 package bstd
 
 import (
+	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"maps"
 	"math"
+	"math/big"
 	"math/rand"
+	"net"
+	"net/netip"
+	"net/url"
 	"reflect"
+	"slices"
 	"strconv"
 	"testing"
 	"time"
@@ -338,6 +346,130 @@ func TestSlices(t *testing.T) {
 	}
 }
 
+func TestUnmarshalSliceInPlace(t *testing.T) {
+	slice := []int32{1, -2, 3}
+	buf := make([]byte, SizeSlice(slice, func(int32) int { return SizeInt32() }))
+	MarshalSlice(0, buf, slice, MarshalInt32)
+
+	dst := make([]int32, 5, 8)
+	n, got, err := UnmarshalSliceInPlace(0, buf, dst, UnmarshalInt32)
+	if err != nil || n != len(buf) || !slices.Equal(got, slice) {
+		t.Fatalf("got %v, %d, %v", got, n, err)
+	}
+	if &got[0] != &dst[0] {
+		t.Fatal("expected the backing array of dst to be reused")
+	}
+	if allocs := testing.AllocsPerRun(100, func() { UnmarshalSliceInPlace(0, buf, dst, UnmarshalInt32) }); allocs != 0 {
+		t.Fatalf("expected no allocations, got %v", allocs)
+	}
+
+	// a too small or nil dst grows, the pointer unmarshalers zero the reused elements
+	for _, dst := range [][]int32{make([]int32, 1), nil} {
+		_, got, err = UnmarshalSliceInPlace(0, buf, dst, func(n int, b []byte, v *int32) (int, error) {
+			var err error
+			n, *v, err = UnmarshalInt32(n, b)
+			return n, err
+		})
+		if err != nil || !slices.Equal(got, slice) {
+			t.Fatalf("got %v, %v", got, err)
+		}
+	}
+
+	if _, got, err = UnmarshalSliceInPlace(0, buf[:len(buf)-1], dst, UnmarshalInt32); !errors.Is(err, ErrBufTooSmall) || got != nil {
+		t.Fatalf("expected ErrBufTooSmall, got %v, %v", got, err)
+	}
+}
+
+func TestUnmarshalMapInto(t *testing.T) {
+	m := map[int32]int32{1: -1, 2: -2, 3: -3}
+	buf := make([]byte, SizeMap(m, SizeInt32, SizeInt32))
+	MarshalMap(0, buf, m, MarshalInt32, MarshalInt32)
+
+	// the stale entries of dst are cleared, dst itself is returned
+	dst := map[int32]int32{4: -4, 1: 0}
+	n, got, err := UnmarshalMapInto(0, buf, dst, UnmarshalInt32, UnmarshalInt32)
+	if err != nil || n != len(buf) || !maps.Equal(got, m) || !maps.Equal(dst, m) {
+		t.Fatalf("got %v, %d, %v", got, n, err)
+	}
+	if allocs := testing.AllocsPerRun(100, func() { UnmarshalMapInto(0, buf, dst, UnmarshalInt32, UnmarshalInt32) }); allocs != 0 {
+		t.Fatalf("expected no allocations, got %v", allocs)
+	}
+
+	if _, got, err = UnmarshalMapInto[int32, int32](0, buf, nil, UnmarshalInt32, UnmarshalInt32); err != nil || !maps.Equal(got, m) {
+		t.Fatalf("got %v, %v", got, err)
+	}
+	if _, got, err = UnmarshalMapInto(0, buf[:len(buf)-1], dst, UnmarshalInt32, UnmarshalInt32); !errors.Is(err, ErrBufTooSmall) || got != nil {
+		t.Fatalf("expected ErrBufTooSmall, got %v, %v", got, err)
+	}
+}
+
+func TestArrays(t *testing.T) {
+	floats := [4]float32{1.5, -2, 0, 3.25}
+	strs := [2]string{"first", ""}
+	raw := [5]byte{1, 2, 3, 4, 5}
+
+	s := len(floats) * SizeFloat32() // no length prefix and terminator
+	if got := SizeArray(floats[:], func(float32) int { return SizeFloat32() }); got != s {
+		t.Fatalf("expected size %d, got %d", s, got)
+	}
+	s += SizeArray(strs[:], SizeString) + SizeByteArray(len(raw))
+
+	buf := make([]byte, s)
+	n := MarshalArray(0, buf, floats[:], MarshalFloat32)
+	n = MarshalArray(n, buf, strs[:], MarshalString)
+	n = MarshalByteArray(n, buf, raw[:])
+	if n != s {
+		t.Fatalf("expected offset %d, got %d", s, n)
+	}
+
+	if err := SkipOnce_Verify(buf, func(n int, b []byte) (int, error) {
+		n, err := SkipArray(n, b, len(floats), SkipFloat32)
+		if err != nil {
+			return 0, err
+		}
+		if n, err = SkipArray(n, b, len(strs), SkipString); err != nil {
+			return 0, err
+		}
+		return SkipByteArray(n, b, len(raw))
+	}); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	var retFloats [4]float32
+	var retStrs [2]string
+	var retBytes [5]byte
+	n, err := UnmarshalArray[float32](0, buf, retFloats[:], UnmarshalFloat32)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if n, err = UnmarshalArray[string](n, buf, retStrs[:], UnmarshalString); err != nil {
+		t.Fatal(err.Error())
+	}
+	if _, err = UnmarshalByteArray(n, buf, retBytes[:]); err != nil {
+		t.Fatal(err.Error())
+	}
+	if retFloats != floats || retStrs != strs || retBytes != raw {
+		t.Fatalf("no match: \norg %v %q %v\ndec %v %q %v", floats, strs, raw, retFloats, retStrs, retBytes)
+	}
+
+	if _, err = SkipArray(0, buf[:5], len(floats), SkipFloat32); !errors.Is(err, ErrBufTooSmall) {
+		t.Fatalf("expected ErrBufTooSmall, got %v", err)
+	}
+	if _, err = SkipByteArray(s-4, buf, len(raw)); !errors.Is(err, ErrBufTooSmall) {
+		t.Fatalf("expected ErrBufTooSmall, got %v", err)
+	}
+	if _, err = UnmarshalArray[string](16, buf[:17], retStrs[:], UnmarshalString); !errors.Is(err, ErrBufTooSmall) {
+		t.Fatalf("expected ErrBufTooSmall, got %v", err)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected MarshalByteArray to panic on a short buffer")
+		}
+	}()
+	MarshalByteArray(0, make([]byte, 4), raw[:])
+}
+
 func TestMaps(t *testing.T) {
 	m := make(map[string]string)
 	m["mapkey1"] = "mapvalue1"
@@ -396,6 +528,415 @@ func TestMaps_2(t *testing.T) {
 	}
 }
 
+type testPoint struct {
+	X, Y int32
+}
+
+func (p *testPoint) Size() int {
+	return SizeInt32() * 2
+}
+
+func (p *testPoint) Marshal(n int, b []byte) int {
+	n = MarshalInt32(n, b, p.X)
+	return MarshalInt32(n, b, p.Y)
+}
+
+func (p *testPoint) Unmarshal(n int, b []byte) (int, error) {
+	var err error
+	if n, p.X, err = UnmarshalInt32(n, b); err != nil {
+		return 0, err
+	}
+	if n, p.Y, err = UnmarshalInt32(n, b); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+func TestMaps_StructKeys(t *testing.T) {
+	m := map[testPoint]string{{1, 2}: "a", {-3, 4}: "b", {0, 0}: ""}
+
+	s := SizeMap(m, SizeMessage[testPoint], SizeString)
+	buf := make([]byte, s)
+	if n := MarshalMap(0, buf, m, MarshalMessage[testPoint], MarshalString); n != s {
+		t.Fatalf("expected size %d, got %d", s, n)
+	}
+
+	if err := SkipOnce_Verify(buf, func(n int, b []byte) (int, error) {
+		return SkipMap(n, b, func(n int, b []byte) (int, error) { return SkipArray(n, b, 2, SkipInt32) }, SkipString)
+	}); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	_, retMap, err := UnmarshalMap[testPoint, string](0, buf, UnmarshalMessage[testPoint], UnmarshalString)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if !reflect.DeepEqual(retMap, m) {
+		t.Logf("org %v\ndec %v", m, retMap)
+		t.Fatal("no match!")
+	}
+
+	if _, _, err = UnmarshalMessage[testPoint](0, buf[:3]); err == nil {
+		t.Fatal("expected an error")
+	}
+}
+
+func TestLimits(t *testing.T) {
+	slice := []int32{1, 2, 3}
+	buf := make([]byte, SizeFixedSlice(slice, SizeInt32()))
+	MarshalSlice(0, buf, slice, MarshalInt32)
+	m := map[string]byte{"a": 1, "b": 2}
+	mbuf := make([]byte, SizeMap(m, SizeString, SizeByte))
+	MarshalMap(0, mbuf, m, MarshalString, MarshalByte)
+
+	unmarshalSlice := func(limits Limits) error {
+		_, _, err := UnmarshalSliceLimited[int32](0, buf, UnmarshalInt32, limits)
+		return err
+	}
+	unmarshalMap := func(limits Limits) error {
+		_, _, err := UnmarshalMapLimited[string, byte](0, mbuf, UnmarshalString, UnmarshalByte, limits)
+		return err
+	}
+	for _, tc := range []struct {
+		limits Limits
+		err    error
+	}{
+		{Limits{}, nil},
+		{Limits{MaxElements: 3, MaxBytes: len(buf)}, nil},
+		{Limits{MaxElements: 2}, ErrLimitExceeded},
+		{Limits{MaxBytes: len(buf) - 1}, ErrLimitExceeded},
+		{Limits{MaxBytes: 5}, ErrLimitExceeded},
+	} {
+		if err := unmarshalSlice(tc.limits); err != tc.err {
+			t.Fatalf("slice, %+v: expected %v, got %v", tc.limits, tc.err, err)
+		}
+	}
+	if err := unmarshalMap(Limits{MaxElements: 2, MaxBytes: len(mbuf)}); err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := unmarshalMap(Limits{MaxElements: 1}); err != ErrLimitExceeded {
+		t.Fatalf("map: expected ErrLimitExceeded, got %v", err)
+	}
+	if err := unmarshalMap(Limits{MaxBytes: len(mbuf) - 1}); err != ErrLimitExceeded {
+		t.Fatalf("map: expected ErrLimitExceeded, got %v", err)
+	}
+
+	// a forged length prefix fails on the missing data instead of allocating it up front
+	b := make([]byte, 16)
+	MarshalUint(0, b, math.MaxInt64)
+	if _, _, err := UnmarshalSlice[int32](0, b, UnmarshalInt32); err != ErrBufTooSmall {
+		t.Fatalf("slice: expected ErrBufTooSmall, got %v", err)
+	}
+	if _, _, err := UnmarshalMap[int32, int32](0, b, UnmarshalInt32, UnmarshalInt32); err != ErrBufTooSmall {
+		t.Fatalf("map: expected ErrBufTooSmall, got %v", err)
+	}
+	if _, _, err := UnmarshalSliceLimited[int32](0, b, UnmarshalInt32, Limits{MaxElements: 1 << 20}); err != ErrLimitExceeded {
+		t.Fatalf("slice: expected ErrLimitExceeded, got %v", err)
+	}
+}
+
+func TestDuplicateKeys(t *testing.T) {
+	// a crafted map of the entries "a": 1, "b": 2, "a": 3, which MarshalMap never writes
+	b := make([]byte, 0, 32)
+	b = append(b, 3)
+	for _, e := range []struct {
+		k string
+		v byte
+	}{{"a", 1}, {"b", 2}, {"a", 3}} {
+		b = append(b, byte(len(e.k)))
+		b = append(b, e.k...)
+		b = append(b, e.v)
+	}
+	b = append(b, 1, 1, 1, 1)
+
+	for _, tc := range []struct {
+		policy DuplicateKeyPolicy
+		want   byte
+		err    error
+	}{
+		{DuplicatesLastWins, 3, nil},
+		{DuplicatesFirstWins, 1, nil},
+		{DuplicatesError, 0, ErrDuplicateKey},
+	} {
+		n, m, err := UnmarshalMapLimited[string, byte](0, b, UnmarshalString, UnmarshalByte, Limits{Duplicates: tc.policy})
+		if err != tc.err {
+			t.Fatalf("policy %d: expected %v, got %v", tc.policy, tc.err, err)
+		}
+		if err == nil && (n != len(b) || len(m) != 2 || m["a"] != tc.want || m["b"] != 2) {
+			t.Fatalf("policy %d: got %v, %d", tc.policy, m, n)
+		}
+	}
+	if _, m, err := UnmarshalMap[string, byte](0, b, UnmarshalString, UnmarshalByte); err != nil || m["a"] != 3 {
+		t.Fatalf("expected the last entry to win, got %v, %v", m, err)
+	}
+}
+
+func TestDepth(t *testing.T) {
+	type node struct {
+		Children []node
+		Next     *node
+	}
+	var size func(v node) int
+	size = func(v node) int { return SizeSlice(v.Children, size) + SizePointer(v.Next, size) }
+	var marshal func(n int, b []byte, v node) int
+	marshal = func(n int, b []byte, v node) int {
+		n = MarshalSlice(n, b, v.Children, marshal)
+		return MarshalPointer(n, b, v.Next, marshal)
+	}
+
+	// 'levels' nodes inside of each other, alternating between slices and pointers
+	nested := func(levels int) []byte {
+		var v node
+		for i := range levels {
+			inner := v
+			if i%2 == 0 {
+				v = node{Children: []node{inner}}
+			} else {
+				v = node{Next: &inner}
+			}
+		}
+		b := make([]byte, size(v))
+		marshal(0, b, v)
+		return b
+	}
+
+	for _, tc := range []struct {
+		levels, max int
+		err         error
+	}{
+		{10, 11, nil},
+		{10, 10, ErrMaxDepth},
+		{DefaultMaxDepth - 1, 0, nil},
+		{DefaultMaxDepth, 0, ErrMaxDepth},
+	} {
+		depth := &Depth{Max: tc.max}
+		limits := Limits{Depth: depth}
+		var unmarshal func(n int, b []byte, v *node) (int, error)
+		unmarshal = func(n int, b []byte, v *node) (int, error) {
+			var err error
+			if n, v.Children, err = UnmarshalSliceLimited[node](n, b, unmarshal, limits); err != nil {
+				return 0, err
+			}
+			n, v.Next, err = UnmarshalPointerLimited[node](n, b, unmarshal, limits)
+			return n, err
+		}
+
+		var v node
+		b := nested(tc.levels)
+		// the outermost node isn't in a container, the empty slice of the innermost one is
+		if _, err := unmarshal(0, b, &v); !errors.Is(err, tc.err) {
+			t.Fatalf("%d levels, max %d: expected %v, got %v", tc.levels, tc.max, tc.err, err)
+		}
+		if depth.current != 0 {
+			t.Fatalf("%d levels: depth not left, %d", tc.levels, depth.current)
+		}
+	}
+}
+
+func TestVarints(t *testing.T) {
+	checkVarint(t, SizeInt16Varint, MarshalInt16Varint, UnmarshalInt16Varint, SkipInt16Varint, []int16{0, 1, -1, 63, -64, math.MinInt16, math.MaxInt16})
+	checkVarint(t, SizeInt32Varint, MarshalInt32Varint, UnmarshalInt32Varint, SkipInt32Varint, []int32{0, 1, -1, 63, -64, math.MinInt32, math.MaxInt32})
+	checkVarint(t, SizeInt64Varint, MarshalInt64Varint, UnmarshalInt64Varint, SkipInt64Varint, []int64{0, 1, -1, 63, -64, math.MinInt64, math.MaxInt64})
+	checkVarint(t, SizeUint16Varint, MarshalUint16Varint, UnmarshalUint16Varint, SkipUint16Varint, []uint16{0, 1, 127, 128, math.MaxUint16})
+	checkVarint(t, SizeUint32Varint, MarshalUint32Varint, UnmarshalUint32Varint, SkipUint32Varint, []uint32{0, 1, 127, 128, math.MaxUint32})
+	checkVarint(t, SizeUint64Varint, MarshalUint64Varint, UnmarshalUint64Varint, SkipUint64Varint, []uint64{0, 1, 127, 128, math.MaxUint64})
+
+	if s := SizeInt64Varint(-64); s != 1 {
+		t.Fatalf("expected small values to take 1 byte, got %d", s)
+	}
+	if s := SizeInt32Varint(-8192); s != 2 {
+		t.Fatalf("expected small negative values to take 2 bytes, got %d", s)
+	}
+
+	buf := make([]byte, SizeUint32Varint(math.MaxUint16+1))
+	MarshalUint32Varint(0, buf, math.MaxUint16+1)
+	if _, _, err := UnmarshalUint16Varint(0, buf); err != ErrOverflow {
+		t.Fatalf("expected ErrOverflow, got %v", err)
+	}
+	if _, err := SkipInt16Varint(0, buf); err != ErrOverflow {
+		t.Fatalf("expected ErrOverflow, got %v", err)
+	}
+	if _, _, err := UnmarshalUint64Varint(0, append(bytes.Repeat([]byte{0xff}, 9), 2)); err != ErrOverflow {
+		t.Fatalf("expected ErrOverflow, got %v", err)
+	}
+	if _, _, err := UnmarshalUint64Varint(0, append(bytes.Repeat([]byte{0xff}, 10), 1)); err != ErrOverflow {
+		t.Fatalf("expected ErrOverflow, got %v", err)
+	}
+	if _, _, err := UnmarshalInt32Varint(0, buf[:len(buf)-1]); err != ErrBufTooSmall {
+		t.Fatalf("expected ErrBufTooSmall, got %v", err)
+	}
+}
+
+func TestFloat16(t *testing.T) {
+	tests := []struct {
+		v    float32
+		bits uint16
+	}{
+		{0, 0x0000},
+		{float32(math.Copysign(0, -1)), 0x8000},
+		{1, 0x3c00},
+		{-2, 0xc000},
+		{0.1, 0x2e66},
+		{65504, 0x7bff},
+		{65519, 0x7bff},
+		{65520, 0x7c00},
+		{float32(math.Inf(-1)), 0xfc00},
+		{math.Float32frombits(0x7f800001), 0x7e00},
+		{1.0 / (1 << 24), 0x0001},
+		{1.0 / (1 << 25), 0x0000},
+		{1.5 / (1 << 25), 0x0001},
+		{1.0 / (1 << 14), 0x0400},
+		{1 + 1.0/(1<<11), 0x3c00},
+		{1 + 3.0/(1<<11), 0x3c02},
+	}
+	buf := make([]byte, SizeFloat16())
+	for _, tt := range tests {
+		if n := MarshalFloat16(0, buf, tt.v); n != 2 {
+			t.Fatalf("%v: expected offset 2, got %d", tt.v, n)
+		}
+		if bits := uint16(buf[0]) | uint16(buf[1])<<8; bits != tt.bits {
+			t.Errorf("%v: expected %#04x, got %#04x", tt.v, tt.bits, bits)
+		}
+	}
+
+	// every half precision float converts to float32 and back unchanged
+	for h := range 1 << 16 {
+		buf[0], buf[1] = byte(h), byte(h>>8)
+		n, v, err := UnmarshalFloat16(0, buf)
+		if err != nil || n != 2 {
+			t.Fatalf("%#04x: unexpected %d, %v", h, n, err)
+		}
+		MarshalFloat16(0, buf, v)
+		if got := int(buf[0]) | int(buf[1])<<8; got != h {
+			t.Fatalf("%#04x: got %#04x after round trip of %v", h, got, v)
+		}
+	}
+
+	if n, v, err := UnmarshalFloat16(0, []byte{0x00, 0x7e}); err != nil || n != 2 || !math.IsNaN(float64(v)) {
+		t.Fatalf("expected NaN, got %v, %v", v, err)
+	}
+	if _, err := SkipFloat16(0, buf[:1]); err != ErrBufTooSmall {
+		t.Fatalf("expected ErrBufTooSmall, got %v", err)
+	}
+	if _, _, err := UnmarshalFloat16(0, buf[:1]); err != ErrBufTooSmall {
+		t.Fatalf("expected ErrBufTooSmall, got %v", err)
+	}
+	if n, err := SkipFloat16(0, buf); err != nil || n != 2 {
+		t.Fatalf("unexpected %d, %v", n, err)
+	}
+}
+
+// testBigDecimal is a minimal BigDecimal like decimal.Decimal of shopspring/decimal.
+type testBigDecimal struct {
+	coef *big.Int
+	exp  int32
+}
+
+func (d testBigDecimal) Coefficient() *big.Int { return d.coef }
+func (d testBigDecimal) Exponent() int32       { return d.exp }
+
+func TestDecimal(t *testing.T) {
+	strs := map[Decimal]string{
+		{12345, 2}:          "123.45",
+		{-5, 2}:             "-0.05",
+		{7, 0}:              "7",
+		{7, -3}:             "7000",
+		{0, -3}:             "0",
+		{0, 2}:              "0.00",
+		{math.MinInt64, 4}:  "-922337203685477.5808",
+		{math.MaxInt64, 19}: "0.9223372036854775807",
+	}
+	for d, want := range strs {
+		if got := d.String(); got != want {
+			t.Errorf("%#v: expected %s, got %s", d, want, got)
+		}
+
+		buf := make([]byte, SizeDecimal(d))
+		if n := MarshalDecimal(0, buf, d); n != len(buf) {
+			t.Fatalf("%v: expected offset %d, got %d", d, len(buf), n)
+		}
+		n, got, err := UnmarshalDecimal(0, buf)
+		if err != nil || n != len(buf) || got != d {
+			t.Fatalf("%v: got %v, %d, %v", d, got, n, err)
+		}
+		if n, err = SkipDecimal(0, buf); err != nil || n != len(buf) {
+			t.Fatalf("%v: skip got %d, %v", d, n, err)
+		}
+		if _, _, err = UnmarshalDecimal(0, buf[:len(buf)-1]); err != ErrBufTooSmall {
+			t.Fatalf("%v: expected ErrBufTooSmall, got %v", d, err)
+		}
+		if _, err = SkipDecimal(0, buf[:len(buf)-1]); err != ErrBufTooSmall {
+			t.Fatalf("%v: expected ErrBufTooSmall, got %v", d, err)
+		}
+	}
+	if s := SizeDecimal(Decimal{-1999, 2}); s != 3 {
+		t.Fatalf("expected small decimals to take 3 bytes, got %d", s)
+	}
+	if _, _, err := UnmarshalDecimal(0, nil); err != ErrBufTooSmall {
+		t.Fatalf("expected ErrBufTooSmall, got %v", err)
+	}
+	if _, err := SkipDecimal(0, nil); err != ErrBufTooSmall {
+		t.Fatalf("expected ErrBufTooSmall, got %v", err)
+	}
+
+	newDecimal := func(coef *big.Int, exp int32) testBigDecimal { return testBigDecimal{coef, exp} }
+	r := rand.New(rand.NewSource(1))
+	for i := range 100 {
+		d := GenerateBigDecimal(newDecimal)(r, 0)
+		if i == 0 {
+			d.coef = new(big.Int)
+		}
+		buf := make([]byte, SizeBigDecimal(d))
+		if n := MarshalBigDecimal(0, buf, d); n != len(buf) {
+			t.Fatalf("%v: expected offset %d, got %d", d, len(buf), n)
+		}
+		n, got, err := UnmarshalBigDecimal(0, buf, newDecimal)
+		if err != nil || n != len(buf) {
+			t.Fatalf("%v: got %d, %v", d, n, err)
+		}
+		if err = CompareBigDecimal(d, got); err != nil {
+			t.Fatal(err)
+		}
+		if n, err = SkipBigDecimal(0, buf); err != nil || n != len(buf) {
+			t.Fatalf("%v: skip got %d, %v", d, n, err)
+		}
+		if _, _, err = UnmarshalBigDecimal(0, buf[:len(buf)-1], newDecimal); err != ErrBufTooSmall {
+			t.Fatalf("%v: expected ErrBufTooSmall, got %v", d, err)
+		}
+	}
+	if _, _, err := UnmarshalBigDecimal(0, nil, newDecimal); err != ErrBufTooSmall {
+		t.Fatalf("expected ErrBufTooSmall, got %v", err)
+	}
+	if _, err := SkipBigDecimal(0, nil); err != ErrBufTooSmall {
+		t.Fatalf("expected ErrBufTooSmall, got %v", err)
+	}
+	if err := CompareBigDecimal(testBigDecimal{big.NewInt(10), 0}, testBigDecimal{big.NewInt(1), 1}); err == nil {
+		t.Fatal("expected a mismatch of different representations")
+	}
+}
+
+func checkVarint[T comparable](t *testing.T, size func(T) int, marshal func(int, []byte, T) int, unmarshal func(int, []byte) (int, T, error), skip func(int, []byte) (int, error), values []T) {
+	t.Helper()
+	for _, v := range values {
+		s := size(v)
+		buf := make([]byte, s)
+		if n := marshal(0, buf, v); n != s {
+			t.Fatalf("%v: expected size %d, got %d", v, s, n)
+		}
+		if err := SkipOnce_Verify(buf, skip); err != nil {
+			t.Fatal(err.Error())
+		}
+		n, ret, err := unmarshal(0, buf)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if n != s || ret != v {
+			t.Fatalf("expected %v (%d bytes), got %v (%d bytes)", v, s, ret, n)
+		}
+	}
+}
+
 func TestEmptyString(t *testing.T) {
 	str := ""
 
@@ -472,6 +1013,31 @@ func getMaxVarintLen() int {
 	return 10
 }
 
+func TestStringValidated(t *testing.T) {
+	for _, str := range []string{"", "benc", "grüße, 世界"} {
+		buf := make([]byte, SizeString(str))
+		MarshalString(0, buf, str)
+		n, got, err := UnmarshalStringValidated(0, buf)
+		if err != nil || n != len(buf) || got != str {
+			t.Fatalf("%q: got %q, %d, %v", str, got, n, err)
+		}
+		if _, _, err = UnmarshalStringValidated(0, buf[:len(buf)-1]); !errors.Is(err, ErrBufTooSmall) {
+			t.Fatalf("%q: expected ErrBufTooSmall, got %v", str, err)
+		}
+	}
+
+	for _, str := range []string{"\xff", "ab\xc3", "\xed\xa0\x80"} {
+		buf := make([]byte, SizeString(str))
+		MarshalString(0, buf, str)
+		if n, _, err := UnmarshalStringValidated(0, buf); !errors.Is(err, ErrInvalidUTF8) || n != 0 {
+			t.Fatalf("%q: expected ErrInvalidUTF8, got %d, %v", str, n, err)
+		}
+		if _, _, err := UnmarshalString(0, buf); err != nil {
+			t.Fatalf("%q: UnmarshalString should not validate, got %v", str, err)
+		}
+	}
+}
+
 func TestSkipVarint(t *testing.T) {
 	maxLen := getMaxVarintLen()
 	overflowEdgeCaseBytes := make([]byte, maxLen)
@@ -560,6 +1126,336 @@ func TestTime(t *testing.T) {
 	}
 }
 
+func TestTimeOptions(t *testing.T) {
+	// time.Now has a monotonic clock reading, which doesn't survive marshalling
+	now := time.Now()
+	for _, precision := range []time.Duration{0, time.Nanosecond, time.Microsecond, time.Millisecond, time.Second} {
+		o := TimeOptions{Precision: precision}
+		buf := make([]byte, SizeTime())
+		if n := o.Marshal(0, buf, now); n != SizeTime() {
+			t.Fatalf("%v: expected offset %d, got %d", precision, SizeTime(), n)
+		}
+		_, got, err := UnmarshalTime(0, buf)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if want := o.Truncate(now); !got.Equal(want) || got.UnixNano()%max(int64(precision), 1) != 0 {
+			t.Fatalf("%v: expected %v, got %v", precision, want, got)
+		}
+		if o.Truncate(now) == now {
+			t.Fatalf("%v: the monotonic clock reading wasn't stripped", precision)
+		}
+
+		zoned := now.In(time.FixedZone("UTC+1", 3600))
+		buf = make([]byte, SizeTimeWithZone(zoned))
+		o.MarshalWithZone(0, buf, zoned)
+		if _, got, err = UnmarshalTimeWithZone(0, buf); err != nil {
+			t.Fatal(err.Error())
+		}
+		if err = CompareTimeWithZone(o.Truncate(zoned), got); err != nil {
+			t.Fatalf("%v: %v", precision, err)
+		}
+	}
+}
+
+func TestDuration(t *testing.T) {
+	d := -(90*time.Minute + 123456789)
+
+	s := SizeDuration()
+	buf := make([]byte, s)
+	MarshalDuration(0, buf, d)
+
+	if err := SkipOnce_Verify(buf, SkipDuration); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	_, retDuration, err := UnmarshalDuration(0, buf)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if retDuration != d {
+		t.Fatalf("no match: \norg %v\ndec %v", d, retDuration)
+	}
+
+	if _, _, err = UnmarshalDuration(0, buf[:s-1]); !errors.Is(err, ErrBufTooSmall) {
+		t.Fatalf("expected ErrBufTooSmall, got %v", err)
+	}
+}
+
+func TestUUID(t *testing.T) {
+	type UUID [16]byte
+	id := UUID{0x12, 0x3e, 0x45, 0x67, 0xe8, 0x9b, 0x12, 0xd3, 0xa4, 0x56, 0x42, 0x66, 0x14, 0x17, 0x40, 0x00}
+
+	s := SizeUUID()
+	buf := make([]byte, s+1)
+	if n := MarshalUUID(1, buf, id); n != s+1 {
+		t.Fatalf("expected offset %d, got %d", s+1, n)
+	}
+
+	if err := SkipOnce_Verify(buf[1:], SkipUUID); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	var ret UUID
+	_, ret, err := UnmarshalUUID(1, buf)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if ret != id {
+		t.Fatalf("no match: \norg %x\ndec %x", id, ret)
+	}
+
+	if _, _, err = UnmarshalUUID(2, buf); !errors.Is(err, ErrBufTooSmall) {
+		t.Fatalf("expected ErrBufTooSmall, got %v", err)
+	}
+	if _, err = SkipUUID(2, buf); !errors.Is(err, ErrBufTooSmall) {
+		t.Fatalf("expected ErrBufTooSmall, got %v", err)
+	}
+}
+
+func TestRawMessage(t *testing.T) {
+	msgs := []json.RawMessage{nil, json.RawMessage(`null`), json.RawMessage(`{"a": [1, 2, "\u00e4"]}`)}
+	for _, msg := range msgs {
+		s := SizeRawMessage(msg)
+		buf := make([]byte, s)
+		if n := MarshalRawMessage(0, buf, msg); n != s {
+			t.Fatalf("%s: expected size %d, got %d", msg, s, n)
+		}
+		if err := SkipOnce_Verify(buf, SkipRawMessage); err != nil {
+			t.Fatal(err.Error())
+		}
+		_, ret, err := UnmarshalRawMessage(0, buf)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if err = CompareRawMessage(msg, ret); err != nil {
+			t.Fatal(err.Error())
+		}
+	}
+}
+
+func TestNetAddrs(t *testing.T) {
+	ips := []net.IP{nil, net.IPv4(10, 0, 0, 1), net.IPv4(10, 0, 0, 1).To4(), net.ParseIP("2001:db8::1")}
+	for _, ip := range ips {
+		s := SizeIP(ip)
+		buf := make([]byte, s)
+		if n := MarshalIP(0, buf, ip); n != s {
+			t.Fatalf("%v: expected size %d, got %d", ip, s, n)
+		}
+		if err := SkipOnce_Verify(buf, SkipIP); err != nil {
+			t.Fatal(err.Error())
+		}
+		_, ret, err := UnmarshalIP(0, buf)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if err = CompareIP(ip, ret); err != nil {
+			t.Fatal(err.Error())
+		}
+	}
+
+	mac, _ := net.ParseMAC("00:00:5e:00:53:01")
+	s := SizeHardwareAddr(mac)
+	buf := make([]byte, s)
+	MarshalHardwareAddr(0, buf, mac)
+	if err := SkipOnce_Verify(buf, SkipHardwareAddr); err != nil {
+		t.Fatal(err.Error())
+	}
+	_, retMac, err := UnmarshalHardwareAddr(0, buf)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if retMac.String() != mac.String() {
+		t.Fatalf("no match: \norg %v\ndec %v", mac, retMac)
+	}
+
+	if _, _, err = UnmarshalHardwareAddr(0, buf[:s-1]); !errors.Is(err, ErrBufTooSmall) {
+		t.Fatalf("expected ErrBufTooSmall, got %v", err)
+	}
+}
+
+func TestNetip(t *testing.T) {
+	addrs := []netip.Addr{
+		{},
+		netip.MustParseAddr("192.168.0.1"),
+		netip.MustParseAddr("2001:db8::1"),
+		netip.MustParseAddr("fe80::1%eth0"),
+		netip.MustParseAddr("::ffff:10.0.0.1"),
+	}
+
+	for _, addr := range addrs {
+		s := SizeAddr(addr)
+		buf := make([]byte, s)
+		if n := MarshalAddr(0, buf, addr); n != s {
+			t.Fatalf("%v: expected size %d, got %d", addr, s, n)
+		}
+		if err := SkipOnce_Verify(buf, SkipAddr); err != nil {
+			t.Fatal(err.Error())
+		}
+		_, ret, err := UnmarshalAddr(0, buf)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if ret != addr {
+			t.Fatalf("no match: \norg %v\ndec %v", addr, ret)
+		}
+
+		ap := netip.AddrPortFrom(addr, 8080)
+		s = SizeAddrPort(ap)
+		buf = make([]byte, s)
+		if n := MarshalAddrPort(0, buf, ap); n != s {
+			t.Fatalf("%v: expected size %d, got %d", ap, s, n)
+		}
+		if err := SkipOnce_Verify(buf, SkipAddrPort); err != nil {
+			t.Fatal(err.Error())
+		}
+		_, retAP, err := UnmarshalAddrPort(0, buf)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if retAP != ap {
+			t.Fatalf("no match: \norg %v\ndec %v", ap, retAP)
+		}
+
+		p := netip.PrefixFrom(addr.WithZone(""), addr.BitLen()/2)
+		s = SizePrefix(p)
+		buf = make([]byte, s)
+		if n := MarshalPrefix(0, buf, p); n != s {
+			t.Fatalf("%v: expected size %d, got %d", p, s, n)
+		}
+		if err := SkipOnce_Verify(buf, SkipPrefix); err != nil {
+			t.Fatal(err.Error())
+		}
+		_, retP, err := UnmarshalPrefix(0, buf)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if retP != p {
+			t.Fatalf("no match: \norg %v\ndec %v", p, retP)
+		}
+	}
+
+	if _, _, err := UnmarshalAddr(0, []byte{3, 1, 2, 3}); err == nil {
+		t.Fatal("expected an error for a 3 byte address")
+	}
+	if _, _, err := UnmarshalAddrPort(0, []byte{6, 1}); !errors.Is(err, ErrBufTooSmall) {
+		t.Fatalf("expected ErrBufTooSmall, got %v", err)
+	}
+}
+
+func TestURL(t *testing.T) {
+	urls := []*url.URL{nil, GenerateURL(rand.New(rand.NewSource(1)), 0)}
+	for _, raw := range []string{"https://user:pw@example.com:8443/a%2Fb?q=1&r=%20#frag", "mailto:someone@example.com", "/relative/path"} {
+		u, err := url.Parse(raw)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		urls = append(urls, u)
+	}
+
+	for _, u := range urls {
+		s := SizeURL(u)
+		buf := make([]byte, s)
+		if n := MarshalURL(0, buf, u); n != s {
+			t.Fatalf("%v: expected size %d, got %d", u, s, n)
+		}
+		if err := SkipOnce_Verify(buf, SkipURL); err != nil {
+			t.Fatal(err.Error())
+		}
+		_, ret, err := UnmarshalURL(0, buf)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if err = CompareURL(u, ret); err != nil {
+			t.Fatal(err.Error())
+		}
+	}
+
+	buf := make([]byte, SizeString(":bad"))
+	MarshalString(0, buf, ":bad")
+	if _, _, err := UnmarshalURL(0, buf); err == nil {
+		t.Fatal("expected a parse error")
+	}
+}
+
+func TestBigNumbers(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	huge, _ := new(big.Int).SetString("-123456789012345678901234567890123456789", 10)
+
+	ints := []*big.Int{nil, new(big.Int), big.NewInt(1), big.NewInt(-256), huge, GenerateBigInt(r, 0)}
+	for _, x := range ints {
+		s := SizeBigInt(x)
+		buf := make([]byte, s)
+		if n := MarshalBigInt(0, buf, x); n != s {
+			t.Fatalf("%v: expected size %d, got %d", x, s, n)
+		}
+
+		if err := SkipOnce_Verify(buf, SkipBigInt); err != nil {
+			t.Fatal(err.Error())
+		}
+
+		_, ret, err := UnmarshalBigInt(0, buf)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if err = CompareBigInt(x, ret); err != nil {
+			t.Fatal(err.Error())
+		}
+
+		if x != nil {
+			// The layout must stay compatible with big.Int.GobDecode.
+			gob, _ := x.GobEncode()
+			if !bytes.Equal(buf[s-len(gob):], gob) {
+				t.Fatalf("%v: expected gob encoding %x, got %x", x, gob, buf)
+			}
+		}
+	}
+
+	floats := []*big.Float{nil, new(big.Float), big.NewFloat(math.Inf(-1)), GenerateBigFloat(r, 0)}
+	for _, x := range floats {
+		buf := make([]byte, SizeBigFloat(x))
+		MarshalBigFloat(0, buf, x)
+
+		if err := SkipOnce_Verify(buf, SkipBigFloat); err != nil {
+			t.Fatal(err.Error())
+		}
+
+		_, ret, err := UnmarshalBigFloat(0, buf)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if err = CompareBigFloat(x, ret); err != nil {
+			t.Fatal(err.Error())
+		}
+	}
+
+	rats := []*big.Rat{nil, new(big.Rat), big.NewRat(-7, 3), GenerateBigRat(r, 0)}
+	for _, x := range rats {
+		buf := make([]byte, SizeBigRat(x))
+		MarshalBigRat(0, buf, x)
+
+		if err := SkipOnce_Verify(buf, SkipBigRat); err != nil {
+			t.Fatal(err.Error())
+		}
+
+		_, ret, err := UnmarshalBigRat(0, buf)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if err = CompareBigRat(x, ret); err != nil {
+			t.Fatal(err.Error())
+		}
+	}
+
+	if _, _, err := UnmarshalBigInt(0, []byte{1, 0xff}); err == nil {
+		t.Fatal("expected an error for an invalid gob version")
+	}
+	if _, _, err := UnmarshalBigFloat(0, []byte{3, 1}); !errors.Is(err, ErrBufTooSmall) {
+		t.Fatalf("expected ErrBufTooSmall, got %v", err)
+	}
+}
+
 func TestPointer(t *testing.T) {
 	t.Run("NonNilPointer", func(t *testing.T) {
 		val := "hello world"
@@ -0,0 +1,83 @@
+package bstd
+
+import (
+	"sync"
+
+	"github.com/banditmoscow1337/benc/wire"
+)
+
+// UnmarshalBytesCropped returns a slice sharing the bytes of the buffer, which changes with
+// it, and UnmarshalBytesCopied allocates a new slice per value. UnmarshalBytesPooled is in
+// between: it copies the bytes into a buffer leased from a BufPool, which the caller hands
+// back with Release once it is done with the value, so decoding many messages neither aliases
+// the input nor allocates per message:
+//
+//	pool := bstd.NewBufPool(1 << 20)
+//	n, payload, err := bstd.UnmarshalBytesPooled(n, b, pool)
+//	...
+//	process(payload.B)
+//	payload.Release()
+
+// BufPool is a pool of byte slices, see NewBufPool. It is safe for concurrent use.
+type BufPool struct {
+	pool   sync.Pool
+	maxCap int
+}
+
+// Returns a BufPool, which doesn't keep buffers larger than 'maxCap' bytes, if 'maxCap' is
+// greater than zero, so a single large value doesn't stay in memory.
+func NewBufPool(maxCap int) *BufPool {
+	return &BufPool{maxCap: maxCap}
+}
+
+// PooledBytes is a byte slice leased from a BufPool.
+type PooledBytes struct {
+	B    []byte
+	pool *BufPool
+}
+
+// Get leases a buffer of 'size' bytes from the pool. Its bytes are not zeroed.
+func (p *BufPool) Get(size int) *PooledBytes {
+	pb, _ := p.pool.Get().(*PooledBytes)
+	if pb == nil {
+		pb = &PooledBytes{}
+	}
+	if cap(pb.B) < size {
+		pb.B = make([]byte, size)
+	}
+	pb.B = pb.B[:size]
+	pb.pool = p
+	return pb
+}
+
+// Release hands the buffer back to its pool. Neither 'pb' nor its bytes may be used
+// afterwards; releasing it again does nothing.
+func (pb *PooledBytes) Release() {
+	p := pb.pool
+	if p == nil {
+		return
+	}
+	pb.pool = nil
+	if p.maxCap > 0 && cap(pb.B) > p.maxCap {
+		pb.B = nil
+	}
+	p.pool.Put(pb)
+}
+
+// Returns the new offset 'n', as well as a copy of the byte slice, that got unmarshalled, in a
+// buffer leased from 'pool'. Release the buffer once the byte slice isn't used anymore.
+//
+// Possible errors returned:
+//   - ErrOverflow          - varint overflowed a N-bit unsigned integer.
+//   - ErrBufTooSmall       - 'buf' was too small to unmarshal the byte slice.
+//
+// If a error is returned, n (the int returned) equals zero ( 0 ).
+func UnmarshalBytesPooled(n int, b []byte, pool *BufPool) (int, *PooledBytes, error) {
+	n, bs, err := wire.UnmarshalBytes(n, b)
+	if err != nil {
+		return 0, nil, err
+	}
+	pb := pool.Get(len(bs))
+	copy(pb.B, bs)
+	return n, pb, nil
+}
@@ -0,0 +1,45 @@
+package bstd
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestUnmarshalBytesPooled(t *testing.T) {
+	bs := []byte("payload")
+	buf := make([]byte, SizeBytes(bs))
+	MarshalBytes(0, buf, bs)
+
+	pool := NewBufPool(16)
+	n, pb, err := UnmarshalBytesPooled(0, buf, pool)
+	if err != nil || n != len(buf) || !bytes.Equal(pb.B, bs) {
+		t.Fatalf("expected %q, got %q, %d, %v", bs, pb.B, n, err)
+	}
+	// the value doesn't share the bytes of the buffer
+	buf[len(buf)-1] = 'X'
+	if !bytes.Equal(pb.B, bs) {
+		t.Fatalf("expected a copy of %q, got %q", bs, pb.B)
+	}
+	pb.Release()
+	pb.Release()
+
+	MarshalBytes(0, buf, bs)
+	if allocs := testing.AllocsPerRun(100, func() {
+		_, pb, _ := UnmarshalBytesPooled(0, buf, pool)
+		pb.Release()
+	}); allocs != 0 {
+		t.Fatalf("expected no allocations, got %v", allocs)
+	}
+
+	// buffers larger than the maximum capacity aren't kept
+	large := pool.Get(32)
+	large.Release()
+	if large.B != nil {
+		t.Fatalf("expected the buffer of %d bytes to be dropped", cap(large.B))
+	}
+
+	if _, _, err = UnmarshalBytesPooled(0, buf[:len(buf)-1], pool); !errors.Is(err, ErrBufTooSmall) {
+		t.Fatalf("expected ErrBufTooSmall, got %v", err)
+	}
+}
@@ -0,0 +1,54 @@
+package bstd
+
+import (
+	"bytes"
+	"slices"
+)
+
+// The canonical mode marshals identical values to identical bytes, in every version of benc,
+// so the bytes can be hashed or signed. The encoding is the same as the one of the default mode,
+// which has a single encoding for every value already: varints and length prefixes are as short
+// as possible, and unmarshalling never shares bytes between the values. The only values without
+// a fixed order are maps, which MarshalMapCanonical and MarshalVariantCanonical sort. The go
+// generator uses them with -go-canonical.
+
+// Returns the new offset 'n' after marshalling the map, its entries sorted by the marshalled
+// bytes of their keys, see the canonical mode. The map is sized with SizeMap and unmarshalled
+// with UnmarshalMap, like one marshalled by MarshalMap.
+//
+// 'kMarshaler' and 'vMarshaler' must not depend on the entries marshalled before, as e.g. the
+// string dictionary does, since the entries are reordered after marshalling them.
+//
+// !- Panics, if 'b' is too small.
+func MarshalMapCanonical[K comparable, V any](n int, b []byte, m map[K]V, kMarshaler MarshalFunc[K], vMarshaler MarshalFunc[V]) int {
+	kMarshaler, vMarshaler = mapMarshalers(kMarshaler, vMarshaler)
+	n = MarshalUint(n, b, uint(len(m)))
+
+	// the offsets of the key, the value and the end of every entry
+	type entry struct{ k, v, end int }
+	entries := make([]entry, 0, len(m))
+	start := n
+	for k, v := range m {
+		e := entry{k: n}
+		e.v = kMarshaler(n, b, k)
+		e.end = vMarshaler(e.v, b, v)
+		entries = append(entries, e)
+		n = e.end
+	}
+	if len(entries) > 1 {
+		slices.SortFunc(entries, func(x, y entry) int { return bytes.Compare(b[x.k:x.v], b[y.k:y.v]) })
+		marshalled := bytes.Clone(b[start:n])
+		w := start
+		for _, e := range entries {
+			w += copy(b[w:], marshalled[e.k-start:e.end-start])
+		}
+	}
+
+	u := b[n : n+4]
+	_ = u[3]
+	u[0] = byte(1)
+	u[1] = byte(1)
+	u[2] = byte(1)
+	u[3] = byte(1)
+	return n + 4
+}
@@ -0,0 +1,52 @@
+package bstd
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestMarshalMapCanonical(t *testing.T) {
+	m := map[string]int32{"b": 2, "a": 1, "ab": 3, "": 4}
+	s := SizeMap(m, SizeString, SizeInt32)
+	expected := []byte{
+		4,
+		0, 4, 0, 0, 0,
+		1, 'a', 1, 0, 0, 0,
+		1, 'b', 2, 0, 0, 0,
+		2, 'a', 'b', 3, 0, 0, 0,
+		1, 1, 1, 1,
+	}
+	// the map iterates in a different order every time
+	for range 20 {
+		buf := make([]byte, s+1)
+		if n := MarshalMapCanonical(1, buf, m, MarshalString, MarshalInt32); n != len(buf) {
+			t.Fatalf("expected offset %d, got %d", len(buf), n)
+		}
+		if !bytes.Equal(buf[1:], expected) {
+			t.Fatalf("expected % x, got % x", expected, buf[1:])
+		}
+		n, got, err := UnmarshalMap[string, int32](1, buf, UnmarshalString, UnmarshalInt32)
+		if err != nil || n != len(buf) || !reflect.DeepEqual(got, m) {
+			t.Fatalf("got %v, %d, %v", got, n, err)
+		}
+	}
+}
+
+func TestMarshalVariantCanonical(t *testing.T) {
+	v := map[string]any{"z": []any{map[string]any{"y": nil, "x": true}}, "a": int64(1)}
+	expected := []byte{
+		VariantMap, 2,
+		1, 'a', VariantInt, 2,
+		1, 'z', VariantList, 1, VariantMap, 2,
+		1, 'x', VariantTrue,
+		1, 'y', VariantNil,
+	}
+	for range 20 {
+		buf := make([]byte, SizeVariant(v))
+		MarshalVariantCanonical(0, buf, v)
+		if !bytes.Equal(buf, expected) {
+			t.Fatalf("expected % x, got % x", expected, buf)
+		}
+	}
+}
@@ -0,0 +1,229 @@
+package bstd
+
+import (
+	"errors"
+	"io"
+)
+
+// The chunked encoding of a byte slice is a sequence of chunks, each prefixed with its length
+// as varint, ended by a chunk of length zero. Unlike MarshalBytes, it needs no length up front,
+// so a ChunkWriter streams a blob of any size, e.g. multiple gigabytes, while it is produced,
+// and a ChunkReader reads it back, neither holding more than a chunk in memory.
+
+var errClosedChunkWriter = errors.New("write to a closed ChunkWriter")
+
+// Returns the bytes needed to marshal the byte slice 'bs' in chunks of 'chunkSize' bytes.
+//
+// !- Panics, if 'chunkSize' isn't positive.
+func SizeBytesChunked(bs []byte, chunkSize int) int {
+	if chunkSize <= 0 {
+		panic("benc: invalid `chunkSize` provided in `SizeBytesChunked`")
+	}
+	full := len(bs) / chunkSize
+	s := full*(SizeUint(uint(chunkSize))+chunkSize) + 1
+	if rest := len(bs) % chunkSize; rest > 0 {
+		s += SizeUint(uint(rest)) + rest
+	}
+	return s
+}
+
+// Returns the new offset 'n' after marshalling the byte slice 'bs' in chunks of 'chunkSize' bytes.
+//
+// !- Panics, if 'b' is too small or 'chunkSize' isn't positive.
+func MarshalBytesChunked(n int, b []byte, bs []byte, chunkSize int) int {
+	if chunkSize <= 0 {
+		panic("benc: invalid `chunkSize` provided in `MarshalBytesChunked`")
+	}
+	for len(bs) > 0 {
+		chunk := bs[:min(chunkSize, len(bs))]
+		n = MarshalBytes(n, b, chunk)
+		bs = bs[len(chunk):]
+	}
+	return MarshalUint(n, b, 0)
+}
+
+// Returns the new offset 'n' after skipping the chunked byte slice.
+//
+// Possible errors returned:
+//   - ErrOverflow          - varint overflowed a N-bit unsigned integer.
+//   - ErrBufTooSmall       - 'b' was too small to skip the chunked byte slice.
+//
+// If a error is returned, n (the int returned) equals zero ( 0 ).
+func SkipBytesChunked(n int, b []byte) (int, error) {
+	n, _, err := skipChunks(n, b)
+	return n, err
+}
+
+// Returns the new offset 'n', as well as the chunked byte slice, that got unmarshalled.
+// The chunks are copied into a single slice.
+//
+// Possible errors returned:
+//   - ErrOverflow          - varint overflowed a N-bit unsigned integer.
+//   - ErrBufTooSmall       - 'b' was too small to unmarshal the chunked byte slice.
+//
+// If a error is returned, n (the int returned) equals zero ( 0 ).
+func UnmarshalBytesChunked(n int, b []byte) (int, []byte, error) {
+	end, size, err := skipChunks(n, b)
+	if err != nil {
+		return 0, nil, err
+	}
+	bs := make([]byte, 0, size)
+	for {
+		var chunk []byte
+		// the chunks are valid, see skipChunks
+		n, chunk, _ = UnmarshalBytesCropped(n, b)
+		if len(chunk) == 0 {
+			return end, bs, nil
+		}
+		bs = append(bs, chunk...)
+	}
+}
+
+// skipChunks returns the offset after the chunked byte slice at 'n' and its length.
+func skipChunks(n int, b []byte) (int, int, error) {
+	size := 0
+	for {
+		var chunk []byte
+		var err error
+		if n, chunk, err = UnmarshalBytesCropped(n, b); err != nil {
+			return 0, 0, err
+		}
+		if len(chunk) == 0 {
+			return n, size, nil
+		}
+		size += len(chunk)
+	}
+}
+
+// ChunkWriter writes a byte slice in the chunked encoding to an io.Writer, see NewChunkWriter.
+type ChunkWriter struct {
+	w     io.Writer
+	chunk []byte
+	err   error
+}
+
+// Returns a ChunkWriter writing chunks of 'chunkSize' bytes to 'w'. The bytes written to it
+// are the byte slice, which Close ends.
+//
+// !- Panics, if 'chunkSize' isn't positive.
+func NewChunkWriter(w io.Writer, chunkSize int) *ChunkWriter {
+	if chunkSize <= 0 {
+		panic("benc: invalid `chunkSize` provided in `NewChunkWriter`")
+	}
+	return &ChunkWriter{w: w, chunk: make([]byte, 0, chunkSize)}
+}
+
+// Write appends the bytes of 'p' to the byte slice, writing every chunk filled. After an error,
+// every call returns it.
+func (cw *ChunkWriter) Write(p []byte) (int, error) {
+	written := 0
+	for cw.err == nil && len(p) > 0 {
+		// full chunks of 'p' are written without copying them
+		if len(cw.chunk) == 0 && len(p) >= cap(cw.chunk) {
+			cw.err = cw.writeChunk(p[:cap(cw.chunk)])
+			if cw.err == nil {
+				written += cap(cw.chunk)
+				p = p[cap(cw.chunk):]
+			}
+			continue
+		}
+		c := copy(cw.chunk[len(cw.chunk):cap(cw.chunk)], p)
+		cw.chunk = cw.chunk[:len(cw.chunk)+c]
+		written += c
+		p = p[c:]
+		if len(cw.chunk) == cap(cw.chunk) {
+			cw.err = cw.writeChunk(cw.chunk)
+			cw.chunk = cw.chunk[:0]
+		}
+	}
+	return written, cw.err
+}
+
+// Reset discards the bytes of the unfinished chunk and any error, and makes the ChunkWriter
+// write a new byte slice to 'w', keeping the buffer of its chunk size.
+func (cw *ChunkWriter) Reset(w io.Writer) {
+	cw.w = w
+	cw.chunk = cw.chunk[:0]
+	cw.err = nil
+}
+
+// Close writes the last chunk and the terminator, which ends the byte slice. It doesn't close
+// the io.Writer.
+func (cw *ChunkWriter) Close() error {
+	if cw.err != nil {
+		return cw.err
+	}
+	if len(cw.chunk) > 0 {
+		if cw.err = cw.writeChunk(cw.chunk); cw.err != nil {
+			return cw.err
+		}
+		cw.chunk = cw.chunk[:0]
+	}
+	if _, cw.err = cw.w.Write([]byte{0}); cw.err != nil {
+		return cw.err
+	}
+	cw.err = errClosedChunkWriter
+	return nil
+}
+
+// writeChunk writes 'chunk' with its length prefix.
+func (cw *ChunkWriter) writeChunk(chunk []byte) error {
+	_, err := MarshalBytesTo(cw.w, chunk)
+	return err
+}
+
+// ChunkReader reads a byte slice in the chunked encoding from a stream, see NewChunkReader.
+type ChunkReader struct {
+	r FrameReader
+	// left are the bytes left of the current chunk
+	left uint
+	done bool
+}
+
+// Returns a ChunkReader reading a chunked byte slice from 'r', e.g. a *bufio.Reader. It reads
+// no byte after the terminator of the slice, so 'r' continues with the value after it.
+func NewChunkReader(r FrameReader) *ChunkReader {
+	return &ChunkReader{r: r}
+}
+
+// Reset makes the ChunkReader read a new chunked byte slice from 'r'.
+func (cr *ChunkReader) Reset(r FrameReader) {
+	*cr = ChunkReader{r: r}
+}
+
+// Read reads the bytes of the byte slice into 'p', returning io.EOF at its end.
+//
+// Possible errors returned:
+//   - io.EOF               - the byte slice ended.
+//   - io.ErrUnexpectedEOF  - 'r' ended in the middle of the byte slice.
+//   - ErrOverflow          - the length of a chunk overflowed a 64-bit unsigned integer.
+//   - any other error of 'r'
+func (cr *ChunkReader) Read(p []byte) (int, error) {
+	if cr.done {
+		return 0, io.EOF
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+	if cr.left == 0 {
+		left, err := readUint(cr.r)
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return 0, err
+		}
+		if left == 0 {
+			cr.done = true
+			return 0, io.EOF
+		}
+		cr.left = left
+	}
+
+	n, err := cr.r.Read(p[:min(uint(len(p)), cr.left)])
+	cr.left -= uint(n)
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return n, err
+}
@@ -0,0 +1,67 @@
+package bstd
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"testing"
+	"testing/iotest"
+)
+
+func TestBytesChunked(t *testing.T) {
+	for _, size := range []int{0, 1, 99, 100, 101, 350} {
+		bs := make([]byte, size)
+		for i := range bs {
+			bs[i] = byte(i)
+		}
+		s := SizeBytesChunked(bs, 100)
+		buf := make([]byte, s)
+		if n := MarshalBytesChunked(0, buf, bs, 100); n != s {
+			t.Fatalf("%d bytes: expected offset %d, got %d", size, s, n)
+		}
+		n, got, err := UnmarshalBytesChunked(0, buf)
+		if err != nil || n != s || !bytes.Equal(got, bs) {
+			t.Fatalf("%d bytes: got %d, %v", size, n, err)
+		}
+		if n, err = SkipBytesChunked(0, buf); err != nil || n != s {
+			t.Fatalf("%d bytes: skip got %d, %v", size, n, err)
+		}
+		for i := range s {
+			if n, _, err := UnmarshalBytesChunked(0, buf[:i]); err == nil || n != 0 {
+				t.Fatalf("%d bytes: expected an error on %d bytes, got %d", size, i, n)
+			}
+		}
+
+		// the streamed encoding equals the marshalled one, however the bytes are written
+		var stream bytes.Buffer
+		cw := NewChunkWriter(&stream, 100)
+		for i := 0; i < size; i += 70 {
+			if _, err = cw.Write(bs[i:min(i+70, size)]); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err = cw.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(stream.Bytes(), buf) {
+			t.Fatalf("%d bytes: expected % x, got % x", size, buf, stream.Bytes())
+		}
+		if _, err = cw.Write([]byte{1}); err == nil {
+			t.Fatal("expected an error writing to a closed ChunkWriter")
+		}
+
+		stream.WriteByte(42)
+		r := bufio.NewReader(iotest.HalfReader(&stream))
+		if got, err = io.ReadAll(NewChunkReader(r)); err != nil || !bytes.Equal(got, bs) {
+			t.Fatalf("%d bytes: read %d bytes, %v", size, len(got), err)
+		}
+		if next, err := r.ReadByte(); err != nil || next != 42 {
+			t.Fatalf("%d bytes: expected the byte after the slice, got %d, %v", size, next, err)
+		}
+	}
+
+	r := bufio.NewReader(bytes.NewReader([]byte{3, 1, 2}))
+	if _, err := io.ReadAll(NewChunkReader(r)); err != io.ErrUnexpectedEOF {
+		t.Fatalf("expected io.ErrUnexpectedEOF, got %v", err)
+	}
+}
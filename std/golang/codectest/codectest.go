@@ -0,0 +1,187 @@
+// Package codectest checks that the Size, Marshal, Unmarshal and Skip functions of a type
+// follow the conventions of benc, for custom codecs and third-party backends.
+//
+//	func TestPoint(t *testing.T) {
+//		codectest.Run(t, codectest.MessageCodec[Point](), Point{}, Point{X: -1, Y: 7})
+//	}
+package codectest
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	bstd "github.com/banditmoscow1337/benc/std/golang"
+)
+
+// Codec holds the functions of a type T, in the form of the bstd functions, e.g. bstd.SizeString.
+type Codec[T any] struct {
+	Size      func(v T) int
+	Marshal   func(n int, b []byte, v T) int
+	Unmarshal func(n int, b []byte) (int, T, error)
+	// Skip is optional, it is only checked if set.
+	Skip func(n int, b []byte) (int, error)
+	// Compare returns an error if the unmarshalled value differs from the marshalled one.
+	// Values are compared by reflect.DeepEqual if Compare is nil.
+	Compare func(want, got T) error
+}
+
+// MessageCodec returns the Codec of a type whose pointer implements bstd.Message, like the generated structs.
+func MessageCodec[T any, PT bstd.Message[T]]() Codec[T] {
+	return Codec[T]{
+		Size:      bstd.SizeMessage[T, PT],
+		Marshal:   bstd.MarshalMessage[T, PT],
+		Unmarshal: bstd.UnmarshalMessage[T, PT],
+	}
+}
+
+// Run runs RoundTrip, Truncated and Corrupted for every value as subtests of 't'.
+func Run[T any](t *testing.T, c Codec[T], values ...T) {
+	t.Helper()
+	for i, v := range values {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			if err := RoundTrip(c, v); err != nil {
+				t.Fatalf("round trip of %v: %v", v, err)
+			}
+			if err := Truncated(c, v); err != nil {
+				t.Errorf("truncated %v: %v", v, err)
+			}
+			if err := Corrupted(c, v); err != nil {
+				t.Errorf("corrupted %v: %v", v, err)
+			}
+		})
+	}
+}
+
+// RoundTrip checks that 'v' marshals into exactly Size bytes, also at an offset without
+// touching the bytes around it, and that Unmarshal and Skip consume those bytes and
+// Unmarshal returns 'v' again.
+func RoundTrip[T any](c Codec[T], v T) error {
+	const pad = 3
+	s := c.Size(v)
+	b := bytes.Repeat([]byte{0xa5}, pad+s+pad)
+
+	var n int
+	if err := catch(func() { n = c.Marshal(pad, b, v) }); err != nil {
+		return fmt.Errorf("marshal: %w", err)
+	}
+	if n != pad+s {
+		return fmt.Errorf("marshal wrote %d bytes, Size returned %d", n-pad, s)
+	}
+	if !bytes.Equal(b[:pad], bytes.Repeat([]byte{0xa5}, pad)) || !bytes.Equal(b[pad+s:], bytes.Repeat([]byte{0xa5}, pad)) {
+		return errors.New("marshal wrote outside of its Size bytes")
+	}
+
+	var got T
+	var err error
+	if perr := catch(func() { n, got, err = c.Unmarshal(pad, b) }); perr != nil {
+		return fmt.Errorf("unmarshal: %w", perr)
+	}
+	if err != nil {
+		return fmt.Errorf("unmarshal: %w", err)
+	}
+	if n != pad+s {
+		return fmt.Errorf("unmarshal read %d bytes, marshal wrote %d", n-pad, s)
+	}
+	if err = compare(c, v, got); err != nil {
+		return fmt.Errorf("unmarshalled value differs: %w", err)
+	}
+
+	if c.Skip != nil {
+		if perr := catch(func() { n, err = c.Skip(pad, b) }); perr != nil {
+			return fmt.Errorf("skip: %w", perr)
+		}
+		if err != nil {
+			return fmt.Errorf("skip: %w", err)
+		}
+		if n != pad+s {
+			return fmt.Errorf("skip skipped %d bytes, marshal wrote %d", n-pad, s)
+		}
+	}
+	return nil
+}
+
+// Truncated checks that Unmarshal and Skip return bstd.ErrBufTooSmall, instead of panicking
+// or succeeding, for every prefix of the marshalled 'v' that is too short.
+func Truncated[T any](c Codec[T], v T) error {
+	b := make([]byte, c.Size(v))
+	c.Marshal(0, b, v)
+
+	for i := range len(b) {
+		var err error
+		if perr := catch(func() { _, _, err = c.Unmarshal(0, b[:i]) }); perr != nil {
+			return fmt.Errorf("unmarshal of %d/%d bytes: %w", i, len(b), perr)
+		}
+		if !errors.Is(err, bstd.ErrBufTooSmall) {
+			return fmt.Errorf("unmarshal of %d/%d bytes: expected ErrBufTooSmall, got %v", i, len(b), err)
+		}
+		if c.Skip == nil {
+			continue
+		}
+		if perr := catch(func() { _, err = c.Skip(0, b[:i]) }); perr != nil {
+			return fmt.Errorf("skip of %d/%d bytes: %w", i, len(b), perr)
+		}
+		if !errors.Is(err, bstd.ErrBufTooSmall) {
+			return fmt.Errorf("skip of %d/%d bytes: expected ErrBufTooSmall, got %v", i, len(b), err)
+		}
+	}
+	return nil
+}
+
+// Corrupted flips every bit of the marshalled 'v', one at a time, and checks that Unmarshal
+// and Skip neither panic nor report an offset outside of the buffer. Whether they fail is
+// up to the codec, many corruptions are valid data.
+//
+// The length prefixes of bstd are not bounded, so a flipped bit may make Unmarshal allocate
+// a large slice or map before it runs out of data.
+func Corrupted[T any](c Codec[T], v T) error {
+	b := make([]byte, c.Size(v))
+	c.Marshal(0, b, v)
+
+	for bit := range len(b) * 8 {
+		b[bit/8] ^= 1 << (bit % 8)
+
+		var n int
+		var err error
+		if perr := catch(func() { n, _, err = c.Unmarshal(0, b) }); perr != nil {
+			return fmt.Errorf("unmarshal with bit %d flipped: %w", bit, perr)
+		}
+		if err == nil && n > len(b) {
+			return fmt.Errorf("unmarshal with bit %d flipped: offset %d is outside of %d bytes", bit, n, len(b))
+		}
+		if c.Skip != nil {
+			if perr := catch(func() { n, err = c.Skip(0, b) }); perr != nil {
+				return fmt.Errorf("skip with bit %d flipped: %w", bit, perr)
+			}
+			if err == nil && n > len(b) {
+				return fmt.Errorf("skip with bit %d flipped: offset %d is outside of %d bytes", bit, n, len(b))
+			}
+		}
+
+		b[bit/8] ^= 1 << (bit % 8)
+	}
+	return nil
+}
+
+func compare[T any](c Codec[T], want, got T) error {
+	if c.Compare != nil {
+		return c.Compare(want, got)
+	}
+	if !reflect.DeepEqual(want, got) {
+		return fmt.Errorf("expected %v, got %v", want, got)
+	}
+	return nil
+}
+
+// catch runs f and returns its panic as error.
+func catch(f func()) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	f()
+	return nil
+}
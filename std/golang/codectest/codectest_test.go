@@ -0,0 +1,115 @@
+package codectest
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	bstd "github.com/banditmoscow1337/benc/std/golang"
+)
+
+type point struct{ X, Y int32 }
+
+func (p *point) Size() int { return 2 * bstd.SizeInt32() }
+
+func (p *point) Marshal(n int, b []byte) int {
+	n = bstd.MarshalInt32(n, b, p.X)
+	return bstd.MarshalInt32(n, b, p.Y)
+}
+
+func (p *point) Unmarshal(n int, b []byte) (int, error) {
+	n, x, err := bstd.UnmarshalInt32(n, b)
+	if err != nil {
+		return 0, err
+	}
+	n, y, err := bstd.UnmarshalInt32(n, b)
+	if err != nil {
+		return 0, err
+	}
+	p.X, p.Y = x, y
+	return n, nil
+}
+
+func skipPoint(n int, b []byte) (int, error) {
+	return bstd.SkipStructOf(bstd.SkipInt32, bstd.SkipInt32)(n, b)
+}
+
+func TestBstd(t *testing.T) {
+	Run(t, Codec[string]{
+		Size: bstd.SizeString, Marshal: bstd.MarshalString, Unmarshal: bstd.UnmarshalString, Skip: bstd.SkipString,
+	}, "", "benc", strings.Repeat("x", 200))
+
+	Run(t, Codec[int64]{
+		Size: bstd.SizeInt64Varint, Marshal: bstd.MarshalInt64Varint, Unmarshal: bstd.UnmarshalInt64Varint, Skip: bstd.SkipInt64Varint,
+	}, 0, -1, math.MaxInt64, math.MinInt64)
+
+	Run(t, Codec[[]string]{
+		Size:    func(v []string) int { return bstd.SizeSlice(v, bstd.SizeString) },
+		Marshal: func(n int, b []byte, v []string) int { return bstd.MarshalSlice(n, b, v, bstd.MarshalString) },
+		Unmarshal: func(n int, b []byte) (int, []string, error) {
+			return bstd.UnmarshalSlice[string](n, b, bstd.UnmarshalString)
+		},
+		Skip: bstd.SkipSliceOf(bstd.SkipString),
+	}, []string{}, []string{"a", "", "bc"})
+
+	Run(t, Codec[map[point]string]{
+		Size: func(v map[point]string) int { return bstd.SizeMap(v, bstd.SizeMessage[point], bstd.SizeString) },
+		Marshal: func(n int, b []byte, v map[point]string) int {
+			return bstd.MarshalMap(n, b, v, bstd.MarshalMessage[point], bstd.MarshalString)
+		},
+		Unmarshal: func(n int, b []byte) (int, map[point]string, error) {
+			return bstd.UnmarshalMap[point, string](n, b, bstd.UnmarshalMessage[point], bstd.UnmarshalString)
+		},
+		Skip: bstd.SkipMapOf(skipPoint, bstd.SkipString),
+	}, map[point]string{}, map[point]string{{1, 2}: "a"})
+
+	Run(t, MessageCodec[point](), point{}, point{X: -1, Y: math.MaxInt32})
+}
+
+func TestDetectsBrokenCodecs(t *testing.T) {
+	c := Codec[string]{Size: bstd.SizeString, Marshal: bstd.MarshalString, Unmarshal: bstd.UnmarshalString, Skip: bstd.SkipString}
+
+	wrongSize := c
+	wrongSize.Size = func(v string) int { return bstd.SizeString(v) + 1 }
+	if err := RoundTrip(wrongSize, "abc"); err == nil || !strings.Contains(err.Error(), "Size returned") {
+		t.Errorf("expected a size mismatch, got %v", err)
+	}
+
+	outside := c
+	outside.Marshal = func(n int, b []byte, v string) int {
+		b[n+bstd.SizeString(v)] = 0
+		return bstd.MarshalString(n, b, v)
+	}
+	if err := RoundTrip(outside, "abc"); err == nil || !strings.Contains(err.Error(), "outside") {
+		t.Errorf("expected a write outside of the value, got %v", err)
+	}
+
+	lossy := c
+	lossy.Unmarshal = func(n int, b []byte) (int, string, error) {
+		n, v, err := bstd.UnmarshalString(n, b)
+		return n, strings.ToUpper(v), err
+	}
+	if err := RoundTrip(lossy, "abc"); err == nil || !strings.Contains(err.Error(), "differs") {
+		t.Errorf("expected a different value, got %v", err)
+	}
+
+	shortSkip := c
+	shortSkip.Skip = bstd.SkipByte
+	if err := RoundTrip(shortSkip, "abc"); err == nil || !strings.Contains(err.Error(), "skip skipped") {
+		t.Errorf("expected a skip mismatch, got %v", err)
+	}
+
+	panicking := c
+	panicking.Unmarshal = func(n int, b []byte) (int, string, error) {
+		return n + 1 + int(b[n]), string(b[n+1 : n+1+int(b[n])]), nil
+	}
+	if err := Truncated(panicking, "abc"); err == nil || !strings.Contains(err.Error(), "panic") {
+		t.Errorf("expected a panic, got %v", err)
+	}
+
+	overreading := c
+	overreading.Skip = func(n int, b []byte) (int, error) { return n + 1 + int(b[n]&0x7f), nil }
+	if err := Corrupted(overreading, "abc"); err == nil || !strings.Contains(err.Error(), "outside") {
+		t.Errorf("expected an offset outside of the buffer, got %v", err)
+	}
+}
@@ -0,0 +1,7 @@
+//go:build benc_experimental
+
+package bstd
+
+func init() {
+	decoders = append(decoders, decoder{"BFloat16", SkipBFloat16, u(UnmarshalBFloat16)})
+}
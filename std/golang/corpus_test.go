@@ -0,0 +1,286 @@
+package bstd
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+// decoder is a Skip function and the Unmarshal function of the same type, with the value
+// dropped. Either may be nil.
+type decoder struct {
+	name      string
+	skip      SkipFunc
+	unmarshal SkipFunc
+}
+
+// u drops the value of an Unmarshal function.
+func u[T any](unmarshal func(n int, b []byte) (int, T, error)) SkipFunc {
+	return func(n int, b []byte) (int, error) {
+		n, _, err := unmarshal(n, b)
+		return n, err
+	}
+}
+
+var decoders = []decoder{
+	{"String", SkipString, u(UnmarshalString)},
+	{"StringValidated", SkipString, u(UnmarshalStringValidated)},
+	{"UnsafeString", SkipString, u(UnmarshalUnsafeString)},
+	{"StringCopied", SkipString, u(UnmarshalStringCopied)},
+	{"StringCropped", SkipString, u(UnmarshalStringCropped)},
+	{"StringNul", SkipStringNul, u(UnmarshalStringNul)},
+	{"Byte", SkipByte, u(UnmarshalByte)},
+	{"BytesCopied", SkipBytes, u(UnmarshalBytesCopied)},
+	{"BytesCropped", SkipBytes, u(UnmarshalBytesCropped)},
+	{"BytesChunked", SkipBytesChunked, u(UnmarshalBytesChunked)},
+	{"BytesPooled", SkipBytes, func(n int, b []byte) (int, error) {
+		n, pb, err := UnmarshalBytesPooled(n, b, corpusPool)
+		if err == nil {
+			pb.Release()
+		}
+		return n, err
+	}},
+	{"Bool", SkipBool, u(UnmarshalBool)},
+	{"Int", SkipVarint, u(UnmarshalInt)},
+	{"Uint", SkipUint, u(UnmarshalUint)},
+	{"Uintptr", SkipUintptr, u(UnmarshalUintptr)},
+	{"Int8", SkipInt8, u(UnmarshalInt8)},
+	{"Int16", SkipInt16, u(UnmarshalInt16)},
+	{"Int32", SkipInt32, u(UnmarshalInt32)},
+	{"Int64", SkipInt64, u(UnmarshalInt64)},
+	{"Uint16", SkipUint16, u(UnmarshalUint16)},
+	{"Uint32", SkipUint32, u(UnmarshalUint32)},
+	{"Uint64", SkipUint64, u(UnmarshalUint64)},
+	{"Int16Varint", SkipInt16Varint, u(UnmarshalInt16Varint)},
+	{"Int32Varint", SkipInt32Varint, u(UnmarshalInt32Varint)},
+	{"Int64Varint", SkipInt64Varint, u(UnmarshalInt64Varint)},
+	{"Uint16Varint", SkipUint16Varint, u(UnmarshalUint16Varint)},
+	{"Uint32Varint", SkipUint32Varint, u(UnmarshalUint32Varint)},
+	{"Uint64Varint", SkipUint64Varint, u(UnmarshalUint64Varint)},
+	{"Float16", SkipFloat16, u(UnmarshalFloat16)},
+	{"Float32", SkipFloat32, u(UnmarshalFloat32)},
+	{"Float64", SkipFloat64, u(UnmarshalFloat64)},
+	{"Time", SkipTime, u(UnmarshalTime)},
+	{"TimeWithZone", SkipTimeWithZone, u(UnmarshalTimeWithZone)},
+	{"TimeSeconds", SkipTimeSeconds, u(UnmarshalTimeSeconds)},
+	{"TimeMillis", SkipTimeMillis, u(UnmarshalTimeMillis)},
+	{"TimeMicros", SkipTimeMicros, u(UnmarshalTimeMicros)},
+	{"Duration", SkipDuration, u(UnmarshalDuration)},
+	{"UUID", SkipUUID, u(UnmarshalUUID)},
+	{"IP", SkipIP, u(UnmarshalIP)},
+	{"HardwareAddr", SkipHardwareAddr, u(UnmarshalHardwareAddr)},
+	{"RawMessage", SkipRawMessage, u(UnmarshalRawMessage)},
+	{"Addr", SkipAddr, u(UnmarshalAddr)},
+	{"AddrPort", SkipAddrPort, u(UnmarshalAddrPort)},
+	{"Prefix", SkipPrefix, u(UnmarshalPrefix)},
+	{"URL", SkipURL, u(UnmarshalURL)},
+	{"Handshake", SkipFixed(SizeHandshake()), u(UnmarshalHandshake)},
+	{"SchemaHeader", SkipSchemaHeader, u(UnmarshalSchemaHeader)},
+	{"BigInt", SkipBigInt, u(UnmarshalBigInt)},
+	{"BigFloat", SkipBigFloat, u(UnmarshalBigFloat)},
+	{"BigRat", SkipBigRat, u(UnmarshalBigRat)},
+	{"Decimal", SkipDecimal, u(UnmarshalDecimal)},
+	{"BigDecimal", SkipBigDecimal, u(func(n int, b []byte) (int, *big.Int, error) {
+		return UnmarshalBigDecimal(n, b, func(coefficient *big.Int, _ int32) *big.Int { return coefficient })
+	})},
+	{"NullString", SkipNullString, u(UnmarshalNullString)},
+	{"NullInt64", SkipNullInt64, u(UnmarshalNullInt64)},
+	{"NullInt32", SkipNullInt32, u(UnmarshalNullInt32)},
+	{"NullInt16", SkipNullInt16, u(UnmarshalNullInt16)},
+	{"NullByte", SkipNullByte, u(UnmarshalNullByte)},
+	{"NullFloat64", SkipNullFloat64, u(UnmarshalNullFloat64)},
+	{"NullBool", SkipNullBool, u(UnmarshalNullBool)},
+	{"NullTime", SkipNullTime, u(UnmarshalNullTime)},
+	{"Variant", SkipVariant, u(UnmarshalVariant)},
+	{"Any", SkipAny, nil},
+	{"Bitset", SkipBitset, u(UnmarshalBitset)},
+	{"EnumByte", SkipEnum[corpusColor], u(UnmarshalEnum[corpusColor])},
+	{"EnumInt32", SkipEnum[corpusLevel], u(UnmarshalEnum[corpusLevel])},
+	{"EnumTable", SkipVarint, u(corpusTable.Unmarshal)},
+	{"Flags", SkipFlags, func(n int, b []byte) (int, error) {
+		var flags [8]bool
+		return UnmarshalFlags(n, b, &flags[0], &flags[1], &flags[2], &flags[3], &flags[4], &flags[5], &flags[6], &flags[7])
+	}},
+	{"Symbol", SkipSymbol, u((&Symbols{Strings: []string{"a", "bc"}}).UnmarshalSymbol)},
+	{"Union", SkipUnionOf(map[uint8]SkipFunc{1: SkipString, 2: SkipInt32}), func(n int, b []byte) (int, error) {
+		n, _, _, err := UnmarshalUnion(n, b, map[uint8]func(n int, b []byte) (int, any, error){
+			1: func(n int, b []byte) (int, any, error) { return UnmarshalString(n, b) },
+			2: func(n int, b []byte) (int, any, error) { return UnmarshalInt32(n, b) },
+		})
+		return n, err
+	}},
+	{"StringDict", SkipStringDict, u(func(n int, b []byte) (int, string, error) {
+		return UnmarshalStringDict(n, b, &StringDict{})
+	})},
+	{"SliceGorilla", SkipSliceGorilla, u(UnmarshalSliceGorilla)},
+	{"SliceDelta", SkipSliceDelta, u(UnmarshalSliceDelta[int64])},
+	{"SliceRLE", SkipSliceRLEOf(SkipInt32), u(func(n int, b []byte) (int, []int32, error) {
+		return UnmarshalSliceRLE(n, b, UnmarshalInt32)
+	})},
+	{"SliceRLELimited", SkipSliceRLEOf(SkipInt32), u(func(n int, b []byte) (int, []int32, error) {
+		return UnmarshalSliceRLELimited(n, b, UnmarshalInt32, Limits{MaxElements: 64})
+	})},
+	{"SliceSparse", SkipSliceSparseOf(SkipInt32), u(func(n int, b []byte) (int, []int32, error) {
+		return UnmarshalSliceSparse(n, b, UnmarshalInt32)
+	})},
+	{"SliceSparseLimited", SkipSliceSparseOf(SkipInt32), u(func(n int, b []byte) (int, []int32, error) {
+		return UnmarshalSliceSparseLimited(n, b, UnmarshalInt32, Limits{MaxElements: 64})
+	})},
+	{"SliceString", SkipSliceOf(SkipString), u(func(n int, b []byte) (int, []string, error) {
+		return UnmarshalSlice[string](n, b, UnmarshalString)
+	})},
+	{"SliceLimited", SkipSliceOf(SkipString), u(func(n int, b []byte) (int, []string, error) {
+		return UnmarshalSliceLimited[string](n, b, UnmarshalString, Limits{MaxElements: 4, MaxBytes: 64})
+	})},
+	{"SliceInPlace", SkipSliceOf(SkipInt32), u(func(n int, b []byte) (int, []int32, error) {
+		return UnmarshalSliceInPlace(n, b, make([]int32, 0, 4), UnmarshalInt32)
+	})},
+	{"SliceSliceInt32", SkipSliceOf(SkipSliceOf(SkipInt32)), u(func(n int, b []byte) (int, [][]int32, error) {
+		return UnmarshalSlice[[]int32](n, b, func(n int, b []byte) (int, []int32, error) { return UnmarshalSlice[int32](n, b, UnmarshalInt32) })
+	})},
+	{"MapStringInt64", SkipMapOf(SkipString, SkipInt64), u(func(n int, b []byte) (int, map[string]int64, error) {
+		return UnmarshalMap[string, int64](n, b, UnmarshalString, UnmarshalInt64)
+	})},
+	{"MapLimited", SkipMapOf(SkipString, SkipInt64), u(func(n int, b []byte) (int, map[string]int64, error) {
+		return UnmarshalMapLimited[string, int64](n, b, UnmarshalString, UnmarshalInt64, Limits{MaxElements: 4})
+	})},
+	{"MapInto", SkipMapOf(SkipString, SkipInt64), u(func(n int, b []byte) (int, map[string]int64, error) {
+		return UnmarshalMapInto(n, b, map[string]int64{"a": 1}, UnmarshalString, UnmarshalInt64)
+	})},
+	{"Matrix", func(n int, b []byte) (int, error) { return SkipMatrix(n, b, SkipInt32) }, u(func(n int, b []byte) (int, [][]int32, error) {
+		return UnmarshalMatrix[int32](n, b, UnmarshalInt32)
+	})},
+	{"MapStringBytes", SkipMapStringBytes, u(UnmarshalMapStringBytes)},
+	{"MapStringBytesCropped", SkipMapStringBytes, u(UnmarshalMapStringBytesCropped)},
+	{"MapUint64Bytes", SkipMapUint64Bytes, u(UnmarshalMapUint64Bytes)},
+	{"MapUint64BytesCropped", SkipMapUint64Bytes, u(UnmarshalMapUint64BytesCropped)},
+	{"ByteArray", func(n int, b []byte) (int, error) { return SkipByteArray(n, b, 4) }, func(n int, b []byte) (int, error) {
+		return UnmarshalByteArray(n, b, make([]byte, 4))
+	}},
+	{"ArrayInt32", func(n int, b []byte) (int, error) { return SkipArray(n, b, 3, SkipInt32) }, func(n int, b []byte) (int, error) {
+		return UnmarshalArray(n, b, make([]int32, 3), UnmarshalInt32)
+	}},
+	{"ArrayString", SkipN(SkipString, 2), func(n int, b []byte) (int, error) {
+		return UnmarshalArray(n, b, make([]string, 2), UnmarshalString)
+	}},
+	{"PointerString", SkipPointerOf(SkipString), u(func(n int, b []byte) (int, *string, error) {
+		return UnmarshalPointer[string](n, b, UnmarshalString)
+	})},
+	{"PointerLimited", SkipPointerOf(SkipString), u(func(n int, b []byte) (int, *string, error) {
+		return UnmarshalPointerLimited[string](n, b, UnmarshalString, Limits{MaxBytes: 64})
+	})},
+	{"OptionInt32", SkipOptionOf(SkipInt32), u(func(n int, b []byte) (int, Option[int32], error) {
+		return UnmarshalOption[int32](n, b, UnmarshalInt32)
+	})},
+	{"Fields", SkipFields, func(n int, b []byte) (int, error) {
+		return UnmarshalFields(n, b, func(id uint16, value []byte) error {
+			if id == 1 {
+				_, _, err := UnmarshalString(0, value)
+				return err
+			}
+			return nil
+		})
+	}},
+	{"StructOf", SkipStructOf(SkipBool, SkipString, SkipSliceOf(SkipVarint)), nil},
+	{"Message", SkipFixed(8), u(UnmarshalMessage[testPoint])},
+	{"Transaction", nil, func(n int, b []byte) (int, error) {
+		return UnmarshalTransaction(n, b, func(uint8, []byte) error { return nil })
+	}},
+}
+
+type (
+	corpusColor uint8
+	corpusLevel int32
+)
+
+var (
+	corpusPool  = NewBufPool(1 << 10)
+	corpusTable = NewEnumTable([]int16{-5, 40, 7}, "Red", "Green", "Blue")
+)
+
+func init() {
+	RegisterEnum[corpusColor](0, 1, 2)
+	RegisterEnum[corpusLevel](-1, 1000)
+}
+
+// TestDecodersComplete fails, if an exported Skip or Unmarshal function of the package isn't
+// used by the decoders in corpus*_test.go, the files of every build tag included. A SkipXOf
+// function covers SkipX.
+func TestDecodersComplete(t *testing.T) {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, ".", func(fi fs.FileInfo) bool {
+		return !strings.HasSuffix(fi.Name(), "_test.go") || strings.HasPrefix(fi.Name(), "corpus")
+	}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	used := map[string]bool{}
+	var funcs []string
+	for name, file := range pkgs["bstd"].Files {
+		if strings.HasSuffix(name, "_test.go") {
+			ast.Inspect(file, func(node ast.Node) bool {
+				if id, ok := node.(*ast.Ident); ok {
+					used[id.Name] = true
+				}
+				return true
+			})
+			continue
+		}
+		for _, decl := range file.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if ok && fn.Recv == nil && (strings.HasPrefix(fn.Name.Name, "Skip") || strings.HasPrefix(fn.Name.Name, "Unmarshal")) {
+				funcs = append(funcs, fn.Name.Name)
+			}
+		}
+	}
+	for _, fn := range funcs {
+		if !used[fn] && !used[fn+"Of"] {
+			t.Errorf("%s isn't in the decoders of FuzzDecode", fn)
+		}
+	}
+}
+
+// FuzzDecode decodes arbitrary buffers with every Skip and Unmarshal function. None may
+// panic, a failing one returns n equal to zero, and a succeeding one an offset within the
+// buffer. If the Unmarshal function of a type succeeds, its Skip function has to succeed
+// with the same offset. Skip functions may accept more, they don't validate the values.
+//
+// testdata/fuzz/FuzzDecode holds buffers that broke decoders before, truncated prefixes,
+// huge declared lengths and deep nesting among them; `go test` runs them all.
+func FuzzDecode(f *testing.F) {
+	f.Fuzz(func(t *testing.T, b []byte) {
+		for _, d := range decoders {
+			un, uerr := decode(t, d.name, d.unmarshal, b)
+			sn, serr := decode(t, d.name, d.skip, b)
+			if d.unmarshal != nil && d.skip != nil && uerr == nil && (serr != nil || sn != un) {
+				t.Fatalf("%s: unmarshal read %d bytes, but skip returned %d, %v", d.name, un, sn, serr)
+			}
+		}
+	})
+}
+
+// decode runs fn on b and checks the offset it returns.
+func decode(t *testing.T, name string, fn SkipFunc, b []byte) (n int, err error) {
+	t.Helper()
+	if fn == nil {
+		return 0, nil
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			t.Fatalf("%s: panicked on %x: %v", name, b, r)
+		}
+	}()
+	n, err = fn(0, b)
+	if err != nil && n != 0 {
+		t.Fatalf("%s: returned %d with %v, expected 0", name, n, err)
+	}
+	if err == nil && (n < 0 || n > len(b)) {
+		t.Fatalf("%s: returned offset %d out of the %d bytes", name, n, len(b))
+	}
+	return n, err
+}
@@ -0,0 +1,151 @@
+package bstd
+
+import (
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Decimal is a fixed-point decimal number with the value Mantissa / 10^Scale,
+// e.g. Decimal{Mantissa: 12345, Scale: 2} is 123.45. Monetary values don't have to
+// round-trip through float64 or strings this way.
+//
+// A Decimal is marshalled as its scale followed by its mantissa, both zigzag varints.
+// The representation is kept as is, 1.0 and 1.00 are different on the wire.
+type Decimal struct {
+	Mantissa int64
+	Scale    int32
+}
+
+// String returns the decimal in plain notation, e.g. "123.45" or "-0.05".
+func (d Decimal) String() string {
+	s := strconv.FormatInt(d.Mantissa, 10)
+	if d.Scale <= 0 {
+		if d.Mantissa == 0 {
+			return "0"
+		}
+		return s + strings.Repeat("0", int(-d.Scale))
+	}
+
+	sign := ""
+	if s[0] == '-' {
+		sign, s = "-", s[1:]
+	}
+	if pad := int(d.Scale) + 1 - len(s); pad > 0 {
+		s = strings.Repeat("0", pad) + s
+	}
+	i := len(s) - int(d.Scale)
+	return sign + s[:i] + "." + s[i:]
+}
+
+// Returns the new offset 'n' after skipping the marshalled decimal.
+//
+// Possible errors returned:
+//   - ErrBufTooSmall       - 'b' was too small to skip the marshalled decimal.
+//   - ErrOverflow          - a varint of the decimal overflowed its integer.
+//
+// If a error is returned, n (the int returned) equals zero ( 0 ).
+func SkipDecimal(n int, b []byte) (int, error) {
+	n, err := SkipInt32Varint(n, b)
+	if err != nil {
+		return 0, err
+	}
+	return SkipInt64Varint(n, b)
+}
+
+// Returns the bytes needed to marshal the decimal 'd'.
+func SizeDecimal(d Decimal) int {
+	return SizeInt32Varint(d.Scale) + SizeInt64Varint(d.Mantissa)
+}
+
+// Returns the new offset 'n' after marshalling the decimal 'd'.
+//
+// !- Panics, if 'b' is too small.
+func MarshalDecimal(n int, b []byte, d Decimal) int {
+	n = MarshalInt32Varint(n, b, d.Scale)
+	return MarshalInt64Varint(n, b, d.Mantissa)
+}
+
+// Returns the new offset 'n', as well as the decimal, that got unmarshalled.
+//
+// Possible errors returned:
+//   - ErrBufTooSmall       - 'b' was too small to unmarshal the decimal.
+//   - ErrOverflow          - a varint of the decimal overflowed its integer.
+//
+// If a error is returned, n (the int returned) equals zero ( 0 ).
+func UnmarshalDecimal(n int, b []byte) (int, Decimal, error) {
+	var d Decimal
+	n, scale, err := UnmarshalInt32Varint(n, b)
+	if err != nil {
+		return 0, d, err
+	}
+	n, mantissa, err := UnmarshalInt64Varint(n, b)
+	if err != nil {
+		return 0, d, err
+	}
+	d.Mantissa, d.Scale = mantissa, scale
+	return n, d, nil
+}
+
+// BigDecimal is implemented by arbitrary precision decimal types with the value
+// Coefficient * 10^Exponent, like decimal.Decimal of github.com/shopspring/decimal.
+//
+// A BigDecimal is marshalled as its exponent as zigzag varint followed by its
+// coefficient like a *big.Int.
+type BigDecimal interface {
+	Coefficient() *big.Int
+	Exponent() int32
+}
+
+// Returns the new offset 'n' after skipping the marshalled big decimal.
+//
+// Possible errors returned:
+//   - ErrBufTooSmall       - 'b' was too small to skip the marshalled big decimal.
+//   - ErrOverflow          - a varint of the big decimal overflowed its integer.
+//
+// If a error is returned, n (the int returned) equals zero ( 0 ).
+func SkipBigDecimal(n int, b []byte) (int, error) {
+	n, err := SkipInt32Varint(n, b)
+	if err != nil {
+		return 0, err
+	}
+	return SkipBigInt(n, b)
+}
+
+// Returns the bytes needed to marshal the big decimal 'd'.
+func SizeBigDecimal(d BigDecimal) int {
+	return SizeInt32Varint(d.Exponent()) + SizeBigInt(d.Coefficient())
+}
+
+// Returns the new offset 'n' after marshalling the big decimal 'd'.
+//
+// !- Panics, if 'b' is too small.
+func MarshalBigDecimal(n int, b []byte, d BigDecimal) int {
+	n = MarshalInt32Varint(n, b, d.Exponent())
+	return MarshalBigInt(n, b, d.Coefficient())
+}
+
+// Returns the new offset 'n', as well as the big decimal created by 'newDecimal'
+// from the unmarshalled coefficient and exponent, e.g. decimal.NewFromBigInt.
+//
+// Possible errors returned:
+//   - ErrBufTooSmall       - 'b' was too small to unmarshal the big decimal.
+//   - ErrOverflow          - a varint of the big decimal overflowed its integer.
+//   - any error returned by big.Int.GobDecode.
+//
+// If a error is returned, n (the int returned) equals zero ( 0 ).
+func UnmarshalBigDecimal[T any](n int, b []byte, newDecimal func(coefficient *big.Int, exponent int32) T) (int, T, error) {
+	var d T
+	n, exp, err := UnmarshalInt32Varint(n, b)
+	if err != nil {
+		return 0, d, err
+	}
+	n, coef, err := UnmarshalBigInt(n, b)
+	if err != nil {
+		return 0, d, err
+	}
+	if coef == nil {
+		coef = new(big.Int)
+	}
+	return n, newDecimal(coef, exp), nil
+}
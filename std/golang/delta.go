@@ -0,0 +1,80 @@
+package bstd
+
+import "golang.org/x/exp/constraints"
+
+// The delta encoding of an integer slice stores the number of elements, followed by the
+// difference of every element to the one before it (to 0 for the first element) as zigzag
+// varint. Sorted IDs or timestamps get a lot smaller, as only their small gaps are stored;
+// any other slice round trips as well, just without the savings. The delta and the plain
+// encoding are not interchangeable on the wire.
+
+// Returns the new offset 'n' after skipping the marshalled delta encoded slice.
+//
+// Possible errors returned:
+//   - ErrOverflow          - a varint overflowed a N-bit unsigned integer.
+//   - ErrBufTooSmall       - 'b' was too small to skip the marshalled slice.
+//
+// If a error is returned, n (the int returned) equals zero ( 0 ).
+func SkipSliceDelta(n int, b []byte) (int, error) {
+	n, count, err := UnmarshalUint(n, b)
+	if err != nil {
+		return 0, err
+	}
+	for range count {
+		if n, err = SkipInt64Varint(n, b); err != nil {
+			return 0, err
+		}
+	}
+	return n, nil
+}
+
+// Returns the bytes needed to marshal the slice delta encoded.
+func SizeSliceDelta[T constraints.Integer](slice []T) int {
+	s := SizeUint(uint(len(slice)))
+	var prev T
+	for _, t := range slice {
+		s += SizeInt64Varint(int64(t) - int64(prev))
+		prev = t
+	}
+	return s
+}
+
+// Returns the new offset 'n' after marshalling the slice delta encoded.
+//
+// !- Panics, if 'b' is too small.
+func MarshalSliceDelta[T constraints.Integer](n int, b []byte, slice []T) int {
+	n = MarshalUint(n, b, uint(len(slice)))
+	var prev T
+	for _, t := range slice {
+		n = MarshalInt64Varint(n, b, int64(t)-int64(prev))
+		prev = t
+	}
+	return n
+}
+
+// Returns the new offset 'n', as well as the delta encoded slice, that got unmarshalled.
+//
+// Possible errors returned:
+//   - ErrOverflow          - a varint overflowed a N-bit unsigned integer.
+//   - ErrBufTooSmall       - 'b' was too small to unmarshal the slice.
+//
+// If a error is returned, n (the int returned) equals zero ( 0 ).
+func UnmarshalSliceDelta[T constraints.Integer](n int, b []byte) (int, []T, error) {
+	n, count, err := UnmarshalUint(n, b)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	// every delta takes at least one byte, which bounds the allocation up front
+	ts := make([]T, 0, min(count, uint(len(b)-n)))
+	var prev T
+	for range count {
+		var delta int64
+		if n, delta, err = UnmarshalInt64Varint(n, b); err != nil {
+			return 0, nil, err
+		}
+		prev = T(int64(prev) + delta)
+		ts = append(ts, prev)
+	}
+	return n, ts, nil
+}
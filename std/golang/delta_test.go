@@ -0,0 +1,53 @@
+package bstd
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestSliceDelta(t *testing.T) {
+	for _, v := range [][]int64{{}, {7}, {1, 2, 3, 5, 8, 13}, {5, -3, math.MaxInt64, math.MinInt64, 0}} {
+		testSliceDelta(t, v)
+	}
+	testSliceDelta(t, []uint64{0, math.MaxUint64, 1, math.MaxUint64 - 1})
+	testSliceDelta(t, []uint32{math.MaxUint32, 0, 10})
+	testSliceDelta(t, []int8{math.MinInt8, math.MaxInt8, -1})
+
+	ids := make([]uint64, 1000)
+	for i := range ids {
+		ids[i] = 1_700_000_000_000 + uint64(i)*3
+	}
+	if s := SizeSliceDelta(ids); s != 1007 {
+		t.Fatalf("expected 1007 bytes for the ids, got %d", s)
+	}
+
+	if _, _, err := UnmarshalSliceDelta[int64](0, []byte{2, 2}); !errors.Is(err, ErrBufTooSmall) {
+		t.Fatalf("expected ErrBufTooSmall, got %v", err)
+	}
+}
+
+func testSliceDelta[T int8 | uint32 | int64 | uint64](t *testing.T, v []T) {
+	t.Helper()
+	s := SizeSliceDelta(v)
+	buf := make([]byte, s)
+	if n := MarshalSliceDelta(0, buf, v); n != s {
+		t.Fatalf("%v: expected offset %d, got %d", v, s, n)
+	}
+	n, got, err := UnmarshalSliceDelta[T](0, buf)
+	if err != nil || n != s || len(got) != len(v) || len(v) > 0 && !reflect.DeepEqual(got, v) {
+		t.Fatalf("%v: got %v, %d, %v", v, got, n, err)
+	}
+	if n, err = SkipSliceDelta(0, buf); err != nil || n != s {
+		t.Fatalf("%v: skip got %d, %v", v, n, err)
+	}
+	for i := range s {
+		if _, _, err = UnmarshalSliceDelta[T](0, buf[:i]); !errors.Is(err, ErrBufTooSmall) {
+			t.Fatalf("%v: %d bytes: expected ErrBufTooSmall, got %v", v, i, err)
+		}
+		if _, err = SkipSliceDelta(0, buf[:i]); !errors.Is(err, ErrBufTooSmall) {
+			t.Fatalf("%v: %d bytes: expected ErrBufTooSmall, got %v", v, i, err)
+		}
+	}
+}
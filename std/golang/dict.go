@@ -0,0 +1,104 @@
+package bstd
+
+// A StringDict writes repeated strings once: the first occurrence of a string is marshalled
+// as a 0 followed by the string, which adds it to the dictionary, every further occurrence
+// as its index in the dictionary plus one. Unlike the Symbols table, nothing has to be
+// collected up front, the dictionary builds up while marshalling and unmarshalling.
+//
+// Sizing and marshalling a message change the dictionary, so both need a dictionary of
+// their own, or a Reset in between. The zero value is an empty dictionary, ready to use:
+//
+//	var dict bstd.StringDict
+//	s := bstd.SizeSlice(keys, func(k string) int { return bstd.SizeStringDict(&dict, k) })
+//	dict.Reset()
+//	n := bstd.MarshalSlice(0, buf, keys, func(n int, b []byte, k string) int { return bstd.MarshalStringDict(n, b, &dict, k) })
+type StringDict struct {
+	strings []string
+	index   map[string]uint
+}
+
+// Reset empties the dictionary, keeping its memory for the next message.
+func (d *StringDict) Reset() {
+	d.strings = d.strings[:0]
+	clear(d.index)
+}
+
+// add returns the index of 'str' and whether it was in the dictionary already, adding it otherwise.
+func (d *StringDict) add(str string) (uint, bool) {
+	if i, ok := d.index[str]; ok {
+		return i, true
+	}
+	if d.index == nil {
+		d.index = make(map[string]uint)
+	}
+	i := uint(len(d.strings))
+	d.strings = append(d.strings, str)
+	d.index[str] = i
+	return i, false
+}
+
+// Returns the new offset 'n' after skipping the marshalled dictionary string.
+//
+// Possible errors returned:
+//   - ErrOverflow          - varint overflowed a N-bit unsigned integer.
+//   - ErrBufTooSmall       - 'b' was too small to skip the marshalled string.
+//
+// If a error is returned, n (the int returned) equals zero ( 0 ).
+func SkipStringDict(n int, b []byte) (int, error) {
+	n, ref, err := UnmarshalUint(n, b)
+	if err != nil {
+		return 0, err
+	}
+	if ref != 0 {
+		return n, nil
+	}
+	return SkipString(n, b)
+}
+
+// Returns the bytes needed to marshal 'str' with the dictionary 'd', adding 'str' to it.
+func SizeStringDict(d *StringDict, str string) int {
+	i, ok := d.add(str)
+	if ok {
+		return SizeUint(i + 1)
+	}
+	return 1 + SizeString(str)
+}
+
+// Returns the new offset 'n' after marshalling 'str' with the dictionary 'd', adding 'str' to it.
+//
+// !- Panics, if 'b' is too small.
+func MarshalStringDict(n int, b []byte, d *StringDict, str string) int {
+	i, ok := d.add(str)
+	if ok {
+		return MarshalUint(n, b, i+1)
+	}
+	n = MarshalUint(n, b, 0)
+	return MarshalString(n, b, str)
+}
+
+// Returns the new offset 'n', as well as the string, that got unmarshalled with the dictionary 'd'.
+//
+// Possible errors returned:
+//   - ErrOverflow          - varint overflowed a N-bit unsigned integer.
+//   - ErrBufTooSmall       - 'b' was too small to unmarshal the string.
+//   - ErrSymbol            - the string refers to an index beyond the dictionary.
+//
+// If a error is returned, n (the int returned) equals zero ( 0 ).
+func UnmarshalStringDict(n int, b []byte, d *StringDict) (int, string, error) {
+	n, ref, err := UnmarshalUint(n, b)
+	if err != nil {
+		return 0, "", err
+	}
+	if ref != 0 {
+		if ref > uint(len(d.strings)) {
+			return 0, "", ErrSymbol
+		}
+		return n, d.strings[ref-1], nil
+	}
+	n, str, err := UnmarshalString(n, b)
+	if err != nil {
+		return 0, "", err
+	}
+	d.strings = append(d.strings, str)
+	return n, str, nil
+}
@@ -0,0 +1,47 @@
+package bstd
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestStringDict(t *testing.T) {
+	keys := []string{"level", "msg", "level", "msg", "", "level", ""}
+
+	var dict StringDict
+	s := SizeSlice(keys, func(k string) int { return SizeStringDict(&dict, k) })
+	if plain := SizeSlice(keys, SizeString); s >= plain {
+		t.Fatalf("expected less than the %d bytes of the plain slice, got %d", plain, s)
+	}
+
+	dict.Reset()
+	buf := make([]byte, s)
+	if n := MarshalSlice(0, buf, keys, func(n int, b []byte, k string) int { return MarshalStringDict(n, b, &dict, k) }); n != s {
+		t.Fatalf("expected offset %d, got %d", s, n)
+	}
+
+	var got StringDict
+	n, gotKeys, err := UnmarshalSlice[string](0, buf, func(n int, b []byte) (int, string, error) { return UnmarshalStringDict(n, b, &got) })
+	if err != nil || n != s || !reflect.DeepEqual(gotKeys, keys) {
+		t.Fatalf("got %v, %d, %v", gotKeys, n, err)
+	}
+	if n, err = SkipSlice(0, buf, SkipStringDict); err != nil || n != s {
+		t.Fatalf("skip got %d, %v", n, err)
+	}
+
+	for i := range s {
+		got.Reset()
+		if _, _, err = UnmarshalSlice[string](0, buf[:i], func(n int, b []byte) (int, string, error) { return UnmarshalStringDict(n, b, &got) }); !errors.Is(err, ErrBufTooSmall) {
+			t.Fatalf("%d bytes: expected ErrBufTooSmall, got %v", i, err)
+		}
+		if _, err = SkipSlice(0, buf[:i], SkipStringDict); !errors.Is(err, ErrBufTooSmall) {
+			t.Fatalf("%d bytes: expected ErrBufTooSmall, got %v", i, err)
+		}
+	}
+
+	got.Reset()
+	if _, _, err = UnmarshalStringDict(0, []byte{1}, &got); err != ErrSymbol {
+		t.Fatalf("expected ErrSymbol, got %v", err)
+	}
+}
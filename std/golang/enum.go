@@ -0,0 +1,121 @@
+package bstd
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// enums maps the registered enum types to the sets of their values.
+var enums sync.Map
+
+// Enum is the constraint of the enum types: named integers of one or four bytes.
+type Enum interface {
+	~uint8 | ~int32
+}
+
+// RegisterEnum registers the allowed values of the enum type T, which UnmarshalEnum checks
+// the unmarshalled values against. Registering a type again adds to its values, e.g.
+//
+//	type Color uint8
+//
+//	const (
+//		Red Color = iota
+//		Green
+//		Blue
+//	)
+//
+//	func init() { bstd.RegisterEnum(Red, Green, Blue) }
+func RegisterEnum[T Enum](values ...T) {
+	set := make(map[T]struct{}, len(values))
+	if old, ok := enums.Load(reflect.TypeFor[T]()); ok {
+		for v := range old.(map[T]struct{}) {
+			set[v] = struct{}{}
+		}
+	}
+	for _, v := range values {
+		set[v] = struct{}{}
+	}
+	enums.Store(reflect.TypeFor[T](), set)
+}
+
+// ValidEnum reports whether 'v' is a registered value of its enum type.
+//
+// !- Panics, if T isn't registered.
+func ValidEnum[T Enum](v T) bool {
+	set, ok := enums.Load(reflect.TypeFor[T]())
+	if !ok {
+		panic(fmt.Sprintf("benc: enum %s is not registered", reflect.TypeFor[T]()))
+	}
+	_, ok = set.(map[T]struct{})[v]
+	return ok
+}
+
+// isByteEnum reports whether T is a uint8, the only unsigned enum type.
+func isByteEnum[T Enum]() bool {
+	return T(0)-1 > 0
+}
+
+// Returns the new offset 'n' after skipping the marshalled enum.
+//
+// Possible errors returned:
+//   - ErrBufTooSmall       - 'b' was too small to skip the marshalled enum.
+//
+// If a error is returned, n (the int returned) equals zero ( 0 ).
+func SkipEnum[T Enum](n int, b []byte) (int, error) {
+	if isByteEnum[T]() {
+		return SkipByte(n, b)
+	}
+	return SkipInt32(n, b)
+}
+
+// Returns the bytes needed to marshal an enum of type T, the size of its integer type.
+func SizeEnum[T Enum]() int {
+	if isByteEnum[T]() {
+		return SizeByte()
+	}
+	return SizeInt32()
+}
+
+// Returns the new offset 'n' after marshalling the enum like its integer type.
+// The value isn't checked, see ValidEnum.
+//
+// !- Panics, if 'b' is too small.
+func MarshalEnum[T Enum](n int, b []byte, v T) int {
+	if isByteEnum[T]() {
+		return MarshalByte(n, b, byte(v))
+	}
+	return MarshalInt32(n, b, int32(v))
+}
+
+// Returns the new offset 'n', as well as the enum, that got unmarshalled.
+//
+// Possible errors returned:
+//   - ErrBufTooSmall       - 'b' was too small to unmarshal the enum.
+//   - ErrInvalidData       - the value isn't registered for T, see RegisterEnum.
+//
+// If a error is returned, n (the int returned) equals zero ( 0 ).
+//
+// !- Panics, if T isn't registered.
+func UnmarshalEnum[T Enum](n int, b []byte) (int, T, error) {
+	var v T
+	if isByteEnum[T]() {
+		var u byte
+		var err error
+		if n, u, err = UnmarshalByte(n, b); err != nil {
+			return 0, 0, err
+		}
+		v = T(u)
+	} else {
+		var i int32
+		var err error
+		if n, i, err = UnmarshalInt32(n, b); err != nil {
+			return 0, 0, err
+		}
+		v = T(i)
+	}
+	if !ValidEnum(v) {
+		return 0, 0, fmt.Errorf("%w: %d is no %s", ErrInvalidData, v, reflect.TypeFor[T]())
+	}
+	return n, v, nil
+}
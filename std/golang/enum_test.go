@@ -0,0 +1,51 @@
+package bstd
+
+import (
+	"errors"
+	"testing"
+)
+
+type enumColor uint8
+
+type enumLevel int32
+
+func TestEnum(t *testing.T) {
+	RegisterEnum[enumColor](0, 1)
+	RegisterEnum[enumColor](2)
+	RegisterEnum[enumLevel](-1, 1000)
+
+	if SizeEnum[enumColor]() != 1 || SizeEnum[enumLevel]() != 4 {
+		t.Fatalf("expected sizes 1 and 4, got %d and %d", SizeEnum[enumColor](), SizeEnum[enumLevel]())
+	}
+
+	buf := make([]byte, SizeEnum[enumColor]()+SizeEnum[enumLevel]())
+	n := MarshalEnum(0, buf, enumColor(2))
+	if n = MarshalEnum(n, buf, enumLevel(-1)); n != len(buf) {
+		t.Fatalf("expected offset %d, got %d", len(buf), n)
+	}
+
+	n, c, err := UnmarshalEnum[enumColor](0, buf)
+	if err != nil || c != 2 {
+		t.Fatalf("expected 2, got %d, %v", c, err)
+	}
+	n, l, err := UnmarshalEnum[enumLevel](n, buf)
+	if err != nil || l != -1 || n != len(buf) {
+		t.Fatalf("expected -1, got %d, %d, %v", l, n, err)
+	}
+	if n, err = SkipEnum[enumColor](0, buf); err == nil {
+		n, err = SkipEnum[enumLevel](n, buf)
+	}
+	if err != nil || n != len(buf) {
+		t.Fatalf("skip got %d, %v", n, err)
+	}
+
+	if _, _, err = UnmarshalEnum[enumLevel](0, buf[:3]); !errors.Is(err, ErrBufTooSmall) {
+		t.Fatalf("expected ErrBufTooSmall, got %v", err)
+	}
+	if _, _, err = UnmarshalEnum[enumColor](0, []byte{3}); !errors.Is(err, ErrInvalidData) {
+		t.Fatalf("expected ErrInvalidData, got %v", err)
+	}
+	if ValidEnum(enumLevel(0)) || !ValidEnum(enumLevel(1000)) {
+		t.Fatal("expected only the registered levels to be valid")
+	}
+}
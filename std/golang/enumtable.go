@@ -0,0 +1,121 @@
+package bstd
+
+import (
+	"fmt"
+	"reflect"
+
+	"golang.org/x/exp/constraints"
+)
+
+// The interned string mode of an enum marshals a constant as its ordinal, the index of the
+// constant in the table of its type, as varint, while the code using the enum gets the names
+// of the constants from the table, e.g.
+//
+//	var colors = bstd.NewEnumTable([]Color{Red, Green, Blue}, "Red", "Green", "Blue")
+//
+//	n = colors.Marshal(n, b, Green) // 0x01
+//	name := colors.Name(Green)     // "Green"
+//
+// Unlike MarshalEnum, the wire doesn't depend on the values of the constants, so every
+// language numbering the names alike reads the enum, and a constant takes a single byte for
+// up to 128 names. The generator builds the table of every schema type with a //benc:enum
+// comment.
+
+// EnumTable maps the constants of the enum type T to their ordinals and names.
+type EnumTable[T constraints.Integer] struct {
+	values   []T
+	names    []string
+	ordinals map[T]uint
+	byName   map[string]T
+}
+
+// NewEnumTable returns the table of the constants 'values' of T, named 'names', the ordinal of
+// a constant being its index.
+//
+// !- Panics, if there are more or less names than values, or a value or a name repeats.
+func NewEnumTable[T constraints.Integer](values []T, names ...string) *EnumTable[T] {
+	if len(names) != len(values) {
+		panic(fmt.Sprintf("benc: %d names for %d values of %s", len(names), len(values), reflect.TypeFor[T]()))
+	}
+	e := &EnumTable[T]{
+		values:   values,
+		names:    names,
+		ordinals: make(map[T]uint, len(values)),
+		byName:   make(map[string]T, len(names)),
+	}
+	for i, v := range values {
+		if _, ok := e.ordinals[v]; ok {
+			panic(fmt.Sprintf("benc: value %d of %s repeats", v, reflect.TypeFor[T]()))
+		}
+		if _, ok := e.byName[names[i]]; ok {
+			panic(fmt.Sprintf("benc: name %s of %s repeats", names[i], reflect.TypeFor[T]()))
+		}
+		e.ordinals[v] = uint(i)
+		e.byName[names[i]] = v
+	}
+	return e
+}
+
+// Name returns the name of the constant 'v', or T(v), e.g. Color(7), if it is none.
+func (e *EnumTable[T]) Name(v T) string {
+	if i, ok := e.ordinals[v]; ok {
+		return e.names[i]
+	}
+	return fmt.Sprintf("%s(%d)", reflect.TypeFor[T]().Name(), v)
+}
+
+// Parse returns the constant named 'name' and whether there is one.
+func (e *EnumTable[T]) Parse(name string) (T, bool) {
+	v, ok := e.byName[name]
+	return v, ok
+}
+
+// Names returns the names of the constants in the order of their ordinals.
+func (e *EnumTable[T]) Names() []string {
+	return e.names
+}
+
+// ordinal returns the ordinal of the constant 'v'.
+//
+// !- Panics, if 'v' is no constant of the table.
+func (e *EnumTable[T]) ordinal(v T) uint {
+	i, ok := e.ordinals[v]
+	if !ok {
+		panic(fmt.Sprintf("benc: %s is no constant of the enum", e.Name(v)))
+	}
+	return i
+}
+
+// Returns the bytes needed to marshal the constant.
+//
+// !- Panics, if 'v' is no constant of the table.
+func (e *EnumTable[T]) Size(v T) int {
+	return SizeUint(e.ordinal(v))
+}
+
+// Returns the new offset 'n' after marshalling the ordinal of the constant.
+//
+// !- Panics, if 'b' is too small or 'v' is no constant of the table.
+func (e *EnumTable[T]) Marshal(n int, b []byte, v T) int {
+	return MarshalUint(n, b, e.ordinal(v))
+}
+
+// Returns the new offset 'n', as well as the constant, whose ordinal got unmarshalled.
+// A marshalled constant is skipped with SkipVarint.
+//
+// Possible errors returned:
+//   - ErrOverflow          - a varint overflowed a N-bit unsigned integer.
+//   - ErrBufTooSmall       - 'b' was too small to unmarshal the ordinal.
+//   - ErrInvalidData       - the ordinal exceeds the constants of the table.
+//
+// If a error is returned, n (the int returned) equals zero ( 0 ).
+func (e *EnumTable[T]) Unmarshal(n int, b []byte) (int, T, error) {
+	n, i, err := UnmarshalUint(n, b)
+	if err != nil {
+		return 0, 0, err
+	}
+	if i >= uint(len(e.values)) {
+		return 0, 0, fmt.Errorf("%w: ordinal %d exceeds the %d constants of %s", ErrInvalidData, i, len(e.values), reflect.TypeFor[T]())
+	}
+	return n, e.values[i], nil
+}
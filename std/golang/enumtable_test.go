@@ -0,0 +1,56 @@
+package bstd
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+)
+
+type tableColor int16
+
+func TestEnumTable(t *testing.T) {
+	// the ordinals follow the table, not the values of the constants
+	colors := NewEnumTable([]tableColor{-5, 40, 7}, "Red", "Green", "Blue")
+
+	buf := make([]byte, colors.Size(40)+colors.Size(7))
+	n := colors.Marshal(0, buf, 40)
+	if n = colors.Marshal(n, buf, 7); n != 2 || buf[0] != 1 || buf[1] != 2 {
+		t.Fatalf("expected ordinals 1 and 2, got %d, %v", n, buf)
+	}
+	n, c, err := colors.Unmarshal(0, buf)
+	if err != nil || c != 40 || n != 1 {
+		t.Fatalf("expected 40, got %d, %d, %v", c, n, err)
+	}
+	if n, err = SkipVarint(n, buf); err != nil || n != 2 {
+		t.Fatalf("skip got %d, %v", n, err)
+	}
+
+	if colors.Name(-5) != "Red" || colors.Name(3) != "tableColor(3)" {
+		t.Fatalf("got names %q and %q", colors.Name(-5), colors.Name(3))
+	}
+	if v, ok := colors.Parse("Blue"); !ok || v != 7 {
+		t.Fatalf("expected Blue to parse as 7, got %d, %v", v, ok)
+	}
+	if _, ok := colors.Parse("blue"); ok {
+		t.Fatal("expected names to be case sensitive")
+	}
+	if _, _, err = colors.Unmarshal(0, []byte{3}); !errors.Is(err, ErrInvalidData) {
+		t.Fatalf("expected ErrInvalidData, got %v", err)
+	}
+	if _, _, err = colors.Unmarshal(0, []byte{0x80}); !errors.Is(err, ErrBufTooSmall) {
+		t.Fatalf("expected ErrBufTooSmall, got %v", err)
+	}
+	r := rand.New(rand.NewSource(1))
+	for range 10 {
+		if v := GenerateEnum(colors)(r, 0); colors.Size(v) != 1 {
+			t.Fatalf("generated %d, which is no constant", v)
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected a panic on marshalling a value, which is no constant")
+		}
+	}()
+	colors.Size(3)
+}
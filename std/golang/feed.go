@@ -0,0 +1,116 @@
+package bstd
+
+import "errors"
+
+// A FeedDecoder decodes a stream, which arrives in pieces of any size, e.g. the reads of a
+// non-blocking network server, without blocking on the rest of a message: the pieces are fed
+// to it as they arrive, and it returns ErrNeedMore until a message is complete.
+//
+//	d := bstd.NewFeedDecoder(1 << 20)
+//	d.Feed(packet)
+//	for {
+//		msg, err := d.Next()
+//		if err == bstd.ErrNeedMore {
+//			break // wait for the next packet
+//		}
+//		...
+//	}
+
+var ErrNeedMore = errors.New("need more data")
+
+// FeedDecoder accumulates the bytes fed to it and returns the complete frames, see WriteFrame,
+// or messages among them, see NewFeedDecoder.
+type FeedDecoder struct {
+	// buf[start:] are the bytes fed, but not decoded yet
+	buf     []byte
+	start   int
+	maxSize int
+}
+
+// Returns a FeedDecoder, which rejects frames and messages larger than 'maxSize', if 'maxSize'
+// is greater than zero, instead of buffering them.
+func NewFeedDecoder(maxSize int) *FeedDecoder {
+	return &FeedDecoder{maxSize: maxSize}
+}
+
+// Feed appends the bytes of 'p' to the stream. The messages returned by Next and the bytes
+// passed to the function of Unmarshal before are invalid afterwards, as their buffer is reused.
+func (d *FeedDecoder) Feed(p []byte) {
+	if d.start > 0 && d.start >= len(d.buf)/2 {
+		d.buf = d.buf[:copy(d.buf, d.buf[d.start:])]
+		d.start = 0
+	}
+	d.buf = append(d.buf, p...)
+}
+
+// Write feeds the bytes of 'p' to the decoder and never fails, so a FeedDecoder is the
+// io.Writer of e.g. io.Copy, see Feed.
+func (d *FeedDecoder) Write(p []byte) (int, error) {
+	d.Feed(p)
+	return len(p), nil
+}
+
+// Reset discards the bytes fed, keeping the buffer, so a FeedDecoder is reused for the next
+// connection, e.g. from a sync.Pool.
+func (d *FeedDecoder) Reset() {
+	d.buf = d.buf[:0]
+	d.start = 0
+}
+
+// Buffered returns the number of bytes fed, but not decoded yet.
+func (d *FeedDecoder) Buffered() int {
+	return len(d.buf) - d.start
+}
+
+// Returns the message inside of the next frame, see WriteFrame, once the frame is complete.
+// The message is a subslice of the buffer of the decoder, valid until the next Feed.
+//
+// Possible errors returned:
+//   - ErrNeedMore          - the frame isn't complete yet, feed more bytes.
+//   - ErrOverflow          - the length prefix overflowed a 64-bit unsigned integer.
+//   - ErrFrameTooLarge     - the frame is larger than 'maxSize' (if 'maxSize' is greater than zero).
+//
+// After an error other than ErrNeedMore, the stream is corrupted.
+func (d *FeedDecoder) Next() ([]byte, error) {
+	b := d.buf[d.start:]
+	n, us, err := UnmarshalUint(0, b)
+	if err == ErrBufTooSmall {
+		return nil, ErrNeedMore
+	}
+	if err != nil {
+		return nil, err
+	}
+	if us > uint(maxInt-n) || (d.maxSize > 0 && us > uint(d.maxSize)) {
+		return nil, ErrFrameTooLarge
+	}
+	end := n + int(us)
+	if len(b) < end {
+		return nil, ErrNeedMore
+	}
+	d.start += end
+	return b[n:end], nil
+}
+
+// Unmarshal calls 'unmarshal', e.g. the Unmarshal method of a message, with the bytes fed, for
+// streams of messages without frames, and consumes the bytes unmarshalled, once the message is
+// complete. Values unmarshalled must not share the bytes passed to 'unmarshal', see Feed.
+//
+// Possible errors returned:
+//   - ErrNeedMore          - 'unmarshal' returned ErrBufTooSmall, feed more bytes.
+//   - ErrFrameTooLarge     - 'unmarshal' returned ErrBufTooSmall on more than 'maxSize' bytes
+//     (if 'maxSize' is greater than zero).
+//   - any other error of 'unmarshal'
+func (d *FeedDecoder) Unmarshal(unmarshal func(n int, b []byte) (int, error)) error {
+	n, err := unmarshal(0, d.buf[d.start:])
+	if errors.Is(err, ErrBufTooSmall) {
+		if d.maxSize > 0 && d.Buffered() > d.maxSize {
+			return ErrFrameTooLarge
+		}
+		return ErrNeedMore
+	}
+	if err != nil {
+		return err
+	}
+	d.start += n
+	return nil
+}
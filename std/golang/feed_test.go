@@ -0,0 +1,87 @@
+package bstd
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestFeedDecoder(t *testing.T) {
+	msgs := [][]byte{[]byte("hello"), {}, bytes.Repeat([]byte{7}, 300)}
+	var stream bytes.Buffer
+	for _, msg := range msgs {
+		if err := WriteFrame(&stream, msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// feed the stream a byte at a time, every message is returned once complete
+	d := NewFeedDecoder(0)
+	var got [][]byte
+	for _, c := range stream.Bytes() {
+		d.Feed([]byte{c})
+		for {
+			msg, err := d.Next()
+			if err == ErrNeedMore {
+				break
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, bytes.Clone(msg))
+		}
+	}
+	if len(got) != len(msgs) || d.Buffered() != 0 {
+		t.Fatalf("expected %d messages, got %d, %d bytes left", len(msgs), len(got), d.Buffered())
+	}
+	for i := range msgs {
+		if !bytes.Equal(got[i], msgs[i]) {
+			t.Fatalf("message %d: expected %v, got %v", i, msgs[i], got[i])
+		}
+	}
+
+	d = NewFeedDecoder(100)
+	d.Write(stream.Bytes())
+	d.Next()
+	d.Next()
+	if _, err := d.Next(); err != ErrFrameTooLarge {
+		t.Fatalf("expected ErrFrameTooLarge, got %v", err)
+	}
+}
+
+func TestFeedDecoderUnmarshal(t *testing.T) {
+	slice := []string{"a", "bc"}
+	buf := make([]byte, 2*SizeSlice(slice, SizeString))
+	MarshalSlice(MarshalSlice(0, buf, slice, MarshalString), buf, slice, MarshalString)
+
+	var got [][]string
+	unmarshal := func(n int, b []byte) (int, error) {
+		n, v, err := UnmarshalSlice[string](n, b, UnmarshalString)
+		if err == nil {
+			got = append(got, v)
+		}
+		return n, err
+	}
+	d := NewFeedDecoder(0)
+	for i := 0; i < len(buf); i += 3 {
+		d.Feed(buf[i:min(i+3, len(buf))])
+		for {
+			err := d.Unmarshal(unmarshal)
+			if err == ErrNeedMore {
+				break
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if len(got) != 2 || d.Buffered() != 0 {
+		t.Fatalf("expected 2 messages, got %v, %d bytes left", got, d.Buffered())
+	}
+
+	d = NewFeedDecoder(3)
+	d.Feed(buf[:4])
+	if err := d.Unmarshal(unmarshal); !errors.Is(err, ErrFrameTooLarge) {
+		t.Fatalf("expected ErrFrameTooLarge, got %v", err)
+	}
+}
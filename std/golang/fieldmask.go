@@ -0,0 +1,50 @@
+package bstd
+
+import "strings"
+
+// FieldMask selects fields of a message by their paths, a path is a field name
+// or a dot separated list of field names of nested structs, e.g. "Address.City".
+// It is used by the generated Merge<T> functions to apply partial updates.
+//
+// A FieldMask is a message itself, so it can be sent along with the update.
+type FieldMask struct {
+	Paths []string
+}
+
+func NewFieldMask(paths ...string) FieldMask {
+	return FieldMask{Paths: paths}
+}
+
+// Contains reports whether the whole field is selected.
+func (m FieldMask) Contains(field string) bool {
+	for _, p := range m.Paths {
+		if p == field {
+			return true
+		}
+	}
+	return false
+}
+
+// Sub returns the paths selecting fields inside of 'field', with the "field." prefix removed.
+func (m FieldMask) Sub(field string) FieldMask {
+	var sub FieldMask
+	for _, p := range m.Paths {
+		if rest, ok := strings.CutPrefix(p, field+"."); ok {
+			sub.Paths = append(sub.Paths, rest)
+		}
+	}
+	return sub
+}
+
+func (m *FieldMask) Size() int {
+	return SizeSlice(m.Paths, SizeString)
+}
+
+func (m *FieldMask) Marshal(tn int, b []byte) int {
+	return MarshalSlice(tn, b, m.Paths, MarshalString)
+}
+
+func (m *FieldMask) Unmarshal(tn int, b []byte) (n int, err error) {
+	n, m.Paths, err = UnmarshalSlice[string](tn, b, UnmarshalString)
+	return
+}
@@ -0,0 +1,40 @@
+package bstd
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFieldMask(t *testing.T) {
+	m := NewFieldMask("Name", "Address.City", "Address.Geo.Lat", "Tags")
+
+	if !m.Contains("Name") || m.Contains("Address") || m.Contains("Nam") {
+		t.Fatal("Contains returned a wrong result")
+	}
+
+	sub := m.Sub("Address")
+	if !reflect.DeepEqual(sub.Paths, []string{"City", "Geo.Lat"}) {
+		t.Fatalf("unexpected sub paths: %v", sub.Paths)
+	}
+	if !sub.Sub("Geo").Contains("Lat") {
+		t.Fatal("expected nested sub mask to contain Lat")
+	}
+	if len(m.Sub("Name").Paths) != 0 {
+		t.Fatal("expected no sub paths for Name")
+	}
+
+	s := m.Size()
+	buf := make([]byte, s)
+	if n := m.Marshal(0, buf); n != s {
+		t.Fatalf("expected size %d, got %d", s, n)
+	}
+
+	var ret FieldMask
+	n, err := ret.Unmarshal(0, buf)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if n != s || !reflect.DeepEqual(ret.Paths, m.Paths) {
+		t.Fatalf("no match: \norg %v\ndec %v", m.Paths, ret.Paths)
+	}
+}
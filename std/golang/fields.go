@@ -0,0 +1,99 @@
+package bstd
+
+// A field container marshals the fields of a message as tag-length-value: the id of the
+// field as varint, the length of the value and the value. The container is prefixed with
+// the length of its fields, like a byte slice. Readers skip the fields whose ids they don't
+// know, so writers may add, remove and reorder fields without breaking old readers, which
+// suits persisted data outliving the code that wrote it:
+//
+//	s := bstd.SizeField(1, p.Name, bstd.SizeString) + bstd.SizeField(2, p.Age, bstd.SizeInt)
+//	n = bstd.MarshalFields(n, b, s, func(n int, b []byte) int {
+//		n = bstd.MarshalField(n, b, 1, p.Name, bstd.SizeString, bstd.MarshalString)
+//		return bstd.MarshalField(n, b, 2, p.Age, bstd.SizeInt, bstd.MarshalInt)
+//	})
+//
+//	n, err = bstd.UnmarshalFields(n, b, func(id uint16, value []byte) (err error) {
+//		switch id {
+//		case 1:
+//			_, p.Name, err = bstd.UnmarshalString(0, value)
+//		case 2:
+//			_, p.Age, err = bstd.UnmarshalInt(0, value)
+//		}
+//		return err
+//	})
+//
+// Ids are never reused: a removed field keeps its id reserved, else an old reader
+// unmarshals the new value as the old one.
+
+// Returns the bytes needed to marshal the field 'id' holding 'v', sizing the value with 'sizer'.
+func SizeField[T any](id uint16, v T, sizer SizeFunc[T]) int {
+	s := sizer(v)
+	return SizeUint16Varint(id) + SizeUint(uint(s)) + s
+}
+
+// Returns the new offset 'n' after marshalling the field 'id' holding 'v', the length of the
+// value with 'sizer' and the value with 'marshaler'.
+//
+// !- Panics, if 'b' is too small.
+func MarshalField[T any](n int, b []byte, id uint16, v T, sizer SizeFunc[T], marshaler MarshalFunc[T]) int {
+	n = MarshalUint16Varint(n, b, id)
+	n = MarshalUint(n, b, uint(sizer(v)))
+	return marshaler(n, b, v)
+}
+
+// Returns the bytes needed to marshal a field container, whose fields take 'size' bytes.
+func SizeFields(size int) int {
+	return SizeUint(uint(size)) + size
+}
+
+// Returns the new offset 'n' after marshalling a field container, whose fields take 'size'
+// bytes and are marshalled by 'marshal', see MarshalField.
+//
+// !- Panics, if 'b' is too small.
+func MarshalFields(n int, b []byte, size int, marshal func(n int, b []byte) int) int {
+	n = MarshalUint(n, b, uint(size))
+	return marshal(n, b)
+}
+
+// Returns the new offset 'n' after skipping the marshalled field container, checking the
+// ids and lengths of its fields.
+//
+// Possible errors returned:
+//   - ErrOverflow          - varint overflowed a N-bit unsigned integer.
+//   - ErrBufTooSmall       - 'b' or the container was too small to skip a field.
+//
+// If a error is returned, n (the int returned) equals zero ( 0 ).
+func SkipFields(n int, b []byte) (int, error) {
+	return UnmarshalFields(n, b, func(uint16, []byte) error { return nil })
+}
+
+// Returns the new offset 'n' after unmarshalling the field container, calling 'field' with
+// the id and the marshalled value of every field in order. 'value' is a subslice of 'b'
+// holding the value only, fields of unknown ids are meant to be ignored.
+//
+// Possible errors returned:
+//   - ErrOverflow          - varint overflowed a N-bit unsigned integer.
+//   - ErrBufTooSmall       - 'b' or the container was too small to unmarshal a field.
+//   - any error of 'field'
+//
+// If a error is returned, n (the int returned) equals zero ( 0 ).
+func UnmarshalFields(n int, b []byte, field func(id uint16, value []byte) error) (int, error) {
+	n, fields, err := UnmarshalBytesCropped(n, b)
+	if err != nil {
+		return 0, err
+	}
+	for fn := 0; fn < len(fields); {
+		var id uint16
+		var value []byte
+		if fn, id, err = UnmarshalUint16Varint(fn, fields); err != nil {
+			return 0, err
+		}
+		if fn, value, err = UnmarshalBytesCropped(fn, fields); err != nil {
+			return 0, err
+		}
+		if err = field(id, value); err != nil {
+			return 0, err
+		}
+	}
+	return n, nil
+}
@@ -0,0 +1,64 @@
+package bstd
+
+import (
+	"errors"
+	"testing"
+)
+
+type fieldsPerson struct {
+	Name string
+	Age  int
+}
+
+func TestFields(t *testing.T) {
+	// version 2 reorders the fields and adds Email, which version 1 readers skip
+	email := "a@b.c"
+	s := SizeField(2, 42, SizeInt) + SizeField(3, email, SizeString) + SizeField(1, "benc", SizeString)
+	buf := make([]byte, SizeFields(s)+1)
+	n := MarshalFields(1, buf, s, func(n int, b []byte) int {
+		n = MarshalField(n, b, 2, 42, SizeInt, MarshalInt)
+		n = MarshalField(n, b, 3, email, SizeString, MarshalString)
+		return MarshalField(n, b, 1, "benc", SizeString, MarshalString)
+	})
+	if n != len(buf) {
+		t.Fatalf("expected offset %d, got %d", len(buf), n)
+	}
+
+	var p fieldsPerson
+	var ids []uint16
+	unmarshal := func(id uint16, value []byte) (err error) {
+		ids = append(ids, id)
+		switch id {
+		case 1:
+			_, p.Name, err = UnmarshalString(0, value)
+		case 2:
+			_, p.Age, err = UnmarshalInt(0, value)
+		}
+		return err
+	}
+	n, err := UnmarshalFields(1, buf, unmarshal)
+	if err != nil || n != len(buf) || p != (fieldsPerson{"benc", 42}) || len(ids) != 3 {
+		t.Fatalf("got %+v, ids %v, %d, %v", p, ids, n, err)
+	}
+	if n, err = SkipFields(1, buf); err != nil || n != len(buf) {
+		t.Fatalf("skip got %d, %v", n, err)
+	}
+
+	for i := 1; i < len(buf); i++ {
+		if _, err = UnmarshalFields(1, buf[:i], unmarshal); !errors.Is(err, ErrBufTooSmall) {
+			t.Fatalf("%d bytes: expected ErrBufTooSmall, got %v", i, err)
+		}
+		if _, err = SkipFields(1, buf[:i]); !errors.Is(err, ErrBufTooSmall) {
+			t.Fatalf("%d bytes: expected ErrBufTooSmall, got %v", i, err)
+		}
+	}
+
+	// a field claiming more bytes than its container holds
+	if _, err = SkipFields(0, []byte{3, 1, 5, 0, 0}); !errors.Is(err, ErrBufTooSmall) {
+		t.Fatalf("expected ErrBufTooSmall, got %v", err)
+	}
+	errField := errors.New("field")
+	if n, err = UnmarshalFields(1, buf, func(uint16, []byte) error { return errField }); err != errField || n != 0 {
+		t.Fatalf("expected the error of the callback, got %d, %v", n, err)
+	}
+}
@@ -0,0 +1,63 @@
+package bstd
+
+// A flag byte packs up to 8 bools into a single byte, the first bool in the lowest bit, e.g.
+// the options of a message:
+//
+//	n = bstd.MarshalFlags(n, b, msg.Compressed, msg.Encrypted, msg.Final)
+//	n, err = bstd.UnmarshalFlags(n, b, &msg.Compressed, &msg.Encrypted, &msg.Final)
+//
+// The go generator writes the bools of a struct with a //benc:flags byte comment this way.
+
+// Returns the new offset 'n' after skipping the flag byte.
+//
+// Possible errors returned:
+//   - ErrBufTooSmall       - 'b' was too small to skip the flag byte.
+//
+// If a error is returned, n (the int returned) equals zero ( 0 ).
+func SkipFlags(n int, b []byte) (int, error) {
+	return SkipByte(n, b)
+}
+
+// Returns the bytes needed to marshal a flag byte.
+func SizeFlags() int {
+	return 1
+}
+
+// Returns the new offset 'n' after marshalling the bools 'flags' as a flag byte.
+//
+// !- Panics, if 'b' is too small or there are more than 8 flags.
+func MarshalFlags(n int, b []byte, flags ...bool) int {
+	if len(flags) > 8 {
+		panic("benc: more than 8 `flags` provided in `MarshalFlags`")
+	}
+	var byt byte
+	for i, f := range flags {
+		if f {
+			byt |= 1 << i
+		}
+	}
+	return MarshalByte(n, b, byt)
+}
+
+// Returns the new offset 'n' after unmarshalling the flag byte into the bools 'flags'. Bits
+// past the flags are ignored.
+//
+// Possible errors returned:
+//   - ErrBufTooSmall       - 'b' was too small to unmarshal the flag byte.
+//
+// If a error is returned, n (the int returned) equals zero ( 0 ).
+//
+// !- Panics, if there are more than 8 flags.
+func UnmarshalFlags(n int, b []byte, flags ...*bool) (int, error) {
+	if len(flags) > 8 {
+		panic("benc: more than 8 `flags` provided in `UnmarshalFlags`")
+	}
+	n, byt, err := UnmarshalByte(n, b)
+	if err != nil {
+		return 0, err
+	}
+	for i, f := range flags {
+		*f = byt&(1<<i) != 0
+	}
+	return n, nil
+}
@@ -0,0 +1,42 @@
+package bstd
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestFlags(t *testing.T) {
+	flags := []bool{true, false, true, true, false, false, false, true}
+	b := make([]byte, SizeFlags())
+	if n := MarshalFlags(0, b, flags...); n != 1 || b[0] != 0b10001101 {
+		t.Fatalf("expected the flag byte 0b10001101, got %#b", b[0])
+	}
+
+	got := make([]bool, len(flags))
+	ptrs := make([]*bool, len(got))
+	for i := range got {
+		ptrs[i] = &got[i]
+	}
+	n, err := UnmarshalFlags(0, b, ptrs...)
+	if err != nil || n != 1 {
+		t.Fatalf("got %d, %v", n, err)
+	}
+	for i := range flags {
+		if got[i] != flags[i] {
+			t.Fatalf("flag %d: expected %v, got %v", i, flags[i], got[i])
+		}
+	}
+
+	// fewer flags than bits leave the remaining bits zero
+	MarshalFlags(0, b, false, true)
+	var first, second bool
+	if _, err = UnmarshalFlags(0, b, &first, &second); err != nil || b[0] != 0b10 || first || !second {
+		t.Fatalf("expected only the second flag set, got %#b, %v", b[0], err)
+	}
+	if _, err = UnmarshalFlags(0, nil, &first); !errors.Is(err, ErrBufTooSmall) {
+		t.Fatalf("expected ErrBufTooSmall, got %v", err)
+	}
+	if _, err = SkipFlags(0, b); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,121 @@
+package bstd
+
+import "math"
+
+// The float16 encoding stores a float32 as IEEE 754 half precision float in 2 bytes,
+// for payloads where the precision loss is acceptable. Values are rounded to the nearest
+// half precision float (ties to even), values out of its range become infinities and
+// NaNs stay NaNs.
+
+// Returns the new offset 'n' after skipping the marshalled 16-bit float.
+//
+// Possible errors returned:
+//   - ErrBufTooSmall       - 'b' was too small to skip the marshalled 16-bit float.
+//
+// If a error is returned, n (the int returned) equals zero ( 0 ).
+func SkipFloat16(n int, b []byte) (int, error) {
+	if len(b)-n < 2 {
+		return 0, ErrBufTooSmall
+	}
+	return n + 2, nil
+}
+
+// Returns the bytes needed to marshal a 16-bit float.
+func SizeFloat16() int {
+	return 2
+}
+
+// Returns the new offset 'n' after marshalling the 32-bit float 'v' as 16-bit float.
+func MarshalFloat16(n int, b []byte, v float32) int {
+	h := float32ToHalf(v)
+	u := b[n : n+2]
+	_ = u[1]
+	u[0] = byte(h)
+	u[1] = byte(h >> 8)
+	return n + 2
+}
+
+// Returns the new offset 'n', as well as the 16-bit float as 32-bit float, that got unmarshalled.
+//
+// Possible errors returned:
+//   - ErrBufTooSmall       - 'b' was too small to unmarshal the 16-bit float.
+//
+// If a error is returned, n (the int returned) equals zero ( 0 ).
+func UnmarshalFloat16(n int, b []byte) (int, float32, error) {
+	if len(b)-n < 2 {
+		return 0, 0, ErrBufTooSmall
+	}
+	u := b[n : n+2]
+	_ = u[1]
+	return n + 2, halfToFloat32(uint16(u[0]) | uint16(u[1])<<8), nil
+}
+
+// float32ToHalf returns the bits of the half precision float nearest to 'v'.
+func float32ToHalf(v float32) uint16 {
+	bits := math.Float32bits(v)
+	sign := uint16(bits>>16) & 0x8000
+	exp := int(bits>>23) & 0xff
+	mant := bits & 0x7fffff
+
+	if exp == 0xff {
+		if mant != 0 {
+			// keep the upper payload bits, which must not all be zero for a NaN
+			if mant>>13 == 0 {
+				return sign | 0x7e00
+			}
+			return sign | 0x7c00 | uint16(mant>>13)
+		}
+		return sign | 0x7c00
+	}
+
+	e := exp - 127 + 15
+	if e >= 0x1f {
+		return sign | 0x7c00
+	}
+	if e <= 0 {
+		// subnormal, or zero if even rounding up can't reach the smallest subnormal
+		if e < -10 {
+			return sign
+		}
+		mant |= 0x800000
+		shift := uint(14 - e)
+		h := mant >> shift
+		rem := mant & (1<<shift - 1)
+		half := uint32(1) << (shift - 1)
+		if rem > half || rem == half && h&1 == 1 {
+			h++
+		}
+		return sign | uint16(h)
+	}
+
+	// a carry of the rounding into the exponent is correct, up to infinity
+	h := uint32(e)<<10 | mant>>13
+	rem := mant & 0x1fff
+	if rem > 0x1000 || rem == 0x1000 && h&1 == 1 {
+		h++
+	}
+	return sign | uint16(h)
+}
+
+// halfToFloat32 returns the float32 of the half precision float bits 'h', which is exact.
+func halfToFloat32(h uint16) float32 {
+	sign := uint32(h&0x8000) << 16
+	exp := int(h>>10) & 0x1f
+	mant := uint32(h & 0x3ff)
+
+	switch exp {
+	case 0x1f:
+		return math.Float32frombits(sign | 0x7f800000 | mant<<13)
+	case 0:
+		if mant == 0 {
+			return math.Float32frombits(sign)
+		}
+		e := -14
+		for mant&0x400 == 0 {
+			mant <<= 1
+			e--
+		}
+		return math.Float32frombits(sign | uint32(e+127)<<23 | (mant&0x3ff)<<13)
+	}
+	return math.Float32frombits(sign | uint32(exp-15+127)<<23 | mant<<13)
+}
@@ -2,13 +2,25 @@ package bstd
 
 import (
 	"bytes"
+	"database/sql"
+	"encoding/json"
 	"fmt"
+	"math/big"
 	"math/rand"
+	"net"
+	"net/netip"
+	"net/url"
 	"time"
+
+	"golang.org/x/exp/constraints"
 )
 
 const MaxDepth = 2 // Controls the maximum nesting level for recursive structs
 
+// LargeMapCount is the number of entries of the maps of GenerateLargeMap, more than a 16-bit
+// count holds.
+const LargeMapCount = 70_000
+
 // region Generic Generators
 
 func RandomCount(r *rand.Rand) int {
@@ -91,6 +103,31 @@ func GenerateBytes(r *rand.Rand, _ int) []byte {
 	return RandomBytes(r, 3+r.Intn(7))
 }
 
+// GenerateVariant returns a random variant, maps and lists only while depth is left.
+func GenerateVariant(r *rand.Rand, depth int) any {
+	kinds := 6
+	if depth > 0 {
+		kinds = 8
+	}
+	switch r.Intn(kinds) {
+	case 0:
+		return nil
+	case 1:
+		return GenerateBool(r, depth)
+	case 2:
+		return GenerateInt64(r, depth) - GenerateInt64(r, depth)
+	case 3:
+		return GenerateFloat64(r, depth)
+	case 4:
+		return GenerateString(r, depth)
+	case 5:
+		return GenerateBytes(r, depth)
+	case 6:
+		return GenerateMap(r, depth-1, GenerateString, GenerateVariant)
+	}
+	return GenerateSlice(r, depth-1, GenerateVariant)
+}
+
 func GenerateRune(r *rand.Rand, _ int) rune {
 	return r.Int31()
 }
@@ -99,6 +136,152 @@ func GenerateTime(r *rand.Rand, _ int) time.Time {
 	return RandomTime(r)
 }
 
+// GenerateTimeWithZone returns a random time in UTC or a fixed zone, which don't depend on the
+// zone database of the system.
+func GenerateTimeWithZone(r *rand.Rand, _ int) time.Time {
+	zones := []*time.Location{time.UTC, time.FixedZone("IST", 19800), time.FixedZone("", -3600)}
+	return RandomTime(r).In(zones[r.Intn(len(zones))])
+}
+
+func GenerateDuration(r *rand.Rand, _ int) time.Duration {
+	return time.Duration(r.Int63())
+}
+
+func GenerateUUID[T ~[16]byte](r *rand.Rand, _ int) T {
+	var id T
+	r.Read(id[:])
+	return id
+}
+
+func GenerateRawMessage(r *rand.Rand, _ int) json.RawMessage {
+	return json.RawMessage(fmt.Sprintf(`{"id":%d,"name":%q}`, r.Int63(), RandomString(r, 8)))
+}
+
+func GenerateIP(r *rand.Rand, _ int) net.IP {
+	if r.Intn(2) == 0 {
+		return net.IP(RandomBytes(r, net.IPv4len))
+	}
+	return net.IP(RandomBytes(r, net.IPv6len))
+}
+
+func GenerateHardwareAddr(r *rand.Rand, _ int) net.HardwareAddr {
+	return net.HardwareAddr(RandomBytes(r, 6))
+}
+
+func GenerateAddr(r *rand.Rand, _ int) netip.Addr {
+	if r.Intn(2) == 0 {
+		return netip.AddrFrom4([4]byte(RandomBytes(r, 4)))
+	}
+	return netip.AddrFrom16([16]byte(RandomBytes(r, 16)))
+}
+
+func GenerateAddrPort(r *rand.Rand, d int) netip.AddrPort {
+	return netip.AddrPortFrom(GenerateAddr(r, d), uint16(r.Intn(65536)))
+}
+
+func GeneratePrefix(r *rand.Rand, d int) netip.Prefix {
+	addr := GenerateAddr(r, d)
+	return netip.PrefixFrom(addr, r.Intn(addr.BitLen()+1))
+}
+
+func GenerateURL(r *rand.Rand, _ int) *url.URL {
+	return &url.URL{
+		Scheme:   "https",
+		Host:     RandomString(r, 8) + ".example",
+		Path:     "/" + RandomString(r, 5) + "/a b",
+		RawQuery: url.Values{"q": {RandomString(r, 4)}}.Encode(),
+	}
+}
+
+// The sql.Null generators leave a quarter of the values invalid, with the zero value, as
+// an invalid value unmarshals as.
+
+func GenerateNullString(r *rand.Rand, d int) sql.NullString {
+	o := GenerateOption(r, d, GenerateString)
+	return sql.NullString{String: o.Value, Valid: o.Valid}
+}
+
+func GenerateNullInt64(r *rand.Rand, d int) sql.NullInt64 {
+	o := GenerateOption(r, d, GenerateInt64)
+	return sql.NullInt64{Int64: o.Value, Valid: o.Valid}
+}
+
+func GenerateNullInt32(r *rand.Rand, d int) sql.NullInt32 {
+	o := GenerateOption(r, d, GenerateInt32)
+	return sql.NullInt32{Int32: o.Value, Valid: o.Valid}
+}
+
+func GenerateNullInt16(r *rand.Rand, d int) sql.NullInt16 {
+	o := GenerateOption(r, d, GenerateInt16)
+	return sql.NullInt16{Int16: o.Value, Valid: o.Valid}
+}
+
+func GenerateNullByte(r *rand.Rand, d int) sql.NullByte {
+	o := GenerateOption(r, d, GenerateByte)
+	return sql.NullByte{Byte: o.Value, Valid: o.Valid}
+}
+
+func GenerateNullFloat64(r *rand.Rand, d int) sql.NullFloat64 {
+	o := GenerateOption(r, d, GenerateFloat64)
+	return sql.NullFloat64{Float64: o.Value, Valid: o.Valid}
+}
+
+func GenerateNullBool(r *rand.Rand, d int) sql.NullBool {
+	o := GenerateOption(r, d, GenerateBool)
+	return sql.NullBool{Bool: o.Value, Valid: o.Valid}
+}
+
+func GenerateNullTime(r *rand.Rand, d int) sql.NullTime {
+	o := GenerateOption(r, d, GenerateTime)
+	return sql.NullTime{Time: o.Value, Valid: o.Valid}
+}
+
+func GenerateBigInt(r *rand.Rand, _ int) *big.Int {
+	x := new(big.Int).SetUint64(r.Uint64())
+	x.Lsh(x, uint(r.Intn(128)))
+	if r.Intn(2) == 1 {
+		x.Neg(x)
+	}
+	return x
+}
+
+func GenerateBigFloat(r *rand.Rand, d int) *big.Float {
+	x := new(big.Float).SetPrec(uint(53 + r.Intn(200)))
+	x.SetInt(GenerateBigInt(r, d))
+	return x.Quo(x, big.NewFloat(3))
+}
+
+func GenerateBigRat(r *rand.Rand, _ int) *big.Rat {
+	return big.NewRat(r.Int63()-r.Int63(), 1+r.Int63n(1_000_000_000))
+}
+
+func GenerateDecimal(r *rand.Rand, _ int) Decimal {
+	return Decimal{Mantissa: r.Int63() - r.Int63(), Scale: int32(r.Intn(10))}
+}
+
+// GenerateBitset returns a random set of integers below 256.
+func GenerateBitset(r *rand.Rand, _ int) Bitset {
+	var s Bitset
+	for range RandomCount(r) {
+		s.Set(r.Intn(256))
+	}
+	return s
+}
+
+// GenerateEnum returns a generator of the constants of the table 'e'.
+func GenerateEnum[T constraints.Integer](e *EnumTable[T]) func(*rand.Rand, int) T {
+	return func(r *rand.Rand, _ int) T {
+		return e.values[r.Intn(len(e.values))]
+	}
+}
+
+// GenerateBigDecimal returns a generator of big decimals created by 'newDecimal', e.g. decimal.NewFromBigInt.
+func GenerateBigDecimal[T any](newDecimal func(*big.Int, int32) T) func(*rand.Rand, int) T {
+	return func(r *rand.Rand, d int) T {
+		return newDecimal(GenerateBigInt(r, d), int32(r.Intn(40)-20))
+	}
+}
+
 //endregion
 
 // region Slice Generators
@@ -142,6 +325,14 @@ func GeneratePointer[T any](r *rand.Rand, depth int, generator func(*rand.Rand,
 	return &v
 }
 
+// GenerateOption randomly returns either an empty option or one holding a generated value.
+func GenerateOption[T any](r *rand.Rand, depth int, generator func(*rand.Rand, int) T) Option[T] {
+	if r.Intn(4) == 0 {
+		return Option[T]{}
+	}
+	return Some(generator(r, depth))
+}
+
 // region Map Generators
 
 func GenerateMap[K comparable, V any](r *rand.Rand, depth int, keyGen func(*rand.Rand, int) K, valGen func(*rand.Rand, int) V) map[K]V {
@@ -153,6 +344,17 @@ func GenerateMap[K comparable, V any](r *rand.Rand, depth int, keyGen func(*rand
 	return m
 }
 
+// GenerateLargeMap returns a map of LargeMapCount entries, or of fewer, if the keys of keyGen
+// repeat too often, e.g. bools. Unlike the few entries of GenerateMap, these exercise the
+// order of a hash map and counts beyond 16 bits.
+func GenerateLargeMap[K comparable, V any](r *rand.Rand, depth int, keyGen func(*rand.Rand, int) K, valGen func(*rand.Rand, int) V) map[K]V {
+	m := make(map[K]V, LargeMapCount)
+	for i := 0; i < 2*LargeMapCount && len(m) < LargeMapCount; i++ {
+		m[keyGen(r, depth)] = valGen(r, depth)
+	}
+	return m
+}
+
 //endregion
 
 // Helper functions for random generation
@@ -171,8 +373,12 @@ func RandomBytes(r *rand.Rand, length int) []byte {
 	return b
 }
 
+// randomTimeEpoch is the earliest time of RandomTime, which depends on 'r' only, so the seeds
+// of the generated tests reproduce their data.
+var randomTimeEpoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
 func RandomTime(r *rand.Rand) time.Time {
-	return time.Now().Add(time.Duration(r.Int63n(1000000)) * time.Second)
+	return randomTimeEpoch.Add(time.Duration(r.Int63n(1000000))*time.Second + time.Duration(r.Int63n(int64(time.Second)))).Local()
 }
 
 func RandomTimePtr(r *rand.Rand) *time.Time {
@@ -194,6 +400,90 @@ func ComparePrimitive[T comparable](a, b T) error {
 	return nil
 }
 
+func CompareRawMessage(a, b json.RawMessage) error {
+	return CompareBytes(a, b)
+}
+
+func CompareIP(a, b net.IP) error {
+	return CompareBytes(a, b)
+}
+
+func CompareHardwareAddr(a, b net.HardwareAddr) error {
+	return CompareBytes(a, b)
+}
+
+// CompareTime compares the instants of the times, see MarshalTime.
+func CompareTime(a, b time.Time) error {
+	if !a.Equal(b) {
+		return fmt.Errorf("mismatch: %v != %v", a, b)
+	}
+	return nil
+}
+
+// CompareTimeWithZone compares the instants and the zones of the times.
+func CompareTimeWithZone(a, b time.Time) error {
+	an, ao := a.Zone()
+	bn, bo := b.Zone()
+	if !a.Equal(b) || an != bn || ao != bo {
+		return fmt.Errorf("mismatch: %v != %v", a, b)
+	}
+	return nil
+}
+
+func CompareURL(a, b *url.URL) error {
+	if (a == nil) != (b == nil) || (a != nil && a.String() != b.String()) {
+		return fmt.Errorf("mismatch: %v != %v", a, b)
+	}
+	return nil
+}
+
+// CompareNullTime compares the instants of the times, which lose their location and
+// monotonic clock reading when marshalled.
+func CompareNullTime(a, b sql.NullTime) error {
+	if a.Valid != b.Valid || !a.Time.Equal(b.Time) {
+		return fmt.Errorf("mismatch: %v != %v", a, b)
+	}
+	return nil
+}
+
+func CompareBigInt(a, b *big.Int) error {
+	if (a == nil) != (b == nil) || (a != nil && a.Cmp(b) != 0) {
+		return fmt.Errorf("mismatch: %v != %v", a, b)
+	}
+	return nil
+}
+
+// CompareBitset compares the integers of two sets, which lose their trailing zero words when
+// marshalled.
+func CompareBitset(a, b Bitset) error {
+	if !a.Equal(b) {
+		return fmt.Errorf("mismatch: %v != %v", a.Indices(), b.Indices())
+	}
+	return nil
+}
+
+// CompareBigDecimal compares the representation of two big decimals, not just their values.
+func CompareBigDecimal[T BigDecimal](a, b T) error {
+	if a.Exponent() != b.Exponent() || a.Coefficient().Cmp(b.Coefficient()) != 0 {
+		return fmt.Errorf("mismatch: %ve%d != %ve%d", a.Coefficient(), a.Exponent(), b.Coefficient(), b.Exponent())
+	}
+	return nil
+}
+
+func CompareBigFloat(a, b *big.Float) error {
+	if (a == nil) != (b == nil) || (a != nil && (a.Cmp(b) != 0 || a.Prec() != b.Prec() || a.Mode() != b.Mode())) {
+		return fmt.Errorf("mismatch: %v != %v", a, b)
+	}
+	return nil
+}
+
+func CompareBigRat(a, b *big.Rat) error {
+	if (a == nil) != (b == nil) || (a != nil && a.Cmp(b) != 0) {
+		return fmt.Errorf("mismatch: %v != %v", a, b)
+	}
+	return nil
+}
+
 func CompareBytes(a, b []byte) error {
 	if !bytes.Equal(a, b) {
 		return fmt.Errorf("mismatch: %x != %x", a, b)
@@ -201,6 +491,41 @@ func CompareBytes(a, b []byte) error {
 	return nil
 }
 
+// CompareVariant compares two variants, nested maps and lists included. An int equals
+// the int64 it unmarshals as.
+func CompareVariant(a, b any) error {
+	if i, ok := a.(int); ok {
+		a = int64(i)
+	}
+	if i, ok := b.(int); ok {
+		b = int64(i)
+	}
+	switch x := a.(type) {
+	case []byte:
+		y, ok := b.([]byte)
+		if !ok {
+			return fmt.Errorf("mismatch: %T != %T", a, b)
+		}
+		return CompareBytes(x, y)
+	case map[string]any:
+		y, ok := b.(map[string]any)
+		if !ok {
+			return fmt.Errorf("mismatch: %T != %T", a, b)
+		}
+		return CompareMap(x, y, CompareVariant)
+	case []any:
+		y, ok := b.([]any)
+		if !ok {
+			return fmt.Errorf("mismatch: %T != %T", a, b)
+		}
+		return CompareSlice(x, y, CompareVariant)
+	}
+	if a != b {
+		return fmt.Errorf("mismatch: %v (%T) != %v (%T)", a, a, b, b)
+	}
+	return nil
+}
+
 func CompareSlice[T any](a, b []T, elemCmp func(T, T) error) error {
 	if len(a) != len(b) {
 		return fmt.Errorf("length mismatch: %d != %d", len(a), len(b))
@@ -249,6 +574,17 @@ func ComparePointer[T any](a, b *T, elemCmp func(T, T) error) error {
 	return elemCmp(*a, *b)
 }
 
+// CompareOption compares two options, the values only if both are present.
+func CompareOption[T any](a, b Option[T], elemCmp func(T, T) error) error {
+	if a.Valid != b.Valid {
+		return fmt.Errorf("mismatch: a valid %v, b valid %v", a.Valid, b.Valid)
+	}
+	if !a.Valid {
+		return nil
+	}
+	return elemCmp(a.Value, b.Value)
+}
+
 // ComparePointerKeyMap handles maps where the key is a pointer. 
 // Standard lookup fails because unmarshalling allocates new addresses.
 func ComparePointerKeyMap[K comparable, V any](a, b map[*K]V, valCmp func(V, V) error) error {
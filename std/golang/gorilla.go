@@ -0,0 +1,192 @@
+package bstd
+
+import (
+	"errors"
+	"math"
+	"math/bits"
+)
+
+// The Gorilla encoding of a float64 slice, as described in "Gorilla: A Fast, Scalable,
+// In-Memory Time Series Database", XORs every value with the one before it and stores
+// only the bits that differ. Series of slowly changing values, like metrics sampled at a
+// fixed interval, need a few bits per value instead of 64.
+//
+// The slice is stored as the number of values, followed by the length of the bit stream
+// in bytes and the bit stream itself. The stream holds the first value in full, then for
+// every value:
+//   - a 0 bit, if it equals the value before it.
+//   - 10 and the differing bits, if they fit into the window of the last XOR.
+//   - 11, the number of leading zeros (5 bits), of differing bits (6 bits, 64 as 0)
+//     and the differing bits, otherwise.
+
+var ErrGorilla = errors.New("gorilla bit stream ends before the last value")
+
+// Returns the new offset 'n' after skipping the marshalled Gorilla encoded slice.
+//
+// Possible errors returned:
+//   - ErrOverflow          - a varint overflowed a N-bit unsigned integer.
+//   - ErrBufTooSmall       - 'b' was too small to skip the marshalled slice.
+//
+// If a error is returned, n (the int returned) equals zero ( 0 ).
+func SkipSliceGorilla(n int, b []byte) (int, error) {
+	n, err := SkipUint(n, b)
+	if err != nil {
+		return 0, err
+	}
+	return SkipBytes(n, b)
+}
+
+// Returns the bytes needed to marshal the slice Gorilla encoded.
+func SizeSliceGorilla(slice []float64) int {
+	var w bitWriter
+	encodeGorilla(&w, slice)
+	l := (w.bits + 7) / 8
+	return SizeUint(uint(len(slice))) + SizeUint(uint(l)) + l
+}
+
+// Returns the new offset 'n' after marshalling the slice Gorilla encoded.
+//
+// !- Panics, if 'b' is too small.
+func MarshalSliceGorilla(n int, b []byte, slice []float64) int {
+	var w bitWriter
+	encodeGorilla(&w, slice)
+	l := (w.bits + 7) / 8
+
+	n = MarshalUint(n, b, uint(len(slice)))
+	n = MarshalUint(n, b, uint(l))
+	w = bitWriter{b: b[n : n+l]}
+	clear(w.b)
+	encodeGorilla(&w, slice)
+	return n + l
+}
+
+// Returns the new offset 'n', as well as the Gorilla encoded slice, that got unmarshalled.
+//
+// Possible errors returned:
+//   - ErrOverflow          - a varint overflowed a N-bit unsigned integer.
+//   - ErrBufTooSmall       - 'b' was too small to unmarshal the slice.
+//   - ErrGorilla           - the bit stream holds fewer values than the slice.
+//
+// If a error is returned, n (the int returned) equals zero ( 0 ).
+func UnmarshalSliceGorilla(n int, b []byte) (int, []float64, error) {
+	n, count, err := UnmarshalUint(n, b)
+	if err != nil {
+		return 0, nil, err
+	}
+	n, stream, err := UnmarshalBytesCropped(n, b)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	// every value but the first takes at least one bit, which bounds the allocation up front
+	r := bitReader{b: stream}
+	fs := make([]float64, 0, min(count, uint(len(stream))*8))
+	var prev uint64
+	leading, trailing := 0, 0
+	for i := range count {
+		v, ok := prev, true
+		switch {
+		case i == 0:
+			v, ok = r.read(64)
+		case !r.bit():
+		case !r.bit():
+			var xor uint64
+			xor, ok = r.read(64 - leading - trailing)
+			v ^= xor << trailing
+		default:
+			l, _ := r.read(5)
+			m, _ := r.read(6)
+			if m == 0 {
+				m = 64
+			}
+			leading, trailing = int(l), 64-int(l)-int(m)
+			if trailing < 0 {
+				return 0, nil, ErrGorilla
+			}
+			var xor uint64
+			xor, ok = r.read(int(m))
+			v ^= xor << trailing
+		}
+		if !ok || r.overrun {
+			return 0, nil, ErrGorilla
+		}
+		fs = append(fs, math.Float64frombits(v))
+		prev = v
+	}
+	return n, fs, nil
+}
+
+func encodeGorilla(w *bitWriter, slice []float64) {
+	var prev uint64
+	leading, trailing := -1, 0
+	for i, f := range slice {
+		v := math.Float64bits(f)
+		xor := v ^ prev
+		prev = v
+		switch {
+		case i == 0:
+			w.write(v, 64)
+		case xor == 0:
+			w.write(0, 1)
+		default:
+			l, t := min(bits.LeadingZeros64(xor), 31), bits.TrailingZeros64(xor)
+			if leading >= 0 && l >= leading && t >= trailing {
+				w.write(0b10, 2)
+				w.write(xor>>trailing, 64-leading-trailing)
+				continue
+			}
+			leading, trailing = l, t
+			m := 64 - l - t
+			w.write(0b11, 2)
+			w.write(uint64(l), 5)
+			w.write(uint64(m&63), 6)
+			w.write(xor>>t, m)
+		}
+	}
+}
+
+// bitWriter writes bits most significant first into b, or only counts them if b is nil.
+type bitWriter struct {
+	b    []byte
+	bits int
+}
+
+func (w *bitWriter) write(v uint64, n int) {
+	if w.b != nil {
+		for i := n - 1; i >= 0; i-- {
+			if v>>i&1 == 1 {
+				w.b[(w.bits+n-1-i)/8] |= 0x80 >> ((w.bits + n - 1 - i) % 8)
+			}
+		}
+	}
+	w.bits += n
+}
+
+// bitReader reads the bits written by bitWriter. Reading past the end yields zeros and
+// sets overrun.
+type bitReader struct {
+	b       []byte
+	bits    int
+	overrun bool
+}
+
+func (r *bitReader) bit() bool {
+	if r.bits >= len(r.b)*8 {
+		r.overrun = true
+		return false
+	}
+	v := r.b[r.bits/8]>>(7-r.bits%8)&1 == 1
+	r.bits++
+	return v
+}
+
+func (r *bitReader) read(n int) (uint64, bool) {
+	var v uint64
+	for range n {
+		v <<= 1
+		if r.bit() {
+			v |= 1
+		}
+	}
+	return v, !r.overrun
+}
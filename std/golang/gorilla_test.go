@@ -0,0 +1,59 @@
+package bstd
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestSliceGorilla(t *testing.T) {
+	series := make([]float64, 1000)
+	for i := range series {
+		series[i] = 20 + float64(i%7)*0.5
+	}
+	for _, v := range [][]float64{{}, {1.5}, {1, 1, 1}, {0, -1, math.Inf(1), math.MaxFloat64, math.SmallestNonzeroFloat64, math.Float64frombits(1 << 63)}, series} {
+		s := SizeSliceGorilla(v)
+		buf := make([]byte, s)
+		for i := range buf {
+			buf[i] = 0xff
+		}
+		if n := MarshalSliceGorilla(0, buf, v); n != s {
+			t.Fatalf("%v: expected offset %d, got %d", v, s, n)
+		}
+		n, got, err := UnmarshalSliceGorilla(0, buf)
+		if err != nil || n != s || len(got) != len(v) || len(v) > 0 && !reflect.DeepEqual(got, v) {
+			t.Fatalf("%v: got %v, %d, %v", v, got, n, err)
+		}
+		if n, err = SkipSliceGorilla(0, buf); err != nil || n != s {
+			t.Fatalf("%v: skip got %d, %v", v, n, err)
+		}
+		for i := range s {
+			if _, _, err = UnmarshalSliceGorilla(0, buf[:i]); !errors.Is(err, ErrBufTooSmall) {
+				t.Fatalf("%v: %d bytes: expected ErrBufTooSmall, got %v", v, i, err)
+			}
+			if _, err = SkipSliceGorilla(0, buf[:i]); !errors.Is(err, ErrBufTooSmall) {
+				t.Fatalf("%v: %d bytes: expected ErrBufTooSmall, got %v", v, i, err)
+			}
+		}
+	}
+
+	nan := math.Float64frombits(0x7ff8000000000001)
+	buf := make([]byte, SizeSliceGorilla([]float64{nan, nan}))
+	MarshalSliceGorilla(0, buf, []float64{nan, nan})
+	if _, got, err := UnmarshalSliceGorilla(0, buf); err != nil || math.Float64bits(got[1]) != 0x7ff8000000000001 {
+		t.Fatalf("expected the NaN bits to be kept, got %v, %v", got, err)
+	}
+
+	if s := SizeSliceGorilla(series); s > 1000 {
+		t.Fatalf("expected the series to take less than a byte per value, got %d bytes", s)
+	}
+
+	// a stream length beyond math.MaxInt must not wrap around
+	if _, _, err := UnmarshalSliceGorilla(0, []byte{1, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01}); !errors.Is(err, ErrBufTooSmall) {
+		t.Fatalf("expected ErrBufTooSmall, got %v", err)
+	}
+	if _, _, err := UnmarshalSliceGorilla(0, []byte{2, 1, 0}); err != ErrGorilla {
+		t.Fatalf("expected ErrGorilla, got %v", err)
+	}
+}
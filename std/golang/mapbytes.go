@@ -0,0 +1,160 @@
+package bstd
+
+// The binary-valued maps, map[string][]byte and map[uint64][]byte, hold attachments or
+// extensions keyed by name or id. Their functions marshal them like SizeMap, MarshalMap and
+// UnmarshalMap do, without calling a function per key and value, and the Cropped variants
+// unmarshal the values without copying them. The go generator uses them for fields of these
+// types.
+
+// Returns the bytes needed to marshal the map.
+func SizeMapStringBytes(m map[string][]byte) int {
+	s := 4 + SizeUint(uint(len(m)))
+	for k, v := range m {
+		s += SizeString(k) + SizeBytes(v)
+	}
+	return s
+}
+
+// Returns the new offset 'n' after marshalling the map.
+//
+// !- Panics, if 'b' is too small.
+func MarshalMapStringBytes(n int, b []byte, m map[string][]byte) int {
+	n = MarshalUint(n, b, uint(len(m)))
+	for k, v := range m {
+		n = MarshalString(n, b, k)
+		n = MarshalBytes(n, b, v)
+	}
+	return marshalMapTerminator(n, b)
+}
+
+// Returns the new offset 'n', as well as the map, that got unmarshalled. The values are copied.
+//
+// Possible errors returned:
+//   - ErrOverflow          - varint overflowed a N-bit unsigned integer.
+//   - ErrBufTooSmall       - 'b' was too small to unmarshal the map.
+//
+// If a error is returned, n (the int returned) equals zero ( 0 ).
+func UnmarshalMapStringBytes(n int, b []byte) (int, map[string][]byte, error) {
+	return unmarshalMapBytes(n, b, UnmarshalString, true)
+}
+
+// Returns the new offset 'n', as well as the map, that got unmarshalled. The values are cropped
+// slices of 'b', see UnmarshalBytesCropped, so they change with 'b'.
+//
+// Possible errors returned:
+//   - ErrOverflow          - varint overflowed a N-bit unsigned integer.
+//   - ErrBufTooSmall       - 'b' was too small to unmarshal the map.
+//
+// If a error is returned, n (the int returned) equals zero ( 0 ).
+func UnmarshalMapStringBytesCropped(n int, b []byte) (int, map[string][]byte, error) {
+	return unmarshalMapBytes(n, b, UnmarshalString, false)
+}
+
+// Returns the new offset 'n' after skipping the marshalled map.
+//
+// Possible errors returned:
+//   - ErrOverflow          - varint overflowed a N-bit unsigned integer.
+//   - ErrBufTooSmall       - 'b' was too small to skip the marshalled map.
+//
+// If a error is returned, n (the int returned) equals zero ( 0 ).
+func SkipMapStringBytes(n int, b []byte) (int, error) {
+	return SkipMap(n, b, SkipString, SkipBytes)
+}
+
+// Returns the bytes needed to marshal the map.
+func SizeMapUint64Bytes(m map[uint64][]byte) int {
+	s := 4 + SizeUint(uint(len(m))) + len(m)*SizeUint64()
+	for _, v := range m {
+		s += SizeBytes(v)
+	}
+	return s
+}
+
+// Returns the new offset 'n' after marshalling the map.
+//
+// !- Panics, if 'b' is too small.
+func MarshalMapUint64Bytes(n int, b []byte, m map[uint64][]byte) int {
+	n = MarshalUint(n, b, uint(len(m)))
+	for k, v := range m {
+		n = MarshalUint64(n, b, k)
+		n = MarshalBytes(n, b, v)
+	}
+	return marshalMapTerminator(n, b)
+}
+
+// Returns the new offset 'n', as well as the map, that got unmarshalled. The values are copied.
+//
+// Possible errors returned:
+//   - ErrOverflow          - varint overflowed a N-bit unsigned integer.
+//   - ErrBufTooSmall       - 'b' was too small to unmarshal the map.
+//
+// If a error is returned, n (the int returned) equals zero ( 0 ).
+func UnmarshalMapUint64Bytes(n int, b []byte) (int, map[uint64][]byte, error) {
+	return unmarshalMapBytes(n, b, UnmarshalUint64, true)
+}
+
+// Returns the new offset 'n', as well as the map, that got unmarshalled. The values are cropped
+// slices of 'b', see UnmarshalBytesCropped, so they change with 'b'.
+//
+// Possible errors returned:
+//   - ErrOverflow          - varint overflowed a N-bit unsigned integer.
+//   - ErrBufTooSmall       - 'b' was too small to unmarshal the map.
+//
+// If a error is returned, n (the int returned) equals zero ( 0 ).
+func UnmarshalMapUint64BytesCropped(n int, b []byte) (int, map[uint64][]byte, error) {
+	return unmarshalMapBytes(n, b, UnmarshalUint64, false)
+}
+
+// Returns the new offset 'n' after skipping the marshalled map.
+//
+// Possible errors returned:
+//   - ErrOverflow          - varint overflowed a N-bit unsigned integer.
+//   - ErrBufTooSmall       - 'b' was too small to skip the marshalled map.
+//
+// If a error is returned, n (the int returned) equals zero ( 0 ).
+func SkipMapUint64Bytes(n int, b []byte) (int, error) {
+	return SkipMap(n, b, SkipUint64, SkipBytes)
+}
+
+// marshalMapTerminator marshals the 4 bytes ending every map.
+func marshalMapTerminator(n int, b []byte) int {
+	u := b[n : n+4]
+	_ = u[3]
+	u[0] = byte(1)
+	u[1] = byte(1)
+	u[2] = byte(1)
+	u[3] = byte(1)
+	return n + 4
+}
+
+// unmarshalMapBytes unmarshals a binary-valued map with the keys of 'unmarshalKey', copying
+// the values if 'copied' is set.
+func unmarshalMapBytes[K comparable](n int, b []byte, unmarshalKey func(n int, b []byte) (int, K, error), copied bool) (int, map[K][]byte, error) {
+	n, us, err := UnmarshalUint(n, b)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	var k K
+	var v []byte
+	m := make(map[K][]byte, min(us, uint(len(b)-n)))
+	for range us {
+		if n, k, err = unmarshalKey(n, b); err != nil {
+			return 0, nil, err
+		}
+		if copied {
+			n, v, err = UnmarshalBytesCopied(n, b)
+		} else {
+			n, v, err = UnmarshalBytesCropped(n, b)
+		}
+		if err != nil {
+			return 0, nil, err
+		}
+		m[k] = v
+	}
+
+	if len(b)-n < 4 {
+		return 0, nil, ErrBufTooSmall
+	}
+	return n + 4, m, nil
+}
@@ -0,0 +1,65 @@
+package bstd
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMapStringBytes(t *testing.T) {
+	m := map[string][]byte{"a": {1, 2}, "": {}, "bc": {3}}
+	s := SizeMapStringBytes(m)
+	if generic := SizeMap(m, SizeString, SizeBytes); s != generic {
+		t.Fatalf("expected size %d, got %d", generic, s)
+	}
+	buf := make([]byte, s)
+	if n := MarshalMapStringBytes(0, buf, m); n != s {
+		t.Fatalf("expected offset %d, got %d", s, n)
+	}
+
+	n, generic, err := UnmarshalMap[string, []byte](0, buf, UnmarshalString, UnmarshalBytesCopied)
+	if err != nil || n != s || !reflect.DeepEqual(generic, m) {
+		t.Fatalf("generic unmarshal: got %v, %d, %v", generic, n, err)
+	}
+	for _, unmarshal := range []func(n int, b []byte) (int, map[string][]byte, error){UnmarshalMapStringBytes, UnmarshalMapStringBytesCropped} {
+		n, got, err := unmarshal(0, buf)
+		if err != nil || n != s || !reflect.DeepEqual(got, m) {
+			t.Fatalf("got %v, %d, %v", got, n, err)
+		}
+	}
+	if n, err := SkipMapStringBytes(0, buf); err != nil || n != s {
+		t.Fatalf("skip: got %d, %v", n, err)
+	}
+
+	_, copied, _ := UnmarshalMapStringBytes(0, buf)
+	_, cropped, _ := UnmarshalMapStringBytesCropped(0, buf)
+	clear(buf)
+	if copied["a"][0] != 1 || cropped["a"][0] != 0 {
+		t.Fatalf("expected the copied values to keep and the cropped ones to share the buffer, got %v and %v", copied, cropped)
+	}
+}
+
+func TestMapUint64Bytes(t *testing.T) {
+	m := map[uint64][]byte{1: {1, 2}, 0: nil, 1 << 40: {3}}
+	s := SizeMapUint64Bytes(m)
+	if generic := SizeMap(m, SizeUint64, SizeBytes); s != generic {
+		t.Fatalf("expected size %d, got %d", generic, s)
+	}
+	buf := make([]byte, s)
+	if n := MarshalMapUint64Bytes(0, buf, m); n != s {
+		t.Fatalf("expected offset %d, got %d", s, n)
+	}
+
+	n, got, err := UnmarshalMapUint64Bytes(0, buf)
+	m[0] = []byte{}
+	if err != nil || n != s || !reflect.DeepEqual(got, m) {
+		t.Fatalf("got %v, %d, %v", got, n, err)
+	}
+	if n, err := SkipMapUint64Bytes(0, buf); err != nil || n != s {
+		t.Fatalf("skip: got %d, %v", n, err)
+	}
+	for i := range s {
+		if n, _, err := UnmarshalMapUint64BytesCropped(0, buf[:i]); err == nil || n != 0 {
+			t.Fatalf("%d bytes: expected an error, got %d", i, n)
+		}
+	}
+}
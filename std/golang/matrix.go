@@ -0,0 +1,158 @@
+package bstd
+
+import (
+	"math"
+	"unsafe"
+)
+
+// A matrix is a [][]T, whose rows all have the same length, e.g. an image or a table of
+// samples. It is marshalled as the number of rows and of columns, followed by the elements row
+// by row, without a length prefix per row:
+//
+//	s := bstd.SizeFixedMatrix(m, bstd.SizeFloat64())
+//	n = bstd.MarshalMatrix(n, b, m, bstd.MarshalFloat64)
+//	n, m, err = bstd.UnmarshalMatrix[float64](n, b, bstd.UnmarshalFloat64)
+//
+// UnmarshalMatrix allocates the elements of all rows at once, so a numeric matrix is a single
+// contiguous block in memory. A matrix without columns is marshalled as one without rows.
+// The matrix and the slice of slices encoding are not interchangeable on the wire.
+
+// matrixCols returns the number of columns of the matrix 'm'.
+//
+// !- Panics, if the rows of 'm' differ in length.
+func matrixCols[T any](m [][]T) int {
+	if len(m) == 0 {
+		return 0
+	}
+	cols := len(m[0])
+	for _, row := range m[1:] {
+		if len(row) != cols {
+			panic("benc: rows of different lengths in a matrix")
+		}
+	}
+	return cols
+}
+
+// Returns the new offset 'n' after skipping the marshalled matrix.
+//
+// Possible errors returned:
+//   - ErrOverflow          - a varint overflowed a N-bit unsigned integer.
+//   - ErrBufTooSmall       - 'b' was too small to skip the marshalled matrix.
+//   - ErrInvalidData       - the matrix has rows, but no columns, or more elements than an int holds.
+//   - any error returned by 'skipElement'.
+//
+// If a error is returned, n (the int returned) equals zero ( 0 ).
+func SkipMatrix(n int, b []byte, skipElement SkipFunc) (int, error) {
+	n, rows, cols, err := unmarshalMatrixHeader(n, b, 0)
+	if err != nil {
+		return 0, err
+	}
+	return SkipArray(n, b, rows*cols, skipElement)
+}
+
+// Returns the bytes needed to marshal the matrix with a dynamic element size.
+//
+// !- Panics, if the rows of 'm' differ in length.
+func SizeMatrix[T any](m [][]T, sizer SizeFunc[T]) int {
+	cols := matrixCols(m)
+	if cols == 0 {
+		return 2 * SizeUint(0)
+	}
+	s := SizeUint(uint(len(m))) + SizeUint(uint(cols))
+	for _, row := range m {
+		for _, t := range row {
+			s += sizer(t)
+		}
+	}
+	return s
+}
+
+// Returns the bytes needed to marshal the matrix with a fixed element size.
+//
+// !- Panics, if the rows of 'm' differ in length.
+func SizeFixedMatrix[T any](m [][]T, elemSize int) int {
+	cols := matrixCols(m)
+	if cols == 0 {
+		return 2 * SizeUint(0)
+	}
+	return SizeUint(uint(len(m))) + SizeUint(uint(cols)) + len(m)*cols*elemSize
+}
+
+// Returns the new offset 'n' after marshalling the matrix.
+//
+// !- Panics, if 'b' is too small or the rows of 'm' differ in length.
+func MarshalMatrix[T any](n int, b []byte, m [][]T, marshaler MarshalFunc[T]) int {
+	cols := matrixCols(m)
+	if cols == 0 {
+		n = MarshalUint(n, b, 0)
+		return MarshalUint(n, b, 0)
+	}
+	n = MarshalUint(n, b, uint(len(m)))
+	n = MarshalUint(n, b, uint(cols))
+	for _, row := range m {
+		for _, t := range row {
+			n = marshaler(n, b, t)
+		}
+	}
+	return n
+}
+
+// Returns the new offset 'n', as well as the matrix, that got unmarshalled. The rows share a
+// single backing array, each capped at its own elements, so appending to a row doesn't
+// overwrite the next one. A matrix without rows unmarshals as nil.
+//
+// Possible errors returned:
+//   - ErrOverflow          - a varint overflowed a N-bit unsigned integer.
+//   - ErrBufTooSmall       - 'b' was too small to unmarshal the matrix.
+//   - ErrInvalidData       - the matrix has rows, but no columns, or more elements than an int holds.
+//   - any error returned by 'unmarshaler'.
+//
+// If a error is returned, n (the int returned) equals zero ( 0 ).
+func UnmarshalMatrix[T any](n int, b []byte, unmarshaler interface{}) (int, [][]T, error) {
+	var minSize uint
+	if unsafe.Sizeof(*new(T)) > 0 {
+		minSize = 1
+	}
+	n, rows, cols, err := unmarshalMatrixHeader(n, b, minSize)
+	if err != nil || rows == 0 {
+		return n, nil, err
+	}
+
+	elems := make([]T, rows*cols)
+	if n, err = UnmarshalArray(n, b, elems, unmarshaler); err != nil {
+		return 0, nil, err
+	}
+	m := make([][]T, rows)
+	for i := range m {
+		m[i] = elems[i*cols : (i+1)*cols : (i+1)*cols]
+	}
+	return n, m, nil
+}
+
+// unmarshalMatrixHeader returns the rows and columns of a matrix, whose elements take at least
+// 'minSize' bytes each, so a forged header fails instead of allocating them. Elements without
+// a size, e.g. struct{}, allocate nothing, so only the count of their matrix is checked.
+func unmarshalMatrixHeader(n int, b []byte, minSize uint) (int, int, int, error) {
+	n, rows, err := UnmarshalUint(n, b)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	n, cols, err := UnmarshalUint(n, b)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	if rows == 0 && cols == 0 {
+		return n, 0, 0, nil
+	}
+	if rows == 0 || cols == 0 {
+		return 0, 0, 0, ErrInvalidData
+	}
+	if minSize == 0 {
+		if rows > math.MaxInt/cols {
+			return 0, 0, 0, ErrInvalidData
+		}
+	} else if avail := uint(len(b)-n) / minSize; cols > avail || rows > avail/cols {
+		return 0, 0, 0, ErrBufTooSmall
+	}
+	return n, int(rows), int(cols), nil
+}
@@ -0,0 +1,81 @@
+package bstd
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestMatrix(t *testing.T) {
+	for _, m := range [][][]float64{nil, {{1.5}}, {{1, 2, 3}, {4, 5, 6}}, {{0}, {-1}, {2}, {3}}} {
+		s := SizeMatrix(m, func(float64) int { return SizeFloat64() })
+		if fs := SizeFixedMatrix(m, SizeFloat64()); fs != s {
+			t.Fatalf("%v: expected fixed size %d, got %d", m, s, fs)
+		}
+		buf := make([]byte, s)
+		if n := MarshalMatrix(0, buf, m, MarshalFloat64); n != s {
+			t.Fatalf("%v: expected offset %d, got %d", m, s, n)
+		}
+		n, got, err := UnmarshalMatrix[float64](0, buf, UnmarshalFloat64)
+		if err != nil || n != s || !reflect.DeepEqual(got, m) {
+			t.Fatalf("%v: got %v, %d, %v", m, got, n, err)
+		}
+		if n, err = SkipMatrix(0, buf, SkipFloat64); err != nil || n != s {
+			t.Fatalf("%v: skip got %d, %v", m, n, err)
+		}
+		for i := range s {
+			if _, _, err = UnmarshalMatrix[float64](0, buf[:i], UnmarshalFloat64); !errors.Is(err, ErrBufTooSmall) {
+				t.Fatalf("%v: %d bytes: expected ErrBufTooSmall, got %v", m, i, err)
+			}
+			if _, err = SkipMatrix(0, buf[:i], SkipFloat64); !errors.Is(err, ErrBufTooSmall) {
+				t.Fatalf("%v: %d bytes: expected ErrBufTooSmall, got %v", m, i, err)
+			}
+		}
+	}
+
+	// the rows share one backing array, appending to a row leaves the next one alone
+	m := [][]string{{"a", "b"}, {"c", "d"}}
+	buf := make([]byte, SizeMatrix(m, SizeString))
+	MarshalMatrix(0, buf, m, MarshalString)
+	_, got, err := UnmarshalMatrix[string](0, buf, func(n int, b []byte, v *string) (int, error) {
+		var err error
+		n, *v, err = UnmarshalString(n, b)
+		return n, err
+	})
+	if err != nil || !reflect.DeepEqual(got, m) {
+		t.Fatalf("got %v, %v", got, err)
+	}
+	_ = append(got[0], "x")
+	if got[1][0] != "c" {
+		t.Fatalf("appending to a row changed the next one: %v", got)
+	}
+
+	// a matrix without columns is one without rows
+	buf = make([]byte, SizeFixedMatrix([][]int32{{}, {}}, SizeInt32()))
+	MarshalMatrix(0, buf, [][]int32{{}, {}}, MarshalInt32)
+	if _, got, err := UnmarshalMatrix[int32](0, buf, UnmarshalInt32); err != nil || got != nil {
+		t.Fatalf("expected a nil matrix, got %v, %v", got, err)
+	}
+	if _, _, err := UnmarshalMatrix[int32](0, []byte{2, 0}, UnmarshalInt32); err != ErrInvalidData {
+		t.Fatalf("expected ErrInvalidData, got %v", err)
+	}
+
+	// elements without a size aren't bounded by the buffer
+	empty := [][]struct{}{make([]struct{}, 3), make([]struct{}, 3)}
+	buf = make([]byte, SizeFixedMatrix(empty, 0))
+	MarshalMatrix(0, buf, empty, func(n int, _ []byte, _ struct{}) int { return n })
+	_, gotEmpty, err := UnmarshalMatrix[struct{}](0, buf, func(n int, _ []byte) (int, struct{}, error) { return n, struct{}{}, nil })
+	if err != nil || !reflect.DeepEqual(gotEmpty, empty) {
+		t.Fatalf("got %v, %v", gotEmpty, err)
+	}
+	if n, err := SkipMatrix(0, buf, func(n int, _ []byte) (int, error) { return n, nil }); err != nil || n != len(buf) {
+		t.Fatalf("got %d, %v", n, err)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected a panic on rows of different lengths")
+		}
+	}()
+	SizeFixedMatrix([][]int32{{1, 2}, {3}}, SizeInt32())
+}
@@ -0,0 +1,64 @@
+package bstd
+
+import (
+	"errors"
+
+	"github.com/banditmoscow1337/benc/wire"
+)
+
+// NUL-terminated string functions
+//
+// A NUL-terminated string is marshalled like a string of its bytes and a trailing NUL, so
+// its length prefix counts the NUL too. C code reads it as char* straight out of the buffer,
+// without copying it into a terminated string first. The generator selects them for the
+// strings of a field with a `benc:"nul"` tag, the C generator unmarshals these to pointers
+// into the buffer. A string containing a NUL itself round trips in Go, but C ends it at its
+// first NUL.
+
+var ErrNoNulTerminator = errors.New("string doesn't end with a NUL terminator")
+
+// Returns the new offset 'n' after skipping the marshalled NUL-terminated string.
+//
+// Possible errors returned:
+//   - ErrOverflow          - varint overflowed a N-bit unsigned integer.
+//   - ErrBufTooSmall       - 'b' was too small to skip the marshalled string.
+//
+// If a error is returned, n (the int returned) equals zero ( 0 ).
+func SkipStringNul(n int, b []byte) (int, error) {
+	return wire.SkipBytes(n, b)
+}
+
+// Returns the bytes needed to marshal a NUL-terminated string.
+func SizeStringNul(str string) int {
+	return wire.SizeUvarint(uint64(len(str)+1)) + len(str) + 1
+}
+
+// Returns the new offset 'n' after marshalling the string and its NUL terminator.
+//
+// !- Panics, if 'b' is too small.
+func MarshalStringNul(n int, b []byte, str string) int {
+	n = wire.MarshalUvarint(n, b, uint64(len(str)+1))
+	n += copy(b[n:], str)
+	b[n] = 0
+	return n + 1
+}
+
+// Returns the new offset 'n', as well as the string without its NUL terminator, that got
+// unmarshalled.
+//
+// Possible errors returned:
+//   - ErrOverflow          - varint overflowed a N-bit unsigned integer.
+//   - ErrBufTooSmall       - 'b' was too small to unmarshal the string.
+//   - ErrNoNulTerminator   - the marshalled string doesn't end with a NUL.
+//
+// If a error is returned, n (the int returned) equals zero ( 0 ).
+func UnmarshalStringNul(n int, b []byte) (int, string, error) {
+	n, bs, err := wire.UnmarshalBytes(n, b)
+	if err != nil {
+		return 0, "", err
+	}
+	if len(bs) == 0 || bs[len(bs)-1] != 0 {
+		return 0, "", ErrNoNulTerminator
+	}
+	return n, string(bs[:len(bs)-1]), nil
+}
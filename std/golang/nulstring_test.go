@@ -0,0 +1,43 @@
+package bstd
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestStringNul(t *testing.T) {
+	for _, str := range []string{"", "benc", "a\x00b", strings.Repeat("x", 200)} {
+		buf := make([]byte, SizeStringNul(str))
+		if n := MarshalStringNul(0, buf, str); n != len(buf) {
+			t.Fatalf("%q: expected offset %d, got %d", str, len(buf), n)
+		}
+		if buf[len(buf)-1] != 0 {
+			t.Fatalf("%q: expected a NUL terminator, got % x", str, buf)
+		}
+		// the same bytes are a plain string with the terminator
+		if _, s, err := UnmarshalString(0, buf); err != nil || s != str+"\x00" {
+			t.Fatalf("%q: got %q, %v", str, s, err)
+		}
+		n, got, err := UnmarshalStringNul(0, buf)
+		if err != nil || n != len(buf) || got != str {
+			t.Fatalf("%q: got %q, %d, %v", str, got, n, err)
+		}
+		if n, err = SkipStringNul(0, buf); err != nil || n != len(buf) {
+			t.Fatalf("%q: skip got %d, %v", str, n, err)
+		}
+		for i := range len(buf) {
+			if _, _, err = UnmarshalStringNul(0, buf[:i]); !errors.Is(err, ErrBufTooSmall) {
+				t.Fatalf("%q, %d bytes: expected ErrBufTooSmall, got %v", str, i, err)
+			}
+		}
+	}
+
+	for _, str := range []string{"", "benc"} {
+		buf := make([]byte, SizeString(str))
+		MarshalString(0, buf, str)
+		if _, _, err := UnmarshalStringNul(0, buf); !errors.Is(err, ErrNoNulTerminator) {
+			t.Fatalf("%q: expected ErrNoNulTerminator, got %v", str, err)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package bstd
+
+// An Option is an optional value that, unlike a pointer, lives inline in its struct or slice,
+// so optional fields don't cost a heap allocation each. It is marshalled like a pointer: a
+// bool telling whether the value is present, followed by the value if it is.
+type Option[T any] struct {
+	Value T
+	Valid bool
+}
+
+// Some returns an Option holding 'v'.
+func Some[T any](v T) Option[T] {
+	return Option[T]{Value: v, Valid: true}
+}
+
+// None returns an empty Option.
+func None[T any]() Option[T] {
+	return Option[T]{}
+}
+
+// Get returns the value and whether it is present.
+func (o Option[T]) Get() (T, bool) {
+	return o.Value, o.Valid
+}
+
+// Returns the new offset 'n' after skipping the marshalled option.
+// The value, if present, is skipped by 'skipElement'.
+//
+// Possible errors returned:
+//   - ErrBufTooSmall       - 'b' was too small to skip the marshalled option.
+//   - any error of 'skipElement'
+//
+// If a error is returned, n (the int returned) equals zero ( 0 ).
+func SkipOption(n int, b []byte, skipElement SkipFunc) (int, error) {
+	return SkipPointer(n, b, skipElement)
+}
+
+// Returns the bytes needed to marshal the option, sizing the value with 'sizer'.
+func SizeOption[T any](o Option[T], sizer SizeFunc[T]) int {
+	if o.Valid {
+		return SizeBool() + sizer(o.Value)
+	}
+	return SizeBool()
+}
+
+// Returns the new offset 'n' after marshalling the option, the value with 'marshaler'.
+// The value of an empty option isn't marshalled, whatever it holds.
+//
+// !- Panics, if 'b' is too small.
+func MarshalOption[T any](n int, b []byte, o Option[T], marshaler MarshalFunc[T]) int {
+	n = MarshalBool(n, b, o.Valid)
+	if o.Valid {
+		n = marshaler(n, b, o.Value)
+	}
+	return n
+}
+
+// Returns the new offset 'n', as well as the option, that got unmarshalled.
+// 'unmarshaler' is either a func(n int, b []byte) (int, T, error) or a
+// func(n int, b []byte, v *T) (int, error), as for UnmarshalPointer.
+//
+// Possible errors returned:
+//   - ErrBufTooSmall       - 'b' was too small to unmarshal the option.
+//   - any error of 'unmarshaler'
+//
+// If a error is returned, n (the int returned) equals zero ( 0 ).
+func UnmarshalOption[T any](n int, b []byte, unmarshaler interface{}) (int, Option[T], error) {
+	var o Option[T]
+	var err error
+	n, o.Valid, err = UnmarshalBool(n, b)
+	if err != nil {
+		return 0, o, err
+	}
+	if !o.Valid {
+		return n, o, nil
+	}
+
+	switch p := unmarshaler.(type) {
+	case func(n int, b []byte) (int, T, error):
+		n, o.Value, err = p(n, b)
+	case func(n int, b []byte, v *T) (int, error):
+		n, err = p(n, b, &o.Value)
+	default:
+		panic("benc: invalid `unmarshaler` provided in `UnmarshalOption`")
+	}
+	if err != nil {
+		return 0, Option[T]{}, err
+	}
+	return n, o, nil
+}
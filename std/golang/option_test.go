@@ -0,0 +1,64 @@
+package bstd
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestOption(t *testing.T) {
+	for _, o := range []Option[string]{Some("benc"), Some(""), None[string](), {Value: "ignored"}} {
+		s := SizeOption(o, SizeString)
+		buf := make([]byte, s)
+		if n := MarshalOption(0, buf, o, MarshalString); n != s {
+			t.Fatalf("%v: expected offset %d, got %d", o, s, n)
+		}
+
+		// the wire format is the one of pointers
+		var p *string
+		if v, ok := o.Get(); ok {
+			p = &v
+		}
+		if ps := SizePointer(p, SizeString); ps != s {
+			t.Fatalf("%v: expected the pointer size %d, got %d", o, ps, s)
+		}
+
+		n, got, err := UnmarshalOption[string](0, buf, UnmarshalString)
+		if err != nil || n != s {
+			t.Fatalf("%v: got %d, %v", o, n, err)
+		}
+		want := o
+		if !want.Valid {
+			want = None[string]()
+		}
+		if got != want {
+			t.Fatalf("expected %v, got %v", want, got)
+		}
+		if n, err = SkipOption(0, buf, SkipString); err != nil || n != s {
+			t.Fatalf("%v: skip got %d, %v", o, n, err)
+		}
+
+		for i := range s {
+			if _, _, err = UnmarshalOption[string](0, buf[:i], UnmarshalString); !errors.Is(err, ErrBufTooSmall) {
+				t.Fatalf("%v, %d bytes: expected ErrBufTooSmall, got %v", o, i, err)
+			}
+			if _, err = SkipOption(0, buf[:i], SkipString); !errors.Is(err, ErrBufTooSmall) {
+				t.Fatalf("%v, %d bytes: expected ErrBufTooSmall, got %v", o, i, err)
+			}
+		}
+	}
+}
+
+func TestOptionPointerUnmarshaler(t *testing.T) {
+	o := Some(int32(-7))
+	buf := make([]byte, SizeOption(o, func(int32) int { return SizeInt32() }))
+	MarshalOption(0, buf, o, MarshalInt32)
+
+	_, got, err := UnmarshalOption[int32](0, buf, func(n int, b []byte, v *int32) (int, error) {
+		n, x, err := UnmarshalInt32(n, b)
+		*v = x
+		return n, err
+	})
+	if err != nil || got != o {
+		t.Fatalf("expected %v, got %v, %v", o, got, err)
+	}
+}
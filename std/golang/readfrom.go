@@ -0,0 +1,156 @@
+package bstd
+
+import (
+	"errors"
+	"io"
+	"slices"
+)
+
+// A Reader unmarshals values from an io.Reader, so a message of a TCP stream or a large file is
+// unmarshalled without reading all of it into memory first. The DecodeFrom methods of the
+// generated code use it to unmarshal a message field by field: strings and byte slices are read
+// into their own memory directly, the other fields are unmarshalled from the buffer of the
+// Reader, which holds a single field at a time and grows up to the maximum size of a value.
+
+var ErrValueTooLarge = errors.New("value exceeds the maximum value size")
+
+// Reader buffers the bytes of an io.Reader for unmarshalling, see NewReader.
+type Reader struct {
+	r io.Reader
+	// buf[start:end] are the bytes read, but not unmarshalled yet
+	buf        []byte
+	start, end int
+	maxSize    int
+}
+
+// minReaderBuf is the size of the buffer of a Reader before its first value.
+const minReaderBuf = 512
+
+// Returns a Reader of 'r', which reads values of at most 'maxSize' bytes, if 'maxSize' is
+// greater than zero. A Reader reads ahead, so the bytes of 'r' after the values read belong
+// to it.
+func NewReader(r io.Reader, maxSize int) *Reader {
+	return &Reader{r: r, maxSize: maxSize}
+}
+
+// Reset discards the bytes buffered and makes the Reader read from 'r', keeping its buffer and
+// maximum size, so a Reader is reused for the next connection, e.g. from a sync.Pool, without
+// growing a new buffer.
+func (r *Reader) Reset(rd io.Reader) {
+	r.r = rd
+	r.start, r.end = 0, 0
+}
+
+// Unmarshal calls 'unmarshal', e.g. the Unmarshal method of a message, with the buffered bytes,
+// reading more of them as long as it returns ErrBufTooSmall, and consumes the bytes unmarshalled.
+// Values unmarshalled must not share the bytes passed to 'unmarshal', which neither the
+// generated code nor the Unmarshal functions other than UnmarshalBytesCropped do.
+//
+// Possible errors returned:
+//   - io.EOF               - 'r' has no more values.
+//   - io.ErrUnexpectedEOF  - 'r' ended in the middle of the value.
+//   - ErrValueTooLarge     - the value is larger than 'maxSize' (if 'maxSize' is greater than zero).
+//   - any other error of 'unmarshal' or of 'r'
+func (r *Reader) Unmarshal(unmarshal func(n int, b []byte) (int, error)) error {
+	for {
+		n, err := unmarshal(0, r.buf[r.start:r.end])
+		if err == nil {
+			r.start += n
+			return nil
+		}
+		if !errors.Is(err, ErrBufTooSmall) {
+			return err
+		}
+		if err = r.fill(); err != nil {
+			return err
+		}
+	}
+}
+
+// UnmarshalString reads a marshalled string, its bytes directly into the returned string.
+//
+// Possible errors returned: see Unmarshal
+func (r *Reader) UnmarshalString() (string, error) {
+	bs, err := r.UnmarshalBytes()
+	if err != nil || len(bs) == 0 {
+		return "", err
+	}
+	return b2s(bs), nil
+}
+
+// UnmarshalBytes reads a marshalled byte slice, its bytes directly into the returned slice,
+// like UnmarshalBytesCopied unmarshals it. The slice grows while its bytes are read, so a
+// corrupted length doesn't allocate more than the stream holds.
+//
+// Possible errors returned: see Unmarshal
+func (r *Reader) UnmarshalBytes() ([]byte, error) {
+	var size uint
+	err := r.Unmarshal(func(n int, b []byte) (int, error) {
+		var err error
+		n, size, err = UnmarshalUint(n, b)
+		return n, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if size > uint(maxInt) || (r.maxSize > 0 && size > uint(r.maxSize)) {
+		return nil, ErrValueTooLarge
+	}
+
+	bs := make([]byte, min(size, uint(max(r.end-r.start, minReaderBuf))))
+	n := copy(bs, r.buf[r.start:r.end])
+	r.start += n
+	for {
+		if _, err = io.ReadFull(r.r, bs[n:]); err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+		if uint(len(bs)) == size {
+			return bs, nil
+		}
+		// doubling, up to the size
+		n = len(bs)
+		grow := min(int(size)-n, n)
+		bs = slices.Grow(bs, grow)[:n+grow]
+	}
+}
+
+// fill reads more bytes into the buffer, growing it if it is full.
+func (r *Reader) fill() error {
+	if r.start > 0 {
+		r.end = copy(r.buf, r.buf[r.start:r.end])
+		r.start = 0
+	}
+	if r.end == len(r.buf) {
+		if r.maxSize > 0 && r.end >= r.maxSize {
+			return ErrValueTooLarge
+		}
+		size := max(2*len(r.buf), minReaderBuf)
+		if r.maxSize > 0 {
+			size = min(size, r.maxSize)
+		}
+		r.buf = append(r.buf, make([]byte, size-len(r.buf))...)
+	}
+
+	n, err := r.r.Read(r.buf[r.end:])
+	r.end += n
+	if n > 0 || err == nil {
+		return nil
+	}
+	if err == io.EOF && r.end > 0 {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// UnexpectedEOF returns io.ErrUnexpectedEOF for io.EOF and any other error as it is. The
+// DecodeFrom methods of the generated code call it on the errors of all but their first
+// field, as the reader ended in the middle of the message there.
+func UnexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
@@ -0,0 +1,96 @@
+package bstd
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"slices"
+	"testing"
+	"testing/iotest"
+)
+
+func TestReader(t *testing.T) {
+	s, bs := "hello", []byte{1, 2, 3}
+	slice := []string{"a", "bc"}
+	buf := make([]byte, SizeString(s)+SizeBytes(bs)+SizeSlice(slice, SizeString))
+	n := MarshalString(0, buf, s)
+	n = MarshalBytes(n, buf, bs)
+	MarshalSlice(n, buf, slice, MarshalString)
+
+	r := NewReader(iotest.OneByteReader(bytes.NewReader(buf)), 0)
+	if rs, err := r.UnmarshalString(); err != nil || rs != s {
+		t.Fatalf("expected %q, got %q, %v", s, rs, err)
+	}
+	if rbs, err := r.UnmarshalBytes(); err != nil || !bytes.Equal(rbs, bs) {
+		t.Fatalf("expected %v, got %v, %v", bs, rbs, err)
+	}
+	var rslice []string
+	err := r.Unmarshal(func(n int, b []byte) (int, error) {
+		var err error
+		n, rslice, err = UnmarshalSlice[string](n, b, UnmarshalString)
+		return n, err
+	})
+	if err != nil || !slices.Equal(rslice, slice) {
+		t.Fatalf("expected %v, got %v, %v", slice, rslice, err)
+	}
+	if _, err = r.UnmarshalString(); err != io.EOF {
+		t.Fatalf("expected io.EOF, got %v", err)
+	}
+
+	skipStrings := func(n int, b []byte) (int, error) { return SkipSlice(n, b, SkipString) }
+	r = NewReader(bytes.NewReader(buf[:len(buf)-1]), 0)
+	r.UnmarshalString()
+	r.UnmarshalBytes()
+	if err = r.Unmarshal(skipStrings); err != io.ErrUnexpectedEOF {
+		t.Fatalf("expected io.ErrUnexpectedEOF, got %v", err)
+	}
+
+	large := make([]byte, 1000)
+	n = MarshalSlice(0, large, []string{string(make([]byte, 900))}, MarshalString)
+	r = NewReader(bytes.NewReader(large[:n]), 600)
+	if err = r.Unmarshal(skipStrings); !errors.Is(err, ErrValueTooLarge) {
+		t.Fatalf("expected ErrValueTooLarge, got %v", err)
+	}
+	r = NewReader(bytes.NewReader(large[1:n]), 600)
+	if _, err = r.UnmarshalString(); !errors.Is(err, ErrValueTooLarge) {
+		t.Fatalf("expected ErrValueTooLarge, got %v", err)
+	}
+
+	// a length of 1 TiB on a stream of a few bytes isn't allocated up front
+	huge := make([]byte, SizeUint(1<<40)+3)
+	MarshalUint(0, huge, 1<<40)
+	r = NewReader(bytes.NewReader(huge), 0)
+	if _, err = r.UnmarshalBytes(); err != io.ErrUnexpectedEOF {
+		t.Fatalf("expected io.ErrUnexpectedEOF, got %v", err)
+	}
+
+	// values larger than the buffer are read whole
+	large = make([]byte, SizeBytes(make([]byte, 5000)))
+	MarshalBytes(0, large, bytes.Repeat([]byte{7}, 5000))
+	r = NewReader(iotest.HalfReader(bytes.NewReader(large)), 0)
+	if rbs, err := r.UnmarshalBytes(); err != nil || !bytes.Equal(rbs, bytes.Repeat([]byte{7}, 5000)) {
+		t.Fatalf("expected 5000 bytes, got %d, %v", len(rbs), err)
+	}
+}
+
+func TestReaderReset(t *testing.T) {
+	buf := make([]byte, SizeString("first")+SizeString("second"))
+	MarshalString(MarshalString(0, buf, "first"), buf, "second")
+
+	// the bytes buffered of the first stream don't leak into the next one
+	r := NewReader(bytes.NewReader(buf), 0)
+	if s, err := r.UnmarshalString(); err != nil || s != "first" {
+		t.Fatalf("expected %q, got %q, %v", "first", s, err)
+	}
+	r.Reset(bytes.NewReader(buf))
+	if s, err := r.UnmarshalString(); err != nil || s != "first" {
+		t.Fatalf("expected %q after Reset, got %q, %v", "first", s, err)
+	}
+
+	if allocs := testing.AllocsPerRun(100, func() {
+		r.Reset(bytes.NewReader(buf))
+		r.Unmarshal(SkipString)
+	}); allocs > 1 {
+		t.Fatalf("expected the buffer to be reused, got %v allocations", allocs)
+	}
+}
@@ -0,0 +1,157 @@
+package bstd
+
+import (
+	"errors"
+	"unsafe"
+)
+
+// The run-length encoding of a slice stores the number of elements, followed by runs of
+// equal elements: the length of the run and the element once. Repetitive slices like tile
+// maps or bitmap masks get a lot smaller, slices without repetition a bit larger than the
+// plain encoding. The RLE and the plain encoding are not interchangeable on the wire.
+
+var ErrRunLength = errors.New("run length is zero or exceeds the slice length")
+var ErrSliceTooLarge = errors.New("expanded slice exceeds 1 GiB")
+
+// maxExpanded bounds the bytes of the elements of a slice expanded from a compact encoding,
+// whose few bytes may declare any number of elements.
+const maxExpanded = 1 << 30
+
+// expandable reports whether 'count' elements of type T fit into maxExpanded bytes.
+func expandable[T any](count uint) bool {
+	var t T
+	return count <= maxExpanded/max(uint(unsafe.Sizeof(t)), 1)
+}
+
+// Returns the new offset 'n' after skipping the marshalled run-length encoded slice.
+//
+// Possible errors returned:
+//   - ErrOverflow          - a varint overflowed a N-bit unsigned integer.
+//   - ErrBufTooSmall       - 'b' was too small to skip the marshalled slice.
+//   - ErrRunLength         - a run is empty or longer than the rest of the slice.
+//   - any error returned by 'skipElement'.
+//
+// If a error is returned, n (the int returned) equals zero ( 0 ).
+func SkipSliceRLE(n int, b []byte, skipElement SkipFunc) (int, error) {
+	n, count, err := UnmarshalUint(n, b)
+	if err != nil {
+		return 0, err
+	}
+	for count > 0 {
+		var run uint
+		if n, run, err = UnmarshalUint(n, b); err != nil {
+			return 0, err
+		}
+		if run == 0 || run > count {
+			return 0, ErrRunLength
+		}
+		if n, err = skipElement(n, b); err != nil {
+			return 0, err
+		}
+		count -= run
+	}
+	return n, nil
+}
+
+// Returns the bytes needed to marshal the slice run-length encoded.
+func SizeSliceRLE[T comparable](slice []T, sizer SizeFunc[T]) int {
+	s := SizeUint(uint(len(slice)))
+	for i := 0; i < len(slice); {
+		run := runLength(slice[i:])
+		s += SizeUint(uint(run)) + sizer(slice[i])
+		i += run
+	}
+	return s
+}
+
+// Returns the new offset 'n' after marshalling the slice run-length encoded.
+//
+// !- Panics, if 'b' is too small.
+func MarshalSliceRLE[T comparable](n int, b []byte, slice []T, marshaler MarshalFunc[T]) int {
+	n = MarshalUint(n, b, uint(len(slice)))
+	for i := 0; i < len(slice); {
+		run := runLength(slice[i:])
+		n = MarshalUint(n, b, uint(run))
+		n = marshaler(n, b, slice[i])
+		i += run
+	}
+	return n
+}
+
+// Returns the new offset 'n', as well as the run-length encoded slice, that got unmarshalled.
+// The slice grows run by run, up to 1 GiB of elements, see UnmarshalSliceRLELimited for
+// untrusted data.
+//
+// Possible errors returned:
+//   - ErrOverflow          - a varint overflowed a N-bit unsigned integer.
+//   - ErrBufTooSmall       - 'b' was too small to unmarshal the slice.
+//   - ErrRunLength         - a run is empty or longer than the rest of the slice.
+//   - ErrSliceTooLarge     - the elements of the slice take more than 1 GiB.
+//   - any error returned by 'unmarshaler'.
+//
+// If a error is returned, n (the int returned) equals zero ( 0 ).
+func UnmarshalSliceRLE[T any](n int, b []byte, unmarshaler func(n int, b []byte) (int, T, error)) (int, []T, error) {
+	return UnmarshalSliceRLELimited(n, b, unmarshaler, Limits{})
+}
+
+// Returns the new offset 'n', as well as the run-length encoded slice, that got unmarshalled.
+// Unlike UnmarshalSliceRLE, it fails if the slice exceeds the limits, MaxElements bounding
+// the elements after expanding the runs in place of the 1 GiB bound.
+//
+// Possible errors returned:
+//   - ErrOverflow          - a varint overflowed a N-bit unsigned integer.
+//   - ErrBufTooSmall       - 'b' was too small to unmarshal the slice.
+//   - ErrRunLength         - a run is empty or longer than the rest of the slice.
+//   - ErrSliceTooLarge     - the elements of the slice take more than 1 GiB, without MaxElements.
+//   - ErrLimitExceeded     - the slice has more elements or bytes than 'limits' allow.
+//   - any error returned by 'unmarshaler'.
+//
+// If a error is returned, n (the int returned) equals zero ( 0 ).
+func UnmarshalSliceRLELimited[T any](n int, b []byte, unmarshaler func(n int, b []byte) (int, T, error), limits Limits) (int, []T, error) {
+	start := n
+	n, count, err := UnmarshalUint(n, b)
+	if err != nil {
+		return 0, nil, err
+	}
+	if limits.MaxElements > 0 {
+		err = limits.checkCount(count)
+	} else if !expandable[T](count) {
+		err = ErrSliceTooLarge
+	}
+	if err != nil {
+		return 0, nil, err
+	}
+
+	// every run takes at least two bytes, which bounds the allocation up front
+	ts := make([]T, 0, min(count, uint(len(b)-n)/2))
+	for remaining := count; remaining > 0; {
+		var run uint
+		if n, run, err = UnmarshalUint(n, b); err != nil {
+			return 0, nil, err
+		}
+		if run == 0 || run > remaining {
+			return 0, nil, ErrRunLength
+		}
+		var t T
+		if n, t, err = unmarshaler(n, b); err != nil {
+			return 0, nil, err
+		}
+		if err = limits.checkBytes(n - start); err != nil {
+			return 0, nil, err
+		}
+		for range run {
+			ts = append(ts, t)
+		}
+		remaining -= run
+	}
+	return n, ts, nil
+}
+
+// runLength returns the number of elements at the start of 's' equal to the first one.
+func runLength[T comparable](s []T) int {
+	i := 1
+	for i < len(s) && s[i] == s[0] {
+		i++
+	}
+	return i
+}
@@ -0,0 +1,69 @@
+package bstd
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestSliceRLE(t *testing.T) {
+	sizeInt32 := func(int32) int { return SizeInt32() }
+	for _, v := range [][]int32{{}, {7}, {1, 1, 1, 1, 2, 2, 1, 3, 3, 3}, {1, 2, 3}} {
+		s := SizeSliceRLE(v, sizeInt32)
+		buf := make([]byte, s)
+		if n := MarshalSliceRLE(0, buf, v, MarshalInt32); n != s {
+			t.Fatalf("%v: expected offset %d, got %d", v, s, n)
+		}
+		n, got, err := UnmarshalSliceRLE(0, buf, UnmarshalInt32)
+		if err != nil || n != s || !reflect.DeepEqual(got, v) {
+			t.Fatalf("%v: got %v, %d, %v", v, got, n, err)
+		}
+		if n, err = SkipSliceRLE(0, buf, SkipInt32); err != nil || n != s {
+			t.Fatalf("%v: skip got %d, %v", v, n, err)
+		}
+		for i := range s {
+			if _, _, err = UnmarshalSliceRLE(0, buf[:i], UnmarshalInt32); !errors.Is(err, ErrBufTooSmall) {
+				t.Fatalf("%v: %d bytes: expected ErrBufTooSmall, got %v", v, i, err)
+			}
+			if _, err = SkipSliceRLE(0, buf[:i], SkipInt32); !errors.Is(err, ErrBufTooSmall) {
+				t.Fatalf("%v: %d bytes: expected ErrBufTooSmall, got %v", v, i, err)
+			}
+		}
+	}
+
+	mask := bytes.Repeat([]byte{0}, 1000)
+	mask[500] = 1
+	if s := SizeSliceRLE(mask, func(byte) int { return SizeByte() }); s != 10 {
+		t.Fatalf("expected 10 bytes for the mask, got %d", s)
+	}
+
+	for _, b := range [][]byte{
+		{3, 0, 1}, // empty run
+		{3, 4, 1}, // run longer than the slice
+		{3, 2, 1, 2, 1},
+	} {
+		if _, _, err := UnmarshalSliceRLE(0, b, UnmarshalByte); err != ErrRunLength {
+			t.Fatalf("%v: expected ErrRunLength, got %v", b, err)
+		}
+		if _, err := SkipSliceRLE(0, b, SkipByte); err != ErrRunLength {
+			t.Fatalf("%v: expected ErrRunLength, got %v", b, err)
+		}
+	}
+	if _, _, err := UnmarshalSliceRLE(0, []byte{1, 1}, UnmarshalInt32); !errors.Is(err, ErrBufTooSmall) {
+		t.Fatalf("expected ErrBufTooSmall, got %v", err)
+	}
+
+	// 16M zeros take a few bytes
+	zeros := make([]byte, 1<<24)
+	buf := make([]byte, SizeSliceRLE(zeros, func(byte) int { return SizeByte() }))
+	MarshalSliceRLE(0, buf, zeros, MarshalByte)
+	if n, got, err := UnmarshalSliceRLE(0, buf, UnmarshalByte); err != nil || n != len(buf) || !bytes.Equal(got, zeros) {
+		t.Fatalf("%d bytes of zeros: got %d elements, %d, %v", len(buf), len(got), n, err)
+	}
+	huge := make([]byte, 2*SizeUint(1<<40)+SizeInt32())
+	MarshalInt32(MarshalUint(MarshalUint(0, huge, 1<<40), huge, 1<<40), huge, 1)
+	if _, _, err := UnmarshalSliceRLE(0, huge, UnmarshalInt32); err != ErrSliceTooLarge {
+		t.Fatalf("expected ErrSliceTooLarge, got %v", err)
+	}
+}
@@ -0,0 +1,61 @@
+package bstd
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+)
+
+var ErrInvalidUse = errors.New("invalid use of benc")
+
+// The Marshal functions panic on a buffer too small for the value, and the helpers taking
+// functions as interface{}, e.g. SizeMap and UnmarshalSlice, on functions of the wrong type.
+// Safe and MarshalSafe turn these panics into returned errors, so a goroutine handling
+// untrusted input reports the misuse instead of crashing the process.
+
+// Calls 'fn' and returns the error of a panic inside of it, nil if it didn't panic.
+//
+// Possible errors returned:
+//   - ErrBufTooSmall       - an index or a slice bound was out of range, e.g. of the buffer passed to a Marshal function.
+//   - ErrInvalidUse        - any other panic, e.g. an invalid function passed to SizeMap or a negative length passed to make.
+//
+// Both wrap the value of the panic.
+func Safe(fn func()) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = panicError(r)
+		}
+	}()
+	fn()
+	return nil
+}
+
+// Returns the new offset 'n' after calling 'marshal', e.g. the Marshal method of a message,
+// with 'n' and 'b', or the error of a panic inside of it, see Safe. The capacity of 'b' is cut
+// to its length, as the Marshal functions could write past the length otherwise.
+//
+// Possible errors returned:
+//   - ErrBufTooSmall       - 'b' was too small for the marshalled value.
+//   - ErrInvalidUse        - any other panic, see Safe.
+//
+// If a error is returned, n (the int returned) equals zero ( 0 ).
+func MarshalSafe(n int, b []byte, marshal func(n int, b []byte) int) (int, error) {
+	err := Safe(func() { n = marshal(n, b[:len(b):len(b)]) })
+	if err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// panicError converts the value of a recovered panic into the error returned by Safe.
+// Only indexing and slicing past the end of a buffer are ErrBufTooSmall, a runtime error
+// like "makeslice: len out of range" is a misuse.
+func panicError(r any) error {
+	if re, ok := r.(runtime.Error); ok {
+		if msg := re.Error(); strings.Contains(msg, "index out of range") || strings.Contains(msg, "slice bounds out of range") {
+			return fmt.Errorf("%w: %v", ErrBufTooSmall, re)
+		}
+	}
+	return fmt.Errorf("%w: %v", ErrInvalidUse, r)
+}
@@ -0,0 +1,38 @@
+package bstd
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSafe(t *testing.T) {
+	slice := []string{"a", "bc"}
+	buf := make([]byte, SizeSlice(slice, SizeString))
+	marshal := func(n int, b []byte) int { return MarshalSlice(n, b, slice, MarshalString) }
+
+	if n, err := MarshalSafe(0, buf, marshal); err != nil || n != len(buf) {
+		t.Fatalf("got %d, %v", n, err)
+	}
+	for i := range len(buf) {
+		if n, err := MarshalSafe(0, buf[:i], marshal); !errors.Is(err, ErrBufTooSmall) || n != 0 {
+			t.Fatalf("%d bytes: expected ErrBufTooSmall, got %d, %v", i, n, err)
+		}
+	}
+
+	err := Safe(func() { SizeMap(map[string]int{"a": 1}, SizeString, "no sizer") })
+	if !errors.Is(err, ErrInvalidUse) {
+		t.Fatalf("expected ErrInvalidUse, got %v", err)
+	}
+	err = Safe(func() { UnmarshalSlice[int](0, []byte{0, 1, 1, 1, 1}, SizeString) })
+	if !errors.Is(err, ErrInvalidUse) {
+		t.Fatalf("expected ErrInvalidUse, got %v", err)
+	}
+	length := -1
+	err = Safe(func() { _ = make([]byte, length) })
+	if !errors.Is(err, ErrInvalidUse) || errors.Is(err, ErrBufTooSmall) {
+		t.Fatalf("expected ErrInvalidUse, got %v", err)
+	}
+	if err = Safe(func() {}); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,129 @@
+package bstd
+
+import (
+	"bytes"
+	"compress/flate"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// A schema header precedes a message with the schema of its type, so a reader decodes the
+// message without having the schema itself, e.g. `benc cat -schema-header`, the dynamic decoder
+// of the benc command. Archives stay readable once the code or the schema of their writer is
+// gone, at the cost of the compressed schema in every message. The generator writes
+// SizeWithSchema and MarshalWithSchema methods with -go-schema-header.
+
+var ErrNoSchemaHeader = errors.New("message doesn't start with a schema header")
+var ErrSchemaTooLarge = errors.New("schema exceeds the maximum schema size")
+
+// schemaHeaderMagic starts every schema header, so a message without one is detected.
+const schemaHeaderMagic = "bencschema"
+
+// MaxSchemaSize is the size SchemaHeader.Source decompresses a schema to at most, so a
+// corrupted or hostile header can't exhaust the memory of a reader.
+const MaxSchemaSize = 16 << 20
+
+// SchemaHeader describes the message following it by the schema of its type.
+type SchemaHeader struct {
+	// Version is the WireFormatVersion of the writer.
+	Version uint16
+	// Format is the format of the schema, the extension of its file, e.g. "go" or "fbs".
+	Format string
+	// Type is the name of the type of the message in the schema.
+	Type string
+	// Schema is the source of the schema compressed by DEFLATE, see Source.
+	Schema []byte
+}
+
+// Returns the schema header of a message of the type 'typeName' of the schema 'source' of the
+// format 'format', compressing the schema.
+func NewSchemaHeader(format, typeName string, source []byte) SchemaHeader {
+	var buf bytes.Buffer
+	// the errors of a bytes.Buffer are nil and the level is valid
+	fw, _ := flate.NewWriter(&buf, flate.BestCompression)
+	fw.Write(source)
+	fw.Close()
+	return SchemaHeader{Version: WireFormatVersion, Format: format, Type: typeName, Schema: buf.Bytes()}
+}
+
+// Source returns the decompressed source of the schema.
+//
+// Possible errors returned:
+//   - ErrSchemaTooLarge    - the schema exceeds MaxSchemaSize.
+//   - any error of the DEFLATE decompression, e.g. io.ErrUnexpectedEOF
+func (h SchemaHeader) Source() ([]byte, error) {
+	fr := flate.NewReader(bytes.NewReader(h.Schema))
+	defer fr.Close()
+	source, err := io.ReadAll(io.LimitReader(fr, MaxSchemaSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("decompressing the %s schema: %w", h.Format, err)
+	}
+	if len(source) > MaxSchemaSize {
+		return nil, fmt.Errorf("%w: %s schema of more than %d bytes", ErrSchemaTooLarge, h.Format, MaxSchemaSize)
+	}
+	return source, nil
+}
+
+// Returns the new offset 'n' after skipping the marshalled schema header.
+//
+// Possible errors returned:
+//   - ErrBufTooSmall       - 'b' was too small to skip the schema header.
+//   - ErrNoSchemaHeader    - 'b' doesn't start with the magic of a schema header.
+//   - ErrOverflow          - varint overflowed a N-bit unsigned integer.
+//
+// If a error is returned, n (the int returned) equals zero ( 0 ).
+func SkipSchemaHeader(n int, b []byte) (int, error) {
+	n, _, err := UnmarshalSchemaHeader(n, b)
+	return n, err
+}
+
+// Returns the bytes needed to marshal the schema header.
+func SizeSchemaHeader(h SchemaHeader) int {
+	return len(schemaHeaderMagic) + SizeUint16() + SizeString(h.Format) + SizeString(h.Type) + SizeBytes(h.Schema)
+}
+
+// Returns the new offset 'n' after marshalling the schema header 'h'.
+//
+// !- Panics, if 'b' is too small.
+func MarshalSchemaHeader(n int, b []byte, h SchemaHeader) int {
+	n += copy(b[n:n+len(schemaHeaderMagic)], schemaHeaderMagic)
+	n = MarshalUint16(n, b, h.Version)
+	n = MarshalString(n, b, h.Format)
+	n = MarshalString(n, b, h.Type)
+	return MarshalBytes(n, b, h.Schema)
+}
+
+// Returns the new offset 'n', as well as the schema header, that got unmarshalled. The
+// compressed schema is copied out of 'b'.
+//
+// Possible errors returned:
+//   - ErrBufTooSmall       - 'b' was too small to unmarshal the schema header.
+//   - ErrNoSchemaHeader    - 'b' doesn't start with the magic of a schema header.
+//   - ErrOverflow          - varint overflowed a N-bit unsigned integer.
+//
+// If a error is returned, n (the int returned) equals zero ( 0 ).
+func UnmarshalSchemaHeader(n int, b []byte) (int, SchemaHeader, error) {
+	if len(b)-n < len(schemaHeaderMagic) {
+		return 0, SchemaHeader{}, ErrBufTooSmall
+	}
+	if string(b[n:n+len(schemaHeaderMagic)]) != schemaHeaderMagic {
+		return 0, SchemaHeader{}, ErrNoSchemaHeader
+	}
+	n += len(schemaHeaderMagic)
+	var h SchemaHeader
+	var err error
+	if n, h.Version, err = UnmarshalUint16(n, b); err != nil {
+		return 0, SchemaHeader{}, err
+	}
+	if n, h.Format, err = UnmarshalString(n, b); err != nil {
+		return 0, SchemaHeader{}, err
+	}
+	if n, h.Type, err = UnmarshalString(n, b); err != nil {
+		return 0, SchemaHeader{}, err
+	}
+	if n, h.Schema, err = UnmarshalBytesCopied(n, b); err != nil {
+		return 0, SchemaHeader{}, err
+	}
+	return n, h, nil
+}
@@ -0,0 +1,59 @@
+package bstd
+
+import (
+	"bytes"
+	"compress/flate"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestSchemaHeader(t *testing.T) {
+	source := []byte(strings.Repeat("type Point struct {\n\tX, Y int32\n}\n\n", 20))
+	h := NewSchemaHeader("go", "Point", source)
+	if h.Version != WireFormatVersion || len(h.Schema) >= len(source) {
+		t.Fatalf("expected version %d and a compressed schema, got %d and %d of %d bytes", WireFormatVersion, h.Version, len(h.Schema), len(source))
+	}
+
+	// a message follows the header
+	buf := make([]byte, SizeSchemaHeader(h)+SizeInt32())
+	n := MarshalSchemaHeader(0, buf, h)
+	MarshalInt32(n, buf, 42)
+
+	n, got, err := UnmarshalSchemaHeader(0, buf)
+	if err != nil || n != SizeSchemaHeader(h) {
+		t.Fatalf("got %d, %v", n, err)
+	}
+	if got.Version != h.Version || got.Format != "go" || got.Type != "Point" || !bytes.Equal(got.Schema, h.Schema) {
+		t.Fatalf("expected %+v, got %+v", h, got)
+	}
+	if src, err := got.Source(); err != nil || !bytes.Equal(src, source) {
+		t.Fatalf("got the source %q, %v", src, err)
+	}
+	if _, v, err := UnmarshalInt32(n, buf); err != nil || v != 42 {
+		t.Fatalf("expected the message 42 after the header, got %d, %v", v, err)
+	}
+	if skipped, err := SkipSchemaHeader(0, buf); err != nil || skipped != n {
+		t.Fatalf("skip got %d, %v", skipped, err)
+	}
+
+	for i := range SizeSchemaHeader(h) {
+		if _, _, err = UnmarshalSchemaHeader(0, buf[:i]); !errors.Is(err, ErrBufTooSmall) {
+			t.Fatalf("%d bytes: expected ErrBufTooSmall, got %v", i, err)
+		}
+	}
+	if _, _, err = UnmarshalSchemaHeader(0, []byte("not a schema header")); !errors.Is(err, ErrNoSchemaHeader) {
+		t.Fatalf("expected ErrNoSchemaHeader, got %v", err)
+	}
+
+	if _, err = (SchemaHeader{Format: "go", Schema: []byte{0xff}}).Source(); err == nil {
+		t.Fatal("expected an error of a corrupted schema")
+	}
+	var bomb bytes.Buffer
+	fw, _ := flate.NewWriter(&bomb, flate.BestCompression)
+	fw.Write(make([]byte, MaxSchemaSize+1))
+	fw.Close()
+	if _, err = (SchemaHeader{Format: "go", Schema: bomb.Bytes()}).Source(); !errors.Is(err, ErrSchemaTooLarge) {
+		t.Fatalf("expected ErrSchemaTooLarge, got %v", err)
+	}
+}
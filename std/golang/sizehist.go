@@ -0,0 +1,78 @@
+package bstd
+
+import (
+	"math/bits"
+	"sync"
+	"sync/atomic"
+)
+
+// SizeHook receives the name of a generated type and the bytes a message of it was marshalled
+// to, see SetSizeHook.
+type SizeHook func(typeName string, size int)
+
+var sizeHook atomic.Pointer[SizeHook]
+
+// SetSizeHook registers the hook, which the Marshal methods generated with -go-size-histogram
+// call for every message, nested ones included, so the sizes of the types can be recorded,
+// e.g. in a SizeHistogram or a histogram of a metrics library. A nil hook removes it.
+func SetSizeHook(hook SizeHook) {
+	if hook == nil {
+		sizeHook.Store(nil)
+		return
+	}
+	sizeHook.Store(&hook)
+}
+
+// ObserveSize passes the size of a marshalled message of the type to the registered hook, if
+// there is one. It is called by the generated code.
+func ObserveSize(typeName string, size int) {
+	if hook := sizeHook.Load(); hook != nil {
+		(*hook)(typeName, size)
+	}
+}
+
+// SizeBuckets is the histogram of the sizes of a type. Buckets[i] counts the sizes of i bits,
+// the sizes from 2^(i-1) to 2^i - 1, Buckets[0] the empty messages.
+type SizeBuckets struct {
+	Count, Sum uint64
+	Buckets    [bits.UintSize]uint64
+}
+
+// SizeHistogram records the sizes of the types, its Observe method is a SizeHook:
+//
+//	var h bstd.SizeHistogram
+//	bstd.SetSizeHook(h.Observe)
+//
+// It is safe for concurrent use.
+type SizeHistogram struct {
+	mu    sync.Mutex
+	types map[string]*SizeBuckets
+}
+
+// Observe records the size of a message of the type.
+func (h *SizeHistogram) Observe(typeName string, size int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.types == nil {
+		h.types = make(map[string]*SizeBuckets)
+	}
+	sb := h.types[typeName]
+	if sb == nil {
+		sb = &SizeBuckets{}
+		h.types[typeName] = sb
+	}
+	sb.Count++
+	sb.Sum += uint64(size)
+	sb.Buckets[bits.Len(uint(size))]++
+}
+
+// Snapshot returns a copy of the histograms of the types recorded so far.
+func (h *SizeHistogram) Snapshot() map[string]SizeBuckets {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	snapshot := make(map[string]SizeBuckets, len(h.types))
+	for name, sb := range h.types {
+		snapshot[name] = *sb
+	}
+	return snapshot
+}
@@ -0,0 +1,43 @@
+package bstd
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestSizeHistogram(t *testing.T) {
+	var h SizeHistogram
+	SetSizeHook(h.Observe)
+	defer SetSizeHook(nil)
+
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Go(func() {
+			for _, size := range []int{0, 1, 3, 200} {
+				ObserveSize("Point", size)
+			}
+		})
+	}
+	wg.Wait()
+	ObserveSize("Line", 8)
+
+	snapshot := h.Snapshot()
+	point := snapshot["Point"]
+	if point.Count != 16 || point.Sum != 4*204 {
+		t.Fatalf("expected 16 sizes summing to %d, got %d, %d", 4*204, point.Count, point.Sum)
+	}
+	for i, expected := range map[int]uint64{0: 4, 1: 4, 2: 4, 8: 4} {
+		if point.Buckets[i] != expected {
+			t.Fatalf("bucket %d: expected %d, got %d", i, expected, point.Buckets[i])
+		}
+	}
+	if line := snapshot["Line"]; line.Count != 1 || line.Buckets[4] != 1 {
+		t.Fatalf("unexpected histogram of Line: %+v", line)
+	}
+
+	SetSizeHook(nil)
+	ObserveSize("Point", 1)
+	if h.Snapshot()["Point"].Count != 16 {
+		t.Fatal("the removed hook was called")
+	}
+}
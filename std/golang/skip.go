@@ -0,0 +1,128 @@
+package bstd
+
+import "github.com/banditmoscow1337/benc/wire"
+
+// The combinators build the SkipFunc of a composite type from the SkipFuncs of its parts,
+// so hand-written protocol code can skip e.g. a []map[string]Point without parsing any
+// length prefixes itself:
+//
+//	skipPoint := SkipStructOf(SkipInt32, SkipInt32)
+//	skip := SkipSliceOf(SkipMapOf(SkipString, skipPoint))
+//
+// Fixed-size parts are skipped at once with SkipFixed, e.g. SkipFixed(8) for the point.
+
+// Returns a SkipFunc skipping 'size' bytes, a value of a fixed size like a [16]byte or a
+// struct of fixed-width fields, without skipping its parts one by one.
+func SkipFixed(size int) SkipFunc {
+	return func(n int, b []byte) (int, error) {
+		return wire.Skip(n, b, size)
+	}
+}
+
+// Returns a SkipFunc skipping 'count' values with 'skip', one after another like a fixed size array.
+func SkipN(skip SkipFunc, count int) SkipFunc {
+	return func(n int, b []byte) (int, error) {
+		return SkipArray(n, b, count, skip)
+	}
+}
+
+// Returns a SkipFunc skipping a struct marshalled field by field, with one SkipFunc per field.
+func SkipStructOf(skippers ...SkipFunc) SkipFunc {
+	return func(n int, b []byte) (int, error) {
+		var err error
+		for _, skip := range skippers {
+			if n, err = skip(n, b); err != nil {
+				return 0, err
+			}
+		}
+		return n, nil
+	}
+}
+
+// Returns a SkipFunc skipping a slice of elements skipped by 'skipElement', see SkipSlice.
+func SkipSliceOf(skipElement SkipFunc) SkipFunc {
+	return func(n int, b []byte) (int, error) {
+		return SkipSlice(n, b, skipElement)
+	}
+}
+
+// Returns a SkipFunc skipping a map of keys and values skipped by 'skipKey' and 'skipValue', see SkipMap.
+func SkipMapOf(skipKey, skipValue SkipFunc) SkipFunc {
+	return func(n int, b []byte) (int, error) {
+		return SkipMap(n, b, skipKey, skipValue)
+	}
+}
+
+// Returns a SkipFunc skipping a pointer to a value skipped by 'skipElement', see SkipPointer.
+func SkipPointerOf(skipElement SkipFunc) SkipFunc {
+	return func(n int, b []byte) (int, error) {
+		return SkipPointer(n, b, skipElement)
+	}
+}
+
+// Validate checks that 'b' holds exactly one value skipped by 'skip', e.g. the generated
+// Skip<T> of a schema struct. It walks the length prefixes and nested values without
+// unmarshalling any of them, so it is a cheap check of untrusted input before decoding it.
+// Skip functions don't check the values themselves, like the UTF-8 of validated strings.
+//
+// Possible errors returned:
+//   - ErrTrailingBytes     - 'b' holds more bytes than the value.
+//   - any error returned by 'skip'.
+func Validate(b []byte, skip SkipFunc) error {
+	n, err := skip(0, b)
+	if err != nil {
+		return err
+	}
+	if n != len(b) {
+		return ErrTrailingBytes
+	}
+	return nil
+}
+
+// Returns a SkipFunc skipping an option of a value skipped by 'skipElement', see SkipOption.
+func SkipOptionOf(skipElement SkipFunc) SkipFunc {
+	return func(n int, b []byte) (int, error) {
+		return SkipOption(n, b, skipElement)
+	}
+}
+
+// Returns a SkipFunc skipping a run-length encoded slice of elements skipped by 'skipElement', see SkipSliceRLE.
+func SkipSliceRLEOf(skipElement SkipFunc) SkipFunc {
+	return func(n int, b []byte) (int, error) {
+		return SkipSliceRLE(n, b, skipElement)
+	}
+}
+
+// Returns a SkipFunc skipping a sparse encoded slice of elements skipped by 'skipElement', see SkipSliceSparse.
+func SkipSliceSparseOf(skipElement SkipFunc) SkipFunc {
+	return func(n int, b []byte) (int, error) {
+		return SkipSliceSparse(n, b, skipElement)
+	}
+}
+
+// Returns a SkipFunc skipping a union of the values skipped by 'cases', by their tags, see SkipUnion.
+func SkipUnionOf(cases map[uint8]SkipFunc) SkipFunc {
+	return func(n int, b []byte) (int, error) {
+		return SkipUnion(n, b, cases)
+	}
+}
+
+// Returns the new offset 'n' after skipping any value with a length prefix, without
+// knowing its type: strings, byte slices, frames, raw messages, URLs and big numbers.
+// Marshalled data carries no type information, so other values need their own SkipFunc.
+//
+// Possible errors returned:
+//   - ErrOverflow          - the length prefix overflowed a N-bit unsigned integer.
+//   - ErrBufTooSmall       - 'b' was too small to skip the marshalled value.
+//
+// If a error is returned, n (the int returned) equals zero ( 0 ).
+func SkipAny(n int, b []byte) (int, error) {
+	n, us, err := UnmarshalUint(n, b)
+	if err != nil {
+		return 0, err
+	}
+	if uint(len(b)-n) < us {
+		return 0, ErrBufTooSmall
+	}
+	return n + int(us), nil
+}
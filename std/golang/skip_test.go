@@ -0,0 +1,100 @@
+package bstd
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSkipCombinators(t *testing.T) {
+	type point struct{ X, Y int32 }
+	marshalPoint := func(n int, b []byte, p point) int {
+		n = MarshalInt32(n, b, p.X)
+		return MarshalInt32(n, b, p.Y)
+	}
+	sizePoint := func(point) int { return 2 * SizeInt32() }
+
+	v := []map[string]point{{"a": {1, 2}, "b": {3, 4}}, nil, {"c": {5, 6}}}
+	label := "trailer"
+	p := &v[2]
+	pair := [2]string{"x", "yz"}
+
+	sizeMap := func(m map[string]point) int { return SizeMap(m, SizeString, sizePoint) }
+	s := SizeSlice(v, sizeMap) + SizeString(label) + SizePointer(p, sizeMap) + SizeArray(pair[:], SizeString)
+	buf := make([]byte, s)
+	marshalMap := func(n int, b []byte, m map[string]point) int { return MarshalMap(n, b, m, MarshalString, marshalPoint) }
+	n := MarshalSlice(0, buf, v, marshalMap)
+	n = MarshalString(n, buf, label)
+	n = MarshalPointer(n, buf, p, marshalMap)
+	MarshalArray(n, buf, pair[:], MarshalString)
+
+	skipMap := SkipMapOf(SkipString, SkipStructOf(SkipInt32, SkipInt32))
+	skip := SkipStructOf(SkipSliceOf(skipMap), SkipAny, SkipPointerOf(skipMap), SkipN(SkipString, 2))
+	if n, err := skip(0, buf); err != nil || n != s {
+		t.Fatalf("expected offset %d, got %d, %v", s, n, err)
+	}
+	for i := range s {
+		if _, err := skip(0, buf[:i]); !errors.Is(err, ErrBufTooSmall) {
+			t.Fatalf("%d bytes: expected ErrBufTooSmall, got %v", i, err)
+		}
+	}
+
+	if n, err := SkipN(SkipByte, 0)(0, nil); err != nil || n != 0 {
+		t.Fatalf("expected nothing to skip, got %d, %v", n, err)
+	}
+	if n, err := SkipStructOf()(3, nil); err != nil || n != 3 {
+		t.Fatalf("expected nothing to skip, got %d, %v", n, err)
+	}
+	if _, err := SkipAny(0, []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x7f}); !errors.Is(err, ErrOverflow) {
+		t.Fatalf("expected ErrOverflow, got %v", err)
+	}
+	if _, err := SkipAny(0, []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01}); !errors.Is(err, ErrBufTooSmall) {
+		t.Fatalf("expected ErrBufTooSmall, got %v", err)
+	}
+}
+
+func TestSkipBuilders(t *testing.T) {
+	opt := Some(int32(7))
+	id := [4]byte{1, 2, 3, 4}
+	runs := []uint16{5, 5, 5, 9}
+	name := "circle"
+
+	s := SizeOption(opt, func(int32) int { return SizeInt32() }) + len(id) +
+		SizeSliceRLE(runs, func(uint16) int { return SizeUint16() }) + SizeUnion(name, SizeString) + SizeFloat64()
+	buf := make([]byte, s)
+	n := MarshalOption(0, buf, opt, MarshalInt32)
+	n = MarshalByteArray(n, buf, id[:])
+	n = MarshalSliceRLE(n, buf, runs, MarshalUint16)
+	n = MarshalUnion(n, buf, 2, name, MarshalString)
+	MarshalFloat64(n, buf, 0.5)
+
+	skip := SkipStructOf(SkipOptionOf(SkipInt32), SkipFixed(len(id)), SkipSliceRLEOf(SkipUint16),
+		SkipUnionOf(map[uint8]SkipFunc{1: SkipFixed(8), 2: SkipString}), SkipFixed(SizeFloat64()))
+	if err := Validate(buf, skip); err != nil {
+		t.Fatalf("expected a valid buffer, got %v", err)
+	}
+	for i := range s {
+		if _, err := skip(0, buf[:i]); !errors.Is(err, ErrBufTooSmall) {
+			t.Fatalf("%d bytes: expected ErrBufTooSmall, got %v", i, err)
+		}
+	}
+	if n, err := SkipFixed(0)(2, buf[:2]); err != nil || n != 2 {
+		t.Fatalf("expected nothing to skip, got %d, %v", n, err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	v := []string{"a", "bc"}
+	buf := make([]byte, SizeSlice(v, SizeString)+1)
+	n := MarshalSlice(0, buf, v, MarshalString)
+
+	skip := SkipSliceOf(SkipString)
+	if err := Validate(buf[:n], skip); err != nil {
+		t.Fatalf("expected a valid buffer, got %v", err)
+	}
+	if err := Validate(buf, skip); !errors.Is(err, ErrTrailingBytes) {
+		t.Fatalf("expected ErrTrailingBytes, got %v", err)
+	}
+	if err := Validate(buf[:n-1], skip); !errors.Is(err, ErrBufTooSmall) {
+		t.Fatalf("expected ErrBufTooSmall, got %v", err)
+	}
+}
@@ -0,0 +1,166 @@
+package bstd
+
+import (
+	"errors"
+	"slices"
+)
+
+// The sparse encoding of a slice stores the number of elements and the number of non-zero
+// elements, followed by the non-zero elements, each after the count of zero elements in front
+// of it, back to the previous non-zero element. A vector of 10k elements with 50 non-zero ones
+// takes a few hundred bytes instead of the plain encoding of all of them; dense slices get a
+// bit larger. Elements equal to the zero value by ==, -0 floats included, are left out and
+// unmarshal as the zero value. The sparse and the plain encoding are not interchangeable on
+// the wire.
+
+var ErrSparseIndex = errors.New("index of a sparse element exceeds the slice length")
+
+// Returns the new offset 'n' after skipping the marshalled sparse slice.
+//
+// Possible errors returned:
+//   - ErrOverflow          - a varint overflowed a N-bit unsigned integer.
+//   - ErrBufTooSmall       - 'b' was too small to skip the marshalled slice.
+//   - ErrSparseIndex       - an element lies beyond the length of the slice.
+//   - any error returned by 'skipElement'.
+//
+// If a error is returned, n (the int returned) equals zero ( 0 ).
+func SkipSliceSparse(n int, b []byte, skipElement SkipFunc) (int, error) {
+	n, length, err := UnmarshalUint(n, b)
+	if err != nil {
+		return 0, err
+	}
+	n, count, err := UnmarshalUint(n, b)
+	if err != nil {
+		return 0, err
+	}
+	if count > length {
+		return 0, ErrSparseIndex
+	}
+	for next := uint(0); count > 0; count-- {
+		var gap uint
+		if n, gap, err = UnmarshalUint(n, b); err != nil {
+			return 0, err
+		}
+		if gap >= length-next {
+			return 0, ErrSparseIndex
+		}
+		next += gap + 1
+		if n, err = skipElement(n, b); err != nil {
+			return 0, err
+		}
+	}
+	return n, nil
+}
+
+// Returns the bytes needed to marshal the slice sparse encoded.
+func SizeSliceSparse[T comparable](slice []T, sizer SizeFunc[T]) int {
+	var zero T
+	count, next := 0, 0
+	s := SizeUint(uint(len(slice)))
+	for i, v := range slice {
+		if v != zero {
+			s += SizeUint(uint(i-next)) + sizer(v)
+			count, next = count+1, i+1
+		}
+	}
+	return s + SizeUint(uint(count))
+}
+
+// Returns the new offset 'n' after marshalling the slice sparse encoded.
+//
+// !- Panics, if 'b' is too small.
+func MarshalSliceSparse[T comparable](n int, b []byte, slice []T, marshaler MarshalFunc[T]) int {
+	var zero T
+	count := 0
+	for _, v := range slice {
+		if v != zero {
+			count++
+		}
+	}
+	n = MarshalUint(n, b, uint(len(slice)))
+	n = MarshalUint(n, b, uint(count))
+	next := 0
+	for i, v := range slice {
+		if v != zero {
+			n = MarshalUint(n, b, uint(i-next))
+			n = marshaler(n, b, v)
+			next = i + 1
+		}
+	}
+	return n
+}
+
+// Returns the new offset 'n', as well as the sparse encoded slice, that got unmarshalled.
+// The slice grows element by element, up to 1 GiB of elements, see UnmarshalSliceSparseLimited
+// for untrusted data.
+//
+// Possible errors returned:
+//   - ErrOverflow          - a varint overflowed a N-bit unsigned integer.
+//   - ErrBufTooSmall       - 'b' was too small to unmarshal the slice.
+//   - ErrSparseIndex       - an element lies beyond the length of the slice.
+//   - ErrSliceTooLarge     - the elements of the slice take more than 1 GiB.
+//   - any error returned by 'unmarshaler'.
+//
+// If a error is returned, n (the int returned) equals zero ( 0 ).
+func UnmarshalSliceSparse[T any](n int, b []byte, unmarshaler func(n int, b []byte) (int, T, error)) (int, []T, error) {
+	return UnmarshalSliceSparseLimited(n, b, unmarshaler, Limits{})
+}
+
+// Returns the new offset 'n', as well as the sparse encoded slice, that got unmarshalled.
+// Unlike UnmarshalSliceSparse, it fails if the slice exceeds the limits, MaxElements bounding
+// the length of the slice, zero elements included, in place of the 1 GiB bound.
+//
+// Possible errors returned:
+//   - ErrOverflow          - a varint overflowed a N-bit unsigned integer.
+//   - ErrBufTooSmall       - 'b' was too small to unmarshal the slice.
+//   - ErrSparseIndex       - an element lies beyond the length of the slice.
+//   - ErrSliceTooLarge     - the elements of the slice take more than 1 GiB, without MaxElements.
+//   - ErrLimitExceeded     - the slice has more elements or bytes than 'limits' allow.
+//   - any error returned by 'unmarshaler'.
+//
+// If a error is returned, n (the int returned) equals zero ( 0 ).
+func UnmarshalSliceSparseLimited[T any](n int, b []byte, unmarshaler func(n int, b []byte) (int, T, error), limits Limits) (int, []T, error) {
+	start := n
+	n, length, err := UnmarshalUint(n, b)
+	if err != nil {
+		return 0, nil, err
+	}
+	n, count, err := UnmarshalUint(n, b)
+	if err != nil {
+		return 0, nil, err
+	}
+	if length > uint(maxInt) || count > length {
+		return 0, nil, ErrSparseIndex
+	}
+	if limits.MaxElements > 0 {
+		err = limits.checkCount(length)
+	} else if !expandable[T](length) {
+		err = ErrSliceTooLarge
+	}
+	if err != nil {
+		return 0, nil, err
+	}
+
+	// the zero elements are added with the next non-zero one, so a short buffer fails before
+	// the whole slice is allocated
+	ts := make([]T, 0, min(count, uint(len(b)-n)))
+	for next := uint(0); count > 0; count-- {
+		var gap uint
+		if n, gap, err = UnmarshalUint(n, b); err != nil {
+			return 0, nil, err
+		}
+		if gap >= length-next {
+			return 0, nil, ErrSparseIndex
+		}
+		next += gap
+		ts = slices.Grow(ts, int(next)+1-len(ts))[:next+1]
+		if n, ts[next], err = unmarshaler(n, b); err != nil {
+			return 0, nil, err
+		}
+		if err = limits.checkBytes(n - start); err != nil {
+			return 0, nil, err
+		}
+		next++
+	}
+	return n, slices.Grow(ts, int(length)-len(ts))[:length], nil
+}
@@ -0,0 +1,74 @@
+package bstd
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestSliceSparse(t *testing.T) {
+	sizeFloat32 := func(float32) int { return SizeFloat32() }
+	for _, v := range [][]float32{{}, {0, 0}, {7}, {0, 1.5, 0, 0, -2, 0}, {1, 2, 3}} {
+		s := SizeSliceSparse(v, sizeFloat32)
+		buf := make([]byte, s)
+		if n := MarshalSliceSparse(0, buf, v, MarshalFloat32); n != s {
+			t.Fatalf("%v: expected offset %d, got %d", v, s, n)
+		}
+		n, got, err := UnmarshalSliceSparse(0, buf, UnmarshalFloat32)
+		if err != nil || n != s || !reflect.DeepEqual(got, v) {
+			t.Fatalf("%v: got %v, %d, %v", v, got, n, err)
+		}
+		if n, err = SkipSliceSparse(0, buf, SkipFloat32); err != nil || n != s {
+			t.Fatalf("%v: skip got %d, %v", v, n, err)
+		}
+		for i := range s {
+			if _, _, err = UnmarshalSliceSparse(0, buf[:i], UnmarshalFloat32); !errors.Is(err, ErrBufTooSmall) {
+				t.Fatalf("%v: %d bytes: expected ErrBufTooSmall, got %v", v, i, err)
+			}
+			if _, err = SkipSliceSparse(0, buf[:i], SkipFloat32); !errors.Is(err, ErrBufTooSmall) {
+				t.Fatalf("%v: %d bytes: expected ErrBufTooSmall, got %v", v, i, err)
+			}
+		}
+	}
+
+	// 50 non-zero elements of 10k take their gap and value each, the first gap a byte less
+	vector := make([]float32, 10000)
+	for i := range 50 {
+		vector[i*200+100] = float32(i + 1)
+	}
+	if s := SizeSliceSparse(vector, sizeFloat32); s != 2+1+50*(2+4)-1 {
+		t.Fatalf("expected 302 bytes for the vector, got %d", s)
+	}
+
+	for _, b := range [][]byte{
+		{2, 3, 0, 1, 0, 1, 0, 1}, // more elements than the slice has
+		{3, 1, 3, 1},             // gap beyond the slice
+		{3, 2, 1, 1, 1, 1},       // second element beyond the slice
+	} {
+		if _, _, err := UnmarshalSliceSparse(0, b, UnmarshalByte); err != ErrSparseIndex {
+			t.Fatalf("%v: expected ErrSparseIndex, got %v", b, err)
+		}
+		if _, err := SkipSliceSparse(0, b, SkipByte); err != ErrSparseIndex {
+			t.Fatalf("%v: expected ErrSparseIndex, got %v", b, err)
+		}
+	}
+	if _, _, err := UnmarshalSliceSparseLimited(0, []byte{200, 1, 0}, UnmarshalByte, Limits{MaxElements: 100}); err != ErrLimitExceeded {
+		t.Fatalf("expected ErrLimitExceeded, got %v", err)
+	}
+
+	// 1M float64s with 50 non-zero ones take a few hundred bytes
+	large := make([]float64, 1<<20)
+	for i := range 50 {
+		large[i*20000+7] = float64(i) + 0.5
+	}
+	buf := make([]byte, SizeSliceSparse(large, func(float64) int { return SizeFloat64() }))
+	MarshalSliceSparse(0, buf, large, MarshalFloat64)
+	if n, got, err := UnmarshalSliceSparse(0, buf, UnmarshalFloat64); err != nil || n != len(buf) || !reflect.DeepEqual(got, large) {
+		t.Fatalf("%d bytes of 1M float64s: got %d elements, %d, %v", len(buf), len(got), n, err)
+	}
+	huge := make([]byte, SizeUint(1<<40)+1)
+	MarshalUint(MarshalUint(0, huge, 1<<40), huge, 0)
+	if _, _, err := UnmarshalSliceSparse(0, huge, UnmarshalFloat64); err != ErrSliceTooLarge {
+		t.Fatalf("expected ErrSliceTooLarge, got %v", err)
+	}
+}
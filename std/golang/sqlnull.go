@@ -0,0 +1,186 @@
+package bstd
+
+import (
+	"database/sql"
+	"time"
+)
+
+// sql.Null functions
+//
+// The Null types of database/sql are marshalled like a pointer or an Option: a bool telling
+// whether the value is valid, followed by the value if it is. Structs scanned from SQL rows
+// can so be marshalled as they are. The value of an invalid Null isn't marshalled, so it
+// unmarshals as the zero value.
+//
+// Possible errors returned by the Skip and Unmarshal functions:
+//   - ErrBufTooSmall       - 'b' was too small to skip or unmarshal the value.
+//   - ErrOverflow          - varint overflowed a N-bit unsigned integer (NullString only).
+//
+// If a error is returned, n (the int returned) equals zero ( 0 ).
+
+func sizeNull(valid bool, size int) int {
+	if valid {
+		return SizeBool() + size
+	}
+	return SizeBool()
+}
+
+func SkipNullString(n int, b []byte) (int, error) {
+	return SkipOption(n, b, SkipString)
+}
+
+func SizeNullString(v sql.NullString) int {
+	return sizeNull(v.Valid, SizeString(v.String))
+}
+
+// Returns the new offset 'n' after marshalling the NullString.
+//
+// !- Panics, if 'b' is too small.
+func MarshalNullString(n int, b []byte, v sql.NullString) int {
+	return MarshalOption(n, b, Option[string]{Value: v.String, Valid: v.Valid}, MarshalString)
+}
+
+func UnmarshalNullString(n int, b []byte) (int, sql.NullString, error) {
+	n, o, err := UnmarshalOption[string](n, b, UnmarshalString)
+	return n, sql.NullString{String: o.Value, Valid: o.Valid}, err
+}
+
+func SkipNullInt64(n int, b []byte) (int, error) {
+	return SkipOption(n, b, SkipInt64)
+}
+
+func SizeNullInt64(v sql.NullInt64) int {
+	return sizeNull(v.Valid, SizeInt64())
+}
+
+// Returns the new offset 'n' after marshalling the NullInt64.
+//
+// !- Panics, if 'b' is too small.
+func MarshalNullInt64(n int, b []byte, v sql.NullInt64) int {
+	return MarshalOption(n, b, Option[int64]{Value: v.Int64, Valid: v.Valid}, MarshalInt64)
+}
+
+func UnmarshalNullInt64(n int, b []byte) (int, sql.NullInt64, error) {
+	n, o, err := UnmarshalOption[int64](n, b, UnmarshalInt64)
+	return n, sql.NullInt64{Int64: o.Value, Valid: o.Valid}, err
+}
+
+func SkipNullInt32(n int, b []byte) (int, error) {
+	return SkipOption(n, b, SkipInt32)
+}
+
+func SizeNullInt32(v sql.NullInt32) int {
+	return sizeNull(v.Valid, SizeInt32())
+}
+
+// Returns the new offset 'n' after marshalling the NullInt32.
+//
+// !- Panics, if 'b' is too small.
+func MarshalNullInt32(n int, b []byte, v sql.NullInt32) int {
+	return MarshalOption(n, b, Option[int32]{Value: v.Int32, Valid: v.Valid}, MarshalInt32)
+}
+
+func UnmarshalNullInt32(n int, b []byte) (int, sql.NullInt32, error) {
+	n, o, err := UnmarshalOption[int32](n, b, UnmarshalInt32)
+	return n, sql.NullInt32{Int32: o.Value, Valid: o.Valid}, err
+}
+
+func SkipNullInt16(n int, b []byte) (int, error) {
+	return SkipOption(n, b, SkipInt16)
+}
+
+func SizeNullInt16(v sql.NullInt16) int {
+	return sizeNull(v.Valid, SizeInt16())
+}
+
+// Returns the new offset 'n' after marshalling the NullInt16.
+//
+// !- Panics, if 'b' is too small.
+func MarshalNullInt16(n int, b []byte, v sql.NullInt16) int {
+	return MarshalOption(n, b, Option[int16]{Value: v.Int16, Valid: v.Valid}, MarshalInt16)
+}
+
+func UnmarshalNullInt16(n int, b []byte) (int, sql.NullInt16, error) {
+	n, o, err := UnmarshalOption[int16](n, b, UnmarshalInt16)
+	return n, sql.NullInt16{Int16: o.Value, Valid: o.Valid}, err
+}
+
+func SkipNullByte(n int, b []byte) (int, error) {
+	return SkipOption(n, b, SkipByte)
+}
+
+func SizeNullByte(v sql.NullByte) int {
+	return sizeNull(v.Valid, SizeByte())
+}
+
+// Returns the new offset 'n' after marshalling the NullByte.
+//
+// !- Panics, if 'b' is too small.
+func MarshalNullByte(n int, b []byte, v sql.NullByte) int {
+	return MarshalOption(n, b, Option[byte]{Value: v.Byte, Valid: v.Valid}, MarshalByte)
+}
+
+func UnmarshalNullByte(n int, b []byte) (int, sql.NullByte, error) {
+	n, o, err := UnmarshalOption[byte](n, b, UnmarshalByte)
+	return n, sql.NullByte{Byte: o.Value, Valid: o.Valid}, err
+}
+
+func SkipNullFloat64(n int, b []byte) (int, error) {
+	return SkipOption(n, b, SkipFloat64)
+}
+
+func SizeNullFloat64(v sql.NullFloat64) int {
+	return sizeNull(v.Valid, SizeFloat64())
+}
+
+// Returns the new offset 'n' after marshalling the NullFloat64.
+//
+// !- Panics, if 'b' is too small.
+func MarshalNullFloat64(n int, b []byte, v sql.NullFloat64) int {
+	return MarshalOption(n, b, Option[float64]{Value: v.Float64, Valid: v.Valid}, MarshalFloat64)
+}
+
+func UnmarshalNullFloat64(n int, b []byte) (int, sql.NullFloat64, error) {
+	n, o, err := UnmarshalOption[float64](n, b, UnmarshalFloat64)
+	return n, sql.NullFloat64{Float64: o.Value, Valid: o.Valid}, err
+}
+
+func SkipNullBool(n int, b []byte) (int, error) {
+	return SkipOption(n, b, SkipBool)
+}
+
+func SizeNullBool(v sql.NullBool) int {
+	return sizeNull(v.Valid, SizeBool())
+}
+
+// Returns the new offset 'n' after marshalling the NullBool.
+//
+// !- Panics, if 'b' is too small.
+func MarshalNullBool(n int, b []byte, v sql.NullBool) int {
+	return MarshalOption(n, b, Option[bool]{Value: v.Bool, Valid: v.Valid}, MarshalBool)
+}
+
+func UnmarshalNullBool(n int, b []byte) (int, sql.NullBool, error) {
+	n, o, err := UnmarshalOption[bool](n, b, UnmarshalBool)
+	return n, sql.NullBool{Bool: o.Value, Valid: o.Valid}, err
+}
+
+func SkipNullTime(n int, b []byte) (int, error) {
+	return SkipOption(n, b, SkipTime)
+}
+
+func SizeNullTime(v sql.NullTime) int {
+	return sizeNull(v.Valid, SizeTime())
+}
+
+// Returns the new offset 'n' after marshalling the NullTime.
+//
+// !- Panics, if 'b' is too small.
+func MarshalNullTime(n int, b []byte, v sql.NullTime) int {
+	return MarshalOption(n, b, Option[time.Time]{Value: v.Time, Valid: v.Valid}, MarshalTime)
+}
+
+func UnmarshalNullTime(n int, b []byte) (int, sql.NullTime, error) {
+	n, o, err := UnmarshalOption[time.Time](n, b, UnmarshalTime)
+	return n, sql.NullTime{Time: o.Value, Valid: o.Valid}, err
+}
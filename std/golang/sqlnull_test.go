@@ -0,0 +1,81 @@
+package bstd
+
+import (
+	"database/sql"
+	"errors"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+// testNull round-trips 'values' of a sql.Null type and checks that they are marshalled like
+// pointers to their values.
+func testNull[T any](t *testing.T, values []T, size func(T) int, marshal MarshalFunc[T], unmarshal func(n int, b []byte) (int, T, error), skip SkipFunc, compare func(a, b T) error, valid func(T) bool) {
+	t.Helper()
+	for _, v := range values {
+		s := size(v)
+		buf := make([]byte, s)
+		if n := marshal(0, buf, v); n != s {
+			t.Fatalf("%v: expected offset %d, got %d", v, s, n)
+		}
+		if valid(v) != (buf[0] == 1) || !valid(v) && s != SizeBool() {
+			t.Fatalf("%v: expected the presence byte only, if invalid, got %v", v, buf)
+		}
+
+		n, got, err := unmarshal(0, buf)
+		if err != nil || n != s {
+			t.Fatalf("%v: got %d, %v", v, n, err)
+		}
+		if err = compare(v, got); err != nil {
+			t.Fatal(err.Error())
+		}
+		if n, err = skip(0, buf); err != nil || n != s {
+			t.Fatalf("%v: skip got %d, %v", v, n, err)
+		}
+
+		for i := range s {
+			if _, _, err = unmarshal(0, buf[:i]); !errors.Is(err, ErrBufTooSmall) {
+				t.Fatalf("%v, %d bytes: expected ErrBufTooSmall, got %v", v, i, err)
+			}
+			if _, err = skip(0, buf[:i]); !errors.Is(err, ErrBufTooSmall) {
+				t.Fatalf("%v, %d bytes: expected ErrBufTooSmall, got %v", v, i, err)
+			}
+		}
+	}
+}
+
+func TestSQLNull(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	testNull(t, []sql.NullString{{String: "benc", Valid: true}, {Valid: true}, {}, GenerateNullString(r, 0)},
+		SizeNullString, MarshalNullString, UnmarshalNullString, SkipNullString, ComparePrimitive[sql.NullString],
+		func(v sql.NullString) bool { return v.Valid })
+	testNull(t, []sql.NullInt64{{Int64: -1 << 40, Valid: true}, {}, GenerateNullInt64(r, 0)},
+		SizeNullInt64, MarshalNullInt64, UnmarshalNullInt64, SkipNullInt64, ComparePrimitive[sql.NullInt64],
+		func(v sql.NullInt64) bool { return v.Valid })
+	testNull(t, []sql.NullInt32{{Int32: -7, Valid: true}, {}, GenerateNullInt32(r, 0)},
+		SizeNullInt32, MarshalNullInt32, UnmarshalNullInt32, SkipNullInt32, ComparePrimitive[sql.NullInt32],
+		func(v sql.NullInt32) bool { return v.Valid })
+	testNull(t, []sql.NullInt16{{Int16: 300, Valid: true}, {}, GenerateNullInt16(r, 0)},
+		SizeNullInt16, MarshalNullInt16, UnmarshalNullInt16, SkipNullInt16, ComparePrimitive[sql.NullInt16],
+		func(v sql.NullInt16) bool { return v.Valid })
+	testNull(t, []sql.NullByte{{Byte: 0xff, Valid: true}, {}, GenerateNullByte(r, 0)},
+		SizeNullByte, MarshalNullByte, UnmarshalNullByte, SkipNullByte, ComparePrimitive[sql.NullByte],
+		func(v sql.NullByte) bool { return v.Valid })
+	testNull(t, []sql.NullFloat64{{Float64: 1.5, Valid: true}, {}, GenerateNullFloat64(r, 0)},
+		SizeNullFloat64, MarshalNullFloat64, UnmarshalNullFloat64, SkipNullFloat64, ComparePrimitive[sql.NullFloat64],
+		func(v sql.NullFloat64) bool { return v.Valid })
+	testNull(t, []sql.NullBool{{Bool: true, Valid: true}, {Valid: true}, {}, GenerateNullBool(r, 0)},
+		SizeNullBool, MarshalNullBool, UnmarshalNullBool, SkipNullBool, ComparePrimitive[sql.NullBool],
+		func(v sql.NullBool) bool { return v.Valid })
+	testNull(t, []sql.NullTime{{Time: time.Unix(1700000000, 5).UTC(), Valid: true}, {}, GenerateNullTime(r, 0)},
+		SizeNullTime, MarshalNullTime, UnmarshalNullTime, SkipNullTime, CompareNullTime,
+		func(v sql.NullTime) bool { return v.Valid })
+
+	// the value of an invalid Null isn't marshalled
+	buf := make([]byte, SizeNullInt64(sql.NullInt64{Int64: 42}))
+	MarshalNullInt64(0, buf, sql.NullInt64{Int64: 42})
+	if _, got, err := UnmarshalNullInt64(0, buf); err != nil || got != (sql.NullInt64{}) {
+		t.Fatalf("expected an invalid zero NullInt64, got %v, %v", got, err)
+	}
+}
@@ -0,0 +1,111 @@
+package bstd
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+
+	"github.com/banditmoscow1337/benc/wire"
+)
+
+// A frame is a single marshalled message prefixed with its length as varint,
+// which is the same layout as a marshalled byte slice. Frames allow multiple
+// messages to be written to and read from a stream one after another.
+
+var ErrFrameTooLarge = errors.New("frame exceeds the maximum frame size")
+
+// defaultMaxFrame is the maximum size of a frame, if none is given, so a corrupted length
+// prefix doesn't allocate a buffer of up to maxInt bytes.
+const defaultMaxFrame = 64 << 20
+
+// FrameReader is the reader required by ReadFrame, e.g. a *bufio.Reader.
+type FrameReader interface {
+	io.Reader
+	io.ByteReader
+}
+
+// Returns the bytes needed to frame a message of 'size' bytes.
+func SizeFrame(size int) int {
+	return SizeUint(uint(size)) + size
+}
+
+// Returns the new offset 'n' after marshalling the message 'msg' as a frame.
+//
+// !- Panics, if 'b' is too small.
+func MarshalFrame(n int, b []byte, msg []byte) int {
+	return MarshalBytes(n, b, msg)
+}
+
+// Writes 'msg' as a single frame to 'w'.
+func WriteFrame(w io.Writer, msg []byte) error {
+	var prefix [binary.MaxVarintLen64]byte
+	p := MarshalUint(0, prefix[:], uint(len(msg)))
+	if _, err := w.Write(prefix[:p]); err != nil {
+		return err
+	}
+	_, err := w.Write(msg)
+	return err
+}
+
+// Reads the next frame from 'r' and returns the message inside of it.
+// The message is read into 'buf' if it is large enough, otherwise a new buffer is allocated.
+//
+// Possible errors returned:
+//   - io.EOF               - 'r' has no more frames.
+//   - io.ErrUnexpectedEOF  - 'r' ended in the middle of a frame.
+//   - ErrOverflow          - the length prefix overflowed a 64-bit unsigned integer.
+//   - ErrFrameTooLarge     - the frame is larger than 'maxSize' (64 MiB, if 'maxSize' isn't greater than zero).
+func ReadFrame(r FrameReader, buf []byte, maxSize int) ([]byte, error) {
+	us, err := readUint(r)
+	if err != nil {
+		return nil, err
+	}
+	if maxSize <= 0 {
+		maxSize = defaultMaxFrame
+	}
+	if us > uint(maxSize) {
+		return nil, ErrFrameTooLarge
+	}
+
+	s := int(us)
+	if cap(buf) < s {
+		buf = make([]byte, s)
+	}
+	buf = buf[:s]
+
+	if _, err = io.ReadFull(r, buf); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return buf, nil
+}
+
+const maxInt = int(^uint(0) >> 1)
+
+// readUint reads a varint encoded unsigned integer from 'r', see UnmarshalUint.
+func readUint(r io.ByteReader) (uint, error) {
+	var x uint
+	var s uint
+	for i := 0; ; i++ {
+		b, err := r.ReadByte()
+		if err != nil {
+			if i > 0 && err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return 0, err
+		}
+		if i == wire.MaxVarintLen {
+			return 0, ErrOverflow
+		}
+		if b < 0x80 {
+			if i == wire.MaxVarintLen-1 && b > 1 {
+				return 0, ErrOverflow
+			}
+			return x | uint(b)<<s, nil
+		}
+		x |= uint(b&0x7f) << s
+		s += 7
+	}
+}
@@ -0,0 +1,188 @@
+package bstd
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestFrames(t *testing.T) {
+	msgs := [][]byte{[]byte("first"), {}, bytes.Repeat([]byte{7}, 300)}
+
+	var stream bytes.Buffer
+	for _, msg := range msgs {
+		if err := WriteFrame(&stream, msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s := 0
+	for _, msg := range msgs {
+		s += SizeFrame(len(msg))
+	}
+	if s != stream.Len() {
+		t.Fatalf("SizeFrame mismatch: expected %d, got %d", stream.Len(), s)
+	}
+
+	buf := make([]byte, s)
+	n := 0
+	for _, msg := range msgs {
+		n = MarshalFrame(n, buf, msg)
+	}
+	if !bytes.Equal(buf, stream.Bytes()) {
+		t.Fatal("MarshalFrame and WriteFrame produced different output")
+	}
+
+	r := bufio.NewReader(&stream)
+	var scratch []byte
+	for i, msg := range msgs {
+		got, err := ReadFrame(r, scratch, 0)
+		if err != nil {
+			t.Fatalf("frame %d: %v", i, err)
+		}
+		if !bytes.Equal(got, msg) {
+			t.Fatalf("frame %d: no match", i)
+		}
+		scratch = got
+	}
+
+	if _, err := ReadFrame(r, nil, 0); err != io.EOF {
+		t.Fatalf("expected io.EOF, got %v", err)
+	}
+}
+
+func TestFrameErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		buf     []byte
+		maxSize int
+		wantErr error
+	}{
+		{"Truncated prefix", []byte{0x80}, 0, io.ErrUnexpectedEOF},
+		{"Truncated message", []byte{3, 1, 2}, 0, io.ErrUnexpectedEOF},
+		{"Prefix overflow", bytes.Repeat([]byte{0xff}, 11), 0, ErrOverflow},
+		{"Frame too large", []byte{3, 1, 2, 3}, 2, ErrFrameTooLarge},
+		{"Frame above default maximum", []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x7f}, 0, ErrFrameTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ReadFrame(bytes.NewReader(tt.buf), nil, tt.maxSize)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("ReadFrame() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSyncFrames(t *testing.T) {
+	msgs := [][]byte{[]byte("first"), {}, []byte("x" + syncMarker + "y"), bytes.Repeat([]byte{7}, 300), []byte("last")}
+
+	var stream bytes.Buffer
+	var ends []int
+	for _, msg := range msgs {
+		if err := WriteSyncFrame(&stream, msg); err != nil {
+			t.Fatal(err)
+		}
+		ends = append(ends, stream.Len())
+	}
+
+	buf := make([]byte, stream.Len())
+	n := 0
+	for _, msg := range msgs {
+		n = MarshalSyncFrame(n, buf, msg)
+		if SizeSyncFrame(len(msg)) != SizeFrame(len(msg))+8 {
+			t.Fatalf("SizeSyncFrame mismatch for %d bytes", len(msg))
+		}
+	}
+	if n != len(buf) || !bytes.Equal(buf, stream.Bytes()) {
+		t.Fatal("MarshalSyncFrame and WriteSyncFrame produced different output")
+	}
+
+	// corrupt the message holding a marker, then garbage in front of the last frame
+	b := stream.Bytes()
+	b[ends[2]-5] ^= 0xff
+	b = append(b[:ends[3]], append([]byte{syncMarker[0], 1, 2}, b[ends[3]:]...)...)
+
+	d := NewDecoder(bufio.NewReader(bytes.NewReader(b)), 0)
+	var got [][]byte
+	var errs []error
+	for {
+		msg, err := d.Next(nil)
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			errs = append(errs, err)
+			if _, err = d.Resync(); err != nil {
+				t.Fatalf("resync: %v", err)
+			}
+			continue
+		}
+		got = append(got, msg)
+	}
+
+	expected := [][]byte{msgs[0], msgs[1], msgs[3], msgs[4]}
+	if len(got) != len(expected) {
+		t.Fatalf("expected %d messages, got %d: %q", len(expected), len(got), got)
+	}
+	for i := range got {
+		if !bytes.Equal(got[i], expected[i]) {
+			t.Fatalf("message %d: expected %q, got %q", i, expected[i], got[i])
+		}
+	}
+	if len(errs) != 3 || !errors.Is(errs[0], ErrChecksum) || !errors.Is(errs[2], ErrNoSyncMarker) {
+		t.Fatalf("expected a checksum error, the one of the marker inside of it, and a missing marker, got %v", errs)
+	}
+}
+
+func TestDecoderReset(t *testing.T) {
+	var stream bytes.Buffer
+	if err := WriteSyncFrame(&stream, []byte("message")); err != nil {
+		t.Fatal(err)
+	}
+	b := stream.Bytes()
+
+	// the bytes of a frame failing at the end of the first stream aren't read of the next one
+	d := NewDecoder(bufio.NewReader(bytes.NewReader(b[:len(b)-1])), 0)
+	if _, err := d.Next(nil); err != io.ErrUnexpectedEOF {
+		t.Fatalf("expected io.ErrUnexpectedEOF, got %v", err)
+	}
+	d.Reset(bufio.NewReader(bytes.NewReader(b)))
+	if msg, err := d.Next(nil); err != nil || string(msg) != "message" {
+		t.Fatalf("expected %q after Reset, got %q, %v", "message", msg, err)
+	}
+	if _, err := d.Next(nil); err != io.EOF {
+		t.Fatalf("expected io.EOF, got %v", err)
+	}
+}
+
+func TestSyncFrameErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		buf     []byte
+		maxSize int
+		wantErr error
+	}{
+		{"No marker", []byte{1, 'a', 0, 0, 0, 0}, 0, ErrNoSyncMarker},
+		{"Truncated marker", []byte(syncMarker[:2]), 0, io.ErrUnexpectedEOF},
+		{"Truncated message", []byte(syncMarker + "\x03\x01\x02"), 0, io.ErrUnexpectedEOF},
+		{"Truncated checksum", []byte(syncMarker + "\x01\x01\x02"), 0, io.ErrUnexpectedEOF},
+		{"Prefix overflow", append([]byte(syncMarker), bytes.Repeat([]byte{0xff}, 11)...), 0, ErrOverflow},
+		{"Frame too large", []byte(syncMarker + "\x03\x01\x02\x03"), 2, ErrFrameTooLarge},
+		{"Frame above default maximum", []byte(syncMarker + "\x80\x80\x80\x80\x80\x20"), 0, ErrFrameTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := NewDecoder(bytes.NewReader(tt.buf), tt.maxSize)
+			if _, err := d.Next(nil); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Next() error = %v, want %v", err, tt.wantErr)
+			}
+			if _, err := d.Resync(); err != io.EOF {
+				t.Errorf("Resync() error = %v, want io.EOF", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package bstd
+
+import "errors"
+
+var ErrSymbol = errors.New("symbol index out of range")
+
+// Symbols is a table of strings that fields refer to by index, so a string repeated
+// throughout a message, like the node IDs of a graph, is stored only once. It is shared
+// by all fields of the message, including map keys, which distinguishes it from encoding
+// the values of a single field.
+//
+// The table is marshalled in front of the fields that refer to it:
+//
+//	var syms bstd.Symbols
+//	s := bstd.SizeMap(edges, syms.SizeSymbol, func(to []string) int { return bstd.SizeSlice(to, syms.SizeSymbol) })
+//	s += syms.Size()
+//	n := syms.Marshal(0, buf)
+//	n = bstd.MarshalMap(n, buf, edges, syms.MarshalSymbol, ...)
+//
+// The sizing adds the strings to the table, so it has to happen before the table is
+// marshalled. On the other side Unmarshal reads the table and UnmarshalSymbol resolves
+// the references; all of them share the string of the table.
+//
+// The zero value is an empty table, ready to use.
+type Symbols struct {
+	Strings []string
+	index   map[string]uint
+}
+
+// Add adds 'str' to the table, if it isn't already in it, and returns its index.
+func (s *Symbols) Add(str string) uint {
+	if i, ok := s.lookup(str); ok {
+		return i
+	}
+	i := uint(len(s.Strings))
+	s.Strings = append(s.Strings, str)
+	s.index[str] = i
+	return i
+}
+
+// lookup returns the index of 'str', indexing the strings first if the table got unmarshalled or set directly.
+func (s *Symbols) lookup(str string) (uint, bool) {
+	if s.index == nil {
+		s.index = make(map[string]uint, len(s.Strings))
+		for i, sym := range s.Strings {
+			if _, ok := s.index[sym]; !ok {
+				s.index[sym] = uint(i)
+			}
+		}
+	}
+	i, ok := s.index[str]
+	return i, ok
+}
+
+// Reset empties the table, keeping its memory for the next message.
+func (s *Symbols) Reset() {
+	s.Strings = s.Strings[:0]
+	clear(s.index)
+}
+
+func (s *Symbols) Size() int {
+	return SizeSlice(s.Strings, SizeString)
+}
+
+func (s *Symbols) Marshal(tn int, b []byte) int {
+	return MarshalSlice(tn, b, s.Strings, MarshalString)
+}
+
+func (s *Symbols) Unmarshal(tn int, b []byte) (n int, err error) {
+	s.index = nil
+	n, s.Strings, err = UnmarshalSlice[string](tn, b, UnmarshalString)
+	return
+}
+
+// Returns the new offset 'n' after skipping the marshalled reference to a symbol.
+//
+// Possible errors returned:
+//   - ErrOverflow          - varint overflowed a N-bit unsigned integer.
+//   - ErrBufTooSmall       - 'b' was too small to skip the marshalled reference.
+//
+// If a error is returned, n (the int returned) equals zero ( 0 ).
+func SkipSymbol(n int, b []byte) (int, error) {
+	return SkipUint(n, b)
+}
+
+// Returns the bytes needed to marshal the reference to 'str', adding 'str' to the table.
+func (s *Symbols) SizeSymbol(str string) int {
+	return SizeUint(s.Add(str))
+}
+
+// Returns the new offset 'n' after marshalling the reference to 'str'.
+//
+// !- Panics, if 'b' is too small or 'str' isn't in the table.
+func (s *Symbols) MarshalSymbol(n int, b []byte, str string) int {
+	i, ok := s.lookup(str)
+	if !ok {
+		panic("bstd: symbol " + str + " isn't in the table, size the message before marshalling it")
+	}
+	return MarshalUint(n, b, i)
+}
+
+// Returns the new offset 'n', as well as the string of the table, a reference to which got unmarshalled.
+//
+// Possible errors returned:
+//   - ErrOverflow          - varint overflowed a N-bit unsigned integer.
+//   - ErrBufTooSmall       - 'b' was too small to unmarshal the reference.
+//   - ErrSymbol            - the reference is no index of the table.
+//
+// If a error is returned, n (the int returned) equals zero ( 0 ).
+func (s *Symbols) UnmarshalSymbol(n int, b []byte) (int, string, error) {
+	n, i, err := UnmarshalUint(n, b)
+	if err != nil {
+		return 0, "", err
+	}
+	if i >= uint(len(s.Strings)) {
+		return 0, "", ErrSymbol
+	}
+	return n, s.Strings[i], nil
+}
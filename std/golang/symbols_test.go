@@ -0,0 +1,71 @@
+package bstd
+
+import (
+	"errors"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestSymbols(t *testing.T) {
+	edges := map[string][]string{
+		"node-a": {"node-b", "node-c"},
+		"node-b": {"node-a", "node-c", "node-c"},
+		"node-c": {},
+	}
+
+	var syms Symbols
+	s := SizeMap(edges, syms.SizeSymbol, func(to []string) int { return SizeSlice(to, syms.SizeSymbol) })
+	if len(syms.Strings) != 3 {
+		t.Fatalf("expected 3 symbols, got %v", syms.Strings)
+	}
+	s += syms.Size()
+
+	buf := make([]byte, s)
+	n := syms.Marshal(0, buf)
+	n = MarshalMap(n, buf, edges, syms.MarshalSymbol, func(n int, b []byte, to []string) int {
+		return MarshalSlice(n, b, to, syms.MarshalSymbol)
+	})
+	if n != s {
+		t.Fatalf("expected offset %d, got %d", s, n)
+	}
+
+	var got Symbols
+	n, err := got.Unmarshal(0, buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, gotEdges, err := UnmarshalMap[string, []string](n, buf, got.UnmarshalSymbol, func(n int, b []byte) (int, []string, error) {
+		return UnmarshalSlice[string](n, b, got.UnmarshalSymbol)
+	})
+	if err != nil || n != s || !reflect.DeepEqual(gotEdges, edges) {
+		t.Fatalf("got %v, %d, %v", gotEdges, n, err)
+	}
+
+	// the unmarshalled table marshals the same references
+	i := uint(slices.Index(got.Strings, "node-c"))
+	if n = got.MarshalSymbol(0, buf, "node-c"); n != 1 || buf[0] != byte(i) {
+		t.Fatalf("expected reference %d, got %v", i, buf[:n])
+	}
+	if n, err = SkipSymbol(0, buf); err != nil || n != 1 {
+		t.Fatalf("skip got %d, %v", n, err)
+	}
+	if _, _, err = got.UnmarshalSymbol(0, []byte{3}); err != ErrSymbol {
+		t.Fatalf("expected ErrSymbol, got %v", err)
+	}
+	if _, _, err = got.UnmarshalSymbol(0, nil); !errors.Is(err, ErrBufTooSmall) {
+		t.Fatalf("expected ErrBufTooSmall, got %v", err)
+	}
+
+	got.Reset()
+	if i := got.Add("x"); i != 0 || len(got.Strings) != 1 {
+		t.Fatalf("expected a fresh table, got %d, %v", i, got.Strings)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected a panic for a string missing in the table")
+		}
+	}()
+	got.MarshalSymbol(0, buf, "node-a")
+}
@@ -0,0 +1,206 @@
+package bstd
+
+import (
+	"errors"
+	"hash/crc32"
+	"io"
+)
+
+// A sync frame is a frame, see WriteFrame, between the 4 bytes of the sync marker
+//
+//	0xb3 0xe5 0xc1 0x7a
+//
+// and the CRC-32C (Castagnoli) of the message in little endian. A Decoder detects a corrupted
+// frame by its checksum and scans forward to the next marker, so a single broken record of a
+// long-lived stream or log file drops that record, not the whole stream.
+const syncMarker = "\xb3\xe5\xc1\x7a"
+
+var ErrChecksum = errors.New("frame checksum mismatch")
+var ErrNoSyncMarker = errors.New("frame doesn't start with the sync marker")
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// Returns the bytes needed to frame a message of 'size' bytes as sync frame.
+func SizeSyncFrame(size int) int {
+	return len(syncMarker) + SizeFrame(size) + 4
+}
+
+// Returns the new offset 'n' after marshalling the message 'msg' as a sync frame.
+//
+// !- Panics, if 'b' is too small.
+func MarshalSyncFrame(n int, b []byte, msg []byte) int {
+	n += copy(b[n:n+len(syncMarker)], syncMarker)
+	n = MarshalFrame(n, b, msg)
+	return MarshalUint32(n, b, crc32.Checksum(msg, castagnoli))
+}
+
+// Writes 'msg' as a single sync frame to 'w'.
+func WriteSyncFrame(w io.Writer, msg []byte) error {
+	if _, err := io.WriteString(w, syncMarker); err != nil {
+		return err
+	}
+	if err := WriteFrame(w, msg); err != nil {
+		return err
+	}
+	var sum [4]byte
+	MarshalUint32(0, sum[:], crc32.Checksum(msg, castagnoli))
+	_, err := w.Write(sum[:])
+	return err
+}
+
+// Decoder reads sync frames from a stream and recovers from corrupted ones, see Resync.
+type Decoder struct {
+	r       FrameReader
+	maxSize int
+	// pending holds bytes already read from r, which are read again before r.
+	pending []byte
+	// head holds the marker and the length prefix of the frame read by Next.
+	head []byte
+}
+
+// Returns a Decoder reading the sync frames of 'r', which rejects frames larger than 'maxSize'
+// (64 MiB, if 'maxSize' isn't greater than zero).
+func NewDecoder(r FrameReader, maxSize int) *Decoder {
+	if maxSize <= 0 {
+		maxSize = defaultMaxFrame
+	}
+	return &Decoder{r: r, maxSize: maxSize}
+}
+
+// Reset discards the bytes read ahead and makes the Decoder read from 'r', keeping its maximum
+// size and buffers, so a Decoder is reused for the next connection, e.g. from a sync.Pool.
+func (d *Decoder) Reset(r FrameReader) {
+	d.r = r
+	d.pending = d.pending[:0]
+	d.head = d.head[:0]
+}
+
+// Reads the next sync frame and returns the message inside of it.
+// The message is read into 'buf' if it is large enough, otherwise a new buffer is allocated.
+// After an error other than io.EOF, Resync skips to the next frame.
+//
+// Possible errors returned:
+//   - io.EOF               - the stream has no more frames.
+//   - io.ErrUnexpectedEOF  - the stream ended in the middle of a frame.
+//   - ErrNoSyncMarker      - the frame doesn't start with the sync marker.
+//   - ErrOverflow          - the length prefix overflowed a 64-bit unsigned integer.
+//   - ErrFrameTooLarge     - the frame is larger than the maximum size of the Decoder.
+//   - ErrChecksum          - the checksum of the message doesn't match.
+func (d *Decoder) Next(buf []byte) ([]byte, error) {
+	d.head = d.head[:0]
+	for i := range len(syncMarker) {
+		c, err := d.readByte()
+		if err != nil {
+			if i > 0 && err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, d.fail(err, nil, nil)
+		}
+		if c != syncMarker[i] {
+			return nil, d.fail(ErrNoSyncMarker, nil, nil)
+		}
+	}
+
+	us, err := readUint(byteReaderFunc(d.readByte))
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	if err == nil && us > uint(d.maxSize) {
+		err = ErrFrameTooLarge
+	}
+	if err != nil {
+		return nil, d.fail(err, nil, nil)
+	}
+
+	s := int(us)
+	if cap(buf) < s {
+		buf = make([]byte, s)
+	}
+	buf = buf[:s]
+	if r, err := d.readFull(buf); err != nil {
+		return nil, d.fail(err, buf[:r], nil)
+	}
+
+	var sum [4]byte
+	if r, err := d.readFull(sum[:]); err != nil {
+		return nil, d.fail(err, buf, sum[:r])
+	}
+	if _, c, _ := UnmarshalUint32(0, sum[:]); c != crc32.Checksum(buf, castagnoli) {
+		return nil, d.fail(ErrChecksum, buf, sum[:])
+	}
+	return buf, nil
+}
+
+// Skips to the next sync marker after a failed Next and returns the number of bytes skipped.
+// The scan starts at the byte after the start of the failed frame, so a marker inside of it
+// is found as well; a marker inside of a message makes the next Next fail with ErrChecksum,
+// after which Resync scans on.
+//
+// Possible errors returned:
+//   - io.EOF               - the stream ended before the next marker.
+//   - any error of the underlying reader.
+func (d *Decoder) Resync() (int, error) {
+	var window [len(syncMarker)]byte
+	read := 0
+	for {
+		c, err := d.readByte()
+		if err != nil {
+			return read, err
+		}
+		d.head = d.head[:0]
+		copy(window[:], window[1:])
+		window[len(window)-1] = c
+		read++
+		if read >= len(window) && string(window[:]) == syncMarker {
+			d.pending = append(window[:], d.pending...)
+			return read - len(window), nil
+		}
+	}
+}
+
+// readByte reads the next byte, from the pending bytes first, and records it in the head.
+func (d *Decoder) readByte() (byte, error) {
+	var c byte
+	if len(d.pending) > 0 {
+		c, d.pending = d.pending[0], d.pending[1:]
+	} else {
+		var err error
+		if c, err = d.r.ReadByte(); err != nil {
+			return 0, err
+		}
+	}
+	d.head = append(d.head, c)
+	return c, nil
+}
+
+// readFull fills 'b', from the pending bytes first, and returns the number of bytes read.
+func (d *Decoder) readFull(b []byte) (int, error) {
+	n := copy(b, d.pending)
+	d.pending = d.pending[n:]
+	r, err := io.ReadFull(d.r, b[n:])
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return n + r, err
+}
+
+// fail returns 'err' after putting the bytes read of the failed frame but its first back in
+// front of the pending bytes, for Resync to scan.
+func (d *Decoder) fail(err error, body, sum []byte) error {
+	if len(d.head) == 0 {
+		return err
+	}
+	read := make([]byte, 0, len(d.head)-1+len(body)+len(sum)+len(d.pending))
+	read = append(read, d.head[1:]...)
+	read = append(read, body...)
+	read = append(read, sum...)
+	d.pending = append(read, d.pending...)
+	return err
+}
+
+// byteReaderFunc is a function implementing io.ByteReader.
+type byteReaderFunc func() (byte, error)
+
+func (f byteReaderFunc) ReadByte() (byte, error) {
+	return f()
+}
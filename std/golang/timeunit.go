@@ -0,0 +1,114 @@
+package bstd
+
+import (
+	"time"
+
+	"github.com/banditmoscow1337/benc/wire"
+)
+
+// Compact time functions
+//
+// MarshalTime writes the nanoseconds of a time in 8 bytes, which most timestamps don't need.
+// The compact encodings write the instant in a coarser unit, truncated like TimeOptions do:
+//   - TimeSeconds: the seconds since the Unix epoch as uint32 in 4 bytes, between the years
+//     1970 and 2106.
+//   - TimeMillis: the milliseconds since the Unix epoch as signed 48-bit integer in 6 bytes,
+//     within about 4400 years of 1970.
+//   - TimeMicros: the microseconds since the Unix epoch as int64 in 8 bytes, within about
+//     290000 years of 1970.
+//
+// Times outside of the range wrap around. Like UnmarshalTime, the unmarshallers return the
+// time in the local zone. The generator selects them for the times of a field with a
+// //benc:precision s|ms|us compact comment.
+
+func SkipTimeSeconds(n int, b []byte) (int, error) {
+	return SkipUint32(n, b)
+}
+
+func SizeTimeSeconds() int {
+	return 4
+}
+
+// Returns the new offset 'n' after marshalling the seconds of the time.
+//
+// !- Panics, if 'b' is too small.
+func MarshalTimeSeconds(n int, b []byte, t time.Time) int {
+	return MarshalUint32(n, b, uint32(t.Unix()))
+}
+
+// Returns the new offset 'n', as well as the time, that got unmarshalled.
+//
+// Possible errors returned:
+//   - ErrBufTooSmall       - 'b' was too small to unmarshal the time.
+//
+// If a error is returned, n (the int returned) equals zero ( 0 ).
+func UnmarshalTimeSeconds(n int, b []byte) (int, time.Time, error) {
+	n, sec, err := UnmarshalUint32(n, b)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	return n, time.Unix(int64(sec), 0), nil
+}
+
+func SkipTimeMillis(n int, b []byte) (int, error) {
+	return wire.Skip(n, b, 6)
+}
+
+func SizeTimeMillis() int {
+	return 6
+}
+
+// Returns the new offset 'n' after marshalling the milliseconds of the time.
+//
+// !- Panics, if 'b' is too small.
+func MarshalTimeMillis(n int, b []byte, t time.Time) int {
+	ms := t.UnixMilli()
+	n = MarshalUint32(n, b, uint32(ms))
+	return MarshalUint16(n, b, uint16(ms>>32))
+}
+
+// Returns the new offset 'n', as well as the time, that got unmarshalled.
+//
+// Possible errors returned:
+//   - ErrBufTooSmall       - 'b' was too small to unmarshal the time.
+//
+// If a error is returned, n (the int returned) equals zero ( 0 ).
+func UnmarshalTimeMillis(n int, b []byte) (int, time.Time, error) {
+	if len(b)-n < 6 {
+		return 0, time.Time{}, ErrBufTooSmall
+	}
+	n, lo, _ := UnmarshalUint32(n, b)
+	n, hi, _ := UnmarshalUint16(n, b)
+	// sign extend the 48 bits
+	ms := int64(uint64(hi)<<48|uint64(lo)<<16) >> 16
+	return n, time.UnixMilli(ms), nil
+}
+
+func SkipTimeMicros(n int, b []byte) (int, error) {
+	return SkipInt64(n, b)
+}
+
+func SizeTimeMicros() int {
+	return 8
+}
+
+// Returns the new offset 'n' after marshalling the microseconds of the time.
+//
+// !- Panics, if 'b' is too small.
+func MarshalTimeMicros(n int, b []byte, t time.Time) int {
+	return MarshalInt64(n, b, t.UnixMicro())
+}
+
+// Returns the new offset 'n', as well as the time, that got unmarshalled.
+//
+// Possible errors returned:
+//   - ErrBufTooSmall       - 'b' was too small to unmarshal the time.
+//
+// If a error is returned, n (the int returned) equals zero ( 0 ).
+func UnmarshalTimeMicros(n int, b []byte) (int, time.Time, error) {
+	n, us, err := UnmarshalInt64(n, b)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	return n, time.UnixMicro(us), nil
+}
@@ -0,0 +1,61 @@
+package bstd
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTimeUnits(t *testing.T) {
+	type codec struct {
+		unit      time.Duration
+		size      int
+		skip      func(int, []byte) (int, error)
+		marshal   func(int, []byte, time.Time) int
+		unmarshal func(int, []byte) (int, time.Time, error)
+	}
+	codecs := []codec{
+		{time.Second, SizeTimeSeconds(), SkipTimeSeconds, MarshalTimeSeconds, UnmarshalTimeSeconds},
+		{time.Millisecond, SizeTimeMillis(), SkipTimeMillis, MarshalTimeMillis, UnmarshalTimeMillis},
+		{time.Microsecond, SizeTimeMicros(), SkipTimeMicros, MarshalTimeMicros, UnmarshalTimeMicros},
+	}
+	times := []time.Time{
+		time.Unix(0, 0),
+		time.Unix(1700000000, 123456789),
+		time.Date(2106, 2, 7, 6, 28, 15, 999999999, time.UTC),
+	}
+
+	for _, c := range codecs {
+		for _, tm := range append(times, time.Date(1900, 1, 1, 0, 0, 0, 1000, time.UTC)) {
+			if c.unit == time.Second && tm.Year() < 1970 {
+				continue
+			}
+			buf := make([]byte, c.size)
+			if n := c.marshal(0, buf, tm); n != c.size {
+				t.Fatalf("%v, %v: expected offset %d, got %d", c.unit, tm, c.size, n)
+			}
+			n, got, err := c.unmarshal(0, buf)
+			if err != nil || n != c.size || !got.Equal(tm.Truncate(c.unit)) {
+				t.Fatalf("%v, %v: got %v, %d, %v", c.unit, tm, got, n, err)
+			}
+			if n, err = c.skip(0, buf); err != nil || n != c.size {
+				t.Fatalf("%v, %v: skip got %d, %v", c.unit, tm, n, err)
+			}
+			for i := range c.size {
+				if _, _, err = c.unmarshal(0, buf[:i]); !errors.Is(err, ErrBufTooSmall) {
+					t.Fatalf("%v, %d bytes: expected ErrBufTooSmall, got %v", c.unit, i, err)
+				}
+				if _, err = c.skip(0, buf[:i]); !errors.Is(err, ErrBufTooSmall) {
+					t.Fatalf("%v, %d bytes: skip expected ErrBufTooSmall, got %v", c.unit, i, err)
+				}
+			}
+		}
+	}
+
+	// the milliseconds of a time before 1970 are negative, which the 48 bits keep
+	buf := make([]byte, SizeTimeMillis())
+	MarshalTimeMillis(0, buf, time.UnixMilli(-2))
+	if want := []byte{0xfe, 0xff, 0xff, 0xff, 0xff, 0xff}; string(buf) != string(want) {
+		t.Fatalf("expected % x, got % x", want, buf)
+	}
+}
@@ -0,0 +1,99 @@
+package bstd
+
+import (
+	"sync"
+	"time"
+)
+
+// TimeWithZone functions
+//
+// MarshalTime keeps the instant only, the time unmarshals in the local zone. MarshalTimeWithZone
+// keeps the zone as well, for wall-clock times like the ones of calendars: the UnixNano int64
+// is followed by the offset of the zone in seconds as int32 and the name of the zone. The name
+// is the IANA name of the location, e.g. "Europe/Berlin", or the abbreviation of the zone, e.g.
+// "CET", for the local zone and fixed zones.
+//
+// UnmarshalTimeWithZone loads the location of the name, if it is known and has the marshalled
+// offset at that instant, so the time keeps the daylight saving rules of its zone. Otherwise the
+// time gets a fixed zone of the name and the offset.
+
+func SkipTimeWithZone(n int, b []byte) (int, error) {
+	n, err := SkipInt64(n, b)
+	if err != nil {
+		return 0, err
+	}
+	if n, err = SkipInt32(n, b); err != nil {
+		return 0, err
+	}
+	return SkipString(n, b)
+}
+
+func SizeTimeWithZone(t time.Time) int {
+	name, _ := timeZone(t)
+	return SizeInt64() + SizeInt32() + SizeString(name)
+}
+
+// Returns the new offset 'n' after marshalling the time and its zone.
+//
+// !- Panics, if 'b' is too small.
+func MarshalTimeWithZone(n int, b []byte, t time.Time) int {
+	name, offset := timeZone(t)
+	n = MarshalInt64(n, b, t.UnixNano())
+	n = MarshalInt32(n, b, int32(offset))
+	return MarshalString(n, b, name)
+}
+
+// Returns the new offset 'n', as well as the time in its marshalled zone, that got unmarshalled.
+//
+// Possible errors returned:
+//   - ErrOverflow          - varint overflowed a N-bit unsigned integer.
+//   - ErrBufTooSmall       - 'b' was too small to unmarshal the time.
+//
+// If a error is returned, n (the int returned) equals zero ( 0 ).
+func UnmarshalTimeWithZone(n int, b []byte) (int, time.Time, error) {
+	n, nano, err := UnmarshalInt64(n, b)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	n, offset, err := UnmarshalInt32(n, b)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	n, name, err := UnmarshalString(n, b)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	t := time.Unix(0, nano)
+	return n, t.In(zoneLocation(t, name, int(offset))), nil
+}
+
+// timeZone returns the name marshalled for the zone of 't' and its offset.
+func timeZone(t time.Time) (string, int) {
+	abbr, offset := t.Zone()
+	if loc := t.Location(); loc != time.Local {
+		return loc.String(), offset
+	}
+	return abbr, offset
+}
+
+// locations caches the locations loaded by zoneLocation, by name.
+var locations sync.Map
+
+// zoneLocation returns the location of 'name', if it has 'offset' at 't', a fixed zone otherwise.
+func zoneLocation(t time.Time, name string, offset int) *time.Location {
+	if name == "UTC" && offset == 0 {
+		return time.UTC
+	}
+	loc, ok := locations.Load(name)
+	if !ok {
+		l, err := time.LoadLocation(name)
+		if err != nil || l == time.Local || l == time.UTC {
+			return time.FixedZone(name, offset)
+		}
+		loc, _ = locations.LoadOrStore(name, l)
+	}
+	if _, o := t.In(loc.(*time.Location)).Zone(); o == offset {
+		return loc.(*time.Location)
+	}
+	return time.FixedZone(name, offset)
+}
@@ -0,0 +1,69 @@
+package bstd
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestTimeWithZone(t *testing.T) {
+	instant := time.Unix(1700000000, 123456789)
+	times := []time.Time{
+		instant.UTC(),
+		instant.In(time.FixedZone("UTC+5:30", 19800)),
+		instant.In(time.FixedZone("", -7200)),
+		instant.Local(),
+		GenerateTimeWithZone(rand.New(rand.NewSource(1)), 0),
+	}
+	if loc, err := time.LoadLocation("America/New_York"); err == nil {
+		// summer and winter time of the same location
+		times = append(times, instant.In(loc), instant.AddDate(0, 6, 0).In(loc))
+	}
+
+	for _, tm := range times {
+		s := SizeTimeWithZone(tm)
+		buf := make([]byte, s)
+		if n := MarshalTimeWithZone(0, buf, tm); n != s {
+			t.Fatalf("%v: expected offset %d, got %d", tm, s, n)
+		}
+		if err := SkipOnce_Verify(buf, SkipTimeWithZone); err != nil {
+			t.Fatal(err.Error())
+		}
+		n, got, err := UnmarshalTimeWithZone(0, buf)
+		if err != nil || n != s {
+			t.Fatalf("%v: got %d, %v", tm, n, err)
+		}
+		if err = CompareTimeWithZone(tm, got); err != nil {
+			t.Fatal(err.Error())
+		}
+		if tm.Location() != time.Local && got.Location().String() != tm.Location().String() {
+			t.Fatalf("expected location %s, got %s", tm.Location(), got.Location())
+		}
+
+		for i := range s {
+			if _, _, err = UnmarshalTimeWithZone(0, buf[:i]); !errors.Is(err, ErrBufTooSmall) {
+				t.Fatalf("%v, %d bytes: expected ErrBufTooSmall, got %v", tm, i, err)
+			}
+			if _, err = SkipTimeWithZone(0, buf[:i]); !errors.Is(err, ErrBufTooSmall) {
+				t.Fatalf("%v, %d bytes: expected ErrBufTooSmall, got %v", tm, i, err)
+			}
+		}
+	}
+}
+
+func TestTimeWithZoneUnknownName(t *testing.T) {
+	// a zone name without location, or with a different offset, becomes a fixed zone
+	for _, name := range []string{"No/Such_Zone", "UTC", "America/New_York"} {
+		tm := time.Unix(1700000000, 0).In(time.FixedZone(name, 3*3600+1))
+		buf := make([]byte, SizeTimeWithZone(tm))
+		MarshalTimeWithZone(0, buf, tm)
+		_, got, err := UnmarshalTimeWithZone(0, buf)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if err = CompareTimeWithZone(tm, got); err != nil {
+			t.Fatal(err.Error())
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package bstd
+
+import (
+	"maps"
+	"slices"
+)
+
+// The truncation of a message drops the last elements of its slices and entries of its maps
+// until the message fits into a byte budget, e.g. a UDP datagram, instead of failing. The
+// generator writes a MarshalTruncated method for every struct with a `benc:"truncated"` field,
+// which records the fields it truncated as bits of that field.
+
+// FitSlice returns the longest prefix of 's', whose size, as returned by 'size', doesn't exceed
+// 'budget', or an empty prefix if there is none. 'size' is called with prefixes of 's' and
+// must not decrease with their length.
+func FitSlice[S ~[]T, T any](s S, budget int, size func(S) int) S {
+	return s[:fitLen(len(s), budget, func(k int) int { return size(s[:k]) })]
+}
+
+// FitMap returns a map of as many entries of 'm' as fit into 'budget', whose size is returned
+// by 'size', or an empty map if none fits. Which entries it keeps is unspecified. 'size' is
+// called with maps of the entries of 'm' and must not decrease with their number.
+func FitMap[M ~map[K]V, K comparable, V any](m M, budget int, size func(M) int) M {
+	keys := slices.Collect(maps.Keys(m))
+	subset := func(k int) M {
+		sub := make(M, k)
+		for _, key := range keys[:k] {
+			sub[key] = m[key]
+		}
+		return sub
+	}
+	return subset(fitLen(len(keys), budget, func(k int) int { return size(subset(k)) }))
+}
+
+// fitLen returns the greatest k <= n, at which size(k) doesn't exceed budget, or 0.
+func fitLen(n, budget int, size func(k int) int) int {
+	lo, hi := 0, n
+	for lo < hi {
+		mid := lo + (hi-lo+1)/2
+		if size(mid) <= budget {
+			lo = mid
+		} else {
+			hi = mid - 1
+		}
+	}
+	return lo
+}
@@ -0,0 +1,42 @@
+package bstd
+
+import (
+	"maps"
+	"testing"
+)
+
+func TestFitSlice(t *testing.T) {
+	v := []string{"a", "bb", "ccc", "dddd"}
+	size := func(s []string) int { return SizeSlice(s, SizeString) }
+	// the prefixes take 5, 7, 10, 14 and 19 bytes
+	for budget, want := range map[int]int{0: 0, 6: 0, 7: 1, 9: 1, 10: 2, 13: 2, 14: 3, 18: 3, 19: 4, 100: 4} {
+		got := FitSlice(v, budget, size)
+		if len(got) != want || size(got) > budget && want > 0 {
+			t.Fatalf("budget %d: expected %d elements, got %v", budget, want, got)
+		}
+	}
+	if got := FitSlice([]string(nil), 10, size); len(got) != 0 {
+		t.Fatalf("expected no elements of an empty slice, got %v", got)
+	}
+}
+
+func TestFitMap(t *testing.T) {
+	m := map[int32]int32{1: 1, 2: 2, 3: 3, 4: 4, 5: 5}
+	size := func(m map[int32]int32) int { return SizeMap(m, SizeInt32, SizeInt32) }
+	// a map takes 5 bytes and 8 for every entry
+	for budget := range 50 {
+		got := FitMap(m, budget, size)
+		want := max(0, min(len(m), (budget-5)/8))
+		if len(got) != want {
+			t.Fatalf("budget %d: expected %d entries, got %v", budget, want, got)
+		}
+		for k, v := range got {
+			if m[k] != v {
+				t.Fatalf("budget %d: entry %d: %d isn't in the map", budget, k, v)
+			}
+		}
+	}
+	if got := FitMap(m, 100, size); !maps.Equal(got, m) {
+		t.Fatalf("expected the whole map, got %v", got)
+	}
+}
@@ -0,0 +1,215 @@
+package bstd
+
+import (
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+)
+
+// A transaction is a group of messages, which a reader gets all or none of, e.g. the rows
+// an outbox writer stages next to a database transaction. A TransactionBuffer stages the
+// messages, each in an envelope of a tag byte naming its type and its length, and Commit
+// writes them with a single header, the length of the envelopes as varint and their
+// CRC-32C (Castagnoli) in little endian, in one write:
+//
+//	var tx bstd.TransactionBuffer
+//	tx.Add(1, order.Size(), order.Marshal)
+//	tx.Add(2, event.Size(), event.Marshal)
+//	if err := tx.Commit(w); err != nil {
+//		tx.Rollback()
+//	}
+//
+// ReadTransaction and UnmarshalTransaction check the checksum before they return the first
+// message, so a transaction torn by a crash is dropped as a whole.
+
+// txHeaderSize is the room for the header in front of the staged envelopes.
+const txHeaderSize = binary.MaxVarintLen64 + 4
+
+// TransactionBuffer stages marshalled messages until they are committed or rolled back.
+// The zero value is an empty buffer ready to use.
+type TransactionBuffer struct {
+	// buf[txHeaderSize:] are the staged envelopes, the header is marshalled in front of them
+	buf   []byte
+	count int
+}
+
+// Add stages the message marshalled by 'marshal', e.g. the Marshal method of a message, which
+// takes 'size' bytes, in an envelope of the type 'tag'.
+//
+// !- Panics, if 'marshal' doesn't marshal exactly 'size' bytes.
+func (tx *TransactionBuffer) Add(tag uint8, size int, marshal func(n int, b []byte) int) {
+	n := tx.grow(SizeByte() + SizeUint(uint(size)) + size)
+	n = MarshalByte(n, tx.buf, tag)
+	n = MarshalUint(n, tx.buf, uint(size))
+	if marshal(n, tx.buf)-n != size {
+		panic("benc: `marshal` didn't marshal `size` bytes in `TransactionBuffer.Add`")
+	}
+	tx.count++
+}
+
+// AddBytes stages the marshalled message 'msg' in an envelope of the type 'tag'. The bytes
+// are copied, so 'msg' may be reused afterwards.
+func (tx *TransactionBuffer) AddBytes(tag uint8, msg []byte) {
+	n := tx.grow(SizeByte() + SizeBytes(msg))
+	n = MarshalByte(n, tx.buf, tag)
+	MarshalBytes(n, tx.buf, msg)
+	tx.count++
+}
+
+// Len returns the number of messages staged.
+func (tx *TransactionBuffer) Len() int {
+	return tx.count
+}
+
+// Size returns the bytes Commit writes for the messages staged.
+func (tx *TransactionBuffer) Size() int {
+	return SizeTransaction(tx.body())
+}
+
+// Commit writes the messages staged to 'w' with a single Write and empties the buffer. An
+// empty buffer writes nothing. If the write fails, the messages stay staged, so Commit may
+// be retried, or the buffer rolled back.
+//
+// Possible errors returned:
+//   - any error of 'w'
+func (tx *TransactionBuffer) Commit(w io.Writer) error {
+	if tx.count == 0 {
+		return nil
+	}
+	body := tx.body()
+	// the header is marshalled right in front of the envelopes, so they aren't copied
+	start := txHeaderSize - SizeUint(uint(len(body))) - SizeUint32()
+	n := MarshalUint(start, tx.buf, uint(len(body)))
+	MarshalUint32(n, tx.buf, crc32.Checksum(body, castagnoli))
+	if _, err := w.Write(tx.buf[start:]); err != nil {
+		return err
+	}
+	tx.Rollback()
+	return nil
+}
+
+// Rollback discards the messages staged. The buffer keeps its memory for the next transaction.
+func (tx *TransactionBuffer) Rollback() {
+	tx.buf = tx.buf[:0]
+	tx.count = 0
+}
+
+// body returns the staged envelopes.
+func (tx *TransactionBuffer) body() []byte {
+	if len(tx.buf) < txHeaderSize {
+		return nil
+	}
+	return tx.buf[txHeaderSize:]
+}
+
+// grow extends the buffer by 's' bytes and returns the offset of them.
+func (tx *TransactionBuffer) grow(s int) int {
+	if len(tx.buf) == 0 {
+		tx.buf = append(tx.buf, make([]byte, txHeaderSize)...)
+	}
+	n := len(tx.buf)
+	tx.buf = append(tx.buf, make([]byte, s)...)
+	return n
+}
+
+// Returns the bytes needed to marshal a transaction, whose envelopes take 'body' bytes.
+func SizeTransaction(body []byte) int {
+	return SizeUint(uint(len(body))) + SizeUint32() + len(body)
+}
+
+// Returns the new offset 'n' after unmarshalling the transaction, calling 'message' with the
+// tag and the marshalled message of every envelope in order, once the checksum and the
+// envelopes of the whole transaction are checked. 'msg' is a subslice of 'b'.
+//
+// Possible errors returned:
+//   - ErrOverflow          - varint overflowed a N-bit unsigned integer.
+//   - ErrBufTooSmall       - 'b' or the transaction was too small to unmarshal it.
+//   - ErrChecksum          - the checksum doesn't match the envelopes.
+//   - any error of 'message'
+//
+// If a error is returned, n (the int returned) equals zero ( 0 ).
+func UnmarshalTransaction(n int, b []byte, message func(tag uint8, msg []byte) error) (int, error) {
+	n, us, err := UnmarshalUint(n, b)
+	if err != nil {
+		return 0, err
+	}
+	n, sum, err := UnmarshalUint32(n, b)
+	if err != nil {
+		return 0, err
+	}
+	if us > uint(len(b)-n) {
+		return 0, ErrBufTooSmall
+	}
+	end := n + int(us)
+	if err := walkTransaction(b[n:end], sum, message); err != nil {
+		return 0, err
+	}
+	return end, nil
+}
+
+// Reads the next transaction from 'r' and calls 'message' with the tag and the marshalled
+// message of every envelope in order, once the checksum and the envelopes of the whole
+// transaction are checked. The transaction is read into 'buf' if it is large enough, otherwise
+// a new buffer is allocated; 'msg' is a subslice of it.
+//
+// Possible errors returned:
+//   - io.EOF               - 'r' has no more transactions.
+//   - io.ErrUnexpectedEOF  - 'r' ended in the middle of a transaction.
+//   - ErrOverflow          - the length prefix overflowed a 64-bit unsigned integer.
+//   - ErrFrameTooLarge     - the transaction is larger than 'maxSize' (if 'maxSize' is greater than zero).
+//   - ErrBufTooSmall       - an envelope exceeds the transaction.
+//   - ErrChecksum          - the checksum doesn't match the envelopes.
+//   - any error of 'message'
+func ReadTransaction(r FrameReader, buf []byte, maxSize int, message func(tag uint8, msg []byte) error) error {
+	us, err := readUint(r)
+	if err != nil {
+		return err
+	}
+	if us > uint(maxInt-4) || (maxSize > 0 && us > uint(maxSize)) {
+		return ErrFrameTooLarge
+	}
+
+	s := 4 + int(us)
+	if cap(buf) < s {
+		buf = make([]byte, s)
+	}
+	buf = buf[:s]
+
+	if _, err = io.ReadFull(r, buf); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return err
+	}
+	_, sum, _ := UnmarshalUint32(0, buf)
+	return walkTransaction(buf[4:], sum, message)
+}
+
+// walkTransaction checks the envelopes 'body' against the checksum 'sum' and calls 'message'
+// with every envelope, once all of them are valid.
+func walkTransaction(body []byte, sum uint32, message func(tag uint8, msg []byte) error) error {
+	if crc32.Checksum(body, castagnoli) != sum {
+		return ErrChecksum
+	}
+	for n := 0; n < len(body); {
+		var err error
+		if n, err = SkipByte(n, body); err != nil {
+			return err
+		}
+		if n, err = SkipBytes(n, body); err != nil {
+			return err
+		}
+	}
+
+	for n := 0; n < len(body); {
+		var tag uint8
+		var msg []byte
+		// the envelopes are valid, see above
+		n, tag, _ = UnmarshalByte(n, body)
+		n, msg, _ = UnmarshalBytesCropped(n, body)
+		if err := message(tag, msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package bstd
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+type txMessage struct {
+	tag uint8
+	msg string
+}
+
+func TestTransactionBuffer(t *testing.T) {
+	var stream bytes.Buffer
+	var tx TransactionBuffer
+	tx.Add(1, SizeString("order"), func(n int, b []byte) int { return MarshalString(n, b, "order") })
+	tx.AddBytes(2, []byte("event"))
+	tx.AddBytes(3, nil)
+	if tx.Len() != 3 {
+		t.Fatalf("expected 3 messages staged, got %d", tx.Len())
+	}
+	size := tx.Size()
+	if err := tx.Commit(&stream); err != nil {
+		t.Fatal(err)
+	}
+	if stream.Len() != size || tx.Len() != 0 {
+		t.Fatalf("expected %d bytes written and an empty buffer, got %d bytes and %d messages", size, stream.Len(), tx.Len())
+	}
+
+	// a rolled back transaction writes nothing, the next one is committed on its own
+	tx.AddBytes(4, []byte("discarded"))
+	tx.Rollback()
+	tx.AddBytes(5, []byte("next"))
+	if err := tx.Commit(&stream); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(&stream); err != nil || stream.Len() != size+SizeTransaction(make([]byte, 1+SizeString("next"))) {
+		t.Fatalf("expected an empty commit to write nothing, got %v", err)
+	}
+
+	var got []txMessage
+	collect := func(tag uint8, msg []byte) error {
+		got = append(got, txMessage{tag, string(msg)})
+		return nil
+	}
+	b := stream.Bytes()
+	n, err := UnmarshalTransaction(0, b, collect)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, err = UnmarshalTransaction(n, b, collect); err != nil || n != len(b) {
+		t.Fatalf("expected %d bytes unmarshalled, got %d: %v", len(b), n, err)
+	}
+	expected := []txMessage{{1, "\x05order"}, {2, "event"}, {3, ""}, {5, "next"}}
+	if len(got) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, got)
+	}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Fatalf("message %d: expected %v, got %v", i, expected[i], got[i])
+		}
+	}
+
+	got = nil
+	r := bufio.NewReader(bytes.NewReader(b))
+	for {
+		if err := ReadTransaction(r, nil, 0, collect); err != nil {
+			if err != io.EOF {
+				t.Fatal(err)
+			}
+			break
+		}
+	}
+	if len(got) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, got)
+	}
+}
+
+func TestTransactionBufferTorn(t *testing.T) {
+	var stream bytes.Buffer
+	var tx TransactionBuffer
+	tx.AddBytes(1, []byte("first"))
+	tx.AddBytes(2, []byte("second"))
+	if err := tx.Commit(&stream); err != nil {
+		t.Fatal(err)
+	}
+	b := stream.Bytes()
+
+	// no message of a corrupted or truncated transaction is returned
+	called := false
+	message := func(uint8, []byte) error {
+		called = true
+		return nil
+	}
+	corrupted := bytes.Clone(b)
+	corrupted[len(corrupted)-1] ^= 0xff
+	if _, err := UnmarshalTransaction(0, corrupted, message); err != ErrChecksum {
+		t.Fatalf("expected ErrChecksum, got %v", err)
+	}
+	if _, err := UnmarshalTransaction(0, b[:len(b)-1], message); err != ErrBufTooSmall {
+		t.Fatalf("expected ErrBufTooSmall, got %v", err)
+	}
+	if err := ReadTransaction(bufio.NewReader(bytes.NewReader(b[:len(b)-1])), nil, 0, message); err != io.ErrUnexpectedEOF {
+		t.Fatalf("expected io.ErrUnexpectedEOF, got %v", err)
+	}
+	if err := ReadTransaction(bufio.NewReader(bytes.NewReader(b)), nil, 4, message); err != ErrFrameTooLarge {
+		t.Fatalf("expected ErrFrameTooLarge, got %v", err)
+	}
+	if called {
+		t.Fatal("expected no message of a broken transaction")
+	}
+
+	// a failed commit keeps the messages staged
+	tx.AddBytes(1, []byte("retried"))
+	if err := tx.Commit(failingWriter{io.ErrClosedPipe}); !errors.Is(err, io.ErrClosedPipe) || tx.Len() != 1 {
+		t.Fatalf("expected io.ErrClosedPipe and the message staged, got %v and %d messages", err, tx.Len())
+	}
+}
@@ -0,0 +1,77 @@
+package bstd
+
+import "errors"
+
+var ErrUnionTag = errors.New("unknown union tag")
+
+// A union, or oneof, is a value of one of several types, marshalled as a tag byte naming
+// the type followed by the value. The tags are chosen by the caller, e.g. for an interface
+// with the implementations Circle and Square:
+//
+//	n = bstd.MarshalUnion(n, b, 1, circle, bstd.MarshalMessage[Circle])
+//
+//	n, tag, shape, err := bstd.UnmarshalUnion(n, b, map[uint8]func(n int, b []byte) (int, Shape, error){
+//		1: func(n int, b []byte) (int, Shape, error) { return bstd.UnmarshalMessage[Circle](n, b) },
+//		2: func(n int, b []byte) (int, Shape, error) { return bstd.UnmarshalMessage[Square](n, b) },
+//	})
+
+// Returns the new offset 'n' after skipping the marshalled union, the value with the
+// skipper of its tag in 'cases'.
+//
+// Possible errors returned:
+//   - ErrBufTooSmall       - 'b' was too small to skip the marshalled union.
+//   - ErrUnionTag          - 'cases' has no skipper for the tag.
+//   - any error of the skipper
+//
+// If a error is returned, n (the int returned) equals zero ( 0 ).
+func SkipUnion(n int, b []byte, cases map[uint8]SkipFunc) (int, error) {
+	n, tag, err := UnmarshalByte(n, b)
+	if err != nil {
+		return 0, err
+	}
+	skip, ok := cases[tag]
+	if !ok {
+		return 0, ErrUnionTag
+	}
+	return skip(n, b)
+}
+
+// Returns the bytes needed to marshal the union holding 'v', sizing the value with 'sizer'.
+func SizeUnion[T any](v T, sizer SizeFunc[T]) int {
+	return SizeByte() + sizer(v)
+}
+
+// Returns the new offset 'n' after marshalling the tag and 'v', the value with 'marshaler'.
+//
+// !- Panics, if 'b' is too small.
+func MarshalUnion[T any](n int, b []byte, tag uint8, v T, marshaler MarshalFunc[T]) int {
+	n = MarshalByte(n, b, tag)
+	return marshaler(n, b, v)
+}
+
+// Returns the new offset 'n', as well as the tag and the value of the union, that got
+// unmarshalled. The value is unmarshalled by the function of its tag in 'cases', which
+// usually return an interface type T.
+//
+// Possible errors returned:
+//   - ErrBufTooSmall       - 'b' was too small to unmarshal the union.
+//   - ErrUnionTag          - 'cases' has no function for the tag.
+//   - any error of the function
+//
+// If a error is returned, n (the int returned) equals zero ( 0 ).
+func UnmarshalUnion[T any](n int, b []byte, cases map[uint8]func(n int, b []byte) (int, T, error)) (int, uint8, T, error) {
+	var v T
+	n, tag, err := UnmarshalByte(n, b)
+	if err != nil {
+		return 0, 0, v, err
+	}
+	unmarshal, ok := cases[tag]
+	if !ok {
+		return 0, 0, v, ErrUnionTag
+	}
+	if n, v, err = unmarshal(n, b); err != nil {
+		var zero T
+		return 0, 0, zero, err
+	}
+	return n, tag, v, nil
+}
@@ -0,0 +1,72 @@
+package bstd
+
+import (
+	"errors"
+	"testing"
+)
+
+type unionShape interface{ area() float64 }
+
+type unionCircle float64
+
+func (c unionCircle) area() float64 { return 3 * float64(c) * float64(c) }
+
+type unionLabel string
+
+func (l unionLabel) area() float64 { return 0 }
+
+func TestUnion(t *testing.T) {
+	cases := map[uint8]func(n int, b []byte) (int, unionShape, error){
+		1: func(n int, b []byte) (int, unionShape, error) {
+			n, r, err := UnmarshalFloat64(n, b)
+			return n, unionCircle(r), err
+		},
+		2: func(n int, b []byte) (int, unionShape, error) {
+			n, s, err := UnmarshalString(n, b)
+			return n, unionLabel(s), err
+		},
+	}
+	skippers := map[uint8]SkipFunc{1: SkipFloat64, 2: SkipString}
+
+	marshal := func(v unionShape) []byte {
+		switch v := v.(type) {
+		case unionCircle:
+			buf := make([]byte, SizeUnion(float64(v), func(float64) int { return SizeFloat64() }))
+			MarshalUnion(0, buf, 1, float64(v), MarshalFloat64)
+			return buf
+		case unionLabel:
+			buf := make([]byte, SizeUnion(string(v), SizeString))
+			MarshalUnion(0, buf, 2, string(v), MarshalString)
+			return buf
+		}
+		return nil
+	}
+
+	for _, v := range []unionShape{unionCircle(1.5), unionLabel("benc")} {
+		buf := marshal(v)
+		n, tag, got, err := UnmarshalUnion(0, buf, cases)
+		if err != nil || n != len(buf) || got != v || tag != buf[0] {
+			t.Fatalf("expected %v, got %v, %d, %d, %v", v, got, tag, n, err)
+		}
+		if n, err = SkipUnion(0, buf, skippers); err != nil || n != len(buf) {
+			t.Fatalf("%v: skip got %d, %v", v, n, err)
+		}
+
+		for i := range len(buf) {
+			if _, _, _, err = UnmarshalUnion(0, buf[:i], cases); !errors.Is(err, ErrBufTooSmall) {
+				t.Fatalf("%v, %d bytes: expected ErrBufTooSmall, got %v", v, i, err)
+			}
+			if _, err = SkipUnion(0, buf[:i], skippers); !errors.Is(err, ErrBufTooSmall) {
+				t.Fatalf("%v, %d bytes: expected ErrBufTooSmall, got %v", v, i, err)
+			}
+		}
+	}
+
+	buf := []byte{3, 0}
+	if _, _, _, err := UnmarshalUnion(0, buf, cases); !errors.Is(err, ErrUnionTag) {
+		t.Fatalf("expected ErrUnionTag, got %v", err)
+	}
+	if _, err := SkipUnion(0, buf, skippers); !errors.Is(err, ErrUnionTag) {
+		t.Fatalf("expected ErrUnionTag, got %v", err)
+	}
+}
@@ -0,0 +1,312 @@
+package bstd
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+)
+
+// A variant is a JSON-like value of a field typed `any`, e.g. the values of a map[string]any,
+// marshalled as a tag byte followed by the value. Variants hold one of
+//
+//	nil, bool, int64, uint64, float64, string, []byte, map[string]any or []any
+//
+// with maps and lists holding variants again, up to VariantMaxDepth levels deep. An int is
+// marshalled as int64, so it unmarshals as int64. Any other type makes SizeVariant and
+// MarshalVariant panic, CheckVariant reports it up front.
+//
+// As every value carries its tag, variants are self-describing: SkipVariant, UnmarshalVariant
+// and VariantToJSON walk them without a schema, which makes them the tagged mode of benc for
+// generic tooling, e.g. `benc tag` converts messages of a schema type into variants.
+const (
+	VariantNil byte = iota
+	VariantFalse
+	VariantTrue
+	// VariantInt is followed by a zigzag varint.
+	VariantInt
+	// VariantFloat is followed by the 8 bytes of a float64.
+	VariantFloat
+	// VariantString and VariantBytes are followed by the length and the bytes.
+	VariantString
+	VariantBytes
+	// VariantMap is followed by the number of entries and the entries, a string key and a variant each.
+	VariantMap
+	// VariantList is followed by the number of elements and the elements.
+	VariantList
+	// VariantUint is followed by a varint, it holds the uint64 values an int64 can't.
+	VariantUint
+)
+
+// VariantMaxDepth is the number of nested maps and lists a variant may have, which keeps
+// malicious input from exhausting the stack.
+const VariantMaxDepth = 64
+
+var ErrVariantTag = errors.New("unknown variant tag")
+var ErrVariantDepth = errors.New("variant nested too deeply")
+
+// CheckVariant returns an error if 'v' or a value inside of it has no variant encoding.
+func CheckVariant(v any) error {
+	return checkVariant(v, 0)
+}
+
+func checkVariant(v any, depth int) error {
+	switch t := v.(type) {
+	case nil, bool, int, int64, uint64, float64, string, []byte:
+		return nil
+	case map[string]any:
+		if depth == VariantMaxDepth {
+			return ErrVariantDepth
+		}
+		for k, e := range t {
+			if err := checkVariant(e, depth+1); err != nil {
+				return fmt.Errorf("%q: %w", k, err)
+			}
+		}
+		return nil
+	case []any:
+		if depth == VariantMaxDepth {
+			return ErrVariantDepth
+		}
+		for i, e := range t {
+			if err := checkVariant(e, depth+1); err != nil {
+				return fmt.Errorf("%d: %w", i, err)
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("benc: %T is no variant type", v)
+}
+
+// Returns the new offset 'n' after skipping the marshalled variant.
+//
+// Possible errors returned:
+//   - ErrOverflow          - varint overflowed a N-bit unsigned integer.
+//   - ErrBufTooSmall       - 'b' was too small to skip the marshalled variant.
+//   - ErrVariantTag        - the variant has an unknown tag.
+//   - ErrVariantDepth      - the variant has more than VariantMaxDepth levels.
+//
+// If a error is returned, n (the int returned) equals zero ( 0 ).
+func SkipVariant(n int, b []byte) (int, error) {
+	return skipVariant(n, b, 0)
+}
+
+func skipVariant(n int, b []byte, depth int) (int, error) {
+	n, tag, err := UnmarshalByte(n, b)
+	if err != nil {
+		return 0, err
+	}
+	switch tag {
+	case VariantNil, VariantFalse, VariantTrue:
+		return n, nil
+	case VariantInt:
+		return SkipVarint(n, b)
+	case VariantUint:
+		return SkipUint64Varint(n, b)
+	case VariantFloat:
+		return SkipFloat64(n, b)
+	case VariantString, VariantBytes:
+		return SkipAny(n, b)
+	case VariantMap, VariantList:
+		if depth == VariantMaxDepth {
+			return 0, ErrVariantDepth
+		}
+		n, count, err := UnmarshalUint(n, b)
+		if err != nil {
+			return 0, err
+		}
+		for range count {
+			if tag == VariantMap {
+				if n, err = SkipString(n, b); err != nil {
+					return 0, err
+				}
+			}
+			if n, err = skipVariant(n, b, depth+1); err != nil {
+				return 0, err
+			}
+		}
+		return n, nil
+	}
+	return 0, ErrVariantTag
+}
+
+// Returns the bytes needed to marshal the variant.
+//
+// !- Panics, if 'v' holds a type without variant encoding, see CheckVariant.
+func SizeVariant(v any) int {
+	s := SizeByte()
+	switch t := v.(type) {
+	case nil, bool:
+	case int:
+		s += SizeInt64Varint(int64(t))
+	case int64:
+		s += SizeInt64Varint(t)
+	case uint64:
+		s += SizeUint64Varint(t)
+	case float64:
+		s += SizeFloat64()
+	case string:
+		s += SizeString(t)
+	case []byte:
+		s += SizeBytes(t)
+	case map[string]any:
+		s += SizeUint(uint(len(t)))
+		for k, e := range t {
+			s += SizeString(k) + SizeVariant(e)
+		}
+	case []any:
+		s += SizeUint(uint(len(t)))
+		for _, e := range t {
+			s += SizeVariant(e)
+		}
+	default:
+		panic(fmt.Sprintf("benc: %T is no variant type", v))
+	}
+	return s
+}
+
+// Returns the new offset 'n' after marshalling the variant.
+//
+// !- Panics, if 'b' is too small or 'v' holds a type without variant encoding, see CheckVariant.
+func MarshalVariant(n int, b []byte, v any) int {
+	return marshalVariant(n, b, v, false)
+}
+
+// Returns the new offset 'n' after marshalling the variant, the entries of its maps sorted
+// by their keys, see the canonical mode.
+//
+// !- Panics, if 'b' is too small or 'v' holds a type without variant encoding, see CheckVariant.
+func MarshalVariantCanonical(n int, b []byte, v any) int {
+	return marshalVariant(n, b, v, true)
+}
+
+func marshalVariant(n int, b []byte, v any, canonical bool) int {
+	switch t := v.(type) {
+	case nil:
+		return MarshalByte(n, b, VariantNil)
+	case bool:
+		if t {
+			return MarshalByte(n, b, VariantTrue)
+		}
+		return MarshalByte(n, b, VariantFalse)
+	case int:
+		n = MarshalByte(n, b, VariantInt)
+		return MarshalInt64Varint(n, b, int64(t))
+	case int64:
+		n = MarshalByte(n, b, VariantInt)
+		return MarshalInt64Varint(n, b, t)
+	case uint64:
+		n = MarshalByte(n, b, VariantUint)
+		return MarshalUint64Varint(n, b, t)
+	case float64:
+		n = MarshalByte(n, b, VariantFloat)
+		return MarshalFloat64(n, b, t)
+	case string:
+		n = MarshalByte(n, b, VariantString)
+		return MarshalString(n, b, t)
+	case []byte:
+		n = MarshalByte(n, b, VariantBytes)
+		return MarshalBytes(n, b, t)
+	case map[string]any:
+		n = MarshalByte(n, b, VariantMap)
+		n = MarshalUint(n, b, uint(len(t)))
+		if canonical {
+			for _, k := range slices.Sorted(maps.Keys(t)) {
+				n = MarshalString(n, b, k)
+				n = marshalVariant(n, b, t[k], true)
+			}
+			return n
+		}
+		for k, e := range t {
+			n = MarshalString(n, b, k)
+			n = marshalVariant(n, b, e, false)
+		}
+		return n
+	case []any:
+		n = MarshalByte(n, b, VariantList)
+		n = MarshalUint(n, b, uint(len(t)))
+		for _, e := range t {
+			n = marshalVariant(n, b, e, canonical)
+		}
+		return n
+	}
+	panic(fmt.Sprintf("benc: %T is no variant type", v))
+}
+
+// Returns the new offset 'n', as well as the variant, that got unmarshalled.
+// Strings and byte slices are copied out of 'b'.
+//
+// Possible errors returned:
+//   - ErrOverflow          - varint overflowed a N-bit unsigned integer.
+//   - ErrBufTooSmall       - 'b' was too small to unmarshal the variant.
+//   - ErrVariantTag        - the variant has an unknown tag.
+//   - ErrVariantDepth      - the variant has more than VariantMaxDepth levels.
+//
+// If a error is returned, n (the int returned) equals zero ( 0 ).
+func UnmarshalVariant(n int, b []byte) (int, any, error) {
+	return unmarshalVariant(n, b, 0)
+}
+
+func unmarshalVariant(n int, b []byte, depth int) (int, any, error) {
+	n, tag, err := UnmarshalByte(n, b)
+	if err != nil {
+		return 0, nil, err
+	}
+	switch tag {
+	case VariantNil:
+		return n, nil, nil
+	case VariantFalse, VariantTrue:
+		return n, tag == VariantTrue, nil
+	case VariantInt:
+		return unmarshalAny(UnmarshalInt64Varint(n, b))
+	case VariantUint:
+		return unmarshalAny(UnmarshalUint64Varint(n, b))
+	case VariantFloat:
+		return unmarshalAny(UnmarshalFloat64(n, b))
+	case VariantString:
+		return unmarshalAny(UnmarshalString(n, b))
+	case VariantBytes:
+		return unmarshalAny(UnmarshalBytesCopied(n, b))
+	case VariantMap, VariantList:
+		if depth == VariantMaxDepth {
+			return 0, nil, ErrVariantDepth
+		}
+		n, count, err := UnmarshalUint(n, b)
+		if err != nil {
+			return 0, nil, err
+		}
+		// every entry takes at least a byte, which bounds the allocation by the input
+		if count > uint(len(b)-n) {
+			return 0, nil, ErrBufTooSmall
+		}
+		if tag == VariantList {
+			l := make([]any, count)
+			for i := range l {
+				if n, l[i], err = unmarshalVariant(n, b, depth+1); err != nil {
+					return 0, nil, err
+				}
+			}
+			return n, l, nil
+		}
+		m := make(map[string]any, count)
+		for range count {
+			var k string
+			if n, k, err = UnmarshalString(n, b); err != nil {
+				return 0, nil, err
+			}
+			if n, m[k], err = unmarshalVariant(n, b, depth+1); err != nil {
+				return 0, nil, err
+			}
+		}
+		return n, m, nil
+	}
+	return 0, nil, ErrVariantTag
+}
+
+// unmarshalAny converts the result of an unmarshaller into the one of UnmarshalVariant.
+func unmarshalAny[T any](n int, v T, err error) (int, any, error) {
+	if err != nil {
+		return 0, nil, err
+	}
+	return n, v, nil
+}
@@ -0,0 +1,85 @@
+package bstd
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestVariant(t *testing.T) {
+	values := []any{
+		nil, true, false, int64(-300), uint64(1<<63 + 5), 1.5, "", "benc", []byte{}, []byte{1, 2},
+		[]any{}, map[string]any{},
+		map[string]any{
+			"name":  "x",
+			"tags":  []any{"a", int64(1), nil, []any{false}},
+			"attrs": map[string]any{"w": 0.25, "raw": []byte("raw")},
+		},
+	}
+	for _, v := range values {
+		if err := CheckVariant(v); err != nil {
+			t.Fatalf("%v: %v", v, err)
+		}
+		s := SizeVariant(v)
+		buf := make([]byte, s)
+		if n := MarshalVariant(0, buf, v); n != s {
+			t.Fatalf("%v: expected offset %d, got %d", v, s, n)
+		}
+
+		n, got, err := UnmarshalVariant(0, buf)
+		if err != nil || n != s || !reflect.DeepEqual(got, v) {
+			t.Fatalf("expected %#v, got %#v, %d, %v", v, got, n, err)
+		}
+		if n, err = SkipVariant(0, buf); err != nil || n != s {
+			t.Fatalf("%v: skip got %d, %v", v, n, err)
+		}
+
+		for i := range s {
+			if _, _, err = UnmarshalVariant(0, buf[:i]); !errors.Is(err, ErrBufTooSmall) {
+				t.Fatalf("%v, %d bytes: expected ErrBufTooSmall, got %v", v, i, err)
+			}
+			if _, err = SkipVariant(0, buf[:i]); !errors.Is(err, ErrBufTooSmall) {
+				t.Fatalf("%v, %d bytes: expected ErrBufTooSmall, got %v", v, i, err)
+			}
+		}
+	}
+}
+
+func TestVariantInvalid(t *testing.T) {
+	if err := CheckVariant(map[string]any{"n": int32(1)}); err == nil {
+		t.Fatal("expected an error for an int32")
+	}
+
+	// ints unmarshal as int64
+	buf := make([]byte, SizeVariant(-1))
+	MarshalVariant(0, buf, -1)
+	if _, v, err := UnmarshalVariant(0, buf); err != nil || v != int64(-1) {
+		t.Fatalf("expected int64(-1), got %#v, %v", v, err)
+	}
+
+	if _, _, err := UnmarshalVariant(0, []byte{VariantUint + 1}); !errors.Is(err, ErrVariantTag) {
+		t.Fatalf("expected ErrVariantTag, got %v", err)
+	}
+	if _, err := SkipVariant(0, []byte{VariantUint + 1}); !errors.Is(err, ErrVariantTag) {
+		t.Fatalf("expected ErrVariantTag, got %v", err)
+	}
+
+	// lists nested beyond the limit
+	deep := bytes.Repeat([]byte{VariantList, 1}, VariantMaxDepth+1)
+	deep = append(deep, VariantNil)
+	if _, _, err := UnmarshalVariant(0, deep); !errors.Is(err, ErrVariantDepth) {
+		t.Fatalf("expected ErrVariantDepth, got %v", err)
+	}
+	if _, err := SkipVariant(0, deep); !errors.Is(err, ErrVariantDepth) {
+		t.Fatalf("expected ErrVariantDepth, got %v", err)
+	}
+	if _, _, err := UnmarshalVariant(0, deep[2:]); err != nil {
+		t.Fatalf("expected %d levels to unmarshal, got %v", VariantMaxDepth, err)
+	}
+
+	// a count larger than the input
+	if _, _, err := UnmarshalVariant(0, []byte{VariantMap, 0xff, 0xff, 0xff, 0x0f}); !errors.Is(err, ErrBufTooSmall) {
+		t.Fatalf("expected ErrBufTooSmall, got %v", err)
+	}
+}
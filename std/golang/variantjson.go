@@ -0,0 +1,226 @@
+package bstd
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"unicode/utf8"
+)
+
+// JSONToVariant reads the next JSON value of 'dec' and appends it to 'b' as variant, token by
+// token, without building the value in between. Objects become maps, arrays lists. Numbers
+// are int64, if the decoder uses json.Number (see json.Decoder.UseNumber) and they are
+// integers, uint64 if only that holds them, float64 otherwise.
+//
+// Possible errors returned:
+//   - any error of 'dec', e.g. io.EOF at the end of the stream
+//   - ErrVariantDepth      - the value has more than VariantMaxDepth levels.
+//
+// If a error is returned, the returned slice is 'b' unchanged.
+func JSONToVariant(b []byte, dec *json.Decoder) ([]byte, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return b, err
+	}
+	vb, err := jsonToVariant(b, dec, tok, 0)
+	if err != nil {
+		return b, err
+	}
+	return vb, nil
+}
+
+func jsonToVariant(b []byte, dec *json.Decoder, tok json.Token, depth int) ([]byte, error) {
+	switch t := tok.(type) {
+	case json.Number:
+		if i, err := t.Int64(); err == nil {
+			return appendVariant(b, i), nil
+		}
+		if u, err := strconv.ParseUint(t.String(), 10, 64); err == nil {
+			return appendVariant(b, u), nil
+		}
+		f, err := t.Float64()
+		if err != nil {
+			return b, err
+		}
+		return appendVariant(b, f), nil
+	case json.Delim:
+		if depth == VariantMaxDepth {
+			return b, ErrVariantDepth
+		}
+		tag := VariantList
+		if t == '{' {
+			tag = VariantMap
+		}
+		start, count := len(b), uint(0)
+		for dec.More() {
+			if tag == VariantMap {
+				key, err := dec.Token()
+				if err != nil {
+					return b, err
+				}
+				b = AppendString(b, key.(string))
+			}
+			elt, err := dec.Token()
+			if err != nil {
+				return b, err
+			}
+			if b, err = jsonToVariant(b, dec, elt, depth+1); err != nil {
+				return b, err
+			}
+			count++
+		}
+		if _, err := dec.Token(); err != nil {
+			return b, err
+		}
+
+		// the count is known only now, the elements move behind the header
+		head := SizeByte() + SizeUint(count)
+		b = slices.Insert(b, start, make([]byte, head)...)
+		MarshalUint(MarshalByte(start, b, tag), b, count)
+		return b, nil
+	}
+	return appendVariant(b, tok), nil
+}
+
+// appendVariant appends the scalar variant 'v' to 'b'.
+func appendVariant(b []byte, v any) []byte {
+	b, n := grow(b, SizeVariant(v))
+	MarshalVariant(n, b, v)
+	return b
+}
+
+// VariantToJSON appends the variant at offset 'n' of 'b' to 'dst' as JSON and returns the new
+// offset 'n' and 'dst', without unmarshalling the value in between. Byte slices become base64
+// strings, like encoding/json writes them, the keys of maps keep their marshalled order.
+//
+// Possible errors returned:
+//   - ErrOverflow          - varint overflowed a N-bit unsigned integer.
+//   - ErrBufTooSmall       - 'b' was too small to unmarshal the variant.
+//   - ErrVariantTag        - the variant has an unknown tag.
+//   - ErrVariantDepth      - the variant has more than VariantMaxDepth levels.
+//   - ErrInvalidData       - a float is NaN or infinite, which JSON can't represent.
+//
+// If a error is returned, n (the int returned) equals zero ( 0 ) and the returned slice is 'dst' unchanged.
+func VariantToJSON(dst []byte, n int, b []byte) (int, []byte, error) {
+	n, jb, err := variantToJSON(dst, n, b, 0)
+	if err != nil {
+		return 0, dst, err
+	}
+	return n, jb, nil
+}
+
+func variantToJSON(dst []byte, n int, b []byte, depth int) (int, []byte, error) {
+	n, tag, err := UnmarshalByte(n, b)
+	if err != nil {
+		return 0, dst, err
+	}
+	switch tag {
+	case VariantNil:
+		return n, append(dst, "null"...), nil
+	case VariantFalse:
+		return n, append(dst, "false"...), nil
+	case VariantTrue:
+		return n, append(dst, "true"...), nil
+	case VariantInt:
+		n, i, err := UnmarshalInt64Varint(n, b)
+		if err != nil {
+			return 0, dst, err
+		}
+		return n, strconv.AppendInt(dst, i, 10), nil
+	case VariantUint:
+		n, u, err := UnmarshalUint64Varint(n, b)
+		if err != nil {
+			return 0, dst, err
+		}
+		return n, strconv.AppendUint(dst, u, 10), nil
+	case VariantFloat:
+		n, f, err := UnmarshalFloat64(n, b)
+		if err != nil {
+			return 0, dst, err
+		}
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return 0, dst, fmt.Errorf("%w: %v has no JSON representation", ErrInvalidData, f)
+		}
+		return n, strconv.AppendFloat(dst, f, 'g', -1, 64), nil
+	case VariantString, VariantBytes:
+		n, s, err := UnmarshalBytesCropped(n, b)
+		if err != nil {
+			return 0, dst, err
+		}
+		if tag == VariantBytes {
+			dst = append(dst, '"')
+			dst = base64.StdEncoding.AppendEncode(dst, s)
+			return n, append(dst, '"'), nil
+		}
+		return n, appendJSONString(dst, s), nil
+	case VariantMap, VariantList:
+		if depth == VariantMaxDepth {
+			return 0, dst, ErrVariantDepth
+		}
+		n, count, err := UnmarshalUint(n, b)
+		if err != nil {
+			return 0, dst, err
+		}
+		begin, end := byte('['), byte(']')
+		if tag == VariantMap {
+			begin, end = '{', '}'
+		}
+		dst = append(dst, begin)
+		for i := range count {
+			if i > 0 {
+				dst = append(dst, ',')
+			}
+			if tag == VariantMap {
+				var key []byte
+				if n, key, err = UnmarshalBytesCropped(n, b); err != nil {
+					return 0, dst, err
+				}
+				dst = append(appendJSONString(dst, key), ':')
+			}
+			if n, dst, err = variantToJSON(dst, n, b, depth+1); err != nil {
+				return 0, dst, err
+			}
+		}
+		return n, append(dst, end), nil
+	}
+	return 0, dst, ErrVariantTag
+}
+
+// appendJSONString appends 's' as quoted JSON string, replacing invalid UTF-8 with U+FFFD
+// like encoding/json.
+func appendJSONString(dst []byte, s []byte) []byte {
+	const hex = "0123456789abcdef"
+	dst = append(dst, '"')
+	for len(s) > 0 {
+		c := s[0]
+		if c >= utf8.RuneSelf {
+			r, size := utf8.DecodeRune(s)
+			if r == utf8.RuneError && size == 1 {
+				dst = append(dst, "\ufffd"...)
+			} else {
+				dst = append(dst, s[:size]...)
+			}
+			s = s[size:]
+			continue
+		}
+		switch {
+		case c == '"' || c == '\\':
+			dst = append(dst, '\\', c)
+		case c == '\n':
+			dst = append(dst, '\\', 'n')
+		case c == '\r':
+			dst = append(dst, '\\', 'r')
+		case c == '\t':
+			dst = append(dst, '\\', 't')
+		case c < 0x20:
+			dst = append(dst, '\\', 'u', '0', '0', hex[c>>4], hex[c&0xf])
+		default:
+			dst = append(dst, c)
+		}
+		s = s[1:]
+	}
+	return append(dst, '"')
+}
@@ -0,0 +1,126 @@
+package bstd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestJSONVariant(t *testing.T) {
+	stream := `{"name":"benc","n":-3,"f":1.5,"big":1e300,"u":18446744073709551615,"ok":true,"none":null,"tags":["a",{"b":[]}],"esc":"q\"\\\n\u0001é"} [] 7 "x"`
+	dec := json.NewDecoder(strings.NewReader(stream))
+	dec.UseNumber()
+	ref := json.NewDecoder(strings.NewReader(stream))
+	ref.UseNumber()
+
+	var b []byte
+	for {
+		var err error
+		if b, err = JSONToVariant(b, dec); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	n := 0
+	for {
+		var want any
+		if err := ref.Decode(&want); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		want = fromJSON(want)
+
+		// the variant is the one of the unmarshalled JSON
+		s, v, err := UnmarshalVariant(n, b)
+		if err != nil || !reflect.DeepEqual(v, want) {
+			t.Fatalf("expected %#v, got %#v, %v", want, v, err)
+		}
+
+		var js []byte
+		next, js, err := VariantToJSON(nil, n, b)
+		if err != nil || next != s {
+			t.Fatalf("got %d, %v, expected offset %d", next, err, s)
+		}
+		var got any
+		d := json.NewDecoder(bytes.NewReader(js))
+		d.UseNumber()
+		if err = d.Decode(&got); err != nil || !reflect.DeepEqual(fromJSON(got), want) {
+			t.Fatalf("expected %#v, got %s, %v", want, js, err)
+		}
+		n = next
+	}
+	if n != len(b) {
+		t.Fatalf("expected offset %d, got %d", len(b), n)
+	}
+}
+
+// fromJSON converts the json.Numbers of v into the variant numbers.
+func fromJSON(v any) any {
+	switch t := v.(type) {
+	case json.Number:
+		if i, err := t.Int64(); err == nil {
+			return i
+		}
+		if u, err := strconv.ParseUint(t.String(), 10, 64); err == nil {
+			return u
+		}
+		f, _ := t.Float64()
+		return f
+	case map[string]any:
+		for k, e := range t {
+			t[k] = fromJSON(e)
+		}
+	case []any:
+		for i, e := range t {
+			t[i] = fromJSON(e)
+		}
+	}
+	return v
+}
+
+func TestVariantToJSON(t *testing.T) {
+	v := map[string]any{"raw": []byte{0xff, 0}, "bad": "\xff", "big": uint64(math.MaxUint64)}
+	b := make([]byte, SizeVariant(v))
+	MarshalVariant(0, b, v)
+	_, js, err := VariantToJSON([]byte("x"), 0, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]any
+	if err = json.Unmarshal(js[1:], &got); err != nil || got["raw"] != "/wA=" || got["bad"] != "\ufffd" ||
+		!bytes.Contains(js, []byte(`"big":18446744073709551615`)) {
+		t.Fatalf("got %s, %v", js, err)
+	}
+
+	for i := range len(b) {
+		if _, js, err = VariantToJSON([]byte("x"), 0, b[:i]); !errors.Is(err, ErrBufTooSmall) || string(js) != "x" {
+			t.Fatalf("%d bytes: expected ErrBufTooSmall and unchanged dst, got %q, %v", i, js, err)
+		}
+	}
+
+	b = make([]byte, SizeVariant(math.NaN()))
+	MarshalVariant(0, b, math.NaN())
+	if _, _, err = VariantToJSON(nil, 0, b); !errors.Is(err, ErrInvalidData) {
+		t.Fatalf("expected ErrInvalidData, got %v", err)
+	}
+}
+
+func TestJSONToVariantDepth(t *testing.T) {
+	deep := strings.Repeat("[", VariantMaxDepth+1) + strings.Repeat("]", VariantMaxDepth+1)
+	b, err := JSONToVariant([]byte("x"), json.NewDecoder(strings.NewReader(deep)))
+	if !errors.Is(err, ErrVariantDepth) || string(b) != "x" {
+		t.Fatalf("expected ErrVariantDepth and unchanged b, got %q, %v", b, err)
+	}
+	if _, err = JSONToVariant(nil, json.NewDecoder(strings.NewReader(deep[1:len(deep)-1]))); err != nil {
+		t.Fatalf("expected %d levels to convert, got %v", VariantMaxDepth, err)
+	}
+}
@@ -0,0 +1,242 @@
+package bstd
+
+import "github.com/banditmoscow1337/benc/wire"
+
+// The varint encodings of the fixed-width integers store a value in as few bytes as
+// it needs, 7 bits per byte, which is smaller than the fixed encoding for mostly small
+// values. Signed integers are zigzag encoded first, so small negative values stay small.
+// The varint and the fixed encoding of a type are not interchangeable on the wire.
+
+// Returns the new offset 'n' after skipping the marshalled 16-bit integer varint.
+//
+// Possible errors returned:
+//   - ErrBufTooSmall       - 'b' was too small to skip the marshalled 16-bit integer varint.
+//   - ErrOverflow          - the varint overflowed a 16-bit integer.
+//
+// If a error is returned, n (the int returned) equals zero ( 0 ).
+func SkipInt16Varint(n int, b []byte) (int, error) {
+	n, _, err := wire.UnmarshalUvarint(n, b, 16)
+	return n, err
+}
+
+// Returns the bytes needed to marshal the 16-bit integer 'v' as varint.
+func SizeInt16Varint(v int16) int {
+	return wire.SizeUvarint(uint64(EncodeZigZag16(v)))
+}
+
+// Returns the new offset 'n' after marshalling the 16-bit integer 'v' as varint.
+//
+// !- Panics, if 'b' is too small.
+func MarshalInt16Varint(n int, b []byte, v int16) int {
+	return wire.MarshalUvarint(n, b, uint64(EncodeZigZag16(v)))
+}
+
+// Returns the new offset 'n', as well as the 16-bit integer, that got unmarshalled from a varint.
+//
+// Possible errors returned:
+//   - ErrBufTooSmall       - 'b' was too small to unmarshal the 16-bit integer varint.
+//   - ErrOverflow          - the varint overflowed a 16-bit integer.
+//
+// If a error is returned, n (the int returned) equals zero ( 0 ).
+func UnmarshalInt16Varint(n int, b []byte) (int, int16, error) {
+	n, x, err := wire.UnmarshalUvarint(n, b, 16)
+	if err != nil {
+		return 0, 0, err
+	}
+	return n, DecodeZigZag16(uint16(x)), nil
+}
+
+// Returns the new offset 'n' after skipping the marshalled 32-bit integer varint.
+//
+// Possible errors returned:
+//   - ErrBufTooSmall       - 'b' was too small to skip the marshalled 32-bit integer varint.
+//   - ErrOverflow          - the varint overflowed a 32-bit integer.
+//
+// If a error is returned, n (the int returned) equals zero ( 0 ).
+func SkipInt32Varint(n int, b []byte) (int, error) {
+	n, _, err := wire.UnmarshalUvarint(n, b, 32)
+	return n, err
+}
+
+// Returns the bytes needed to marshal the 32-bit integer 'v' as varint.
+func SizeInt32Varint(v int32) int {
+	return wire.SizeUvarint(uint64(EncodeZigZag32(v)))
+}
+
+// Returns the new offset 'n' after marshalling the 32-bit integer 'v' as varint.
+//
+// !- Panics, if 'b' is too small.
+func MarshalInt32Varint(n int, b []byte, v int32) int {
+	return wire.MarshalUvarint(n, b, uint64(EncodeZigZag32(v)))
+}
+
+// Returns the new offset 'n', as well as the 32-bit integer, that got unmarshalled from a varint.
+//
+// Possible errors returned:
+//   - ErrBufTooSmall       - 'b' was too small to unmarshal the 32-bit integer varint.
+//   - ErrOverflow          - the varint overflowed a 32-bit integer.
+//
+// If a error is returned, n (the int returned) equals zero ( 0 ).
+func UnmarshalInt32Varint(n int, b []byte) (int, int32, error) {
+	n, x, err := wire.UnmarshalUvarint(n, b, 32)
+	if err != nil {
+		return 0, 0, err
+	}
+	return n, DecodeZigZag32(uint32(x)), nil
+}
+
+// Returns the new offset 'n' after skipping the marshalled 64-bit integer varint.
+//
+// Possible errors returned:
+//   - ErrBufTooSmall       - 'b' was too small to skip the marshalled 64-bit integer varint.
+//   - ErrOverflow          - the varint overflowed a 64-bit integer.
+//
+// If a error is returned, n (the int returned) equals zero ( 0 ).
+func SkipInt64Varint(n int, b []byte) (int, error) {
+	n, _, err := wire.UnmarshalUvarint(n, b, 64)
+	return n, err
+}
+
+// Returns the bytes needed to marshal the 64-bit integer 'v' as varint.
+func SizeInt64Varint(v int64) int {
+	return wire.SizeUvarint(uint64(EncodeZigZag64(v)))
+}
+
+// Returns the new offset 'n' after marshalling the 64-bit integer 'v' as varint.
+//
+// !- Panics, if 'b' is too small.
+func MarshalInt64Varint(n int, b []byte, v int64) int {
+	return wire.MarshalUvarint(n, b, uint64(EncodeZigZag64(v)))
+}
+
+// Returns the new offset 'n', as well as the 64-bit integer, that got unmarshalled from a varint.
+//
+// Possible errors returned:
+//   - ErrBufTooSmall       - 'b' was too small to unmarshal the 64-bit integer varint.
+//   - ErrOverflow          - the varint overflowed a 64-bit integer.
+//
+// If a error is returned, n (the int returned) equals zero ( 0 ).
+func UnmarshalInt64Varint(n int, b []byte) (int, int64, error) {
+	n, x, err := wire.UnmarshalUvarint(n, b, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	return n, DecodeZigZag64(uint64(x)), nil
+}
+
+// Returns the new offset 'n' after skipping the marshalled 16-bit unsigned integer varint.
+//
+// Possible errors returned:
+//   - ErrBufTooSmall       - 'b' was too small to skip the marshalled 16-bit unsigned integer varint.
+//   - ErrOverflow          - the varint overflowed a 16-bit unsigned integer.
+//
+// If a error is returned, n (the int returned) equals zero ( 0 ).
+func SkipUint16Varint(n int, b []byte) (int, error) {
+	n, _, err := wire.UnmarshalUvarint(n, b, 16)
+	return n, err
+}
+
+// Returns the bytes needed to marshal the 16-bit unsigned integer 'v' as varint.
+func SizeUint16Varint(v uint16) int {
+	return wire.SizeUvarint(uint64(v))
+}
+
+// Returns the new offset 'n' after marshalling the 16-bit unsigned integer 'v' as varint.
+//
+// !- Panics, if 'b' is too small.
+func MarshalUint16Varint(n int, b []byte, v uint16) int {
+	return wire.MarshalUvarint(n, b, uint64(v))
+}
+
+// Returns the new offset 'n', as well as the 16-bit unsigned integer, that got unmarshalled from a varint.
+//
+// Possible errors returned:
+//   - ErrBufTooSmall       - 'b' was too small to unmarshal the 16-bit unsigned integer varint.
+//   - ErrOverflow          - the varint overflowed a 16-bit unsigned integer.
+//
+// If a error is returned, n (the int returned) equals zero ( 0 ).
+func UnmarshalUint16Varint(n int, b []byte) (int, uint16, error) {
+	n, x, err := wire.UnmarshalUvarint(n, b, 16)
+	if err != nil {
+		return 0, 0, err
+	}
+	return n, uint16(x), nil
+}
+
+// Returns the new offset 'n' after skipping the marshalled 32-bit unsigned integer varint.
+//
+// Possible errors returned:
+//   - ErrBufTooSmall       - 'b' was too small to skip the marshalled 32-bit unsigned integer varint.
+//   - ErrOverflow          - the varint overflowed a 32-bit unsigned integer.
+//
+// If a error is returned, n (the int returned) equals zero ( 0 ).
+func SkipUint32Varint(n int, b []byte) (int, error) {
+	n, _, err := wire.UnmarshalUvarint(n, b, 32)
+	return n, err
+}
+
+// Returns the bytes needed to marshal the 32-bit unsigned integer 'v' as varint.
+func SizeUint32Varint(v uint32) int {
+	return wire.SizeUvarint(uint64(v))
+}
+
+// Returns the new offset 'n' after marshalling the 32-bit unsigned integer 'v' as varint.
+//
+// !- Panics, if 'b' is too small.
+func MarshalUint32Varint(n int, b []byte, v uint32) int {
+	return wire.MarshalUvarint(n, b, uint64(v))
+}
+
+// Returns the new offset 'n', as well as the 32-bit unsigned integer, that got unmarshalled from a varint.
+//
+// Possible errors returned:
+//   - ErrBufTooSmall       - 'b' was too small to unmarshal the 32-bit unsigned integer varint.
+//   - ErrOverflow          - the varint overflowed a 32-bit unsigned integer.
+//
+// If a error is returned, n (the int returned) equals zero ( 0 ).
+func UnmarshalUint32Varint(n int, b []byte) (int, uint32, error) {
+	n, x, err := wire.UnmarshalUvarint(n, b, 32)
+	if err != nil {
+		return 0, 0, err
+	}
+	return n, uint32(x), nil
+}
+
+// Returns the new offset 'n' after skipping the marshalled 64-bit unsigned integer varint.
+//
+// Possible errors returned:
+//   - ErrBufTooSmall       - 'b' was too small to skip the marshalled 64-bit unsigned integer varint.
+//   - ErrOverflow          - the varint overflowed a 64-bit unsigned integer.
+//
+// If a error is returned, n (the int returned) equals zero ( 0 ).
+func SkipUint64Varint(n int, b []byte) (int, error) {
+	n, _, err := wire.UnmarshalUvarint(n, b, 64)
+	return n, err
+}
+
+// Returns the bytes needed to marshal the 64-bit unsigned integer 'v' as varint.
+func SizeUint64Varint(v uint64) int {
+	return wire.SizeUvarint(uint64(v))
+}
+
+// Returns the new offset 'n' after marshalling the 64-bit unsigned integer 'v' as varint.
+//
+// !- Panics, if 'b' is too small.
+func MarshalUint64Varint(n int, b []byte, v uint64) int {
+	return wire.MarshalUvarint(n, b, uint64(v))
+}
+
+// Returns the new offset 'n', as well as the 64-bit unsigned integer, that got unmarshalled from a varint.
+//
+// Possible errors returned:
+//   - ErrBufTooSmall       - 'b' was too small to unmarshal the 64-bit unsigned integer varint.
+//   - ErrOverflow          - the varint overflowed a 64-bit unsigned integer.
+//
+// If a error is returned, n (the int returned) equals zero ( 0 ).
+func UnmarshalUint64Varint(n int, b []byte) (int, uint64, error) {
+	n, x, err := wire.UnmarshalUvarint(n, b, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	return n, uint64(x), nil
+}
@@ -0,0 +1,150 @@
+package bstd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// WireFormatVersion is the version of the encoding of benc, which is independent of the version
+// of the module: a release changing the API only keeps it. The rules of bumping it are:
+//
+//   - The encoding of an existing type never changes, a changed encoding is a new type or a
+//     new option, e.g. MarshalTimeWithZone next to MarshalTime, so the version isn't bumped.
+//   - An optional feature, which a peer can't decode unless it knows it, e.g. a new variant tag,
+//     bumps the version and gets a Feature, introduced by that version, see featureVersions.
+//   - Features are never removed, so a peer of a newer version decodes everything of an
+//     older one.
+//
+// Peers of different versions agree on the features both of them know with ExchangeHandshake.
+const WireFormatVersion uint16 = 1
+
+// Feature is a set of optional features of the wire format, which the peers of a connection
+// negotiate, see Handshake.
+type Feature uint32
+
+const (
+	// FeatureVarint is the varint encoding of the integers of the fields with the varint option.
+	FeatureVarint Feature = 1 << iota
+	// FeatureTagged is the tagged mode, messages as self-describing variants, see MarshalVariant.
+	FeatureTagged
+)
+
+// featureVersions are the wire format versions introducing the features.
+var featureVersions = map[Feature]uint16{
+	FeatureVarint: 1,
+	FeatureTagged: 1,
+}
+
+// FeaturesOf returns the features known by the wire format version.
+func FeaturesOf(version uint16) Feature {
+	var features Feature
+	for feature, since := range featureVersions {
+		if since <= version {
+			features |= feature
+		}
+	}
+	return features
+}
+
+var ErrNoHandshake = errors.New("peer didn't send a benc handshake")
+var ErrVersionTooOld = errors.New("wire format version of the peer is too old")
+
+// handshakeMagic starts every handshake, so a peer not speaking benc is detected.
+const handshakeMagic = "benc"
+
+// Handshake is what a peer announces at the start of a connection: the wire format version
+// it speaks and the features it uses, if the other peer knows them as well.
+type Handshake struct {
+	Version  uint16
+	Features Feature
+}
+
+// Returns the handshake of this version of benc, offering those of the 'features' it knows.
+func NewHandshake(features Feature) Handshake {
+	return Handshake{Version: WireFormatVersion, Features: features & FeaturesOf(WireFormatVersion)}
+}
+
+// Negotiate returns the handshake both peers use: the lower version and the features offered
+// by both, which that version knows.
+//
+// Possible errors returned:
+//   - ErrVersionTooOld     - the version of 'peer' is lower than 'minPeerVersion'.
+func (h Handshake) Negotiate(peer Handshake, minPeerVersion uint16) (Handshake, error) {
+	if peer.Version < minPeerVersion {
+		return Handshake{}, fmt.Errorf("%w: %d, expected at least %d", ErrVersionTooOld, peer.Version, minPeerVersion)
+	}
+	version := min(h.Version, peer.Version)
+	return Handshake{Version: version, Features: h.Features & peer.Features & FeaturesOf(version)}, nil
+}
+
+// Returns the bytes needed to marshal a handshake.
+func SizeHandshake() int {
+	return len(handshakeMagic) + SizeUint16() + SizeUint32()
+}
+
+// Returns the new offset 'n' after marshalling the handshake 'h'.
+//
+// !- Panics, if 'b' is too small.
+func MarshalHandshake(n int, b []byte, h Handshake) int {
+	n += copy(b[n:n+len(handshakeMagic)], handshakeMagic)
+	n = MarshalUint16(n, b, h.Version)
+	return MarshalUint32(n, b, uint32(h.Features))
+}
+
+// Returns the new offset 'n', as well as the handshake, that got unmarshalled.
+//
+// Possible errors returned:
+//   - ErrBufTooSmall       - 'b' was too small to unmarshal the handshake.
+//   - ErrNoHandshake       - 'b' doesn't start with the magic of a handshake.
+//
+// If a error is returned, n (the int returned) equals zero ( 0 ).
+func UnmarshalHandshake(n int, b []byte) (int, Handshake, error) {
+	if len(b)-n < SizeHandshake() {
+		return 0, Handshake{}, ErrBufTooSmall
+	}
+	if string(b[n:n+len(handshakeMagic)]) != handshakeMagic {
+		return 0, Handshake{}, ErrNoHandshake
+	}
+	n += len(handshakeMagic)
+	var h Handshake
+	n, h.Version, _ = UnmarshalUint16(n, b)
+	n, features, _ := UnmarshalUint32(n, b)
+	h.Features = Feature(features)
+	return n, h, nil
+}
+
+// ExchangeHandshake writes the handshake 'local' to 'rw', e.g. a net.Conn, reads the one of the
+// peer and returns the handshake both use, see Negotiate. The peer does the same, so both agree
+// on it without another round trip.
+//
+// Possible errors returned:
+//   - io.EOF, io.ErrUnexpectedEOF - the peer closed the connection during the handshake.
+//   - ErrNoHandshake       - the peer didn't send a handshake.
+//   - ErrVersionTooOld     - the version of the peer is lower than 'minPeerVersion'.
+//   - any other error of 'rw'
+func ExchangeHandshake(rw io.ReadWriter, local Handshake, minPeerVersion uint16) (Handshake, error) {
+	b := make([]byte, SizeHandshake())
+	MarshalHandshake(0, b, local)
+	// the write runs next to the read, as the write of a synchronous connection, e.g. of
+	// net.Pipe, blocks until the peer reads, which writes its handshake first as well
+	written := make(chan error, 1)
+	go func() {
+		_, err := rw.Write(b)
+		written <- err
+	}()
+
+	peerBytes := make([]byte, SizeHandshake())
+	_, err := io.ReadFull(rw, peerBytes)
+	if werr := <-written; werr != nil {
+		return Handshake{}, werr
+	}
+	if err != nil {
+		return Handshake{}, err
+	}
+	_, peer, err := UnmarshalHandshake(0, peerBytes)
+	if err != nil {
+		return Handshake{}, err
+	}
+	return local.Negotiate(peer, minPeerVersion)
+}
@@ -0,0 +1,56 @@
+package bstd
+
+import (
+	"errors"
+	"net"
+	"testing"
+)
+
+func TestNegotiate(t *testing.T) {
+	local := Handshake{Version: 3, Features: FeatureVarint | FeatureTagged}
+	peer := Handshake{Version: 1, Features: FeatureTagged}
+	agreed, err := local.Negotiate(peer, 1)
+	if err != nil || agreed != (Handshake{Version: 1, Features: FeatureTagged & FeaturesOf(1)}) {
+		t.Fatalf("got %+v, %v", agreed, err)
+	}
+	if _, err = local.Negotiate(peer, 2); !errors.Is(err, ErrVersionTooOld) {
+		t.Fatalf("expected ErrVersionTooOld, got %v", err)
+	}
+	if h := NewHandshake(^Feature(0)); h.Version != WireFormatVersion || h.Features != FeaturesOf(WireFormatVersion) {
+		t.Fatalf("expected the known features only, got %+v", h)
+	}
+}
+
+func TestExchangeHandshake(t *testing.T) {
+	a, b := net.Pipe()
+	defer a.Close()
+	defer b.Close()
+
+	type result struct {
+		h   Handshake
+		err error
+	}
+	peer := make(chan result)
+	go func() {
+		h, err := ExchangeHandshake(b, Handshake{Version: WireFormatVersion, Features: FeatureVarint}, 1)
+		peer <- result{h, err}
+	}()
+	h, err := ExchangeHandshake(a, NewHandshake(FeatureVarint|FeatureTagged), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := <-peer
+	if p.err != nil || p.h != h || h.Features != FeatureVarint {
+		t.Fatalf("expected both to agree on the varint feature, got %+v and %+v, %v", h, p.h, p.err)
+	}
+
+	buf := make([]byte, SizeHandshake())
+	MarshalHandshake(0, buf, h)
+	buf[0] = 'x'
+	if _, _, err = UnmarshalHandshake(0, buf); err != ErrNoHandshake {
+		t.Fatalf("expected ErrNoHandshake, got %v", err)
+	}
+	if _, _, err = UnmarshalHandshake(0, buf[:3]); err != ErrBufTooSmall {
+		t.Fatalf("expected ErrBufTooSmall, got %v", err)
+	}
+}
@@ -0,0 +1,59 @@
+package bstd
+
+import (
+	"encoding/binary"
+	"io"
+	"sync"
+)
+
+// The To functions marshal a value to an io.Writer instead of a buffer, so a large message
+// can be streamed to a socket or file field by field, see the EncodeTo methods of the
+// generated code, without marshalling it into one contiguous buffer first.
+
+// scratch holds the buffers MarshalTo marshals into.
+var scratch = sync.Pool{New: func() any { return new([]byte) }}
+
+// Returns the bytes written to 'w' after marshalling a value of 'size' bytes with 'marshal',
+// e.g. the Marshal method of a message, into a pooled buffer and writing it to 'w'.
+//
+// Possible errors returned:
+//   - any error of 'w'
+func MarshalTo(w io.Writer, size int, marshal func(n int, b []byte) int) (int, error) {
+	bp := scratch.Get().(*[]byte)
+	defer scratch.Put(bp)
+	if cap(*bp) < size {
+		*bp = make([]byte, size)
+	}
+	b := (*bp)[:size]
+	return w.Write(b[:marshal(0, b)])
+}
+
+// Returns the bytes written to 'w' after marshalling the string 's', its bytes written to 'w'
+// as they are.
+//
+// Possible errors returned:
+//   - any error of 'w'
+func MarshalStringTo(w io.Writer, s string) (int, error) {
+	var prefix [binary.MaxVarintLen64]byte
+	n, err := w.Write(prefix[:MarshalUint(0, prefix[:], uint(len(s)))])
+	if err != nil {
+		return n, err
+	}
+	sn, err := io.WriteString(w, s)
+	return n + sn, err
+}
+
+// Returns the bytes written to 'w' after marshalling the byte slice 'bs', its bytes written
+// to 'w' as they are.
+//
+// Possible errors returned:
+//   - any error of 'w'
+func MarshalBytesTo(w io.Writer, bs []byte) (int, error) {
+	var prefix [binary.MaxVarintLen64]byte
+	n, err := w.Write(prefix[:MarshalUint(0, prefix[:], uint(len(bs)))])
+	if err != nil {
+		return n, err
+	}
+	bn, err := w.Write(bs)
+	return n + bn, err
+}
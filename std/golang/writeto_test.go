@@ -0,0 +1,47 @@
+package bstd
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestMarshalTo(t *testing.T) {
+	s, bs := "hello", []byte{1, 2, 3}
+	slice := []string{"a", "bc"}
+	size := SizeString(s) + SizeBytes(bs) + SizeSlice(slice, SizeString)
+	expected := make([]byte, size)
+	n := MarshalString(0, expected, s)
+	n = MarshalBytes(n, expected, bs)
+	MarshalSlice(n, expected, slice, MarshalString)
+
+	var buf bytes.Buffer
+	written := 0
+	for _, write := range []func() (int, error){
+		func() (int, error) { return MarshalStringTo(&buf, s) },
+		func() (int, error) { return MarshalBytesTo(&buf, bs) },
+		func() (int, error) {
+			return MarshalTo(&buf, SizeSlice(slice, SizeString), func(n int, b []byte) int {
+				return MarshalSlice(n, b, slice, MarshalString)
+			})
+		},
+	} {
+		wn, err := write()
+		if err != nil {
+			t.Fatal(err)
+		}
+		written += wn
+	}
+	if written != size || !bytes.Equal(buf.Bytes(), expected) {
+		t.Fatalf("expected %v, got %v (%d written)", expected, buf.Bytes(), written)
+	}
+
+	errWrite := errors.New("write failed")
+	if _, err := MarshalStringTo(failingWriter{errWrite}, s); !errors.Is(err, errWrite) {
+		t.Fatalf("expected the error of the writer, got %v", err)
+	}
+}
+
+type failingWriter struct{ err error }
+
+func (w failingWriter) Write([]byte) (int, error) { return 0, w.err }
@@ -0,0 +1,50 @@
+package bstd
+
+import "github.com/banditmoscow1337/benc/wire"
+
+// ZigZag functions
+//
+// The zigzag encoding maps signed integers to unsigned ones, so small values of either sign
+// stay small as varint: 0, -1, 1, -2, 2... become 0, 1, 2, 3, 4... The functions shift and XOR
+// the sign bit instead of branching on it, which doesn't mispredict on data of mixed signs.
+// They are the ones of the wire package, exported here next to the varint functions.
+
+// Returns the zigzag encoding of the integer 'v'.
+func EncodeZigZag(v int) uint {
+	return wire.EncodeZigZag(v)
+}
+
+// Returns the integer of the zigzag encoded 'u'.
+func DecodeZigZag(u uint) int {
+	return wire.DecodeZigZag(u)
+}
+
+// Returns the zigzag encoding of the 16-bit integer 'v'.
+func EncodeZigZag16(v int16) uint16 {
+	return wire.EncodeZigZag16(v)
+}
+
+// Returns the 16-bit integer of the zigzag encoded 'u'.
+func DecodeZigZag16(u uint16) int16 {
+	return wire.DecodeZigZag16(u)
+}
+
+// Returns the zigzag encoding of the 32-bit integer 'v'.
+func EncodeZigZag32(v int32) uint32 {
+	return wire.EncodeZigZag32(v)
+}
+
+// Returns the 32-bit integer of the zigzag encoded 'u'.
+func DecodeZigZag32(u uint32) int32 {
+	return wire.DecodeZigZag32(u)
+}
+
+// Returns the zigzag encoding of the 64-bit integer 'v'.
+func EncodeZigZag64(v int64) uint64 {
+	return wire.EncodeZigZag64(v)
+}
+
+// Returns the 64-bit integer of the zigzag encoded 'u'.
+func DecodeZigZag64(u uint64) int64 {
+	return wire.DecodeZigZag64(u)
+}
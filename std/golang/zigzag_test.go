@@ -0,0 +1,102 @@
+package bstd
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// zigzag is the branching encoding the branch free functions are checked against.
+func zigzag(v int64) uint64 {
+	if v < 0 {
+		return ^(uint64(v) << 1)
+	}
+	return uint64(v) << 1
+}
+
+func TestZigZag(t *testing.T) {
+	values := []int64{0, -1, 1, -2, 2, 63, -64, 64, math.MaxInt16, math.MinInt16, math.MaxInt32, math.MinInt32, math.MaxInt64, math.MinInt64}
+	r := rand.New(rand.NewSource(1))
+	for range 1000 {
+		values = append(values, r.Int63()-r.Int63())
+	}
+
+	for _, v := range values {
+		want := zigzag(v)
+		if got := EncodeZigZag64(v); got != want {
+			t.Fatalf("EncodeZigZag64(%d) = %d, expected %d", v, got, want)
+		}
+		if got := DecodeZigZag64(want); got != v {
+			t.Fatalf("DecodeZigZag64(%d) = %d, expected %d", want, got, v)
+		}
+		if got := EncodeZigZag(int(v)); uint64(got) != want {
+			t.Fatalf("EncodeZigZag(%d) = %d, expected %d", v, got, want)
+		}
+		if got := DecodeZigZag(uint(want)); int64(got) != v {
+			t.Fatalf("DecodeZigZag(%d) = %d, expected %d", want, got, v)
+		}
+
+		v32 := int32(v)
+		if got := EncodeZigZag32(v32); uint64(got) != zigzag(int64(v32)) {
+			t.Fatalf("EncodeZigZag32(%d) = %d, expected %d", v32, got, zigzag(int64(v32)))
+		}
+		if got := DecodeZigZag32(EncodeZigZag32(v32)); got != v32 {
+			t.Fatalf("DecodeZigZag32 of %d = %d", v32, got)
+		}
+
+		v16 := int16(v)
+		if got := EncodeZigZag16(v16); uint64(got) != zigzag(int64(v16)) {
+			t.Fatalf("EncodeZigZag16(%d) = %d, expected %d", v16, got, zigzag(int64(v16)))
+		}
+		if got := DecodeZigZag16(EncodeZigZag16(v16)); got != v16 {
+			t.Fatalf("DecodeZigZag16 of %d = %d", v16, got)
+		}
+	}
+}
+
+// mixedSigns returns small integers with random signs, the worst case for a branch on the sign.
+func mixedSigns() []int64 {
+	r := rand.New(rand.NewSource(1))
+	values := make([]int64, 1024)
+	for i := range values {
+		values[i] = r.Int63n(1000) - 500
+	}
+	return values
+}
+
+var zigzagSink uint64
+
+func BenchmarkEncodeZigZag64(b *testing.B) {
+	values := mixedSigns()
+	for i := 0; b.Loop(); i++ {
+		zigzagSink += EncodeZigZag64(values[i%len(values)])
+	}
+}
+
+func BenchmarkDecodeZigZag64(b *testing.B) {
+	values := mixedSigns()
+	encoded := make([]uint64, len(values))
+	for i, v := range values {
+		encoded[i] = EncodeZigZag64(v)
+	}
+	for i := 0; b.Loop(); i++ {
+		zigzagSink += uint64(DecodeZigZag64(encoded[i%len(encoded)]))
+	}
+}
+
+func BenchmarkUnmarshalInt64Varint(b *testing.B) {
+	values := mixedSigns()
+	buf := make([]byte, 0, len(values)*2)
+	for _, v := range values {
+		buf = append(buf, make([]byte, SizeInt64Varint(v))...)
+		MarshalInt64Varint(len(buf)-SizeInt64Varint(v), buf, v)
+	}
+	b.SetBytes(int64(len(buf)))
+	for b.Loop() {
+		for n := 0; n < len(buf); {
+			var v int64
+			n, v, _ = UnmarshalInt64Varint(n, buf)
+			zigzagSink += uint64(v)
+		}
+	}
+}
@@ -6,6 +6,10 @@
 
 const MaxDepth = 2; // Controls the maximum nesting level for recursive structures
 
+// LargeMapCount is the number of entries of the maps of GenerateLargeMap, more than a 16-bit
+// count holds.
+const LargeMapCount = 70000;
+
 // --- Helper: Randomness ---
 
 // Simulating Go's rand.Intn(n)
@@ -64,8 +68,8 @@ function GenerateInt64(_depth) {
     // Use BigInt for 64-bit precision
     const low = BigInt(randUint32());
     const high = BigInt(randUint32());
-    const val = (high << 32n) | low;
-    return randIntn(2) === 0 ? val : -val;
+    // asIntN keeps the value in the int64 range, the sign comes from the top bit
+    return BigInt.asIntN(64, (high << 32n) | low);
 }
 
 function GenerateUint(_depth) {
@@ -133,6 +137,32 @@ function GenerateTime(_depth) {
     return RandomTime();
 }
 
+// Returns the name of a random constant of the enum table, see bstd.enumTable.
+function GenerateEnum(_depth, table) {
+    return table.names[randIntn(table.names.length)];
+}
+
+// GenerateVariant returns a random variant, maps and lists only while depth is left.
+function GenerateVariant(depth) {
+    switch (randIntn(depth > 0 ? 8 : 6)) {
+    case 0:
+        return null;
+    case 1:
+        return GenerateBool(depth);
+    case 2:
+        return GenerateInt64(depth);
+    case 3:
+        return GenerateFloat64(depth);
+    case 4:
+        return GenerateString(depth);
+    case 5:
+        return GenerateBytes(depth);
+    case 6:
+        return GenerateObjectMap(depth - 1, GenerateString, GenerateVariant);
+    }
+    return GenerateSlice(depth - 1, GenerateVariant);
+}
+
 // --- Slice Generators ---
 
 function GenerateSlice(depth, generator) {
@@ -185,6 +215,32 @@ function GenerateMap(depth, keyGen, valGen) {
     return m;
 }
 
+// GenerateObjectMap generates a plain object map, keys are converted to decimal strings.
+function GenerateObjectMap(depth, keyGen, valGen) {
+    const count = RandomCount();
+    const obj = {};
+    for (let i = 0; i < count; i++) {
+        obj[String(keyGen(depth))] = valGen(depth);
+    }
+    return obj;
+}
+
+// GenerateLargeMap generates a map of LargeMapCount entries, or of fewer, if the keys of keyGen
+// repeat too often, e.g. bools. Unlike the few entries of GenerateMap, these exercise counts
+// beyond 16 bits.
+function GenerateLargeMap(depth, keyGen, valGen) {
+    const m = new Map();
+    for (let i = 0; i < 2 * LargeMapCount && m.size < LargeMapCount; i++) {
+        m.set(keyGen(depth), valGen(depth));
+    }
+    return m;
+}
+
+// GenerateLargeObjectMap is GenerateLargeMap for a plain object map, see GenerateObjectMap.
+function GenerateLargeObjectMap(depth, keyGen, valGen) {
+    return Object.fromEntries([...GenerateLargeMap(depth, (d) => String(keyGen(d)), valGen)]);
+}
+
 // --- Random Helpers ---
 
 function RandomString(length) {
@@ -279,6 +335,30 @@ function CompareMap(a, b, valCmp) {
     return null;
 }
 
+function CompareObjectMap(a, b, valCmp) {
+    return CompareMap(new Map(Object.entries(a)), new Map(Object.entries(b)), valCmp);
+}
+
+// CompareVariant compares two variants, nested maps and lists included. A Map equals the
+// plain object it unmarshals as.
+function CompareVariant(a, b) {
+    if (a instanceof Uint8Array && b instanceof Uint8Array) {
+        return CompareBytes(a, b);
+    }
+    if (Array.isArray(a) && Array.isArray(b)) {
+        return CompareSlice(a, b, CompareVariant);
+    }
+    const isMap = (v) => v !== null && typeof v === 'object' && !Array.isArray(v) && !(v instanceof Uint8Array);
+    if (isMap(a) && isMap(b)) {
+        const entries = (v) => v instanceof Map ? v : new Map(Object.entries(v));
+        return CompareMap(entries(a), entries(b), CompareVariant);
+    }
+    if (typeof a !== typeof b) {
+        return new Error(`mismatch: ${a} (${typeof a}) != ${b} (${typeof b})`);
+    }
+    return ComparePrimitive(a, b);
+}
+
 function ComparePointer(a, b, elemCmp) {
     if (a === null && b === null) {
         return null;
@@ -299,6 +379,7 @@ function ComparePointer(a, b, elemCmp) {
 if (typeof module !== 'undefined' && module.exports) {
     module.exports = {
         MaxDepth,
+        LargeMapCount,
         RandomCount,
         GenerateBool,
         GenerateInt, GenerateInt8, GenerateInt16, GenerateInt32, GenerateInt64,
@@ -309,11 +390,14 @@ if (typeof module !== 'undefined' && module.exports) {
         GenerateByte, GenerateBytes,
         GenerateRune,
         GenerateTime,
+        GenerateEnum,
+        GenerateVariant,
         GenerateSlice, GenerateSliceSlice,
         GeneratePointer,
-        GenerateMap,
+        GenerateMap, GenerateObjectMap, GenerateLargeMap, GenerateLargeObjectMap,
         RandomString, RandomBytes, RandomTime, RandomTimePtr,
         BytesEqual,
-        CompareField, ComparePrimitive, CompareBytes, CompareSlice, CompareMap, ComparePointer
+        CompareField, ComparePrimitive, CompareBytes, CompareSlice, CompareMap, CompareObjectMap, ComparePointer,
+        CompareVariant
     };
 }
\ No newline at end of file
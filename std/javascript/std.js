@@ -3,6 +3,9 @@
  * This library provides functions for marshalling and unmarshalling various data types
  * into a compact binary format. It aims to be a direct, feature-complete port of the original.
  *
+ * The primitives (varints, zigzag, little-endian fixed-width values, length prefixes) follow
+ * the Go `wire` package, which documents the format.
+ *
  * @see https://github.com/banditmoscow1337/bstd
  */
 
@@ -17,6 +20,7 @@ class BencError extends Error {
 
 const ErrOverflow = new BencError('varint overflowed a 64-bit integer');
 const ErrBufTooSmall = new BencError('buffer was too small');
+const ErrInvalidData = new BencError('invalid data');
 
 // --- Varint (uint / int) ---
 
@@ -363,6 +367,48 @@ function unmarshalMap(n, b, kUnmarshaler, vUnmarshaler) {
     return [currentN + 4, map];
 }
 
+// --- Object Map ---
+// Maps with 64-bit integer keys can be represented as plain objects with decimal string keys,
+// instead of a Map with BigInt keys. Keys are converted with BigInt() before marshalling,
+// so they stay exact beyond Number.MAX_SAFE_INTEGER. The wire format is the same as for maps.
+
+const skipObjectMap = skipMap;
+
+function sizeObjectMap(obj, kSizer, vSizer) {
+    const entries = Object.entries(obj);
+    let s = 4 + sizeUint(entries.length);
+    for (const [k, v] of entries) {
+        s += kSizer(BigInt(k)) + vSizer(v);
+    }
+    return s;
+}
+
+function marshalObjectMap(n, b, obj, kMarshaler, vMarshaler) {
+    const entries = Object.entries(obj);
+    n = marshalUint(n, b, entries.length);
+    for (const [k, v] of entries) {
+        n = kMarshaler(n, b, BigInt(k));
+        n = vMarshaler(n, b, v);
+    }
+    b.set(sliceTerminator, n);
+    return n + 4;
+}
+
+function unmarshalObjectMap(n, b, kUnmarshaler, vUnmarshaler) {
+    const [newN, sizeBI] = unmarshalUint(n, b);
+    const size = Number(sizeBI);
+    const obj = {};
+    let currentN = newN;
+    for (let i = 0; i < size; i++) {
+        let key, value;
+        [currentN, key] = kUnmarshaler(currentN, b);
+        [currentN, value] = vUnmarshaler(currentN, b);
+        obj[String(key)] = value;
+    }
+    if (b.length - currentN < 4) throw ErrBufTooSmall;
+    return [currentN + 4, obj];
+}
+
 // --- Fixed-size Primitives ---
 
 function createFixedSizeFuncs(byteSize, type) {
@@ -483,12 +529,261 @@ function unmarshalPointer(n, b, unmarshaler) {
     return unmarshaler(newN, b);
 }
 
+// --- Variant ---
+// A variant is a JSON-like value of a field typed `any`, marshalled as a tag byte followed by
+// the value, see the variants of the Go bstd. The JavaScript values of the tags are
+//   null (and undefined), booleans, bigints (Int, or Uint beyond the int64 range), numbers
+//   (Float), strings, Uint8Arrays, arrays and plain objects or Maps with string keys (Map).
+// Integers must be bigints, a number is always a float. Maps unmarshal as plain objects.
+
+const VariantNil = 0;
+const VariantFalse = 1;
+const VariantTrue = 2;
+const VariantInt = 3;
+const VariantFloat = 4;
+const VariantString = 5;
+const VariantBytes = 6;
+const VariantMap = 7;
+const VariantList = 8;
+const VariantUint = 9;
+
+// VariantMaxDepth is the number of nested maps and lists a variant may have.
+const VariantMaxDepth = 64;
+
+const ErrVariantTag = new BencError('unknown variant tag');
+const ErrVariantDepth = new BencError('variant nested too deeply');
+
+const int64Max = (1n << 63n) - 1n;
+const uint64Max = (1n << 64n) - 1n;
+
+// variantEntries returns the entries of a variant map, a Map or a plain object.
+function variantEntries(v) {
+    return v instanceof Map ? [...v] : Object.entries(v);
+}
+
+/**
+ * Returns the bytes needed to marshal the variant.
+ * @param {*} v The variant.
+ * @returns {number} The number of bytes.
+ */
+function sizeVariant(v) {
+    if (v === null || v === undefined || typeof v === 'boolean') {
+        return 1;
+    }
+    switch (typeof v) {
+    case 'bigint':
+        return 1 + (v > int64Max ? sizeUint(v) : sizeInt(v));
+    case 'number':
+        return 1 + 8;
+    case 'string':
+        return 1 + sizeString(v);
+    }
+    if (v instanceof Uint8Array) {
+        return 1 + sizeBytes(v);
+    }
+    if (Array.isArray(v)) {
+        let s = 1 + sizeUint(v.length);
+        for (const e of v) {
+            s += sizeVariant(e);
+        }
+        return s;
+    }
+    if (typeof v === 'object') {
+        const entries = variantEntries(v);
+        let s = 1 + sizeUint(entries.length);
+        for (const [k, e] of entries) {
+            s += sizeString(k) + sizeVariant(e);
+        }
+        return s;
+    }
+    throw new BencError(`${typeof v} is no variant type`);
+}
+
+/**
+ * Marshals the variant into a buffer.
+ * @param {number} n The offset in the buffer.
+ * @param {Uint8Array} b The buffer.
+ * @param {*} v The variant.
+ * @returns {number} The new offset.
+ */
+function marshalVariant(n, b, v) {
+    if (v === null || v === undefined) {
+        return marshalByte(n, b, VariantNil);
+    }
+    switch (typeof v) {
+    case 'boolean':
+        return marshalByte(n, b, v ? VariantTrue : VariantFalse);
+    case 'bigint':
+        if (v < -int64Max - 1n || v > uint64Max) {
+            throw new BencError(`${v} exceeds the 64-bit integers`);
+        }
+        if (v > int64Max) {
+            return marshalUint(marshalByte(n, b, VariantUint), b, v);
+        }
+        return marshalInt(marshalByte(n, b, VariantInt), b, v);
+    case 'number':
+        return marshalFloat64(marshalByte(n, b, VariantFloat), b, v);
+    case 'string':
+        return marshalString(marshalByte(n, b, VariantString), b, v);
+    }
+    if (v instanceof Uint8Array) {
+        return marshalBytes(marshalByte(n, b, VariantBytes), b, v);
+    }
+    if (Array.isArray(v)) {
+        n = marshalUint(marshalByte(n, b, VariantList), b, v.length);
+        for (const e of v) {
+            n = marshalVariant(n, b, e);
+        }
+        return n;
+    }
+    if (typeof v === 'object') {
+        const entries = variantEntries(v);
+        n = marshalUint(marshalByte(n, b, VariantMap), b, entries.length);
+        for (const [k, e] of entries) {
+            n = marshalString(n, b, k);
+            n = marshalVariant(n, b, e);
+        }
+        return n;
+    }
+    throw new BencError(`${typeof v} is no variant type`);
+}
+
+/**
+ * Unmarshals a variant from a buffer. Byte slices are copied out of the buffer.
+ * @param {number} n The offset in the buffer.
+ * @param {Uint8Array} b The buffer.
+ * @returns {[number, *]} The new offset and the variant.
+ */
+function unmarshalVariant(n, b, depth = 0) {
+    let tag;
+    [n, tag] = unmarshalByte(n, b);
+    switch (tag) {
+    case VariantNil:
+        return [n, null];
+    case VariantFalse:
+    case VariantTrue:
+        return [n, tag === VariantTrue];
+    case VariantInt:
+        return unmarshalInt(n, b);
+    case VariantUint:
+        return unmarshalUint(n, b);
+    case VariantFloat:
+        return unmarshalFloat64(n, b);
+    case VariantString:
+        return unmarshalString(n, b);
+    case VariantBytes:
+        return unmarshalBytesCopied(n, b);
+    case VariantMap:
+    case VariantList: {
+        if (depth === VariantMaxDepth) {
+            throw ErrVariantDepth;
+        }
+        let count;
+        [n, count] = unmarshalUint(n, b);
+        // every entry takes at least a byte, which bounds the allocation by the input
+        if (count > BigInt(b.length - n)) {
+            throw ErrBufTooSmall;
+        }
+        if (tag === VariantList) {
+            const l = new Array(Number(count));
+            for (let i = 0; i < l.length; i++) {
+                [n, l[i]] = unmarshalVariant(n, b, depth + 1);
+            }
+            return [n, l];
+        }
+        const m = {};
+        for (let i = 0n; i < count; i++) {
+            let k;
+            [n, k] = unmarshalString(n, b);
+            [n, m[k]] = unmarshalVariant(n, b, depth + 1);
+        }
+        return [n, m];
+    }
+    }
+    throw ErrVariantTag;
+}
+
+/**
+ * Skips a variant in the buffer.
+ * @param {number} n The offset in the buffer.
+ * @param {Uint8Array} b The buffer.
+ * @returns {number} The new offset.
+ */
+function skipVariant(n, b, depth = 0) {
+    let tag;
+    [n, tag] = unmarshalByte(n, b);
+    switch (tag) {
+    case VariantNil:
+    case VariantFalse:
+    case VariantTrue:
+        return n;
+    case VariantInt:
+    case VariantUint:
+        return skipVarint(n, b);
+    case VariantFloat:
+        return skipFloat64(n, b);
+    case VariantString:
+    case VariantBytes:
+        return skipBytes(n, b);
+    case VariantMap:
+    case VariantList: {
+        if (depth === VariantMaxDepth) {
+            throw ErrVariantDepth;
+        }
+        let count;
+        [n, count] = unmarshalUint(n, b);
+        for (let i = 0n; i < count; i++) {
+            if (tag === VariantMap) {
+                n = skipString(n, b);
+            }
+            n = skipVariant(n, b, depth + 1);
+        }
+        return n;
+    }
+    }
+    throw ErrVariantTag;
+}
+
+// --- Enum ---
+
+/**
+ * Creates the table of an enum in the interned string mode: its constants are their names,
+ * which are marshalled as their ordinal, the index of the name, as varint.
+ * A marshalled constant is skipped with skipVarint.
+ * @param {string[]} names The names of the constants, indexed by their ordinal.
+ * @returns {{names: string[], ordinal: function(string): number, size: function(string): number,
+ *   marshal: function(number, Uint8Array, string): number, unmarshal: function(number, Uint8Array): [number, string]}}
+ */
+function enumTable(names) {
+    const ordinals = new Map(names.map((name, i) => [name, i]));
+    const ordinal = (name) => {
+        const i = ordinals.get(name);
+        if (i === undefined) {
+            throw new BencError(`${name} is no constant of the enum`);
+        }
+        return i;
+    };
+    return Object.freeze({
+        names: Object.freeze([...names]),
+        ordinal,
+        size: (name) => sizeUint(ordinal(name)),
+        marshal: (n, b, name) => marshalUint(n, b, ordinal(name)),
+        unmarshal: (n, b) => {
+            const [newN, i] = unmarshalUint(n, b);
+            if (i >= BigInt(names.length)) {
+                throw ErrInvalidData;
+            }
+            return [newN, names[Number(i)]];
+        },
+    });
+}
+
 // --- Exports ---
 
 // For CommonJS environments
 if (typeof module !== 'undefined' && module.exports) {
   module.exports = {
-    BencError, ErrOverflow, ErrBufTooSmall,
+    BencError, ErrOverflow, ErrBufTooSmall, ErrInvalidData,
     // Varint
     sizeUint, marshalUint, unmarshalUint, skipVarint,
     sizeInt, marshalInt, unmarshalInt,
@@ -502,6 +797,7 @@ if (typeof module !== 'undefined' && module.exports) {
     skipSlice, sizeSlice, sizeFixedSlice, marshalSlice, unmarshalSlice,
     // Map
     skipMap, sizeMap, marshalMap, unmarshalMap,
+    skipObjectMap, sizeObjectMap, marshalObjectMap, unmarshalObjectMap,
     // Fixed-size
     skipInt8, sizeInt8, marshalInt8, unmarshalInt8,
     skipInt16, sizeInt16, marshalInt16, unmarshalInt16,
@@ -517,7 +813,13 @@ if (typeof module !== 'undefined' && module.exports) {
     // Time
     skipTime, sizeTime, marshalTime, unmarshalTime,
     // Pointer
-    skipPointer, sizePointer, marshalPointer, unmarshalPointer
+    skipPointer, sizePointer, marshalPointer, unmarshalPointer,
+    // Variant
+    VariantNil, VariantFalse, VariantTrue, VariantInt, VariantFloat, VariantString, VariantBytes,
+    VariantMap, VariantList, VariantUint, VariantMaxDepth, ErrVariantTag, ErrVariantDepth,
+    sizeVariant, marshalVariant, unmarshalVariant, skipVariant,
+    // Enum
+    enumTable
   };
 }
 
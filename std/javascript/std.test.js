@@ -11,7 +11,7 @@
 const bstd = require('./std.js');
 const {
     // Errors
-    BencError, ErrOverflow, ErrBufTooSmall,
+    BencError, ErrOverflow, ErrBufTooSmall, ErrInvalidData,
     // Varint
     sizeUint, marshalUint, unmarshalUint, skipVarint,
     sizeInt, marshalInt, unmarshalInt,
@@ -25,6 +25,7 @@ const {
     skipSlice, sizeSlice, sizeFixedSlice, marshalSlice, unmarshalSlice,
     // Map
     skipMap, sizeMap, marshalMap, unmarshalMap,
+    skipObjectMap, sizeObjectMap, marshalObjectMap, unmarshalObjectMap,
     // Fixed-size
     skipInt8, sizeInt8, marshalInt8, unmarshalInt8,
     skipInt16, sizeInt16, marshalInt16, unmarshalInt16,
@@ -40,7 +41,9 @@ const {
     // Time
     skipTime, sizeTime, marshalTime, unmarshalTime,
     // Pointer
-    skipPointer, sizePointer, marshalPointer, unmarshalPointer
+    skipPointer, sizePointer, marshalPointer, unmarshalPointer,
+    // Enum
+    enumTable
 } = bstd;
 
 
@@ -251,6 +254,45 @@ describe('Complex Types', () => {
         expect(finalN).toBe(s);
         expect(retMap).toEqual(m);
     });
+
+    test('should keep 64-bit map keys exact as BigInt', () => {
+        const m = new Map([
+            [9007199254740993n, "above MAX_SAFE_INTEGER"],
+            [-9223372036854775808n, "min int64"],
+        ]);
+        const s = sizeMap(m, sizeInt, sizeString);
+        const buf = new Uint8Array(s);
+        marshalMap(0, buf, m, marshalInt, marshalString);
+
+        const [finalN, retMap] = unmarshalMap(0, buf, unmarshalInt, unmarshalString);
+        expect(finalN).toBe(s);
+        expect(retMap).toEqual(m);
+        expect(retMap.get(9007199254740993n)).toBe("above MAX_SAFE_INTEGER");
+    });
+
+    test('should handle object maps with 64-bit keys as strings', () => {
+        const obj = {
+            "18446744073709551615": "max uint64",
+            "9007199254740993": "above MAX_SAFE_INTEGER",
+        };
+        const s = sizeObjectMap(obj, sizeUint64, sizeString);
+        const buf = new Uint8Array(s);
+        marshalObjectMap(0, buf, obj, marshalUint64, marshalString);
+
+        const finalSkipN = skipObjectMap(0, buf, skipUint64, skipString);
+        expect(finalSkipN).toBe(s);
+
+        const [finalN, retObj] = unmarshalObjectMap(0, buf, unmarshalUint64, unmarshalString);
+        expect(finalN).toBe(s);
+        expect(retObj).toEqual(obj);
+
+        // Same wire format as a Map with BigInt keys
+        const m = new Map([[18446744073709551615n, "max uint64"], [9007199254740993n, "above MAX_SAFE_INTEGER"]]);
+        const mapBuf = new Uint8Array(sizeMap(m, sizeUint64, sizeString));
+        marshalMap(0, mapBuf, m, marshalUint64, marshalString);
+        const [, fromMap] = unmarshalObjectMap(0, mapBuf, unmarshalUint64, unmarshalString);
+        expect(fromMap).toEqual(obj);
+    });
 });
 
 
@@ -368,3 +410,46 @@ describe('Time and Pointer Types', () => {
     });
 });
 
+describe('Variant Types', () => {
+    test('should marshal variants like the Go bstd', () => {
+        const v = [null, true, -3n, 1n << 63n, 1.5, 'hi', new Uint8Array([7]), new Map([['k', 'v']])];
+        // the bytes of bstd.MarshalVariant for the same values in Go
+        const want = new Uint8Array([8, 8, 0, 2, 3, 5, 9, 128, 128, 128, 128, 128, 128, 128, 128, 128, 1,
+            4, 0, 0, 0, 0, 0, 0, 248, 63, 5, 2, 104, 105, 6, 1, 7, 7, 1, 1, 107, 5, 1, 118]);
+        const s = bstd.sizeVariant(v);
+        expect(s).toBe(want.length);
+        const buf = new Uint8Array(s);
+        expect(bstd.marshalVariant(0, buf, v)).toBe(s);
+        expect(buf).toEqual(want);
+
+        const [n, got] = bstd.unmarshalVariant(0, buf);
+        expect(n).toBe(s);
+        expect(got).toEqual([null, true, -3n, 1n << 63n, 1.5, 'hi', new Uint8Array([7]), { k: 'v' }]);
+        expect(bstd.skipVariant(0, buf)).toBe(s);
+
+        expect(() => bstd.unmarshalVariant(0, buf.subarray(0, s - 1))).toThrow(ErrBufTooSmall);
+        expect(() => bstd.unmarshalVariant(0, new Uint8Array([10]))).toThrow(bstd.ErrVariantTag);
+        expect(() => bstd.sizeVariant(() => null)).toThrow(BencError);
+    });
+});
+
+describe('Enum Types', () => {
+    test('should marshal the names of an enum as their ordinals', () => {
+        const Color = enumTable(['Red', 'Green', 'Blue']);
+        const s = Color.size('Green') + Color.size('Blue');
+        const buf = new Uint8Array(s);
+        let n = Color.marshal(0, buf, 'Green');
+        n = Color.marshal(n, buf, 'Blue');
+        expect(n).toBe(2);
+        expect(buf).toEqual(new Uint8Array([1, 2]));
+
+        let val;
+        [n, val] = Color.unmarshal(0, buf);
+        expect(n).toBe(1);
+        expect(val).toBe('Green');
+        expect(skipVarint(n, buf)).toBe(s);
+
+        expect(() => Color.unmarshal(0, new Uint8Array([3]))).toThrow(ErrInvalidData);
+        expect(() => Color.size('Purple')).toThrow(BencError);
+    });
+});
@@ -0,0 +1,44 @@
+// Package std embeds the runtimes the generated code of the languages other than Go
+// includes, so the generator can install them next to the code it generated, whichever
+// directory it runs in, see `benc selfinstall`.
+package std
+
+import (
+	"embed"
+	"path"
+)
+
+//go:embed javascript/std.js javascript/gen.js c/benc.h c/gen.h c++/std.hpp c++/gen.h
+var files embed.FS
+
+// runtimes maps the languages to the files of their runtimes in files. The generated
+// code includes them by their base names, from the directory of the generated code.
+var runtimes = map[string][]string{
+	"js":  {"javascript/std.js", "javascript/gen.js"},
+	"c":   {"c/benc.h", "c/gen.h"},
+	"cpp": {"c++/std.hpp", "c++/gen.h"},
+}
+
+// RuntimeFile is a file of a runtime, named as the generated code includes it.
+type RuntimeFile struct {
+	Name    string
+	Content []byte
+}
+
+// Runtime returns the files of the runtime of the language lang, false if there are none,
+// like for Go, whose generated code imports bstd as a module.
+func Runtime(lang string) ([]RuntimeFile, bool) {
+	paths, ok := runtimes[lang]
+	if !ok {
+		return nil, false
+	}
+	rf := make([]RuntimeFile, 0, len(paths))
+	for _, p := range paths {
+		b, err := files.ReadFile(p)
+		if err != nil {
+			panic(err) // embedded above
+		}
+		rf = append(rf, RuntimeFile{Name: path.Base(p), Content: b})
+	}
+	return rf, true
+}
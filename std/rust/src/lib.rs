@@ -1,5 +1,7 @@
 //! This module is a Rust implementation of the Go `bstd` package, providing tools
-//! for binary serialization and deserialization.
+//! for binary serialization and deserialization. The primitives (varints, zigzag,
+//! little-endian fixed-width values, length prefixes) follow the Go `wire` package,
+//! which documents the format.
 //!
 //! The API is designed to be safe, idiomatic, and performant, leveraging Rust's
 //! strengths to improve upon the original Go implementation.
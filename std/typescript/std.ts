@@ -1,6 +1,8 @@
 /**
  * This module is a TypeScript implementation of the Go `bstd` package,
- * providing tools for binary serialization and deserialization.
+ * providing tools for binary serialization and deserialization. The primitives
+ * (varints, zigzag, little-endian fixed-width values, length prefixes) follow the
+ * Go `wire` package, which documents the format.
  *
  * ## API Design
  *
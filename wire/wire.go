@@ -0,0 +1,404 @@
+// Package wire holds the primitive operations of the benc wire format: varints, zigzag,
+// fixed-width little-endian integers and floats, bools and length-prefixed bytes. Every
+// other encoding of benc is built from them, by bstd, by the generated code and by the
+// runtimes of the other languages, which implement exactly these operations.
+//
+// The API is frozen: functions are only ever added, never changed or removed, and the
+// bytes they write never change. The package has no dependencies besides the standard
+// library, so it can be vendored or ported on its own.
+//
+// Like bstd, the functions take the offset 'n' into the buffer 'b' and return the offset
+// after the value. Marshal functions panic if 'b' is too small, size it with the Size
+// functions first. Unmarshal and Skip functions return an error instead, and n equal to
+// zero ( 0 ) with it.
+//
+// # Format
+//
+//   - A varint stores an unsigned integer 7 bits per byte, least significant group first,
+//     the high bit of a byte set if another byte follows. It has at most 10 bytes.
+//   - Signed varints are zigzag encoded first: 0, -1, 1, -2, 2... become 0, 1, 2, 3, 4...
+//   - Fixed-width integers are little-endian, floats are their IEEE 754 bits as such.
+//   - A bool is one byte, 1 for true and 0 for false.
+//   - Bytes and strings are a varint length followed by that many bytes.
+package wire
+
+import (
+	"errors"
+	"math"
+	"math/bits"
+)
+
+// ErrBufTooSmall is returned if the buffer ends before the value.
+var ErrBufTooSmall = errors.New("buffer too small")
+
+// ErrOverflow is returned if a varint doesn't fit into the integer it is unmarshalled into.
+var ErrOverflow = errors.New("varint overflows a 64-bit integer")
+
+// MaxVarintLen is the length of the longest varint, the one of a 64-bit integer.
+const MaxVarintLen = 10
+
+// Varint functions
+
+// Returns the bytes needed to marshal 'v' as varint.
+func SizeUvarint(v uint64) int {
+	i := 1
+	for v >= 0x80 {
+		v >>= 7
+		i++
+	}
+	return i
+}
+
+// Returns the new offset 'n' after marshalling 'v' as varint.
+//
+// !- Panics, if 'b' is too small.
+func MarshalUvarint(n int, b []byte, v uint64) int {
+	for v >= 0x80 {
+		b[n] = byte(v) | 0x80
+		v >>= 7
+		n++
+	}
+	b[n] = byte(v)
+	return n + 1
+}
+
+// Returns the new offset 'n', as well as the varint, that got unmarshalled. The varint
+// must fit into an unsigned integer of the given bits, e.g. 32 for a uint32.
+//
+// Possible errors returned:
+//   - ErrOverflow          - the varint overflowed an unsigned integer of the given bits.
+//   - ErrBufTooSmall       - 'b' was too small to unmarshal the varint.
+//
+// If a error is returned, n (the int returned) equals zero ( 0 ).
+func UnmarshalUvarint(n int, b []byte, bits uint) (int, uint64, error) {
+	var x uint64
+	var s uint
+	for i, c := range b[n:] {
+		if i == MaxVarintLen {
+			return 0, 0, ErrOverflow
+		}
+		if c < 0x80 {
+			if i == MaxVarintLen-1 && c > 1 {
+				return 0, 0, ErrOverflow
+			}
+			x |= uint64(c) << s
+			if bits < 64 && x>>bits != 0 {
+				return 0, 0, ErrOverflow
+			}
+			return n + i + 1, x, nil
+		}
+		x |= uint64(c&0x7f) << s
+		s += 7
+	}
+	return 0, 0, ErrBufTooSmall
+}
+
+// Returns the new offset 'n' after skipping the varint, which must fit into an unsigned
+// integer of the given bits.
+//
+// Possible errors returned:
+//   - ErrOverflow          - the varint overflowed an unsigned integer of the given bits.
+//   - ErrBufTooSmall       - 'b' was too small to skip the varint.
+//
+// If a error is returned, n (the int returned) equals zero ( 0 ).
+func SkipUvarint(n int, b []byte, bits uint) (int, error) {
+	n, _, err := UnmarshalUvarint(n, b, bits)
+	return n, err
+}
+
+// ZigZag functions
+//
+// The functions shift and XOR the sign bit instead of branching on it, which doesn't
+// mispredict on data of mixed signs.
+
+// Returns the zigzag encoding of the integer 'v'.
+func EncodeZigZag(v int) uint {
+	return uint(v<<1) ^ uint(v>>(bits.UintSize-1))
+}
+
+// Returns the integer of the zigzag encoded 'u'.
+func DecodeZigZag(u uint) int {
+	return int(u>>1) ^ -int(u&1)
+}
+
+// Returns the zigzag encoding of the 16-bit integer 'v'.
+func EncodeZigZag16(v int16) uint16 {
+	return uint16(v<<1) ^ uint16(v>>15)
+}
+
+// Returns the 16-bit integer of the zigzag encoded 'u'.
+func DecodeZigZag16(u uint16) int16 {
+	return int16(u>>1) ^ -int16(u&1)
+}
+
+// Returns the zigzag encoding of the 32-bit integer 'v'.
+func EncodeZigZag32(v int32) uint32 {
+	return uint32(v<<1) ^ uint32(v>>31)
+}
+
+// Returns the 32-bit integer of the zigzag encoded 'u'.
+func DecodeZigZag32(u uint32) int32 {
+	return int32(u>>1) ^ -int32(u&1)
+}
+
+// Returns the zigzag encoding of the 64-bit integer 'v'.
+func EncodeZigZag64(v int64) uint64 {
+	return uint64(v<<1) ^ uint64(v>>63)
+}
+
+// Returns the 64-bit integer of the zigzag encoded 'u'.
+func DecodeZigZag64(u uint64) int64 {
+	return int64(u>>1) ^ -int64(u&1)
+}
+
+// Fixed-width functions
+
+// Returns the new offset 'n' after skipping 'size' bytes, the ones of a fixed-width value.
+//
+// Possible errors returned:
+//   - ErrBufTooSmall       - 'b' was too small to skip the bytes.
+//
+// If a error is returned, n (the int returned) equals zero ( 0 ).
+func Skip(n int, b []byte, size int) (int, error) {
+	if len(b)-n < size {
+		return 0, ErrBufTooSmall
+	}
+	return n + size, nil
+}
+
+// Returns the new offset 'n' after marshalling the byte.
+//
+// !- Panics, if 'b' is too small.
+func MarshalByte(n int, b []byte, v byte) int {
+	b[n] = v
+	return n + 1
+}
+
+// Returns the new offset 'n', as well as the byte, that got unmarshalled.
+//
+// Possible errors returned:
+//   - ErrBufTooSmall       - 'b' was too small to unmarshal the byte.
+//
+// If a error is returned, n (the int returned) equals zero ( 0 ).
+func UnmarshalByte(n int, b []byte) (int, byte, error) {
+	if len(b)-n < 1 {
+		return 0, 0, ErrBufTooSmall
+	}
+	return n + 1, b[n], nil
+}
+
+// Returns the new offset 'n' after marshalling the bool.
+//
+// !- Panics, if 'b' is too small.
+func MarshalBool(n int, b []byte, v bool) int {
+	var i byte
+	if v {
+		i = 1
+	}
+	b[n] = i
+	return n + 1
+}
+
+// Returns the new offset 'n', as well as the bool, that got unmarshalled. Any byte
+// other than 1 is false.
+//
+// Possible errors returned:
+//   - ErrBufTooSmall       - 'b' was too small to unmarshal the bool.
+//
+// If a error is returned, n (the int returned) equals zero ( 0 ).
+func UnmarshalBool(n int, b []byte) (int, bool, error) {
+	if len(b)-n < 1 {
+		return 0, false, ErrBufTooSmall
+	}
+	return n + 1, b[n] == 1, nil
+}
+
+// Returns the new offset 'n' after marshalling the 16-bit unsigned integer.
+//
+// !- Panics, if 'b' is too small.
+func MarshalUint16(n int, b []byte, v uint16) int {
+	u := b[n : n+2]
+	_ = u[1]
+	u[0] = byte(v)
+	u[1] = byte(v >> 8)
+	return n + 2
+}
+
+// Returns the new offset 'n', as well as the 16-bit unsigned integer, that got unmarshalled.
+//
+// Possible errors returned:
+//   - ErrBufTooSmall       - 'b' was too small to unmarshal the 16-bit unsigned integer.
+//
+// If a error is returned, n (the int returned) equals zero ( 0 ).
+func UnmarshalUint16(n int, b []byte) (int, uint16, error) {
+	if len(b)-n < 2 {
+		return 0, 0, ErrBufTooSmall
+	}
+	u := b[n : n+2]
+	_ = u[1]
+	return n + 2, uint16(u[0]) | uint16(u[1])<<8, nil
+}
+
+// Returns the new offset 'n' after marshalling the 32-bit unsigned integer.
+//
+// !- Panics, if 'b' is too small.
+func MarshalUint32(n int, b []byte, v uint32) int {
+	u := b[n : n+4]
+	_ = u[3]
+	u[0] = byte(v)
+	u[1] = byte(v >> 8)
+	u[2] = byte(v >> 16)
+	u[3] = byte(v >> 24)
+	return n + 4
+}
+
+// Returns the new offset 'n', as well as the 32-bit unsigned integer, that got unmarshalled.
+//
+// Possible errors returned:
+//   - ErrBufTooSmall       - 'b' was too small to unmarshal the 32-bit unsigned integer.
+//
+// If a error is returned, n (the int returned) equals zero ( 0 ).
+func UnmarshalUint32(n int, b []byte) (int, uint32, error) {
+	if len(b)-n < 4 {
+		return 0, 0, ErrBufTooSmall
+	}
+	u := b[n : n+4]
+	_ = u[3]
+	return n + 4, uint32(u[0]) | uint32(u[1])<<8 | uint32(u[2])<<16 | uint32(u[3])<<24, nil
+}
+
+// Returns the new offset 'n' after marshalling the 64-bit unsigned integer.
+//
+// !- Panics, if 'b' is too small.
+func MarshalUint64(n int, b []byte, v uint64) int {
+	u := b[n : n+8]
+	_ = u[7]
+	u[0] = byte(v)
+	u[1] = byte(v >> 8)
+	u[2] = byte(v >> 16)
+	u[3] = byte(v >> 24)
+	u[4] = byte(v >> 32)
+	u[5] = byte(v >> 40)
+	u[6] = byte(v >> 48)
+	u[7] = byte(v >> 56)
+	return n + 8
+}
+
+// Returns the new offset 'n', as well as the 64-bit unsigned integer, that got unmarshalled.
+//
+// Possible errors returned:
+//   - ErrBufTooSmall       - 'b' was too small to unmarshal the 64-bit unsigned integer.
+//
+// If a error is returned, n (the int returned) equals zero ( 0 ).
+func UnmarshalUint64(n int, b []byte) (int, uint64, error) {
+	if len(b)-n < 8 {
+		return 0, 0, ErrBufTooSmall
+	}
+	u := b[n : n+8]
+	_ = u[7]
+	v := uint64(u[0]) | uint64(u[1])<<8 | uint64(u[2])<<16 | uint64(u[3])<<24 |
+		uint64(u[4])<<32 | uint64(u[5])<<40 | uint64(u[6])<<48 | uint64(u[7])<<56
+	return n + 8, v, nil
+}
+
+// Returns the new offset 'n' after marshalling the 32-bit float.
+//
+// !- Panics, if 'b' is too small.
+func MarshalFloat32(n int, b []byte, v float32) int {
+	return MarshalUint32(n, b, math.Float32bits(v))
+}
+
+// Returns the new offset 'n', as well as the 32-bit float, that got unmarshalled.
+//
+// Possible errors returned:
+//   - ErrBufTooSmall       - 'b' was too small to unmarshal the 32-bit float.
+//
+// If a error is returned, n (the int returned) equals zero ( 0 ).
+func UnmarshalFloat32(n int, b []byte) (int, float32, error) {
+	n, v, err := UnmarshalUint32(n, b)
+	return n, math.Float32frombits(v), err
+}
+
+// Returns the new offset 'n' after marshalling the 64-bit float.
+//
+// !- Panics, if 'b' is too small.
+func MarshalFloat64(n int, b []byte, v float64) int {
+	return MarshalUint64(n, b, math.Float64bits(v))
+}
+
+// Returns the new offset 'n', as well as the 64-bit float, that got unmarshalled.
+//
+// Possible errors returned:
+//   - ErrBufTooSmall       - 'b' was too small to unmarshal the 64-bit float.
+//
+// If a error is returned, n (the int returned) equals zero ( 0 ).
+func UnmarshalFloat64(n int, b []byte) (int, float64, error) {
+	n, v, err := UnmarshalUint64(n, b)
+	return n, math.Float64frombits(v), err
+}
+
+// Length-prefixed functions
+
+// Returns the bytes needed to marshal the byte slice.
+func SizeBytes(bs []byte) int {
+	return SizeUvarint(uint64(len(bs))) + len(bs)
+}
+
+// Returns the new offset 'n' after marshalling the byte slice.
+//
+// !- Panics, if 'b' is too small.
+func MarshalBytes(n int, b []byte, bs []byte) int {
+	n = MarshalUvarint(n, b, uint64(len(bs)))
+	return n + copy(b[n:], bs)
+}
+
+// Returns the bytes needed to marshal the string.
+func SizeString(str string) int {
+	return SizeUvarint(uint64(len(str))) + len(str)
+}
+
+// Returns the new offset 'n' after marshalling the string.
+//
+// !- Panics, if 'b' is too small.
+func MarshalString(n int, b []byte, str string) int {
+	n = MarshalUvarint(n, b, uint64(len(str)))
+	return n + copy(b[n:], str)
+}
+
+// Returns the new offset 'n', as well as the marshalled byte slice or string. The bytes
+// are a slice of 'b', copy them to keep them beyond 'b'.
+//
+// Possible errors returned:
+//   - ErrOverflow          - the length overflowed an int.
+//   - ErrBufTooSmall       - 'b' was too small to unmarshal the bytes.
+//
+// If a error is returned, n (the int returned) equals zero ( 0 ).
+func UnmarshalBytes(n int, b []byte) (int, []byte, error) {
+	n, l, err := UnmarshalUvarint(n, b, bits.UintSize)
+	if err != nil {
+		return 0, nil, err
+	}
+	if uint64(len(b)-n) < l {
+		return 0, nil, ErrBufTooSmall
+	}
+	end := n + int(l)
+	return end, b[n:end], nil
+}
+
+// Returns the new offset 'n' after skipping the marshalled byte slice or string.
+//
+// Possible errors returned:
+//   - ErrOverflow          - the length overflowed an int.
+//   - ErrBufTooSmall       - 'b' was too small to skip the bytes.
+//
+// If a error is returned, n (the int returned) equals zero ( 0 ).
+func SkipBytes(n int, b []byte) (int, error) {
+	n, l, err := UnmarshalUvarint(n, b, bits.UintSize)
+	if err != nil {
+		return 0, err
+	}
+	if uint64(len(b)-n) < l {
+		return 0, ErrBufTooSmall
+	}
+	return n + int(l), nil
+}
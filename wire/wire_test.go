@@ -0,0 +1,125 @@
+package wire
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"testing"
+)
+
+// The signatures of the frozen API, changing one breaks the build of the test.
+var (
+	_ func(uint64) int                             = SizeUvarint
+	_ func(int, []byte, uint64) int                = MarshalUvarint
+	_ func(int, []byte, uint) (int, uint64, error) = UnmarshalUvarint
+	_ func(int, []byte, uint) (int, error)         = SkipUvarint
+	_ func(int) uint                               = EncodeZigZag
+	_ func(uint) int                               = DecodeZigZag
+	_ func(int16) uint16                           = EncodeZigZag16
+	_ func(uint16) int16                           = DecodeZigZag16
+	_ func(int32) uint32                           = EncodeZigZag32
+	_ func(uint32) int32                           = DecodeZigZag32
+	_ func(int64) uint64                           = EncodeZigZag64
+	_ func(uint64) int64                           = DecodeZigZag64
+	_ func(int, []byte, int) (int, error)          = Skip
+	_ func(int, []byte, byte) int                  = MarshalByte
+	_ func(int, []byte) (int, byte, error)         = UnmarshalByte
+	_ func(int, []byte, bool) int                  = MarshalBool
+	_ func(int, []byte) (int, bool, error)         = UnmarshalBool
+	_ func(int, []byte, uint16) int                = MarshalUint16
+	_ func(int, []byte) (int, uint16, error)       = UnmarshalUint16
+	_ func(int, []byte, uint32) int                = MarshalUint32
+	_ func(int, []byte) (int, uint32, error)       = UnmarshalUint32
+	_ func(int, []byte, uint64) int                = MarshalUint64
+	_ func(int, []byte) (int, uint64, error)       = UnmarshalUint64
+	_ func(int, []byte, float32) int               = MarshalFloat32
+	_ func(int, []byte) (int, float32, error)      = UnmarshalFloat32
+	_ func(int, []byte, float64) int               = MarshalFloat64
+	_ func(int, []byte) (int, float64, error)      = UnmarshalFloat64
+	_ func([]byte) int                             = SizeBytes
+	_ func(int, []byte, []byte) int                = MarshalBytes
+	_ func(string) int                             = SizeString
+	_ func(int, []byte, string) int                = MarshalString
+	_ func(int, []byte) (int, []byte, error)       = UnmarshalBytes
+	_ func(int, []byte) (int, error)               = SkipBytes
+)
+
+// TestFormat pins the bytes of the primitives, which must never change.
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		name     string
+		size     int
+		marshal  func(n int, b []byte) int
+		expected []byte
+	}{
+		{"uvarint 0", SizeUvarint(0), func(n int, b []byte) int { return MarshalUvarint(n, b, 0) }, []byte{0x00}},
+		{"uvarint 300", SizeUvarint(300), func(n int, b []byte) int { return MarshalUvarint(n, b, 300) }, []byte{0xac, 0x02}},
+		{"uvarint max", SizeUvarint(math.MaxUint64), func(n int, b []byte) int { return MarshalUvarint(n, b, math.MaxUint64) },
+			[]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01}},
+		{"zigzag -3", 1, func(n int, b []byte) int { return MarshalUvarint(n, b, EncodeZigZag64(-3)) }, []byte{0x05}},
+		{"byte", 1, func(n int, b []byte) int { return MarshalByte(n, b, 0xab) }, []byte{0xab}},
+		{"bool", 1, func(n int, b []byte) int { return MarshalBool(n, b, true) }, []byte{0x01}},
+		{"uint16", 2, func(n int, b []byte) int { return MarshalUint16(n, b, 0x0102) }, []byte{0x02, 0x01}},
+		{"uint32", 4, func(n int, b []byte) int { return MarshalUint32(n, b, 0x01020304) }, []byte{0x04, 0x03, 0x02, 0x01}},
+		{"uint64", 8, func(n int, b []byte) int { return MarshalUint64(n, b, 0x0102030405060708) },
+			[]byte{0x08, 0x07, 0x06, 0x05, 0x04, 0x03, 0x02, 0x01}},
+		{"float32", 4, func(n int, b []byte) int { return MarshalFloat32(n, b, 1) }, []byte{0x00, 0x00, 0x80, 0x3f}},
+		{"float64", 8, func(n int, b []byte) int { return MarshalFloat64(n, b, -2) },
+			[]byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xc0}},
+		{"string", SizeString("hi"), func(n int, b []byte) int { return MarshalString(n, b, "hi") }, []byte{0x02, 'h', 'i'}},
+		{"bytes", SizeBytes([]byte{7}), func(n int, b []byte) int { return MarshalBytes(n, b, []byte{7}) }, []byte{0x01, 0x07}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.size != len(tt.expected) {
+				t.Fatalf("size %d, expected %d", tt.size, len(tt.expected))
+			}
+			b := make([]byte, tt.size)
+			if n := tt.marshal(0, b); n != tt.size || !bytes.Equal(b, tt.expected) {
+				t.Fatalf("marshalled % x to offset %d, expected % x", b, n, tt.expected)
+			}
+		})
+	}
+}
+
+func TestUnmarshal(t *testing.T) {
+	if n, v, err := UnmarshalUvarint(0, []byte{0xac, 0x02}, 64); err != nil || n != 2 || v != 300 {
+		t.Fatalf("uvarint: got %d, %d, %v", n, v, err)
+	}
+	if n, v, err := UnmarshalBytes(1, []byte{0xff, 0x02, 'h', 'i'}); err != nil || n != 4 || string(v) != "hi" {
+		t.Fatalf("bytes: got %d, %q, %v", n, v, err)
+	}
+	if n, v, err := UnmarshalFloat64(0, []byte{0, 0, 0, 0, 0, 0, 0, 0xc0}); err != nil || n != 8 || v != -2 {
+		t.Fatalf("float64: got %d, %v, %v", n, v, err)
+	}
+	for _, v := range []int64{0, -1, 1, math.MinInt64, math.MaxInt64} {
+		if got := DecodeZigZag64(EncodeZigZag64(v)); got != v {
+			t.Fatalf("zigzag: %d round-tripped to %d", v, got)
+		}
+	}
+}
+
+func TestErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		fn       func() (int, error)
+		expected error
+	}{
+		{"uvarint truncated", func() (int, error) { return SkipUvarint(0, []byte{0x80}, 64) }, ErrBufTooSmall},
+		{"uvarint 11 bytes", func() (int, error) {
+			return SkipUvarint(0, bytes.Repeat([]byte{0x80}, 11), 64)
+		}, ErrOverflow},
+		{"uvarint 10th byte", func() (int, error) {
+			return SkipUvarint(0, append(bytes.Repeat([]byte{0xff}, 9), 0x02), 64)
+		}, ErrOverflow},
+		{"uvarint bits", func() (int, error) { return SkipUvarint(0, []byte{0x80, 0x80, 0x04}, 16) }, ErrOverflow},
+		{"fixed", func() (int, error) { return Skip(1, []byte{0, 0, 0}, 4) }, ErrBufTooSmall},
+		{"uint32", func() (int, error) { n, _, err := UnmarshalUint32(0, []byte{1, 2, 3}); return n, err }, ErrBufTooSmall},
+		{"bytes length", func() (int, error) { return SkipBytes(0, []byte{0x03, 'h', 'i'}) }, ErrBufTooSmall},
+	}
+	for _, tt := range tests {
+		if n, err := tt.fn(); !errors.Is(err, tt.expected) || n != 0 {
+			t.Errorf("%s: got %d, %v, expected 0, %v", tt.name, n, err, tt.expected)
+		}
+	}
+}